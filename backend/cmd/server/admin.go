@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/segmentio/ksuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"routerx/internal/config"
+	"routerx/internal/store"
+)
+
+const adminUsage = `usage: routerx admin <command> [flags]
+
+commands:
+  create-admin    -username NAME [-password PW]
+  reset-password  -username NAME [-tenant-user] [-password PW]
+  create-tenant   -name NAME -owner USER [-owner-password PW] [-email E] [-id ID] [-balance USD] [-tier T] [-key]
+  issue-key       -tenant ID [-name NAME]
+  adjust-balance  -tenant ID -amount USD [-reason TEXT]
+  list-providers
+
+Passwords left out are generated and printed once.`
+
+// runAdmin handles "routerx admin ...": bootstrap and break-glass operations
+// run directly against the database. Every change is written to the audit
+// log with actor type "cli".
+func runAdmin(cfg config.Config) {
+	if len(os.Args) < 3 {
+		fmt.Println(adminUsage)
+		os.Exit(2)
+	}
+	sub, args := os.Args[2], os.Args[3:]
+	fs := flag.NewFlagSet("admin "+sub, flag.ExitOnError)
+	fs.Usage = func() { fmt.Println(adminUsage) }
+
+	ctx := context.Background()
+	connect := func() *store.Store {
+		pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+		if err != nil {
+			fmt.Println("db connect failed:", err)
+			os.Exit(1)
+		}
+		st := store.New(pool)
+		if st.Keys, err = newKeyring(cfg); err != nil {
+			fmt.Println("invalid provider key encryption config:", err)
+			os.Exit(1)
+		}
+		return st
+	}
+	fail := func(what string, err error) {
+		fmt.Printf("%s: %v\n", what, err)
+		os.Exit(1)
+	}
+
+	switch sub {
+	case "create-admin":
+		username := fs.String("username", "", "admin username")
+		password := fs.String("password", "", "password (generated when empty)")
+		fs.Parse(args)
+		requireFlags(fs, "username")
+		pw, generated := passwordOrRandom(*password)
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+		if err != nil {
+			fail("hash password", err)
+		}
+		st := connect()
+		defer st.DB.Close()
+		id := ksuid.New().String()
+		if err := st.CreateAdminUser(ctx, store.AdminUser{ID: id, Username: *username, PasswordHash: string(hash)}); err != nil {
+			fail("create admin", err)
+		}
+		cliAudit(ctx, st, "", "admin.create", "admin_user", id, map[string]string{"username": *username})
+		fmt.Printf("created admin %s\n", *username)
+		printGenerated(generated, pw)
+
+	case "reset-password":
+		username := fs.String("username", "", "username")
+		tenantUser := fs.Bool("tenant-user", false, "reset a tenant user rather than an admin")
+		password := fs.String("password", "", "new password (generated when empty)")
+		fs.Parse(args)
+		requireFlags(fs, "username")
+		pw, generated := passwordOrRandom(*password)
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+		if err != nil {
+			fail("hash password", err)
+		}
+		actor := store.ActorAdmin
+		if *tenantUser {
+			actor = store.ActorTenantUser
+		}
+		st := connect()
+		defer st.DB.Close()
+		if err := st.SetPassword(ctx, actor, *username, string(hash)); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = fmt.Errorf("no %s named %q", strings.ReplaceAll(actor, "_", " "), *username)
+			}
+			fail("reset password", err)
+		}
+		cliAudit(ctx, st, "", "password.reset", actor, *username, nil)
+		fmt.Printf("password reset for %s\n", *username)
+		printGenerated(generated, pw)
+
+	case "create-tenant":
+		name := fs.String("name", "", "tenant name")
+		id := fs.String("id", "", "tenant id (generated when empty)")
+		owner := fs.String("owner", "", "owner username")
+		ownerPassword := fs.String("owner-password", "", "owner password (generated when empty)")
+		email := fs.String("email", "", "owner email")
+		balance := fs.Float64("balance", 0, "opening balance in USD")
+		tier := fs.String("tier", "", "free, standard or premium")
+		withKey := fs.Bool("key", false, "also issue an API key")
+		fs.Parse(args)
+		requireFlags(fs, "name", "owner")
+		if *tier != "" && !store.ValidTier(*tier) {
+			fail("create tenant", errors.New("tier must be free, standard or premium"))
+		}
+		if *balance < 0 {
+			fail("create tenant", errors.New("balance must not be negative"))
+		}
+		if *id == "" {
+			*id = ksuid.New().String()
+		}
+		pw, generated := passwordOrRandom(*ownerPassword)
+		hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+		if err != nil {
+			fail("hash password", err)
+		}
+		var key *store.APIKey
+		if *withKey {
+			key = &store.APIKey{Key: "user_key_" + ksuid.New().String(), Name: "default", CreatedBy: store.ActorCLI}
+		}
+		st := connect()
+		defer st.DB.Close()
+		t := store.Tenant{ID: *id, Name: *name, BalanceUSD: *balance, Tier: *tier}
+		u := store.TenantUser{ID: ksuid.New().String(), Username: *owner, PasswordHash: string(hash), Email: *email}
+		if err := st.CreateTenantAccount(ctx, t, u, key); err != nil {
+			fail("create tenant", err)
+		}
+		cliAudit(ctx, st, *id, "tenant.create", "tenant", *id, map[string]interface{}{"name": *name, "owner": *owner, "balance_usd": *balance})
+		fmt.Printf("created tenant %s (%s) owned by %s\n", *name, *id, *owner)
+		printGenerated(generated, pw)
+		if key != nil {
+			fmt.Println("api key:", key.Key)
+		}
+
+	case "issue-key":
+		tenantID := fs.String("tenant", "", "tenant id")
+		name := fs.String("name", "", "key name")
+		fs.Parse(args)
+		requireFlags(fs, "tenant")
+		st := connect()
+		defer st.DB.Close()
+		if _, err := st.GetTenantByID(ctx, *tenantID); err != nil {
+			fail("issue key", fmt.Errorf("tenant %q: %w", *tenantID, err))
+		}
+		k := store.APIKey{Key: "user_key_" + ksuid.New().String(), TenantID: *tenantID, Name: *name, CreatedBy: store.ActorCLI}
+		if err := st.CreateAPIKey(ctx, k); err != nil {
+			fail("issue key", err)
+		}
+		cliAudit(ctx, st, *tenantID, "api_key.create", "api_key", k.Key[:len("user_key_")+6], map[string]string{"name": *name})
+		fmt.Println(k.Key)
+
+	case "adjust-balance":
+		tenantID := fs.String("tenant", "", "tenant id")
+		amount := fs.String("amount", "", "USD to add; negative to deduct")
+		reason := fs.String("reason", "", "ledger description")
+		fs.Parse(args)
+		requireFlags(fs, "tenant", "amount")
+		usd, err := strconv.ParseFloat(*amount, 64)
+		if err != nil || usd == 0 {
+			fail("adjust balance", errors.New("-amount must be a non-zero number of USD"))
+		}
+		desc := *reason
+		if desc == "" {
+			desc = fmt.Sprintf("CLI adjustment: %+.2f", usd)
+		}
+		st := connect()
+		defer st.DB.Close()
+		balance, err := st.AdjustTenantBalance(ctx, *tenantID, usd, desc)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = fmt.Errorf("no tenant %q", *tenantID)
+			}
+			fail("adjust balance", err)
+		}
+		cliAudit(ctx, st, *tenantID, "balance.adjust", "tenant", *tenantID, map[string]interface{}{"amount_usd": usd, "balance_usd": balance, "reason": desc})
+		fmt.Printf("balance of %s is now $%.4f\n", *tenantID, balance)
+
+	case "list-providers":
+		fs.Parse(args)
+		st := connect()
+		defer st.DB.Close()
+		providers, err := st.ListProviders(ctx)
+		if err != nil {
+			fail("list providers", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tTYPE\tENABLED\tREGION\tKEY")
+		for _, p := range providers {
+			key := "none"
+			if p.APIKey != "" {
+				key = "set"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\n", p.ID, p.Name, p.Type, p.Enabled, p.Region, key)
+		}
+		tw.Flush()
+
+	default:
+		fmt.Println(adminUsage)
+		os.Exit(2)
+	}
+}
+
+// requireFlags exits with usage when a required flag was not given.
+func requireFlags(fs *flag.FlagSet, names ...string) {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = f.Value.String() != "" })
+	for _, n := range names {
+		if !set[n] {
+			fmt.Printf("-%s is required\n\n%s\n", n, adminUsage)
+			os.Exit(2)
+		}
+	}
+}
+
+// passwordOrRandom returns pw, or a new random password when pw is empty.
+func passwordOrRandom(pw string) (string, bool) {
+	if pw != "" {
+		return pw, false
+	}
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		fmt.Println("generate password:", err)
+		os.Exit(1)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), true
+}
+
+func printGenerated(generated bool, pw string) {
+	if generated {
+		fmt.Println("password:", pw)
+	}
+}
+
+// cliAudit records a CLI action; the actor is the operating-system user.
+func cliAudit(ctx context.Context, st *store.Store, tenantID, action, targetType, targetID string, after interface{}) {
+	actor := os.Getenv("USER")
+	if actor == "" {
+		actor = store.ActorCLI
+	}
+	e := store.AuditEntry{ActorType: store.ActorCLI, Actor: actor, TenantID: tenantID, Action: action, TargetType: targetType, TargetID: targetID, IP: "local", StatusCode: 200}
+	if after != nil {
+		e.After, _ = json.Marshal(after)
+	}
+	if err := st.InsertAuditEntry(ctx, e); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: audit log write failed:", err)
+	}
+}
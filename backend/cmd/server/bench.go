@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// benchResult is the outcome of one benchmark request.
+type benchResult struct {
+	stream   bool
+	outcome  string // "ok", an API error code, or a transport failure
+	provider string
+	fallback bool
+	latency  time.Duration
+	ttft     time.Duration // streamed requests only
+}
+
+// runBench handles "routerx bench": it drives synthetic chat traffic at a
+// running RouterX and reports throughput, latency percentiles and how the
+// limiter and circuit breakers responded. It is meant for a server with
+// ENABLE_REAL_CALLS off, where every provider answers with a dummy response,
+// and refuses to run against real providers unless -allow-real is given.
+func runBench() {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	baseURL := fs.String("url", "http://localhost:8080", "RouterX base URL")
+	key := fs.String("key", os.Getenv("ROUTERX_API_KEY"), "API key (default $ROUTERX_API_KEY)")
+	model := fs.String("model", "gpt-4o-mini", "model to request")
+	concurrency := fs.Int("c", 10, "concurrent clients")
+	total := fs.Int("n", 0, "total requests; 0 runs for -d")
+	duration := fs.Duration("d", 30*time.Second, "how long to run when -n is 0")
+	rate := fs.Float64("rate", 0, "cap on requests per second across all clients; 0 is unlimited")
+	streamMix := fs.Float64("stream", 0.3, "fraction of requests that stream, 0 to 1")
+	timeout := fs.Duration("timeout", 60*time.Second, "per-request timeout")
+	allowReal := fs.Bool("allow-real", false, "run even if the server calls real providers")
+	fs.Parse(os.Args[2:])
+	if *key == "" {
+		fmt.Println("bench: an API key is required (-key or ROUTERX_API_KEY)")
+		os.Exit(2)
+	}
+	if *concurrency < 1 || *streamMix < 0 || *streamMix > 1 || *rate < 0 || *total < 0 {
+		fmt.Println("bench: -c must be at least 1, -stream between 0 and 1, -rate and -n not negative")
+		os.Exit(2)
+	}
+	url := strings.TrimRight(*baseURL, "/") + "/v1/chat/completions"
+	client := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency, MaxConnsPerHost: *concurrency},
+	}
+
+	// One buffered probe: it confirms the key and model work and that the
+	// answers are dummies, so a misconfigured target cannot run up a bill.
+	dummy, err := benchProbe(client, url, *key, *model)
+	if err != nil {
+		fmt.Println("bench: probe request failed:", err)
+		os.Exit(1)
+	}
+	if !dummy && !*allowReal {
+		fmt.Println("bench: the server is calling real providers (ENABLE_REAL_CALLS is on); pass -allow-real to benchmark it anyway")
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *total == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	// The feeder hands out one token per request, paced by -rate, and stops
+	// after -n requests or when the run is over.
+	tokens := make(chan bool)
+	go func() {
+		defer close(tokens)
+		var tick <-chan time.Time
+		if *rate > 0 {
+			t := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+			defer t.Stop()
+			tick = t.C
+		}
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for i := 0; *total == 0 || i < *total; i++ {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case tokens <- rng.Float64() < *streamMix:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	fmt.Printf("benchmarking %s: model=%s clients=%d stream=%.0f%%\n", url, *model, *concurrency, *streamMix*100)
+	var (
+		mu      sync.Mutex
+		results []benchResult
+		wg      sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for stream := range tokens {
+				res := benchRequest(ctx, client, url, *key, *model, stream)
+				if res.outcome == "canceled" {
+					continue
+				}
+				mu.Lock()
+				results = append(results, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	benchReport(os.Stdout, results, time.Since(start))
+}
+
+func benchBody(model string, stream bool) []byte {
+	b, _ := json.Marshal(map[string]interface{}{
+		"model":    model,
+		"stream":   stream,
+		"messages": []map[string]string{{"role": "user", "content": "RouterX benchmark request: reply with one short sentence."}},
+	})
+	return b
+}
+
+func benchProbe(client *http.Client, url, key, model string) (dummy bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(benchBody(model, false)))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(out.ID, "dummy_"), nil
+}
+
+func benchRequest(ctx context.Context, client *http.Client, url, key, model string, stream bool) benchResult {
+	res := benchResult{stream: stream}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(benchBody(model, stream)))
+	if err != nil {
+		res.outcome = "request_error"
+		return res
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Content-Type", "application/json")
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.outcome = transportOutcome(ctx, err)
+		return res
+	}
+	defer resp.Body.Close()
+	res.provider = resp.Header.Get("X-RouterX-Provider")
+	res.fallback = resp.Header.Get("X-RouterX-Fallback") == "true"
+
+	if resp.StatusCode != http.StatusOK {
+		res.latency = time.Since(start)
+		res.outcome = errorOutcome(resp)
+		return res
+	}
+	if !stream {
+		if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+			res.outcome = transportOutcome(ctx, err)
+			return res
+		}
+		res.latency = time.Since(start)
+		res.outcome = "ok"
+		return res
+	}
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	done := false
+	for sc.Scan() {
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if res.ttft == 0 {
+			res.ttft = time.Since(start)
+		}
+		if data == "[DONE]" {
+			done = true
+		}
+	}
+	res.latency = time.Since(start)
+	switch {
+	case sc.Err() != nil:
+		res.outcome = transportOutcome(ctx, sc.Err())
+	case !done:
+		res.outcome = "stream_incomplete"
+	default:
+		res.outcome = "ok"
+	}
+	return res
+}
+
+// errorOutcome names a failed response by its API error code, splitting
+// upstream failures by the router's reason so breaker and provider budget
+// behaviour shows up separately.
+func errorOutcome(resp *http.Response) string {
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil || body.Error.Code == "" {
+		return fmt.Sprintf("http_%d", resp.StatusCode)
+	}
+	msg := body.Error.Message
+	switch {
+	case body.Error.Code != "upstream_failed":
+		return body.Error.Code
+	case strings.Contains(msg, "circuit open"):
+		return "circuit_open"
+	case strings.Contains(msg, "cooling down"):
+		return "provider_cooldown"
+	case strings.Contains(msg, "budget"):
+		return "provider_budget"
+	}
+	return body.Error.Code
+}
+
+func transportOutcome(ctx context.Context, err error) string {
+	if ctx.Err() != nil {
+		return "canceled"
+	}
+	if os.IsTimeout(err) {
+		return "timeout"
+	}
+	return "connection_error"
+}
+
+func benchReport(w io.Writer, results []benchResult, elapsed time.Duration) {
+	var ok, limited, breaker, fallbacks int
+	outcomes := map[string]int{}
+	providers := map[string]int{}
+	var all, buffered, streamed, ttfts []time.Duration
+	for _, r := range results {
+		outcomes[r.outcome]++
+		switch r.outcome {
+		case "ok":
+			ok++
+			all = append(all, r.latency)
+			if r.stream {
+				streamed = append(streamed, r.latency)
+				ttfts = append(ttfts, r.ttft)
+			} else {
+				buffered = append(buffered, r.latency)
+			}
+		case "rate_limit_exceeded", "concurrency_limit_exceeded", "brownout":
+			limited++
+		case "circuit_open":
+			breaker++
+		}
+		if r.provider != "" {
+			providers[r.provider]++
+		}
+		if r.fallback {
+			fallbacks++
+		}
+	}
+	secs := elapsed.Seconds()
+	fmt.Fprintf(w, "\n%d requests in %s: %.1f req/s, %.1f ok/s\n", len(results), elapsed.Round(time.Millisecond), float64(len(results))/secs, float64(ok)/secs)
+	if len(results) == 0 {
+		return
+	}
+	pct := func(n int) string { return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(len(results))) }
+	fmt.Fprintf(w, "succeeded %d (%s), limiter rejections %d (%s), circuit open %d (%s), fallbacks %d\n\n",
+		ok, pct(ok), limited, pct(limited), breaker, pct(breaker), fallbacks)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "latency\tcount\tp50\tp90\tp99\tmax\t")
+	for _, row := range []struct {
+		name string
+		d    []time.Duration
+	}{{"all", all}, {"buffered", buffered}, {"streamed", streamed}, {"ttft", ttfts}} {
+		if len(row.d) == 0 {
+			continue
+		}
+		sort.Slice(row.d, func(i, j int) bool { return row.d[i] < row.d[j] })
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t\n", row.name, len(row.d),
+			percentile(row.d, 50), percentile(row.d, 90), percentile(row.d, 99), row.d[len(row.d)-1].Round(100*time.Microsecond))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\noutcomes:")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, k := range sortedByCount(outcomes) {
+		fmt.Fprintf(tw, "  %s\t%d\t%s\n", k, outcomes[k], pct(outcomes[k]))
+	}
+	tw.Flush()
+	if len(providers) > 0 {
+		fmt.Fprintln(w, "\nproviders:")
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, k := range sortedByCount(providers) {
+			fmt.Fprintf(tw, "  %s\t%d\n", k, providers[k])
+		}
+		tw.Flush()
+	}
+}
+
+// percentile returns the p-th percentile of sorted d by nearest rank.
+func percentile(d []time.Duration, p int) time.Duration {
+	i := (len(d)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return d[i].Round(100 * time.Microsecond)
+}
+
+func sortedByCount(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -1,11 +1,17 @@
-﻿package main
+package main
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -15,32 +21,86 @@ import (
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
 
+	"routerx/internal/alerting"
 	"routerx/internal/api"
+	"routerx/internal/batch"
+	"routerx/internal/cluster"
 	"routerx/internal/config"
+	"routerx/internal/delinquency"
+	"routerx/internal/devenv"
+	"routerx/internal/fxrates"
+	"routerx/internal/guardrails"
+	"routerx/internal/jobs"
+	"routerx/internal/keyusage"
 	"routerx/internal/limiter"
+	"routerx/internal/mailer"
 	"routerx/internal/metrics"
 	"routerx/internal/middleware"
+	"routerx/internal/migrate"
 	"routerx/internal/observability"
+	"routerx/internal/providers"
 	"routerx/internal/router"
+	"routerx/internal/routingconfig"
+	"routerx/internal/secrets"
+	"routerx/internal/sessions"
 	"routerx/internal/store"
 	"routerx/internal/webhook"
+	"routerx/migrations"
+	"routerx/seed"
 )
 
+// version identifies the build in the instance registry; release builds set
+// it with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
 	cmd := "serve"
 	if len(os.Args) > 1 {
 		cmd = os.Args[1]
 	}
-	cfg := config.Load()
+	if cmd == "config" {
+		runConfig()
+		return
+	}
+	if cmd == "bench" {
+		// Runs against a server elsewhere and needs none of its config.
+		runBench()
+		return
+	}
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	switch cmd {
+	case "dev":
+		env := startDevEnv(&cfg)
+		defer env.Stop()
 	case "migrate":
 		runMigrations(cfg)
 		return
 	case "seed":
 		runSeed(cfg)
 		return
+	case "reencrypt-keys":
+		runReencryptKeys(cfg)
+		return
+	case "apply":
+		runApply(cfg)
+		return
+	case "export":
+		runExport(cfg)
+		return
+	case "admin":
+		runAdmin(cfg)
+		return
+	case "init":
+		runInit(cfg)
+		return
 	default:
 		// serve
 	}
@@ -54,72 +114,301 @@ func main() {
 		defer shutdown(ctx)
 	}
 
-	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	poolCfg, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		logger.Fatal("invalid DATABASE_URL", zap.Error(err))
+	}
+	poolCfg.ConnConfig.Tracer = observability.NewPgxTracer()
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		logger.Fatal("db connect failed", zap.Error(err))
 	}
 	defer pool.Close()
+	if cfg.MigrateOnStart {
+		versions, err := migrate.Load(migrations.FS)
+		if err == nil {
+			var done []migrate.Migration
+			done, err = migrate.Up(ctx, pool, versions)
+			logger.Info("migrations applied", zap.Int("new", len(done)))
+		}
+		if err != nil {
+			logger.Fatal("migrate failed", zap.Error(err))
+		}
+	}
 
-	redisClient := redis.NewClient(&redis.Options{Addr: parseRedisAddr(cfg.RedisURL)})
+	redisClient, err := newRedisClient(cfg)
+	if err != nil {
+		logger.Fatal("invalid redis config", zap.Error(err))
+	}
+	defer redisClient.Close()
 
 	st := store.New(pool)
+	if st.Keys, err = newKeyring(cfg); err != nil {
+		logger.Fatal("invalid provider key encryption config", zap.Error(err))
+	}
+	if cfg.ProvidersConfig != "" {
+		bootstrapProviders(ctx, st, cfg.ProvidersConfig, logger)
+	}
+	// POST /setup always needs a token; without a configured one a fresh
+	// token is made and logged, so only someone who can read the server's
+	// logs can claim the install.
+	setupToken := cfg.SetupToken
+	if setupToken == "" {
+		if setupToken, err = api.NewSetupToken(); err != nil {
+			logger.Fatal("failed to generate setup token", zap.Error(err))
+		}
+	}
+	if needed, err := st.NeedsSetup(ctx); err == nil && needed {
+		if cfg.SetupToken == "" {
+			logger.Warn("no admin user exists yet; finish setup with \"routerx init\" or POST /setup with this X-Setup-Token", zap.String("setup_token", setupToken))
+		} else {
+			logger.Warn("no admin user exists yet; finish setup with POST /setup or \"routerx init\"")
+		}
+	}
 	r := router.New(st, cfg.EnableRealCalls, redisClient)
+	metrics.Tenants.Max, metrics.Models.Max = cfg.MetricsMaxTenants, cfg.MetricsMaxModels
+	providers.Pool = providers.PoolConfig{
+		MaxIdleConnsPerHost: cfg.UpstreamMaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(cfg.UpstreamIdleConnTimeoutSec) * time.Second,
+		DialTimeout:         time.Duration(cfg.UpstreamDialTimeoutSec) * time.Second,
+		HTTP2:               cfg.UpstreamHTTP2,
+	}
+	providers.MaxStreamLineBytes = cfg.UpstreamStreamMaxLineBytes
+	if err := middleware.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal("invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 	metrics.Register()
-	lim := limiter.New(redisClient, 10, 5)
+	r.MaxAttempts, r.RetryBackoff = cfg.RetryMaxAttempts, time.Duration(cfg.RetryBackoffMS)*time.Millisecond
+	lim := limiter.New(redisClient, cfg.LimiterQPS, cfg.LimiterConcurrency)
+	lim.Algorithm, lim.Burst = cfg.LimiterAlgorithm, cfg.LimiterBurst
+	brownout := limiter.NewBrownout(time.Duration(cfg.BrownoutDBLatencyMS)*time.Millisecond, cfg.BrownoutSaturation)
+	go brownout.Run(ctx, 5*time.Second, st.Ping, lim.Saturation)
+	go r.WatchChanges(ctx)
+	instances := cluster.New(redisClient, version, cfg.Region, logger)
+	go instances.Run(ctx)
+
+	runner := jobs.New(st, logger, cfg.JobWorkers)
+	runner.Every("jobs.prune", time.Hour, func(ctx context.Context, _ json.RawMessage) error {
+		_, err := st.PruneJobs(ctx, time.Now().UTC().Add(-7*24*time.Hour))
+		return err
+	})
+	runner.Every("credits.expire", 10*time.Minute, func(ctx context.Context, _ json.RawMessage) error {
+		_, err := st.ExpireCreditGrants(ctx)
+		return err
+	})
 
 	wh := webhook.New(st)
-	srv := &api.Server{Store: st, Router: r, Limiter: lim, Logger: logger, JWTSecret: cfg.JWTSecret, Webhooks: wh}
+	wh.Register(runner, 15*time.Second)
+	r.OnProviderEvent = func(ev store.ProviderEvent) {
+		switch ev.EventType {
+		case store.EventCircuitOpened, store.EventCircuitClosed:
+			wctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			wh.Fire(wctx, "", "provider."+ev.EventType, ev)
+		}
+	}
+	moderation, err := newModerationClassifier(cfg)
+	if err != nil {
+		logger.Fatal("invalid moderation config", zap.Error(err))
+	}
+	mail := mailer.New(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	alerts := alerting.New(st, mail, logger)
+	alerts.Webhooks = wh
+	if cfg.AlertEvalIntervalSeconds > 0 {
+		alerts.Register(runner, time.Duration(cfg.AlertEvalIntervalSeconds)*time.Second)
+		alerts.RegisterSpend(runner, time.Duration(cfg.AlertEvalIntervalSeconds)*time.Second)
+	}
+	if cfg.FXRefreshHours > 0 {
+		fxrates.New(st, logger).Register(runner, time.Duration(cfg.FXRefreshHours)*time.Hour)
+	}
+	policy := delinquency.Policy{
+		NegativeBalanceDays: cfg.DelinquencyNegativeBalanceDays,
+		Abuse:               store.AbuseThresholds{ModerationFlagsPerHour: cfg.AbuseModerationFlagsPerHour, ClientErrorsPerHour: cfg.AbuseClientErrorsPerHour},
+	}
+	if policy.Enabled() {
+		delinquency.New(st, policy, wh, mail, logger).Register(runner, 10*time.Minute)
+	}
+	keyUsage := keyusage.New(redisClient, st, logger)
+	if cfg.KeyUsageFlushSeconds > 0 {
+		keyUsage.Register(runner, time.Duration(cfg.KeyUsageFlushSeconds)*time.Second)
+	}
+	srv := &api.Server{Store: st, Router: r, Limiter: lim, Brownout: brownout, Logger: logger, JWTSecret: cfg.JWTSecret, Webhooks: wh, Mailer: mail, PublicURL: cfg.PublicURL, RequireAdmin2FA: cfg.RequireAdmin2FA, Moderation: moderation, LowBalanceThresholdUSD: cfg.LowBalanceThresholdUSD, Alerts: alerts, KeyUsage: keyUsage, Region: cfg.Region,
+		EmbeddingCacheTTL: time.Duration(cfg.EmbeddingCacheTTLSec) * time.Second,
+		SetupToken:        setupToken,
+		Reservations:      limiter.NewReservations(redisClient),
+		FreeAllowances:    limiter.NewFreeAllowances(redisClient),
+		Cluster:           instances,
+		Sessions:          sessions.New(redisClient),
+		Limits:            api.RequestLimits{MaxMessages: cfg.MaxMessages, MaxImageBytes: cfg.MaxImageBytes, StrictJSON: cfg.StrictJSON}}
+
+	if cfg.BatchWorkers > 0 {
+		batches := batch.New(st, srv.ExecBatchItem, cfg.BatchWorkers, cfg.BatchTenantConcurrency, logger)
+		batches.Register(ctx, runner, 2*time.Second)
+	}
+	go runner.Run(ctx, time.Second)
+	go instances.Singleton(ctx, time.Minute, "jobs.reschedule", runner.Reschedule)
 
 	router := chi.NewRouter()
-	router.Use(cors.Handler(cors.Options{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"}, AllowedHeaders: []string{"*"}}))
+	router.Use(cors.Handler(cors.Options{AllowedOrigins: cfg.CORSAllowedOrigins, AllowedMethods: cfg.CORSAllowedMethods, AllowedHeaders: cfg.CORSAllowedHeaders, AllowCredentials: cfg.CORSAllowCredentials}))
 	router.Use(func(next http.Handler) http.Handler { return otelhttp.NewHandler(next, "http") })
+	router.Use(middleware.AccessLog(logger, middleware.AccessLogOptions{
+		SampleRate:    cfg.AccessLogSampleRate,
+		SlowThreshold: time.Duration(cfg.AccessLogSlowMS) * time.Millisecond,
+	}))
 
 	router.Get("/health", srv.Health)
+	router.Get("/status", srv.Status)
 	router.Handle("/metrics", promhttp.Handler())
+	router.Get("/setup", srv.SetupStatus)
+	router.Post("/setup", srv.CompleteSetup)
 
 	router.Route("/v1", func(r chi.Router) {
 		r.Get("/models", srv.ListModels)
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.WithAPIKey(st))
+			r.Use(middleware.MaxBodyBytes(cfg.MaxRequestBodyBytes))
+			r.Use(middleware.WithAPIKey(st, redisClient, keyUsage))
 			r.Post("/chat/completions", srv.ChatCompletions)
 			r.Post("/embeddings", srv.Embeddings)
 		})
+		// Batches carry up to 10,000 requests and have their own body cap.
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.WithAPIKey(st, redisClient, keyUsage))
+			r.Post("/batches", srv.CreateBatch)
+			r.Get("/batches", srv.ListBatches)
+			r.Get("/batches/{id}", srv.GetBatch)
+			r.Get("/batches/{id}/results", srv.BatchResults)
+			r.Post("/batches/{id}/cancel", srv.CancelBatch)
+		})
 	})
 
 	router.Route("/admin", func(r chi.Router) {
 		r.Post("/login", srv.AdminLogin)
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.AdminAuth(cfg.JWTSecret))
+			r.Use(middleware.TwoFactorRoutes)
+			r.Use(middleware.AdminAuth(cfg.JWTSecret, srv.Sessions))
+			r.Use(srv.Audit)
+			r.Get("/2fa/status", srv.AdminTwoFactorStatus)
+			r.Post("/2fa/enroll", srv.AdminTwoFactorEnroll)
+			r.Post("/2fa/verify", srv.AdminTwoFactorVerify)
+			r.Post("/2fa/disable", srv.AdminTwoFactorDisable)
+			r.Post("/2fa/backup-codes", srv.AdminTwoFactorBackupCodes)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AdminAuth(cfg.JWTSecret, srv.Sessions))
+			r.Use(srv.Audit)
 			r.Get("/stats", srv.AdminDashboardStats)
+			r.Get("/instances", srv.AdminInstances)
+			r.Get("/sessions", srv.AdminSessions)
+			r.Delete("/sessions/{id}", srv.AdminRevokeSession)
 			r.Get("/providers", srv.AdminProviders)
 			r.Post("/providers", srv.AdminCreateProvider)
 			r.Put("/providers/{id}", srv.AdminUpdateProvider)
+			r.Delete("/providers/{id}", srv.AdminArchiveProvider)
+			r.Post("/providers/{id}/restore", srv.AdminRestoreProvider)
 			r.Delete("/providers/{id}/api-key", srv.AdminClearProviderKey)
+			r.Post("/providers/{id}/test", srv.AdminTestProvider)
+			r.Get("/providers/{id}/rate-budget", srv.AdminProviderRateBudget)
+			r.Put("/providers/{id}/rate-budget", srv.AdminSetProviderRateBudget)
+			r.Post("/providers/{id}/billing", srv.AdminImportProviderBilling)
+			r.Get("/providers/{id}/reconciliation", srv.AdminProviderReconciliation)
+			r.Post("/providers/{id}/circuit", srv.AdminSetProviderCircuit)
+			r.Put("/providers/{id}/network", srv.AdminSetProviderNetwork)
+			r.Put("/providers/{id}/maintenance", srv.AdminSetProviderMaintenance)
+			r.Get("/providers/{id}/maintenance-windows", srv.AdminMaintenanceWindows)
+			r.Post("/providers/{id}/maintenance-windows", srv.AdminCreateMaintenanceWindow)
+			r.Delete("/providers/{id}/maintenance-windows/{windowID}", srv.AdminDeleteMaintenanceWindow)
+			r.Post("/providers/{id}/api-key/stage", srv.AdminStageProviderKey)
+			r.Post("/providers/{id}/api-key/test", srv.AdminTestStagedProviderKey)
+			r.Post("/providers/{id}/api-key/promote", srv.AdminPromoteProviderKey)
+			r.Delete("/providers/{id}/api-key/stage", srv.AdminDiscardStagedProviderKey)
+			r.Post("/providers/{id}/api-key/rollback", srv.AdminRollbackProviderKey)
 			r.Get("/provider-health", srv.AdminProviderHealth)
+			r.Get("/provider-health/events", srv.AdminProviderEvents)
+			r.Get("/analytics/provider-events", srv.AdminProviderEventSummary)
+			r.Get("/analytics/margin", srv.AdminMarginReport)
+			r.Get("/analytics/attempt-failures", srv.AdminAttemptFailures)
 			r.Get("/tenants", srv.AdminTenants)
+			r.Get("/tenants/search", srv.AdminTenants)
+			r.Post("/tenants", srv.AdminCreateTenant)
 			r.Get("/tenants/{id}", srv.AdminTenantDetail)
+			r.Put("/tenants/{id}", srv.AdminUpdateTenant)
+			r.Delete("/tenants/{id}", srv.AdminDeleteTenant)
+			r.Post("/tenants/{id}/merge", srv.AdminMergeTenant)
+			r.Get("/tenants/{id}/export", srv.AdminExportTenant)
+			r.Post("/tenants/{id}/erase", srv.AdminEraseTenant)
+			r.Get("/tenant-archives", srv.AdminTenantArchives)
+			r.Get("/api-keys", srv.AdminAPIKeys)
+			r.Post("/api-keys/{key}/revoke", srv.AdminRevokeAPIKey)
 			r.Post("/tenants/{id}/balance", srv.AdminAdjustBalance)
+			r.Get("/tenants/{id}/credits", srv.AdminTenantCredits)
+			r.Post("/tenants/{id}/credits", srv.AdminGrantCredits)
+			r.Delete("/tenants/{id}/credits/{grant}", srv.AdminRevokeCredits)
+			r.Put("/tenants/{id}/currency", srv.AdminSetTenantCurrency)
+			r.Put("/tenants/{id}/overdraft", srv.AdminSetTenantOverdraft)
+			r.Get("/exchange-rates", srv.ExchangeRates)
+			r.Put("/exchange-rates/{currency}", srv.AdminSetExchangeRate)
+			r.Delete("/exchange-rates/{currency}", srv.AdminDeleteExchangeRate)
 			r.Post("/tenants/{id}/suspend", srv.AdminSuspendTenant)
 			r.Post("/tenants/{id}/unsuspend", srv.AdminUnsuspendTenant)
 			r.Put("/tenants/{id}/limits", srv.AdminUpdateTenantLimits)
+			r.Get("/tenants/{id}/moderation", srv.AdminGetModerationPolicy)
+			r.Put("/tenants/{id}/moderation", srv.AdminSetModerationPolicy)
+			r.Get("/moderation/events", srv.AdminModerationEvents)
+			r.Get("/tenants/{id}/redaction", srv.AdminGetRedactionPolicy)
+			r.Put("/tenants/{id}/redaction", srv.AdminSetRedactionPolicy)
+			r.Get("/tenants/{id}/policy", srv.AdminGetRequestPolicy)
+			r.Put("/tenants/{id}/policy", srv.AdminSetRequestPolicy)
 			r.Get("/tenants/{id}/transactions", srv.AdminTenantTransactions)
+			r.Get("/tenants/{id}/limits", srv.AdminTenantLimits)
 			r.Get("/requests", srv.AdminRequestsPaginated)
 			r.Get("/requests/export", srv.AdminExportRequestsCSV)
+			r.Get("/requests/stream", srv.AdminTrafficStream)
+			r.Get("/requests/{id}", srv.AdminRequestDetail)
 			r.Delete("/requests/{id}", srv.AdminDeleteRequest)
 			r.Get("/generation/{id}", srv.AdminGetGeneration)
 			r.Get("/model-usage", srv.AdminModelUsage)
+			r.Get("/usage/by-tag", srv.AdminUsageByTag)
 			r.Get("/models", srv.AdminListModels)
 			r.Post("/models", srv.AdminAddModel)
 			r.Delete("/models/{model}", srv.AdminDeleteModel)
+			r.Get("/models/archived", srv.AdminArchivedModels)
+			r.Post("/models/{model}/restore", srv.AdminRestoreModel)
+			r.Get("/models/{model}/providers", srv.AdminModelProviders)
+			r.Put("/models/{model}/providers", srv.AdminSetModelProviders)
 			r.Get("/model-pricing", srv.AdminListModelPricing)
 			r.Post("/model-pricing", srv.AdminUpsertModelPricing)
 			r.Get("/routing-rules", srv.AdminRoutingRules)
 			r.Post("/routing-rules", srv.AdminCreateRoutingRule)
 			r.Put("/routing-rules/{id}", srv.AdminUpdateRoutingRule)
 			r.Delete("/routing-rules/{id}", srv.AdminDeleteRoutingRule)
+			r.Post("/routing/explain", srv.AdminExplainRoute)
+			r.Get("/routing/config", srv.AdminExportRoutingConfig)
+			r.Put("/routing/config", srv.AdminApplyRoutingConfig)
+			r.Get("/experiments", srv.AdminListExperiments)
+			r.Post("/experiments", srv.AdminCreateExperiment)
+			r.Put("/experiments/{id}", srv.AdminUpdateExperiment)
+			r.Delete("/experiments/{id}", srv.AdminDeleteExperiment)
+			r.Get("/experiments/{id}/results", srv.AdminExperimentResults)
 			r.Get("/webhooks", srv.AdminListWebhooks)
+			r.Get("/audit-log", srv.AdminAuditLog)
 			r.Post("/webhooks", srv.AdminCreateWebhook)
+			r.Get("/webhooks/{id}", srv.AdminGetWebhook)
+			r.Put("/webhooks/{id}", srv.AdminUpdateWebhook)
 			r.Delete("/webhooks/{id}", srv.AdminDeleteWebhook)
+			r.Post("/webhooks/{id}/test", srv.AdminTestWebhook)
+			r.Get("/alerts/rules", srv.AdminAlertRules)
+			r.Post("/alerts/rules", srv.AdminCreateAlertRule)
+			r.Put("/alerts/rules/{id}", srv.AdminUpdateAlertRule)
+			r.Delete("/alerts/rules/{id}", srv.AdminDeleteAlertRule)
+			r.Post("/alerts/rules/{id}/test", srv.AdminTestAlertRule)
+			r.Get("/alerts/events", srv.AdminAlertEvents)
+			r.Get("/jobs", srv.AdminJobs)
+			r.Get("/jobs/summary", srv.AdminJobSummary)
+			r.Get("/jobs/{id}", srv.AdminJob)
+			r.Post("/jobs/{id}/retry", srv.AdminRetryJob)
+			r.Get("/webhooks/deliveries", srv.AdminWebhookDeliveries)
+			r.Get("/webhooks/deliveries/{id}", srv.AdminWebhookDelivery)
+			r.Post("/webhooks/deliveries/{id}/redeliver", srv.AdminRedeliverWebhook)
 		})
 	})
 
@@ -130,41 +419,248 @@ func main() {
 
 	router.Route("/user", func(r chi.Router) {
 		r.Post("/login", srv.TenantLogin)
+		r.Post("/invitations/accept", srv.AcceptInvitation)
+		// Viewers can manage their own two-factor authentication.
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.TenantUserAuth(cfg.JWTSecret, st))
-			r.Get("/profile", srv.TenantProfile)
-			r.Get("/usage", srv.TenantUsage)
-			r.Get("/summary", srv.TenantSummary)
-			r.Get("/api-keys", srv.TenantAPIKeys)
-			r.Post("/api-keys", srv.TenantCreateAPIKey)
-			r.Delete("/api-keys/{key}", srv.TenantDeleteAPIKey)
-			r.Post("/topup", srv.TenantTopup)
+			r.Use(middleware.TwoFactorRoutes)
+			r.Use(middleware.TenantUserAuth(cfg.JWTSecret, st, srv.Sessions))
+			r.Use(srv.Audit)
+			r.Get("/2fa/status", srv.TenantTwoFactorStatus)
+			r.Post("/2fa/enroll", srv.TenantTwoFactorEnroll)
+			r.Post("/2fa/verify", srv.TenantTwoFactorVerify)
+			r.Post("/2fa/disable", srv.TenantTwoFactorDisable)
+			r.Post("/2fa/backup-codes", srv.TenantTwoFactorBackupCodes)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.TenantUserAuth(cfg.JWTSecret, st, srv.Sessions))
+			// Viewers can end their own sessions.
+			r.Group(func(r chi.Router) {
+				r.Use(srv.Audit)
+				r.Get("/sessions", srv.TenantSessions)
+				r.Delete("/sessions/{id}", srv.TenantRevokeSession)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.TenantWriteAccess)
+				r.Use(srv.Audit)
+				r.Get("/profile", srv.TenantProfile)
+				r.Get("/credits", srv.TenantCredits)
+				r.Get("/free-allowances", srv.TenantFreeAllowances)
+				r.Get("/transactions", srv.TenantTransactions)
+				r.Get("/exchange-rates", srv.ExchangeRates)
+				r.Put("/currency", srv.TenantSetCurrency)
+				r.Get("/usage", srv.TenantUsage)
+				r.Get("/usage/by-tag", srv.TenantUsageByTag)
+				r.Get("/usage/export", srv.TenantUsageExport)
+				r.Get("/requests", srv.TenantRequests)
+				r.Get("/summary", srv.TenantSummary)
+				r.Get("/analytics", srv.TenantAnalytics)
+				r.Get("/api-keys", srv.TenantAPIKeys)
+				r.Post("/api-keys", srv.TenantCreateAPIKey)
+				r.Delete("/api-keys/{key}", srv.TenantDeleteAPIKey)
+				r.Post("/api-keys/{key}/signing-secret", srv.TenantRotateSigningSecret)
+				r.Delete("/api-keys/{key}/signing-secret", srv.TenantDeleteSigningSecret)
+				r.Get("/audit-log", srv.TenantAuditLog)
+				r.Get("/members", srv.TenantMembers)
+				r.Get("/moderation", srv.TenantModerationPolicy)
+				r.Get("/moderation/events", srv.TenantModerationEvents)
+				r.Get("/redaction", srv.TenantRedactionPolicy)
+				r.Get("/policy", srv.TenantRequestPolicy)
+				r.Get("/prompt-templates", srv.TenantPromptTemplates)
+				r.Get("/webhooks", srv.TenantWebhooks)
+				r.Get("/alerts", srv.TenantSpendAlerts)
+				r.Post("/alerts", srv.TenantCreateSpendAlert)
+				r.Put("/alerts/{id}", srv.TenantUpdateSpendAlert)
+				r.Delete("/alerts/{id}", srv.TenantDeleteSpendAlert)
+				r.Post("/prompt-templates", srv.TenantCreatePromptTemplate)
+				r.Get("/prompt-templates/{id}", srv.TenantGetPromptTemplate)
+				r.Put("/prompt-templates/{id}", srv.TenantUpdatePromptTemplate)
+				r.Delete("/prompt-templates/{id}", srv.TenantDeletePromptTemplate)
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.RequireTenantRole(store.RoleOwner))
+					r.Post("/topup", srv.TenantTopup)
+					r.Put("/security", srv.TenantSecurity)
+					r.Put("/moderation", srv.TenantSetModerationPolicy)
+					r.Put("/redaction", srv.TenantSetRedactionPolicy)
+					r.Put("/members/{id}", srv.TenantUpdateMember)
+					r.Delete("/members/{id}", srv.TenantDeleteMember)
+					r.Get("/invitations", srv.TenantInvitations)
+					r.Post("/invitations", srv.TenantCreateInvitation)
+					r.Delete("/invitations/{id}", srv.TenantDeleteInvitation)
+					r.Post("/webhooks", srv.TenantCreateWebhook)
+					r.Delete("/webhooks/{id}", srv.TenantDeleteWebhook)
+				})
+			})
 		})
 	})
 
-	addr := ":" + cfg.Port
-	logger.Info("server starting", zap.String("addr", addr))
-	if err := http.ListenAndServe(addr, router); err != nil {
+	server := newHTTPServer(cfg, router)
+	logger.Info("server starting", zap.String("addr", server.Addr), zap.Bool("tls", server.TLSConfig != nil || cfg.TLSCertFile != ""))
+	switch {
+	case len(cfg.TLSACMEDomains) > 0:
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(cfg.TLSACMECacheDir),
+			HostPolicy: autocert.HostWhitelist(cfg.TLSACMEDomains...),
+			Email:      cfg.TLSACMEEmail,
+		}
+		tlsCfg := m.TLSConfig()
+		if !cfg.ServerHTTP2 {
+			tlsCfg.NextProtos = []string{"http/1.1", acme.ALPNProto}
+		}
+		server.TLSConfig = tlsCfg
+		if cfg.TLSACMEHTTPAddr != "" {
+			go func() {
+				challenge := &http.Server{Addr: cfg.TLSACMEHTTPAddr, Handler: m.HTTPHandler(nil), ReadHeaderTimeout: 10 * time.Second}
+				if err := challenge.ListenAndServe(); err != nil {
+					logger.Error("acme http listener failed", zap.Error(err))
+				}
+			}()
+		}
+		err = server.ListenAndServeTLS("", "")
+	case cfg.TLSCertFile != "":
+		err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	default:
+		err = server.ListenAndServe()
+	}
+	if err != nil {
 		logger.Fatal("server failed", zap.Error(err))
 	}
 }
 
-func parseRedisAddr(url string) string {
-	// minimal parse for redis://host:port/db
-	trimmed := url
-	if len(trimmed) > 8 && trimmed[:8] == "redis://" {
-		trimmed = trimmed[8:]
+// newHTTPServer applies the configured listener timeouts. HTTP/2 is
+// negotiated over TLS unless turned off.
+func newHTTPServer(cfg config.Config, h http.Handler) *http.Server {
+	s := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           h,
+		ReadHeaderTimeout: time.Duration(cfg.ServerReadHeaderTimeoutSec) * time.Second,
+		ReadTimeout:       time.Duration(cfg.ServerReadTimeoutSec) * time.Second,
+		WriteTimeout:      time.Duration(cfg.ServerWriteTimeoutSec) * time.Second,
+		IdleTimeout:       time.Duration(cfg.ServerIdleTimeoutSec) * time.Second,
+	}
+	if !cfg.ServerHTTP2 {
+		// A non-nil empty map turns off the server's HTTP/2 support.
+		s.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
 	}
-	for i, ch := range trimmed {
-		if ch == '/' {
-			return trimmed[:i]
+	return s
+}
+
+// newRedisClient connects in the configured mode. REDIS_URL's credentials,
+// TLS (rediss://) and database apply to sentinel and cluster modes too.
+func newRedisClient(cfg config.Config) (redis.UniversalClient, error) {
+	opt, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL: %w", err)
+	}
+	switch cfg.RedisMode {
+	case "sentinel":
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.RedisMasterName,
+			SentinelAddrs:    cfg.RedisAddrs,
+			SentinelPassword: cfg.RedisSentinelPassword,
+			Username:         opt.Username,
+			Password:         opt.Password,
+			DB:               opt.DB,
+			TLSConfig:        opt.TLSConfig,
+		}), nil
+	case "cluster":
+		if opt.DB != 0 {
+			return nil, errors.New("REDIS_URL: Redis Cluster only has database 0")
 		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     cfg.RedisAddrs,
+			Username:  opt.Username,
+			Password:  opt.Password,
+			TLSConfig: opt.TLSConfig,
+		}), nil
+	}
+	return redis.NewClient(opt), nil
+}
+
+// startDevEnv handles "routerx dev [-data dir]": it starts a throwaway
+// Postgres and in-memory Redis, points cfg at them, migrates and seeds the
+// database, and leaves the caller to serve. Interrupting the server stops
+// both.
+func startDevEnv(cfg *config.Config) *devenv.Env {
+	fs := flag.NewFlagSet("dev", flag.ExitOnError)
+	dir := fs.String("data", "", "keep the database in this directory across runs (default: temporary)")
+	fs.Parse(os.Args[2:])
+	ctx := context.Background()
+	env, err := devenv.Start(ctx, *dir)
+	if err != nil {
+		fmt.Println("dev environment failed:", err)
+		os.Exit(1)
+	}
+	cfg.DatabaseURL, cfg.RedisURL, cfg.RedisMode = env.DatabaseURL, env.RedisURL, "standalone"
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		env.Stop()
+		os.Exit(0)
+	}()
+	fail := func(msg string, err error) {
+		env.Stop()
+		fmt.Println(msg, err)
+		os.Exit(1)
+	}
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		fail("db connect failed:", err)
+	}
+	defer pool.Close()
+	versions, err := migrate.Load(migrations.FS)
+	if err == nil {
+		_, err = migrate.Up(ctx, pool, versions)
+	}
+	if err != nil {
+		fail("migrate failed:", err)
+	}
+	if err := seedData(ctx, pool, true); err != nil {
+		fail("seed failed:", err)
 	}
-	return trimmed
+	fmt.Printf("dev environment ready\n  DATABASE_URL=%s\n  REDIS_URL=%s\n", env.DatabaseURL, env.RedisURL)
+	return env
 }
 
+// runConfig handles "routerx config validate [-f file]", which loads the
+// config file (CONFIG_FILE by default) with environment overrides and
+// reports every problem found.
+func runConfig() {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	file := fs.String("f", os.Getenv("CONFIG_FILE"), "config file to validate")
+	if len(os.Args) < 3 || os.Args[2] != "validate" {
+		fmt.Println("usage: routerx config validate [-f config.yaml]")
+		os.Exit(2)
+	}
+	fs.Parse(os.Args[3:])
+	if _, err := config.Load(*file); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *file == "" {
+		fmt.Println("configuration valid (environment only)")
+		return
+	}
+	fmt.Printf("configuration valid (%s)\n", *file)
+}
+
+// runMigrations handles "routerx migrate [up | down [-n N] | status]"; a
+// bare "migrate" applies pending migrations.
 func runMigrations(cfg config.Config) {
-	flag.CommandLine.Parse(os.Args[2:])
+	action := "up"
+	args := os.Args[2:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	n := fs.Int("n", 1, "migrations to revert (down only)")
+	fs.Parse(args)
+	versions, err := migrate.Load(migrations.FS)
+	if err != nil {
+		fmt.Println("invalid migrations:", err)
+		os.Exit(1)
+	}
 	ctx := context.Background()
 	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
 	if err != nil {
@@ -172,14 +668,61 @@ func runMigrations(cfg config.Config) {
 		os.Exit(1)
 	}
 	defer pool.Close()
-	if err := migrateDir(ctx, pool, resolvePath("migrations")); err != nil {
-		fmt.Println("migrate failed:", err)
-		os.Exit(1)
+	switch action {
+	case "up":
+		done, err := migrate.Up(ctx, pool, versions)
+		for _, m := range done {
+			fmt.Println("applied", m.Name)
+		}
+		if err != nil {
+			fmt.Println("migrate failed:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("migrations applied (%d new)\n", len(done))
+	case "down":
+		done, err := migrate.Down(ctx, pool, versions, *n)
+		for _, m := range done {
+			fmt.Println("reverted", m.Name)
+		}
+		if err != nil {
+			fmt.Println("migrate down failed:", err)
+			os.Exit(1)
+		}
+	case "status":
+		statuses, err := migrate.Statuses(ctx, pool, versions)
+		if err != nil {
+			fmt.Println("migrate status failed:", err)
+			os.Exit(1)
+		}
+		pending, modified := 0, 0
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Modified:
+				state, modified = "MODIFIED", modified+1
+			case s.AppliedAt != nil:
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			default:
+				pending++
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+		}
+		fmt.Printf("%d migrations, %d pending, %d modified since applied\n", len(statuses), pending, modified)
+		if modified > 0 {
+			os.Exit(1)
+		}
+	default:
+		fmt.Println("usage: routerx migrate [up | down [-n N] | status]")
+		os.Exit(2)
 	}
-	fmt.Println("migrations applied")
 }
 
+// runSeed handles "routerx seed [-demo]": providers, the model catalog and
+// pricing, plus with -demo the local-only demo accounts.
 func runSeed(cfg config.Config) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	demo := fs.Bool("demo", false, "also create the demo accounts with well-known passwords (local use only)")
+	fs.Parse(os.Args[2:])
 	ctx := context.Background()
 	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
 	if err != nil {
@@ -187,49 +730,202 @@ func runSeed(cfg config.Config) {
 		os.Exit(1)
 	}
 	defer pool.Close()
-	if err := seedData(ctx, pool); err != nil {
+	if err := seedData(ctx, pool, *demo); err != nil {
 		fmt.Println("seed failed:", err)
 		os.Exit(1)
 	}
 	fmt.Println("seed completed")
 }
 
-func migrateDir(ctx context.Context, pool *pgxpool.Pool, dir string) error {
-	if _, err := pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (filename TEXT PRIMARY KEY, applied_at TIMESTAMP NOT NULL)`); err != nil {
-		return err
+// runInit handles "routerx init": on a database without admin users it
+// creates the first admin, a demo tenant and an API key with random
+// credentials and prints them once. It does nothing once set up.
+func runInit(cfg config.Config) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	var req api.SetupRequest
+	var balance float64
+	fs.StringVar(&req.AdminUsername, "admin", "admin", "admin username")
+	fs.StringVar(&req.TenantName, "tenant", "Demo Tenant", "tenant name")
+	fs.StringVar(&req.OwnerUsername, "owner", "demo", "tenant owner username")
+	fs.Float64Var(&balance, "balance", 0, "opening balance in USD")
+	fs.Parse(os.Args[2:])
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		fmt.Println("db connect failed:", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	st := store.New(pool)
+	res, err := api.Setup(ctx, st, req, balance)
+	if errors.Is(err, store.ErrAlreadySetUp) {
+		fmt.Println("already set up; use \"routerx admin\" to add users or keys")
+		return
 	}
-	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return err
+		fmt.Println("init failed:", err)
+		os.Exit(1)
 	}
-	for _, e := range entries {
-		if e.IsDir() { continue }
-		name := e.Name()
-		var exists bool
-		row := pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE filename=$1)`, name)
-		if err := row.Scan(&exists); err != nil { return err }
-		if exists { continue }
-		b, err := os.ReadFile(dir + "/" + name)
-		if err != nil { return err }
-		if _, err := pool.Exec(ctx, string(b)); err != nil { return err }
-		if _, err := pool.Exec(ctx, `INSERT INTO schema_migrations (filename, applied_at) VALUES ($1,$2)`, name, time.Now().UTC()); err != nil { return err }
+	cliAudit(ctx, st, res.TenantID, "setup", "tenant", res.TenantID, map[string]string{"admin": res.AdminUsername, "owner": res.OwnerUsername})
+	fmt.Printf("RouterX is set up. These credentials are shown only once:\n\n")
+	fmt.Printf("  admin:   %s / %s\n", res.AdminUsername, res.AdminPassword)
+	fmt.Printf("  tenant:  %s (%s)\n", res.TenantName, res.TenantID)
+	fmt.Printf("  owner:   %s / %s\n", res.OwnerUsername, res.OwnerPassword)
+	fmt.Printf("  API key: %s\n", res.APIKey)
+}
+
+// runReencryptKeys encrypts plaintext provider keys, and keys sealed with a
+// retired encryption key, under the current primary key.
+func runReencryptKeys(cfg config.Config) {
+	keys, err := newKeyring(cfg)
+	if err != nil || keys == nil {
+		fmt.Println("PROVIDER_KEY_ENCRYPTION_KEY must be set to a valid key:", err)
+		os.Exit(1)
 	}
-	return nil
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		fmt.Println("db connect failed:", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	st := store.New(pool)
+	st.Keys = keys
+	n, err := st.ReencryptProviderKeys(ctx)
+	if err != nil {
+		fmt.Println("re-encryption failed:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("re-encrypted keys for %d providers\n", n)
 }
 
-func seedData(ctx context.Context, pool *pgxpool.Pool) error {
-	b, err := os.ReadFile(resolvePath("scripts/seed.sql"))
-	if err != nil { return err }
-	_, err = pool.Exec(ctx, string(b))
-	return err
+// runApply applies a routing document: routerx apply -f routing.yaml
+// [-prune] [-dry-run]. "-f -" reads standard input.
+func runApply(cfg config.Config) {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	file := fs.String("f", "", "routing document to apply (- for stdin)")
+	prune := fs.Bool("prune", false, "delete models, pricing and rules missing from the document")
+	dryRun := fs.Bool("dry-run", false, "report changes without writing them")
+	fs.Parse(os.Args[2:])
+	if *file == "" {
+		fmt.Println("usage: routerx apply -f routing.yaml [-prune] [-dry-run]")
+		os.Exit(2)
+	}
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Println("open failed:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+	doc, err := routingconfig.Decode(in)
+	if err != nil {
+		fmt.Println("invalid routing config:", err)
+		os.Exit(1)
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		fmt.Println("db connect failed:", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	changes, err := routingconfig.Apply(ctx, store.New(pool), doc, *prune, *dryRun)
+	if err != nil {
+		fmt.Println("apply failed:", err)
+		os.Exit(1)
+	}
+	sections := []struct {
+		name string
+		c    routingconfig.Change
+	}{{"providers", changes.Providers}, {"models", changes.Models}, {"pricing", changes.Pricing}, {"rules", changes.Rules}}
+	for _, s := range sections {
+		fmt.Printf("%-9s created=%v updated=%v deleted=%v\n", s.name, s.c.Created, s.c.Updated, s.c.Deleted)
+	}
+	switch {
+	case *dryRun:
+		fmt.Println("dry run: nothing written")
+	case changes.Empty():
+		fmt.Println("routing config already up to date")
+	default:
+		fmt.Println("routing config applied")
+	}
 }
 
-func resolvePath(path string) string {
-	if _, err := os.Stat(path); err == nil {
-		return path
+// bootstrapProviders applies the PROVIDERS_CONFIG document. It runs on every
+// start and changes nothing once the database matches; a document that does
+// not load or apply stops startup rather than serve half-configured.
+func bootstrapProviders(ctx context.Context, st *store.Store, src string, logger *zap.Logger) {
+	doc, err := routingconfig.LoadBootstrap(src)
+	if err != nil {
+		logger.Fatal("invalid PROVIDERS_CONFIG", zap.Error(err))
 	}
-	if _, err := os.Stat("../" + path); err == nil {
-		return "../" + path
+	changes, keys, err := routingconfig.ApplyBootstrap(ctx, st, doc, os.Getenv)
+	if err != nil {
+		logger.Fatal("PROVIDERS_CONFIG apply failed", zap.Error(err))
+	}
+	logger.Info("providers config applied",
+		zap.Any("providers", changes.Providers), zap.Any("models", changes.Models),
+		zap.Any("pricing", changes.Pricing), zap.Any("rules", changes.Rules), zap.Strings("keys_set", keys))
+}
+
+// runExport writes the routing document to standard output.
+func runExport(cfg config.Config) {
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "db connect failed:", err)
+		os.Exit(1)
 	}
-	return path
+	defer pool.Close()
+	doc, err := routingconfig.Export(ctx, store.New(pool))
+	if err == nil {
+		err = routingconfig.Encode(os.Stdout, doc)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "export failed:", err)
+		os.Exit(1)
+	}
+}
+
+// newModerationClassifier returns nil when moderation is disabled.
+func newModerationClassifier(cfg config.Config) (guardrails.Classifier, error) {
+	switch cfg.ModerationProvider {
+	case "":
+		return nil, nil
+	case "openai":
+		return guardrails.NewModerationAPI(cfg.ModerationURL, cfg.ModerationAPIKey, cfg.ModerationModel, time.Duration(cfg.ModerationTimeoutMS)*time.Millisecond), nil
+	case "patterns":
+		return guardrails.ParsePatterns(cfg.ModerationPatterns)
+	}
+	return nil, fmt.Errorf("unknown MODERATION_PROVIDER %q", cfg.ModerationProvider)
+}
+
+// newKeyring returns nil when no encryption key is configured.
+func newKeyring(cfg config.Config) (*secrets.Keyring, error) {
+	if cfg.ProviderKeyEncryptionKey == "" {
+		return nil, nil
+	}
+	keys, err := secrets.ParseKeyList(cfg.ProviderKeyRetiredKeys)
+	if err != nil {
+		return nil, err
+	}
+	keys[cfg.ProviderKeyEncryptionKeyID] = cfg.ProviderKeyEncryptionKey
+	return secrets.NewKeyring(cfg.ProviderKeyEncryptionKeyID, keys)
+}
+
+// seedData loads the reference data and, with demo, the fixed-credential
+// demo accounts.
+func seedData(ctx context.Context, pool *pgxpool.Pool, demo bool) error {
+	if _, err := pool.Exec(ctx, seed.SQL); err != nil {
+		return err
+	}
+	if demo {
+		_, err := pool.Exec(ctx, seed.Demo)
+		return err
+	}
+	return nil
 }
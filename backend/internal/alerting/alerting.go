@@ -0,0 +1,190 @@
+// Package alerting evaluates operator alert rules on an interval and notifies
+// people by email, Slack or PagerDuty when a rule starts or stops firing. It
+// also evaluates the spend alerts tenants set for themselves.
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"routerx/internal/jobs"
+	"routerx/internal/mailer"
+	"routerx/internal/store"
+	"routerx/internal/webhook"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Engine evaluates enabled alert rules. A rule notifies when it starts
+// firing, again every cooldown while it keeps firing, and once when it
+// resolves.
+type Engine struct {
+	Store  *store.Store
+	Mailer *mailer.Mailer
+	Client *http.Client
+	Logger *zap.Logger
+	// PagerDutyURL overrides the Events API v2 endpoint.
+	PagerDutyURL string
+	// Webhooks delivers tenant spend alerts on the webhook channel.
+	Webhooks *webhook.Dispatcher
+	// Jobs, when set by RegisterSpend, runs the spend checks CheckSpend queues.
+	Jobs *jobs.Runner
+}
+
+func New(st *store.Store, m *mailer.Mailer, logger *zap.Logger) *Engine {
+	return &Engine{
+		Store:        st,
+		Mailer:       m,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		Logger:       logger,
+		PagerDutyURL: pagerDutyEventsURL,
+	}
+}
+
+// JobEvaluate is the job kind Register schedules.
+const JobEvaluate = "alerts.evaluate"
+
+// Register evaluates every rule each interval on r.
+func (e *Engine) Register(r *jobs.Runner, interval time.Duration) {
+	r.Every(JobEvaluate, interval, func(ctx context.Context, _ json.RawMessage) error {
+		return e.Evaluate(ctx)
+	})
+}
+
+// Evaluate runs one evaluation pass over the enabled rules. A rule that fails
+// to evaluate is logged and does not stop the others.
+func (e *Engine) Evaluate(ctx context.Context) error {
+	rules, err := e.Store.ListEnabledAlertRules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if err := e.evaluate(ctx, rule, time.Now().UTC()); err != nil {
+			e.Logger.Warn("alert evaluation failed", zap.String("rule_id", rule.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (e *Engine) evaluate(ctx context.Context, rule store.AlertRule, now time.Time) error {
+	window := time.Duration(rule.WindowMinutes) * time.Minute
+	values, err := e.Store.AlertMetricValues(ctx, rule.Metric, rule.Scope, window)
+	if err != nil {
+		return err
+	}
+	var breaches []string
+	worst := 0.0
+	for subject, v := range values {
+		if v > rule.Threshold {
+			breaches = append(breaches, fmt.Sprintf("%s=%s", subject, formatValue(rule.Metric, v)))
+			if v > worst {
+				worst = v
+			}
+		}
+	}
+	sort.Strings(breaches)
+
+	switch {
+	case len(breaches) > 0:
+		cooldown := time.Duration(rule.CooldownMinutes) * time.Minute
+		if rule.State == store.AlertFiring && rule.LastNotifiedAt != nil && now.Sub(*rule.LastNotifiedAt) < cooldown {
+			return nil
+		}
+		msg := fmt.Sprintf("%s exceeded %s over the last %dm: %s", rule.Metric, formatValue(rule.Metric, rule.Threshold), rule.WindowMinutes, strings.Join(breaches, ", "))
+		return e.transition(ctx, rule, store.AlertFiring, worst, msg, now)
+	case rule.State == store.AlertFiring:
+		msg := fmt.Sprintf("%s is back under %s", rule.Metric, formatValue(rule.Metric, rule.Threshold))
+		return e.transition(ctx, rule, store.AlertOK, 0, msg, now)
+	}
+	return nil
+}
+
+// transition claims the state change, then notifies and records an event.
+// Claiming first keeps several instances from notifying the same transition,
+// and a broken channel from causing a notification storm; notification
+// failures are kept on the event.
+func (e *Engine) transition(ctx context.Context, rule store.AlertRule, state string, value float64, msg string, now time.Time) error {
+	claimed, err := e.Store.ClaimAlertTransition(ctx, rule, state, now)
+	if err != nil || !claimed {
+		return err
+	}
+	ev := store.AlertEvent{RuleID: rule.ID, State: state, Value: value, Message: msg}
+	if err := e.Notify(ctx, rule, state, msg); err != nil {
+		ev.NotifyError = err.Error()
+		e.Logger.Warn("alert notification failed", zap.String("rule_id", rule.ID), zap.String("channel", rule.Channel), zap.Error(err))
+	}
+	return e.Store.InsertAlertEvent(ctx, ev)
+}
+
+// Notify sends a message for rule over its channel.
+func (e *Engine) Notify(ctx context.Context, rule store.AlertRule, state, msg string) error {
+	title := fmt.Sprintf("[RouterX] %s: %s", strings.ToUpper(state), rule.Name)
+	switch rule.Channel {
+	case store.AlertChannelEmail:
+		if e.Mailer == nil {
+			return fmt.Errorf("smtp is not configured")
+		}
+		return e.Mailer.Send(rule.Target, title, msg+"\n")
+	case store.AlertChannelSlack:
+		return e.post(ctx, rule.Target, map[string]string{"text": "*" + title + "*\n" + msg})
+	case store.AlertChannelPagerDuty:
+		action := "trigger"
+		if state == store.AlertOK {
+			action = "resolve"
+		}
+		return e.post(ctx, e.PagerDutyURL, map[string]interface{}{
+			"routing_key":  rule.Target,
+			"event_action": action,
+			"dedup_key":    "routerx-alert-" + rule.ID,
+			"payload": map[string]string{
+				"summary":  title + " - " + msg,
+				"source":   "routerx",
+				"severity": "critical",
+			},
+		})
+	}
+	return fmt.Errorf("unknown alert channel %q", rule.Channel)
+}
+
+func (e *Engine) post(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+func formatValue(metric string, v float64) string {
+	switch metric {
+	case store.AlertProviderErrorRate:
+		return fmt.Sprintf("%.1f%%", v*100)
+	case store.AlertP95LatencyMS:
+		return fmt.Sprintf("%.0fms", v)
+	case store.AlertSpendPerHourUSD:
+		return fmt.Sprintf("$%.2f/h", v)
+	}
+	return fmt.Sprintf("%g", v)
+}
@@ -0,0 +1,145 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"routerx/internal/jobs"
+	"routerx/internal/store"
+	"routerx/internal/webhook"
+)
+
+// Job kinds RegisterSpend handles: a sweep over every tenant's alerts each
+// interval, and a check of one tenant queued after it is charged.
+const (
+	JobSpendEvaluate = "alerts.spend_evaluate"
+	JobSpendCheck    = "alerts.spend_check"
+)
+
+type spendCheckJob struct {
+	TenantID string `json:"tenant_id"`
+}
+
+// RegisterSpend evaluates tenant spend alerts on r every interval and
+// whenever CheckSpend queues a tenant.
+func (e *Engine) RegisterSpend(r *jobs.Runner, interval time.Duration) {
+	e.Jobs = r
+	r.Every(JobSpendEvaluate, interval, func(ctx context.Context, _ json.RawMessage) error {
+		return e.EvaluateSpend(ctx, "")
+	})
+	r.Handle(JobSpendCheck, 1, func(ctx context.Context, payload json.RawMessage) error {
+		var j spendCheckJob
+		if err := json.Unmarshal(payload, &j); err != nil || j.TenantID == "" {
+			return fmt.Errorf("invalid spend check payload")
+		}
+		return e.EvaluateSpend(ctx, j.TenantID)
+	})
+}
+
+// CheckSpend queues an evaluation of tenantID's spend alerts after a charge.
+// A check already queued for the tenant covers this one too.
+func (e *Engine) CheckSpend(ctx context.Context, tenantID string) {
+	if e == nil || e.Jobs == nil {
+		return
+	}
+	if err := e.Jobs.EnqueueUnique(ctx, JobSpendCheck, JobSpendCheck+":"+tenantID, spendCheckJob{TenantID: tenantID}); err != nil {
+		e.Logger.Warn("spend check enqueue failed", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+}
+
+// EvaluateSpend fires the enabled spend alerts of tenantID, or of every
+// tenant when it is empty, whose threshold the current day or month has
+// crossed. An alert fires at most once per period.
+func (e *Engine) EvaluateSpend(ctx context.Context, tenantID string) error {
+	alerts, err := e.Store.ListEnabledSpendAlerts(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	var t *store.Tenant
+	spent := map[string]float64{}
+	for _, a := range alerts {
+		if t == nil || t.ID != a.TenantID {
+			if t, err = e.Store.GetTenantByID(ctx, a.TenantID); err != nil {
+				return err
+			}
+			spent = map[string]float64{}
+		}
+		if err := e.evaluateSpend(ctx, a, t, spent, now); err != nil {
+			e.Logger.Warn("spend alert evaluation failed", zap.String("alert_id", a.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// evaluateSpend checks one alert of tenant t. spent caches the tenant's spend
+// per period across its alerts.
+func (e *Engine) evaluateSpend(ctx context.Context, a store.TenantSpendAlert, t *store.Tenant, spent map[string]float64, now time.Time) error {
+	start, period := now.Truncate(24*time.Hour), now.Format("2006-01-02")
+	if a.Period == store.SpendPeriodMonthly {
+		start, period = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), now.Format("2006-01")
+	}
+	if a.LastFiredPeriod == period {
+		return nil
+	}
+	v, ok := spent[a.Period]
+	if !ok {
+		var err error
+		if v, err = e.Store.TenantSpendSince(ctx, t.ID, start); err != nil {
+			return err
+		}
+		spent[a.Period] = v
+	}
+	limit := a.Threshold
+	if a.Kind == store.SpendAlertBalancePercent {
+		limit = (t.BalanceUSD + v) * a.Threshold / 100
+	}
+	if v < limit || v == 0 {
+		return nil
+	}
+	claimed, err := e.Store.ClaimSpendAlert(ctx, a.ID, period, now)
+	if err != nil || !claimed {
+		return err
+	}
+	msg := fmt.Sprintf("%s spend of $%.2f for %s has reached $%.2f", a.Period, v, period, limit)
+	if a.Kind == store.SpendAlertBalancePercent {
+		msg += fmt.Sprintf(" (%g%% of balance)", a.Threshold)
+	}
+	notifyErr := ""
+	if err := e.notifySpend(ctx, a, t, v, limit, start, msg); err != nil {
+		notifyErr = err.Error()
+		e.Logger.Warn("spend alert notification failed", zap.String("alert_id", a.ID), zap.String("channel", a.Channel), zap.Error(err))
+	}
+	return e.Store.SetSpendAlertNotifyError(ctx, a.ID, notifyErr)
+}
+
+func (e *Engine) notifySpend(ctx context.Context, a store.TenantSpendAlert, t *store.Tenant, spent, limit float64, start time.Time, msg string) error {
+	switch a.Channel {
+	case store.SpendAlertChannelEmail:
+		if e.Mailer == nil {
+			return fmt.Errorf("smtp is not configured")
+		}
+		return e.Mailer.Send(a.Target, fmt.Sprintf("[RouterX] Spend alert: %s", t.Name), msg+"\n")
+	case store.SpendAlertChannelWebhook:
+		if e.Webhooks == nil {
+			return fmt.Errorf("webhooks are not configured")
+		}
+		e.Webhooks.Fire(ctx, t.ID, webhook.EventSpendAlert, map[string]interface{}{
+			"tenant_id":     t.ID,
+			"alert_id":      a.ID,
+			"period":        a.Period,
+			"period_start":  start.Format("2006-01-02"),
+			"kind":          a.Kind,
+			"threshold":     a.Threshold,
+			"threshold_usd": limit,
+			"spent_usd":     spent,
+			"balance_usd":   t.BalanceUSD,
+		})
+		return nil
+	}
+	return fmt.Errorf("unknown spend alert channel %q", a.Channel)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/store"
+	"routerx/internal/webhook"
+)
+
+func (s *Server) AdminAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.Store.ListAlertRules(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list alert rules", http.StatusInternalServerError)
+		return
+	}
+	if rules == nil {
+		rules = []store.AlertRule{}
+	}
+	writeJSON(w, rules)
+}
+
+func (s *Server) AdminCreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ID = ksuid.New().String()
+	if err := s.Store.CreateAlertRule(r.Context(), rule); err != nil {
+		http.Error(w, "failed to create alert rule", http.StatusInternalServerError)
+		return
+	}
+	s.writeAlertRule(w, r, rule.ID)
+}
+
+func (s *Server) AdminUpdateAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := decodeAlertRule(w, r)
+	if !ok {
+		return
+	}
+	rule.ID = chi.URLParam(r, "id")
+	if err := s.Store.UpdateAlertRule(r.Context(), rule); err != nil {
+		writeAlertRuleError(w, err, "failed to update alert rule")
+		return
+	}
+	s.writeAlertRule(w, r, rule.ID)
+}
+
+func (s *Server) AdminDeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteAlertRule(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeAlertRuleError(w, err, "failed to delete alert rule")
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// AdminTestAlertRule sends a test notification over the rule's channel without
+// changing its state.
+func (s *Server) AdminTestAlertRule(w http.ResponseWriter, r *http.Request) {
+	if s.Alerts == nil {
+		http.Error(w, "alerting is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	rule, err := s.Store.GetAlertRule(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeAlertRuleError(w, err, "failed to load alert rule")
+		return
+	}
+	res := map[string]interface{}{"rule_id": rule.ID, "channel": rule.Channel, "success": true}
+	if err := s.Alerts.Notify(r.Context(), *rule, "test", "This is a test notification for the "+rule.Metric+" alert."); err != nil {
+		res["success"], res["error"] = false, err.Error()
+	}
+	writeJSON(w, res)
+}
+
+func (s *Server) AdminAlertEvents(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	events, err := s.Store.ListAlertEvents(r.Context(), r.URL.Query().Get("rule_id"), limit)
+	if err != nil {
+		http.Error(w, "failed to list alert events", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []store.AlertEvent{}
+	}
+	writeJSON(w, events)
+}
+
+func (s *Server) writeAlertRule(w http.ResponseWriter, r *http.Request, id string) {
+	rule, err := s.Store.GetAlertRule(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load alert rule", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, rule)
+}
+
+func decodeAlertRule(w http.ResponseWriter, r *http.Request) (store.AlertRule, bool) {
+	payload := store.AlertRule{WindowMinutes: 5, CooldownMinutes: 30, Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return store.AlertRule{}, false
+	}
+	if payload.Name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return store.AlertRule{}, false
+	}
+	if !store.ValidAlertMetric(payload.Metric) {
+		http.Error(w, "metric must be provider_error_rate, circuit_opens, p95_latency_ms or spend_per_hour_usd", http.StatusBadRequest)
+		return store.AlertRule{}, false
+	}
+	if payload.WindowMinutes < 1 || payload.CooldownMinutes < 0 || payload.Threshold < 0 {
+		http.Error(w, "window_minutes must be positive; threshold and cooldown_minutes must not be negative", http.StatusBadRequest)
+		return store.AlertRule{}, false
+	}
+	switch payload.Channel {
+	case store.AlertChannelEmail:
+		if _, err := mail.ParseAddress(payload.Target); err != nil {
+			http.Error(w, "target must be an email address", http.StatusBadRequest)
+			return store.AlertRule{}, false
+		}
+	case store.AlertChannelSlack:
+		if webhook.ValidateURL(r.Context(), payload.Target) != nil {
+			http.Error(w, "target must be a Slack webhook URL", http.StatusBadRequest)
+			return store.AlertRule{}, false
+		}
+	case store.AlertChannelPagerDuty:
+		if payload.Target == "" {
+			http.Error(w, "target must be a PagerDuty routing key", http.StatusBadRequest)
+			return store.AlertRule{}, false
+		}
+	default:
+		http.Error(w, "channel must be email, slack or pagerduty", http.StatusBadRequest)
+		return store.AlertRule{}, false
+	}
+	return payload, true
+}
+
+func writeAlertRuleError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "alert rule not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
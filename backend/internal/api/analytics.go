@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"routerx/internal/middleware"
+)
+
+// TenantAnalytics returns the tenant's top models by cost, per-provider error
+// rates, latency/TTFT percentiles and fallback rate. The window defaults to
+// the last 7 days.
+func (s *Server) TenantAnalytics(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -7)
+	}
+	top, _ := strconv.Atoi(r.URL.Query().Get("top"))
+	if top < 1 || top > 50 {
+		top = 10
+	}
+	analytics, err := s.Store.GetTenantAnalytics(r.Context(), user.TenantID, from, to, top)
+	if err != nil {
+		http.Error(w, "failed to load analytics", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, analytics)
+}
+
+// AdminMarginReport compares upstream provider cost with billed revenue per
+// provider and per tenant. The window defaults to the last 30 days.
+func (s *Server) AdminMarginReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	report, err := s.Store.GetMarginReport(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, "failed to load margin report", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, report)
+}
+
+// AdminAttemptFailures groups failed provider attempts (including those a
+// fallback recovered from) by provider and error class. The window defaults
+// to the last 7 days; provider and error_class filter it.
+func (s *Server) AdminAttemptFailures(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -7)
+	}
+	q := r.URL.Query()
+	failures, err := s.Store.GetAttemptFailures(r.Context(), from, to, q.Get("provider"), q.Get("error_class"))
+	if err != nil {
+		http.Error(w, "failed to load attempt failures", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"from": from, "to": to, "failures": failures})
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Values of the X-RouterX-Cache response header. It is only set when the
+// request asked for caching with X-RouterX-Cache: true.
+const (
+	cacheHit     = "hit"
+	cacheMiss    = "miss"
+	cachePartial = "partial"
+	// cacheBypass means caching was asked for but could not apply: streamed
+	// responses are not cached, and without Redis there is no cache.
+	cacheBypass = "bypass"
+)
+
+// routingOutcome is how a /v1 request was served, reported to the client so
+// it does not need a second lookup: as X-RouterX-* response headers, or for
+// a stream, whose headers went out before the outcome was known, in the
+// closing SSE comment.
+type routingOutcome struct {
+	Provider      string
+	Model         string
+	Latency       time.Duration
+	CostUSD       float64
+	HasCost       bool
+	Fallback      bool
+	Cache         string
+	DroppedParams []string
+}
+
+func (o routingOutcome) setHeaders(h http.Header) {
+	if o.Provider != "" {
+		h.Set("X-RouterX-Provider", o.Provider)
+	}
+	if o.Model != "" {
+		h.Set("X-RouterX-Model", o.Model)
+	}
+	if o.Latency > 0 {
+		h.Set("X-RouterX-Latency-Ms", strconv.FormatInt(o.Latency.Milliseconds(), 10))
+	}
+	if o.HasCost {
+		h.Set("X-RouterX-Cost-USD", fmt.Sprintf("%.6f", o.CostUSD))
+	}
+	h.Set("X-RouterX-Fallback", strconv.FormatBool(o.Fallback))
+	if o.Cache != "" {
+		h.Set("X-RouterX-Cache", o.Cache)
+	}
+	if len(o.DroppedParams) > 0 {
+		h.Set("X-RouterX-Dropped-Params", strings.Join(o.DroppedParams, ","))
+	}
+}
+
+// annotateSpan records the outcome on the request's span, next to the
+// tenant and model set when the request was admitted.
+func (o routingOutcome) annotateSpan(ctx context.Context) {
+	attrs := []attribute.KeyValue{
+		attribute.String("routerx.provider", o.Provider),
+		attribute.String("routerx.model", o.Model),
+		attribute.Bool("routerx.fallback", o.Fallback),
+	}
+	if o.HasCost {
+		attrs = append(attrs, attribute.Float64("routerx.cost_usd", o.CostUSD))
+	}
+	if o.Cache != "" {
+		attrs = append(attrs, attribute.String("routerx.cache", o.Cache))
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// comment is the outcome as the SSE comment that follows data: [DONE].
+func (o routingOutcome) comment() string {
+	meta := fmt.Sprintf(": provider=%s model=%s latency_ms=%d fallback=%v", o.Provider, o.Model, o.Latency.Milliseconds(), o.Fallback)
+	if o.HasCost {
+		meta += fmt.Sprintf(" cost_usd=%.6f", o.CostUSD)
+	}
+	if o.Cache != "" {
+		meta += " cache=" + o.Cache
+	}
+	if len(o.DroppedParams) > 0 {
+		meta += " dropped_params=" + strings.Join(o.DroppedParams, ",")
+	}
+	return meta + "\n\n"
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+	"routerx/internal/webhook"
+)
+
+// AdminAPIKeys searches API keys across all tenants by key prefix, tenant,
+// creation date and revocation state.
+func (s *Server) AdminAPIKeys(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := store.APIKeyFilters{Prefix: q.Get("prefix"), TenantID: q.Get("tenant_id"), Revoked: q.Get("revoked")}
+	var err error
+	if f.CreatedAfter, err = parseTimeParam(q.Get("created_after")); err != nil {
+		http.Error(w, "invalid created_after", http.StatusBadRequest)
+		return
+	}
+	if f.CreatedBefore, err = parseTimeParam(q.Get("created_before")); err != nil {
+		http.Error(w, "invalid created_before", http.StatusBadRequest)
+		return
+	}
+	if f.Revoked != "" && f.Revoked != "true" && f.Revoked != "false" {
+		http.Error(w, "revoked must be true or false", http.StatusBadRequest)
+		return
+	}
+	f.Limit, _ = strconv.Atoi(q.Get("limit"))
+	keys, err := s.Store.SearchAPIKeys(r.Context(), f)
+	if err != nil {
+		http.Error(w, "failed to list api keys", http.StatusInternalServerError)
+		return
+	}
+	if keys == nil {
+		keys = []store.APIKey{}
+	}
+	writeJSON(w, keys)
+}
+
+// AdminRevokeAPIKey revokes a key in any tenant. Authentication looks keys up
+// on every request, so the key is rejected from its next request on.
+func (s *Server) AdminRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	admin := middleware.AdminUsernameFromContext(r.Context())
+	key, err := s.Store.RevokeAPIKey(r.Context(), chi.URLParam(r, "key"), admin, payload.Reason)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "api key not found or already revoked", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to revoke api key", http.StatusInternalServerError)
+		return
+	}
+	s.fireEvent(r.Context(), key.TenantID, webhook.EventKeyRevoked, map[string]interface{}{
+		"tenant_id":  key.TenantID,
+		"key":        maskKey(key.Key),
+		"name":       key.Name,
+		"revoked_by": admin,
+		"reason":     payload.Reason,
+	})
+	writeJSON(w, key)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+)
+
+// AdminDeleteModel archives a catalog model: it leaves routing and
+// /v1/models, but its pricing, provider list and history stay intact.
+func (s *Server) AdminDeleteModel(w http.ResponseWriter, r *http.Request) {
+	err := s.Store.ArchiveModel(r.Context(), chi.URLParam(r, "model"), middleware.AdminUsernameFromContext(r.Context()))
+	writeArchiveResult(w, err, "model", "failed to archive model")
+}
+
+func (s *Server) AdminRestoreModel(w http.ResponseWriter, r *http.Request) {
+	err := s.Store.RestoreModel(r.Context(), chi.URLParam(r, "model"))
+	writeArchiveResult(w, err, "archived model", "failed to restore model")
+}
+
+func (s *Server) AdminArchivedModels(w http.ResponseWriter, r *http.Request) {
+	list, err := s.Store.ListArchivedModels(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list archived models", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []store.ArchivedModel{}
+	}
+	writeJSON(w, list)
+}
+
+// AdminArchiveProvider takes a provider out of routing. Unlike disabling it,
+// archiving also hides it from the provider list; logs and usage that name
+// it keep resolving.
+func (s *Server) AdminArchiveProvider(w http.ResponseWriter, r *http.Request) {
+	err := s.Store.ArchiveProvider(r.Context(), chi.URLParam(r, "id"), middleware.AdminUsernameFromContext(r.Context()))
+	writeArchiveResult(w, err, "provider", "failed to archive provider")
+}
+
+func (s *Server) AdminRestoreProvider(w http.ResponseWriter, r *http.Request) {
+	err := s.Store.RestoreProvider(r.Context(), chi.URLParam(r, "id"))
+	writeArchiveResult(w, err, "archived provider", "failed to restore provider")
+}
+
+// writeArchiveResult reports an archive or restore: 404 when nothing was
+// in the state to change.
+func writeArchiveResult(w http.ResponseWriter, err error, what, msg string) {
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, what+" not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"routerx/internal/middleware"
+	"routerx/internal/redact"
+	"routerx/internal/store"
+	"routerx/internal/util"
+)
+
+// maxAuditBody caps how much of a request body is kept when no target
+// snapshot is available.
+const maxAuditBody = 16 << 10
+
+// auditWriter captures the status code written by the wrapped handler.
+type auditWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *auditWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Audit records every mutating request in the audit log. It must run inside
+// the authenticated group so the actor and route are known. Targets with a
+// known shape are snapshotted before and after the handler runs; otherwise the
+// request body (secrets masked) is kept as the after value.
+func (s *Server) Audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rctx := chi.RouteContext(r.Context())
+		pattern := rctx.RoutePattern()
+		entry := store.AuditEntry{Action: r.Method + " " + pattern, IP: middleware.ClientIP(r)}
+		if user := middleware.TenantUserFromContext(r.Context()); user != nil {
+			entry.ActorType, entry.Actor, entry.TenantID = store.ActorTenantUser, user.Username, user.TenantID
+		} else {
+			entry.ActorType, entry.Actor = store.ActorAdmin, middleware.AdminUsernameFromContext(r.Context())
+		}
+		entry.TargetType, entry.TargetID = auditTarget(pattern, rctx)
+		if entry.TargetType == "tenant" && entry.TenantID == "" {
+			entry.TenantID = entry.TargetID
+		}
+
+		entry.Before = s.auditSnapshot(r.Context(), entry)
+		var body []byte
+		if entry.Before == nil && r.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(r.Body, maxAuditBody))
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		}
+
+		aw := &auditWriter{ResponseWriter: w}
+		next.ServeHTTP(aw, r)
+		entry.StatusCode = aw.status
+		if entry.Before != nil {
+			entry.After = s.auditSnapshot(r.Context(), entry)
+		} else {
+			entry.After = redactJSON(body, s.auditRedactor(r.Context(), entry.TenantID))
+		}
+		if entry.TargetType == "api-key" {
+			entry.TargetID = maskKey(entry.TargetID)
+		}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+		defer cancel()
+		_ = s.Store.InsertAuditEntry(ctx, entry)
+	})
+}
+
+// auditTarget derives the target type and id from the route, e.g.
+// /admin/providers/{id}/api-key/promote -> ("provider", id).
+func auditTarget(pattern string, rctx *chi.Context) (string, string) {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		if i+1 < len(parts) && strings.HasPrefix(parts[i+1], "{") {
+			return strings.TrimSuffix(parts[i], "s"), rctx.URLParam(strings.Trim(parts[i+1], "{}"))
+		}
+	}
+	if len(parts) > 1 {
+		return strings.TrimSuffix(parts[1], "s"), ""
+	}
+	return "", ""
+}
+
+// auditSnapshot loads the current state of the target, or nil when the target
+// type has no snapshot or does not exist.
+func (s *Server) auditSnapshot(ctx context.Context, e store.AuditEntry) json.RawMessage {
+	if e.TargetID == "" {
+		return nil
+	}
+	var v interface{}
+	switch e.TargetType {
+	case "provider":
+		p, err := s.Store.GetProviderByID(ctx, e.TargetID)
+		if err != nil {
+			return nil
+		}
+		staged, _ := s.Store.GetStagedProviderAPIKey(ctx, e.TargetID)
+		v = struct {
+			store.Provider
+			APIKeyFingerprint       string `json:"api_key_fingerprint,omitempty"`
+			StagedAPIKeyFingerprint string `json:"staged_api_key_fingerprint,omitempty"`
+		}{*p, keyFingerprint(p.APIKey), keyFingerprint(staged)}
+	case "tenant":
+		// An erased tenant's name must not survive in the audit log.
+		if strings.HasSuffix(e.Action, "/erase") {
+			return nil
+		}
+		t, err := s.Store.GetTenantByID(ctx, e.TargetID)
+		if err != nil {
+			return nil
+		}
+		v = t
+	case "routing-rule":
+		rule, err := s.Store.GetRoutingRuleByID(ctx, e.TargetID)
+		if err != nil {
+			return nil
+		}
+		v = rule
+	case "model":
+		entries, err := s.Store.ListModelProviderEntries(ctx, e.TargetID)
+		if err != nil {
+			return nil
+		}
+		v = entries
+	case "experiment":
+		exp, err := s.Store.GetExperiment(ctx, e.TargetID)
+		if err != nil || exp == nil {
+			return nil
+		}
+		v = exp
+	case "api-key":
+		// Admins act on any tenant's key and carry no tenant of their own;
+		// a tenant user's entry never shows another tenant's key.
+		k, err := s.Store.GetAPIKey(ctx, e.TargetID)
+		if err != nil || (e.ActorType != store.ActorAdmin && k.TenantID != e.TenantID) {
+			return json.RawMessage(`{}`)
+		}
+		k.Key = maskKey(k.Key)
+		v = k
+	default:
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// keyFingerprint identifies a secret without revealing it.
+func keyFingerprint(key string) string {
+	if key == "" {
+		return ""
+	}
+	return util.HashString(key)[:12]
+}
+
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
+
+// auditRedactor returns the PII redactor for audit bodies, or nil when the
+// tenant has opted out of redacting stored data.
+func (s *Server) auditRedactor(ctx context.Context, tenantID string) *redact.Redactor {
+	p, rd := s.redactionPolicy(ctx, tenantID)
+	if !p.RedactLogs {
+		return nil
+	}
+	return rd
+}
+
+// redactedSecret replaces secret-looking values in audit bodies. It is a
+// fixed marker rather than a fingerprint: tenants can read their audit log,
+// and an unsalted hash of a password or OTP can be guessed offline.
+const redactedSecret = "[redacted]"
+
+// redactJSON masks values of secret-looking fields in a JSON object body and
+// PII in other string values. Non-JSON bodies are dropped.
+func redactJSON(body []byte, pii *redact.Redactor) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	b, err := json.Marshal(redactValue(v, pii))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func redactValue(v interface{}, pii *redact.Redactor) interface{} {
+	switch t := v.(type) {
+	case string:
+		out, _ := pii.String(t)
+		return out
+	case map[string]interface{}:
+		for k, val := range t {
+			lk := strings.ToLower(k)
+			if strings.Contains(lk, "key") || strings.Contains(lk, "secret") || strings.Contains(lk, "password") || strings.Contains(lk, "token") || lk == "code" || lk == "otp" {
+				if str, ok := val.(string); ok && str != "" {
+					t[k] = redactedSecret
+					continue
+				}
+			}
+			t[k] = redactValue(val, pii)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i], pii)
+		}
+	}
+	return v
+}
+
+func auditFilters(r *http.Request) (store.AuditFilters, error) {
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		return store.AuditFilters{}, err
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	return store.AuditFilters{
+		ActorType:  q.Get("actor_type"),
+		Actor:      q.Get("actor"),
+		TenantID:   q.Get("tenant_id"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+		From:       from,
+		To:         to,
+		Limit:      limit,
+	}, nil
+}
+
+func (s *Server) AdminAuditLog(w http.ResponseWriter, r *http.Request) {
+	f, err := auditFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.writeAuditLog(w, r, f)
+}
+
+// TenantAuditLog lists audit entries scoped to the caller's tenant.
+func (s *Server) TenantAuditLog(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	f, err := auditFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.TenantID = user.TenantID
+	s.writeAuditLog(w, r, f)
+}
+
+func (s *Server) writeAuditLog(w http.ResponseWriter, r *http.Request, f store.AuditFilters) {
+	entries, err := s.Store.ListAuditLog(r.Context(), f)
+	if err != nil {
+		http.Error(w, "failed to list audit log", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []store.AuditEntry{}
+	}
+	writeJSON(w, entries)
+}
@@ -0,0 +1,44 @@
+package api_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"routerx/internal/api"
+	"routerx/internal/store"
+	"routerx/internal/store/storemock"
+)
+
+func TestAuditSnapshotAPIKey(t *testing.T) {
+	const key = "user_key_0123456789abcdef"
+	tests := []struct {
+		name  string
+		entry store.AuditEntry
+		empty bool
+	}{
+		{name: "admin revoking any tenant's key", entry: store.AuditEntry{ActorType: store.ActorAdmin}},
+		{name: "tenant user on an own key", entry: store.AuditEntry{ActorType: store.ActorTenantUser, TenantID: "t1"}},
+		{name: "tenant user on another tenant's key", entry: store.AuditEntry{ActorType: store.ActorTenantUser, TenantID: "t2"}, empty: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &api.Server{Store: &storemock.Store{
+				GetAPIKeyFunc: func(context.Context, string) (*store.APIKey, error) {
+					return &store.APIKey{Key: key, TenantID: "t1", Name: "ci"}, nil
+				},
+			}}
+			tt.entry.TargetType, tt.entry.TargetID = "api-key", key
+			got := string(api.AuditSnapshot(s, context.Background(), tt.entry))
+			if tt.empty {
+				if got != "{}" {
+					t.Fatalf("snapshot = %s, want {}", got)
+				}
+				return
+			}
+			if !strings.Contains(got, `"ci"`) || strings.Contains(got, key) {
+				t.Fatalf("snapshot = %s, want the key's state with the key masked", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/store"
+)
+
+// Batch size limits.
+const (
+	maxBatchRequests  = 10000
+	maxBatchBodyBytes = 50 << 20
+)
+
+// batchLine is one request in a batch: the OpenAI batch-file line shape, also
+// accepted as the elements of a JSON "requests" array.
+type batchLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// CreateBatch queues chat completion requests for background processing. The
+// body is either JSON ({"requests": [...], "metadata": {...}}) or, with a
+// JSONL content type, one request per line.
+func (s *Server) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantFromContext(r.Context())
+	if tenant == nil {
+		writeAPIError(w, http.StatusUnauthorized, errInvalidRequest, "invalid_api_key", "missing tenant")
+		return
+	}
+	if tenant.Suspended {
+		writeAPIError(w, http.StatusForbidden, errPermission, "account_suspended", "account suspended")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+	var lines []batchLine
+	var rawMeta json.RawMessage
+	ct := r.Header.Get("Content-Type")
+	if strings.Contains(ct, "jsonl") || strings.Contains(ct, "ndjson") {
+		sc := bufio.NewScanner(r.Body)
+		sc.Buffer(make([]byte, 64<<10), maxBatchBodyBytes)
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var l batchLine
+			if err := json.Unmarshal(line, &l); err != nil {
+				writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", fmt.Sprintf("invalid json on line %d", len(lines)+1))
+				return
+			}
+			lines = append(lines, l)
+		}
+		if err := sc.Err(); err != nil {
+			writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_body", "failed to read body")
+			return
+		}
+		if m := r.URL.Query().Get("metadata"); m != "" {
+			rawMeta = json.RawMessage(m)
+		}
+	} else {
+		var payload struct {
+			Requests []batchLine     `json:"requests"`
+			Metadata json.RawMessage `json:"metadata"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_json", "invalid json")
+			return
+		}
+		lines, rawMeta = payload.Requests, payload.Metadata
+	}
+	if len(lines) == 0 {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", "batch has no requests")
+		return
+	}
+	if len(lines) > maxBatchRequests {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", fmt.Sprintf("batch exceeds %d requests", maxBatchRequests))
+		return
+	}
+	metadata, err := parseRequestMetadata(rawMeta)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", err.Error())
+		return
+	}
+
+	apiKey := extractAPIKey(r)
+	var allowedModels []string
+	if keyRec, err := s.Store.GetAPIKey(r.Context(), apiKey); err == nil {
+		allowedModels = keyRec.AllowedModels
+	}
+	items := make([]store.BatchItem, len(lines))
+	seen := map[string]bool{}
+	for i, l := range lines {
+		if l.URL != "" && l.URL != "/v1/chat/completions" {
+			writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", fmt.Sprintf("request %d: only /v1/chat/completions is supported", i))
+			return
+		}
+		if l.CustomID != "" {
+			if seen[l.CustomID] {
+				writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", fmt.Sprintf("request %d: duplicate custom_id %q", i, l.CustomID))
+				return
+			}
+			seen[l.CustomID] = true
+		}
+		var req models.ChatCompletionRequest
+		if err := json.Unmarshal(l.Body, &req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", fmt.Sprintf("request %d: invalid body", i))
+			return
+		}
+		if req.Stream {
+			writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_request", fmt.Sprintf("request %d: streaming is not supported in batches", i))
+			return
+		}
+		if len(allowedModels) > 0 && !contains(allowedModels, strings.TrimSuffix(req.Model, ":free")) {
+			writeAPIError(w, http.StatusForbidden, errInvalidRequest, "model_not_allowed", fmt.Sprintf("request %d: model not allowed for api key", i))
+			return
+		}
+		items[i] = store.BatchItem{CustomID: l.CustomID, Request: l.Body}
+	}
+
+	b := store.Batch{ID: "batch_" + ksuid.New().String(), TenantID: tenant.ID, APIKey: apiKey, Metadata: metadata}
+	if err := s.Store.CreateBatch(r.Context(), b, items); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to create batch")
+		return
+	}
+	created, err := s.Store.GetBatch(r.Context(), tenant.ID, b.ID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to load batch")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(created)
+}
+
+func (s *Server) ListBatches(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantFromContext(r.Context())
+	if tenant == nil {
+		writeAPIError(w, http.StatusUnauthorized, errInvalidRequest, "invalid_api_key", "missing tenant")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	batches, err := s.Store.ListBatches(r.Context(), tenant.ID, limit)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to list batches")
+		return
+	}
+	if batches == nil {
+		batches = []store.Batch{}
+	}
+	writeJSON(w, batches)
+}
+
+func (s *Server) GetBatch(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantFromContext(r.Context())
+	if tenant == nil {
+		writeAPIError(w, http.StatusUnauthorized, errInvalidRequest, "invalid_api_key", "missing tenant")
+		return
+	}
+	b, err := s.Store.GetBatch(r.Context(), tenant.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeBatchError(w, err, "failed to load batch")
+		return
+	}
+	writeJSON(w, b)
+}
+
+// BatchResults streams finished items as JSONL in request order. It can be
+// polled while the batch is still running.
+func (s *Server) BatchResults(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantFromContext(r.Context())
+	if tenant == nil {
+		writeAPIError(w, http.StatusUnauthorized, errInvalidRequest, "invalid_api_key", "missing tenant")
+		return
+	}
+	b, err := s.Store.GetBatch(r.Context(), tenant.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeBatchError(w, err, "failed to load batch")
+		return
+	}
+	results, err := s.Store.ListBatchResults(r.Context(), b.ID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to load batch results")
+		return
+	}
+	w.Header().Set("Content-Type", "application/jsonl")
+	enc := json.NewEncoder(w)
+	for _, it := range results {
+		_ = enc.Encode(it)
+	}
+}
+
+func (s *Server) CancelBatch(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantFromContext(r.Context())
+	if tenant == nil {
+		writeAPIError(w, http.StatusUnauthorized, errInvalidRequest, "invalid_api_key", "missing tenant")
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if err := s.Store.CancelBatch(r.Context(), tenant.ID, id); err != nil {
+		writeBatchError(w, err, "failed to cancel batch")
+		return
+	}
+	b, err := s.Store.GetBatch(r.Context(), tenant.ID, id)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to load batch")
+		return
+	}
+	writeJSON(w, b)
+}
+
+type batchItemKey struct{}
+
+// isBatchItem reports whether ctx belongs to a request ExecBatchItem made.
+func isBatchItem(ctx context.Context) bool {
+	return ctx.Value(batchItemKey{}) != nil
+}
+
+// ExecBatchItem runs a batch item through ChatCompletions as the batch's API
+// key, so batches get the same policy, moderation, billing and request
+// logging as synchronous calls. The key is looked up again for every item:
+// revoking it stops the batch, and its model restrictions and owner apply as
+// they stand when the item runs. The tenant's QPS and concurrency limits are
+// skipped: the batch processor bounds each tenant's running items itself,
+// and offline work must not eat into the live request budget.
+func (s *Server) ExecBatchItem(ctx context.Context, tenantID, apiKey string, body []byte) (int, []byte) {
+	if apiKey == "" {
+		return http.StatusUnauthorized, []byte("batch has no api key; resubmit it")
+	}
+	tenant, err := s.Store.GetTenantByAPIKey(ctx, apiKey)
+	if errors.Is(err, pgx.ErrNoRows) || err == nil && tenant.ID != tenantID {
+		return http.StatusUnauthorized, []byte("api key revoked")
+	}
+	if err != nil {
+		return http.StatusServiceUnavailable, []byte("failed to load tenant")
+	}
+	ctx = context.WithValue(middleware.WithTenantKey(ctx, tenant, apiKey), batchItemKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return http.StatusInternalServerError, []byte(err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp := &itemResponse{header: http.Header{}}
+	s.ChatCompletions(resp, req)
+	if resp.code == 0 {
+		resp.code = http.StatusOK
+	}
+	return resp.code, resp.body.Bytes()
+}
+
+// itemResponse collects what ChatCompletions writes for a batch item.
+type itemResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *itemResponse) Header() http.Header { return w.header }
+
+func (w *itemResponse) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *itemResponse) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+func writeBatchError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeAPIError(w, http.StatusNotFound, errInvalidRequest, "not_found", "batch not found")
+		return
+	}
+	writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", msg)
+}
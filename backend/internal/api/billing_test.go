@@ -0,0 +1,106 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"routerx/internal/api"
+	"routerx/internal/router"
+	"routerx/internal/store"
+	"routerx/internal/store/storemock"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestTenantCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		price  float64
+		priced bool
+		err    error
+		want   float64
+	}{
+		{name: "model_pricing override", price: 0.01, priced: true, want: 0.02},
+		{name: "list price without override", want: router.EstimateCostUSD("gpt-4o", 2000)},
+		{name: "list price when lookup fails", price: 0.01, priced: true, err: errors.New("db down"), want: router.EstimateCostUSD("gpt-4o", 2000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &api.Server{Store: &storemock.Store{
+				GetModelPriceFunc: func(context.Context, string) (float64, bool, error) {
+					return tt.price, tt.priced, tt.err
+				},
+			}}
+			if got := api.TenantCost(s, context.Background(), "gpt-4o", 2000); !approx(got, tt.want) {
+				t.Errorf("TenantCost = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type ledgerEntry struct {
+	kind    string
+	amount  float64
+	balance float64
+}
+
+func TestChargeRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		charge store.Charge
+		err    error
+		want   []ledgerEntry
+	}{
+		{name: "credits cover the cost", charge: store.Charge{CreditsUSD: 0.5, BalanceAfter: 10},
+			want: []ledgerEntry{{store.TxCreditCharge, -0.5, 10}}},
+		{name: "balance pays", charge: store.Charge{BalanceUSD: 0.5, BalanceAfter: 9.5},
+			want: []ledgerEntry{{"charge", -0.5, 9.5}}},
+		{name: "credits then balance", charge: store.Charge{CreditsUSD: 0.2, BalanceUSD: 0.3, BalanceAfter: 9.7},
+			want: []ledgerEntry{{store.TxCreditCharge, -0.2, 9.7}, {"charge", -0.3, 9.7}}},
+		{name: "charge runs into overdraft", charge: store.Charge{BalanceUSD: 0.5, BalanceAfter: -0.2},
+			want: []ledgerEntry{{"charge", -0.3, 0}, {store.TxOverdraft, -0.2, -0.2}}},
+		{name: "already overdrawn", charge: store.Charge{BalanceUSD: 0.5, BalanceAfter: -1},
+			want: []ledgerEntry{{store.TxOverdraft, -0.5, -1}}},
+		{name: "failed charge records nothing", err: errors.New("db down")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usage float64
+			var ledger []ledgerEntry
+			st := &storemock.Store{
+				AddUsageCostFunc: func(_ context.Context, _, _, _ string, _ int, cost float64, _ time.Time) error {
+					usage += cost
+					return nil
+				},
+				ChargeTenantFunc: func(_ context.Context, _ string, amount float64) (store.Charge, error) {
+					if !approx(amount, 0.5) {
+						t.Errorf("ChargeTenant amount = %v, want 0.5", amount)
+					}
+					return tt.charge, tt.err
+				},
+				RecordTransactionFunc: func(_ context.Context, _, kind string, amount, balance float64, _ string) error {
+					ledger = append(ledger, ledgerEntry{kind, amount, balance})
+					return nil
+				},
+			}
+			s := &api.Server{Store: st}
+			api.ChargeRequest(s, context.Background(), &store.Tenant{ID: "t1"}, "openai", "gpt-4o", 1000, 0.5)
+
+			if !approx(usage, 0.5) {
+				t.Errorf("usage cost = %v, want 0.5", usage)
+			}
+			if len(ledger) != len(tt.want) {
+				t.Fatalf("ledger = %+v, want %+v", ledger, tt.want)
+			}
+			for i, e := range ledger {
+				w := tt.want[i]
+				if e.kind != w.kind || !approx(e.amount, w.amount) || !approx(e.balance, w.balance) {
+					t.Errorf("ledger[%d] = %+v, want %+v", i, e, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"routerx/internal/metrics"
+	"routerx/internal/models"
+	"routerx/internal/router"
+	"routerx/internal/util"
+)
+
+// routeResult is what a coalesced upstream call hands every consumer.
+type routeResult struct {
+	resp     models.ChatCompletionResponse
+	provider string
+	fallback bool
+	ttft     time.Duration
+	tokens   int
+	// charged is shared by every copy of the result and set by the first
+	// consumer, the one that is billed for it.
+	charged *atomic.Bool
+}
+
+// coalesceKey identifies requests that may share one upstream call: the same
+// tenant sending the same request body with the same routing options.
+// Attribution fields do not change the answer and are left out.
+func coalesceKey(tenantID string, req models.ChatCompletionRequest, opts router.RouteOptions) string {
+	opts.UserID, opts.AppTitle, opts.AppReferer = "", "", ""
+	b, _ := json.Marshal(struct {
+		Req  models.ChatCompletionRequest
+		Opts router.RouteOptions
+	}{req, opts})
+	return tenantID + ":" + util.HashString(string(b))
+}
+
+// coalescedRoute routes req once for all identical requests in flight on
+// this instance; each gets a copy of the one call's result. charge is true
+// for exactly one of the requests that received the result, the first to
+// do so, so under charge_once the call is billed once even when the request
+// that made it gave up waiting. The call outlives that request, since others
+// may be waiting on it.
+func (s *Server) coalescedRoute(ctx context.Context, tenantID string, req models.ChatCompletionRequest, opts router.RouteOptions) (routeResult, bool, error) {
+	key := coalesceKey(tenantID, req, opts)
+	// ran is only written by the call this request started, and only read
+	// once that call's result has been received.
+	ran := false
+	ch := s.inflight.DoChan(key, func() (interface{}, error) {
+		ran = true
+		r := routeResult{charged: new(atomic.Bool)}
+		var err error
+		r.resp, r.provider, r.fallback, r.ttft, r.tokens, err = s.Router.RouteWith(context.WithoutCancel(ctx), tenantID, req, false, nil, opts)
+		return r, err
+	})
+	select {
+	case out := <-ch:
+		if !ran {
+			metrics.CoalescedRequestsTotal.WithLabelValues(metrics.TenantLabel(tenantID)).Inc()
+		}
+		r, _ := out.Val.(routeResult)
+		charge := r.charged != nil && r.charged.CompareAndSwap(false, true)
+		return r, charge, out.Err
+	case <-ctx.Done():
+		return routeResult{}, false, ctx.Err()
+	}
+}
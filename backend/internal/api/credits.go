@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+)
+
+// AdminTenantCredits lists a tenant's credit grants, live and spent.
+func (s *Server) AdminTenantCredits(w http.ResponseWriter, r *http.Request) {
+	s.writeCreditGrants(w, r, chi.URLParam(r, "id"))
+}
+
+// AdminGrantCredits grants a tenant promotional credits that expire at
+// expires_at, or expires_in_days from now.
+func (s *Server) AdminGrantCredits(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		AmountUSD     float64    `json:"amount_usd"`
+		ExpiresAt     *time.Time `json:"expires_at"`
+		ExpiresInDays int        `json:"expires_in_days"`
+		Reason        string     `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.AmountUSD <= 0 {
+		http.Error(w, "amount_usd must be positive", http.StatusBadRequest)
+		return
+	}
+	var expires time.Time
+	switch {
+	case payload.ExpiresAt != nil:
+		expires = payload.ExpiresAt.UTC()
+	case payload.ExpiresInDays > 0:
+		expires = time.Now().UTC().AddDate(0, 0, payload.ExpiresInDays)
+	default:
+		http.Error(w, "expires_at or expires_in_days required", http.StatusBadRequest)
+		return
+	}
+	if !expires.After(time.Now()) {
+		http.Error(w, "expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+	grant := store.CreditGrant{
+		ID:        "cg_" + ksuid.New().String(),
+		TenantID:  chi.URLParam(r, "id"),
+		AmountUSD: payload.AmountUSD,
+		Reason:    payload.Reason,
+		ExpiresAt: expires,
+		CreatedBy: middleware.AdminUsernameFromContext(r.Context()),
+	}
+	if _, err := s.Store.GetTenantByID(r.Context(), grant.TenantID); err != nil {
+		http.Error(w, "tenant not found", http.StatusNotFound)
+		return
+	}
+	if err := s.Store.GrantCredits(r.Context(), grant); err != nil {
+		http.Error(w, "failed to grant credits", http.StatusInternalServerError)
+		return
+	}
+	grant.RemainingUSD = grant.AmountUSD
+	writeJSON(w, grant)
+}
+
+// AdminRevokeCredits withdraws what is left of a grant.
+func (s *Server) AdminRevokeCredits(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.RevokeCreditGrant(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "grant")); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "credit grant not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to revoke credits", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// TenantCredits lists the tenant's own credit grants.
+func (s *Server) TenantCredits(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.writeCreditGrants(w, r, user.TenantID)
+}
+
+func (s *Server) writeCreditGrants(w http.ResponseWriter, r *http.Request, tenantID string) {
+	grants, err := s.Store.ListCreditGrants(r.Context(), tenantID)
+	if err != nil {
+		http.Error(w, "failed to list credits", http.StatusInternalServerError)
+		return
+	}
+	if grants == nil {
+		grants = []store.CreditGrant{}
+	}
+	writeJSON(w, grants)
+}
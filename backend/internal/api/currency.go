@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// displayCurrency converts USD amounts into a tenant's display currency.
+type displayCurrency struct {
+	Code string
+	Rate float64
+}
+
+// tenantCurrency returns the tenant's display currency, or USD if it cannot
+// be loaded: display must never fail a response.
+func (s *Server) tenantCurrency(ctx context.Context, tenantID string) displayCurrency {
+	code, rate, err := s.Store.GetTenantCurrency(ctx, tenantID)
+	if err != nil {
+		return displayCurrency{Code: store.BaseCurrency, Rate: 1}
+	}
+	return displayCurrency{Code: code, Rate: rate}
+}
+
+// amount converts usd, rounded to four decimal places.
+func (d displayCurrency) amount(usd float64) float64 {
+	return math.Round(usd*d.Rate*1e4) / 1e4
+}
+
+// displayUsage is a usage row with its cost in the display currency.
+type displayUsage struct {
+	store.DailyUsage
+	Display map[string]interface{} `json:"display"`
+}
+
+// displayTransaction is a ledger entry with its amounts in the display
+// currency.
+type displayTransaction struct {
+	store.BalanceTransaction
+	Display map[string]interface{} `json:"display"`
+}
+
+// view returns the display block for USD amounts keyed by name.
+func (d displayCurrency) view(amounts map[string]float64) map[string]interface{} {
+	out := map[string]interface{}{"currency": d.Code, "exchange_rate": d.Rate}
+	for k, v := range amounts {
+		out[k] = d.amount(v)
+	}
+	return out
+}
+
+// TenantTransactions lists the tenant's own ledger, newest first.
+func (s *Server) TenantTransactions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	txs, err := s.Store.ListTransactions(r.Context(), user.TenantID, limit)
+	if err != nil {
+		http.Error(w, "failed to list transactions", http.StatusInternalServerError)
+		return
+	}
+	cur := s.tenantCurrency(r.Context(), user.TenantID)
+	out := make([]displayTransaction, 0, len(txs))
+	for _, tx := range txs {
+		out = append(out, displayTransaction{BalanceTransaction: tx, Display: cur.view(map[string]float64{"amount": tx.AmountUSD, "balance_after": tx.BalanceAfter})})
+	}
+	writeJSON(w, out)
+}
+
+// TenantSetCurrency sets the tenant's own display currency.
+func (s *Server) TenantSetCurrency(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.setTenantCurrency(w, r, user.TenantID)
+}
+
+func (s *Server) AdminSetTenantCurrency(w http.ResponseWriter, r *http.Request) {
+	s.setTenantCurrency(w, r, chi.URLParam(r, "id"))
+}
+
+func (s *Server) setTenantCurrency(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var payload struct {
+		Currency string `json:"currency"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	code := strings.ToUpper(payload.Currency)
+	if code != store.BaseCurrency {
+		if _, err := s.Store.GetExchangeRate(r.Context(), code); err != nil {
+			http.Error(w, "no exchange rate for "+payload.Currency, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := s.Store.SetTenantCurrency(r.Context(), tenantID, code); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "tenant not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to set currency", http.StatusInternalServerError)
+		return
+	}
+	cur := s.tenantCurrency(r.Context(), tenantID)
+	writeJSON(w, map[string]interface{}{"currency": cur.Code, "exchange_rate": cur.Rate})
+}
+
+// ExchangeRates lists the display currencies tenants can choose.
+func (s *Server) ExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := s.Store.ListExchangeRates(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list exchange rates", http.StatusInternalServerError)
+		return
+	}
+	if rates == nil {
+		rates = []store.ExchangeRate{}
+	}
+	writeJSON(w, rates)
+}
+
+// AdminSetExchangeRate sets a manual rate, which the ECB refresh leaves alone.
+func (s *Server) AdminSetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "currency"))
+	if !currencyCode.MatchString(code) || code == store.BaseCurrency {
+		http.Error(w, "currency must be a three-letter code other than USD", http.StatusBadRequest)
+		return
+	}
+	var payload struct {
+		PerUSD float64 `json:"per_usd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.PerUSD <= 0 {
+		http.Error(w, "per_usd must be positive", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.SetExchangeRate(r.Context(), code, payload.PerUSD); err != nil {
+		http.Error(w, "failed to set exchange rate", http.StatusInternalServerError)
+		return
+	}
+	rate, err := s.Store.GetExchangeRate(r.Context(), code)
+	if err != nil {
+		http.Error(w, "failed to load exchange rate", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, rate)
+}
+
+// AdminDeleteExchangeRate removes a rate. Tenants displaying that currency
+// fall back to USD.
+func (s *Server) AdminDeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteExchangeRate(r.Context(), strings.ToUpper(chi.URLParam(r, "currency"))); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "exchange rate not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to delete exchange rate", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"routerx/internal/metrics"
+	"routerx/internal/util"
+)
+
+// maxDedupWindowSec caps a tenant's dedup window.
+const maxDedupWindowSec = 3600
+
+// dedupMaxWait bounds how long a repeat waits for the in-flight original,
+// and so how long a leader that dies mid-request can block its key.
+const dedupMaxWait = 2 * time.Minute
+
+const dedupPending = "pending"
+
+// dedupKey identifies a request for deduplication. The API key is hashed so
+// it never appears in Redis.
+func dedupKey(apiKey, model, promptHash string) string {
+	return "dedup:" + util.HashString(apiKey)[:16] + ":" + model + ":" + promptHash
+}
+
+// dedupJoin claims key for this request or waits on the request that holds
+// it. It returns the original's response body for a repeat, leader=true
+// when this request must make the call and then dedupFinish, and neither
+// when the request should go ahead without deduplication (Redis failed, or
+// the original failed or took too long).
+func (s *Server) dedupJoin(ctx context.Context, tenantID, key string, window time.Duration) (body []byte, leader bool) {
+	claimed, err := s.Router.Redis.SetNX(ctx, key, dedupPending, window+dedupMaxWait).Result()
+	if err != nil {
+		return nil, false
+	}
+	if claimed {
+		metrics.DedupTotal.WithLabelValues(metrics.TenantLabel(tenantID), "leader").Inc()
+		return nil, true
+	}
+	deadline := time.NewTimer(dedupMaxWait)
+	defer deadline.Stop()
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		val, err := s.Router.Redis.Get(ctx, key).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+			// The original failed and released the key.
+			metrics.DedupTotal.WithLabelValues(metrics.TenantLabel(tenantID), "miss").Inc()
+			return nil, false
+		case err == nil && val != dedupPending:
+			metrics.DedupTotal.WithLabelValues(metrics.TenantLabel(tenantID), "hit").Inc()
+			return []byte(val), false
+		}
+		select {
+		case <-tick.C:
+		case <-deadline.C:
+			metrics.DedupTotal.WithLabelValues(metrics.TenantLabel(tenantID), "miss").Inc()
+			return nil, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// dedupFinish publishes the leader's response for the rest of the window,
+// or releases the key when the request did not succeed so waiting repeats
+// make their own call.
+func (s *Server) dedupFinish(ctx context.Context, key string, body []byte, window time.Duration) {
+	if body == nil {
+		s.Router.Redis.Del(ctx, key)
+		return
+	}
+	s.Router.Redis.Set(ctx, key, body, window)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"routerx/internal/models"
+	"routerx/internal/router"
+	"routerx/internal/store"
+)
+
+// budgetDowngrade swaps req.Model for its configured cheaper model when the
+// tenant's remaining daily budget is below the policy threshold, returning
+// the original model when it did. A target the tenant policy or the API
+// key's allowed models (keyModels, empty for any) do not allow is never
+// substituted.
+func (s *Server) budgetDowngrade(ctx context.Context, p *store.RequestPolicy, keyModels []string, req *models.ChatCompletionRequest) (from string) {
+	target, ok := p.DowngradeModels[req.Model]
+	if !ok || p.DailyBudgetUSD <= 0 || target == req.Model {
+		return ""
+	}
+	if !router.ModelAllowed(target, p.AllowedModels, p.DeniedModels) {
+		return ""
+	}
+	if len(keyModels) > 0 && !contains(keyModels, target) {
+		return ""
+	}
+	spent, err := s.Store.TenantSpendOn(ctx, p.TenantID, time.Now())
+	if err != nil || p.DailyBudgetUSD-spent >= p.DowngradeBelowUSD {
+		return ""
+	}
+	from, req.Model = req.Model, target
+	return from
+}
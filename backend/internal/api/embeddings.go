@@ -0,0 +1,314 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"routerx/internal/middleware"
+	"routerx/internal/observability"
+	"routerx/internal/providers"
+	"routerx/internal/router"
+	"routerx/internal/store"
+)
+
+const (
+	// embeddingBatchSize is the most inputs sent upstream in one call;
+	// OpenAI rejects embedding requests with more than 2048 inputs.
+	embeddingBatchSize = 2048
+	// embeddingCacheTTL is how long a cached embedding is reused unless
+	// Server.EmbeddingCacheTTL says otherwise.
+	embeddingCacheTTL = 24 * time.Hour
+)
+
+type embeddingRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"`
+	EncodingFormat string          `json:"encoding_format,omitempty"`
+	Dimensions     int             `json:"dimensions,omitempty"`
+	User           string          `json:"user,omitempty"`
+}
+
+type embeddingData struct {
+	Object    string          `json:"object"`
+	Index     int             `json:"index"`
+	Embedding json.RawMessage `json:"embedding"`
+}
+
+type embeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+type embeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []embeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  embeddingUsage  `json:"usage"`
+}
+
+// Embeddings proxies embedding requests to the appropriate provider. Input
+// arrays longer than embeddingBatchSize are split across upstream calls and
+// merged back in order. With X-RouterX-Cache: true, embeddings are cached in
+// Redis per model, options and input, and only uncached inputs go upstream.
+func (s *Server) Embeddings(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantFromContext(r.Context())
+	if tenant == nil {
+		writeAPIError(w, http.StatusUnauthorized, errInvalidRequest, "invalid_api_key", "missing tenant")
+		return
+	}
+	if tenant.Suspended {
+		writeAPIError(w, http.StatusForbidden, errPermission, "account_suspended", "account suspended")
+		return
+	}
+	if tenant.AvailableUSD() <= 0 {
+		writeAPIError(w, http.StatusPaymentRequired, errInsufficientQuota, "insufficient_quota", "insufficient balance")
+		return
+	}
+	if _, ok := s.brownoutLimit(w, tenant); !ok {
+		return
+	}
+
+	var req embeddingRequest
+	if err := s.decodeJSON(r.Body, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	r = r.WithContext(observability.WithModel(r.Context(), req.Model))
+	inputs, err := embeddingInputs(req.Input)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_input", err.Error())
+		return
+	}
+
+	policy, err := s.Store.GetRequestPolicy(r.Context(), tenant.ID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to load tenant policy")
+		return
+	}
+	if !router.ModelAllowed(req.Model, policy.AllowedModels, policy.DeniedModels) {
+		writePolicyViolation(w, http.StatusForbidden, fmt.Sprintf("model %s is not allowed for this tenant", req.Model))
+		return
+	}
+	regions, ok := residencyRegions(w, r, policy)
+	if !ok {
+		return
+	}
+
+	// Find provider for this model
+	providerType, ok, _ := s.Store.GetModelProvider(r.Context(), req.Model)
+	if !ok || providerType == "" {
+		providerType = "openai" // default to openai for embeddings
+	}
+	all, err := s.Store.GetEnabledProvidersByType(r.Context(), providerType)
+	var eligible []store.Provider
+	for _, p := range all {
+		if router.ProviderAllowed(p, policy.AllowedProviders, policy.DeniedProviders) && router.InRegions(p, regions) && !p.UnderMaintenance() {
+			eligible = append(eligible, p)
+		}
+	}
+	if err != nil || len(eligible) == 0 {
+		writeAPIError(w, http.StatusBadGateway, "upstream_error", "no_provider", "no provider available for embeddings")
+		return
+	}
+
+	out := embeddingResponse{Object: "list", Data: make([]embeddingData, len(inputs)), Model: req.Model}
+	useCache := r.Header.Get("X-RouterX-Cache") == "true" && s.Router.Redis != nil
+	var keys []string
+	var missing []int
+	outcome := routingOutcome{}
+	if r.Header.Get("X-RouterX-Cache") == "true" {
+		outcome.Cache = cacheBypass
+	}
+	if useCache {
+		keys, missing = s.cachedEmbeddings(r.Context(), req, inputs, out.Data)
+		w.Header().Set("X-RouterX-Cache-Hits", strconv.Itoa(len(inputs)-len(missing)))
+		switch len(missing) {
+		case 0:
+			outcome.Cache = cacheHit
+		case len(inputs):
+			outcome.Cache = cacheMiss
+		default:
+			outcome.Cache = cachePartial
+		}
+	} else {
+		missing = make([]int, len(inputs))
+		for i := range missing {
+			missing[i] = i
+		}
+	}
+
+	for start := 0; start < len(missing); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		batch := missing[start:end]
+		chunk := make([]json.RawMessage, len(batch))
+		for i, idx := range batch {
+			chunk[i] = inputs[idx]
+		}
+		body := req
+		body.Input, _ = json.Marshal(chunk)
+		resp, provider, fallback, err := s.embedUpstream(r.Context(), eligible, body)
+		if err != nil {
+			writeError(w, fmt.Errorf("embeddings failed: %w", err))
+			return
+		}
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(batch) {
+				writeError(w, fmt.Errorf("embeddings failed: provider returned index %d for %d inputs", d.Index, len(batch)))
+				return
+			}
+			out.Data[batch[d.Index]].Embedding = d.Embedding
+		}
+		out.Usage.PromptTokens += resp.Usage.PromptTokens
+		out.Usage.TotalTokens += resp.Usage.TotalTokens
+		if resp.Model != "" {
+			out.Model = resp.Model
+		}
+		outcome.Provider = provider
+		outcome.Fallback = outcome.Fallback || fallback
+	}
+	for i := range out.Data {
+		out.Data[i].Object = "embedding"
+		out.Data[i].Index = i
+	}
+	if useCache && len(missing) > 0 {
+		ttl := s.EmbeddingCacheTTL
+		if ttl <= 0 {
+			ttl = embeddingCacheTTL
+		}
+		pipe := s.Router.Redis.Pipeline()
+		for _, idx := range missing {
+			if len(out.Data[idx].Embedding) > 0 {
+				pipe.Set(r.Context(), keys[idx], []byte(out.Data[idx].Embedding), ttl)
+			}
+		}
+		_, _ = pipe.Exec(r.Context())
+	}
+	outcome.Model = out.Model
+	outcome.setHeaders(w.Header())
+	outcome.annotateSpan(r.Context())
+	writeJSON(w, out)
+}
+
+// embeddingInputs splits an embeddings input into its items: a string or a
+// single token array is one item, an array of strings or token arrays is one
+// item per element.
+func embeddingInputs(raw json.RawMessage) ([]json.RawMessage, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, errors.New("input is required")
+	}
+	if raw[0] != '[' {
+		return []json.RawMessage{raw}, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, errors.New("input must be a string or an array")
+	}
+	if len(items) == 0 {
+		return nil, errors.New("input must not be empty")
+	}
+	if first := bytes.TrimSpace(items[0]); len(first) > 0 && first[0] != '"' && first[0] != '[' {
+		// An array of numbers is one tokenized input.
+		return []json.RawMessage{raw}, nil
+	}
+	return items, nil
+}
+
+// cachedEmbeddings fills data with cached embeddings for inputs and returns
+// every input's cache key and the indices of the inputs not in the cache.
+func (s *Server) cachedEmbeddings(ctx context.Context, req embeddingRequest, inputs []json.RawMessage, data []embeddingData) ([]string, []int) {
+	keys := make([]string, len(inputs))
+	for i, in := range inputs {
+		h := sha256.New()
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00", req.Model, req.EncodingFormat, req.Dimensions)
+		h.Write(in)
+		keys[i] = "embed_cache:" + hex.EncodeToString(h.Sum(nil))
+	}
+	// Pipelined GETs rather than MGET: the keys hash to different Redis
+	// Cluster slots.
+	pipe := s.Router.Redis.Pipeline()
+	gets := make([]*redis.StringCmd, len(keys))
+	for i, k := range keys {
+		gets[i] = pipe.Get(ctx, k)
+	}
+	_, _ = pipe.Exec(ctx)
+	var missing []int
+	for i, g := range gets {
+		if v, err := g.Result(); err == nil {
+			data[i].Embedding = json.RawMessage(v)
+			continue
+		}
+		missing = append(missing, i)
+	}
+	return keys, missing
+}
+
+// embedUpstream sends one embeddings request, trying providers in order
+// until one succeeds. It returns the provider that answered and whether an
+// earlier one failed first.
+func (s *Server) embedUpstream(ctx context.Context, eligible []store.Provider, body embeddingRequest) (embeddingResponse, string, bool, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return embeddingResponse{}, "", false, err
+	}
+	var lastErr error
+	for _, p := range eligible {
+		if p.APIKey == "" {
+			continue
+		}
+		url := "https://api.openai.com/v1/embeddings"
+		if p.BaseURL != "" {
+			url = strings.TrimRight(p.BaseURL, "/") + "/v1/embeddings"
+		}
+		resp, err := postEmbeddings(ctx, providers.Transport(p.Type, p.Network), url, p.APIKey, payload)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return resp, p.Name, lastErr != nil, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no provider with API key for embeddings")
+	}
+	return embeddingResponse{}, "", false, lastErr
+}
+
+func postEmbeddings(ctx context.Context, transport http.RoundTripper, url, apiKey string, payload []byte) (embeddingResponse, error) {
+	var out embeddingResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return out, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{Timeout: 30 * time.Second, Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return out, fmt.Errorf("%s", string(b))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	return out, nil
+}
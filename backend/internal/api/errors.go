@@ -0,0 +1,25 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"routerx/internal/models"
+)
+
+// Error types used in /v1 error bodies, as OpenAI's API reports them.
+const (
+	errInvalidRequest    = "invalid_request_error"
+	errPermission        = "permission_error"
+	errRateLimit         = "rate_limit_error"
+	errInsufficientQuota = "insufficient_quota"
+	errServer            = "server_error"
+)
+
+// writeAPIError writes an OpenAI-style {"error": {...}} body. /v1 handlers
+// use it for every error so OpenAI SDKs can parse the failure.
+func writeAPIError(w http.ResponseWriter, status int, errType, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{Error: models.ErrorDetail{Message: msg, Type: errType, Code: code}})
+}
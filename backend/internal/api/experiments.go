@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/store"
+)
+
+type experimentPayload struct {
+	Name              string  `json:"name"`
+	TenantID          string  `json:"tenant_id"`
+	Model             string  `json:"model"`
+	VariantProviderID string  `json:"variant_provider_id"`
+	VariantModel      string  `json:"variant_model"`
+	TrafficPercent    float64 `json:"traffic_percent"`
+	Enabled           *bool   `json:"enabled"`
+}
+
+func (p experimentPayload) validate() string {
+	if p.TenantID == "" || p.Model == "" {
+		return "tenant_id and model required"
+	}
+	if p.VariantProviderID == "" && p.VariantModel == "" {
+		return "variant_provider_id or variant_model required"
+	}
+	if p.TrafficPercent < 0 || p.TrafficPercent > 100 {
+		return "traffic_percent must be between 0 and 100"
+	}
+	return ""
+}
+
+func (p experimentPayload) toExperiment(id string) store.Experiment {
+	enabled := true
+	if p.Enabled != nil {
+		enabled = *p.Enabled
+	}
+	return store.Experiment{
+		ID:                id,
+		Name:              p.Name,
+		TenantID:          p.TenantID,
+		Model:             p.Model,
+		VariantProviderID: p.VariantProviderID,
+		VariantModel:      p.VariantModel,
+		TrafficPercent:    p.TrafficPercent,
+		Enabled:           enabled,
+	}
+}
+
+func (s *Server) AdminListExperiments(w http.ResponseWriter, r *http.Request) {
+	list, err := s.Store.ListExperiments(r.Context(), r.URL.Query().Get("tenant_id"))
+	if err != nil {
+		http.Error(w, "failed to list experiments", http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []store.Experiment{}
+	}
+	writeJSON(w, list)
+}
+
+func (s *Server) AdminCreateExperiment(w http.ResponseWriter, r *http.Request) {
+	var payload experimentPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if msg := payload.validate(); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	exp := payload.toExperiment(ksuid.New().String())
+	if err := s.Store.UpsertExperiment(r.Context(), exp); err != nil {
+		http.Error(w, "failed to create experiment", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, exp)
+}
+
+func (s *Server) AdminUpdateExperiment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "missing experiment id", http.StatusBadRequest)
+		return
+	}
+	var payload experimentPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if msg := payload.validate(); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.UpsertExperiment(r.Context(), payload.toExperiment(id)); err != nil {
+		http.Error(w, "failed to update experiment", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func (s *Server) AdminDeleteExperiment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		http.Error(w, "missing experiment id", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.DeleteExperiment(r.Context(), id); err != nil {
+		http.Error(w, "failed to delete experiment", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// AdminExperimentResults compares latency, cost and error rate between the
+// control and variant arms of an experiment.
+func (s *Server) AdminExperimentResults(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	exp, err := s.Store.GetExperiment(r.Context(), id)
+	if err != nil {
+		http.Error(w, "experiment not found", http.StatusNotFound)
+		return
+	}
+	arms, err := s.Store.GetExperimentResults(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load experiment results", http.StatusInternalServerError)
+		return
+	}
+	if arms == nil {
+		arms = []store.ExperimentArmStats{}
+	}
+	writeJSON(w, map[string]interface{}{
+		"experiment": exp,
+		"arms":       arms,
+	})
+}
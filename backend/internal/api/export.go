@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/store"
+)
+
+// exportErrorTrailer is the trailer set when an export fails part way, after
+// rows have been sent with a 200.
+const exportErrorTrailer = "X-Export-Error"
+
+// exportFlushEvery is how many rows are written between flushes so large
+// exports start arriving before the query finishes.
+const exportFlushEvery = 500
+
+var (
+	requestExportColumns = []string{"id", "created_at", "provider", "model", "status_code", "latency_ms", "ttft_ms", "tokens", "cost_usd", "fallback_used", "error_code", "user_id", "app_title", "key_owner"}
+	dailyExportColumns   = []string{"day", "provider", "model", "tokens", "cost_usd"}
+)
+
+// TenantUsageExport streams the tenant's request logs (type=requests, the
+// default) or daily usage (type=daily) for a date range as CSV or NDJSON.
+// The range defaults to the last 30 days. A failure after rows have been
+// sent ends the file with an error line and sets the X-Export-Error
+// trailer, so a truncated export is not mistaken for a complete one.
+func (s *Server) TenantUsageExport(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		http.Error(w, "format must be csv or ndjson", http.StatusBadRequest)
+		return
+	}
+	kind := r.URL.Query().Get("type")
+	if kind == "" {
+		kind = "requests"
+	}
+	if kind != "requests" && kind != "daily" {
+		http.Error(w, "type must be requests or daily", http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("usage_%s_%s_%s.%s", kind, from.Format("20060102"), to.Format("20060102"), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Header().Set("Trailer", exportErrorTrailer)
+
+	ew := newExportWriter(w, format)
+	if kind == "daily" {
+		ew.header(dailyExportColumns)
+		err = s.Store.EachDailyUsage(r.Context(), user.TenantID, from, to, func(u store.DailyUsage) error {
+			return ew.row(u, []string{u.Day.Format("2006-01-02"), u.Provider, u.Model, strconv.FormatInt(u.Tokens, 10), strconv.FormatFloat(u.CostUSD, 'f', 6, 64)})
+		})
+	} else {
+		ew.header(requestExportColumns)
+		err = s.Store.EachRequestLog(r.Context(), user.TenantID, from, to, func(l models.RequestLog) error {
+			return ew.row(l, []string{
+				strconv.Itoa(l.ID), l.CreatedAt.Format(time.RFC3339), l.Provider, l.Model,
+				strconv.Itoa(l.StatusCode), strconv.FormatInt(l.LatencyMS, 10), strconv.FormatInt(l.TTFTMS, 10),
+				strconv.Itoa(l.Tokens), strconv.FormatFloat(l.CostUSD, 'f', 6, 64), strconv.FormatBool(l.FallbackUsed),
+				l.ErrorCode, l.UserID, l.AppTitle, l.KeyOwner,
+			})
+		})
+	}
+	if err != nil {
+		s.Logger.Error("usage export failed", zap.String("tenant_id", user.TenantID), zap.String("type", kind), zap.Int("rows", ew.rows), zap.Error(err))
+		if ew.rows == 0 {
+			// Nothing has been streamed yet, so a proper error status is still possible.
+			w.Header().Del("Trailer")
+			http.Error(w, "failed to export usage", http.StatusInternalServerError)
+			return
+		}
+		ew.fail("export incomplete: failed after " + strconv.Itoa(ew.rows) + " rows")
+	}
+	ew.flush()
+}
+
+// exportWriter writes rows as CSV or NDJSON and flushes periodically.
+type exportWriter struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer
+	enc     *json.Encoder
+	flusher http.Flusher
+	rows    int
+}
+
+func newExportWriter(w http.ResponseWriter, format string) *exportWriter {
+	ew := &exportWriter{w: w}
+	ew.flusher, _ = w.(http.Flusher)
+	if format == "csv" {
+		ew.csv = csv.NewWriter(w)
+	} else {
+		ew.enc = json.NewEncoder(w)
+	}
+	return ew
+}
+
+func (ew *exportWriter) header(cols []string) {
+	if ew.csv != nil {
+		_ = ew.csv.Write(cols)
+	}
+}
+
+// row writes record as a CSV line, or v as a JSON line.
+func (ew *exportWriter) row(v interface{}, record []string) error {
+	var err error
+	if ew.csv != nil {
+		err = ew.csv.Write(record)
+	} else {
+		err = ew.enc.Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+	ew.rows++
+	if ew.rows%exportFlushEvery == 0 {
+		ew.flush()
+	}
+	return nil
+}
+
+// fail ends a partly written export with msg as a last line and in the
+// error trailer.
+func (ew *exportWriter) fail(msg string) {
+	if ew.csv != nil {
+		_ = ew.csv.Write([]string{"error: " + msg})
+	} else {
+		_ = ew.enc.Encode(map[string]string{"error": msg})
+	}
+	ew.w.Header().Set(exportErrorTrailer, msg)
+}
+
+func (ew *exportWriter) flush() {
+	if ew.csv != nil {
+		ew.csv.Flush()
+	}
+	if ew.flusher != nil {
+		ew.flusher.Flush()
+	}
+}
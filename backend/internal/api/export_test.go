@@ -0,0 +1,9 @@
+package api
+
+// Hooks for the tests in package api_test, which cannot live in package api:
+// storemock imports it.
+var (
+	AuditSnapshot = (*Server).auditSnapshot
+	ChargeRequest = (*Server).chargeRequest
+	TenantCost    = (*Server).tenantCost
+)
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"routerx/internal/limiter"
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/router"
+)
+
+// claimFreeAllowance counts req against its model's daily free allowance,
+// lowering req's max_tokens and max_completion_tokens to what the token
+// allowance has left. It returns
+// nil, and the request is billed as usual, when the model has no allowance,
+// the tenant has used it up, or the counter cannot be reached.
+func (s *Server) claimFreeAllowance(ctx context.Context, tenantID string, req *models.ChatCompletionRequest) *limiter.FreeClaim {
+	if s.FreeAllowances == nil {
+		return nil
+	}
+	model := req.Model
+	a, err := s.Store.GetModelFreeAllowance(ctx, model)
+	if err != nil || !a.Active() {
+		return nil
+	}
+	completion := req.MaxTokens
+	if req.MaxCompletionTokens > completion {
+		completion = req.MaxCompletionTokens
+	}
+	claim, ok, err := s.FreeAllowances.Claim(ctx, tenantID, model, a.RequestsPerDay, a.TokensPerDay,
+		router.EstimatePromptTokens(*req), completion, func(ctx context.Context) (int64, int64, error) {
+			return s.Store.FreeUsageOn(ctx, tenantID, model, time.Now())
+		})
+	if err != nil || !ok {
+		return nil
+	}
+	if limit := claim.MaxTokens; limit > 0 {
+		// Providers prefer max_completion_tokens, so it is capped too.
+		if req.MaxCompletionTokens > limit {
+			req.MaxCompletionTokens = limit
+		}
+		if req.MaxTokens > limit || (req.MaxTokens == 0 && req.MaxCompletionTokens == 0) {
+			req.MaxTokens = limit
+		}
+	}
+	return claim
+}
+
+// freeAllowanceUsage is a model's free allowance with the tenant's use of it
+// today.
+type freeAllowanceUsage struct {
+	Model          string `json:"model"`
+	RequestsPerDay int    `json:"free_requests_per_day"`
+	TokensPerDay   int    `json:"free_tokens_per_day"`
+	RequestsUsed   int64  `json:"requests_used"`
+	TokensUsed     int64  `json:"tokens_used"`
+	Exhausted      bool   `json:"exhausted"`
+}
+
+// TenantFreeAllowances lists the models with a free allowance and how much
+// of each the tenant has used today (UTC).
+func (s *Server) TenantFreeAllowances(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	allowances, err := s.Store.ListFreeAllowances(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list free allowances", http.StatusInternalServerError)
+		return
+	}
+	out := make([]freeAllowanceUsage, 0, len(allowances))
+	for _, a := range allowances {
+		u := freeAllowanceUsage{Model: a.Model, RequestsPerDay: a.RequestsPerDay, TokensPerDay: a.TokensPerDay}
+		found := false
+		if s.FreeAllowances != nil {
+			u.RequestsUsed, u.TokensUsed, found, _ = s.FreeAllowances.Used(r.Context(), user.TenantID, a.Model)
+		}
+		if !found {
+			u.RequestsUsed, u.TokensUsed, _ = s.Store.FreeUsageOn(r.Context(), user.TenantID, a.Model, time.Now())
+		}
+		u.Exhausted = (a.RequestsPerDay > 0 && u.RequestsUsed >= int64(a.RequestsPerDay)) ||
+			(a.TokensPerDay > 0 && u.TokensUsed >= int64(a.TokensPerDay))
+		out = append(out, u)
+	}
+	writeJSON(w, out)
+}
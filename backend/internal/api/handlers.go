@@ -1,10 +1,13 @@
 package api
 
 import (
-	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,12 +16,21 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/segmentio/ksuid"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/sync/singleflight"
 
+	"routerx/internal/alerting"
+	"routerx/internal/cluster"
+	"routerx/internal/guardrails"
+	"routerx/internal/keyusage"
 	"routerx/internal/limiter"
+	"routerx/internal/mailer"
 	"routerx/internal/metrics"
 	"routerx/internal/middleware"
 	"routerx/internal/models"
+	"routerx/internal/observability"
+	"routerx/internal/providers"
 	"routerx/internal/router"
+	"routerx/internal/sessions"
 	"routerx/internal/store"
 	"routerx/internal/util"
 	"routerx/internal/webhook"
@@ -27,45 +39,124 @@ import (
 )
 
 type Server struct {
-	Store     *store.Store
+	Store     Store
 	Router    *router.Router
 	Limiter   *limiter.Limiter
+	Brownout  *limiter.Brownout
 	Logger    *zap.Logger
 	JWTSecret string
-	Webhooks  *webhook.Dispatcher
+	// RequireAdmin2FA forces every admin to enroll in two-factor authentication.
+	RequireAdmin2FA bool
+	Webhooks        *webhook.Dispatcher
+	Mailer          *mailer.Mailer
+	PublicURL       string
+	// Moderation classifies prompts and completions; nil disables guardrails.
+	Moderation guardrails.Classifier
+	// LowBalanceThresholdUSD is the balance below which tenant.balance_low fires.
+	LowBalanceThresholdUSD float64
+	Alerts                 *alerting.Engine
+	// KeyUsage counts requests and tokens per API key; nil disables it.
+	KeyUsage *keyusage.Tracker
+	// Region is this deployment's region, preferred when routing unless a
+	// request names another.
+	Region string
+	// Limits caps message counts and inline images and selects strict JSON
+	// decoding on /v1.
+	Limits RequestLimits
+	// EmbeddingCacheTTL is how long an opted-in embedding stays cached;
+	// zero uses embeddingCacheTTL.
+	EmbeddingCacheTTL time.Duration
+	// SetupToken must be presented to POST /setup; setup is refused when
+	// it is empty.
+	SetupToken string
+	// Reservations holds estimated cost against balances while requests
+	// are in flight; nil disables reservations.
+	Reservations *limiter.Reservations
+	// FreeAllowances counts use of models' daily free allowances; nil
+	// disables them.
+	FreeAllowances *limiter.FreeAllowances
+	// Sessions records issued admin and tenant sessions for listing and
+	// revocation; nil leaves sessions untracked.
+	Sessions *sessions.Tracker
+	// Cluster is the registry of instances sharing this deployment, listed
+	// by /admin/instances; nil disables the listing.
+	Cluster *cluster.Registry
+
+	// inflight coalesces identical concurrent requests; see coalescedRoute.
+	inflight singleflight.Group
+	// traffic fans out the live request feed when there is no Redis.
+	traffic trafficHub
 }
 
 func (s *Server) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 	tenant := middleware.TenantFromContext(r.Context())
 	if tenant == nil {
-		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		writeAPIError(w, http.StatusUnauthorized, errInvalidRequest, "invalid_api_key", "missing tenant")
 		return
 	}
 	// Check if tenant is suspended
 	if tenant.Suspended {
-		http.Error(w, "account suspended", http.StatusForbidden)
+		writeAPIError(w, http.StatusForbidden, errPermission, "account_suspended", "account suspended")
 		return
 	}
 	// Check spend limit
 	if tenant.SpendLimitUSD > 0 && tenant.TotalSpentUSD >= tenant.SpendLimitUSD {
-		http.Error(w, "spending limit reached", http.StatusPaymentRequired)
+		writeAPIError(w, http.StatusPaymentRequired, errInsufficientQuota, "spend_limit_reached", "spending limit reached")
 		return
 	}
-	allowed, err := s.Limiter.Allow(r.Context(), tenant.ID)
-	if err != nil || !allowed {
-		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	concLimit, ok := s.brownoutLimit(w, tenant)
+	if !ok {
 		return
 	}
-	acq, err := s.Limiter.Acquire(r.Context(), tenant.ID)
-	if err != nil || !acq {
-		http.Error(w, "too many concurrent requests", http.StatusTooManyRequests)
+	policy, err := s.Store.GetRequestPolicy(r.Context(), tenant.ID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to load tenant policy")
 		return
 	}
-	defer s.Limiter.Release(r.Context(), tenant.ID)
+	// Batch items are admitted by the batch processor's own per-tenant
+	// budget, not the live QPS and concurrency limits.
+	var lease *limiter.Lease
+	if !isBatchItem(r.Context()) {
+		allowed, err := s.Limiter.AllowRate(r.Context(), tenant.ID, policy.RateLimitQPS, policy.RateLimitBurst)
+		if err != nil || !allowed {
+			metrics.RateLimitRejectionsTotal.WithLabelValues(metrics.TenantLabel(tenant.ID), "rpm").Inc()
+			writeAPIError(w, http.StatusTooManyRequests, errRateLimit, "rate_limit_exceeded", "rate limited")
+			return
+		}
+		keyID := maskKey(extractAPIKey(r))
+		var acq bool
+		lease, acq, err = s.Limiter.AcquireLimit(r.Context(), tenant.ID, keyID, concLimit)
+		if err != nil || !acq {
+			if concLimit < s.Limiter.Conc {
+				s.writeBrownout(w, tenant)
+				return
+			}
+			metrics.RateLimitRejectionsTotal.WithLabelValues(metrics.TenantLabel(tenant.ID), "concurrency").Inc()
+			writeAPIError(w, http.StatusTooManyRequests, errRateLimit, "concurrency_limit_exceeded", "too many concurrent requests")
+			return
+		}
+	}
+	defer lease.Release(context.WithoutCancel(r.Context()))
 
+	if policy.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, policy.MaxBodyBytes)
+	}
 	var req models.ChatCompletionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+	if err := s.decodeJSON(r.Body, &req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) && tooLarge.Limit == policy.MaxBodyBytes {
+			writePolicyViolation(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", policy.MaxBodyBytes))
+			return
+		}
+		writeDecodeError(w, err)
+		return
+	}
+	if code, msg := s.checkRequestLimits(req); code != "" {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, code, msg)
+		return
+	}
+	if err := providers.ValidateReasoning(req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_reasoning", err.Error())
 		return
 	}
 	if req.Model == "" {
@@ -77,34 +168,156 @@ func (s *Server) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 		freeMode = true
 		req.Model = strings.TrimSuffix(req.Model, ":free")
 	}
+	r = r.WithContext(observability.WithModel(r.Context(), req.Model))
+	if err := s.renderPromptTemplate(r.Context(), tenant.ID, &req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_prompt_template", err.Error())
+		return
+	}
+	// Experiments: a sampled share of traffic is routed to the variant arm.
+	// The variant is picked before any model-based check, so the key's
+	// allowed models, the tenant policy, cost limits, the free allowance and
+	// the balance reservation all apply to the model actually served.
+	var experimentOpts router.RouteOptions
+	assignment := s.Router.ApplyExperiment(r.Context(), tenant.ID, &req, &experimentOpts)
+	if assignment.ExperimentID != "" {
+		w.Header().Set("X-RouterX-Experiment", assignment.ExperimentID+":"+assignment.Variant)
+	}
 	apiKeyValue := extractAPIKey(r)
+	keyOwner := ""
+	var keyModels []string
 	if apiKeyValue != "" {
 		if keyRec, err := s.Store.GetAPIKey(r.Context(), apiKeyValue); err == nil {
 			if len(keyRec.AllowedModels) > 0 && !contains(keyRec.AllowedModels, req.Model) {
-				http.Error(w, "model not allowed for api key", http.StatusForbidden)
+				writeAPIError(w, http.StatusForbidden, errInvalidRequest, "model_not_allowed", "model not allowed for api key")
 				return
 			}
+			keyOwner, keyModels = keyRec.CreatedBy, keyRec.AllowedModels
 		}
 	}
-	if tenant.BalanceUSD <= 0 {
-		http.Error(w, "insufficient balance", http.StatusPaymentRequired)
+	if clamped, violation := checkRequestPolicy(policy, &req); violation != "" {
+		writePolicyViolation(w, http.StatusForbidden, violation)
+		return
+	} else if clamped {
+		w.Header().Set("X-RouterX-Policy-Clamped", "max_tokens")
+	}
+	if from := s.budgetDowngrade(r.Context(), policy, keyModels, &req); from != "" {
+		w.Header().Set("X-RouterX-Downgraded-From", from)
+	}
+	maxCost, err := requestMaxCost(r, &req)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_max_cost", err.Error())
 		return
 	}
+	if maxCost > 0 {
+		if clamped, err := s.applyMaxCost(r.Context(), &req, maxCost); err != nil {
+			writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "max_cost_exceeded", err.Error())
+			return
+		} else if clamped {
+			w.Header().Set("X-RouterX-Max-Cost-Clamped", "max_tokens")
+		}
+	}
+	// A model's daily free allowance admits the request whatever the
+	// balance, its completion capped to the tokens left. The claim is given
+	// back unless the request succeeds.
+	var freeClaim *limiter.FreeClaim
+	freeTokens, freeServed := 0, false
+	if !freeMode {
+		freeClaim = s.claimFreeAllowance(r.Context(), tenant.ID, &req)
+	}
+	allowanceFree := freeClaim != nil
+	if allowanceFree {
+		w.Header().Set("X-RouterX-Free-Allowance", "true")
+		defer func() { freeClaim.Settle(context.WithoutCancel(r.Context()), freeTokens, freeServed) }()
+	}
+	if !allowanceFree && tenant.AvailableUSD() <= 0 {
+		writeAPIError(w, http.StatusPaymentRequired, errInsufficientQuota, "insufficient_quota", "insufficient balance")
+		return
+	}
+	metadata, err := parseRequestMetadata(req.Metadata)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_metadata", err.Error())
+		return
+	}
+	redPolicy, redactor := s.redactionPolicy(r.Context(), tenant.ID)
+	if redPolicy.RedactLogs {
+		metadata = redactTags(metadata, redactor)
+	}
+	if redPolicy.ScrubUpstream {
+		if n := scrubMessages(req.Messages, redactor); n > 0 {
+			w.Header().Set("X-RouterX-Redactions", strconv.Itoa(n))
+		}
+	}
 	promptHash := util.HashString(util.NormalizeSpaces(extractText(req)))
 
+	modPolicy := s.moderationPolicy(r.Context(), tenant.ID)
+	if categories, blocked := s.moderate(r.Context(), modPolicy, moderationInput, req.Model, promptHash, extractText(req)); blocked {
+		writeModerationBlocked(w, moderationInput, categories)
+		return
+	} else if len(categories) > 0 {
+		w.Header().Set("X-RouterX-Moderation", "flagged")
+	}
+
 	// Prompt caching: check Redis if cache header set
 	cacheEnabled := r.Header.Get("X-RouterX-Cache") == "true"
 	cacheKey := "prompt_cache:" + req.Model + ":" + promptHash
+	cacheStatus := ""
+	if cacheEnabled {
+		cacheStatus = cacheBypass
+	}
 	if cacheEnabled && !req.Stream && s.Router.Redis != nil {
 		if cached, err := s.Router.Redis.Get(r.Context(), cacheKey).Result(); err == nil {
+			metrics.CacheLookupsTotal.WithLabelValues(metrics.ModelLabel(req.Model), "hit").Inc()
+			_ = s.Store.RecordCacheLookup(r.Context(), true, time.Now().UTC())
 			w.Header().Set("Content-Type", "application/json")
 			w.Header().Set("X-RouterX-Cache-Hit", "true")
+			w.Header().Set("X-RouterX-Cache", cacheHit)
+			w.Header().Set("X-RouterX-Model", req.Model)
 			w.Write([]byte(cached))
 			return
 		}
+		metrics.CacheLookupsTotal.WithLabelValues(metrics.ModelLabel(req.Model), "miss").Inc()
+		_ = s.Store.RecordCacheLookup(r.Context(), false, time.Now().UTC())
+		cacheStatus = cacheMiss
+	}
+
+	// Deduplication: a repeat of an in-flight or just-completed request from
+	// the same key gets the original's response without an upstream call.
+	var dedupBody []byte
+	dedupLeader := false
+	if policy.DedupWindowSec > 0 && !req.Stream && apiKeyValue != "" && s.Router.Redis != nil {
+		window := time.Duration(policy.DedupWindowSec) * time.Second
+		key := dedupKey(apiKeyValue, req.Model, promptHash)
+		body, leader := s.dedupJoin(r.Context(), tenant.ID, key, window)
+		if body != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-RouterX-Dedup", "hit")
+			w.Header().Set("X-RouterX-Model", req.Model)
+			w.Write(body)
+			return
+		}
+		if leader {
+			dedupLeader = true
+			defer func() { s.dedupFinish(context.WithoutCancel(r.Context()), key, dedupBody, window) }()
+		}
+	}
+
+	// Hold the request's worst-case cost until it is charged, so parallel
+	// requests cannot overdraw the balance they were all admitted against.
+	if !freeMode && !allowanceFree {
+		release, ok := s.reserveBalance(w, r, tenant, req)
+		if !ok {
+			return
+		}
+		defer release()
 	}
 
 	start := time.Now()
+	var queued time.Duration
+	if arrived := middleware.RequestStart(r.Context()); !arrived.IsZero() {
+		queued = start.Sub(arrived)
+	}
+	routeCtx, trail := router.WithAttemptTrail(r.Context())
+	r = r.WithContext(routeCtx)
 
 	stream := req.Stream
 	var ttft time.Duration
@@ -113,6 +326,9 @@ func (s *Server) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 	fallbackUsed := false
 	var resp models.ChatCompletionResponse
 	var routeErr error
+	// coalescedFree marks a request that shared another's upstream call
+	// under a charge-once policy: it is logged but not billed.
+	coalescedFree := false
 
 	// Build route options from headers
 	opts := router.DefaultRouteOptions()
@@ -138,6 +354,18 @@ func (s *Server) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 	if order := r.Header.Get("X-RouterX-Provider-Order"); order != "" {
 		opts.ProviderOrder = strings.Split(order, ",")
 	}
+	// Parameter compatibility: by default unsupported parameters are dropped
+	// and reported; require_parameters skips providers that would drop any.
+	opts.RequireParameters = req.RequireParameters || r.Header.Get("X-RouterX-Require-Parameters") == "true"
+	req.RequireParameters = false
+	opts.ApplyPolicy(policy)
+	// Data residency: the tenant's allowed regions, optionally narrowed per request
+	regions, ok := residencyRegions(w, r, policy)
+	if !ok {
+		return
+	}
+	opts.Regions = regions
+	opts.PreferRegion = s.preferredRegion(r)
 	// Fallback control
 	if fb := r.Header.Get("X-RouterX-Allow-Fallbacks"); fb == "false" {
 		opts.AllowFallbacks = false
@@ -146,28 +374,44 @@ func (s *Server) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 	opts.UserID = r.Header.Get("X-RouterX-User")
 	opts.AppTitle = r.Header.Get("X-Title")
 	opts.AppReferer = r.Header.Get("HTTP-Referer")
+	// Metadata tags feed routing rule conditions
+	opts.Tags = metadata
 
-	// Set routing metadata headers (available even for streaming)
-	setRoutingHeaders := func(provider string, latencyMs int64, costUSD float64, fallback bool) {
-		w.Header().Set("X-RouterX-Provider", provider)
-		w.Header().Set("X-RouterX-Latency-Ms", fmt.Sprintf("%d", latencyMs))
-		w.Header().Set("X-RouterX-Cost-USD", fmt.Sprintf("%.6f", costUSD))
-		if fallback {
-			w.Header().Set("X-RouterX-Fallback", "true")
-		}
+	// Session affinity: explicit header, else the OpenAI `user` field
+	opts.SessionKey = r.Header.Get("X-RouterX-Session")
+	if opts.SessionKey == "" {
+		opts.SessionKey = req.User
 	}
 
+	// An experiment's variant provider is tried first.
+	opts.PreferProvider = experimentOpts.PreferProvider
+
+	// Debug mode: report how the request would be routed instead of sending it
+	if r.Header.Get("X-RouterX-Debug") == "route" {
+		writeJSON(w, s.Router.Explain(r.Context(), tenant.ID, req, opts))
+		return
+	}
+
+	// streamStarted is set once any event has gone to the client; from then
+	// on errors must be reported inside the event stream. firstEvent times it.
+	// streamDone is set once data: [DONE] has been sent.
+	streamStarted := false
+	streamDone := false
+	var firstEvent time.Time
 	if stream {
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "keep-alive")
 		flusher, ok := w.(http.Flusher)
 		if !ok {
-			http.Error(w, "stream unsupported", http.StatusInternalServerError)
+			writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "stream unsupported")
 			return
 		}
-		streamDone := false
 		send := func(event string) error {
+			if !streamStarted {
+				firstEvent = time.Now()
+			}
+			streamStarted = true
 			if event == "[DONE]" {
 				streamDone = true
 				_, _ = w.Write([]byte("data: [DONE]\n\n"))
@@ -179,68 +423,155 @@ func (s *Server) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 			return err
 		}
 		resp, providerName, fallbackUsed, ttft, tokens, routeErr = s.Router.RouteWith(r.Context(), tenant.ID, req, true, send, opts)
-		// After stream completes, emit metadata as SSE comment
-		if streamDone {
-			_, _ = w.Write([]byte(fmt.Sprintf(": provider=%s latency_ms=%d fallback=%v\n\n", providerName, time.Since(start).Milliseconds(), fallbackUsed)))
-			flusher.Flush()
+		// Providers time the whole stream; time to first token is when the
+		// client got its first event.
+		if streamStarted {
+			ttft = firstEvent.Sub(start)
 		}
+	} else if policy.Coalesce != store.CoalesceOff && opts.BYOKKey == "" {
+		var res routeResult
+		var charge bool
+		res, charge, routeErr = s.coalescedRoute(r.Context(), tenant.ID, req, opts)
+		resp, providerName, fallbackUsed, ttft, tokens = res.resp, res.provider, res.fallback, res.ttft, res.tokens
+		coalescedFree = !charge && policy.Coalesce == store.CoalesceChargeOnce
 	} else {
 		resp, providerName, fallbackUsed, ttft, tokens, routeErr = s.Router.RouteWith(r.Context(), tenant.ID, req, false, nil, opts)
 	}
 
 	latency := time.Since(start)
 	status := http.StatusOK
-	if routeErr != nil {
+	switch {
+	case errors.Is(routeErr, router.ErrModelNotAllowed):
+		status = http.StatusForbidden
+		writePolicyViolation(w, status, routeErr.Error())
+	case routeErr != nil && streamStarted:
+		status = http.StatusBadGateway
+		writeStreamError(w, routeErr)
+	case router.RateLimited(routeErr):
+		// Every provider tried was busy: tell the caller to come back
+		// rather than report a failure.
+		status = http.StatusTooManyRequests
+		writeAPIError(w, status, errRateLimit, "upstream_rate_limited", routeErr.Error())
+	case routeErr != nil:
 		status = http.StatusBadGateway
 		writeError(w, routeErr)
 	}
+	errorCode := errCode(routeErr)
+
+	// Output moderation runs before the request is logged, billed or
+	// announced, so a withheld completion is recorded as blocked and is not
+	// charged: the tenant never receives it. It only applies to buffered
+	// responses; streamed tokens have already reached the client.
+	outputBlocked := false
+	if !stream && routeErr == nil && modPolicy.CheckOutput {
+		if categories, blocked := s.moderate(r.Context(), modPolicy, moderationOutput, req.Model, promptHash, completionText(resp)); blocked {
+			outputBlocked = true
+			status, errorCode = http.StatusBadRequest, "content_policy_violation"
+			writeModerationBlocked(w, moderationOutput, categories)
+		} else if len(categories) > 0 {
+			w.Header().Set("X-RouterX-Moderation", "flagged")
+		}
+	}
 
 	metrics.RequestsTotal.WithLabelValues(providerName, http.StatusText(status)).Inc()
 	metrics.LatencyMS.WithLabelValues(providerName).Observe(float64(latency.Milliseconds()))
 	metrics.TTFTMS.WithLabelValues(providerName).Observe(float64(ttft.Milliseconds()))
 
+	// cost is the price charged to the tenant (model_pricing override, else
+	// list price); providerCost estimates what the upstream call cost us at
+	// list price. Provider invoices are only compared with it by the
+	// reconciliation report.
 	cost := 0.0
+	providerCost := 0.0
 	if tokens > 0 {
-		if price, ok, err := s.Store.GetModelPrice(r.Context(), req.Model); err == nil && ok {
-			cost = price * float64(tokens) / 1000.0
-		} else {
-			cost = router.EstimateCostUSD(req.Model, tokens)
-		}
-	}
+		providerCost = router.EstimateCostUSD(req.Model, tokens)
+		cost = s.tenantCost(r.Context(), req.Model, tokens)
+	}
+	billed := cost
+	if freeMode || allowanceFree || coalescedFree || status != http.StatusOK {
+		billed = 0
+	}
+	outcome := routingOutcome{
+		Provider:      providerName,
+		Model:         req.Model,
+		Latency:       latency,
+		CostUSD:       cost,
+		HasCost:       true,
+		Fallback:      fallbackUsed,
+		Cache:         cacheStatus,
+		DroppedParams: resp.DroppedParams,
+	}
+	outcome.annotateSpan(r.Context())
+	// A completed stream already sent its headers; the outcome follows
+	// data: [DONE] as an SSE comment.
+	if streamDone {
+		_, _ = w.Write([]byte(outcome.comment()))
+		w.(http.Flusher).Flush()
+	}
+	recordRequestMetrics(tenant.ID, req.Model, providerName, status, latency, tokens, billed, providerCost, fallbackUsed)
 	_ = s.Store.InsertRequestLog(r.Context(), models.RequestLog{
-		TenantID:     tenant.ID,
-		Provider:     providerName,
-		Model:        req.Model,
-		LatencyMS:    latency.Milliseconds(),
-		TTFTMS:       ttft.Milliseconds(),
-		Tokens:       tokens,
-		CostUSD:      cost,
-		PromptHash:   promptHash,
-		FallbackUsed: fallbackUsed,
-		StatusCode:   status,
-		ErrorCode:    errCode(routeErr),
-		UserID:       opts.UserID,
-		AppTitle:     opts.AppTitle,
-		AppReferer:   opts.AppReferer,
-		CreatedAt:    time.Now().UTC(),
+		TenantID:        tenant.ID,
+		Provider:        providerName,
+		Model:           req.Model,
+		LatencyMS:       latency.Milliseconds(),
+		TTFTMS:          ttft.Milliseconds(),
+		Tokens:          tokens,
+		CostUSD:         cost,
+		ProviderCostUSD: providerCost,
+		BilledUSD:       billed,
+		PromptHash:      promptHash,
+		FallbackUsed:    fallbackUsed,
+		StatusCode:      status,
+		ErrorCode:       errorCode,
+		UserID:          opts.UserID,
+		AppTitle:        opts.AppTitle,
+		AppReferer:      opts.AppReferer,
+		KeyOwner:        keyOwner,
+		ExperimentID:    assignment.ExperimentID,
+		Variant:         assignment.Variant,
+		Metadata:        metadata,
+		CreatedAt:       time.Now().UTC(),
+		TraceID:         traceID(r.Context()),
+		QueueMS:         queued.Milliseconds(),
+		Stream:          stream,
+		Attempts:        trail.Attempts(),
+		ErrorMessage:    errMessage(routeErr),
+	})
+	s.publishTraffic(r.Context(), trafficEvent{
+		TenantID:  tenant.ID,
+		Model:     req.Model,
+		Provider:  providerName,
+		Status:    status,
+		ErrorCode: errorCode,
+		LatencyMS: latency.Milliseconds(),
+		TTFTMS:    ttft.Milliseconds(),
+		Tokens:    tokens,
+		BilledUSD: billed,
+		Stream:    stream,
+		Fallback:  fallbackUsed,
+		At:        time.Now().UTC(),
 	})
+	if tokens > 0 {
+		// The request itself was counted when the key authenticated.
+		s.KeyUsage.Record(r.Context(), apiKeyValue, 0, tokens)
+	}
 	// Set metadata headers (for non-stream, headers haven't been flushed yet)
 	if !stream {
-		setRoutingHeaders(providerName, latency.Milliseconds(), cost, fallbackUsed)
+		outcome.setHeaders(w.Header())
 	}
 
-	if freeMode {
+	if freeMode || allowanceFree || coalescedFree || outputBlocked {
 		cost = 0
 	}
+	if allowanceFree && status == http.StatusOK {
+		freeTokens, freeServed = tokens, true
+		_ = s.Store.RecordFreeUsage(r.Context(), tenant.ID, providerName, req.Model, tokens, time.Now().UTC())
+	}
 	if status == http.StatusOK && tokens > 0 && cost > 0 {
-		_ = s.Store.AddUsageCost(r.Context(), tenant.ID, providerName, req.Model, tokens, cost, time.Now().UTC())
-		newBalance := tenant.BalanceUSD - cost
-		_ = s.Store.UpdateTenantBalance(r.Context(), tenant.ID, newBalance)
-		_ = s.Store.RecordTransaction(r.Context(), tenant.ID, "charge", -cost, newBalance, fmt.Sprintf("%s / %s / %d tokens", providerName, req.Model, tokens))
+		s.chargeRequest(r.Context(), tenant, providerName, req.Model, tokens, cost)
 	}
 
-	s.Logger.Info("request completed",
-		zap.String("tenant_id", tenant.ID),
+	middleware.AnnotateAccessLog(r.Context(),
 		zap.String("provider", providerName),
 		zap.String("model", req.Model),
 		zap.Int64("latency_ms", latency.Milliseconds()),
@@ -251,26 +582,39 @@ func (s *Server) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 
 	// Fire webhook
 	if s.Webhooks != nil {
-		s.Webhooks.Fire(r.Context(), "request.completed", map[string]interface{}{
-			"tenant_id":    tenant.ID,
-			"provider":     providerName,
-			"model":        req.Model,
-			"latency_ms":   latency.Milliseconds(),
-			"tokens":       tokens,
-			"cost_usd":     cost,
-			"status_code":  status,
-			"fallback":     fallbackUsed,
-			"free_mode":    freeMode,
+		s.Webhooks.Fire(r.Context(), tenant.ID, webhook.EventRequestCompleted, map[string]interface{}{
+			"tenant_id":      tenant.ID,
+			"provider":       providerName,
+			"model":          req.Model,
+			"latency_ms":     latency.Milliseconds(),
+			"tokens":         tokens,
+			"cost_usd":       cost,
+			"status_code":    status,
+			"fallback":       fallbackUsed,
+			"free_mode":      freeMode,
+			"free_allowance": allowanceFree,
+		})
+	}
+	if routeErr != nil {
+		s.fireEvent(r.Context(), tenant.ID, webhook.EventRequestFailed, map[string]interface{}{
+			"tenant_id":  tenant.ID,
+			"provider":   providerName,
+			"model":      req.Model,
+			"latency_ms": latency.Milliseconds(),
+			"error_code": errCode(routeErr),
 		})
 	}
 
-	if !stream && routeErr == nil {
+	if !stream && routeErr == nil && !outputBlocked {
 		// Cache response if caching enabled
 		if cacheEnabled && s.Router.Redis != nil {
 			if respBytes, err := json.Marshal(resp); err == nil {
 				_ = s.Router.Redis.Set(r.Context(), cacheKey, string(respBytes), 5*time.Minute).Err()
 			}
 		}
+		if dedupLeader {
+			dedupBody, _ = json.Marshal(resp)
+		}
 		writeJSON(w, resp)
 	}
 }
@@ -279,6 +623,7 @@ func (s *Server) AdminLogin(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
+		OTP      string `json:"otp"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -293,18 +638,14 @@ func (s *Server) AdminLogin(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
-	token, err := middleware.NewAdminToken(s.JWTSecret, user.Username, 8*time.Hour)
-	if err != nil {
-		http.Error(w, "failed to issue token", http.StatusInternalServerError)
-		return
-	}
-	writeJSON(w, map[string]string{"token": token})
+	s.issueSession(w, r, adminAccount(user, s.RequireAdmin2FA), payload.OTP, nil)
 }
 
 func (s *Server) AuthLogin(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
+		OTP      string `json:"otp"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -313,12 +654,7 @@ func (s *Server) AuthLogin(w http.ResponseWriter, r *http.Request) {
 	// try admin first
 	if admin, err := s.Store.GetAdminByUsername(r.Context(), payload.Username); err == nil {
 		if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(payload.Password)); err == nil {
-			token, err := middleware.NewAdminToken(s.JWTSecret, admin.Username, 8*time.Hour)
-			if err != nil {
-				http.Error(w, "failed to issue token", http.StatusInternalServerError)
-				return
-			}
-			writeJSON(w, map[string]string{"token": token, "role": "admin"})
+			s.issueSession(w, r, adminAccount(admin, s.RequireAdmin2FA), payload.OTP, map[string]string{"role": "admin"})
 			return
 		}
 	}
@@ -331,12 +667,7 @@ func (s *Server) AuthLogin(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
-	token, err := middleware.NewTenantToken(s.JWTSecret, user.Username, user.TenantID, 8*time.Hour)
-	if err != nil {
-		http.Error(w, "failed to issue token", http.StatusInternalServerError)
-		return
-	}
-	writeJSON(w, map[string]string{"token": token, "role": "tenant"})
+	s.issueSession(w, r, s.tenantAccount(r, user), payload.OTP, map[string]string{"role": "tenant"})
 }
 
 func (s *Server) AuthRegister(w http.ResponseWriter, r *http.Request) {
@@ -379,6 +710,7 @@ func (s *Server) TenantLogin(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
+		OTP      string `json:"otp"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -393,20 +725,25 @@ func (s *Server) TenantLogin(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
-	token, err := middleware.NewTenantToken(s.JWTSecret, user.Username, user.TenantID, 8*time.Hour)
-	if err != nil {
-		http.Error(w, "failed to issue token", http.StatusInternalServerError)
-		return
-	}
-	writeJSON(w, map[string]string{"token": token})
+	s.issueSession(w, r, s.tenantAccount(r, user), payload.OTP, nil)
 }
 
+// AdminProviders lists providers; archived ones only with ?archived=true.
 func (s *Server) AdminProviders(w http.ResponseWriter, r *http.Request) {
 	providers, err := s.Store.ListProviders(r.Context())
 	if err != nil {
 		http.Error(w, "failed to list providers", http.StatusInternalServerError)
 		return
 	}
+	if r.URL.Query().Get("archived") != "true" {
+		live := providers[:0]
+		for _, p := range providers {
+			if p.ArchivedAt == nil {
+				live = append(live, p)
+			}
+		}
+		providers = live
+	}
 	writeJSON(w, providers)
 }
 
@@ -423,11 +760,20 @@ func (s *Server) AdminUpdateProvider(w http.ResponseWriter, r *http.Request) {
 		SupportsText   bool   `json:"supports_text"`
 		SupportsVision bool   `json:"supports_vision"`
 		Enabled        bool   `json:"enabled"`
+		Region         string `json:"region"`
+		// Circuit, when present, replaces the circuit-breaker settings.
+		Circuit *store.CircuitSettings `json:"circuit"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
+	if payload.Circuit != nil {
+		if err := router.ValidateCircuitSettings(*payload.Circuit); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 	apiKey := payload.APIKey
 	if apiKey == "" {
 		if existing, err := s.Store.GetProviderByID(r.Context(), id); err == nil {
@@ -442,11 +788,19 @@ func (s *Server) AdminUpdateProvider(w http.ResponseWriter, r *http.Request) {
 		SupportsText:   payload.SupportsText,
 		SupportsVision: payload.SupportsVision,
 		Enabled:        payload.Enabled,
+		Region:         strings.TrimSpace(payload.Region),
 	})
 	if err != nil {
 		http.Error(w, "failed to update provider", http.StatusInternalServerError)
 		return
 	}
+	s.Router.InvalidateProvider(r.Context(), id)
+	if payload.Circuit != nil {
+		if err := s.Store.SetProviderCircuitSettings(r.Context(), id, *payload.Circuit); err != nil {
+			http.Error(w, "failed to update circuit settings", http.StatusInternalServerError)
+			return
+		}
+	}
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
@@ -460,6 +814,7 @@ func (s *Server) AdminClearProviderKey(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to clear api key", http.StatusInternalServerError)
 		return
 	}
+	s.Router.InvalidateProvider(r.Context(), id)
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
@@ -473,11 +828,18 @@ func (s *Server) AdminCreateProvider(w http.ResponseWriter, r *http.Request) {
 		SupportsText   bool   `json:"supports_text"`
 		SupportsVision bool   `json:"supports_vision"`
 		Enabled        bool   `json:"enabled"`
+		Region         string `json:"region"`
+
+		Circuit store.CircuitSettings `json:"circuit"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
+	if err := router.ValidateCircuitSettings(payload.Circuit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if payload.Type == "" {
 		payload.Type = "generic-openai"
 	}
@@ -492,21 +854,79 @@ func (s *Server) AdminCreateProvider(w http.ResponseWriter, r *http.Request) {
 		SupportsText:   payload.SupportsText,
 		SupportsVision: payload.SupportsVision,
 		Enabled:        payload.Enabled,
+		Region:         strings.TrimSpace(payload.Region),
+		Circuit:        payload.Circuit,
 	}
 	if err := s.Store.UpsertProvider(r.Context(), provider); err != nil {
 		http.Error(w, "failed to create provider", http.StatusInternalServerError)
 		return
 	}
+	if provider.Circuit != (store.CircuitSettings{}) {
+		if err := s.Store.SetProviderCircuitSettings(r.Context(), id, provider.Circuit); err != nil {
+			http.Error(w, "failed to set circuit settings", http.StatusInternalServerError)
+			return
+		}
+	}
 	writeJSON(w, provider)
 }
 
+// AdminTenants pages through tenants (`page`, `page_size` up to 200) with
+// the filters tenantListFilters reads. It also serves /admin/tenants/search.
 func (s *Server) AdminTenants(w http.ResponseWriter, r *http.Request) {
-	items, err := s.Store.ListTenants(r.Context())
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	f, err := tenantListFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := s.Store.ListTenantsPaginated(r.Context(), page, pageSize, f)
 	if err != nil {
 		http.Error(w, "failed to list tenants", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, items)
+	writeJSON(w, result)
+}
+
+// tenantListFilters reads the tenant list filters: `q` (name or id),
+// `from`/`to` (created), `balance_below`, `spend_above`, `inactive_days`,
+// `suspended` and `sort_by` (created_at, name, spend, balance,
+// last_active) with `sort_dir`.
+func tenantListFilters(r *http.Request) (store.TenantListFilters, error) {
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		return store.TenantListFilters{}, err
+	}
+	f := store.TenantListFilters{Search: strings.TrimSpace(q.Get("q")), From: from, To: to, Suspended: q.Get("suspended"), SortBy: q.Get("sort_by"), SortDir: q.Get("sort_dir")}
+	if v := q.Get("balance_below"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return store.TenantListFilters{}, fmt.Errorf("invalid balance_below")
+		}
+		f.BalanceBelow = &n
+	}
+	if v := q.Get("spend_above"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return store.TenantListFilters{}, fmt.Errorf("invalid spend_above")
+		}
+		f.SpendAbove = &n
+	}
+	if v := q.Get("inactive_days"); v != "" {
+		if f.InactiveDays, err = strconv.Atoi(v); err != nil || f.InactiveDays < 1 {
+			return store.TenantListFilters{}, fmt.Errorf("inactive_days must be a positive integer")
+		}
+	}
+	if f.Suspended != "" && f.Suspended != "true" && f.Suspended != "false" {
+		return store.TenantListFilters{}, fmt.Errorf("suspended must be true or false")
+	}
+	switch f.SortBy {
+	case "", "created_at", "name", "spend", "balance", "last_active":
+	default:
+		return store.TenantListFilters{}, fmt.Errorf("sort_by must be created_at, name, spend, balance or last_active")
+	}
+	return f, nil
 }
 
 func (s *Server) AdminRequests(w http.ResponseWriter, r *http.Request) {
@@ -571,7 +991,7 @@ func (s *Server) AdminListModelPricing(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) AdminUpsertModelPricing(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
-		Model        string  `json:"model"`
+		Model         string  `json:"model"`
 		PricePer1KUSD float64 `json:"price_per_1k_usd"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -604,10 +1024,7 @@ func (s *Server) AdminListModels(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) AdminAddModel(w http.ResponseWriter, r *http.Request) {
-	var payload struct {
-		Model        string `json:"model"`
-		ProviderType string `json:"provider_type"`
-	}
+	var payload store.ModelCatalog
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
@@ -616,21 +1033,88 @@ func (s *Server) AdminAddModel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "model and provider_type required", http.StatusBadRequest)
 		return
 	}
-	if err := s.Store.AddModelCatalog(r.Context(), payload.Model, payload.ProviderType); err != nil {
+	if payload.ContextLength < 0 || payload.MaxOutputTokens < 0 {
+		http.Error(w, "context_length and max_output_tokens must not be negative", http.StatusBadRequest)
+		return
+	}
+	if payload.TTFTSLOMS < 0 || payload.LatencySLOMS < 0 || payload.TimeoutMS < 0 {
+		http.Error(w, "ttft_slo_ms, latency_slo_ms and timeout_ms must not be negative", http.StatusBadRequest)
+		return
+	}
+	if payload.FreeRequestsPerDay < 0 || payload.FreeTokensPerDay < 0 {
+		http.Error(w, "free_requests_per_day and free_tokens_per_day must not be negative", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.AddModelCatalog(r.Context(), payload); err != nil {
 		http.Error(w, "failed to add model", http.StatusInternalServerError)
 		return
 	}
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
-func (s *Server) AdminDeleteModel(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AdminModelProviders(w http.ResponseWriter, r *http.Request) {
 	model := chi.URLParam(r, "model")
 	if model == "" {
 		http.Error(w, "missing model", http.StatusBadRequest)
 		return
 	}
-	if err := s.Store.DeleteModelCatalog(r.Context(), model); err != nil {
-		http.Error(w, "failed to delete model", http.StatusInternalServerError)
+	entries, err := s.Store.ListModelProviderEntries(r.Context(), model)
+	if err != nil {
+		http.Error(w, "failed to list model providers", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []store.ModelProviderEntry{}
+	}
+	writeJSON(w, entries)
+}
+
+// AdminSetModelProviders replaces a model's ordered provider list. Providers
+// are tried in the order given; an empty list restores routing by provider type.
+func (s *Server) AdminSetModelProviders(w http.ResponseWriter, r *http.Request) {
+	model := chi.URLParam(r, "model")
+	if model == "" {
+		http.Error(w, "missing model", http.StatusBadRequest)
+		return
+	}
+	var payload struct {
+		Providers []struct {
+			ProviderID string `json:"provider_id"`
+			Enabled    *bool  `json:"enabled"`
+		} `json:"providers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if _, ok, _ := s.Store.GetModelProvider(r.Context(), model); !ok {
+		http.Error(w, "model not in catalog", http.StatusNotFound)
+		return
+	}
+	entries := make([]store.ModelProviderEntry, 0, len(payload.Providers))
+	seen := map[string]bool{}
+	for _, p := range payload.Providers {
+		if p.ProviderID == "" {
+			http.Error(w, "provider_id required", http.StatusBadRequest)
+			return
+		}
+		if seen[p.ProviderID] {
+			http.Error(w, "duplicate provider_id: "+p.ProviderID, http.StatusBadRequest)
+			return
+		}
+		seen[p.ProviderID] = true
+		if _, err := s.Store.GetProviderByID(r.Context(), p.ProviderID); err != nil {
+			http.Error(w, "unknown provider: "+p.ProviderID, http.StatusBadRequest)
+			return
+		}
+		enabled := true
+		if p.Enabled != nil {
+			enabled = *p.Enabled
+		}
+		entries = append(entries, store.ModelProviderEntry{Model: model, ProviderID: p.ProviderID, Enabled: enabled})
+	}
+	if err := s.Store.SetModelProviderEntries(r.Context(), model, entries); err != nil {
+		http.Error(w, "failed to update model providers", http.StatusInternalServerError)
 		return
 	}
 	writeJSON(w, map[string]string{"status": "ok"})
@@ -642,27 +1126,25 @@ func (s *Server) TenantUsage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing tenant", http.StatusUnauthorized)
 		return
 	}
-	rows, err := s.Store.DB.Query(r.Context(), `SELECT provider, model, day, tokens, cost_usd FROM usage_daily WHERE tenant_id=$1 AND (tokens > 0 OR cost_usd > 0) ORDER BY day DESC LIMIT 30`, user.TenantID)
+	win, err := parseUsageWindow(r)
 	if err != nil {
-		http.Error(w, "failed to list usage", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer rows.Close()
-	type usageRow struct {
-		Provider string    `json:"provider"`
-		Model    string    `json:"model"`
-		Day      time.Time `json:"day"`
-		Tokens   int       `json:"tokens"`
-		CostUSD  float64   `json:"cost_usd"`
+	// Without a range keep the old behaviour of the 30 most recent rows
+	limit := 0
+	if win.From.IsZero() && win.To.IsZero() {
+		limit = 30
 	}
-	var out []usageRow
-	for rows.Next() {
-		var u usageRow
-		if err := rows.Scan(&u.Provider, &u.Model, &u.Day, &u.Tokens, &u.CostUSD); err != nil {
-			http.Error(w, "failed to list usage", http.StatusInternalServerError)
-			return
-		}
-		out = append(out, u)
+	rows, err := s.Store.ListTenantUsage(r.Context(), user.TenantID, win, limit)
+	if err != nil {
+		http.Error(w, "failed to list usage", http.StatusInternalServerError)
+		return
+	}
+	cur := s.tenantCurrency(r.Context(), user.TenantID)
+	out := make([]displayUsage, 0, len(rows))
+	for _, u := range rows {
+		out = append(out, displayUsage{DailyUsage: u, Display: cur.view(map[string]float64{"cost": u.CostUSD})})
 	}
 	writeJSON(w, out)
 }
@@ -673,12 +1155,20 @@ func (s *Server) TenantSummary(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "missing tenant", http.StatusUnauthorized)
 		return
 	}
-	summary, err := s.Store.GetTenantRequestSummary(r.Context(), user.TenantID)
+	win, err := parseUsageWindow(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	summary, err := s.Store.GetTenantRequestSummary(r.Context(), user.TenantID, win)
 	if err != nil {
 		http.Error(w, "failed to load summary", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, summary)
+	writeJSON(w, struct {
+		*store.TenantRequestSummary
+		Display map[string]interface{} `json:"display"`
+	}{summary, s.tenantCurrency(r.Context(), user.TenantID).view(map[string]float64{"total_cost": summary.TotalCostUSD})})
 }
 
 func (s *Server) TenantAPIKeys(w http.ResponseWriter, r *http.Request) {
@@ -714,10 +1204,17 @@ func (s *Server) TenantCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 		payload.Key = "user_key_" + ksuid.New().String()
 	}
 	createdAt := time.Now().UTC()
-	if err := s.Store.CreateAPIKey(r.Context(), store.APIKey{Key: payload.Key, TenantID: user.TenantID, Name: payload.Name, AllowedModels: payload.AllowedModels, CreatedAt: createdAt}); err != nil {
+	if err := s.Store.CreateAPIKey(r.Context(), store.APIKey{Key: payload.Key, TenantID: user.TenantID, Name: payload.Name, AllowedModels: payload.AllowedModels, CreatedBy: user.Username, CreatedAt: createdAt}); err != nil {
 		http.Error(w, "failed to create api key", http.StatusInternalServerError)
 		return
 	}
+	s.fireEvent(r.Context(), user.TenantID, webhook.EventKeyCreated, map[string]interface{}{
+		"tenant_id":      user.TenantID,
+		"key":            maskKey(payload.Key),
+		"name":           payload.Name,
+		"allowed_models": payload.AllowedModels,
+		"created_by":     user.Username,
+	})
 	writeJSON(w, map[string]interface{}{"key": payload.Key, "created_at": createdAt})
 }
 
@@ -739,6 +1236,51 @@ func (s *Server) TenantDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
+// TenantRotateSigningSecret issues a new HMAC signing credential for one of the
+// tenant's API keys. The secret is only returned once.
+func (s *Server) TenantRotateSigningSecret(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	key := chi.URLParam(r, "key")
+	if key == "" {
+		http.Error(w, "missing api key", http.StatusBadRequest)
+		return
+	}
+	keyID := "sk_id_" + ksuid.New().String()
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		http.Error(w, "failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+	secret := hex.EncodeToString(secretBytes)
+	if err := s.Store.SetAPIKeySigningSecret(r.Context(), user.TenantID, key, keyID, secret); err != nil {
+		http.Error(w, "failed to set signing secret", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]string{"signing_key_id": keyID, "signing_secret": secret})
+}
+
+func (s *Server) TenantDeleteSigningSecret(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	key := chi.URLParam(r, "key")
+	if key == "" {
+		http.Error(w, "missing api key", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.SetAPIKeySigningSecret(r.Context(), user.TenantID, key, "", ""); err != nil {
+		http.Error(w, "failed to remove signing secret", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
 func (s *Server) TenantProfile(w http.ResponseWriter, r *http.Request) {
 	user := middleware.TenantUserFromContext(r.Context())
 	if user == nil {
@@ -750,14 +1292,28 @@ func (s *Server) TenantProfile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to load tenant", http.StatusInternalServerError)
 		return
 	}
+	var reserved float64
+	if s.Reservations != nil {
+		reserved, _ = s.Reservations.Outstanding(r.Context(), tenant.ID)
+	}
 	writeJSON(w, map[string]interface{}{
-		"tenant_id":      tenant.ID,
-		"name":           tenant.Name,
-		"username":       user.Username,
-		"balance_usd":    tenant.BalanceUSD,
-		"suspended":      tenant.Suspended,
+		"tenant_id":       tenant.ID,
+		"name":            tenant.Name,
+		"username":        user.Username,
+		"role":            user.Role,
+		"balance_usd":     tenant.BalanceUSD,
+		"credits_usd":     tenant.CreditsUSD,
+		"reserved_usd":    reserved,
+		"suspended":       tenant.Suspended,
 		"total_topup_usd": tenant.TotalTopupUSD,
 		"total_spent_usd": tenant.TotalSpentUSD,
+		"display": s.tenantCurrency(r.Context(), tenant.ID).view(map[string]float64{
+			"balance":     tenant.BalanceUSD,
+			"credits":     tenant.CreditsUSD,
+			"reserved":    reserved,
+			"total_topup": tenant.TotalTopupUSD,
+			"total_spent": tenant.TotalSpentUSD,
+		}),
 	})
 }
 
@@ -789,15 +1345,53 @@ func (s *Server) TenantTopup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Update total_topup_usd and record transaction
-	_, _ = s.Store.DB.Exec(r.Context(), `UPDATE tenants SET total_topup_usd = total_topup_usd + $2 WHERE id=$1`, user.TenantID, payload.Amount)
+	_ = s.Store.AddTenantTopup(r.Context(), user.TenantID, payload.Amount)
 	_ = s.Store.RecordTransaction(r.Context(), user.TenantID, "topup", payload.Amount, newBalance, fmt.Sprintf("Self-service topup $%.2f", payload.Amount))
+	s.liftBalanceSuspension(r.Context(), user.TenantID)
 	writeJSON(w, map[string]interface{}{"balance_usd": newBalance})
 }
 
+// liftBalanceSuspension ends an automatic negative-balance suspension once a
+// top-up has brought the tenant's balance back to zero or more. The
+// delinquency sweep does the same for top-ups made elsewhere.
+func (s *Server) liftBalanceSuspension(ctx context.Context, tenantID string) {
+	released, err := s.Store.ReleaseBalanceSuspensions(ctx, tenantID)
+	if err != nil || len(released) == 0 {
+		return
+	}
+	s.fireEvent(ctx, tenantID, webhook.EventTenantUnsuspended, map[string]interface{}{
+		"tenant_id": tenantID,
+		"reason":    store.SuspendNegativeBalance,
+	})
+}
+
 // ---- Admin Dashboard Stats ----
 
 func (s *Server) AdminDashboardStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := s.Store.GetAdminDashboardStats(r.Context())
+	win, err := parseUsageWindow(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if win.To.IsZero() {
+		win.To = time.Now().UTC()
+	}
+	if win.Granularity == "" {
+		win.Granularity = store.GranularityHour
+	}
+	if win.From.IsZero() {
+		// Default: the last 24 hours, as 24 whole hourly buckets
+		if win.Granularity == store.GranularityHour {
+			win.From = win.Truncate(win.To).Add(-23 * time.Hour)
+		} else {
+			win.From = win.To.Add(-24 * time.Hour)
+		}
+	}
+	if win.BucketCount() > maxUsageBuckets {
+		http.Error(w, "range too large for granularity", http.StatusBadRequest)
+		return
+	}
+	stats, err := s.Store.GetAdminDashboardStats(r.Context(), win)
 	if err != nil {
 		http.Error(w, "failed to load stats", http.StatusInternalServerError)
 		return
@@ -816,15 +1410,13 @@ func (s *Server) AdminRequestsPaginated(w http.ResponseWriter, r *http.Request)
 	if pageSize < 1 {
 		pageSize = 50
 	}
-	statusCode, _ := strconv.Atoi(r.URL.Query().Get("status_code"))
-	filters := store.RequestLogFilters{
-		TenantID:   r.URL.Query().Get("tenant_id"),
-		Provider:   r.URL.Query().Get("provider"),
-		Model:      r.URL.Query().Get("model"),
-		StatusCode: statusCode,
-		SortBy:     r.URL.Query().Get("sort_by"),
-		SortDir:    r.URL.Query().Get("sort_dir"),
+	filters, err := requestLogFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	filters.SortBy = r.URL.Query().Get("sort_by")
+	filters.SortDir = r.URL.Query().Get("sort_dir")
 	result, err := s.Store.ListRequestLogsPaginated(r.Context(), page, pageSize, filters)
 	if err != nil {
 		http.Error(w, "failed to list requests", http.StatusInternalServerError)
@@ -833,6 +1425,109 @@ func (s *Server) AdminRequestsPaginated(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, result)
 }
 
+// TenantRequests lists the calling tenant's own request logs, newest first,
+// with the same filters as the admin view.
+func (s *Server) TenantRequests(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	filters, err := requestLogFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filters.TenantID = user.TenantID
+	filters.SortBy = r.URL.Query().Get("sort_by")
+	filters.SortDir = r.URL.Query().Get("sort_dir")
+	result, err := s.Store.ListRequestLogsPaginated(r.Context(), page, pageSize, filters)
+	if err != nil {
+		http.Error(w, "failed to list requests", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, result)
+}
+
+// requestLogFilters reads the shared request-log filters from the query
+// string. Tags are passed as repeated `tag=key:value` parameters; `status` is
+// success or error and `from`/`to` bound the date range.
+func requestLogFilters(r *http.Request) (store.RequestLogFilters, error) {
+	q := r.URL.Query()
+	statusCode, _ := strconv.Atoi(q.Get("status_code"))
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		return store.RequestLogFilters{}, err
+	}
+	f := store.RequestLogFilters{
+		TenantID:   q.Get("tenant_id"),
+		Provider:   q.Get("provider"),
+		Model:      q.Get("model"),
+		StatusCode: statusCode,
+		UserID:     q.Get("user_id"),
+		AppTitle:   q.Get("app_title"),
+		KeyOwner:   q.Get("key_owner"),
+		From:       from,
+		To:         to,
+	}
+	switch status := q.Get("status"); status {
+	case "", "success", "error":
+		f.Status = status
+	default:
+		return store.RequestLogFilters{}, fmt.Errorf("status must be success or error")
+	}
+	for _, tag := range q["tag"] {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok || k == "" {
+			continue
+		}
+		if f.Tags == nil {
+			f.Tags = map[string]string{}
+		}
+		f.Tags[k] = v
+	}
+	return f, nil
+}
+
+// ---- Usage By Tag ----
+
+func (s *Server) AdminUsageByTag(w http.ResponseWriter, r *http.Request) {
+	s.writeUsageByTag(w, r, r.URL.Query().Get("tenant_id"))
+}
+
+func (s *Server) TenantUsageByTag(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.writeUsageByTag(w, r, user.TenantID)
+}
+
+func (s *Server) writeUsageByTag(w http.ResponseWriter, r *http.Request, tenantID string) {
+	q := r.URL.Query()
+	groupBy := q.Get("group_by")
+	if groupBy == "" {
+		groupBy = "app_title"
+	}
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	usage, err := s.Store.GetUsageByTag(r.Context(), tenantID, groupBy, from, to)
+	if err != nil {
+		http.Error(w, "failed to load usage", http.StatusInternalServerError)
+		return
+	}
+	if usage == nil {
+		usage = []store.TagUsage{}
+	}
+	writeJSON(w, map[string]interface{}{"group_by": groupBy, "data": usage})
+}
+
 // ---- Routing Rules CRUD ----
 
 func (s *Server) AdminRoutingRules(w http.ResponseWriter, r *http.Request) {
@@ -854,35 +1549,94 @@ func (s *Server) AdminRoutingRules(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, rules)
 }
 
-func (s *Server) AdminCreateRoutingRule(w http.ResponseWriter, r *http.Request) {
-	var payload struct {
-		TenantID            string `json:"tenant_id"`
-		Capability          string `json:"capability"`
-		PrimaryProviderID   string `json:"primary_provider_id"`
-		SecondaryProviderID string `json:"secondary_provider_id"`
-		Model               string `json:"model"`
+// routingRulePayload is the body of rule create and update requests. The
+// two-slot primary/secondary fields are still accepted when provider_ids is
+// omitted.
+type routingRulePayload struct {
+	TenantID            string            `json:"tenant_id"`
+	Capability          string            `json:"capability"`
+	ProviderIDs         []string          `json:"provider_ids"`
+	PrimaryProviderID   string            `json:"primary_provider_id"`
+	SecondaryProviderID string            `json:"secondary_provider_id"`
+	Model               string            `json:"model"`
+	Priority            int               `json:"priority"`
+	ModelPattern        string            `json:"model_pattern"`
+	MinContextTokens    int               `json:"min_context_tokens"`
+	RequiresTools       bool              `json:"requires_tools"`
+	Tags                map[string]string `json:"tags"`
+	Enabled             *bool             `json:"enabled"`
+}
+
+// routingRule validates the payload and builds the rule it describes.
+func (s *Server) routingRule(ctx context.Context, id string, p routingRulePayload) (store.RoutingRule, error) {
+	providerIDs := p.ProviderIDs
+	if len(providerIDs) == 0 {
+		for _, pid := range []string{p.PrimaryProviderID, p.SecondaryProviderID} {
+			if pid != "" {
+				providerIDs = append(providerIDs, pid)
+			}
+		}
+	}
+	switch {
+	case p.TenantID == "":
+		return store.RoutingRule{}, errors.New("tenant_id required")
+	case len(providerIDs) == 0:
+		return store.RoutingRule{}, errors.New("provider_ids required")
+	case p.Capability != "" && p.Capability != "text" && p.Capability != "vision":
+		return store.RoutingRule{}, errors.New("capability must be text, vision or empty")
+	case p.MinContextTokens < 0:
+		return store.RoutingRule{}, errors.New("min_context_tokens must not be negative")
+	}
+	seen := map[string]bool{}
+	for _, pid := range providerIDs {
+		if seen[pid] {
+			return store.RoutingRule{}, fmt.Errorf("provider %s listed twice", pid)
+		}
+		seen[pid] = true
+		if _, err := s.Store.GetProviderByID(ctx, pid); err != nil {
+			return store.RoutingRule{}, fmt.Errorf("unknown provider %s", pid)
+		}
 	}
+	enabled := true
+	if p.Enabled != nil {
+		enabled = *p.Enabled
+	}
+	return store.RoutingRule{
+		ID:               id,
+		TenantID:         p.TenantID,
+		Capability:       p.Capability,
+		ProviderIDs:      providerIDs,
+		Model:            p.Model,
+		Priority:         p.Priority,
+		ModelPattern:     strings.TrimSpace(p.ModelPattern),
+		MinContextTokens: p.MinContextTokens,
+		RequiresTools:    p.RequiresTools,
+		Tags:             p.Tags,
+		Enabled:          enabled,
+	}, nil
+}
+
+func (s *Server) AdminCreateRoutingRule(w http.ResponseWriter, r *http.Request) {
+	var payload routingRulePayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	if payload.TenantID == "" || payload.Capability == "" || payload.PrimaryProviderID == "" || payload.Model == "" {
-		http.Error(w, "tenant_id, capability, primary_provider_id, model required", http.StatusBadRequest)
+	rule, err := s.routingRule(r.Context(), ksuid.New().String(), payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	rule := store.RoutingRule{
-		ID:                  ksuid.New().String(),
-		TenantID:            payload.TenantID,
-		Capability:          payload.Capability,
-		PrimaryProviderID:   payload.PrimaryProviderID,
-		SecondaryProviderID: payload.SecondaryProviderID,
-		Model:               payload.Model,
-	}
 	if err := s.Store.UpsertRoutingRule(r.Context(), rule); err != nil {
 		http.Error(w, "failed to create rule", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, rule)
+	created, err := s.Store.GetRoutingRuleByID(r.Context(), rule.ID)
+	if err != nil {
+		http.Error(w, "failed to load rule", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, created)
 }
 
 func (s *Server) AdminUpdateRoutingRule(w http.ResponseWriter, r *http.Request) {
@@ -891,24 +1645,15 @@ func (s *Server) AdminUpdateRoutingRule(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "missing rule id", http.StatusBadRequest)
 		return
 	}
-	var payload struct {
-		TenantID            string `json:"tenant_id"`
-		Capability          string `json:"capability"`
-		PrimaryProviderID   string `json:"primary_provider_id"`
-		SecondaryProviderID string `json:"secondary_provider_id"`
-		Model               string `json:"model"`
-	}
+	var payload routingRulePayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	rule := store.RoutingRule{
-		ID:                  id,
-		TenantID:            payload.TenantID,
-		Capability:          payload.Capability,
-		PrimaryProviderID:   payload.PrimaryProviderID,
-		SecondaryProviderID: payload.SecondaryProviderID,
-		Model:               payload.Model,
+	rule, err := s.routingRule(r.Context(), id, payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 	if err := s.Store.UpsertRoutingRule(r.Context(), rule); err != nil {
 		http.Error(w, "failed to update rule", http.StatusInternalServerError)
@@ -963,9 +1708,10 @@ func (s *Server) AdminAdjustBalance(w http.ResponseWriter, r *http.Request) {
 	txType := "adjustment"
 	if diff > 0 {
 		// Positive adjustment counts as topup
-		_, _ = s.Store.DB.Exec(r.Context(), `UPDATE tenants SET total_topup_usd = total_topup_usd + $2 WHERE id=$1`, id, diff)
+		_ = s.Store.AddTenantTopup(r.Context(), id, diff)
 	}
 	_ = s.Store.RecordTransaction(r.Context(), id, txType, diff, payload.BalanceUSD, desc)
+	s.liftBalanceSuspension(r.Context(), id)
 	writeJSON(w, map[string]interface{}{"status": "ok", "balance_usd": payload.BalanceUSD})
 }
 
@@ -977,6 +1723,16 @@ func (s *Server) AdminProviderHealth(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to list providers", http.StatusInternalServerError)
 		return
 	}
+	maintenanceEnds, err := s.Store.ActiveMaintenanceEnds(r.Context())
+	if err != nil {
+		http.Error(w, "failed to load maintenance windows", http.StatusInternalServerError)
+		return
+	}
+	slos, err := s.Store.ListModelSLOs(r.Context())
+	if err != nil {
+		http.Error(w, "failed to load model SLOs", http.StatusInternalServerError)
+		return
+	}
 	circuitStates := s.Router.GetCircuitStates()
 	latencies := s.Router.GetProviderLatencies()
 	var result []store.ProviderHealthStatus
@@ -988,15 +1744,19 @@ func (s *Server) AdminProviderHealth(w http.ResponseWriter, r *http.Request) {
 				health = val
 			}
 		}
-		circuitOpen := false
-		if open, ok := circuitStates[p.ID]; ok {
-			circuitOpen = open
+		circuitState := "closed"
+		if state, ok := circuitStates[p.ID]; ok {
+			circuitState = state
+		}
+		if !s.Router.CircuitForcedOpenUntil(r.Context(), p.ID).IsZero() {
+			circuitState = "open"
 		}
+		circuitOpen := circuitState == "open"
 		avgLatency := int64(0)
 		if l, ok := latencies[p.ID]; ok {
 			avgLatency = l
 		}
-		result = append(result, store.ProviderHealthStatus{
+		status := store.ProviderHealthStatus{
 			ProviderID:   p.ID,
 			ProviderName: p.Name,
 			Type:         p.Type,
@@ -1004,11 +1764,76 @@ func (s *Server) AdminProviderHealth(w http.ResponseWriter, r *http.Request) {
 			HealthStatus: health,
 			CircuitOpen:  circuitOpen,
 			AvgLatencyMS: avgLatency,
-		})
+			CircuitState: circuitState,
+			SLO:          s.Router.SLOStatus(p.ID, slos),
+		}
+		if until := s.Router.CooldownUntil(r.Context(), p.ID); !until.IsZero() {
+			status.CooldownUntil = &until
+		}
+		if p.UnderMaintenance() {
+			status.HealthStatus = "maintenance"
+		}
+		if end, ok := maintenanceEnds[p.ID]; ok {
+			status.MaintenanceUntil = &end
+		}
+		result = append(result, status)
 	}
 	writeJSON(w, result)
 }
 
+// AdminProviderEvents lists persisted circuit and health transitions, newest first.
+func (s *Server) AdminProviderEvents(w http.ResponseWriter, r *http.Request) {
+	f, ok := providerEventFilters(w, r)
+	if !ok {
+		return
+	}
+	f.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	events, err := s.Store.ListProviderEvents(r.Context(), f)
+	if err != nil {
+		http.Error(w, "failed to list provider events", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []store.ProviderEvent{}
+	}
+	writeJSON(w, events)
+}
+
+// AdminProviderEventSummary counts transitions per provider and event type.
+func (s *Server) AdminProviderEventSummary(w http.ResponseWriter, r *http.Request) {
+	f, ok := providerEventFilters(w, r)
+	if !ok {
+		return
+	}
+	if f.From.IsZero() {
+		f.From = time.Now().UTC().Add(-24 * time.Hour)
+	}
+	counts, err := s.Store.CountProviderEvents(r.Context(), f)
+	if err != nil {
+		http.Error(w, "failed to summarize provider events", http.StatusInternalServerError)
+		return
+	}
+	if counts == nil {
+		counts = []store.ProviderEventCount{}
+	}
+	writeJSON(w, map[string]interface{}{"from": f.From, "to": f.To, "counts": counts})
+}
+
+func providerEventFilters(w http.ResponseWriter, r *http.Request) (store.ProviderEventFilters, bool) {
+	q := r.URL.Query()
+	f := store.ProviderEventFilters{ProviderID: q.Get("provider_id"), EventType: q.Get("type")}
+	var err error
+	if f.From, err = parseTimeParam(q.Get("from")); err != nil {
+		http.Error(w, "invalid from", http.StatusBadRequest)
+		return f, false
+	}
+	if f.To, err = parseTimeParam(q.Get("to")); err != nil {
+		http.Error(w, "invalid to", http.StatusBadRequest)
+		return f, false
+	}
+	return f, true
+}
+
 // ---- Tenant Suspend/Unsuspend ----
 
 func (s *Server) AdminSuspendTenant(w http.ResponseWriter, r *http.Request) {
@@ -1021,6 +1846,10 @@ func (s *Server) AdminSuspendTenant(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to suspend tenant", http.StatusInternalServerError)
 		return
 	}
+	s.fireEvent(r.Context(), id, webhook.EventTenantSuspended, map[string]interface{}{
+		"tenant_id":    id,
+		"suspended_by": middleware.AdminUsernameFromContext(r.Context()),
+	})
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
@@ -1046,15 +1875,26 @@ func (s *Server) AdminUpdateTenantLimits(w http.ResponseWriter, r *http.Request)
 	var payload struct {
 		RateLimitRPM  int     `json:"rate_limit_rpm"`
 		SpendLimitUSD float64 `json:"spend_limit_usd"`
+		Tier          string  `json:"tier"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
+	if payload.Tier != "" && !store.ValidTier(payload.Tier) {
+		http.Error(w, "tier must be free, standard or premium", http.StatusBadRequest)
+		return
+	}
 	if err := s.Store.UpdateTenantLimits(r.Context(), id, payload.RateLimitRPM, payload.SpendLimitUSD); err != nil {
 		http.Error(w, "failed to update limits", http.StatusInternalServerError)
 		return
 	}
+	if payload.Tier != "" {
+		if err := s.Store.UpdateTenantTier(r.Context(), id, payload.Tier); err != nil {
+			http.Error(w, "failed to update tier", http.StatusInternalServerError)
+			return
+		}
+	}
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
@@ -1082,115 +1922,30 @@ func (s *Server) AdminTenantTransactions(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "missing tenant id", http.StatusBadRequest)
 		return
 	}
-	limitStr := r.URL.Query().Get("limit")
-	limit, _ := strconv.Atoi(limitStr)
-	if limit <= 0 {
-		limit = 100
-	}
-	txs, err := s.Store.ListTransactions(r.Context(), id, limit)
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	from, to, err := parseTimeRange(r)
 	if err != nil {
-		http.Error(w, "failed to list transactions", http.StatusInternalServerError)
-		return
-	}
-	writeJSON(w, txs)
-}
-
-// Embeddings proxies embedding requests to the appropriate provider.
-func (s *Server) Embeddings(w http.ResponseWriter, r *http.Request) {
-	tenant := middleware.TenantFromContext(r.Context())
-	if tenant == nil {
-		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if tenant.Suspended {
-		http.Error(w, "account suspended", http.StatusForbidden)
-		return
-	}
-	if tenant.BalanceUSD <= 0 {
-		http.Error(w, "insufficient balance", http.StatusPaymentRequired)
-		return
-	}
-
-	// Read raw body and forward to an OpenAI-compatible provider
-	bodyBytes, err := io.ReadAll(r.Body)
+	result, err := s.Store.ListTransactionsPaginated(r.Context(), id, page, pageSize, store.TransactionFilters{Type: r.URL.Query().Get("type"), From: from, To: to})
 	if err != nil {
-		http.Error(w, "failed to read body", http.StatusBadRequest)
-		return
-	}
-
-	// Parse model from request
-	var parsed struct {
-		Model string `json:"model"`
-	}
-	if err := json.Unmarshal(bodyBytes, &parsed); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
-		return
-	}
-
-	// Find provider for this model
-	providerType, ok, _ := s.Store.GetModelProvider(r.Context(), parsed.Model)
-	if !ok || providerType == "" {
-		providerType = "openai" // default to openai for embeddings
-	}
-
-	providers, err := s.Store.GetEnabledProvidersByType(r.Context(), providerType)
-	if err != nil || len(providers) == 0 {
-		http.Error(w, "no provider available for embeddings", http.StatusBadGateway)
-		return
-	}
-
-	var lastErr error
-	for _, p := range providers {
-		if p.APIKey == "" {
-			continue
-		}
-		url := "https://api.openai.com/v1/embeddings"
-		if providerType == "generic-openai" && p.BaseURL != "" {
-			url = strings.TrimRight(p.BaseURL, "/") + "/v1/embeddings"
-		}
-
-		req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, url, bytes.NewReader(bodyBytes))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+p.APIKey)
-
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode >= 300 {
-			b, _ := io.ReadAll(resp.Body)
-			lastErr = fmt.Errorf("%s", string(b))
-			continue
-		}
-
-		// Forward the response directly
-		w.Header().Set("Content-Type", "application/json")
-		io.Copy(w, resp.Body)
-		return
-	}
-
-	if lastErr != nil {
-		writeError(w, fmt.Errorf("embeddings failed: %w", lastErr))
+		http.Error(w, "failed to list transactions", http.StatusInternalServerError)
 		return
 	}
-	http.Error(w, "no provider with API key for embeddings", http.StatusBadGateway)
+	writeJSON(w, result)
 }
 
 // AdminExportRequestsCSV exports request logs as CSV.
 func (s *Server) AdminExportRequestsCSV(w http.ResponseWriter, r *http.Request) {
-	statusCode, _ := strconv.Atoi(r.URL.Query().Get("status_code"))
-	filters := store.RequestLogFilters{
-		TenantID:   r.URL.Query().Get("tenant_id"),
-		Provider:   r.URL.Query().Get("provider"),
-		Model:      r.URL.Query().Get("model"),
-		StatusCode: statusCode,
-		SortBy:     "created_at",
-		SortDir:    "desc",
+	filters, err := requestLogFilters(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	filters.SortBy = "created_at"
+	filters.SortDir = "desc"
 	result, err := s.Store.ListRequestLogsPaginated(r.Context(), 1, 10000, filters)
 	if err != nil {
 		http.Error(w, "failed to export", http.StatusInternalServerError)
@@ -1214,23 +1969,51 @@ func (s *Server) AdminExportRequestsCSV(w http.ResponseWriter, r *http.Request)
 func (s *Server) ListModels(w http.ResponseWriter, r *http.Request) {
 	items, err := s.Store.ListAllModels(r.Context())
 	if err != nil {
-		http.Error(w, "failed to list models", http.StatusInternalServerError)
+		writeAPIError(w, http.StatusInternalServerError, errServer, "internal_error", "failed to list models")
 		return
 	}
+	type pricing struct {
+		Prompt     string `json:"prompt"`
+		Completion string `json:"completion"`
+	}
 	type modelObj struct {
 		ID      string `json:"id"`
 		Object  string `json:"object"`
 		Created int64  `json:"created"`
 		OwnedBy string `json:"owned_by"`
+
+		// OpenRouter-style metadata for client-side model selection.
+		Provider        string   `json:"provider"`
+		ContextLength   int      `json:"context_length,omitempty"`
+		MaxOutputTokens int      `json:"max_output_tokens,omitempty"`
+		Pricing         *pricing `json:"pricing,omitempty"`
+		Capabilities    []string `json:"capabilities"`
 	}
 	data := make([]modelObj, 0, len(items))
 	for _, m := range items {
-		data = append(data, modelObj{
-			ID:      m.Model,
-			Object:  "model",
-			Created: 1700000000,
-			OwnedBy: m.ProviderType,
-		})
+		obj := modelObj{
+			ID:              m.Model,
+			Object:          "model",
+			Created:         1700000000,
+			OwnedBy:         m.ProviderType,
+			Provider:        m.ProviderType,
+			ContextLength:   m.ContextLength,
+			MaxOutputTokens: m.MaxOutputTokens,
+			Capabilities:    []string{"text"},
+		}
+		if m.PricePer1K > 0 {
+			// Prices are USD per token; one rate is billed for prompt and
+			// completion tokens alike.
+			perToken := strconv.FormatFloat(m.PricePer1K/1000, 'f', -1, 64)
+			obj.Pricing = &pricing{Prompt: perToken, Completion: perToken}
+		}
+		if m.SupportsVision {
+			obj.Capabilities = append(obj.Capabilities, "vision")
+		}
+		if m.SupportsTools {
+			obj.Capabilities = append(obj.Capabilities, "tools")
+		}
+		data = append(data, obj)
 	}
 	writeJSON(w, map[string]interface{}{
 		"object": "list",
@@ -1242,21 +2025,217 @@ func (s *Server) Health(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]string{"status": "ok"})
 }
 
+// Status reports whether the gateway is serving normally or browning out.
+func (s *Server) Status(w http.ResponseWriter, r *http.Request) {
+	status := "ok"
+	var state limiter.BrownoutState
+	if s.Brownout != nil {
+		state = s.Brownout.State()
+		if state.Level > limiter.BrownoutNone {
+			status = "degraded"
+		}
+	}
+	writeJSON(w, map[string]interface{}{"status": status, "brownout": state})
+}
+
+// brownoutLimit returns the tenant's concurrency limit under the current
+// brownout level, writing a 503 and returning false when the tenant's tier is
+// being shed entirely.
+func (s *Server) brownoutLimit(w http.ResponseWriter, tenant *store.Tenant) (int, bool) {
+	if s.Brownout == nil {
+		return s.Limiter.Conc, true
+	}
+	limit := s.Brownout.ConcurrencyLimit(tenant.Tier, s.Limiter.Conc)
+	if limit == 0 {
+		s.writeBrownout(w, tenant)
+		return 0, false
+	}
+	return limit, true
+}
+
+func (s *Server) writeBrownout(w http.ResponseWriter, tenant *store.Tenant) {
+	metrics.BrownoutShedTotal.WithLabelValues(tenant.Tier).Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(s.Brownout.RetryAfterSeconds()))
+	writeAPIError(w, http.StatusServiceUnavailable, "service_unavailable", "brownout", "service is shedding load, retry later")
+}
+
+// chargeRequest bills a completed request's cost to tenant and records it
+// in the usage rollup and the tenant's ledger.
+func (s *Server) chargeRequest(ctx context.Context, tenant *store.Tenant, providerName, model string, tokens int, cost float64) {
+	_ = s.Store.AddUsageCost(ctx, tenant.ID, providerName, model, tokens, cost, time.Now().UTC())
+	// Deduct rather than overwrite: concurrent requests each loaded the
+	// same starting balance.
+	if charge, err := s.Store.ChargeTenant(ctx, tenant.ID, cost); err == nil {
+		// Requests reserving from now on must re-read the balance.
+		if s.Reservations != nil {
+			s.Reservations.Charged(ctx, tenant.ID)
+		}
+		desc := fmt.Sprintf("%s / %s / %d tokens", providerName, model, tokens)
+		if charge.CreditsUSD > 0 {
+			_ = s.Store.RecordTransaction(ctx, tenant.ID, store.TxCreditCharge, -charge.CreditsUSD, charge.BalanceAfter, desc)
+		}
+		// The part that took the balance below zero is drawn on the
+		// tenant's overdraft and recorded apart from the charge.
+		overdraft := math.Min(charge.BalanceUSD, math.Max(-charge.BalanceAfter, 0))
+		if paid := charge.BalanceUSD - overdraft; paid > 0 {
+			_ = s.Store.RecordTransaction(ctx, tenant.ID, "charge", -paid, charge.BalanceAfter+overdraft, desc)
+		}
+		if overdraft > 0 {
+			_ = s.Store.RecordTransaction(ctx, tenant.ID, store.TxOverdraft, -overdraft, charge.BalanceAfter, desc)
+		}
+		before := *tenant
+		before.BalanceUSD = charge.BalanceAfter + charge.BalanceUSD
+		s.fireChargeEvents(ctx, &before, cost, charge.BalanceUSD)
+	}
+	s.Alerts.CheckSpend(ctx, tenant.ID)
+}
+
+// recordRequestMetrics updates the per-tenant and per-model chat metrics.
+func recordRequestMetrics(tenantID, model, provider string, status int, latency time.Duration, tokens int, billed, upstreamCost float64, fallback bool) {
+	tenantLabel, modelLabel := metrics.TenantLabel(tenantID), metrics.ModelLabel(model)
+	metrics.ModelRequestsTotal.WithLabelValues(tenantLabel, modelLabel, http.StatusText(status)).Inc()
+	metrics.ModelLatencyMS.WithLabelValues(modelLabel).Observe(float64(latency.Milliseconds()))
+	if tokens > 0 {
+		metrics.TokensTotal.WithLabelValues(tenantLabel, modelLabel, provider).Add(float64(tokens))
+	}
+	if billed > 0 {
+		metrics.CostUSDTotal.WithLabelValues(tenantLabel, modelLabel, provider).Add(billed)
+	}
+	if upstreamCost > 0 {
+		metrics.UpstreamCostUSDTotal.WithLabelValues(modelLabel, provider).Add(upstreamCost)
+	}
+	if fallback {
+		metrics.FallbacksTotal.WithLabelValues(modelLabel, provider).Inc()
+	}
+}
+
 func writeJSON(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(v)
 }
 
 func writeError(w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadGateway)
-	_ = json.NewEncoder(w).Encode(models.ErrorResponse{Error: models.ErrorDetail{Message: err.Error(), Type: "upstream_error", Code: "upstream_failed"}})
+	writeAPIError(w, http.StatusBadGateway, "upstream_error", "upstream_failed", err.Error())
 }
 
-func errCode(err error) string {
+// writeStreamError reports an upstream failure to a client that is already
+// reading an event stream, as OpenAI does: an error event, then [DONE].
+func writeStreamError(w http.ResponseWriter, err error) {
+	b, _ := json.Marshal(models.ErrorResponse{Error: models.ErrorDetail{Message: err.Error(), Type: "upstream_error", Code: errCode(err)}})
+	_, _ = w.Write([]byte("data: " + string(b) + "\n\ndata: [DONE]\n\n"))
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Request metadata limits, matching OpenAI's.
+const (
+	maxMetadataKeys     = 16
+	maxMetadataKeyLen   = 64
+	maxMetadataValueLen = 512
+)
+
+// parseRequestMetadata turns the request's `metadata` object into flat string
+// tags. Numbers and booleans are stringified; nested values are rejected.
+func parseRequestMetadata(raw json.RawMessage) (map[string]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("metadata must be an object")
+	}
+	if len(obj) > maxMetadataKeys {
+		return nil, fmt.Errorf("metadata may have at most %d keys", maxMetadataKeys)
+	}
+	out := make(map[string]string, len(obj))
+	for k, v := range obj {
+		if len(k) > maxMetadataKeyLen {
+			return nil, fmt.Errorf("metadata key %q exceeds %d characters", k, maxMetadataKeyLen)
+		}
+		var val string
+		switch t := v.(type) {
+		case string:
+			val = t
+		case float64, bool:
+			val = fmt.Sprint(t)
+		default:
+			return nil, fmt.Errorf("metadata value for %q must be a string", k)
+		}
+		if len(val) > maxMetadataValueLen {
+			return nil, fmt.Errorf("metadata value for %q exceeds %d characters", k, maxMetadataValueLen)
+		}
+		out[k] = val
+	}
+	return out, nil
+}
+
+// parseTimeParam accepts RFC3339 timestamps or YYYY-MM-DD dates. An empty
+// value yields the zero time.
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// parseTimeRange reads the `from` and `to` query parameters. A date-only `to`
+// is inclusive, so to=2024-01-31 covers all of January 31st.
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	q := r.URL.Query()
+	from, err := parseTimeParam(q.Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from")
+	}
+	to, err := parseTimeParam(q.Get("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to")
+	}
+	if len(q.Get("to")) == len("2006-01-02") {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// maxUsageBuckets bounds how many series points a single usage query returns.
+const maxUsageBuckets = 1000
+
+// parseUsageWindow reads `from`, `to` and `granularity` (hour, day, week or
+// month) from the query string.
+func parseUsageWindow(r *http.Request) (store.UsageWindow, error) {
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		return store.UsageWindow{}, err
+	}
+	win := store.UsageWindow{From: from, To: to, Granularity: r.URL.Query().Get("granularity")}
+	if win.Granularity != "" && !store.ValidGranularity(win.Granularity) {
+		return win, fmt.Errorf("granularity must be hour, day, week or month")
+	}
+	return win, nil
+}
+
+func errMessage(err error) string {
 	if err == nil {
 		return ""
 	}
+	return err.Error()
+}
+
+func errCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, providers.ErrStreamInterrupted):
+		return "stream_interrupted"
+	case router.RateLimited(err):
+		return "upstream_rate_limited"
+	}
 	return "upstream_failed"
 }
 
@@ -1275,6 +2254,9 @@ func extractText(req models.ChatCompletionRequest) string {
 }
 
 func extractAPIKey(r *http.Request) string {
+	if key := middleware.APIKeyFromContext(r.Context()); key != "" {
+		return key
+	}
 	auth := r.Header.Get("Authorization")
 	if strings.HasPrefix(auth, "Bearer ") {
 		return strings.TrimPrefix(auth, "Bearer ")
@@ -1315,18 +2297,23 @@ func (s *Server) AdminCreateWebhook(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	if payload.URL == "" {
-		http.Error(w, "url required", http.StatusBadRequest)
+	if err := webhook.ValidateURL(r.Context(), payload.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	if len(payload.Events) == 0 {
-		payload.Events = []string{"request.completed"}
+		payload.Events = []string{webhook.EventRequestCompleted}
 	}
-	if err := s.Store.CreateWebhook(r.Context(), payload.URL, payload.Events, payload.Secret); err != nil {
+	if !webhook.ValidEvents(payload.Events, false) {
+		http.Error(w, "unknown event type", http.StatusBadRequest)
+		return
+	}
+	id, err := s.Store.CreateWebhook(r.Context(), store.Webhook{URL: payload.URL, Events: payload.Events, Secret: payload.Secret})
+	if err != nil {
 		http.Error(w, "failed to create webhook", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, map[string]string{"status": "ok"})
+	writeJSON(w, map[string]interface{}{"status": "ok", "id": id})
 }
 
 func (s *Server) AdminDeleteWebhook(w http.ResponseWriter, r *http.Request) {
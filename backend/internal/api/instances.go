@@ -0,0 +1,24 @@
+package api
+
+import "net/http"
+
+// AdminInstances lists the gateway instances heartbeating into the registry,
+// oldest first, with their version, start time and which one is leader.
+func (s *Server) AdminInstances(w http.ResponseWriter, r *http.Request) {
+	if s.Cluster == nil {
+		http.Error(w, "instance registry not enabled", http.StatusNotFound)
+		return
+	}
+	instances, err := s.Cluster.Instances(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list instances", http.StatusInternalServerError)
+		return
+	}
+	leader := ""
+	for _, in := range instances {
+		if in.Leader {
+			leader = in.ID
+		}
+	}
+	writeJSON(w, map[string]interface{}{"self": s.Cluster.Self.ID, "leader": leader, "instances": instances})
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/store"
+)
+
+func (s *Server) AdminJobs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	jobs, err := s.Store.ListJobs(r.Context(), store.JobFilters{Kind: q.Get("kind"), Status: q.Get("status"), Limit: limit})
+	if err != nil {
+		http.Error(w, "failed to list jobs", http.StatusInternalServerError)
+		return
+	}
+	if jobs == nil {
+		jobs = []store.Job{}
+	}
+	writeJSON(w, jobs)
+}
+
+// AdminJobSummary reports job counts by kind and status.
+func (s *Server) AdminJobSummary(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.Store.JobCounts(r.Context())
+	if err != nil {
+		http.Error(w, "failed to load job summary", http.StatusInternalServerError)
+		return
+	}
+	if counts == nil {
+		counts = []store.JobCount{}
+	}
+	writeJSON(w, counts)
+}
+
+func (s *Server) AdminJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.Store.GetJob(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeJobError(w, err, "failed to load job")
+		return
+	}
+	writeJSON(w, job)
+}
+
+// AdminRetryJob queues a failed job to run again.
+func (s *Server) AdminRetryJob(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.Store.RetryJob(r.Context(), id); err != nil {
+		writeJobError(w, err, "failed to retry job")
+		return
+	}
+	job, err := s.Store.GetJob(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load job", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, job)
+}
+
+func writeJobError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "job not found or not failed", http.StatusNotFound)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
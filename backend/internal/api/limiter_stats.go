@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"routerx/internal/limiter"
+)
+
+// AdminTenantLimits shows a tenant's limits, what it has in flight and how
+// many of its recent requests the limiter turned away, so a 429 complaint
+// can be answered from data. ?minutes= sets the window (default and max 60).
+func (s *Server) AdminTenantLimits(w http.ResponseWriter, r *http.Request) {
+	tenant, err := s.Store.GetTenantByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "tenant not found", http.StatusNotFound)
+		return
+	}
+	minutes := limiter.MaxStatsMinutes
+	if v := r.URL.Query().Get("minutes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > limiter.MaxStatsMinutes {
+			http.Error(w, "minutes must be between 1 and 60", http.StatusBadRequest)
+			return
+		}
+		minutes = n
+	}
+	stats, err := s.Limiter.Stats(r.Context(), tenant.ID, minutes)
+	if err != nil {
+		http.Error(w, "failed to load limiter stats", http.StatusInternalServerError)
+		return
+	}
+	policy, err := s.Store.GetRequestPolicy(r.Context(), tenant.ID)
+	if err != nil {
+		http.Error(w, "failed to load request policy", http.StatusInternalServerError)
+		return
+	}
+	qps, burst := s.Limiter.QPS, s.Limiter.Burst
+	if policy.RateLimitQPS > 0 {
+		qps = policy.RateLimitQPS
+	}
+	if policy.RateLimitBurst > 0 {
+		burst = policy.RateLimitBurst
+	}
+	if burst <= 0 {
+		burst = qps
+	}
+	effective := s.Limiter.Conc
+	if s.Brownout != nil {
+		effective = s.Brownout.ConcurrencyLimit(tenant.Tier, s.Limiter.Conc)
+	}
+	writeJSON(w, map[string]interface{}{
+		"tenant_id":                   tenant.ID,
+		"algorithm":                   s.Limiter.Algorithm,
+		"qps_limit":                   qps,
+		"burst":                       burst,
+		"concurrency_limit":           s.Limiter.Conc,
+		"effective_concurrency_limit": effective,
+		"stats":                       stats,
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"routerx/internal/models"
+)
+
+// RequestLimits bounds /v1 request bodies before they are routed. Zero fields
+// disable a check. The overall body size is capped by middleware.MaxBodyBytes.
+type RequestLimits struct {
+	// MaxMessages caps the messages in one chat request.
+	MaxMessages int
+	// MaxImageBytes caps each inline (data: URL) image after decoding.
+	MaxImageBytes int
+	// StrictJSON rejects bodies with fields the API does not know and
+	// trailing data after the JSON value.
+	StrictJSON bool
+}
+
+// decodeJSON decodes a /v1 request body into v, honouring StrictJSON.
+func (s *Server) decodeJSON(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	if s.Limits.StrictJSON {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if s.Limits.StrictJSON {
+		if err := dec.Decode(&struct{}{}); err != io.EOF {
+			return errTrailingData
+		}
+	}
+	return nil
+}
+
+var errTrailingData = errors.New("unexpected data after the JSON body")
+
+// writeDecodeError reports a decodeJSON failure: 413 past the body cap, and
+// 400 for malformed JSON or, in strict mode, unknown fields.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeAPIError(w, http.StatusRequestEntityTooLarge, errInvalidRequest, "request_too_large", fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+	case strings.HasPrefix(err.Error(), "json: unknown field"):
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "unknown_parameter", strings.TrimPrefix(err.Error(), "json: "))
+	case errors.Is(err, errTrailingData):
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_json", err.Error())
+	default:
+		writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "invalid_json", "invalid json")
+	}
+}
+
+// checkRequestLimits returns the error code and message for a chat request
+// over the message or inline image caps, or "" when it is within them.
+func (s *Server) checkRequestLimits(req models.ChatCompletionRequest) (string, string) {
+	if s.Limits.MaxMessages > 0 && len(req.Messages) > s.Limits.MaxMessages {
+		return "too_many_messages", fmt.Sprintf("at most %d messages are allowed per request", s.Limits.MaxMessages)
+	}
+	if s.Limits.MaxImageBytes <= 0 {
+		return "", ""
+	}
+	for i, msg := range req.Messages {
+		for _, u := range models.ImageURLs(msg.Content) {
+			if n := inlineImageBytes(u); n > s.Limits.MaxImageBytes {
+				return "image_too_large", fmt.Sprintf("messages[%d]: inline image of about %d bytes exceeds %d", i, n, s.Limits.MaxImageBytes)
+			}
+		}
+	}
+	return "", ""
+}
+
+// inlineImageBytes estimates the decoded size of a base64 data: URL; remote
+// URLs are not fetched and count as zero.
+func inlineImageBytes(u string) int {
+	if !strings.HasPrefix(u, "data:") {
+		return 0
+	}
+	i := strings.IndexByte(u, ',')
+	if i < 0 {
+		return 0
+	}
+	return base64.StdEncoding.DecodedLen(len(u) - i - 1)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// trafficChannel carries finished-request summaries between instances over
+// Redis pub/sub, so the live feed shows traffic from every instance.
+const trafficChannel = "routerx:traffic"
+
+// trafficPing keeps idle live-feed connections open through proxies.
+const trafficPing = 15 * time.Second
+
+// trafficEvent summarizes one finished chat request for the live feed.
+type trafficEvent struct {
+	TenantID  string    `json:"tenant_id"`
+	Model     string    `json:"model"`
+	Provider  string    `json:"provider"`
+	Status    int       `json:"status"`
+	ErrorCode string    `json:"error_code,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	TTFTMS    int64     `json:"ttft_ms"`
+	Tokens    int       `json:"tokens"`
+	BilledUSD float64   `json:"billed_usd"`
+	Stream    bool      `json:"stream"`
+	Fallback  bool      `json:"fallback"`
+	At        time.Time `json:"at"`
+}
+
+// trafficHub fans events out to this instance's feed subscribers when there
+// is no Redis to do it. A subscriber that falls behind misses events rather
+// than slowing requests down.
+type trafficHub struct {
+	mu   sync.Mutex
+	subs map[chan []byte]struct{}
+}
+
+func (h *trafficHub) subscribe() (chan []byte, func()) {
+	ch := make(chan []byte, 64)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = map[chan []byte]struct{}{}
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+func (h *trafficHub) publish(b []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- b:
+		default:
+		}
+	}
+}
+
+// publishTraffic sends ev to every live feed.
+func (s *Server) publishTraffic(ctx context.Context, ev trafficEvent) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	if s.Router.Redis != nil {
+		_ = s.Router.Redis.Publish(ctx, trafficChannel, b).Err()
+		return
+	}
+	s.traffic.publish(b)
+}
+
+// subscribeTraffic returns a channel of encoded events that stays open until
+// ctx ends.
+func (s *Server) subscribeTraffic(ctx context.Context) <-chan []byte {
+	if s.Router.Redis == nil {
+		ch, stop := s.traffic.subscribe()
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+		return ch
+	}
+	sub := s.Router.Redis.Subscribe(ctx, trafficChannel)
+	out := make(chan []byte, 64)
+	go func() {
+		defer sub.Close()
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- []byte(msg.Payload):
+				default:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// AdminTrafficStream streams a summary of each chat request as it finishes,
+// across all instances, as server-sent events until the client disconnects.
+// tenant_id and model narrow the feed.
+func (s *Server) AdminTrafficStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "stream unsupported", http.StatusInternalServerError)
+		return
+	}
+	tenantID, model := r.URL.Query().Get("tenant_id"), r.URL.Query().Get("model")
+	events := s.subscribeTraffic(r.Context())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ping := time.NewTicker(trafficPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			_, _ = w.Write([]byte(": ping\n\n"))
+		case b := <-events:
+			if tenantID != "" || model != "" {
+				var ev trafficEvent
+				if json.Unmarshal(b, &ev) != nil || tenantID != "" && ev.TenantID != tenantID || model != "" && ev.Model != model {
+					continue
+				}
+			}
+			_, _ = w.Write([]byte("data: " + string(b) + "\n\n"))
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/store"
+)
+
+// AdminSetProviderMaintenance puts a provider into or out of maintenance.
+// Routing skips it silently while the flag is on.
+func (s *Server) AdminSetProviderMaintenance(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if err := s.Store.SetProviderMaintenance(r.Context(), id, payload.Enabled); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "provider not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to set maintenance", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"provider_id": id, "maintenance": payload.Enabled})
+}
+
+// AdminMaintenanceWindows lists a provider's current and upcoming windows.
+func (s *Server) AdminMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows, err := s.Store.ListMaintenanceWindows(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "failed to list maintenance windows", http.StatusInternalServerError)
+		return
+	}
+	if windows == nil {
+		windows = []store.MaintenanceWindow{}
+	}
+	writeJSON(w, windows)
+}
+
+// AdminCreateMaintenanceWindow schedules downtime for a provider.
+func (s *Server) AdminCreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		StartsAt time.Time `json:"starts_at"`
+		EndsAt   time.Time `json:"ends_at"`
+		Reason   string    `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.StartsAt.IsZero() || !payload.EndsAt.After(payload.StartsAt) {
+		http.Error(w, "starts_at and a later ends_at required", http.StatusBadRequest)
+		return
+	}
+	if !payload.EndsAt.After(time.Now()) {
+		http.Error(w, "ends_at is in the past", http.StatusBadRequest)
+		return
+	}
+	p, err := s.Store.GetProviderByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	window, err := s.Store.CreateMaintenanceWindow(r.Context(), store.MaintenanceWindow{
+		ProviderID: p.ID,
+		StartsAt:   payload.StartsAt,
+		EndsAt:     payload.EndsAt,
+		Reason:     strings.TrimSpace(payload.Reason),
+	})
+	if err != nil {
+		http.Error(w, "failed to create maintenance window", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, window)
+}
+
+func (s *Server) AdminDeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	windowID, err := strconv.ParseInt(chi.URLParam(r, "windowID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid window id", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.DeleteMaintenanceWindow(r.Context(), chi.URLParam(r, "id"), windowID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "maintenance window not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to delete maintenance window", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"routerx/internal/models"
+	"routerx/internal/router"
+)
+
+// requestMaxCost returns the request's cost ceiling in USD: max_cost_usd in
+// the body, else X-RouterX-Max-Cost. Zero means no ceiling.
+func requestMaxCost(r *http.Request, req *models.ChatCompletionRequest) (float64, error) {
+	if req.MaxCostUSD != nil {
+		v := *req.MaxCostUSD
+		req.MaxCostUSD = nil
+		if v <= 0 {
+			return 0, fmt.Errorf("max_cost_usd must be positive")
+		}
+		return v, nil
+	}
+	h := r.Header.Get("X-RouterX-Max-Cost")
+	if h == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(h, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("X-RouterX-Max-Cost must be a positive number of USD")
+	}
+	return v, nil
+}
+
+// applyMaxCost holds req under maxCost. The prompt alone costing more is an
+// error; otherwise the completion is clamped to the tokens the rest of the
+// budget buys, which also bounds requests that set no max_tokens.
+func (s *Server) applyMaxCost(ctx context.Context, req *models.ChatCompletionRequest, maxCost float64) (clamped bool, err error) {
+	perToken := s.tenantCost(ctx, req.Model, 1000) / 1000
+	if perToken <= 0 {
+		return false, nil
+	}
+	promptCost := perToken * float64(router.EstimatePromptTokens(*req))
+	if promptCost >= maxCost {
+		return false, fmt.Errorf("estimated prompt cost $%.6f exceeds the request's max cost of $%.6f", promptCost, maxCost)
+	}
+	affordable := int((maxCost - promptCost) / perToken)
+	if affordable < 1 {
+		return false, fmt.Errorf("max cost of $%.6f leaves no room for a completion after the prompt's estimated $%.6f", maxCost, promptCost)
+	}
+	if req.MaxCompletionTokens > 0 {
+		if req.MaxCompletionTokens > affordable {
+			req.MaxCompletionTokens = affordable
+			clamped = true
+		}
+		if req.MaxTokens > affordable {
+			req.MaxTokens = affordable
+		}
+		return clamped, nil
+	}
+	if req.MaxTokens == 0 || req.MaxTokens > affordable {
+		req.MaxTokens = affordable
+		clamped = true
+	}
+	return clamped, nil
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/segmentio/ksuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+	"routerx/internal/util"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+func (s *Server) TenantMembers(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	members, err := s.Store.ListTenantMembers(r.Context(), user.TenantID)
+	if err != nil {
+		http.Error(w, "failed to list members", http.StatusInternalServerError)
+		return
+	}
+	if members == nil {
+		members = []store.TenantMember{}
+	}
+	writeJSON(w, members)
+}
+
+func (s *Server) TenantUpdateMember(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	var payload struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if !store.ValidRole(payload.Role) {
+		http.Error(w, "role must be owner, member or viewer", http.StatusBadRequest)
+		return
+	}
+	err := s.Store.UpdateTenantMemberRole(r.Context(), user.TenantID, chi.URLParam(r, "id"), payload.Role)
+	if err != nil {
+		writeMemberError(w, err, "failed to update member")
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func (s *Server) TenantDeleteMember(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	if err := s.Store.DeleteTenantMember(r.Context(), user.TenantID, chi.URLParam(r, "id")); err != nil {
+		writeMemberError(w, err, "failed to remove member")
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func writeMemberError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, store.ErrLastOwner) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	http.Error(w, msg, http.StatusNotFound)
+}
+
+func (s *Server) TenantInvitations(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	invs, err := s.Store.ListPendingInvitations(r.Context(), user.TenantID)
+	if err != nil {
+		http.Error(w, "failed to list invitations", http.StatusInternalServerError)
+		return
+	}
+	if invs == nil {
+		invs = []store.Invitation{}
+	}
+	writeJSON(w, invs)
+}
+
+// TenantCreateInvitation invites an email address to join the tenant. The
+// token is only stored hashed; it is mailed when SMTP is configured and is
+// always returned once so owners can share the link themselves.
+func (s *Server) TenantCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	var payload struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if _, err := mail.ParseAddress(payload.Email); err != nil {
+		http.Error(w, "invalid email", http.StatusBadRequest)
+		return
+	}
+	if payload.Role == "" {
+		payload.Role = store.RoleMember
+	}
+	if !store.ValidRole(payload.Role) {
+		http.Error(w, "role must be owner, member or viewer", http.StatusBadRequest)
+		return
+	}
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		http.Error(w, "failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(tokenBytes)
+	now := time.Now().UTC()
+	inv := store.Invitation{
+		ID:        ksuid.New().String(),
+		TenantID:  user.TenantID,
+		Email:     payload.Email,
+		Role:      payload.Role,
+		TokenHash: util.HashString(token),
+		InvitedBy: user.Username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(invitationTTL),
+	}
+	if err := s.Store.CreateInvitation(r.Context(), inv); err != nil {
+		http.Error(w, "failed to create invitation", http.StatusInternalServerError)
+		return
+	}
+	acceptURL := strings.TrimRight(s.PublicURL, "/") + "/invite?token=" + token
+	emailed := false
+	if s.Mailer != nil {
+		body := fmt.Sprintf("%s invited you to join their RouterX workspace as %s.\n\nAccept the invitation: %s\n\nThe link expires on %s.\n",
+			user.Username, inv.Role, acceptURL, inv.ExpiresAt.Format("2006-01-02"))
+		if err := s.Mailer.Send(inv.Email, "You're invited to RouterX", body); err != nil {
+			s.Logger.Warn("invitation email failed", zap.String("invitation_id", inv.ID), zap.Error(err))
+		} else {
+			emailed = true
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"invitation": inv,
+		"token":      token,
+		"accept_url": acceptURL,
+		"emailed":    emailed,
+	})
+}
+
+func (s *Server) TenantDeleteInvitation(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	if err := s.Store.DeleteInvitation(r.Context(), user.TenantID, chi.URLParam(r, "id")); err != nil {
+		http.Error(w, "failed to delete invitation", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// AcceptInvitation creates the invited user and returns a tenant session token.
+func (s *Server) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Token    string `json:"token"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.Token == "" || payload.Username == "" || payload.Password == "" {
+		http.Error(w, "missing token, username or password", http.StatusBadRequest)
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "failed to accept invitation", http.StatusInternalServerError)
+		return
+	}
+	u := store.TenantUser{
+		ID:           ksuid.New().String(),
+		Username:     payload.Username,
+		PasswordHash: string(hash),
+	}
+	inv, err := s.Store.AcceptInvitation(r.Context(), util.HashString(payload.Token), u)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	u.TenantID, u.Role = inv.TenantID, inv.Role
+	s.issueSession(w, r, s.tenantAccount(r, &u), "", map[string]string{"tenant_id": inv.TenantID, "role": inv.Role})
+}
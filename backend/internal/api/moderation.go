@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"routerx/internal/metrics"
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/store"
+	"routerx/internal/webhook"
+)
+
+// Moderation directions.
+const (
+	moderationInput  = "input"
+	moderationOutput = "output"
+)
+
+// moderationPolicy loads the tenant's policy, treating lookup failures as "off".
+func (s *Server) moderationPolicy(ctx context.Context, tenantID string) *store.ModerationPolicy {
+	if s.Moderation == nil {
+		return &store.ModerationPolicy{TenantID: tenantID, Mode: store.ModerationOff}
+	}
+	p, err := s.Store.GetModerationPolicy(ctx, tenantID)
+	if err != nil {
+		s.Logger.Warn("moderation policy lookup failed", zap.String("tenant_id", tenantID), zap.Error(err))
+		return &store.ModerationPolicy{TenantID: tenantID, Mode: store.ModerationOff}
+	}
+	return p
+}
+
+// moderate classifies text under the tenant's policy and records anything
+// flagged. It returns the matched categories and whether the request must be
+// blocked. Classifier errors fail open.
+func (s *Server) moderate(ctx context.Context, p *store.ModerationPolicy, direction, model, promptHash, text string) ([]string, bool) {
+	if s.Moderation == nil || p.Mode == store.ModerationOff || strings.TrimSpace(text) == "" {
+		return nil, false
+	}
+	res, err := s.Moderation.Classify(ctx, text)
+	if err != nil {
+		metrics.ModerationErrorsTotal.Inc()
+		s.Logger.Warn("moderation failed", zap.String("tenant_id", p.TenantID), zap.String("direction", direction), zap.Error(err))
+		return nil, false
+	}
+	if !res.Flagged {
+		return nil, false
+	}
+	categories := res.Categories
+	if len(p.Categories) > 0 {
+		categories = nil
+		for _, c := range res.Categories {
+			if contains(p.Categories, c) {
+				categories = append(categories, c)
+			}
+		}
+		if len(categories) == 0 {
+			return nil, false
+		}
+	}
+	metrics.ModerationFlaggedTotal.WithLabelValues(direction, p.Mode).Inc()
+	_ = s.Store.InsertModerationEvent(ctx, store.ModerationEvent{
+		TenantID:   p.TenantID,
+		Direction:  direction,
+		Action:     p.Mode,
+		Model:      model,
+		Categories: categories,
+		PromptHash: promptHash,
+	})
+	if p.Mode != store.ModerationLog && s.Webhooks != nil {
+		s.Webhooks.Fire(ctx, p.TenantID, webhook.EventModerationFlagged, map[string]interface{}{
+			"tenant_id":   p.TenantID,
+			"direction":   direction,
+			"action":      p.Mode,
+			"model":       model,
+			"categories":  categories,
+			"prompt_hash": promptHash,
+		})
+	}
+	return categories, p.Mode == store.ModerationBlock
+}
+
+func writeModerationBlocked(w http.ResponseWriter, direction string, categories []string) {
+	msg := "the prompt was rejected by the content policy"
+	if direction == moderationOutput {
+		msg = "the completion was withheld by the content policy"
+	}
+	if len(categories) > 0 {
+		msg += " (" + strings.Join(categories, ", ") + ")"
+	}
+	writeAPIError(w, http.StatusBadRequest, errInvalidRequest, "content_policy_violation", msg)
+}
+
+// completionText joins the assistant text of every choice.
+func completionText(resp models.ChatCompletionResponse) string {
+	var b strings.Builder
+	for _, c := range resp.Choices {
+		if c.Message.Content != nil {
+			b.WriteString(*c.Message.Content)
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
+
+func (s *Server) AdminGetModerationPolicy(w http.ResponseWriter, r *http.Request) {
+	s.writeModerationPolicy(w, r, chi.URLParam(r, "id"))
+}
+
+func (s *Server) AdminSetModerationPolicy(w http.ResponseWriter, r *http.Request) {
+	s.setModerationPolicy(w, r, chi.URLParam(r, "id"))
+}
+
+func (s *Server) AdminModerationEvents(w http.ResponseWriter, r *http.Request) {
+	s.writeModerationEvents(w, r, r.URL.Query().Get("tenant_id"))
+}
+
+func (s *Server) TenantModerationPolicy(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.writeModerationPolicy(w, r, user.TenantID)
+}
+
+func (s *Server) TenantSetModerationPolicy(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.setModerationPolicy(w, r, user.TenantID)
+}
+
+func (s *Server) TenantModerationEvents(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.writeModerationEvents(w, r, user.TenantID)
+}
+
+func (s *Server) writeModerationPolicy(w http.ResponseWriter, r *http.Request, tenantID string) {
+	p, err := s.Store.GetModerationPolicy(r.Context(), tenantID)
+	if err != nil {
+		http.Error(w, "failed to load moderation policy", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"policy": p, "classifier_enabled": s.Moderation != nil})
+}
+
+func (s *Server) setModerationPolicy(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var payload struct {
+		Mode        string   `json:"mode"`
+		CheckOutput bool     `json:"check_output"`
+		Categories  []string `json:"categories"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if !store.ValidModerationMode(payload.Mode) {
+		http.Error(w, "mode must be off, log, flag or block", http.StatusBadRequest)
+		return
+	}
+	if _, err := s.Store.GetTenantByID(r.Context(), tenantID); err != nil {
+		http.Error(w, "tenant not found", http.StatusNotFound)
+		return
+	}
+	p := store.ModerationPolicy{TenantID: tenantID, Mode: payload.Mode, CheckOutput: payload.CheckOutput, Categories: payload.Categories}
+	if err := s.Store.UpsertModerationPolicy(r.Context(), p); err != nil {
+		http.Error(w, "failed to save moderation policy", http.StatusInternalServerError)
+		return
+	}
+	s.writeModerationPolicy(w, r, tenantID)
+}
+
+func (s *Server) writeModerationEvents(w http.ResponseWriter, r *http.Request, tenantID string) {
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	events, err := s.Store.ListModerationEvents(r.Context(), tenantID, from, to, limit)
+	if err != nil {
+		http.Error(w, "failed to list moderation events", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []store.ModerationEvent{}
+	}
+	writeJSON(w, events)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// AdminSetTenantOverdraft sets how far below zero a tenant's balance may go
+// before requests are refused. Requests admitted within it are charged in
+// full, so the balance can end up further below zero than the allowance.
+func (s *Server) AdminSetTenantOverdraft(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		OverdraftUSD float64 `json:"overdraft_usd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.OverdraftUSD < 0 {
+		http.Error(w, "overdraft_usd must not be negative", http.StatusBadRequest)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if err := s.Store.SetTenantOverdraft(r.Context(), id, payload.OverdraftUSD); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "tenant not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to set overdraft", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"tenant_id": id, "overdraft_usd": payload.OverdraftUSD})
+}
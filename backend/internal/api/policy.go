@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/router"
+	"routerx/internal/store"
+)
+
+// checkRequestPolicy enforces the tenant's request policy on req, clamping
+// max_tokens in place. It returns a non-empty message when the request must be
+// rejected.
+func checkRequestPolicy(p *store.RequestPolicy, req *models.ChatCompletionRequest) (clamped bool, violation string) {
+	if req.Model != "" && !router.ModelAllowed(req.Model, p.AllowedModels, p.DeniedModels) {
+		return false, fmt.Sprintf("model %s is not allowed for this tenant", req.Model)
+	}
+	if p.MaxMessages > 0 && len(req.Messages) > p.MaxMessages {
+		return false, fmt.Sprintf("at most %d messages are allowed per request", p.MaxMessages)
+	}
+	if req.Temperature != nil {
+		if p.MinTemperature != nil && *req.Temperature < *p.MinTemperature {
+			return false, fmt.Sprintf("temperature must be at least %g", *p.MinTemperature)
+		}
+		if p.MaxTemperature != nil && *req.Temperature > *p.MaxTemperature {
+			return false, fmt.Sprintf("temperature must be at most %g", *p.MaxTemperature)
+		}
+	}
+	if p.MaxTokens > 0 {
+		if req.MaxTokens == 0 && req.MaxCompletionTokens == 0 {
+			req.MaxTokens = p.MaxTokens
+			clamped = true
+		}
+		if req.MaxTokens > p.MaxTokens {
+			req.MaxTokens = p.MaxTokens
+			clamped = true
+		}
+		if req.MaxCompletionTokens > p.MaxTokens {
+			req.MaxCompletionTokens = p.MaxTokens
+			clamped = true
+		}
+	}
+	return clamped, ""
+}
+
+func writePolicyViolation(w http.ResponseWriter, status int, msg string) {
+	writeAPIError(w, status, errInvalidRequest, "tenant_policy", msg)
+}
+
+// residencyRegions returns the regions a request may be served from: the
+// tenant's allowed regions, narrowed by X-RouterX-Data-Residency. It writes a
+// policy violation when the header names only regions the tenant may not use.
+func residencyRegions(w http.ResponseWriter, r *http.Request, p *store.RequestPolicy) ([]string, bool) {
+	regions, ok := router.ResidencyRegions(p.AllowedRegions, router.ParseRegions(r.Header.Get("X-RouterX-Data-Residency")))
+	if !ok {
+		writePolicyViolation(w, http.StatusForbidden, "requested regions are outside the tenant's data residency")
+	}
+	return regions, ok
+}
+
+// preferredRegion is the region routing favours: X-RouterX-Region, else the
+// deployment's own.
+func (s *Server) preferredRegion(r *http.Request) string {
+	if region := r.Header.Get("X-RouterX-Region"); region != "" {
+		return region
+	}
+	return s.Region
+}
+
+func (s *Server) AdminGetRequestPolicy(w http.ResponseWriter, r *http.Request) {
+	s.writeRequestPolicy(w, r, chi.URLParam(r, "id"))
+}
+
+func (s *Server) AdminSetRequestPolicy(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var payload struct {
+		MaxTokens       int      `json:"max_tokens"`
+		MinTemperature  *float64 `json:"min_temperature"`
+		MaxTemperature  *float64 `json:"max_temperature"`
+		DeniedModels    []string `json:"denied_models"`
+		DeniedProviders []string `json:"denied_providers"`
+		MaxMessages     int      `json:"max_messages"`
+		MaxBodyBytes    int64    `json:"max_body_bytes"`
+		AllowedRegions  []string `json:"allowed_regions"`
+
+		AllowedProviders []string `json:"allowed_providers"`
+		AllowedModels    []string `json:"allowed_models"`
+		DedupWindowSec   int      `json:"dedup_window_seconds"`
+		Coalesce         string   `json:"coalesce"`
+		RateLimitQPS     int      `json:"rate_limit_qps"`
+		RateLimitBurst   int      `json:"rate_limit_burst"`
+
+		DailyBudgetUSD    float64           `json:"daily_budget_usd"`
+		DowngradeBelowUSD float64           `json:"downgrade_below_usd"`
+		DowngradeModels   map[string]string `json:"downgrade_models"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.MaxTokens < 0 || payload.MaxMessages < 0 || payload.MaxBodyBytes < 0 || payload.RateLimitQPS < 0 || payload.RateLimitBurst < 0 {
+		http.Error(w, "limits must not be negative", http.StatusBadRequest)
+		return
+	}
+	if payload.DedupWindowSec < 0 || payload.DedupWindowSec > maxDedupWindowSec {
+		http.Error(w, fmt.Sprintf("dedup_window_seconds must be between 0 and %d", maxDedupWindowSec), http.StatusBadRequest)
+		return
+	}
+	if payload.DailyBudgetUSD < 0 || payload.DowngradeBelowUSD < 0 {
+		http.Error(w, "daily_budget_usd and downgrade_below_usd must not be negative", http.StatusBadRequest)
+		return
+	}
+	if len(payload.DowngradeModels) > 0 && (payload.DailyBudgetUSD == 0 || payload.DowngradeBelowUSD == 0) {
+		http.Error(w, "downgrade_models needs daily_budget_usd and downgrade_below_usd", http.StatusBadRequest)
+		return
+	}
+	for from, to := range payload.DowngradeModels {
+		if from == "" || to == "" {
+			http.Error(w, "downgrade_models entries must name both models", http.StatusBadRequest)
+			return
+		}
+	}
+	if !store.ValidCoalesce(payload.Coalesce) {
+		http.Error(w, "coalesce must be empty, charge_once or charge_each", http.StatusBadRequest)
+		return
+	}
+	if payload.MinTemperature != nil && payload.MaxTemperature != nil && *payload.MinTemperature > *payload.MaxTemperature {
+		http.Error(w, "min_temperature must not exceed max_temperature", http.StatusBadRequest)
+		return
+	}
+	if _, err := s.Store.GetTenantByID(r.Context(), id); err != nil {
+		http.Error(w, "tenant not found", http.StatusNotFound)
+		return
+	}
+	err := s.Store.UpsertRequestPolicy(r.Context(), store.RequestPolicy{
+		TenantID:        id,
+		MaxTokens:       payload.MaxTokens,
+		MinTemperature:  payload.MinTemperature,
+		MaxTemperature:  payload.MaxTemperature,
+		DeniedModels:    payload.DeniedModels,
+		DeniedProviders: payload.DeniedProviders,
+		MaxMessages:     payload.MaxMessages,
+		MaxBodyBytes:    payload.MaxBodyBytes,
+		AllowedRegions:  router.NormalizeRegions(payload.AllowedRegions),
+
+		AllowedProviders: payload.AllowedProviders,
+		AllowedModels:    payload.AllowedModels,
+		DedupWindowSec:   payload.DedupWindowSec,
+		Coalesce:         payload.Coalesce,
+		RateLimitQPS:     payload.RateLimitQPS,
+		RateLimitBurst:   payload.RateLimitBurst,
+
+		DailyBudgetUSD:    payload.DailyBudgetUSD,
+		DowngradeBelowUSD: payload.DowngradeBelowUSD,
+		DowngradeModels:   payload.DowngradeModels,
+	})
+	if err != nil {
+		http.Error(w, "failed to save request policy", http.StatusInternalServerError)
+		return
+	}
+	s.writeRequestPolicy(w, r, id)
+}
+
+// TenantRequestPolicy shows tenants the limits applied to their requests.
+func (s *Server) TenantRequestPolicy(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.writeRequestPolicy(w, r, user.TenantID)
+}
+
+func (s *Server) writeRequestPolicy(w http.ResponseWriter, r *http.Request, tenantID string) {
+	p, err := s.Store.GetRequestPolicy(r.Context(), tenantID)
+	if err != nil {
+		http.Error(w, "failed to load request policy", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, p)
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/store"
+)
+
+// templateVar matches {{name}} placeholders; surrounding spaces are allowed.
+var templateVar = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// templateVariables returns the sorted, de-duplicated placeholder names used
+// by the messages.
+func templateVariables(msgs []store.TemplateMessage) []string {
+	seen := map[string]bool{}
+	vars := []string{}
+	for _, m := range msgs {
+		for _, match := range templateVar.FindAllStringSubmatch(m.Content, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				vars = append(vars, match[1])
+			}
+		}
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// renderPromptTemplate expands req.PromptTemplate in place: the template's
+// messages, with variables substituted, are prepended to req.Messages and the
+// extension fields are cleared so they never reach a provider. Errors are
+// client errors.
+func (s *Server) renderPromptTemplate(ctx context.Context, tenantID string, req *models.ChatCompletionRequest) error {
+	if req.PromptTemplate == "" {
+		if len(req.Variables) > 0 {
+			return fmt.Errorf("variables require prompt_template")
+		}
+		return nil
+	}
+	tpl, err := s.Store.GetPromptTemplateByName(ctx, tenantID, req.PromptTemplate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("prompt template %q not found", req.PromptTemplate)
+		}
+		return fmt.Errorf("failed to load prompt template")
+	}
+	var missing []string
+	for _, v := range tpl.Variables {
+		if _, ok := req.Variables[v]; !ok {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing variables for prompt template %q: %s", tpl.Name, strings.Join(missing, ", "))
+	}
+	rendered := make([]models.Message, 0, len(tpl.Messages)+len(req.Messages))
+	for _, m := range tpl.Messages {
+		text := templateVar.ReplaceAllStringFunc(m.Content, func(match string) string {
+			return req.Variables[templateVar.FindStringSubmatch(match)[1]]
+		})
+		content, _ := json.Marshal(text)
+		rendered = append(rendered, models.Message{Role: m.Role, Content: content})
+	}
+	req.Messages = append(rendered, req.Messages...)
+	req.PromptTemplate, req.Variables = "", nil
+	return nil
+}
+
+func (s *Server) TenantPromptTemplates(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	templates, err := s.Store.ListPromptTemplates(r.Context(), user.TenantID)
+	if err != nil {
+		http.Error(w, "failed to list prompt templates", http.StatusInternalServerError)
+		return
+	}
+	if templates == nil {
+		templates = []store.PromptTemplate{}
+	}
+	writeJSON(w, templates)
+}
+
+func (s *Server) TenantGetPromptTemplate(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	tpl, err := s.Store.GetPromptTemplate(r.Context(), user.TenantID, chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "prompt template not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, tpl)
+}
+
+func (s *Server) TenantCreatePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	tpl, ok := decodePromptTemplate(w, r)
+	if !ok {
+		return
+	}
+	tpl.ID = ksuid.New().String()
+	tpl.TenantID = user.TenantID
+	tpl.CreatedBy = user.Username
+	if err := s.Store.CreatePromptTemplate(r.Context(), tpl); err != nil {
+		writePromptTemplateError(w, err, "failed to create prompt template")
+		return
+	}
+	s.writePromptTemplate(w, r, user.TenantID, tpl.ID)
+}
+
+func (s *Server) TenantUpdatePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	tpl, ok := decodePromptTemplate(w, r)
+	if !ok {
+		return
+	}
+	tpl.ID = chi.URLParam(r, "id")
+	tpl.TenantID = user.TenantID
+	if err := s.Store.UpdatePromptTemplate(r.Context(), tpl); err != nil {
+		writePromptTemplateError(w, err, "failed to update prompt template")
+		return
+	}
+	s.writePromptTemplate(w, r, user.TenantID, tpl.ID)
+}
+
+func (s *Server) TenantDeletePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	if err := s.Store.DeletePromptTemplate(r.Context(), user.TenantID, chi.URLParam(r, "id")); err != nil {
+		writePromptTemplateError(w, err, "failed to delete prompt template")
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func (s *Server) writePromptTemplate(w http.ResponseWriter, r *http.Request, tenantID, id string) {
+	tpl, err := s.Store.GetPromptTemplate(r.Context(), tenantID, id)
+	if err != nil {
+		http.Error(w, "failed to load prompt template", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, tpl)
+}
+
+// decodePromptTemplate validates a create/update payload and derives the
+// template's variables from its messages.
+func decodePromptTemplate(w http.ResponseWriter, r *http.Request) (store.PromptTemplate, bool) {
+	var payload struct {
+		Name        string                  `json:"name"`
+		Description string                  `json:"description"`
+		Messages    []store.TemplateMessage `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return store.PromptTemplate{}, false
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" || len(payload.Messages) == 0 {
+		http.Error(w, "missing name or messages", http.StatusBadRequest)
+		return store.PromptTemplate{}, false
+	}
+	for _, m := range payload.Messages {
+		switch m.Role {
+		case "system", "developer", "user", "assistant":
+		default:
+			http.Error(w, "message role must be system, developer, user or assistant", http.StatusBadRequest)
+			return store.PromptTemplate{}, false
+		}
+	}
+	return store.PromptTemplate{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Messages:    payload.Messages,
+		Variables:   templateVariables(payload.Messages),
+	}, true
+}
+
+func writePromptTemplateError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, store.ErrTemplateExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, pgx.ErrNoRows):
+		http.Error(w, "prompt template not found", http.StatusNotFound)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/providers"
+	"routerx/internal/store"
+)
+
+const providerTestTimeout = 20 * time.Second
+
+// AdminTestProvider sends a minimal request through the provider's live key.
+func (s *Server) AdminTestProvider(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	p, err := s.Store.GetProviderByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), providerTestTimeout)
+	defer cancel()
+	latency, err := s.Router.TestProvider(ctx, *p, "")
+	writeProviderTestResult(w, latency, err)
+}
+
+// AdminStageProviderKey stores a new upstream key alongside the live one.
+// Routing keeps using the live key until the staged key is promoted.
+func (s *Server) AdminStageProviderKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var payload struct {
+		APIKey string `json:"api_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.APIKey == "" {
+		http.Error(w, "api_key required", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.StageProviderAPIKey(r.Context(), id, payload.APIKey); err != nil {
+		http.Error(w, "failed to stage api key", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "staged"})
+}
+
+// AdminTestStagedProviderKey validates the staged key without promoting it.
+func (s *Server) AdminTestStagedProviderKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	p, staged, ok := s.loadStagedKey(w, r, id)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), providerTestTimeout)
+	defer cancel()
+	latency, err := s.Router.TestProvider(ctx, p, staged)
+	writeProviderTestResult(w, latency, err)
+}
+
+// AdminPromoteProviderKey validates the staged key and, if it works, makes it
+// the live key. A failed validation discards the staged key and leaves the
+// live key untouched.
+func (s *Server) AdminPromoteProviderKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	p, staged, ok := s.loadStagedKey(w, r, id)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), providerTestTimeout)
+	defer cancel()
+	latency, err := s.Router.TestProvider(ctx, p, staged)
+	if err != nil {
+		_ = s.Store.ClearStagedProviderAPIKey(r.Context(), id)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "rolled_back",
+			"error":  err.Error(),
+		})
+		return
+	}
+	if err := s.Store.PromoteStagedProviderAPIKey(r.Context(), id); err != nil {
+		http.Error(w, "failed to promote api key", http.StatusConflict)
+		return
+	}
+	s.Router.InvalidateProvider(r.Context(), id)
+	writeJSON(w, map[string]interface{}{"status": "promoted", "latency_ms": latency.Milliseconds()})
+}
+
+func (s *Server) AdminDiscardStagedProviderKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.Store.ClearStagedProviderAPIKey(r.Context(), id); err != nil {
+		http.Error(w, "failed to discard staged api key", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// AdminRollbackProviderKey restores the key that was live before the last promotion.
+func (s *Server) AdminRollbackProviderKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.Store.RollbackProviderAPIKey(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	s.Router.InvalidateProvider(r.Context(), id)
+	writeJSON(w, map[string]string{"status": "rolled_back"})
+}
+
+func (s *Server) loadStagedKey(w http.ResponseWriter, r *http.Request, id string) (store.Provider, string, bool) {
+	p, err := s.Store.GetProviderByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return store.Provider{}, "", false
+	}
+	staged, err := s.Store.GetStagedProviderAPIKey(r.Context(), id)
+	if err != nil || staged == "" {
+		http.Error(w, "no staged api key", http.StatusConflict)
+		return store.Provider{}, "", false
+	}
+	return *p, staged, true
+}
+
+func writeProviderTestResult(w http.ResponseWriter, latency time.Duration, err error) {
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, map[string]interface{}{"ok": true, "latency_ms": latency.Milliseconds()})
+}
+
+// AdminProviderRateBudget shows a provider's RPM/TPM budget and what the
+// current minute has used of it.
+func (s *Server) AdminProviderRateBudget(w http.ResponseWriter, r *http.Request) {
+	p, err := s.Store.GetProviderByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"provider_id": p.ID,
+		"rpm_limit":   p.RPMLimit,
+		"tpm_limit":   p.TPMLimit,
+		"usage":       s.Router.BudgetUsage(r.Context(), p.ID),
+	})
+}
+
+// AdminSetProviderRateBudget sets the requests- and tokens-per-minute ceiling
+// of a provider's upstream contract. Zero removes a limit.
+func (s *Server) AdminSetProviderRateBudget(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		RPMLimit int `json:"rpm_limit"`
+		TPMLimit int `json:"tpm_limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.RPMLimit < 0 || payload.TPMLimit < 0 {
+		http.Error(w, "limits must not be negative", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.SetProviderRateBudget(r.Context(), chi.URLParam(r, "id"), payload.RPMLimit, payload.TPMLimit); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "provider not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to set rate budget", http.StatusInternalServerError)
+		return
+	}
+	s.AdminProviderRateBudget(w, r)
+}
+
+// AdminSetProviderCircuit opens or closes a provider's circuit breaker by
+// hand. An open lasts duration_seconds, or the provider's cooldown when that
+// is omitted.
+func (s *Server) AdminSetProviderCircuit(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		State           string `json:"state"`
+		DurationSeconds int    `json:"duration_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.DurationSeconds < 0 {
+		http.Error(w, "duration_seconds must not be negative", http.StatusBadRequest)
+		return
+	}
+	p, err := s.Store.GetProviderByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	switch payload.State {
+	case "open":
+		until := s.Router.OpenCircuit(r.Context(), p, time.Duration(payload.DurationSeconds)*time.Second)
+		writeJSON(w, map[string]interface{}{"provider_id": p.ID, "state": "open", "open_until": until.UTC()})
+	case "closed":
+		s.Router.CloseCircuit(r.Context(), p)
+		writeJSON(w, map[string]interface{}{"provider_id": p.ID, "state": "closed"})
+	default:
+		http.Error(w, `state must be "open" or "closed"`, http.StatusBadRequest)
+	}
+}
+
+// AdminSetProviderNetwork replaces a provider's proxy and TLS settings. An
+// omitted client_key keeps the stored one while client_cert is set.
+func (s *Server) AdminSetProviderNetwork(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ProxyURL   string `json:"proxy_url"`
+		CABundle   string `json:"ca_bundle"`
+		ClientCert string `json:"client_cert"`
+		ClientKey  string `json:"client_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	p, err := s.Store.GetProviderByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	n := store.NetworkSettings{ProxyURL: payload.ProxyURL, CABundle: payload.CABundle, ClientCert: payload.ClientCert, ClientKey: payload.ClientKey}
+	if n.ClientKey == "" && n.ClientCert != "" {
+		n.ClientKey = p.Network.ClientKey
+	}
+	if err := providers.ValidateNetwork(n); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.SetProviderNetwork(r.Context(), p.ID, n); err != nil {
+		http.Error(w, "failed to set network settings", http.StatusInternalServerError)
+		return
+	}
+	s.Router.InvalidateProvider(r.Context(), p.ID)
+	n.HasClientKey = n.ClientKey != ""
+	writeJSON(w, n)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/billingimport"
+	"routerx/internal/middleware"
+)
+
+const (
+	maxBillingExportBytes = 20 << 20
+	// defaultDriftThresholdPct is how far billed and computed cost may
+	// differ before a reconciliation line is flagged.
+	defaultDriftThresholdPct = 5
+)
+
+// AdminImportProviderBilling imports a provider's billing export (the CSV as
+// the request body, ?format=openai or anthropic) and returns the
+// reconciliation for the days it covered. Re-importing a day replaces it.
+func (s *Server) AdminImportProviderBilling(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	format := r.URL.Query().Get("format")
+	if !billingimport.ValidFormat(format) {
+		http.Error(w, "format must be openai or anthropic", http.StatusBadRequest)
+		return
+	}
+	threshold, ok := parseDriftThreshold(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.Store.GetProviderByID(r.Context(), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "provider not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to load provider", http.StatusInternalServerError)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBillingExportBytes)
+	lines, err := billingimport.Parse(r.Body, format)
+	if err != nil {
+		http.Error(w, "invalid billing export: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(lines) == 0 {
+		http.Error(w, "billing export has no rows", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.ImportProviderBilling(r.Context(), id, format, middleware.AdminUsernameFromContext(r.Context()), lines); err != nil {
+		http.Error(w, "failed to import billing", http.StatusInternalServerError)
+		return
+	}
+	// Parse returns lines sorted by day.
+	from, to := lines[0].Day, lines[len(lines)-1].Day.AddDate(0, 0, 1)
+	report, err := s.Store.GetReconciliation(r.Context(), id, from, to, threshold)
+	if err != nil {
+		http.Error(w, "failed to reconcile billing", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"imported": len(lines), "reconciliation": report})
+}
+
+// AdminProviderReconciliation compares imported billing with the provider
+// cost RouterX computed, per day and model. The window defaults to the last
+// 30 days; only days with imported billing are reported.
+func (s *Server) AdminProviderReconciliation(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	threshold, ok := parseDriftThreshold(w, r)
+	if !ok {
+		return
+	}
+	report, err := s.Store.GetReconciliation(r.Context(), chi.URLParam(r, "id"), from, to, threshold)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "provider not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to load reconciliation", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, report)
+}
+
+func parseDriftThreshold(w http.ResponseWriter, r *http.Request) (float64, bool) {
+	v := r.URL.Query().Get("threshold_pct")
+	if v == "" {
+		return defaultDriftThresholdPct, true
+	}
+	pct, err := strconv.ParseFloat(v, 64)
+	if err != nil || pct < 0 {
+		http.Error(w, "invalid threshold_pct", http.StatusBadRequest)
+		return 0, false
+	}
+	return pct, true
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/redact"
+	"routerx/internal/store"
+)
+
+// redactionPolicy loads the tenant's redaction policy and compiles it. Lookup
+// or compile failures fall back to the default policy so stored data is never
+// left unredacted.
+func (s *Server) redactionPolicy(ctx context.Context, tenantID string) (*store.RedactionPolicy, *redact.Redactor) {
+	p, err := s.Store.GetRedactionPolicy(ctx, tenantID)
+	if err != nil {
+		s.Logger.Warn("redaction policy lookup failed", zap.String("tenant_id", tenantID), zap.Error(err))
+		p = store.DefaultRedactionPolicy(tenantID)
+	}
+	rd, err := redact.New(p.Detectors, p.Patterns)
+	if err != nil {
+		s.Logger.Warn("invalid redaction policy", zap.String("tenant_id", tenantID), zap.Error(err))
+		p = store.DefaultRedactionPolicy(tenantID)
+		rd, _ = redact.New(p.Detectors, nil)
+	}
+	return p, rd
+}
+
+// redactTags masks PII in request metadata values.
+func redactTags(tags map[string]string, rd *redact.Redactor) map[string]string {
+	for k, v := range tags {
+		tags[k], _ = rd.String(v)
+	}
+	return tags
+}
+
+// scrubMessages masks PII in the text content of every message in place and
+// returns the number of replacements. Non-text parts are left untouched.
+func scrubMessages(msgs []models.Message, rd *redact.Redactor) int {
+	total := 0
+	for i := range msgs {
+		raw := msgs[i].Content
+		if len(raw) == 0 {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			out, n := rd.String(text)
+			if n > 0 {
+				msgs[i].Content, _ = json.Marshal(out)
+				total += n
+			}
+			continue
+		}
+		var parts []map[string]interface{}
+		if err := json.Unmarshal(raw, &parts); err != nil {
+			continue
+		}
+		changed := 0
+		for _, part := range parts {
+			if t, _ := part["type"].(string); t != "" && t != "text" {
+				continue
+			}
+			if text, ok := part["text"].(string); ok {
+				out, n := rd.String(text)
+				part["text"] = out
+				changed += n
+			}
+		}
+		if changed > 0 {
+			if b, err := json.Marshal(parts); err == nil {
+				msgs[i].Content = b
+				total += changed
+			}
+		}
+	}
+	return total
+}
+
+func (s *Server) AdminGetRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	s.writeRedactionPolicy(w, r, chi.URLParam(r, "id"))
+}
+
+func (s *Server) AdminSetRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	s.setRedactionPolicy(w, r, chi.URLParam(r, "id"))
+}
+
+func (s *Server) TenantRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.writeRedactionPolicy(w, r, user.TenantID)
+}
+
+func (s *Server) TenantSetRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	s.setRedactionPolicy(w, r, user.TenantID)
+}
+
+func (s *Server) writeRedactionPolicy(w http.ResponseWriter, r *http.Request, tenantID string) {
+	p, err := s.Store.GetRedactionPolicy(r.Context(), tenantID)
+	if err != nil {
+		http.Error(w, "failed to load redaction policy", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, p)
+}
+
+func (s *Server) setRedactionPolicy(w http.ResponseWriter, r *http.Request, tenantID string) {
+	var payload struct {
+		Detectors     []string `json:"detectors"`
+		Patterns      []string `json:"patterns"`
+		RedactLogs    bool     `json:"redact_logs"`
+		ScrubUpstream bool     `json:"scrub_upstream"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if _, err := redact.New(payload.Detectors, payload.Patterns); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := s.Store.GetTenantByID(r.Context(), tenantID); err != nil {
+		http.Error(w, "tenant not found", http.StatusNotFound)
+		return
+	}
+	p := store.RedactionPolicy{
+		TenantID:      tenantID,
+		Detectors:     payload.Detectors,
+		Patterns:      payload.Patterns,
+		RedactLogs:    payload.RedactLogs,
+		ScrubUpstream: payload.ScrubUpstream,
+	}
+	if err := s.Store.UpsertRedactionPolicy(r.Context(), p); err != nil {
+		http.Error(w, "failed to save redaction policy", http.StatusInternalServerError)
+		return
+	}
+	s.writeRedactionPolicy(w, r, tenantID)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+
+	"routerx/internal/models"
+)
+
+// requestTiming splits a request's wall time. RoutingMS covers every
+// provider attempt; for streams it is TTFTMS plus StreamMS.
+type requestTiming struct {
+	QueueMS   int64 `json:"queue_ms"`
+	TTFTMS    int64 `json:"ttft_ms"`
+	StreamMS  int64 `json:"stream_ms,omitempty"`
+	RoutingMS int64 `json:"routing_ms"`
+	TotalMS   int64 `json:"total_ms"`
+}
+
+type requestDetail struct {
+	*models.RequestLog
+	Timing requestTiming `json:"timing"`
+}
+
+// AdminRequestDetail returns one request log with its timing breakdown, the
+// providers routing tried (and why each failed) and its trace ID.
+func (s *Server) AdminRequestDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid request id", http.StatusBadRequest)
+		return
+	}
+	log, err := s.Store.GetRequestLog(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "request not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to load request", http.StatusInternalServerError)
+		return
+	}
+	if log.Attempts == nil {
+		log.Attempts = []models.RouteAttempt{}
+	}
+	timing := requestTiming{
+		QueueMS:   log.QueueMS,
+		TTFTMS:    log.TTFTMS,
+		RoutingMS: log.LatencyMS,
+		TotalMS:   log.QueueMS + log.LatencyMS,
+	}
+	if log.Stream && log.LatencyMS > log.TTFTMS {
+		timing.StreamMS = log.LatencyMS - log.TTFTMS
+	}
+	writeJSON(w, requestDetail{RequestLog: log, Timing: timing})
+}
+
+// traceID is the hex ID of the trace ctx belongs to, or "" when untraced.
+func traceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
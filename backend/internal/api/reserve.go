@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/models"
+	"routerx/internal/router"
+	"routerx/internal/store"
+)
+
+// defaultReserveCompletionTokens stands in for max_tokens when a request
+// leaves it unset.
+const defaultReserveCompletionTokens = 1024
+
+// tenantCost is what tokens of model cost a tenant: the model_pricing
+// override, else the list price.
+func (s *Server) tenantCost(ctx context.Context, model string, tokens int) float64 {
+	if price, ok, err := s.Store.GetModelPrice(ctx, model); err == nil && ok {
+		return price * float64(tokens) / 1000.0
+	}
+	return router.EstimateCostUSD(model, tokens)
+}
+
+// reservationEstimate is the most req should cost: its estimated prompt
+// tokens plus the completion tokens it may generate.
+func (s *Server) reservationEstimate(ctx context.Context, req models.ChatCompletionRequest) float64 {
+	completion := req.MaxTokens
+	if req.MaxCompletionTokens > completion {
+		completion = req.MaxCompletionTokens
+	}
+	if completion <= 0 {
+		completion = defaultReserveCompletionTokens
+	}
+	return s.tenantCost(ctx, req.Model, router.EstimatePromptTokens(req)+completion)
+}
+
+// reserveBalance holds req's estimated cost against the tenant's balance
+// until release is called. It writes 402 and returns ok=false when requests
+// already in flight have reserved too much of the balance, or 503 when the
+// reservation cannot be checked: like the limiter it fails closed, since
+// admitting requests unchecked would let concurrent ones overdraw the
+// balance. Reservations are skipped without Redis.
+func (s *Server) reserveBalance(w http.ResponseWriter, r *http.Request, tenant *store.Tenant, req models.ChatCompletionRequest) (release func(), ok bool) {
+	if s.Reservations == nil {
+		return func() {}, true
+	}
+	id := ksuid.New().String()
+	// The balance is re-read here rather than taken from tenant, which was
+	// loaded when the key authenticated and may predate charges since.
+	held, err := s.Reservations.Reserve(r.Context(), tenant.ID, id, s.reservationEstimate(r.Context(), req), func(ctx context.Context) (float64, error) {
+		t, err := s.Store.GetTenantByID(ctx, tenant.ID)
+		if err != nil {
+			return 0, err
+		}
+		return t.AvailableUSD(), nil
+	})
+	if err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, errServer, "reservation_unavailable", "balance reservation unavailable, retry later")
+		return nil, false
+	}
+	if !held {
+		writeAPIError(w, http.StatusPaymentRequired, errInsufficientQuota, "insufficient_quota", "insufficient balance for this request alongside requests in flight")
+		return nil, false
+	}
+	return func() { s.Reservations.Release(context.WithoutCancel(r.Context()), tenant.ID, id) }, true
+}
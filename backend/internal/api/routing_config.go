@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"routerx/internal/router"
+	"routerx/internal/routingconfig"
+)
+
+// maxRoutingConfigBody bounds an applied routing document.
+const maxRoutingConfigBody = 4 << 20
+
+// AdminExportRoutingConfig returns the routing setup as a YAML document.
+// Provider keys are never included.
+func (s *Server) AdminExportRoutingConfig(w http.ResponseWriter, r *http.Request) {
+	doc, err := routingconfig.Export(r.Context(), s.Store)
+	if err != nil {
+		http.Error(w, "failed to export routing config", http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	if err := routingconfig.Encode(&buf, doc); err != nil {
+		http.Error(w, "failed to encode routing config", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="routing.yaml"`)
+	w.Write(buf.Bytes())
+}
+
+// AdminApplyRoutingConfig applies a YAML (or JSON) routing document and
+// reports what changed. ?dry_run=true only reports; ?prune=true also deletes
+// models, pricing and rules the document leaves out.
+func (s *Server) AdminApplyRoutingConfig(w http.ResponseWriter, r *http.Request) {
+	doc, err := routingconfig.Decode(io.LimitReader(r.Body, maxRoutingConfigBody))
+	if err != nil {
+		http.Error(w, "invalid routing config: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := doc.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	prune := r.URL.Query().Get("prune") == "true"
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	changes, err := routingconfig.Apply(r.Context(), s.Store, doc, prune, dryRun)
+	if err != nil {
+		http.Error(w, "failed to apply routing config", http.StatusInternalServerError)
+		return
+	}
+	if !dryRun {
+		s.Router.Notify(r.Context(), router.Change{Kind: router.ChangeAll})
+	}
+	writeJSON(w, map[string]interface{}{"dry_run": dryRun, "prune": prune, "changes": changes})
+}
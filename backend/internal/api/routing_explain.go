@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"routerx/internal/models"
+	"routerx/internal/router"
+)
+
+// AdminExplainRoute dry-runs routing for a hypothetical request and reports
+// every candidate considered, why any were skipped and which provider would
+// serve it. No upstream is called.
+func (s *Server) AdminExplainRoute(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		TenantID       string                       `json:"tenant_id"`
+		Request        models.ChatCompletionRequest `json:"request"`
+		Sort           string                       `json:"sort"`
+		ProviderOnly   []string                     `json:"provider_only"`
+		ProviderIgnore []string                     `json:"provider_ignore"`
+		ProviderOrder  []string                     `json:"provider_order"`
+		AllowFallbacks *bool                        `json:"allow_fallbacks"`
+		PreferProvider string                       `json:"prefer_provider"`
+		Session        string                       `json:"session"`
+		Regions        []string                     `json:"regions"`
+		PreferRegion   string                       `json:"prefer_region"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.TenantID == "" {
+		http.Error(w, "tenant_id required", http.StatusBadRequest)
+		return
+	}
+	if _, err := s.Store.GetTenantByID(r.Context(), payload.TenantID); err != nil {
+		writeTenantError(w, err, "failed to load tenant")
+		return
+	}
+	tags, err := parseRequestMetadata(payload.Request.Metadata)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	policy, err := s.Store.GetRequestPolicy(r.Context(), payload.TenantID)
+	if err != nil {
+		http.Error(w, "failed to load tenant policy", http.StatusInternalServerError)
+		return
+	}
+	opts := router.DefaultRouteOptions()
+	opts.Sort = router.SortMode(payload.Sort)
+	opts.ProviderOnly = payload.ProviderOnly
+	opts.ProviderIgnore = payload.ProviderIgnore
+	opts.ApplyPolicy(policy)
+	opts.ProviderOrder = payload.ProviderOrder
+	if payload.AllowFallbacks != nil {
+		opts.AllowFallbacks = *payload.AllowFallbacks
+	}
+	opts.PreferProvider = payload.PreferProvider
+	opts.SessionKey = payload.Session
+	if opts.SessionKey == "" {
+		opts.SessionKey = payload.Request.User
+	}
+	opts.Tags = tags
+	regions, ok := router.ResidencyRegions(policy.AllowedRegions, router.NormalizeRegions(payload.Regions))
+	if !ok {
+		http.Error(w, "requested regions are outside the tenant's data residency", http.StatusBadRequest)
+		return
+	}
+	opts.Regions = regions
+	opts.PreferRegion = payload.PreferRegion
+	if opts.PreferRegion == "" {
+		opts.PreferRegion = s.Region
+	}
+	writeJSON(w, s.Router.Explain(r.Context(), payload.TenantID, payload.Request, opts))
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"routerx/internal/middleware"
+	"routerx/internal/sessions"
+	"routerx/internal/store"
+)
+
+// AdminSessions lists live admin and tenant sessions, newest first, with
+// the IP and user agent each was issued to and its last activity. Filter
+// with kind (admin or tenant), tenant_id and username.
+func (s *Server) AdminSessions(w http.ResponseWriter, r *http.Request) {
+	if s.Sessions == nil {
+		http.Error(w, "session tracking not enabled", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	list, err := s.Sessions.List(r.Context(), q.Get("tenant_id"))
+	if err != nil {
+		http.Error(w, "failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+	kind, username := q.Get("kind"), q.Get("username")
+	current := middleware.SessionIDFromContext(r.Context())
+	out := make([]sessions.Session, 0, len(list))
+	for _, sess := range list {
+		if (kind != "" && sess.Kind != kind) || (username != "" && sess.Username != username) {
+			continue
+		}
+		sess.Current = sess.ID == current
+		out = append(out, sess)
+	}
+	writeJSON(w, out)
+}
+
+// AdminRevokeSession ends any admin or tenant session.
+func (s *Server) AdminRevokeSession(w http.ResponseWriter, r *http.Request) {
+	if s.Sessions == nil {
+		http.Error(w, "session tracking not enabled", http.StatusNotFound)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if err := s.Sessions.Revoke(r.Context(), id); err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "revoked", "id": id})
+}
+
+// TenantSessions lists the caller's live sessions; owners see every
+// member's.
+func (s *Server) TenantSessions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	if s.Sessions == nil {
+		writeJSON(w, []sessions.Session{})
+		return
+	}
+	list, err := s.Sessions.List(r.Context(), user.TenantID)
+	if err != nil {
+		http.Error(w, "failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+	current := middleware.SessionIDFromContext(r.Context())
+	out := make([]sessions.Session, 0, len(list))
+	for _, sess := range list {
+		if user.Role != store.RoleOwner && sess.Username != user.Username {
+			continue
+		}
+		sess.Current = sess.ID == current
+		out = append(out, sess)
+	}
+	writeJSON(w, out)
+}
+
+// TenantRevokeSession ends one of the caller's sessions or, for owners, any
+// member's. Revoking the current session logs the caller out.
+func (s *Server) TenantRevokeSession(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	if s.Sessions == nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	sess, err := s.Sessions.Get(r.Context(), id)
+	if err == nil && (sess.TenantID != user.TenantID || (user.Role != store.RoleOwner && sess.Username != user.Username)) {
+		err = sessions.ErrNotFound
+	}
+	if err == nil {
+		err = s.Sessions.Revoke(r.Context(), id)
+	}
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "revoked", "id": id})
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/segmentio/ksuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+)
+
+// ErrInvalidSetup wraps problems with a SetupRequest.
+var ErrInvalidSetup = errors.New("invalid setup request")
+
+// SetupRequest names the first admin and demo tenant; empty fields take
+// defaults and empty passwords are generated.
+type SetupRequest struct {
+	AdminUsername string `json:"admin_username"`
+	AdminPassword string `json:"admin_password"`
+	TenantName    string `json:"tenant_name"`
+	OwnerUsername string `json:"owner_username"`
+	OwnerPassword string `json:"owner_password"`
+}
+
+// SetupResult carries the credentials created by Setup. Passwords appear
+// only when they were generated; this is the only time they are shown.
+type SetupResult struct {
+	AdminUsername string `json:"admin_username"`
+	AdminPassword string `json:"admin_password,omitempty"`
+	TenantID      string `json:"tenant_id"`
+	TenantName    string `json:"tenant_name"`
+	OwnerUsername string `json:"owner_username"`
+	OwnerPassword string `json:"owner_password,omitempty"`
+	APIKey        string `json:"api_key"`
+}
+
+// Setup creates the first admin user, a demo tenant with its owner, an
+// opening balance of balanceUSD and an API key on a database without admin
+// users. It returns store.ErrAlreadySetUp once an admin exists.
+func Setup(ctx context.Context, st Store, req SetupRequest, balanceUSD float64) (SetupResult, error) {
+	if req.AdminUsername == "" {
+		req.AdminUsername = "admin"
+	}
+	if req.TenantName == "" {
+		req.TenantName = "Demo Tenant"
+	}
+	if req.OwnerUsername == "" {
+		req.OwnerUsername = "demo"
+	}
+	// Login tries admins before tenant users, so a shared name would lock
+	// the owner out.
+	if req.AdminUsername == req.OwnerUsername {
+		return SetupResult{}, fmt.Errorf("%w: admin and owner usernames must differ", ErrInvalidSetup)
+	}
+	if balanceUSD < 0 {
+		return SetupResult{}, fmt.Errorf("%w: balance must not be negative", ErrInvalidSetup)
+	}
+	res := SetupResult{
+		AdminUsername: req.AdminUsername,
+		TenantID:      ksuid.New().String(),
+		TenantName:    req.TenantName,
+		OwnerUsername: req.OwnerUsername,
+		APIKey:        "user_key_" + ksuid.New().String(),
+	}
+	adminPassword, ownerPassword := req.AdminPassword, req.OwnerPassword
+	var err error
+	if adminPassword == "" {
+		if adminPassword, err = randomPassword(); err != nil {
+			return SetupResult{}, err
+		}
+		res.AdminPassword = adminPassword
+	}
+	if ownerPassword == "" {
+		if ownerPassword, err = randomPassword(); err != nil {
+			return SetupResult{}, err
+		}
+		res.OwnerPassword = ownerPassword
+	}
+	adminHash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return SetupResult{}, err
+	}
+	ownerHash, err := bcrypt.GenerateFromPassword([]byte(ownerPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return SetupResult{}, err
+	}
+	err = st.Bootstrap(ctx,
+		store.AdminUser{ID: ksuid.New().String(), Username: res.AdminUsername, PasswordHash: string(adminHash)},
+		store.Tenant{ID: res.TenantID, Name: res.TenantName, BalanceUSD: balanceUSD},
+		store.TenantUser{ID: ksuid.New().String(), Username: res.OwnerUsername, PasswordHash: string(ownerHash)},
+		store.APIKey{Key: res.APIKey, Name: "Default", CreatedBy: "setup"},
+	)
+	return res, err
+}
+
+// NewSetupToken returns a random token for POST /setup, for installs that
+// do not configure one.
+func NewSetupToken() (string, error) {
+	return randomPassword()
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SetupStatus reports whether the install still needs its first admin.
+func (s *Server) SetupStatus(w http.ResponseWriter, r *http.Request) {
+	needed, err := s.Store.NeedsSetup(r.Context())
+	if err != nil {
+		http.Error(w, "failed to check setup state", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"needs_setup": needed, "token_required": s.SetupToken != ""})
+}
+
+// CompleteSetup is the one-time first-run flow: on a database without admin
+// users it creates the first admin, a demo tenant and an API key and returns
+// their credentials once. Afterwards it answers 409. The request must carry
+// the setup token in X-Setup-Token; without one setup is refused.
+func (s *Server) CompleteSetup(w http.ResponseWriter, r *http.Request) {
+	if s.SetupToken == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Setup-Token")), []byte(s.SetupToken)) != 1 {
+		http.Error(w, "invalid setup token", http.StatusUnauthorized)
+		return
+	}
+	var payload SetupRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+	payload.AdminUsername = strings.TrimSpace(payload.AdminUsername)
+	payload.OwnerUsername = strings.TrimSpace(payload.OwnerUsername)
+	payload.TenantName = strings.TrimSpace(payload.TenantName)
+	res, err := Setup(r.Context(), s.Store, payload, 0)
+	switch {
+	case errors.Is(err, store.ErrAlreadySetUp):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, store.ErrUsernameTaken):
+		http.Error(w, "owner username already taken", http.StatusConflict)
+		return
+	case errors.Is(err, ErrInvalidSetup):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "setup failed", http.StatusInternalServerError)
+		return
+	}
+	_ = s.Store.InsertAuditEntry(r.Context(), store.AuditEntry{
+		ActorType: store.ActorAdmin, Actor: res.AdminUsername, TenantID: res.TenantID,
+		Action: "POST /setup", TargetType: "tenant", TargetID: res.TenantID, IP: middleware.ClientIP(r), StatusCode: http.StatusCreated,
+	})
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(res)
+}
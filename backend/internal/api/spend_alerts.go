@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+)
+
+// TenantSpendAlerts lists the tenant's spend alerts.
+func (s *Server) TenantSpendAlerts(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	alerts, err := s.Store.ListTenantSpendAlerts(r.Context(), user.TenantID)
+	if err != nil {
+		http.Error(w, "failed to list alerts", http.StatusInternalServerError)
+		return
+	}
+	if alerts == nil {
+		alerts = []store.TenantSpendAlert{}
+	}
+	writeJSON(w, alerts)
+}
+
+func (s *Server) TenantCreateSpendAlert(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	alert, ok := decodeSpendAlert(w, r)
+	if !ok {
+		return
+	}
+	alert.ID = ksuid.New().String()
+	alert.TenantID = user.TenantID
+	if err := s.Store.CreateTenantSpendAlert(r.Context(), alert); err != nil {
+		http.Error(w, "failed to create alert", http.StatusInternalServerError)
+		return
+	}
+	s.writeSpendAlert(w, r, user.TenantID, alert.ID)
+}
+
+func (s *Server) TenantUpdateSpendAlert(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	alert, ok := decodeSpendAlert(w, r)
+	if !ok {
+		return
+	}
+	alert.ID = chi.URLParam(r, "id")
+	alert.TenantID = user.TenantID
+	if err := s.Store.UpdateTenantSpendAlert(r.Context(), alert); err != nil {
+		writeSpendAlertError(w, err, "failed to update alert")
+		return
+	}
+	s.writeSpendAlert(w, r, user.TenantID, alert.ID)
+}
+
+func (s *Server) TenantDeleteSpendAlert(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	if err := s.Store.DeleteTenantSpendAlert(r.Context(), user.TenantID, chi.URLParam(r, "id")); err != nil {
+		writeSpendAlertError(w, err, "failed to delete alert")
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+func (s *Server) writeSpendAlert(w http.ResponseWriter, r *http.Request, tenantID, id string) {
+	alert, err := s.Store.GetTenantSpendAlert(r.Context(), tenantID, id)
+	if err != nil {
+		writeSpendAlertError(w, err, "failed to load alert")
+		return
+	}
+	writeJSON(w, alert)
+}
+
+func decodeSpendAlert(w http.ResponseWriter, r *http.Request) (store.TenantSpendAlert, bool) {
+	payload := store.TenantSpendAlert{Kind: store.SpendAlertAbsolute, Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return store.TenantSpendAlert{}, false
+	}
+	if payload.Period != store.SpendPeriodDaily && payload.Period != store.SpendPeriodMonthly {
+		http.Error(w, "period must be daily or monthly", http.StatusBadRequest)
+		return store.TenantSpendAlert{}, false
+	}
+	switch payload.Kind {
+	case store.SpendAlertAbsolute:
+		if payload.Threshold <= 0 {
+			http.Error(w, "threshold must be a positive USD amount", http.StatusBadRequest)
+			return store.TenantSpendAlert{}, false
+		}
+	case store.SpendAlertBalancePercent:
+		if payload.Threshold <= 0 || payload.Threshold > 100 {
+			http.Error(w, "threshold must be a percentage between 0 and 100", http.StatusBadRequest)
+			return store.TenantSpendAlert{}, false
+		}
+	default:
+		http.Error(w, "kind must be absolute or balance_percent", http.StatusBadRequest)
+		return store.TenantSpendAlert{}, false
+	}
+	switch payload.Channel {
+	case store.SpendAlertChannelEmail:
+		if _, err := mail.ParseAddress(payload.Target); err != nil {
+			http.Error(w, "target must be an email address", http.StatusBadRequest)
+			return store.TenantSpendAlert{}, false
+		}
+	case store.SpendAlertChannelWebhook:
+		payload.Target = ""
+	default:
+		http.Error(w, "channel must be email or webhook", http.StatusBadRequest)
+		return store.TenantSpendAlert{}, false
+	}
+	return payload, true
+}
+
+func writeSpendAlertError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "alert not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
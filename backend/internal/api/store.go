@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"routerx/internal/models"
+	"routerx/internal/store"
+)
+
+// Store is the data access the handlers need. *store.Store implements it;
+// storemock.Store stands in for it in unit tests.
+type Store interface {
+	AcceptInvitation(ctx context.Context, tokenHash string, u store.TenantUser) (*store.Invitation, error)
+	ActiveMaintenanceEnds(ctx context.Context) (map[string]time.Time, error)
+	AddModelCatalog(ctx context.Context, m store.ModelCatalog) error
+	AddTenantTopup(ctx context.Context, tenantID string, amount float64) error
+	AddUsageCost(ctx context.Context, tenantID string, provider string, model string, tokens int, cost float64, day time.Time) error
+	ApplyRoutingConfig(ctx context.Context, c store.RoutingConfig, prune bool) error
+	ArchiveModel(ctx context.Context, model string, actor string) error
+	ArchiveProvider(ctx context.Context, id string, actor string) error
+	Bootstrap(ctx context.Context, admin store.AdminUser, t store.Tenant, owner store.TenantUser, key store.APIKey) error
+	CancelBatch(ctx context.Context, tenantID string, id string) error
+	ChargeTenant(ctx context.Context, tenantID string, amount float64) (store.Charge, error)
+	ClaimTOTPAttempt(ctx context.Context, actorType string, userID string, limit int, lockout time.Duration) (bool, error)
+	ClearStagedProviderAPIKey(ctx context.Context, id string) error
+	CountProviderEvents(ctx context.Context, f store.ProviderEventFilters) ([]store.ProviderEventCount, error)
+	CreateAPIKey(ctx context.Context, k store.APIKey) error
+	CreateAlertRule(ctx context.Context, a store.AlertRule) error
+	CreateBatch(ctx context.Context, b store.Batch, items []store.BatchItem) error
+	CreateInvitation(ctx context.Context, inv store.Invitation) error
+	CreateMaintenanceWindow(ctx context.Context, w store.MaintenanceWindow) (store.MaintenanceWindow, error)
+	CreatePromptTemplate(ctx context.Context, t store.PromptTemplate) error
+	CreateTenant(ctx context.Context, t store.Tenant) error
+	CreateTenantAccount(ctx context.Context, t store.Tenant, owner store.TenantUser, key *store.APIKey) error
+	CreateTenantSpendAlert(ctx context.Context, a store.TenantSpendAlert) error
+	CreateTenantUser(ctx context.Context, u store.TenantUser) error
+	CreateWebhook(ctx context.Context, h store.Webhook) (int, error)
+	DeleteAPIKey(ctx context.Context, tenantID string, key string) error
+	DeleteAlertRule(ctx context.Context, id string) error
+	DeleteExchangeRate(ctx context.Context, currency string) error
+	DeleteExperiment(ctx context.Context, id string) error
+	DeleteInvitation(ctx context.Context, tenantID string, id string) error
+	DeleteMaintenanceWindow(ctx context.Context, providerID string, id int64) error
+	DeletePromptTemplate(ctx context.Context, tenantID string, id string) error
+	DeleteRequestLog(ctx context.Context, id int) error
+	DeleteRoutingRule(ctx context.Context, id string) error
+	DeleteTenant(ctx context.Context, id string, actor string) (*store.TenantArchive, error)
+	DeleteTenantMember(ctx context.Context, tenantID string, userID string) error
+	DeleteTenantSpendAlert(ctx context.Context, tenantID string, id string) error
+	DeleteTenantWebhook(ctx context.Context, tenantID string, id int) error
+	DeleteWebhook(ctx context.Context, id int) error
+	DisableTOTP(ctx context.Context, actorType string, userID string) error
+	EachDailyUsage(ctx context.Context, tenantID string, from time.Time, to time.Time, fn func(store.DailyUsage) error) error
+	EachTransaction(ctx context.Context, tenantID string, fn func(store.BalanceTransaction) error) error
+	EachRequestLog(ctx context.Context, tenantID string, from time.Time, to time.Time, fn func(models.RequestLog) error) error
+	EnableTOTP(ctx context.Context, actorType string, userID string, backupHashes []string) error
+	EraseTenant(ctx context.Context, id string, actor string) (*store.TenantArchive, error)
+	FreeUsageOn(ctx context.Context, tenantID string, model string, day time.Time) (int64, int64, error)
+	GetAPIKey(ctx context.Context, key string) (*store.APIKey, error)
+	GetAdminByUsername(ctx context.Context, username string) (*store.AdminUser, error)
+	GetAdminDashboardStats(ctx context.Context, w store.UsageWindow) (*store.AdminDashboardStats, error)
+	GetAlertRule(ctx context.Context, id string) (*store.AlertRule, error)
+	GetBatch(ctx context.Context, tenantID string, id string) (*store.Batch, error)
+	GetEnabledProvidersByType(ctx context.Context, providerType string) ([]store.Provider, error)
+	GetExchangeRate(ctx context.Context, currency string) (*store.ExchangeRate, error)
+	GetExperiment(ctx context.Context, id string) (*store.Experiment, error)
+	GetExperimentResults(ctx context.Context, id string) ([]store.ExperimentArmStats, error)
+	GetJob(ctx context.Context, id string) (*store.Job, error)
+	GetMarginReport(ctx context.Context, from time.Time, to time.Time) (*store.MarginReport, error)
+	GetModelFreeAllowance(ctx context.Context, model string) (store.FreeAllowance, error)
+	GetModelPrice(ctx context.Context, model string) (float64, bool, error)
+	GetModelProvider(ctx context.Context, model string) (string, bool, error)
+	GetModerationPolicy(ctx context.Context, tenantID string) (*store.ModerationPolicy, error)
+	GetPromptTemplate(ctx context.Context, tenantID string, id string) (*store.PromptTemplate, error)
+	GetPromptTemplateByName(ctx context.Context, tenantID string, name string) (*store.PromptTemplate, error)
+	GetProviderByID(ctx context.Context, id string) (*store.Provider, error)
+	GetRedactionPolicy(ctx context.Context, tenantID string) (*store.RedactionPolicy, error)
+	GetReconciliation(ctx context.Context, providerID string, from, to time.Time, thresholdPct float64) (*store.ReconciliationReport, error)
+	GetRequestLog(ctx context.Context, id int) (*models.RequestLog, error)
+	GetAttemptFailures(ctx context.Context, from, to time.Time, provider, errorClass string) ([]store.AttemptFailure, error)
+	GetRequestPolicy(ctx context.Context, tenantID string) (*store.RequestPolicy, error)
+	GetRoutingConfig(ctx context.Context) (store.RoutingConfig, error)
+	GetRoutingRuleByID(ctx context.Context, id string) (*store.RoutingRule, error)
+	GetStagedProviderAPIKey(ctx context.Context, id string) (string, error)
+	GetTenantAnalytics(ctx context.Context, tenantID string, from time.Time, to time.Time, topN int) (*store.TenantAnalytics, error)
+	GetTenantByAPIKey(ctx context.Context, key string) (*store.Tenant, error)
+	GetTenantByID(ctx context.Context, id string) (*store.Tenant, error)
+	GetTenantCurrency(ctx context.Context, tenantID string) (string, float64, error)
+	GetTenantRequestSummary(ctx context.Context, tenantID string, w store.UsageWindow) (*store.TenantRequestSummary, error)
+	GetTenantSpendAlert(ctx context.Context, tenantID string, id string) (*store.TenantSpendAlert, error)
+	GetTenantUserByUsername(ctx context.Context, username string) (*store.TenantUser, error)
+	GetUsageByTag(ctx context.Context, tenantID string, groupBy string, from time.Time, to time.Time) ([]store.TagUsage, error)
+	GetWebhook(ctx context.Context, id int) (*store.Webhook, error)
+	GetWebhookDelivery(ctx context.Context, id string) (*store.WebhookDelivery, error)
+	GrantCredits(ctx context.Context, g store.CreditGrant) error
+	ImportProviderBilling(ctx context.Context, providerID, source, actor string, lines []store.ProviderBillingLine) error
+	InsertAuditEntry(ctx context.Context, e store.AuditEntry) error
+	InsertModerationEvent(ctx context.Context, e store.ModerationEvent) error
+	InsertRequestLog(ctx context.Context, log models.RequestLog) error
+	JobCounts(ctx context.Context) ([]store.JobCount, error)
+	ListAPIKeysByTenant(ctx context.Context, tenantID string) ([]store.APIKey, error)
+	ListAlertEvents(ctx context.Context, ruleID string, limit int) ([]store.AlertEvent, error)
+	ListAlertRules(ctx context.Context) ([]store.AlertRule, error)
+	ListAllModels(ctx context.Context) ([]store.ModelInfo, error)
+	ListCreditGrants(ctx context.Context, tenantID string) ([]store.CreditGrant, error)
+	ListExchangeRates(ctx context.Context) ([]store.ExchangeRate, error)
+	ListModelSLOs(ctx context.Context) ([]store.ModelSLO, error)
+	ListArchivedModels(ctx context.Context) ([]store.ArchivedModel, error)
+	ListAuditLog(ctx context.Context, f store.AuditFilters) ([]store.AuditEntry, error)
+	ListBatchResults(ctx context.Context, batchID string) ([]store.BatchItem, error)
+	ListBatches(ctx context.Context, tenantID string, limit int) ([]store.Batch, error)
+	ListExperiments(ctx context.Context, tenantID string) ([]store.Experiment, error)
+	ListFreeAllowances(ctx context.Context) ([]store.FreeAllowance, error)
+	ListJobs(ctx context.Context, f store.JobFilters) ([]store.Job, error)
+	ListMaintenanceWindows(ctx context.Context, providerID string) ([]store.MaintenanceWindow, error)
+	ListModelPricing(ctx context.Context) ([]store.ModelPricing, error)
+	ListModelProviderEntries(ctx context.Context, model string) ([]store.ModelProviderEntry, error)
+	ListModelUsage(ctx context.Context) ([]store.ModelUsageSummary, error)
+	ListModelsByProviderType(ctx context.Context, providerType string) ([]string, error)
+	ListModerationEvents(ctx context.Context, tenantID string, from time.Time, to time.Time, limit int) ([]store.ModerationEvent, error)
+	ListPendingInvitations(ctx context.Context, tenantID string) ([]store.Invitation, error)
+	ListPromptTemplates(ctx context.Context, tenantID string) ([]store.PromptTemplate, error)
+	ListProviderEvents(ctx context.Context, f store.ProviderEventFilters) ([]store.ProviderEvent, error)
+	ListProviders(ctx context.Context) ([]store.Provider, error)
+	ListRequestLogs(ctx context.Context, limit int) ([]models.RequestLog, error)
+	ListRequestLogsPaginated(ctx context.Context, page int, pageSize int, f store.RequestLogFilters) (*store.PaginatedRequestLogs, error)
+	ListRoutingRules(ctx context.Context) ([]store.RoutingRule, error)
+	ListRoutingRulesByTenant(ctx context.Context, tenantID string) ([]store.RoutingRule, error)
+	ListTenantArchives(ctx context.Context, limit int) ([]store.TenantArchive, error)
+	ListTenantMembers(ctx context.Context, tenantID string) ([]store.TenantMember, error)
+	ListTenantSpendAlerts(ctx context.Context, tenantID string) ([]store.TenantSpendAlert, error)
+	ListTenantUsage(ctx context.Context, tenantID string, w store.UsageWindow, limit int) ([]store.DailyUsage, error)
+	ListTenantWebhooks(ctx context.Context, tenantID string) ([]store.Webhook, error)
+	ListTenantsPaginated(ctx context.Context, page int, pageSize int, f store.TenantListFilters) (*store.PaginatedTenants, error)
+	ListTransactions(ctx context.Context, tenantID string, limit int) ([]store.BalanceTransaction, error)
+	ListTransactionsPaginated(ctx context.Context, tenantID string, page int, pageSize int, f store.TransactionFilters) (*store.PaginatedTransactions, error)
+	ListWebhookDeliveries(ctx context.Context, f store.WebhookDeliveryFilters) ([]store.WebhookDelivery, error)
+	ListWebhooks(ctx context.Context) ([]store.Webhook, error)
+	MergeTenants(ctx context.Context, sourceID string, targetID string, actor string) (*store.TenantArchive, error)
+	NeedsSetup(ctx context.Context) (bool, error)
+	PromoteStagedProviderAPIKey(ctx context.Context, id string) error
+	RecordCacheLookup(ctx context.Context, hit bool, at time.Time) error
+	RecordFreeUsage(ctx context.Context, tenantID string, provider string, model string, tokens int, day time.Time) error
+	RecordTransaction(ctx context.Context, tenantID string, txType string, amount float64, balanceAfter float64, description string) error
+	ReleaseBalanceSuspensions(ctx context.Context, tenantID string) ([]string, error)
+	RenameTenant(ctx context.Context, id string, name string) error
+	ReplaceTOTPBackupCodes(ctx context.Context, actorType string, userID string, backupHashes []string) error
+	ResetTOTPAttempts(ctx context.Context, actorType string, userID string) error
+	RestoreModel(ctx context.Context, model string) error
+	RestoreProvider(ctx context.Context, id string) error
+	RetryJob(ctx context.Context, id string) error
+	RevokeAPIKey(ctx context.Context, key string, revokedBy string, reason string) (*store.APIKey, error)
+	RevokeCreditGrant(ctx context.Context, tenantID string, id string) error
+	RollbackProviderAPIKey(ctx context.Context, id string) error
+	SearchAPIKeys(ctx context.Context, f store.APIKeyFilters) ([]store.APIKey, error)
+	SetAPIKeySigningSecret(ctx context.Context, tenantID string, key string, keyID string, secret string) error
+	SetExchangeRate(ctx context.Context, currency string, perUSD float64) error
+	SetModelProviderEntries(ctx context.Context, model string, entries []store.ModelProviderEntry) error
+	SetPendingTOTPSecret(ctx context.Context, actorType string, userID string, secret string) error
+	SetProviderCircuitSettings(ctx context.Context, id string, c store.CircuitSettings) error
+	SetProviderMaintenance(ctx context.Context, id string, on bool) error
+	SetProviderNetwork(ctx context.Context, id string, n store.NetworkSettings) error
+	SetProviderRateBudget(ctx context.Context, id string, rpm int, tpm int) error
+	SetTenantCurrency(ctx context.Context, tenantID string, currency string) error
+	SetTenantOverdraft(ctx context.Context, tenantID string, overdraftUSD float64) error
+	SetTenantRequire2FA(ctx context.Context, tenantID string, required bool) error
+	StageProviderAPIKey(ctx context.Context, id string, apiKey string) error
+	SuspendTenant(ctx context.Context, tenantID string, suspended bool) error
+	TenantRequires2FA(ctx context.Context, tenantID string) (bool, error)
+	TenantSpendOn(ctx context.Context, tenantID string, day time.Time) (float64, error)
+	UpdateAlertRule(ctx context.Context, a store.AlertRule) error
+	UpdatePromptTemplate(ctx context.Context, t store.PromptTemplate) error
+	UpdateProvider(ctx context.Context, p store.Provider) error
+	UpdateProviderAPIKey(ctx context.Context, id string, apiKey string) error
+	UpdateTenantBalance(ctx context.Context, tenantID string, balance float64) error
+	UpdateTenantLimits(ctx context.Context, tenantID string, rateLimitRPM int, spendLimitUSD float64) error
+	UpdateTenantMemberRole(ctx context.Context, tenantID string, userID string, role string) error
+	UpdateTenantSpendAlert(ctx context.Context, a store.TenantSpendAlert) error
+	UpdateTenantTier(ctx context.Context, tenantID string, tier string) error
+	UpdateWebhook(ctx context.Context, h store.Webhook) error
+	UpsertExperiment(ctx context.Context, e store.Experiment) error
+	UpsertModelPricing(ctx context.Context, m store.ModelPricing) error
+	UpsertModerationPolicy(ctx context.Context, p store.ModerationPolicy) error
+	UpsertProvider(ctx context.Context, p store.Provider) error
+	UpsertRedactionPolicy(ctx context.Context, p store.RedactionPolicy) error
+	UpsertRequestPolicy(ctx context.Context, p store.RequestPolicy) error
+	UpsertRoutingRule(ctx context.Context, r store.RoutingRule) error
+	UseTOTPBackupCode(ctx context.Context, actorType string, userID string, codeHash string) (bool, error)
+	UseTOTPStep(ctx context.Context, actorType string, userID string, step int64) (bool, error)
+}
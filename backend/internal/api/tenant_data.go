@@ -0,0 +1,140 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"routerx/internal/middleware"
+	"routerx/internal/models"
+	"routerx/internal/store"
+)
+
+// AdminExportTenant streams everything stored about a tenant as a zip archive
+// for a data-subject access request: its profile, members, API keys (masked),
+// credit grants, ledger, daily usage and request logs. Exports are reads, so
+// the audit middleware skips them; this handler records its own entry.
+func (s *Server) AdminExportTenant(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	tenant, err := s.Store.GetTenantByID(r.Context(), id)
+	if err != nil {
+		writeTenantError(w, err, "failed to load tenant")
+		return
+	}
+	members, err := s.Store.ListTenantMembers(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to export tenant", http.StatusInternalServerError)
+		return
+	}
+	keys, err := s.Store.ListAPIKeysByTenant(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to export tenant", http.StatusInternalServerError)
+		return
+	}
+	for i := range keys {
+		keys[i].Key = maskKey(keys[i].Key)
+	}
+	grants, err := s.Store.ListCreditGrants(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to export tenant", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=tenant_%s_%s.zip", id, now.Format("20060102")))
+	zw := zip.NewWriter(w)
+	err = writeZipJSON(zw, "tenant.json", map[string]interface{}{"tenant": tenant, "exported_at": now})
+	if err == nil {
+		err = writeZipJSON(zw, "members.json", members)
+	}
+	if err == nil {
+		err = writeZipJSON(zw, "api_keys.json", keys)
+	}
+	if err == nil {
+		err = writeZipJSON(zw, "credit_grants.json", grants)
+	}
+	if err == nil {
+		err = writeZipNDJSON(zw, "transactions.ndjson", func(enc *json.Encoder) error {
+			return s.Store.EachTransaction(r.Context(), id, func(tx store.BalanceTransaction) error { return enc.Encode(tx) })
+		})
+	}
+	if err == nil {
+		err = writeZipNDJSON(zw, "usage_daily.ndjson", func(enc *json.Encoder) error {
+			return s.Store.EachDailyUsage(r.Context(), id, time.Time{}, now.AddDate(0, 0, 1), func(u store.DailyUsage) error { return enc.Encode(u) })
+		})
+	}
+	if err == nil {
+		err = writeZipNDJSON(zw, "request_logs.ndjson", func(enc *json.Encoder) error {
+			return s.Store.EachRequestLog(r.Context(), id, time.Time{}, now.Add(time.Second), func(l models.RequestLog) error { return enc.Encode(l) })
+		})
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		// The archive is already streaming; a truncated zip fails to open.
+		s.Logger.Warn("tenant export failed", zap.String("tenant_id", id), zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+	defer cancel()
+	_ = s.Store.InsertAuditEntry(ctx, store.AuditEntry{
+		ActorType:  store.ActorAdmin,
+		Actor:      middleware.AdminUsernameFromContext(r.Context()),
+		TenantID:   id,
+		Action:     r.Method + " " + chi.RouteContext(r.Context()).RoutePattern(),
+		TargetType: "tenant",
+		TargetID:   id,
+		IP:         middleware.ClientIP(r),
+		StatusCode: http.StatusOK,
+	})
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeZipNDJSON(zw *zip.Writer, name string, each func(*json.Encoder) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return each(json.NewEncoder(f))
+}
+
+// AdminEraseTenant deletes a tenant on a data-subject erasure request,
+// leaving only an anonymized archive; see store.EraseTenant. The body must
+// repeat the tenant id as "confirm".
+func (s *Server) AdminEraseTenant(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var payload struct {
+		Confirm string `json:"confirm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.Confirm != id {
+		http.Error(w, "confirm must repeat the tenant id", http.StatusBadRequest)
+		return
+	}
+	archive, err := s.Store.EraseTenant(r.Context(), id, middleware.AdminUsernameFromContext(r.Context()))
+	if err != nil {
+		writeTenantError(w, err, "failed to erase tenant")
+		return
+	}
+	writeJSON(w, archive)
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/segmentio/ksuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+)
+
+// AdminCreateTenant creates a tenant with its owner account and, optionally,
+// a first API key. The key is only returned in this response.
+func (s *Server) AdminCreateTenant(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ID         string  `json:"id"`
+		Name       string  `json:"name"`
+		BalanceUSD float64 `json:"balance_usd"`
+		Tier       string  `json:"tier"`
+		Owner      struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Email    string `json:"email"`
+		} `json:"owner"`
+		CreateAPIKey bool   `json:"create_api_key"`
+		APIKeyName   string `json:"api_key_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return
+	}
+	if payload.Owner.Username == "" || payload.Owner.Password == "" {
+		http.Error(w, "owner username and password required", http.StatusBadRequest)
+		return
+	}
+	if payload.BalanceUSD < 0 {
+		http.Error(w, "balance_usd must not be negative", http.StatusBadRequest)
+		return
+	}
+	if payload.Tier != "" && !store.ValidTier(payload.Tier) {
+		http.Error(w, "tier must be free, standard or premium", http.StatusBadRequest)
+		return
+	}
+	if payload.ID == "" {
+		payload.ID = ksuid.New().String()
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(payload.Owner.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "failed to create tenant", http.StatusInternalServerError)
+		return
+	}
+	tenant := store.Tenant{ID: payload.ID, Name: payload.Name, BalanceUSD: payload.BalanceUSD, Tier: payload.Tier}
+	owner := store.TenantUser{ID: ksuid.New().String(), Username: payload.Owner.Username, PasswordHash: string(hash), Email: payload.Owner.Email}
+	var key *store.APIKey
+	if payload.CreateAPIKey {
+		key = &store.APIKey{Key: "user_key_" + ksuid.New().String(), Name: payload.APIKeyName, CreatedBy: middleware.AdminUsernameFromContext(r.Context())}
+	}
+	if err := s.Store.CreateTenantAccount(r.Context(), tenant, owner, key); err != nil {
+		switch {
+		case errors.Is(err, store.ErrTenantExists), errors.Is(err, store.ErrUsernameTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "failed to create tenant", http.StatusInternalServerError)
+		}
+		return
+	}
+	created, err := s.Store.GetTenantByID(r.Context(), payload.ID)
+	if err != nil {
+		http.Error(w, "failed to load tenant", http.StatusInternalServerError)
+		return
+	}
+	res := map[string]interface{}{"tenant": created, "owner": map[string]string{"id": owner.ID, "username": owner.Username, "email": owner.Email}}
+	if key != nil {
+		res["api_key"] = key.Key
+	}
+	writeJSON(w, res)
+}
+
+// AdminUpdateTenant renames a tenant.
+func (s *Server) AdminUpdateTenant(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if err := s.Store.RenameTenant(r.Context(), id, payload.Name); err != nil {
+		writeTenantError(w, err, "failed to rename tenant")
+		return
+	}
+	tenant, err := s.Store.GetTenantByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load tenant", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, tenant)
+}
+
+// AdminDeleteTenant deletes a tenant and everything it owns, keeping an
+// archived summary of its members, keys, usage and spend.
+func (s *Server) AdminDeleteTenant(w http.ResponseWriter, r *http.Request) {
+	archive, err := s.Store.DeleteTenant(r.Context(), chi.URLParam(r, "id"), middleware.AdminUsernameFromContext(r.Context()))
+	if err != nil {
+		writeTenantError(w, err, "failed to delete tenant")
+		return
+	}
+	writeJSON(w, archive)
+}
+
+// AdminMergeTenant folds the tenant into another one ("into"), moving its
+// keys, members, logs, usage and balance, then deletes it.
+func (s *Server) AdminMergeTenant(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Into string `json:"into"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.Into == "" {
+		http.Error(w, "into required", http.StatusBadRequest)
+		return
+	}
+	archive, err := s.Store.MergeTenants(r.Context(), chi.URLParam(r, "id"), payload.Into, middleware.AdminUsernameFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, store.ErrMergeSameTenant) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeTenantError(w, err, "failed to merge tenant")
+		return
+	}
+	target, err := s.Store.GetTenantByID(r.Context(), payload.Into)
+	if err != nil {
+		http.Error(w, "failed to load tenant", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"tenant": target, "archive": archive})
+}
+
+func (s *Server) AdminTenantArchives(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	archives, err := s.Store.ListTenantArchives(r.Context(), limit)
+	if err != nil {
+		http.Error(w, "failed to list tenant archives", http.StatusInternalServerError)
+		return
+	}
+	if archives == nil {
+		archives = []store.TenantArchive{}
+	}
+	writeJSON(w, archives)
+}
+
+func writeTenantError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "tenant not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
@@ -0,0 +1,432 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"routerx/internal/middleware"
+	"routerx/internal/sessions"
+	"routerx/internal/store"
+	"routerx/internal/totp"
+	"routerx/internal/util"
+)
+
+const (
+	totpIssuer      = "RouterX"
+	backupCodeCount = 10
+	sessionTTL      = 8 * time.Hour
+
+	// maxOTPAttempts second-factor codes may be tried per account before
+	// checks are locked for otpLockout; a success starts the count again.
+	maxOTPAttempts = 5
+	otpLockout     = 15 * time.Minute
+)
+
+var (
+	errInvalidOTP = errors.New("invalid otp")
+	errOTPLocked  = errors.New("too many otp attempts, try again later")
+)
+
+// twoFactorAccount is the part of an admin or tenant user that two-factor
+// authentication needs.
+type twoFactorAccount struct {
+	ActorType string
+	ID        string
+	Username  string
+	TenantID  string
+	Secret    string
+	Enabled   bool
+	Required  bool
+}
+
+func adminAccount(u *store.AdminUser, required bool) *twoFactorAccount {
+	return &twoFactorAccount{ActorType: store.ActorAdmin, ID: u.ID, Username: u.Username, Secret: u.TOTPSecret, Enabled: u.TOTPEnabled, Required: required}
+}
+
+func (s *Server) tenantAccount(r *http.Request, u *store.TenantUser) *twoFactorAccount {
+	required, _ := s.Store.TenantRequires2FA(r.Context(), u.TenantID)
+	return &twoFactorAccount{ActorType: store.ActorTenantUser, ID: u.ID, Username: u.Username, TenantID: u.TenantID, Secret: u.TOTPSecret, Enabled: u.TOTPEnabled, Required: required}
+}
+
+func (s *Server) currentAdminAccount(r *http.Request) (*twoFactorAccount, error) {
+	u, err := s.Store.GetAdminByUsername(r.Context(), middleware.AdminUsernameFromContext(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	return adminAccount(u, s.RequireAdmin2FA), nil
+}
+
+func (s *Server) currentTenantAccount(r *http.Request) (*twoFactorAccount, error) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil || user.ID == "" {
+		return nil, errors.New("missing tenant user")
+	}
+	return s.tenantAccount(r, user), nil
+}
+
+// newSessionToken issues a session token for the account, an
+// enrollment-only one when pending, and records the session so it can be
+// listed and revoked. It fails if the session cannot be recorded.
+func (s *Server) newSessionToken(r *http.Request, a *twoFactorAccount, pending bool) (string, error) {
+	jti, ttl := sessions.NewID(), sessionTTL
+	kind := sessions.KindTenant
+	if a.ActorType == store.ActorAdmin {
+		kind = sessions.KindAdmin
+	}
+	var token string
+	var err error
+	switch {
+	case pending:
+		ttl = middleware.TwoFactorEnrollTTL
+		token, err = middleware.NewEnrollmentToken(s.JWTSecret, jti, kind, a.Username, a.TenantID)
+	case kind == sessions.KindAdmin:
+		token, err = middleware.NewAdminToken(s.JWTSecret, jti, a.Username, ttl)
+	default:
+		token, err = middleware.NewTenantToken(s.JWTSecret, jti, a.Username, a.TenantID, ttl)
+	}
+	if err != nil || s.Sessions == nil {
+		return token, err
+	}
+	now := time.Now().UTC()
+	sess := sessions.Session{ID: jti, Kind: kind, Username: a.Username, TenantID: a.TenantID, IP: middleware.ClientIP(r), UserAgent: r.UserAgent(),
+		IssuedAt: now, ExpiresAt: now.Add(ttl), TwoFactorPending: pending}
+	if err := s.Sessions.Create(r.Context(), sess); err != nil {
+		// An unrecorded session would be refused as revoked on its first
+		// request, so the login fails instead.
+		s.Logger.Warn("session record failed", zap.String("username", a.Username), zap.Error(err))
+		return "", err
+	}
+	return token, nil
+}
+
+// issueSession finishes a password login. Enrolled users must supply a valid
+// TOTP or backup code; users required to have two-factor authentication who
+// have not enrolled get a short-lived token that only reaches /2fa.
+func (s *Server) issueSession(w http.ResponseWriter, r *http.Request, a *twoFactorAccount, otp string, extra map[string]string) {
+	resp := map[string]interface{}{}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	if a.Enabled {
+		if otp == "" {
+			http.Error(w, "otp required", http.StatusUnauthorized)
+			return
+		}
+		if err := s.checkSecondFactor(r, a, otp); err != nil {
+			writeOTPError(w, err)
+			return
+		}
+	} else if a.Required {
+		token, err := s.newSessionToken(r, a, true)
+		if err != nil {
+			http.Error(w, "failed to issue token", http.StatusInternalServerError)
+			return
+		}
+		resp["token"] = token
+		resp["2fa_enrollment_required"] = true
+		writeJSON(w, resp)
+		return
+	}
+	token, err := s.newSessionToken(r, a, false)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	resp["token"] = token
+	writeJSON(w, resp)
+}
+
+// checkSecondFactor accepts a current TOTP code (each at most once) or an
+// unused backup code, which is consumed. Every attempt is counted first;
+// after maxOTPAttempts without a success it returns errOTPLocked, even for
+// a valid code, until the lockout ends.
+func (s *Server) checkSecondFactor(r *http.Request, a *twoFactorAccount, code string) error {
+	if a.Secret == "" {
+		return errInvalidOTP
+	}
+	allowed, err := s.Store.ClaimTOTPAttempt(r.Context(), a.ActorType, a.ID, maxOTPAttempts, otpLockout)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errOTPLocked
+	}
+	var ok bool
+	if step, valid := totp.Validate(a.Secret, code, time.Now()); valid {
+		ok, err = s.Store.UseTOTPStep(r.Context(), a.ActorType, a.ID, step)
+	} else {
+		ok, err = s.Store.UseTOTPBackupCode(r.Context(), a.ActorType, a.ID, util.HashString(normalizeBackupCode(code)))
+	}
+	if err != nil || !ok {
+		return errInvalidOTP
+	}
+	_ = s.Store.ResetTOTPAttempts(r.Context(), a.ActorType, a.ID)
+	return nil
+}
+
+// writeOTPError reports a failed checkSecondFactor.
+func writeOTPError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errInvalidOTP):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, errOTPLocked):
+		w.Header().Set("Retry-After", strconv.Itoa(int(otpLockout.Seconds())))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, "failed to check otp", http.StatusInternalServerError)
+	}
+}
+
+func normalizeBackupCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// newBackupCodes returns plaintext codes for the user and their hashes for storage.
+func newBackupCodes() ([]string, []string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567"
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		for j, b := range buf {
+			buf[j] = alphabet[b&31]
+		}
+		codes[i] = string(buf[:5]) + "-" + string(buf[5:])
+		hashes[i] = util.HashString(normalizeBackupCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+func (s *Server) AdminTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorStatus(w, r, s.currentAdminAccount)
+}
+
+func (s *Server) AdminTwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorEnroll(w, r, s.currentAdminAccount)
+}
+
+func (s *Server) AdminTwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorVerify(w, r, s.currentAdminAccount)
+}
+
+func (s *Server) AdminTwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorDisable(w, r, s.currentAdminAccount)
+}
+
+func (s *Server) AdminTwoFactorBackupCodes(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorBackupCodes(w, r, s.currentAdminAccount)
+}
+
+func (s *Server) TenantTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorStatus(w, r, s.currentTenantAccount)
+}
+
+func (s *Server) TenantTwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorEnroll(w, r, s.currentTenantAccount)
+}
+
+func (s *Server) TenantTwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorVerify(w, r, s.currentTenantAccount)
+}
+
+func (s *Server) TenantTwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorDisable(w, r, s.currentTenantAccount)
+}
+
+func (s *Server) TenantTwoFactorBackupCodes(w http.ResponseWriter, r *http.Request) {
+	s.twoFactorBackupCodes(w, r, s.currentTenantAccount)
+}
+
+// TenantSecurity lets owners require two-factor authentication for every
+// user of the tenant.
+func (s *Server) TenantSecurity(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	var payload struct {
+		Require2FA bool `json:"require_2fa"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.Require2FA && !user.TOTPEnabled {
+		http.Error(w, "enable two-factor authentication for your own account first", http.StatusConflict)
+		return
+	}
+	if err := s.Store.SetTenantRequire2FA(r.Context(), user.TenantID, payload.Require2FA); err != nil {
+		http.Error(w, "failed to update security settings", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]bool{"require_2fa": payload.Require2FA})
+}
+
+type accountLoader func(*http.Request) (*twoFactorAccount, error)
+
+func (s *Server) twoFactorStatus(w http.ResponseWriter, r *http.Request, load accountLoader) {
+	a, err := load(r)
+	if err != nil {
+		http.Error(w, "account not found", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]bool{"enabled": a.Enabled, "required": a.Required})
+}
+
+// twoFactorEnroll generates a new secret. It is not enforced until confirmed
+// with twoFactorVerify.
+func (s *Server) twoFactorEnroll(w http.ResponseWriter, r *http.Request, load accountLoader) {
+	a, err := load(r)
+	if err != nil {
+		http.Error(w, "account not found", http.StatusUnauthorized)
+		return
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		http.Error(w, "failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+	if err := s.Store.SetPendingTOTPSecret(r.Context(), a.ActorType, a.ID, secret); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, map[string]string{"secret": secret, "otpauth_url": totp.URI(totpIssuer, a.Username, secret)})
+}
+
+// twoFactorVerify confirms enrollment with a code from the new secret, enables
+// two-factor authentication and returns backup codes plus a full session token.
+func (s *Server) twoFactorVerify(w http.ResponseWriter, r *http.Request, load accountLoader) {
+	a, err := load(r)
+	if err != nil {
+		http.Error(w, "account not found", http.StatusUnauthorized)
+		return
+	}
+	code, ok := decodeOTP(w, r)
+	if !ok {
+		return
+	}
+	if a.Enabled {
+		http.Error(w, "two-factor authentication already enabled", http.StatusConflict)
+		return
+	}
+	if a.Secret == "" {
+		http.Error(w, "enroll first", http.StatusConflict)
+		return
+	}
+	step, valid := totp.Validate(a.Secret, code, time.Now())
+	if !valid {
+		http.Error(w, "invalid otp", http.StatusUnauthorized)
+		return
+	}
+	if fresh, err := s.Store.UseTOTPStep(r.Context(), a.ActorType, a.ID, step); err != nil || !fresh {
+		http.Error(w, "invalid otp", http.StatusUnauthorized)
+		return
+	}
+	codes, hashes, err := newBackupCodes()
+	if err != nil {
+		http.Error(w, "failed to generate backup codes", http.StatusInternalServerError)
+		return
+	}
+	if err := s.Store.EnableTOTP(r.Context(), a.ActorType, a.ID, hashes); err != nil {
+		http.Error(w, "failed to enable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	token, err := s.newSessionToken(r, a, false)
+	if err != nil {
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+	// A full session replaces the enrollment-only one it was verified on.
+	if id := middleware.SessionIDFromContext(r.Context()); id != "" && s.Sessions != nil {
+		if cur, err := s.Sessions.Get(r.Context(), id); err == nil && cur.TwoFactorPending {
+			_ = s.Sessions.Revoke(r.Context(), id)
+		}
+	}
+	writeJSON(w, map[string]interface{}{"enabled": true, "backup_codes": codes, "token": token})
+}
+
+func (s *Server) twoFactorDisable(w http.ResponseWriter, r *http.Request, load accountLoader) {
+	a, err := load(r)
+	if err != nil {
+		http.Error(w, "account not found", http.StatusUnauthorized)
+		return
+	}
+	code, ok := decodeOTP(w, r)
+	if !ok {
+		return
+	}
+	if a.Required {
+		http.Error(w, "two-factor authentication is required for this account", http.StatusForbidden)
+		return
+	}
+	if !a.Enabled {
+		http.Error(w, "invalid otp", http.StatusUnauthorized)
+		return
+	}
+	if err := s.checkSecondFactor(r, a, code); err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	if err := s.Store.DisableTOTP(r.Context(), a.ActorType, a.ID); err != nil {
+		http.Error(w, "failed to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]bool{"enabled": false})
+}
+
+// twoFactorBackupCodes replaces all backup codes after checking a current code.
+func (s *Server) twoFactorBackupCodes(w http.ResponseWriter, r *http.Request, load accountLoader) {
+	a, err := load(r)
+	if err != nil {
+		http.Error(w, "account not found", http.StatusUnauthorized)
+		return
+	}
+	code, ok := decodeOTP(w, r)
+	if !ok {
+		return
+	}
+	if !a.Enabled {
+		http.Error(w, "invalid otp", http.StatusUnauthorized)
+		return
+	}
+	if err := s.checkSecondFactor(r, a, code); err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	codes, hashes, err := newBackupCodes()
+	if err != nil {
+		http.Error(w, "failed to generate backup codes", http.StatusInternalServerError)
+		return
+	}
+	if err := s.Store.ReplaceTOTPBackupCodes(r.Context(), a.ActorType, a.ID, hashes); err != nil {
+		http.Error(w, "failed to store backup codes", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"backup_codes": codes})
+}
+
+func decodeOTP(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var payload struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return "", false
+	}
+	if payload.Code == "" {
+		http.Error(w, "code required", http.StatusBadRequest)
+		return "", false
+	}
+	return payload.Code, true
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"routerx/internal/api"
+	"routerx/internal/store"
+	"routerx/internal/store/storemock"
+	"routerx/internal/totp"
+)
+
+func TestSecondFactorLockout(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	step := time.Now().Unix() / totp.Period
+	valid, _ := totp.Code(secret, step)
+	wrong := "000000"
+	if wrong == valid {
+		wrong = "111111"
+	}
+
+	tests := []struct {
+		name  string
+		codes []string
+		want  []int
+	}{
+		{name: "locked after the limit, even for a valid code",
+			codes: []string{wrong, wrong, wrong, wrong, wrong, valid},
+			want:  []int{401, 401, 401, 401, 401, 429}},
+		{name: "a success starts the count again",
+			codes: []string{wrong, wrong, wrong, wrong, valid, wrong, wrong},
+			want:  []int{401, 401, 401, 401, 200, 401, 401}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The mock mirrors ClaimTOTPAttempt: the limit-th attempt locks.
+			attempts, locked, lastStep := 0, false, int64(0)
+			st := &storemock.Store{
+				GetAdminByUsernameFunc: func(context.Context, string) (*store.AdminUser, error) {
+					return &store.AdminUser{ID: "a1", Username: "root", PasswordHash: string(hash), TOTPSecret: secret, TOTPEnabled: true}, nil
+				},
+				ClaimTOTPAttemptFunc: func(_ context.Context, _, _ string, limit int, _ time.Duration) (bool, error) {
+					if locked {
+						return false, nil
+					}
+					attempts++
+					locked = attempts >= limit
+					return true, nil
+				},
+				ResetTOTPAttemptsFunc: func(context.Context, string, string) error {
+					attempts, locked = 0, false
+					return nil
+				},
+				UseTOTPStepFunc: func(_ context.Context, _, _ string, s int64) (bool, error) {
+					// Let the test reuse its code: only older steps are replays.
+					fresh := s >= lastStep
+					lastStep = s
+					return fresh, nil
+				},
+			}
+			s := &api.Server{Store: st, JWTSecret: "test-secret"}
+			for i, code := range tt.codes {
+				body := `{"username":"root","password":"pw","otp":"` + code + `"}`
+				w := httptest.NewRecorder()
+				s.AdminLogin(w, httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(body)))
+				if w.Code != tt.want[i] {
+					t.Fatalf("attempt %d: status %d, want %d (%s)", i+1, w.Code, tt.want[i], strings.TrimSpace(w.Body.String()))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"routerx/internal/middleware"
+	"routerx/internal/store"
+	"routerx/internal/webhook"
+)
+
+func (s *Server) AdminGetWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, hook)
+}
+
+// AdminUpdateWebhook changes a webhook's URL, events, secret or enabled flag.
+// Omitted fields keep their current values.
+func (s *Server) AdminUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		URL     *string  `json:"url"`
+		Events  []string `json:"events"`
+		Secret  *string  `json:"secret"`
+		Enabled *bool    `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if payload.URL != nil {
+		hook.URL = *payload.URL
+	}
+	if payload.Events != nil {
+		hook.Events = payload.Events
+	}
+	if payload.Secret != nil {
+		hook.Secret = *payload.Secret
+	}
+	if payload.Enabled != nil {
+		hook.Enabled = *payload.Enabled
+	}
+	if err := webhook.ValidateURL(r.Context(), hook.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(hook.Events) == 0 || !webhook.ValidEvents(hook.Events, hook.TenantID != "") {
+		http.Error(w, "unknown event type", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.UpdateWebhook(r.Context(), *hook); err != nil {
+		http.Error(w, "failed to update webhook", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, hook)
+}
+
+// AdminTestWebhook sends a signed webhook.test event and reports the
+// endpoint's response, so operators can verify an endpoint before relying on
+// it. Disabled webhooks can be tested too.
+func (s *Server) AdminTestWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.Webhooks == nil {
+		http.Error(w, "webhooks are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	hook, ok := s.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	res := s.Webhooks.Test(r.Context(), *hook)
+	writeJSON(w, map[string]interface{}{
+		"webhook_id":  hook.ID,
+		"url":         hook.URL,
+		"success":     res.Error == "",
+		"status_code": res.StatusCode,
+		"duration_ms": res.DurationMS,
+		"error":       res.Error,
+	})
+}
+
+func (s *Server) loadWebhook(w http.ResponseWriter, r *http.Request) (*store.Webhook, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return nil, false
+	}
+	hook, err := s.Store.GetWebhook(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "webhook not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "failed to load webhook", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return hook, true
+}
+
+// AdminWebhookDeliveries lists deliveries, newest first. Filter by
+// ?status=pending|delivered|dead, ?webhook_id= and ?event_type=.
+func (s *Server) AdminWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	webhookID, _ := strconv.Atoi(q.Get("webhook_id"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	deliveries, err := s.Store.ListWebhookDeliveries(r.Context(), store.WebhookDeliveryFilters{
+		WebhookID: webhookID,
+		Status:    q.Get("status"),
+		EventType: q.Get("event_type"),
+		Limit:     limit,
+	})
+	if err != nil {
+		http.Error(w, "failed to list webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []store.WebhookDelivery{}
+	}
+	writeJSON(w, deliveries)
+}
+
+func (s *Server) AdminWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	d, err := s.Store.GetWebhookDelivery(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeDeliveryError(w, err, "failed to load webhook delivery")
+		return
+	}
+	writeJSON(w, d)
+}
+
+// AdminRedeliverWebhook makes one immediate attempt at a delivery, typically a
+// dead-lettered one, and returns the updated delivery with its attempt log.
+func (s *Server) AdminRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.Webhooks == nil {
+		http.Error(w, "webhooks are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	d, err := s.Webhooks.Redeliver(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeDeliveryError(w, err, "failed to redeliver webhook")
+		return
+	}
+	writeJSON(w, d)
+}
+
+func writeDeliveryError(w http.ResponseWriter, err error, msg string) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, "webhook delivery not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, msg, http.StatusInternalServerError)
+}
+
+// spendThresholds are the fractions of a tenant's spend limit that fire
+// spend.threshold_crossed.
+var spendThresholds = []float64{0.5, 0.8, 1.0}
+
+func (s *Server) fireEvent(ctx context.Context, tenantID, event string, data map[string]interface{}) {
+	if s.Webhooks == nil {
+		return
+	}
+	s.Webhooks.Fire(ctx, tenantID, event, data)
+}
+
+// fireChargeEvents fires balance and spend events for thresholds crossed by a
+// charge of cost, of which paid came out of the balance and the rest out of
+// credits. t holds the tenant as loaded before the charge.
+func (s *Server) fireChargeEvents(ctx context.Context, t *store.Tenant, cost, paid float64) {
+	balance := t.BalanceUSD - paid
+	if s.LowBalanceThresholdUSD > 0 && t.BalanceUSD >= s.LowBalanceThresholdUSD && balance < s.LowBalanceThresholdUSD {
+		s.fireEvent(ctx, t.ID, webhook.EventTenantBalanceLow, map[string]interface{}{
+			"tenant_id":     t.ID,
+			"balance_usd":   balance,
+			"threshold_usd": s.LowBalanceThresholdUSD,
+		})
+	}
+	if t.SpendLimitUSD <= 0 {
+		return
+	}
+	spent := t.TotalSpentUSD + cost
+	for _, frac := range spendThresholds {
+		limit := t.SpendLimitUSD * frac
+		if t.TotalSpentUSD < limit && spent >= limit {
+			s.fireEvent(ctx, t.ID, webhook.EventSpendThresholdCrossed, map[string]interface{}{
+				"tenant_id":       t.ID,
+				"percent":         int(frac * 100),
+				"spent_usd":       spent,
+				"spend_limit_usd": t.SpendLimitUSD,
+			})
+		}
+	}
+}
+
+// TenantWebhooks lists the tenant's own webhooks. Secrets are only shown once,
+// at creation.
+func (s *Server) TenantWebhooks(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	hooks, err := s.Store.ListTenantWebhooks(r.Context(), user.TenantID)
+	if err != nil {
+		http.Error(w, "failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+	if hooks == nil {
+		hooks = []store.Webhook{}
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	writeJSON(w, hooks)
+}
+
+// TenantCreateWebhook registers a webhook for the tenant's events. Each
+// webhook gets its own generated signing secret.
+func (s *Server) TenantCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	var payload struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if err := webhook.ValidateURL(r.Context(), payload.URL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(payload.Events) == 0 {
+		payload.Events = []string{webhook.EventRequestCompleted}
+	}
+	if !webhook.ValidEvents(payload.Events, true) {
+		http.Error(w, "events must be one of: "+strings.Join(webhook.TenantEvents, ", "), http.StatusBadRequest)
+		return
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		http.Error(w, "failed to generate secret", http.StatusInternalServerError)
+		return
+	}
+	hook := store.Webhook{TenantID: user.TenantID, URL: payload.URL, Events: payload.Events, Secret: "whsec_" + hex.EncodeToString(secretBytes), Enabled: true}
+	id, err := s.Store.CreateWebhook(r.Context(), hook)
+	if err != nil {
+		http.Error(w, "failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+	hook.ID, hook.CreatedAt = id, time.Now().UTC()
+	writeJSON(w, hook)
+}
+
+func (s *Server) TenantDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	user := middleware.TenantUserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "missing tenant", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := s.Store.DeleteTenantWebhook(r.Context(), user.TenantID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.Error(w, "webhook not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"status": "ok"})
+}
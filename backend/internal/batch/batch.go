@@ -0,0 +1,148 @@
+// Package batch runs asynchronous batch completion requests. Items are
+// persisted by the API and worked off the queue in the background, so offline
+// jobs neither hold client connections open nor compete for the synchronous
+// path's per-tenant concurrency.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"routerx/internal/jobs"
+	"routerx/internal/store"
+)
+
+// itemLease is how long a claimed item is reserved for its worker. It must
+// outlast the slowest upstream call; a lapsed item is claimed again.
+const itemLease = 5 * time.Minute
+
+// rateLimitedDelay is how long a rate-limited item waits before it is
+// claimed again.
+const rateLimitedDelay = 30 * time.Second
+
+// JobDispatch is the job kind Register schedules.
+const JobDispatch = "batch.dispatch"
+
+// ExecFunc runs one chat completion request body for tenantID as apiKey and
+// returns the HTTP status and response body the synchronous endpoint would
+// have produced.
+type ExecFunc func(ctx context.Context, tenantID, apiKey string, body []byte) (int, []byte)
+
+// Processor works batch items off the queue with a bounded worker pool.
+type Processor struct {
+	Store *store.Store
+	Exec  ExecFunc
+	// Workers bounds in-flight items on this instance; TenantConcurrency
+	// bounds running items per tenant across instances.
+	Workers           int
+	TenantConcurrency int
+	// MaxAttempts bounds retries of upstream-failed items. Rate-limited
+	// items are retried without counting against it.
+	MaxAttempts int
+	Logger      *zap.Logger
+
+	sem chan struct{}
+}
+
+func New(st *store.Store, exec ExecFunc, workers, tenantConcurrency int, logger *zap.Logger) *Processor {
+	return &Processor{
+		Store:             st,
+		Exec:              exec,
+		Workers:           workers,
+		TenantConcurrency: tenantConcurrency,
+		MaxAttempts:       3,
+		Logger:            logger,
+		sem:               make(chan struct{}, workers),
+	}
+}
+
+// Register claims due items each interval on r. Items run on this
+// processor's pool after the dispatch job returns; items in flight at
+// shutdown are picked up again once their lease lapses.
+func (p *Processor) Register(ctx context.Context, r *jobs.Runner, interval time.Duration) {
+	r.Every(JobDispatch, interval, func(jctx context.Context, _ json.RawMessage) error {
+		return p.Dispatch(jctx, ctx)
+	})
+}
+
+// Dispatch claims as many due items as there are free workers and starts
+// them under runCtx, which outlives the claim.
+func (p *Processor) Dispatch(ctx, runCtx context.Context) error {
+	free := cap(p.sem) - len(p.sem)
+	if free <= 0 {
+		return nil
+	}
+	items, err := p.Store.ClaimBatchItems(ctx, free, p.TenantConcurrency, itemLease)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		p.sem <- struct{}{}
+		go func(it store.BatchItem) {
+			defer func() { <-p.sem }()
+			p.process(runCtx, it)
+		}(it)
+	}
+	return nil
+}
+
+func (p *Processor) process(ctx context.Context, it store.BatchItem) {
+	code, body := p.Exec(ctx, it.TenantID, it.APIKey, it.Request)
+	if ctx.Err() != nil {
+		// Shutting down: leave the lease to lapse so the item is retried.
+		return
+	}
+	errMsg := ""
+	if code != http.StatusOK {
+		errMsg = errorMessage(body)
+	}
+	if code == http.StatusTooManyRequests {
+		// Rate limited upstream: the item never ran, so wait and try again
+		// without spending one of its attempts.
+		if err := p.Store.DeferBatchItem(ctx, it.ID, code, errMsg, time.Now().UTC().Add(rateLimitedDelay)); err != nil {
+			p.Logger.Warn("batch item retry failed", zap.String("batch_id", it.BatchID), zap.Int("index", it.Index), zap.Error(err))
+		}
+		return
+	}
+	if retryable(code) && it.Attempts < p.MaxAttempts {
+		retryAt := time.Now().UTC().Add(time.Duration(it.Attempts*it.Attempts) * 30 * time.Second)
+		if err := p.Store.RetryBatchItem(ctx, it.ID, code, errMsg, retryAt); err != nil {
+			p.Logger.Warn("batch item retry failed", zap.String("batch_id", it.BatchID), zap.Int("index", it.Index), zap.Error(err))
+		}
+		return
+	}
+	it.StatusCode, it.Error = code, errMsg
+	it.Status = store.BatchItemFailed
+	if code == http.StatusOK {
+		it.Status = store.BatchItemSucceeded
+	}
+	if json.Valid(body) {
+		it.Response = body
+	}
+	if err := p.Store.FinishBatchItem(ctx, it); err != nil {
+		p.Logger.Warn("batch item update failed", zap.String("batch_id", it.BatchID), zap.Int("index", it.Index), zap.Error(err))
+	}
+}
+
+func retryable(code int) bool {
+	return code == http.StatusServiceUnavailable || code == http.StatusBadGateway
+}
+
+// errorMessage extracts an OpenAI-style error message, falling back to the
+// plain-text body http.Error writes.
+func errorMessage(body []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
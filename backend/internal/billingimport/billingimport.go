@@ -0,0 +1,191 @@
+// Package billingimport parses provider billing exports (OpenAI and
+// Anthropic usage and cost CSVs) into per-day, per-model totals that can be
+// reconciled against the costs RouterX computed.
+package billingimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"routerx/internal/store"
+)
+
+// Export formats.
+const (
+	FormatOpenAI    = "openai"
+	FormatAnthropic = "anthropic"
+)
+
+// ValidFormat reports whether format is a known export format.
+func ValidFormat(format string) bool {
+	return format == FormatOpenAI || format == FormatAnthropic
+}
+
+// columns lists the header names each export uses for a field, first match
+// wins. Headers are compared lowercased with spaces as underscores.
+type columns struct {
+	day, model, cost []string
+	tokens           []string
+}
+
+var formats = map[string]columns{
+	FormatOpenAI: {
+		day:    []string{"date", "start_time", "start_time_iso", "usage_date"},
+		model:  []string{"model", "line_item", "snapshot_id"},
+		cost:   []string{"cost_usd", "amount_value", "cost", "amount"},
+		tokens: []string{"input_tokens", "output_tokens", "input_cached_tokens", "n_context_tokens_total", "n_generated_tokens_total"},
+	},
+	FormatAnthropic: {
+		day:    []string{"usage_date_utc", "date", "day", "starting_at"},
+		model:  []string{"model", "model_name"},
+		cost:   []string{"cost_usd", "cost", "amount", "total_cost_usd"},
+		tokens: []string{"input_tokens", "output_tokens", "uncached_input_tokens", "cache_creation_input_tokens", "cache_read_input_tokens"},
+	},
+}
+
+// Parse reads a billing export and sums its rows per day and model. The
+// token columns are optional; the day, model and cost columns are not.
+func Parse(r io.Reader, format string) ([]store.ProviderBillingLine, error) {
+	cols, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty export")
+		}
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.ReplaceAll(strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))), " ", "_")] = i
+	}
+	find := func(names []string) int {
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	dayCol, modelCol, costCol := find(cols.day), find(cols.model), find(cols.cost)
+	switch {
+	case dayCol < 0:
+		return nil, fmt.Errorf("no date column (one of %s)", strings.Join(cols.day, ", "))
+	case modelCol < 0:
+		return nil, fmt.Errorf("no model column (one of %s)", strings.Join(cols.model, ", "))
+	case costCol < 0:
+		return nil, fmt.Errorf("no cost column (one of %s)", strings.Join(cols.cost, ", "))
+	}
+	var tokenCols []int
+	for _, n := range cols.tokens {
+		if i, ok := index[n]; ok {
+			tokenCols = append(tokenCols, i)
+		}
+	}
+
+	type key struct {
+		day   time.Time
+		model string
+	}
+	sums := map[key]*store.ProviderBillingLine{}
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+			continue
+		}
+		field := func(i int) string {
+			if i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		day, err := parseDay(field(dayCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		model := modelName(field(modelCol))
+		if model == "" {
+			return nil, fmt.Errorf("line %d: empty model", line)
+		}
+		cost, err := parseAmount(field(costCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid cost %q", line, field(costCol))
+		}
+		var tokens int64
+		for _, i := range tokenCols {
+			if n, err := strconv.ParseInt(strings.ReplaceAll(field(i), ",", ""), 10, 64); err == nil {
+				tokens += n
+			}
+		}
+		k := key{day, model}
+		l, ok := sums[k]
+		if !ok {
+			l = &store.ProviderBillingLine{Day: day, Model: model}
+			sums[k] = l
+		}
+		l.CostUSD += cost
+		l.Tokens += tokens
+	}
+	out := make([]store.ProviderBillingLine, 0, len(sums))
+	for _, l := range sums {
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Day.Equal(out[j].Day) {
+			return out[i].Day.Before(out[j].Day)
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out, nil
+}
+
+// modelName strips OpenAI line-item suffixes ("gpt-4o-2024-08-06, input").
+func modelName(s string) string {
+	name, _, _ := strings.Cut(s, ",")
+	return strings.TrimSpace(name)
+}
+
+// parseDay accepts dates, timestamps and Unix seconds, truncated to the UTC
+// day.
+func parseDay(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) >= 9 {
+		return truncateDay(time.Unix(n, 0)), nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "01/02/2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return truncateDay(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseAmount reads a dollar amount, allowing a "$" and thousands separators.
+func parseAmount(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimPrefix(s, "$"), ",", "")
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
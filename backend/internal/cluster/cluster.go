@@ -0,0 +1,195 @@
+// Package cluster tracks the gateway instances sharing a deployment. Each
+// instance heartbeats into a Redis registry, and one of them holds a leader
+// lease so work that must not run once per replica runs exactly once.
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	registryKey = "instances"
+	leaderKey   = "instances:leader"
+	// HeartbeatInterval is how often an instance refreshes its entry and,
+	// when leader, its lease.
+	HeartbeatInterval = 5 * time.Second
+	// InstanceTTL is how long an entry outlives its last heartbeat; after it
+	// the instance is considered gone and its leader lease lapses.
+	InstanceTTL = 3 * HeartbeatInterval
+)
+
+// renewLeaderScript extends the leader lease if ARGV[1] still holds it.
+// It returns 1 when renewed and 0 when the lease belongs to someone else.
+// KEYS: leader key. ARGV: instance id, ttl ms.
+var renewLeaderScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// resignLeaderScript deletes the leader lease if ARGV[1] holds it.
+var resignLeaderScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Instance is one gateway process as last reported by its heartbeat.
+type Instance struct {
+	ID        string    `json:"id"`
+	Version   string    `json:"version"`
+	Region    string    `json:"region,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	Leader    bool      `json:"leader"`
+}
+
+// Registry announces this instance and competes for the leader lease.
+type Registry struct {
+	Redis  redis.UniversalClient
+	Logger *zap.Logger
+	Self   Instance
+
+	leader atomic.Bool
+}
+
+// New returns a registry entry for this process. Its id is host-pid, the
+// same name the job runner records on the jobs it claims.
+func New(rdb redis.UniversalClient, version, region string, logger *zap.Logger) *Registry {
+	host, _ := os.Hostname()
+	return &Registry{
+		Redis:  rdb,
+		Logger: logger,
+		Self: Instance{
+			ID:        fmt.Sprintf("%s-%d", host, os.Getpid()),
+			Version:   version,
+			Region:    region,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+}
+
+// Run heartbeats every HeartbeatInterval until ctx is cancelled, then
+// removes the entry and gives up the lease so another instance takes over
+// without waiting for it to lapse.
+func (g *Registry) Run(ctx context.Context) {
+	g.beat(ctx)
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			g.leave()
+			return
+		case <-ticker.C:
+			g.beat(ctx)
+		}
+	}
+}
+
+// IsLeader reports whether this instance held the leader lease as of its
+// last heartbeat.
+func (g *Registry) IsLeader() bool { return g.leader.Load() }
+
+// Singleton runs fn every interval until ctx is cancelled, skipping the
+// ticks on which this instance is not the leader, so across the deployment
+// fn runs on one replica at a time.
+func (g *Registry) Singleton(ctx context.Context, interval time.Duration, name string, fn func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !g.IsLeader() {
+				continue
+			}
+			if err := fn(ctx); err != nil && ctx.Err() == nil {
+				g.Logger.Warn("singleton task failed", zap.String("task", name), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Instances lists the live instances, oldest first, and drops entries that
+// stopped heartbeating.
+func (g *Registry) Instances(ctx context.Context) ([]Instance, error) {
+	entries, err := g.Redis.HGetAll(ctx, registryKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	leader, err := g.Redis.Get(ctx, leaderKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	cutoff := time.Now().UTC().Add(-InstanceTTL)
+	out := make([]Instance, 0, len(entries))
+	var stale []string
+	for id, raw := range entries {
+		var in Instance
+		if json.Unmarshal([]byte(raw), &in) != nil || in.LastSeen.Before(cutoff) {
+			stale = append(stale, id)
+			continue
+		}
+		in.Leader = in.ID == leader
+		out = append(out, in)
+	}
+	if len(stale) > 0 {
+		g.Redis.HDel(ctx, registryKey, stale...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out, nil
+}
+
+// beat refreshes this instance's entry and takes or renews the leader
+// lease. A Redis failure drops leadership: an instance that cannot renew
+// must assume another has taken over.
+func (g *Registry) beat(ctx context.Context) {
+	ttl := InstanceTTL.Milliseconds()
+	held, err := renewLeaderScript.Run(ctx, g.Redis, []string{leaderKey}, g.Self.ID, ttl).Int()
+	if err == nil && held == 0 {
+		var ok bool
+		ok, err = g.Redis.SetNX(ctx, leaderKey, g.Self.ID, InstanceTTL).Result()
+		if ok {
+			held = 1
+		}
+	}
+	if err != nil {
+		g.Logger.Warn("leader election failed", zap.Error(err))
+		held = 0
+	}
+	if was := g.leader.Swap(held == 1); was != (held == 1) {
+		g.Logger.Info("leadership changed", zap.String("instance", g.Self.ID), zap.Bool("leader", held == 1))
+	}
+
+	self := g.Self
+	self.LastSeen = time.Now().UTC()
+	self.Leader = held == 1
+	body, _ := json.Marshal(self)
+	if err := g.Redis.HSet(ctx, registryKey, self.ID, body).Err(); err != nil {
+		g.Logger.Warn("instance heartbeat failed", zap.Error(err))
+	}
+}
+
+// leave removes this instance from the registry on shutdown.
+func (g *Registry) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	g.leader.Store(false)
+	resignLeaderScript.Run(ctx, g.Redis, []string{leaderKey}, g.Self.ID)
+	g.Redis.HDel(ctx, registryKey, g.Self.ID)
+}
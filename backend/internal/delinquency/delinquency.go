@@ -0,0 +1,139 @@
+// Package delinquency suspends tenants automatically: those whose balance
+// has stayed negative for too long and those over an abuse threshold. It
+// notifies them by webhook and email, and lifts a balance suspension once a
+// top-up brings the balance back to zero or more.
+package delinquency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"routerx/internal/jobs"
+	"routerx/internal/mailer"
+	"routerx/internal/store"
+	"routerx/internal/webhook"
+)
+
+// JobSweep is the job kind Register schedules.
+const JobSweep = "tenants.delinquency"
+
+// Policy configures the sweep; zero fields disable their check.
+type Policy struct {
+	NegativeBalanceDays int
+	Abuse               store.AbuseThresholds
+}
+
+// Enabled reports whether any check is on.
+func (p Policy) Enabled() bool {
+	return p.NegativeBalanceDays > 0 || p.Abuse.ModerationFlagsPerHour > 0 || p.Abuse.ClientErrorsPerHour > 0
+}
+
+// Enforcer applies a Policy.
+type Enforcer struct {
+	Store    *store.Store
+	Policy   Policy
+	Webhooks *webhook.Dispatcher
+	Mailer   *mailer.Mailer
+	Logger   *zap.Logger
+}
+
+func New(st *store.Store, p Policy, wh *webhook.Dispatcher, m *mailer.Mailer, logger *zap.Logger) *Enforcer {
+	return &Enforcer{Store: st, Policy: p, Webhooks: wh, Mailer: m, Logger: logger}
+}
+
+// Register sweeps every interval on r.
+func (e *Enforcer) Register(r *jobs.Runner, interval time.Duration) {
+	r.Every(JobSweep, interval, func(ctx context.Context, _ json.RawMessage) error {
+		return e.Sweep(ctx)
+	})
+}
+
+// Sweep lifts balance suspensions that a top-up has cleared, then suspends
+// the tenants the policy catches. A tenant that fails to suspend is logged
+// and does not stop the others.
+func (e *Enforcer) Sweep(ctx context.Context) error {
+	if err := e.Store.TrackNegativeBalances(ctx); err != nil {
+		return err
+	}
+	released, err := e.Store.ReleaseBalanceSuspensions(ctx, "")
+	if err != nil {
+		return err
+	}
+	for _, id := range released {
+		e.fireUnsuspended(ctx, id)
+	}
+	var found []store.Delinquent
+	if e.Policy.NegativeBalanceDays > 0 {
+		list, err := e.Store.ListNegativeBalanceTenants(ctx, e.Policy.NegativeBalanceDays)
+		if err != nil {
+			return err
+		}
+		found = append(found, list...)
+	}
+	if e.Policy.Abuse.ModerationFlagsPerHour > 0 || e.Policy.Abuse.ClientErrorsPerHour > 0 {
+		list, err := e.Store.ListAbusiveTenants(ctx, e.Policy.Abuse)
+		if err != nil {
+			return err
+		}
+		found = append(found, list...)
+	}
+	for _, d := range found {
+		suspended, err := e.Store.AutoSuspendTenant(ctx, d.TenantID, d.Reason)
+		if err != nil {
+			e.Logger.Warn("auto-suspend failed", zap.String("tenant_id", d.TenantID), zap.Error(err))
+			continue
+		}
+		if !suspended {
+			// Caught by both checks; the first suspension already notified.
+			continue
+		}
+		e.Logger.Info("tenant auto-suspended", zap.String("tenant_id", d.TenantID), zap.String("reason", d.Reason), zap.String("detail", d.Detail))
+		e.notify(ctx, d)
+	}
+	return nil
+}
+
+func (e *Enforcer) notify(ctx context.Context, d store.Delinquent) {
+	if e.Webhooks != nil {
+		e.Webhooks.Fire(ctx, d.TenantID, webhook.EventTenantSuspended, map[string]interface{}{
+			"tenant_id":    d.TenantID,
+			"suspended_by": "policy",
+			"reason":       d.Reason,
+			"detail":       d.Detail,
+			"balance_usd":  d.BalanceUSD,
+		})
+	}
+	if e.Mailer == nil {
+		return
+	}
+	to, err := e.Store.TenantOwnerEmails(ctx, d.TenantID)
+	if err != nil {
+		e.Logger.Warn("suspension email lookup failed", zap.String("tenant_id", d.TenantID), zap.Error(err))
+		return
+	}
+	body := fmt.Sprintf("Your RouterX account %q has been suspended: %s.\n", d.Name, d.Detail)
+	if d.Reason == store.SuspendNegativeBalance {
+		body += fmt.Sprintf("Your balance is $%.2f. Top up at least $%.2f and the suspension is lifted automatically.\n", d.BalanceUSD, -d.BalanceUSD)
+	} else {
+		body += "Contact support to have it reviewed.\n"
+	}
+	for _, addr := range to {
+		if err := e.Mailer.Send(addr, fmt.Sprintf("[RouterX] Account suspended: %s", d.Name), body); err != nil {
+			e.Logger.Warn("suspension email failed", zap.String("tenant_id", d.TenantID), zap.Error(err))
+		}
+	}
+}
+
+func (e *Enforcer) fireUnsuspended(ctx context.Context, tenantID string) {
+	if e.Webhooks == nil {
+		return
+	}
+	e.Webhooks.Fire(ctx, tenantID, webhook.EventTenantUnsuspended, map[string]interface{}{
+		"tenant_id": tenantID,
+		"reason":    store.SuspendNegativeBalance,
+	})
+}
@@ -0,0 +1,179 @@
+// Package devenv starts a throwaway Postgres and an in-memory Redis from the
+// binaries installed on the machine, for local development and integration
+// tests without Docker. Both listen on loopback only, on free ports, and
+// keep their data in a temporary directory removed on Stop.
+package devenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ErrNotInstalled is returned by Start when the Postgres or Redis server
+// binaries cannot be found. Integration tests skip on it.
+var ErrNotInstalled = errors.New("not installed")
+
+// Env is a running development database and cache.
+type Env struct {
+	DatabaseURL string
+	RedisURL    string
+
+	dir   string
+	keep  bool
+	pgCtl string
+	redis *exec.Cmd
+}
+
+// Start initializes and starts both servers under dir, or a new temporary
+// directory when dir is empty. A given dir is kept on Stop, so its database
+// survives restarts.
+func Start(ctx context.Context, dir string) (*Env, error) {
+	e := &Env{dir: dir, keep: dir != ""}
+	if dir != "" {
+		// Postgres resolves relative paths against its data directory.
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		e.dir = abs
+	} else {
+		d, err := os.MkdirTemp("", "routerx-dev-")
+		if err != nil {
+			return nil, err
+		}
+		e.dir = d
+	}
+	if err := e.startPostgres(ctx); err != nil {
+		e.Stop()
+		return nil, err
+	}
+	if err := e.startRedis(ctx); err != nil {
+		e.Stop()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Env) startPostgres(ctx context.Context) error {
+	initdb, err := lookPostgres("initdb")
+	if err != nil {
+		return err
+	}
+	e.pgCtl = filepath.Join(filepath.Dir(initdb), "pg_ctl")
+	data := filepath.Join(e.dir, "pg")
+	if _, err := os.Stat(filepath.Join(data, "PG_VERSION")); err != nil {
+		out, err := exec.CommandContext(ctx, initdb, "-D", data, "-U", "routerx", "--auth=trust", "-E", "UTF8").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("initdb: %w: %s", err, out)
+		}
+	}
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off", port, e.dir)
+	out, err := exec.CommandContext(ctx, e.pgCtl, "-D", data, "-o", opts, "-l", filepath.Join(e.dir, "postgres.log"), "-w", "start").CombinedOutput()
+	if err != nil {
+		e.pgCtl = ""
+		return fmt.Errorf("pg_ctl start: %w: %s", err, out)
+	}
+	e.DatabaseURL = fmt.Sprintf("postgres://routerx@127.0.0.1:%d/postgres?sslmode=disable", port)
+	return nil
+}
+
+func (e *Env) startRedis(ctx context.Context) error {
+	bin, err := exec.LookPath("redis-server")
+	if err != nil {
+		return fmt.Errorf("redis-server %w: not found on PATH; install Redis to use the development environment", ErrNotInstalled)
+	}
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	// No snapshots and no append-only file: the data lives in memory only.
+	e.redis = exec.Command(bin, "--port", strconv.Itoa(port), "--bind", "127.0.0.1", "--save", "", "--appendonly", "no")
+	if err := e.redis.Start(); err != nil {
+		e.redis = nil
+		return fmt.Errorf("redis-server: %w", err)
+	}
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	if err := waitForPort(ctx, addr, 10*time.Second); err != nil {
+		return fmt.Errorf("redis-server: %w", err)
+	}
+	e.RedisURL = "redis://" + addr + "/0"
+	return nil
+}
+
+// Stop shuts both servers down and removes a temporary data directory.
+func (e *Env) Stop() {
+	if e.redis != nil && e.redis.Process != nil {
+		_ = e.redis.Process.Kill()
+		_ = e.redis.Wait()
+	}
+	if e.pgCtl != "" {
+		_ = exec.Command(e.pgCtl, "-D", filepath.Join(e.dir, "pg"), "-m", "fast", "-w", "stop").Run()
+	}
+	if !e.keep {
+		_ = os.RemoveAll(e.dir)
+	}
+}
+
+// lookPostgres finds a Postgres server binary on PATH or in the versioned
+// directories Debian and Homebrew install to, preferring the newest.
+func lookPostgres(name string) (string, error) {
+	if p, err := exec.LookPath(name); err == nil {
+		return p, nil
+	}
+	var found []string
+	for _, pattern := range []string{"/usr/lib/postgresql/*/bin/", "/opt/homebrew/opt/postgresql@*/bin/", "/usr/local/opt/postgresql@*/bin/"} {
+		m, _ := filepath.Glob(pattern + name)
+		found = append(found, m...)
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("%s %w: not found on PATH; install the PostgreSQL server to use the development environment", name, ErrNotInstalled)
+	}
+	// Versions are bare integers since PostgreSQL 10, so a longer path
+	// within a pattern is the newer release.
+	sort.Slice(found, func(i, j int) bool {
+		if len(found[i]) != len(found[j]) {
+			return len(found[i]) < len(found[j])
+		}
+		return found[i] < found[j]
+	})
+	return found[len(found)-1], nil
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func waitForPort(ctx context.Context, addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		c, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			return c.Close()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not listening on %s after %s", addr, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
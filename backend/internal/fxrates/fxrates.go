@@ -0,0 +1,108 @@
+// Package fxrates refreshes display exchange rates from the European Central
+// Bank's daily reference rates. Accounting stays in USD; these rates only
+// convert amounts shown to tenants.
+package fxrates
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"routerx/internal/jobs"
+	"routerx/internal/store"
+)
+
+const ecbDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// JobRefresh is the job kind Register schedules.
+const JobRefresh = "fx.refresh"
+
+// Fetcher loads ECB rates into the store.
+type Fetcher struct {
+	Store  *store.Store
+	Client *http.Client
+	Logger *zap.Logger
+	// URL overrides the ECB daily feed.
+	URL string
+}
+
+func New(st *store.Store, logger *zap.Logger) *Fetcher {
+	return &Fetcher{Store: st, Client: &http.Client{Timeout: 10 * time.Second}, Logger: logger, URL: ecbDailyURL}
+}
+
+// Register refreshes rates every interval on r.
+func (f *Fetcher) Register(r *jobs.Runner, interval time.Duration) {
+	r.Every(JobRefresh, interval, func(ctx context.Context, _ json.RawMessage) error {
+		return f.Refresh(ctx)
+	})
+}
+
+// Refresh fetches the feed and stores every rate it quotes, except those an
+// operator set by hand.
+func (f *Fetcher) Refresh(ctx context.Context) error {
+	rates, err := f.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	n, err := f.Store.UpsertFeedRates(ctx, store.RateSourceECB, rates)
+	if err != nil {
+		return err
+	}
+	f.Logger.Info("exchange rates refreshed", zap.Int("rates", n))
+	return nil
+}
+
+// ecbEnvelope is the shape of the ECB feed: rates per euro.
+type ecbEnvelope struct {
+	Cubes []struct {
+		Currency string `xml:"currency,attr"`
+		Rate     string `xml:"rate,attr"`
+	} `xml:"Cube>Cube>Cube"`
+}
+
+// fetch returns rates per US dollar. The feed quotes per euro, so each rate is
+// divided by the euro's dollar rate, and EUR itself is added.
+func (f *Fetcher) fetch(ctx context.Context) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ecb feed returned %d", resp.StatusCode)
+	}
+	var env ecbEnvelope
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&env); err != nil {
+		return nil, fmt.Errorf("decode ecb feed: %w", err)
+	}
+	perEUR := map[string]float64{"EUR": 1}
+	for _, c := range env.Cubes {
+		v, err := strconv.ParseFloat(c.Rate, 64)
+		if err != nil || v <= 0 {
+			continue
+		}
+		perEUR[c.Currency] = v
+	}
+	usd, ok := perEUR[store.BaseCurrency]
+	if !ok {
+		return nil, fmt.Errorf("ecb feed has no %s rate", store.BaseCurrency)
+	}
+	out := make(map[string]float64, len(perEUR))
+	for currency, v := range perEUR {
+		if currency != store.BaseCurrency {
+			out[currency] = v / usd
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+// Package guardrails classifies prompts and completions for content policy
+// violations before and after they pass through the router.
+package guardrails
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of classifying one piece of text.
+type Result struct {
+	Flagged    bool               `json:"flagged"`
+	Categories []string           `json:"categories"`
+	Scores     map[string]float64 `json:"scores,omitempty"`
+}
+
+// Classifier decides whether text violates a content policy.
+type Classifier interface {
+	Classify(ctx context.Context, text string) (Result, error)
+}
+
+// ModerationAPI calls an OpenAI-compatible /v1/moderations endpoint. Pointing
+// URL at a self-hosted service with the same schema gives a local classifier.
+type ModerationAPI struct {
+	URL    string
+	APIKey string
+	Model  string
+	Client *http.Client
+}
+
+func NewModerationAPI(url, apiKey, model string, timeout time.Duration) *ModerationAPI {
+	return &ModerationAPI{URL: url, APIKey: apiKey, Model: model, Client: &http.Client{Timeout: timeout}}
+}
+
+func (m *ModerationAPI) Classify(ctx context.Context, text string) (Result, error) {
+	payload := map[string]string{"input": text}
+	if m.Model != "" {
+		payload["model"] = m.Model
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.APIKey)
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Result{}, fmt.Errorf("moderation api: %d %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	var out struct {
+		Results []struct {
+			Flagged        bool               `json:"flagged"`
+			Categories     map[string]bool    `json:"categories"`
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Result{}, err
+	}
+	var res Result
+	for _, r := range out.Results {
+		res.Flagged = res.Flagged || r.Flagged
+		for cat, hit := range r.Categories {
+			if hit {
+				res.Categories = append(res.Categories, cat)
+			}
+		}
+		if len(r.CategoryScores) > 0 && res.Scores == nil {
+			res.Scores = r.CategoryScores
+		}
+	}
+	sort.Strings(res.Categories)
+	return res, nil
+}
+
+// PatternClassifier flags text matching per-category regular expressions.
+type PatternClassifier struct {
+	patterns map[string]*regexp.Regexp
+}
+
+// ParsePatterns parses "category=regex;category=regex". Patterns are
+// case-insensitive.
+func ParsePatterns(spec string) (*PatternClassifier, error) {
+	pc := &PatternClassifier{patterns: map[string]*regexp.Regexp{}}
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		cat, expr, ok := strings.Cut(item, "=")
+		if !ok || cat == "" || expr == "" {
+			return nil, fmt.Errorf("invalid moderation pattern %q", item)
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("moderation pattern %s: %w", cat, err)
+		}
+		pc.patterns[strings.TrimSpace(cat)] = re
+	}
+	return pc, nil
+}
+
+func (p *PatternClassifier) Classify(_ context.Context, text string) (Result, error) {
+	var res Result
+	for cat, re := range p.patterns {
+		if re.MatchString(text) {
+			res.Categories = append(res.Categories, cat)
+		}
+	}
+	sort.Strings(res.Categories)
+	res.Flagged = len(res.Categories) > 0
+	return res, nil
+}
@@ -0,0 +1,220 @@
+// Package jobs runs background work from a persistent Postgres queue, so
+// queued and periodic work survives restarts and is shared across instances.
+//
+// Loops that watch one instance's own state stay plain goroutines: the
+// brownout probe of this instance's database latency and limiter, the
+// routing change subscription, cluster heartbeats and lease renewals. They
+// have nothing to persist and must run on every instance.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/segmentio/ksuid"
+	"go.uber.org/zap"
+
+	"routerx/internal/store"
+)
+
+// jobLease is how long a claimed job is reserved for its worker. Handlers
+// that may run longer must finish their work idempotently, as a lapsed job is
+// claimed again.
+const jobLease = 5 * time.Minute
+
+// Handler runs one job. A returned error fails the attempt.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type registration struct {
+	handler     Handler
+	maxAttempts int
+	every       time.Duration
+}
+
+// Runner claims jobs of registered kinds and runs them on a bounded worker
+// pool. Register every kind before Run.
+type Runner struct {
+	Store   *store.Store
+	Logger  *zap.Logger
+	Workers int
+	// BaseBackoff is the wait before the first retry; it doubles per attempt
+	// up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	worker   string
+	handlers map[string]registration
+	sem      chan struct{}
+}
+
+func New(st *store.Store, logger *zap.Logger, workers int) *Runner {
+	host, _ := os.Hostname()
+	return &Runner{
+		Store:       st,
+		Logger:      logger,
+		Workers:     workers,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  time.Hour,
+		worker:      fmt.Sprintf("%s-%d", host, os.Getpid()),
+		handlers:    map[string]registration{},
+		sem:         make(chan struct{}, workers),
+	}
+}
+
+// Handle registers h for jobs of kind, retried up to maxAttempts times.
+func (r *Runner) Handle(kind string, maxAttempts int, h Handler) {
+	r.handlers[kind] = registration{handler: h, maxAttempts: maxAttempts}
+}
+
+// Every registers h as a periodic job that runs once per interval across all
+// instances. Failures are not retried; the next run is scheduled either way.
+func (r *Runner) Every(kind string, interval time.Duration, h Handler) {
+	r.handlers[kind] = registration{handler: h, maxAttempts: 1, every: interval}
+}
+
+// Enqueue queues a job of kind to run at runAt (now if zero).
+func (r *Runner) Enqueue(ctx context.Context, kind string, payload interface{}, runAt time.Time) (string, error) {
+	reg, ok := r.handlers[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown job kind %q", kind)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	if runAt.IsZero() {
+		runAt = time.Now().UTC()
+	}
+	id := "job_" + ksuid.New().String()
+	_, err = r.Store.EnqueueJob(ctx, store.Job{ID: id, Kind: kind, Payload: body, MaxAttempts: reg.maxAttempts, RunAt: runAt})
+	return id, err
+}
+
+// EnqueueUnique queues a job like Enqueue unless a job with the same key is
+// already queued or running, in which case it does nothing.
+func (r *Runner) EnqueueUnique(ctx context.Context, kind, key string, payload interface{}) error {
+	reg, ok := r.handlers[kind]
+	if !ok {
+		return fmt.Errorf("unknown job kind %q", kind)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = r.Store.EnqueueJob(ctx, store.Job{ID: "job_" + ksuid.New().String(), Kind: kind, Payload: body, UniqueKey: &key, MaxAttempts: reg.maxAttempts, RunAt: time.Now().UTC()})
+	return err
+}
+
+// schedule queues the next run of a periodic job unless one is already live.
+func (r *Runner) schedule(ctx context.Context, kind string, runAt time.Time) {
+	key := kind
+	if _, err := r.Store.EnqueueJob(ctx, store.Job{ID: "job_" + ksuid.New().String(), Kind: kind, UniqueKey: &key, MaxAttempts: 1, RunAt: runAt}); err != nil {
+		r.Logger.Warn("job schedule failed", zap.String("kind", kind), zap.Error(err))
+	}
+}
+
+// Reschedule queues a run of every periodic job that has none queued or
+// running, recovering a schedule lost when a run could not queue its
+// successor. One instance calling it is enough.
+func (r *Runner) Reschedule(ctx context.Context) error {
+	for kind, reg := range r.handlers {
+		if reg.every > 0 {
+			r.schedule(ctx, kind, time.Now().UTC())
+		}
+	}
+	return nil
+}
+
+// Run polls for due jobs every interval until ctx is cancelled. Jobs in
+// flight at shutdown are claimed again once their lease lapses.
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	kinds := make([]string, 0, len(r.handlers))
+	for kind, reg := range r.handlers {
+		kinds = append(kinds, kind)
+		if reg.every > 0 {
+			r.schedule(ctx, kind, time.Now().UTC())
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.poll(ctx, kinds)
+		}
+	}
+}
+
+func (r *Runner) poll(ctx context.Context, kinds []string) {
+	free := cap(r.sem) - len(r.sem)
+	if free <= 0 {
+		return
+	}
+	due, err := r.Store.ClaimJobs(ctx, kinds, free, jobLease, r.worker)
+	if err != nil {
+		r.Logger.Warn("job claim failed", zap.Error(err))
+		return
+	}
+	for _, j := range due {
+		r.sem <- struct{}{}
+		go func(j store.Job) {
+			defer func() { <-r.sem }()
+			r.run(ctx, j)
+		}(j)
+	}
+}
+
+func (r *Runner) run(ctx context.Context, j store.Job) {
+	reg := r.handlers[j.Kind]
+	jctx, cancel := context.WithTimeout(ctx, jobLease)
+	err := safeCall(jctx, reg.handler, j.Payload)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	status, lastError, retryAt := store.JobSucceeded, "", time.Now().UTC()
+	if err != nil {
+		lastError = err.Error()
+		status = store.JobFailed
+		if j.Attempts < j.MaxAttempts {
+			status = store.JobQueued
+			retryAt = retryAt.Add(r.backoff(j.Attempts))
+		}
+		r.Logger.Warn("job failed", zap.String("job_id", j.ID), zap.String("kind", j.Kind), zap.Int("attempt", j.Attempts), zap.Error(err))
+	}
+	if err := r.Store.FinishJob(ctx, j.ID, status, lastError, retryAt); err != nil {
+		r.Logger.Warn("job update failed", zap.String("job_id", j.ID), zap.Error(err))
+		return
+	}
+	if reg.every > 0 {
+		r.schedule(ctx, j.Kind, time.Now().UTC().Add(reg.every))
+	}
+}
+
+// safeCall runs h, turning a panic into an error so one bad job cannot take
+// the process down.
+func safeCall(ctx context.Context, h Handler, payload json.RawMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h(ctx, payload)
+}
+
+// backoff returns the wait before retrying after the given attempt.
+func (r *Runner) backoff(attempt int) time.Duration {
+	wait := r.BaseBackoff
+	for i := 1; i < attempt && wait < r.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > r.MaxBackoff {
+		wait = r.MaxBackoff
+	}
+	return wait
+}
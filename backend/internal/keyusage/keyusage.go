@@ -0,0 +1,168 @@
+// Package keyusage counts requests and tokens per API key. Counts accumulate
+// in Redis on the request path and are flushed to Postgres in the background,
+// so tracking costs no database write per request.
+package keyusage
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"routerx/internal/jobs"
+	"routerx/internal/store"
+)
+
+const (
+	// JobFlush is the job kind Register schedules.
+	JobFlush = "api_keys.flush_usage"
+
+	dirtySet = "key_usage:dirty"
+	// counterTTL bounds how long unflushed counters survive if flushing stops.
+	counterTTL = 7 * 24 * time.Hour
+	flushBatch = 500
+	dayLayout  = "2006-01-02"
+)
+
+// Tracker records per-key usage. Without Redis it writes straight to the
+// store.
+type Tracker struct {
+	Redis  redis.UniversalClient
+	Store  *store.Store
+	Logger *zap.Logger
+}
+
+func New(rdb redis.UniversalClient, st *store.Store, logger *zap.Logger) *Tracker {
+	return &Tracker{Redis: rdb, Store: st, Logger: logger}
+}
+
+// Record counts requests and tokens against key. Failures are logged, never
+// returned: usage tracking must not fail the request.
+func (t *Tracker) Record(ctx context.Context, key string, requests, tokens int) {
+	if t == nil || key == "" {
+		return
+	}
+	now := time.Now().UTC()
+	if t.Redis == nil {
+		if err := t.Store.AddAPIKeyUsage(ctx, []store.APIKeyUsage{{Key: key, Day: day(now), Requests: int64(requests), Tokens: int64(tokens), LastUsed: now}}); err != nil {
+			t.Logger.Warn("api key usage write failed", zap.Error(err))
+		}
+		return
+	}
+	member := now.Format(dayLayout) + "|" + key
+	counter := "key_usage:" + member
+	pipe := t.Redis.Pipeline()
+	if requests != 0 {
+		pipe.HIncrBy(ctx, counter, "requests", int64(requests))
+	}
+	if tokens != 0 {
+		pipe.HIncrBy(ctx, counter, "tokens", int64(tokens))
+	}
+	pipe.HSet(ctx, counter, "last_used", now.Unix())
+	pipe.Expire(ctx, counter, counterTTL)
+	pipe.SAdd(ctx, dirtySet, member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Logger.Warn("api key usage record failed", zap.Error(err))
+	}
+}
+
+// Register flushes counters to Postgres each interval on r.
+func (t *Tracker) Register(r *jobs.Runner, interval time.Duration) {
+	r.Every(JobFlush, interval, func(ctx context.Context, _ json.RawMessage) error {
+		return t.Flush(ctx)
+	})
+}
+
+// Flush moves pending counters into Postgres. Each counter is read and
+// deleted atomically, so increments racing the flush land in the next one.
+func (t *Tracker) Flush(ctx context.Context) error {
+	if t.Redis == nil {
+		return nil
+	}
+	for {
+		members, err := t.Redis.SPopN(ctx, dirtySet, flushBatch).Result()
+		if err != nil || len(members) == 0 {
+			return err
+		}
+		usage := make([]store.APIKeyUsage, 0, len(members))
+		for i, m := range members {
+			u, ok, err := t.take(ctx, m)
+			if err != nil {
+				// Requeue what this batch has not taken yet and put back
+				// what it has.
+				rest := make([]interface{}, 0, len(members)-i)
+				for _, m := range members[i:] {
+					rest = append(rest, m)
+				}
+				t.Redis.SAdd(ctx, dirtySet, rest...)
+				t.restore(ctx, usage)
+				return err
+			}
+			if ok {
+				usage = append(usage, u)
+			}
+		}
+		if err := t.Store.AddAPIKeyUsage(ctx, usage); err != nil {
+			t.restore(ctx, usage)
+			return err
+		}
+		if len(members) < flushBatch {
+			return nil
+		}
+	}
+}
+
+// take reads and clears the counter for a dirty-set member.
+func (t *Tracker) take(ctx context.Context, member string) (store.APIKeyUsage, bool, error) {
+	d, key, ok := strings.Cut(member, "|")
+	if !ok {
+		return store.APIKeyUsage{}, false, nil
+	}
+	dayStart, err := time.Parse(dayLayout, d)
+	if err != nil {
+		return store.APIKeyUsage{}, false, nil
+	}
+	counter := "key_usage:" + member
+	pipe := t.Redis.TxPipeline()
+	fields := pipe.HGetAll(ctx, counter)
+	pipe.Del(ctx, counter)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return store.APIKeyUsage{}, false, err
+	}
+	vals := fields.Val()
+	if len(vals) == 0 {
+		return store.APIKeyUsage{}, false, nil
+	}
+	u := store.APIKeyUsage{Key: key, Day: dayStart}
+	u.Requests, _ = strconv.ParseInt(vals["requests"], 10, 64)
+	u.Tokens, _ = strconv.ParseInt(vals["tokens"], 10, 64)
+	if ts, err := strconv.ParseInt(vals["last_used"], 10, 64); err == nil {
+		u.LastUsed = time.Unix(ts, 0).UTC()
+	}
+	return u, true, nil
+}
+
+// restore puts counters taken by a failed flush back so they are retried.
+func (t *Tracker) restore(ctx context.Context, usage []store.APIKeyUsage) {
+	pipe := t.Redis.Pipeline()
+	for _, u := range usage {
+		member := u.Day.Format(dayLayout) + "|" + u.Key
+		counter := "key_usage:" + member
+		pipe.HIncrBy(ctx, counter, "requests", u.Requests)
+		pipe.HIncrBy(ctx, counter, "tokens", u.Tokens)
+		pipe.HSet(ctx, counter, "last_used", u.LastUsed.Unix())
+		pipe.Expire(ctx, counter, counterTTL)
+		pipe.SAdd(ctx, dirtySet, member)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Logger.Warn("api key usage restore failed", zap.Int("keys", len(usage)), zap.Error(err))
+	}
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,125 @@
+package limiter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// allowanceKeyTTL keeps a day's counters past midnight so a request claimed
+// just before it can still settle.
+const allowanceKeyTTL = 48 * time.Hour
+
+// claimAllowanceScript seeds the day's counters if they are missing, then
+// counts one request if the tenant is below every non-zero limit and
+// reserves the tokens it may use. With a token limit, the completion is
+// capped to what the prompt leaves of the allowance. It returns the
+// completion cap (0 for none), or -1 when the allowance is used up.
+// KEYS: counter hash. ARGV: request limit, token limit, seed requests, seed
+// tokens, key ttl, prompt tokens, requested max tokens.
+var claimAllowanceScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  redis.call('HSET', KEYS[1], 'requests', ARGV[3], 'tokens', ARGV[4])
+  redis.call('EXPIRE', KEYS[1], ARGV[5])
+end
+local h = redis.call('HMGET', KEYS[1], 'requests', 'tokens')
+local req, tok = tonumber(h[1]) or 0, tonumber(h[2]) or 0
+local rl, tl = tonumber(ARGV[1]), tonumber(ARGV[2])
+local prompt, max = tonumber(ARGV[6]), tonumber(ARGV[7])
+if rl > 0 and req >= rl then return -1 end
+if tl > 0 then
+  local room = tl - tok - prompt
+  if room <= 0 then return -1 end
+  if max == 0 or max > room then max = room end
+end
+redis.call('HINCRBY', KEYS[1], 'requests', 1)
+redis.call('HINCRBY', KEYS[1], 'tokens', prompt + max)
+return max
+`)
+
+// FreeAllowances counts each tenant's daily use of models' free allowances.
+// Counters are per UTC day; when a day's counter is missing, e.g. because
+// Redis lost it, it is rebuilt from the usage recorded in Postgres.
+type FreeAllowances struct {
+	Redis redis.UniversalClient
+}
+
+func NewFreeAllowances(client redis.UniversalClient) *FreeAllowances {
+	return &FreeAllowances{Redis: client}
+}
+
+// AllowanceSeed loads the free requests and tokens already recorded for a
+// tenant and model on the current day.
+type AllowanceSeed func(ctx context.Context) (requests, tokens int64, err error)
+
+// FreeClaim is a request admitted under a free allowance. Settle it once the
+// request finishes.
+type FreeClaim struct {
+	a        *FreeAllowances
+	key      string
+	reserved int
+	// MaxTokens caps the request's completion so it stays within the token
+	// allowance; 0 when the allowance has no token limit.
+	MaxTokens int
+}
+
+func allowanceKey(tenantID, model string, day time.Time) string {
+	return "free_allowance:" + tenantID + ":" + model + ":" + day.UTC().Format("2006-01-02")
+}
+
+// Claim counts a request against the tenant's allowance for model today and
+// reserves promptTokens plus its completion, maxTokens or, when that is 0 or
+// more than the allowance has left, the rest of the token allowance. It
+// returns ok=false when the allowance is used up.
+func (f *FreeAllowances) Claim(ctx context.Context, tenantID, model string, requestsPerDay, tokensPerDay, promptTokens, maxTokens int, seed AllowanceSeed) (*FreeClaim, bool, error) {
+	key := allowanceKey(tenantID, model, time.Now())
+	var seedReq, seedTok int64
+	n, err := f.Redis.Exists(ctx, key).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if n == 0 {
+		if seedReq, seedTok, err = seed(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+	granted, err := claimAllowanceScript.Run(ctx, f.Redis, []string{key},
+		requestsPerDay, tokensPerDay, seedReq, seedTok, int(allowanceKeyTTL.Seconds()), promptTokens, maxTokens).Int()
+	if err != nil || granted < 0 {
+		return nil, false, err
+	}
+	c := &FreeClaim{a: f, key: key, reserved: promptTokens + granted}
+	if tokensPerDay > 0 {
+		c.MaxTokens = granted
+	}
+	return c, true, nil
+}
+
+// Settle replaces the claim's reserved tokens with those a successful
+// request used, or gives back the request and its reservation if it failed.
+func (c *FreeClaim) Settle(ctx context.Context, tokens int, succeeded bool) {
+	if !succeeded {
+		pipe := c.a.Redis.Pipeline()
+		pipe.HIncrBy(ctx, c.key, "requests", -1)
+		pipe.HIncrBy(ctx, c.key, "tokens", -int64(c.reserved))
+		_, _ = pipe.Exec(ctx)
+		return
+	}
+	if delta := tokens - c.reserved; delta != 0 {
+		c.a.Redis.HIncrBy(ctx, c.key, "tokens", int64(delta))
+	}
+}
+
+// Used returns the tenant's counted use of model today. found is false when
+// there is no counter, in which case Postgres has the answer.
+func (f *FreeAllowances) Used(ctx context.Context, tenantID, model string) (requests, tokens int64, found bool, err error) {
+	h, err := f.Redis.HGetAll(ctx, allowanceKey(tenantID, model, time.Now())).Result()
+	if err != nil || len(h) == 0 {
+		return 0, 0, false, err
+	}
+	requests, _ = strconv.ParseInt(h["requests"], 10, 64)
+	tokens, _ = strconv.ParseInt(h["tokens"], 10, 64)
+	return requests, tokens, true, nil
+}
@@ -0,0 +1,144 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"routerx/internal/metrics"
+	"routerx/internal/store"
+)
+
+// Brownout levels. Each level tightens limits for lower tenant tiers while
+// premium traffic is left untouched.
+const (
+	BrownoutNone     = 0
+	BrownoutElevated = 1 // free tier capped to a single in-flight request
+	BrownoutCritical = 2 // free tier shed, standard tier concurrency halved
+)
+
+// BrownoutState is the last load sample and the level derived from it.
+type BrownoutState struct {
+	Level       int        `json:"level"`
+	Reason      string     `json:"reason,omitempty"`
+	DBLatencyMS int64      `json:"db_latency_ms"`
+	Saturation  float64    `json:"saturation"`
+	Since       *time.Time `json:"since,omitempty"`
+	CheckedAt   time.Time  `json:"checked_at"`
+}
+
+// Brownout tracks overall system load and decides how aggressively to shed
+// low-tier traffic. Load is the worse of database round-trip latency and
+// limiter saturation, each relative to its threshold.
+type Brownout struct {
+	DBLatencyThreshold  time.Duration
+	SaturationThreshold float64
+	RetryAfter          time.Duration
+
+	mu    sync.RWMutex
+	state BrownoutState
+}
+
+func NewBrownout(dbLatency time.Duration, saturation float64) *Brownout {
+	return &Brownout{DBLatencyThreshold: dbLatency, SaturationThreshold: saturation, RetryAfter: 30 * time.Second}
+}
+
+// Run samples load every interval until ctx is cancelled.
+func (b *Brownout) Run(ctx context.Context, interval time.Duration, probe func(context.Context) error, saturation func() float64) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pctx, cancel := context.WithTimeout(ctx, interval)
+			start := time.Now()
+			err := probe(pctx)
+			latency := time.Since(start)
+			cancel()
+			b.Observe(latency, saturation(), err)
+		}
+	}
+}
+
+// Observe records a load sample. A failed database probe counts as critical.
+// Levels only step down once pressure falls below 80% of the current level so
+// the state does not flap around a threshold.
+func (b *Brownout) Observe(dbLatency time.Duration, saturation float64, probeErr error) {
+	pressure, reason := 0.0, ""
+	if b.DBLatencyThreshold > 0 {
+		if p := float64(dbLatency) / float64(b.DBLatencyThreshold); p > pressure {
+			pressure, reason = p, "database latency"
+		}
+	}
+	if b.SaturationThreshold > 0 {
+		if p := saturation / b.SaturationThreshold; p > pressure {
+			pressure, reason = p, "limiter saturation"
+		}
+	}
+	if probeErr != nil {
+		pressure, reason = BrownoutCritical, "database unavailable"
+	}
+	level := BrownoutNone
+	switch {
+	case pressure >= BrownoutCritical:
+		level = BrownoutCritical
+	case pressure >= BrownoutElevated:
+		level = BrownoutElevated
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now().UTC()
+	if level < b.state.Level && pressure >= 0.8*float64(b.state.Level) {
+		level = b.state.Level
+	}
+	if level == BrownoutNone {
+		reason = ""
+		b.state.Since = nil
+	} else if b.state.Level == BrownoutNone {
+		b.state.Since = &now
+	}
+	b.state.Level = level
+	b.state.Reason = reason
+	b.state.DBLatencyMS = dbLatency.Milliseconds()
+	b.state.Saturation = saturation
+	b.state.CheckedAt = now
+	metrics.BrownoutLevel.Set(float64(level))
+}
+
+func (b *Brownout) State() BrownoutState {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.state
+}
+
+// ConcurrencyLimit returns the concurrency ceiling for a tenant tier under
+// the current level. Zero means the request should be shed.
+func (b *Brownout) ConcurrencyLimit(tier string, base int) int {
+	level := b.State().Level
+	switch tier {
+	case store.TierPremium:
+		return base
+	case store.TierFree:
+		switch level {
+		case BrownoutElevated:
+			return 1
+		case BrownoutCritical:
+			return 0
+		}
+	default:
+		if level == BrownoutCritical {
+			if base/2 < 1 {
+				return 1
+			}
+			return base / 2
+		}
+	}
+	return base
+}
+
+func (b *Brownout) RetryAfterSeconds() int {
+	return int(b.RetryAfter.Seconds())
+}
@@ -0,0 +1,152 @@
+package limiter
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/ksuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"routerx/internal/metrics"
+)
+
+// Concurrency slots are leases in a per-tenant sorted set scored by expiry.
+// A held lease is renewed every LeaseRenewInterval, so a request keeps its
+// slot however long it streams, while the slots of a crashed process lapse
+// after LeaseTTL instead of leaking.
+const (
+	LeaseTTL           = 30 * time.Second
+	LeaseRenewInterval = LeaseTTL / 3
+)
+
+// acquireLeaseScript drops lapsed leases and adds one if the tenant is
+// under its limit. It returns the number of live leases after the call, or
+// -(live leases + 1) when the tenant is at its limit.
+// KEYS: lease set. ARGV: now ms, expiry ms, member, limit, key ttl ms.
+var acquireLeaseScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local n = redis.call('ZCARD', KEYS[1])
+if n >= tonumber(ARGV[4]) then return -(n + 1) end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return n + 1
+`)
+
+// renewLeaseScript extends a lease that is still held. It returns 0 when the
+// lease already lapsed and was swept.
+var renewLeaseScript = redis.NewScript(`
+local renewed = redis.call('ZADD', KEYS[1], 'XX', 'CH', ARGV[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return renewed
+`)
+
+func leasesKey(tenantID string) string {
+	return "conc_leases:" + tenantID
+}
+
+// leaseMember names a lease. keyID follows the first "|" so Stats can count
+// leases per API key.
+func leaseMember(keyID string) string {
+	return ksuid.New().String() + "|" + keyID
+}
+
+func leaseKeyID(member string) string {
+	_, keyID, _ := strings.Cut(member, "|")
+	return keyID
+}
+
+// Lease is a held concurrency slot. Release it exactly once when the
+// request finishes; until then it renews itself in the background.
+type Lease struct {
+	l        *Limiter
+	tenantID string
+	member   string
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// Acquire takes a concurrency slot for a request made with keyID, which
+// only labels the slot in Stats.
+func (l *Limiter) Acquire(ctx context.Context, tenantID, keyID string) (*Lease, bool, error) {
+	return l.AcquireLimit(ctx, tenantID, keyID, l.Conc)
+}
+
+// AcquireLimit is Acquire with an explicit concurrency ceiling, used when
+// brownout tightens a tenant's limit below the default.
+func (l *Limiter) AcquireLimit(ctx context.Context, tenantID, keyID string, limit int) (*Lease, bool, error) {
+	l.attempts.Add(1)
+	ctx, span := tracer.Start(ctx, "limiter.acquire", trace.WithAttributes(attribute.String("routerx.tenant_id", tenantID), attribute.Int("limiter.limit", limit)))
+	member := leaseMember(keyID)
+	now := time.Now()
+	n, err := acquireLeaseScript.Run(ctx, l.Redis, []string{leasesKey(tenantID)},
+		now.UnixMilli(), now.Add(LeaseTTL).UnixMilli(), member, limit, LeaseTTL.Milliseconds()).Int64()
+	if err != nil {
+		endSpan(span, false, err)
+		return nil, false, err
+	}
+	if n < 0 {
+		pipe := l.Redis.Pipeline()
+		l.count(ctx, pipe, tenantID, statConcurrencyLimited)
+		pipe.Exec(ctx)
+		// Only rejections the base limit would also have made count towards
+		// Saturation; ones caused by a brownout's tighter limit would
+		// otherwise keep the brownout going.
+		if -n-1 >= int64(l.Conc) {
+			l.rejected.Add(1)
+		}
+		endSpan(span, false, nil)
+		return nil, false, nil
+	}
+	metrics.TenantConcurrency.WithLabelValues(metrics.TenantLabel(tenantID)).Set(float64(n))
+	endSpan(span, true, nil)
+	lease := &Lease{l: l, tenantID: tenantID, member: member, stop: make(chan struct{})}
+	go lease.renew()
+	return lease, true, nil
+}
+
+// renew extends the lease until Release. A failed renewal is retried at the
+// next tick; the lease only lapses if renewals fail for a whole LeaseTTL.
+func (s *Lease) renew() {
+	ticker := time.NewTicker(LeaseRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), LeaseRenewInterval)
+			renewed, err := renewLeaseScript.Run(ctx, s.l.Redis, []string{leasesKey(s.tenantID)},
+				strconv.FormatInt(time.Now().Add(LeaseTTL).UnixMilli(), 10), s.member, LeaseTTL.Milliseconds()).Int()
+			cancel()
+			if err == nil && renewed == 0 {
+				// Swept while renewals were failing; the slot is gone.
+				return
+			}
+		}
+	}
+}
+
+// Release gives the slot back. It is safe on a nil lease and idempotent.
+func (s *Lease) Release(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.stop)
+		ctx, span := tracer.Start(ctx, "limiter.release", trace.WithAttributes(attribute.String("routerx.tenant_id", s.tenantID)))
+		defer span.End()
+		pipe := s.l.Redis.Pipeline()
+		pipe.ZRem(ctx, leasesKey(s.tenantID), s.member)
+		card := pipe.ZCard(ctx, leasesKey(s.tenantID))
+		if _, err := pipe.Exec(ctx); err != nil {
+			span.RecordError(err)
+			return
+		}
+		metrics.TenantConcurrency.WithLabelValues(metrics.TenantLabel(s.tenantID)).Set(float64(card.Val()))
+	})
+}
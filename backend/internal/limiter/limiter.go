@@ -1,54 +1,85 @@
-﻿package limiter
+package limiter
 
 import (
 	"context"
-	"time"
+	"sync/atomic"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("routerx/limiter")
+
+// endSpan records the limiter decision (or Redis error) and ends span.
+func endSpan(span trace.Span, allowed bool, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "redis error")
+	} else {
+		span.SetAttributes(attribute.Bool("limiter.allowed", allowed))
+	}
+	span.End()
+}
+
 type Limiter struct {
-	Redis *redis.Client
+	Redis redis.UniversalClient
 	QPS   int
 	Conc  int
+	// Algorithm is AlgorithmFixed (the default when empty), AlgorithmSliding
+	// or AlgorithmTokenBucket. Burst is the default token bucket capacity;
+	// zero means QPS.
+	Algorithm string
+	Burst     int
+
+	attempts atomic.Int64
+	rejected atomic.Int64
 }
 
-func New(client *redis.Client, qps, conc int) *Limiter {
+func New(client redis.UniversalClient, qps, conc int) *Limiter {
 	return &Limiter{Redis: client, QPS: qps, Conc: conc}
 }
 
 func (l *Limiter) Allow(ctx context.Context, tenantID string) (bool, error) {
-	key := "qps:" + tenantID + ":" + time.Now().UTC().Format("20060102150405")
-	pipe := l.Redis.TxPipeline()
-	incr := pipe.Incr(ctx, key)
-	pipe.Expire(ctx, key, 2*time.Second)
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		return false, err
-	}
-	if int(incr.Val()) > l.QPS {
-		return false, nil
-	}
-	return true, nil
+	return l.AllowRate(ctx, tenantID, 0, 0)
 }
 
-func (l *Limiter) Acquire(ctx context.Context, tenantID string) (bool, error) {
-	key := "conc:" + tenantID
-	val, err := l.Redis.Incr(ctx, key).Result()
+// AllowRate is Allow with a tenant's own rate and burst; zero values fall
+// back to the limiter defaults.
+func (l *Limiter) AllowRate(ctx context.Context, tenantID string, qps, burst int) (bool, error) {
+	if qps <= 0 {
+		qps = l.QPS
+	}
+	if burst <= 0 {
+		burst = l.Burst
+	}
+	ctx, span := tracer.Start(ctx, "limiter.allow", trace.WithAttributes(attribute.String("routerx.tenant_id", tenantID), attribute.Int("limiter.qps", qps)))
+	allowed, err := l.allow(ctx, tenantID, qps, burst)
 	if err != nil {
+		endSpan(span, false, err)
 		return false, err
 	}
-	if val == 1 {
-		l.Redis.Expire(ctx, key, 60*time.Second)
-	}
-	if int(val) > l.Conc {
-		l.Redis.Decr(ctx, key)
-		return false, nil
+	pipe := l.Redis.Pipeline()
+	l.count(ctx, pipe, tenantID, statRequests)
+	if !allowed {
+		l.count(ctx, pipe, tenantID, statRateLimited)
 	}
-	return true, nil
+	pipe.Exec(ctx)
+	endSpan(span, allowed, nil)
+	return allowed, nil
 }
 
-func (l *Limiter) Release(ctx context.Context, tenantID string) {
-	key := "conc:" + tenantID
-	_ = l.Redis.Decr(ctx, key).Err()
+// Saturation returns the fraction of concurrency acquisitions since the
+// previous call that were rejected at the base limit, Conc, and resets the
+// counters. Rejections under a lower, brownout-tightened limit are not
+// counted, so shedding load does not itself read as saturation.
+func (l *Limiter) Saturation() float64 {
+	attempts := l.attempts.Swap(0)
+	rejected := l.rejected.Swap(0)
+	if attempts == 0 {
+		return 0
+	}
+	return float64(rejected) / float64(attempts)
 }
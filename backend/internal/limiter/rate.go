@@ -0,0 +1,94 @@
+package limiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rate limiting algorithms, selected by LIMITER_ALGORITHM.
+const (
+	// AlgorithmFixed counts requests per wall-clock second. It is the
+	// cheapest, but admits up to 2×QPS across a second boundary.
+	AlgorithmFixed = "fixed"
+	// AlgorithmSliding weights the previous second's count by how much of
+	// it still overlaps the last 1s, smoothing the boundary burst.
+	AlgorithmSliding = "sliding"
+	// AlgorithmTokenBucket refills QPS tokens a second up to a burst
+	// capacity, so a tenant may spend saved-up capacity in one burst.
+	AlgorithmTokenBucket = "token_bucket"
+)
+
+// ValidAlgorithm reports whether name is a known rate limiting algorithm.
+func ValidAlgorithm(name string) bool {
+	return name == AlgorithmFixed || name == AlgorithmSliding || name == AlgorithmTokenBucket
+}
+
+// slidingWindowScript keeps the current and previous second's counts in one
+// hash. Time comes from Redis so instances with skewed clocks agree.
+// KEYS: window hash. ARGV: limit.
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local limit = tonumber(ARGV[1])
+local win = math.floor(now / 1000)
+local h = redis.call('HMGET', KEYS[1], 'w', 'cur', 'prev')
+local w, cur, prev = tonumber(h[1]) or win, tonumber(h[2]) or 0, tonumber(h[3]) or 0
+if win == w + 1 then
+  prev, cur = cur, 0
+elseif win > w + 1 then
+  prev, cur = 0, 0
+end
+local allowed = 0
+if prev * (1 - (now % 1000) / 1000) + cur + 1 <= limit then
+  cur = cur + 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'w', win, 'cur', cur, 'prev', prev)
+redis.call('PEXPIRE', KEYS[1], 2000)
+return allowed
+`)
+
+// tokenBucketScript refills the bucket for the time since it was last used
+// and takes one token if there is one.
+// KEYS: bucket hash. ARGV: rate (tokens/s), burst.
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+local rate, burst = tonumber(ARGV[1]), tonumber(ARGV[2])
+local h = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens, ts = tonumber(h[1]) or burst, tonumber(h[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return allowed
+`)
+
+// allow applies the configured algorithm to one request.
+func (l *Limiter) allow(ctx context.Context, tenantID string, qps, burst int) (bool, error) {
+	switch l.Algorithm {
+	case AlgorithmSliding:
+		ok, err := slidingWindowScript.Run(ctx, l.Redis, []string{"qps_sliding:" + tenantID}, qps).Int()
+		return ok == 1, err
+	case AlgorithmTokenBucket:
+		if burst <= 0 {
+			burst = qps
+		}
+		ok, err := tokenBucketScript.Run(ctx, l.Redis, []string{"qps_bucket:" + tenantID}, qps, burst).Int()
+		return ok == 1, err
+	}
+	key := "qps:" + tenantID + ":" + time.Now().UTC().Format("20060102150405")
+	pipe := l.Redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return int(incr.Val()) <= qps, nil
+}
@@ -0,0 +1,143 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReservationTTL is how long a reservation holds balance if the request
+// that made it never releases it, e.g. because its instance died.
+const ReservationTTL = 10 * time.Minute
+
+// chargesField counts, in the reservations hash, the charges committed
+// against the tenant's balance. A reservation made against a balance read
+// before the count moved is retried, since the balance may be stale.
+const chargesField = "_charges"
+
+// reserveAttempts bounds how often Reserve re-reads a balance that changed
+// under it.
+const reserveAttempts = 3
+
+// reserveBalanceScript admits a reservation when the tenant's outstanding
+// reservations plus this one fit in its balance, dropping expired entries
+// first. Entries are "amount:expiry" in micro-USD and Unix seconds. It
+// returns -1 when a charge was committed since the balance was read.
+// KEYS: reservations hash. ARGV: id, amount, balance, now, expiry, key ttl,
+// charge count the balance was read at.
+var reserveBalanceScript = redis.NewScript(`
+if (redis.call('HGET', KEYS[1], '_charges') or '0') ~= ARGV[7] then return -1 end
+local amount, balance, now = tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4])
+local held = 0
+local entries = redis.call('HGETALL', KEYS[1])
+for i = 1, #entries, 2 do
+  if entries[i] ~= '_charges' then
+    local amt, exp = string.match(entries[i+1], '^(%d+):(%d+)$')
+    if not amt or tonumber(exp) <= now then
+      redis.call('HDEL', KEYS[1], entries[i])
+    else
+      held = held + tonumber(amt)
+    end
+  end
+end
+if held + amount > balance then return 0 end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2] .. ':' .. ARGV[5])
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+return 1
+`)
+
+// ErrBalanceChanging is returned by Reserve when charges kept landing while
+// it read the balance.
+var ErrBalanceChanging = errors.New("balance changed during reservation")
+
+// Reservations holds part of a tenant's balance for each request in flight,
+// so parallel requests cannot together spend more than the balance before
+// any of them is charged.
+type Reservations struct {
+	Redis redis.UniversalClient
+}
+
+func NewReservations(client redis.UniversalClient) *Reservations {
+	return &Reservations{Redis: client}
+}
+
+func reservationKey(tenantID string) string {
+	return "balance_reservations:" + tenantID
+}
+
+func microUSD(usd float64) int64 {
+	return int64(math.Ceil(usd * 1e6))
+}
+
+// Reserve holds amountUSD of the tenant's balance under id. It reads the
+// balance with balance, and reads it again if a charge is committed before
+// the reservation is made, so a request is never admitted against a balance
+// that a finished request has already spent. It returns false when the
+// reservations in flight leave too little balance.
+func (r *Reservations) Reserve(ctx context.Context, tenantID, id string, amountUSD float64, balance func(context.Context) (float64, error)) (bool, error) {
+	key := reservationKey(tenantID)
+	for i := 0; i < reserveAttempts; i++ {
+		charges, err := r.Redis.HGet(ctx, key, chargesField).Result()
+		if errors.Is(err, redis.Nil) {
+			charges = "0"
+		} else if err != nil {
+			return false, err
+		}
+		balanceUSD, err := balance(ctx)
+		if err != nil {
+			return false, err
+		}
+		now := time.Now()
+		ok, err := reserveBalanceScript.Run(ctx, r.Redis, []string{key},
+			id, microUSD(amountUSD), int64(math.Floor(balanceUSD*1e6)), now.Unix(), now.Add(ReservationTTL).Unix(), int(ReservationTTL.Seconds()), charges).Int()
+		if err != nil {
+			return false, err
+		}
+		if ok >= 0 {
+			return ok == 1, nil
+		}
+	}
+	return false, ErrBalanceChanging
+}
+
+// Charged records a charge committed against the tenant's balance. Call it
+// after the charge and before releasing the request's reservation.
+func (r *Reservations) Charged(ctx context.Context, tenantID string) {
+	key := reservationKey(tenantID)
+	pipe := r.Redis.Pipeline()
+	pipe.HIncrBy(ctx, key, chargesField, 1)
+	pipe.Expire(ctx, key, ReservationTTL)
+	_, _ = pipe.Exec(ctx)
+}
+
+// Release drops a reservation once its request has been charged or failed.
+func (r *Reservations) Release(ctx context.Context, tenantID, id string) {
+	r.Redis.HDel(ctx, reservationKey(tenantID), id)
+}
+
+// Outstanding is the total the tenant has reserved for requests in flight.
+func (r *Reservations) Outstanding(ctx context.Context, tenantID string) (float64, error) {
+	entries, err := r.Redis.HGetAll(ctx, reservationKey(tenantID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().Unix()
+	var held int64
+	for id, v := range entries {
+		if id == chargesField {
+			continue
+		}
+		a, e, _ := strings.Cut(v, ":")
+		amt, _ := strconv.ParseInt(a, 10, 64)
+		exp, _ := strconv.ParseInt(e, 10, 64)
+		if exp > now {
+			held += amt
+		}
+	}
+	return float64(held) / 1e6, nil
+}
@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limiter decisions are counted per tenant per minute so support can see why
+// a tenant is getting 429s. statsTTL bounds how far back Stats can look.
+const (
+	statsTTL        = time.Hour + time.Minute
+	MaxStatsMinutes = 60
+
+	statRequests           = "requests"
+	statRateLimited        = "rate_limited"
+	statConcurrencyLimited = "concurrency_limited"
+)
+
+// MinuteStats is one minute of limiter decisions for a tenant.
+type MinuteStats struct {
+	Minute             time.Time `json:"minute"`
+	Requests           int64     `json:"requests"`
+	RateLimited        int64     `json:"rate_limited"`
+	ConcurrencyLimited int64     `json:"concurrency_limited"`
+}
+
+// Stats is a tenant's current concurrency and its recent limiter decisions.
+// Requests counts every request that reached the limiter.
+type Stats struct {
+	InFlight           int64            `json:"in_flight"`
+	InFlightByKey      map[string]int64 `json:"in_flight_by_key"`
+	Minutes            int              `json:"window_minutes"`
+	Requests           int64            `json:"requests"`
+	RateLimited        int64            `json:"rate_limited"`
+	ConcurrencyLimited int64            `json:"concurrency_limited"`
+	ByMinute           []MinuteStats    `json:"by_minute"`
+}
+
+func statsKey(tenantID string, minute time.Time) string {
+	return "limiter_stats:" + tenantID + ":" + strconv.FormatInt(minute.Unix()/60, 10)
+}
+
+// count adds one to field in the tenant's current minute.
+func (l *Limiter) count(ctx context.Context, pipe redis.Pipeliner, tenantID, field string) {
+	key := statsKey(tenantID, time.Now())
+	pipe.HIncrBy(ctx, key, field, 1)
+	pipe.Expire(ctx, key, statsTTL)
+}
+
+// Stats reports the tenant's in-flight requests and its limiter decisions
+// over the last minutes (at most MaxStatsMinutes). Minutes with no traffic
+// are left out of ByMinute.
+func (l *Limiter) Stats(ctx context.Context, tenantID string, minutes int) (Stats, error) {
+	if minutes <= 0 || minutes > MaxStatsMinutes {
+		minutes = MaxStatsMinutes
+	}
+	now := time.Now().UTC().Truncate(time.Minute)
+	pipe := l.Redis.Pipeline()
+	leases := pipe.ZRangeByScore(ctx, leasesKey(tenantID), &redis.ZRangeBy{Min: strconv.FormatInt(time.Now().UnixMilli(), 10), Max: "+inf"})
+	cmds := make([]*redis.MapStringStringCmd, minutes)
+	for i := range cmds {
+		cmds[i] = pipe.HGetAll(ctx, statsKey(tenantID, now.Add(-time.Duration(minutes-1-i)*time.Minute)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Stats{}, err
+	}
+
+	st := Stats{Minutes: minutes, InFlightByKey: map[string]int64{}, ByMinute: []MinuteStats{}}
+	for _, member := range leases.Val() {
+		st.InFlight++
+		st.InFlightByKey[leaseKeyID(member)]++
+	}
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			continue
+		}
+		m := MinuteStats{Minute: now.Add(-time.Duration(minutes-1-i) * time.Minute)}
+		m.Requests, _ = strconv.ParseInt(fields[statRequests], 10, 64)
+		m.RateLimited, _ = strconv.ParseInt(fields[statRateLimited], 10, 64)
+		m.ConcurrencyLimited, _ = strconv.ParseInt(fields[statConcurrencyLimited], 10, 64)
+		st.Requests += m.Requests
+		st.RateLimited += m.RateLimited
+		st.ConcurrencyLimited += m.ConcurrencyLimited
+		st.ByMinute = append(st.ByMinute, m)
+	}
+	return st, nil
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import "sync"
+
+// OverflowLabel replaces label values once a guard is full.
+const OverflowLabel = "other"
+
+// LabelGuard admits up to Max distinct values for a label and maps the rest
+// to OverflowLabel, so per-tenant and per-model series stay bounded.
+type LabelGuard struct {
+	Max  int
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func NewLabelGuard(max int) *LabelGuard {
+	return &LabelGuard{Max: max, seen: map[string]struct{}{}}
+}
+
+// Value returns v if it is already tracked or there is room for it, and
+// OverflowLabel otherwise. Empty values are reported as "unknown".
+func (g *LabelGuard) Value(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.seen[v]; ok {
+		return v
+	}
+	if len(g.seen) >= g.Max {
+		return OverflowLabel
+	}
+	g.seen[v] = struct{}{}
+	return v
+}
+
+// Guards for the tenant and model labels. Limits can be changed at startup,
+// before any metric is recorded.
+var (
+	Tenants = NewLabelGuard(200)
+	Models  = NewLabelGuard(200)
+)
+
+func TenantLabel(id string) string { return Tenants.Value(id) }
+
+func ModelLabel(model string) string { return Models.Value(model) }
@@ -1,4 +1,4 @@
-﻿package metrics
+package metrics
 
 import "github.com/prometheus/client_golang/prometheus"
 
@@ -15,8 +15,95 @@ var (
 		prometheus.HistogramOpts{Name: "routerx_ttft_ms", Help: "Time to first token in ms", Buckets: prometheus.LinearBuckets(50, 50, 20)},
 		[]string{"provider"},
 	)
+	BrownoutLevel = prometheus.NewGauge(
+		prometheus.GaugeOpts{Name: "routerx_brownout_level", Help: "Current brownout level (0 = normal)"},
+	)
+	BrownoutShedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_brownout_shed_total", Help: "Requests shed by brownout"},
+		[]string{"tier"},
+	)
+	ModerationFlaggedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_moderation_flagged_total", Help: "Prompts and completions flagged by moderation"},
+		[]string{"direction", "action"},
+	)
+	ModerationErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{Name: "routerx_moderation_errors_total", Help: "Moderation classifier failures (requests are allowed through)"},
+	)
+
+	// Tenant and model labels go through TenantLabel and ModelLabel to bound
+	// cardinality.
+	ModelRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_model_requests_total", Help: "Chat requests by tenant, model and status"},
+		[]string{"tenant", "model", "status"},
+	)
+	ModelLatencyMS = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "routerx_model_latency_ms", Help: "Chat latency in ms by model", Buckets: prometheus.ExponentialBuckets(50, 2, 10)},
+		[]string{"model"},
+	)
+	TokensTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_tokens_total", Help: "Tokens served by tenant, model and provider"},
+		[]string{"tenant", "model", "provider"},
+	)
+	CostUSDTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_cost_usd_total", Help: "Amount billed to tenants in USD"},
+		[]string{"tenant", "model", "provider"},
+	)
+	UpstreamCostUSDTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_upstream_cost_usd_total", Help: "Estimated upstream provider cost in USD"},
+		[]string{"model", "provider"},
+	)
+	FallbacksTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_fallbacks_total", Help: "Requests served by a fallback provider"},
+		[]string{"model", "provider"},
+	)
+	CacheLookupsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_cache_lookups_total", Help: "Prompt cache lookups by result (hit or miss)"},
+		[]string{"model", "result"},
+	)
+	DedupTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_dedup_total", Help: "Requests in a tenant dedup window by result: leader (called upstream), hit (reused its response) or miss (leader failed or timed out)"},
+		[]string{"tenant", "result"},
+	)
+	CoalescedRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_coalesced_requests_total", Help: "Requests served by another identical request's in-flight upstream call"},
+		[]string{"tenant"},
+	)
+	CircuitOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "routerx_circuit_open", Help: "Provider circuit breaker state: 1 open, 0.5 half-open, 0 closed"},
+		[]string{"provider"},
+	)
+	TenantConcurrency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "routerx_tenant_concurrency", Help: "Requests a tenant has in flight across all instances, as last seen by this instance"},
+		[]string{"tenant"},
+	)
+	RateLimitRejectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_rate_limit_rejections_total", Help: "Requests rejected by rate or concurrency limits"},
+		[]string{"tenant", "reason"},
+	)
+	UpstreamErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_upstream_errors_total", Help: "Failed provider attempts by error class"},
+		[]string{"provider", "class"},
+	)
+	ProviderBudgetSkipsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_provider_budget_skips_total", Help: "Provider attempts skipped because its RPM or TPM budget was used up"},
+		[]string{"provider", "limit"},
+	)
+	UpstreamConnectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "routerx_upstream_connections_total", Help: "Upstream connections used by provider type and whether they were reused from the pool"},
+		[]string{"provider_type", "reused"},
+	)
+	// UpstreamPhaseMS times the network phases of upstream calls, to tell a
+	// slow provider (ttfb) from slow egress (dns, connect, tls). Reused
+	// connections only report ttfb.
+	UpstreamPhaseMS = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Name: "routerx_upstream_phase_ms", Help: "Upstream call phase duration in ms: dns, connect, tls, or ttfb from request written to first response byte", Buckets: prometheus.ExponentialBuckets(1, 2, 15)},
+		[]string{"provider_type", "phase"},
+	)
 )
 
 func Register() {
-	prometheus.MustRegister(RequestsTotal, LatencyMS, TTFTMS)
+	prometheus.MustRegister(RequestsTotal, LatencyMS, TTFTMS, BrownoutLevel, BrownoutShedTotal, ModerationFlaggedTotal, ModerationErrorsTotal,
+		ModelRequestsTotal, ModelLatencyMS, TokensTotal, CostUSDTotal, UpstreamCostUSDTotal, FallbacksTotal, CacheLookupsTotal,
+		DedupTotal, CoalescedRequestsTotal, CircuitOpen, TenantConcurrency, RateLimitRejectionsTotal, UpstreamErrorsTotal, ProviderBudgetSkipsTotal, UpstreamConnectionsTotal,
+		UpstreamPhaseMS)
 }
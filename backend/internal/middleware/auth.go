@@ -1,22 +1,32 @@
-﻿package middleware
+package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"routerx/internal/keyusage"
+	"routerx/internal/models"
+	"routerx/internal/observability"
+	"routerx/internal/sessions"
 	"routerx/internal/store"
 )
 
 type contextKey string
 
 const (
-	ctxTenant contextKey = "tenant"
-	ctxUser   contextKey = "tenant_user"
-	ctxRole   contextKey = "role"
+	ctxTenant  contextKey = "tenant"
+	ctxUser    contextKey = "tenant_user"
+	ctxRole    contextKey = "role"
+	ctxAPIKey  contextKey = "api_key"
+	ctxAdmin   contextKey = "admin_user"
+	ctxSession contextKey = "session"
+	ctxEnroll  contextKey = "2fa_enrollment"
 )
 
 func TenantFromContext(ctx context.Context) *store.Tenant {
@@ -28,27 +38,74 @@ func TenantFromContext(ctx context.Context) *store.Tenant {
 	return tenant
 }
 
-func WithAPIKey(store *store.Store) func(http.Handler) http.Handler {
+// APIKeyFromContext returns the API key the request authenticated with.
+func APIKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(ctxAPIKey).(string)
+	return key
+}
+
+// WithAPIKey authenticates /v1 callers by bearer key or, for server-to-server
+// callers, by an HMAC-signed request (see SignRequest). Each authenticated
+// request is counted against its key in usage, which may be nil.
+func WithAPIKey(store *store.Store, rdb redis.UniversalClient, usage *keyusage.Tracker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			auth := r.Header.Get("Authorization")
-			if !strings.HasPrefix(auth, "Bearer ") {
-				http.Error(w, "missing api key", http.StatusUnauthorized)
-				return
+			var key string
+			if isSignedRequest(r) {
+				k, err := verifySignedRequest(r.Context(), store, rdb, w, r)
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeBodyTooLarge(w, tooLarge.Limit)
+					return
+				}
+				if err != nil {
+					writeAuthError(w, "invalid_signature", err.Error())
+					return
+				}
+				key = k
+			} else {
+				auth := r.Header.Get("Authorization")
+				if !strings.HasPrefix(auth, "Bearer ") {
+					writeAuthError(w, "missing_api_key", "missing api key")
+					return
+				}
+				key = strings.TrimPrefix(auth, "Bearer ")
 			}
-			key := strings.TrimPrefix(auth, "Bearer ")
 			tenant, err := store.GetTenantByAPIKey(r.Context(), key)
 			if err != nil {
-				http.Error(w, "invalid api key", http.StatusUnauthorized)
+				writeAuthError(w, "invalid_api_key", "invalid api key")
 				return
 			}
 			_ = store.UpdateTenantLastActive(r.Context(), tenant.ID, time.Now().UTC())
-			ctx := context.WithValue(r.Context(), ctxTenant, tenant)
+			usage.Record(r.Context(), key, 1, 0)
+			setAccessTenant(r.Context(), tenant.ID)
+			ctx := observability.WithTenant(r.Context(), tenant.ID)
+			ctx = context.WithValue(ctx, ctxTenant, tenant)
+			ctx = context.WithValue(ctx, ctxAPIKey, key)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// writeAuthError rejects a /v1 caller with a 401 in OpenAI's error format.
+func writeAuthError(w http.ResponseWriter, code, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{Error: models.ErrorDetail{Message: msg, Type: "invalid_request_error", Code: code}})
+}
+
+// WithTenant returns ctx carrying tenant as WithAPIKey would, for requests
+// the server makes on a tenant's behalf.
+func WithTenant(ctx context.Context, tenant *store.Tenant) context.Context {
+	return context.WithValue(ctx, ctxTenant, tenant)
+}
+
+// WithTenantKey is WithTenant for work the server runs as one of the
+// tenant's API keys, carrying the key as WithAPIKey would.
+func WithTenantKey(ctx context.Context, tenant *store.Tenant, key string) context.Context {
+	return context.WithValue(WithTenant(ctx, tenant), ctxAPIKey, key)
+}
+
 func TenantUserFromContext(ctx context.Context) *store.TenantUser {
 	val := ctx.Value(ctxUser)
 	if val == nil {
@@ -62,10 +119,13 @@ type Claims struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
 	TenantID string `json:"tenant_id,omitempty"`
+	// TwoFactorPending marks a session that must enroll in two-factor
+	// authentication before it can use anything but the TwoFactorRoutes.
+	TwoFactorPending bool `json:"2fa_pending,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func AdminAuth(secret string) func(http.Handler) http.Handler {
+func AdminAuth(secret string, tracker *sessions.Tracker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			auth := r.Header.Get("Authorization")
@@ -82,17 +142,70 @@ func AdminAuth(secret string) func(http.Handler) http.Handler {
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
+			if !sessionLive(r, tracker, claims) {
+				http.Error(w, "session revoked", http.StatusUnauthorized)
+				return
+			}
+			if !allowPending(claims, r) {
+				http.Error(w, "two-factor enrollment required", http.StatusForbidden)
+				return
+			}
 			ctx := context.WithValue(r.Context(), ctxRole, "admin")
+			ctx = context.WithValue(ctx, ctxAdmin, claims.Username)
+			ctx = context.WithValue(ctx, ctxSession, claims.ID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func NewAdminToken(secret, username string, ttl time.Duration) (string, error) {
+// sessionLive checks the token's session has not been revoked, recording
+// the request as its latest activity. Tokens issued before sessions were
+// tracked carry no jti and stay valid until they expire. A Redis failure
+// lets the token through, as its signature and expiry were already checked.
+func sessionLive(r *http.Request, tracker *sessions.Tracker, claims *Claims) bool {
+	if tracker == nil || claims.ID == "" {
+		return true
+	}
+	live, err := tracker.Touch(r.Context(), claims.ID, ClientIP(r))
+	return err != nil || live
+}
+
+// SessionIDFromContext returns the id of the session the request
+// authenticated with, or "" for a token without one.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxSession).(string)
+	return id
+}
+
+// TwoFactorRoutes marks the route group enrollment-only sessions may reach.
+// It must run before AdminAuth or TenantUserAuth.
+func TwoFactorRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxEnroll, true)))
+	})
+}
+
+// allowPending restricts enrollment-only sessions to the routes mounted
+// under TwoFactorRoutes.
+func allowPending(claims *Claims, r *http.Request) bool {
+	enroll, _ := r.Context().Value(ctxEnroll).(bool)
+	return !claims.TwoFactorPending || enroll
+}
+
+// AdminUsernameFromContext returns the authenticated admin's username.
+func AdminUsernameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(ctxAdmin).(string)
+	return name
+}
+
+// NewAdminToken issues an admin session token; jti identifies the session
+// for revocation.
+func NewAdminToken(secret, jti, username string, ttl time.Duration) (string, error) {
 	claims := Claims{
 		Username: username,
 		Role:     "admin",
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -103,7 +216,7 @@ func NewAdminToken(secret, username string, ttl time.Duration) (string, error) {
 
 var ErrUnauthorized = errors.New("unauthorized")
 
-func TenantUserAuth(secret string, st *store.Store) func(http.Handler) http.Handler {
+func TenantUserAuth(secret string, st *store.Store, tracker *sessions.Tracker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			auth := r.Header.Get("Authorization")
@@ -120,22 +233,77 @@ func TenantUserAuth(secret string, st *store.Store) func(http.Handler) http.Hand
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
-			ctx := context.WithValue(r.Context(), ctxRole, "tenant")
-			ctx = context.WithValue(ctx, ctxUser, &store.TenantUser{TenantID: claims.TenantID, Username: claims.Username})
+			if !sessionLive(r, tracker, claims) {
+				http.Error(w, "session revoked", http.StatusUnauthorized)
+				return
+			}
+			if !allowPending(claims, r) {
+				http.Error(w, "two-factor enrollment required", http.StatusForbidden)
+				return
+			}
+			user := &store.TenantUser{TenantID: claims.TenantID, Username: claims.Username, Role: store.RoleOwner}
 			if st != nil {
+				// Reload the user so removed members and role changes take
+				// effect without waiting for the token to expire.
+				u, err := st.GetTenantUserByUsername(r.Context(), claims.Username)
+				if err != nil || u.TenantID != claims.TenantID {
+					http.Error(w, "invalid token", http.StatusUnauthorized)
+					return
+				}
+				user = u
 				_ = st.UpdateTenantLastActive(r.Context(), claims.TenantID, time.Now().UTC())
 			}
+			setAccessTenant(r.Context(), user.TenantID)
+			ctx := context.WithValue(r.Context(), ctxRole, "tenant")
+			ctx = context.WithValue(ctx, ctxUser, user)
+			ctx = context.WithValue(ctx, ctxSession, claims.ID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func NewTenantToken(secret, username, tenantID string, ttl time.Duration) (string, error) {
+// RequireTenantRole rejects tenant users whose role is not in roles.
+func RequireTenantRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := TenantUserFromContext(r.Context())
+			if user == nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			for _, role := range roles {
+				if user.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			http.Error(w, "forbidden", http.StatusForbidden)
+		})
+	}
+}
+
+// TenantWriteAccess keeps viewers read-only. Routes viewers may write to,
+// their own two-factor authentication and sessions, are mounted outside it.
+func TenantWriteAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := TenantUserFromContext(r.Context())
+		if user != nil && user.Role == store.RoleViewer && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewTenantToken issues a tenant user session token; jti identifies the
+// session for revocation.
+func NewTenantToken(secret, jti, username, tenantID string, ttl time.Duration) (string, error) {
 	claims := Claims{
 		Username: username,
 		Role:     "tenant",
 		TenantID: tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -143,3 +311,24 @@ func NewTenantToken(secret, username, tenantID string, ttl time.Duration) (strin
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
+
+// TwoFactorEnrollTTL bounds how long an enrollment-only session stays valid.
+const TwoFactorEnrollTTL = 15 * time.Minute
+
+// NewEnrollmentToken issues a short-lived session that can only reach the
+// /2fa endpoints, for users who must enroll before getting full access.
+func NewEnrollmentToken(secret, jti, role, username, tenantID string) (string, error) {
+	claims := Claims{
+		Username:         username,
+		Role:             role,
+		TenantID:         tenantID,
+		TwoFactorPending: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TwoFactorEnrollTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"routerx/internal/models"
+)
+
+// MaxBodyBytes caps request bodies at n bytes (0 disables the cap). Bodies
+// declaring a larger Content-Length are refused with 413 at once; others fail
+// with *http.MaxBytesError when read past the cap. It runs ahead of
+// authentication so signature checks never buffer an oversized body.
+func MaxBodyBytes(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				writeBodyTooLarge(w, n)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{Error: models.ErrorDetail{Message: fmt.Sprintf("request body exceeds %d bytes", limit), Type: "invalid_request_error", Code: "request_too_large"}})
+}
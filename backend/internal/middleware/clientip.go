@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// trustedProxies are the peers whose X-Forwarded-For entries ClientIP
+// believes. Set them at startup with SetTrustedProxies.
+var trustedProxies []netip.Prefix
+
+// SetTrustedProxies sets the proxies, as IP addresses or CIDR ranges, that
+// requests are expected to arrive through.
+func SetTrustedProxies(list []string) error {
+	out := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		p, err := parseProxy(s)
+		if err != nil {
+			return err
+		}
+		out = append(out, p)
+	}
+	trustedProxies = out
+	return nil
+}
+
+// parseProxy parses a trusted proxy entry: an IP address or a CIDR range.
+func parseProxy(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("trusted proxy %q: %w", s, err)
+		}
+		return p.Masked(), nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("trusted proxy %q: %w", s, err)
+	}
+	a = a.Unmap()
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+func trustedProxy(a netip.Addr) bool {
+	a = a.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the caller's address. X-Forwarded-For is only believed
+// when the connection comes from a trusted proxy; the client is then the
+// right-most entry not added by another trusted proxy, since anything left
+// of that was sent by the client itself.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !trustedProxy(peer) {
+		return host
+	}
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	client := host
+	for i := len(hops) - 1; i >= 0; i-- {
+		a, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = a.Unmap().String()
+		if !trustedProxy(a) {
+			break
+		}
+	}
+	return client
+}
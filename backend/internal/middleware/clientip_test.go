@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	if err := SetTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { trustedProxies = nil })
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{name: "direct connection", remote: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "untrusted peer cannot forge", remote: "203.0.113.7:5000", xff: []string{"1.1.1.1"}, want: "203.0.113.7"},
+		{name: "trusted proxy", remote: "10.1.2.3:5000", xff: []string{"198.51.100.9"}, want: "198.51.100.9"},
+		{name: "client-supplied entries are skipped", remote: "10.1.2.3:5000", xff: []string{"1.1.1.1, 198.51.100.9"}, want: "198.51.100.9"},
+		{name: "chain of trusted proxies", remote: "10.1.2.3:5000", xff: []string{"1.1.1.1, 198.51.100.9, 192.0.2.1", "10.9.9.9"}, want: "198.51.100.9"},
+		{name: "trusted proxy without header", remote: "10.1.2.3:5000", want: "10.1.2.3"},
+		{name: "malformed hop", remote: "10.1.2.3:5000", xff: []string{"198.51.100.9, bogus"}, want: "10.1.2.3"},
+		{name: "ipv6 proxy", remote: "[::ffff:10.1.2.3]:5000", xff: []string{"2001:db8::1"}, want: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package migrate applies the numbered SQL migrations. Each file runs in its
+// own transaction under a Postgres advisory lock, so replicas starting
+// together cannot apply the same migration twice, and applied files are
+// checksummed so an edit after the fact is caught instead of silently
+// diverging from the database.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockID is the advisory lock key held while migrating ("routerx/" read as
+// an int64).
+const lockID = 0x726f75746572782f
+
+// Migration is one version: NNN_name.sql, with an optional NNN_name.down.sql
+// undoing it.
+type Migration struct {
+	Version  int
+	Name     string // the up file's name, as recorded in schema_migrations
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	// Modified is set when the applied file's contents have since changed.
+	Modified bool
+}
+
+// Load reads the migrations in fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	downs := map[int]string{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		num, _, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("%s: migration names must start with a version number, as in 045_name.sql", name)
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(name, ".down.sql") {
+			if _, dup := downs[version]; dup {
+				return nil, fmt.Errorf("%s: more than one down migration for version %d", name, version)
+			}
+			downs[version] = string(b)
+			continue
+		}
+		if m, dup := byVersion[version]; dup {
+			return nil, fmt.Errorf("%s and %s share version %d", m.Name, name, version)
+		}
+		sum := sha256.Sum256(b)
+		byVersion[version] = &Migration{Version: version, Name: name, Up: string(b), Checksum: hex.EncodeToString(sum[:])}
+	}
+	out := make([]Migration, 0, len(byVersion))
+	for v, m := range byVersion {
+		m.Down = downs[v]
+		delete(downs, v)
+		out = append(out, *m)
+	}
+	for v := range downs {
+		return nil, fmt.Errorf("down migration for version %d has no up migration", v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+type applied struct {
+	at       time.Time
+	checksum string
+}
+
+// withLock runs fn on one connection holding the migration lock, after
+// making sure schema_migrations exists.
+func withLock(ctx context.Context, pool *pgxpool.Pool, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, int64(lockID)); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, int64(lockID))
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (filename TEXT PRIMARY KEY, applied_at TIMESTAMP NOT NULL);
+ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS version INT;
+ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT`); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func appliedSet(ctx context.Context, conn *pgxpool.Conn) (map[string]applied, error) {
+	rows, err := conn.Query(ctx, `SELECT filename, applied_at, COALESCE(checksum,'') FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]applied{}
+	for rows.Next() {
+		var name string
+		var a applied
+		if err := rows.Scan(&name, &a.at, &a.checksum); err != nil {
+			return nil, err
+		}
+		out[name] = a
+	}
+	return out, rows.Err()
+}
+
+// Up applies every pending migration in order and returns those applied.
+// It refuses to run when an applied file has been modified. Rows recorded
+// before checksums were kept get the current file's checksum.
+func Up(ctx context.Context, pool *pgxpool.Pool, migrations []Migration) ([]Migration, error) {
+	var done []Migration
+	err := withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		have, err := appliedSet(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			a, ok := have[m.Name]
+			if !ok {
+				continue
+			}
+			switch a.checksum {
+			case m.Checksum:
+			case "":
+				if _, err := conn.Exec(ctx, `UPDATE schema_migrations SET version=$2, checksum=$3 WHERE filename=$1`, m.Name, m.Version, m.Checksum); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%s was modified after it was applied; restore it and put the change in a new migration", m.Name)
+			}
+		}
+		for _, m := range migrations {
+			if _, ok := have[m.Name]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, m.Up, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (filename, applied_at, version, checksum) VALUES ($1,$2,$3,$4)`, m.Name, time.Now().UTC(), m.Version, m.Checksum)
+				return err
+			}); err != nil {
+				return fmt.Errorf("%s: %w", m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the n most recently applied migrations, newest first, and
+// returns those reverted. Every one must have a down file.
+func Down(ctx context.Context, pool *pgxpool.Pool, migrations []Migration, n int) ([]Migration, error) {
+	var done []Migration
+	err := withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		have, err := appliedSet(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(done) < n; i-- {
+			m := migrations[i]
+			if _, ok := have[m.Name]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("%s has no down migration", m.Name)
+			}
+			if err := apply(ctx, conn, m.Down, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE filename=$1`, m.Name)
+				return err
+			}); err != nil {
+				return fmt.Errorf("%s (down): %w", m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Statuses reports every migration with when it was applied.
+func Statuses(ctx context.Context, pool *pgxpool.Pool, migrations []Migration) ([]Status, error) {
+	var out []Status
+	err := withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		have, err := appliedSet(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			s := Status{Migration: m}
+			if a, ok := have[m.Name]; ok {
+				at := a.at
+				s.AppliedAt = &at
+				s.Modified = a.checksum != "" && a.checksum != m.Checksum
+			}
+			out = append(out, s)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// apply runs sql and record in one transaction, so a failed migration
+// leaves neither partial schema changes nor a bookkeeping row behind.
+func apply(ctx context.Context, conn *pgxpool.Conn, sql string, record func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
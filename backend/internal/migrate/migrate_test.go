@@ -0,0 +1,67 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"routerx/internal/devenv"
+	"routerx/internal/migrate"
+	"routerx/migrations"
+)
+
+// TestUpDownUp applies every migration to a throwaway database, reverts
+// all that have down files and applies them again, so a down file that does
+// not undo its migration fails here rather than in production.
+func TestUpDownUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts Postgres")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	env, err := devenv.Start(ctx, "")
+	if errors.Is(err, devenv.ErrNotInstalled) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer env.Stop()
+	pool, err := pgxpool.New(ctx, env.DatabaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	versions, err := migrate.Load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversible := 0
+	for _, m := range versions {
+		if m.Down != "" {
+			reversible++
+		}
+	}
+	if done, err := migrate.Up(ctx, pool, versions); err != nil || len(done) != len(versions) {
+		t.Fatalf("Up applied %d of %d: %v", len(done), len(versions), err)
+	}
+	if done, err := migrate.Down(ctx, pool, versions, reversible); err != nil || len(done) != reversible {
+		t.Fatalf("Down reverted %d of %d: %v", len(done), reversible, err)
+	}
+	if done, err := migrate.Up(ctx, pool, versions); err != nil || len(done) != reversible {
+		t.Fatalf("Up after Down applied %d of %d: %v", len(done), reversible, err)
+	}
+	statuses, err := migrate.Statuses(ctx, pool, versions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil || s.Modified {
+			t.Errorf("%s: applied %v, modified %t", s.Name, s.AppliedAt, s.Modified)
+		}
+	}
+}
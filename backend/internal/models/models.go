@@ -20,6 +20,10 @@ type Message struct {
 	Name       string          `json:"name,omitempty"`
 	ToolCalls  json.RawMessage `json:"tool_calls,omitempty"`
 	ToolCallID string          `json:"tool_call_id,omitempty"`
+
+	// ThinkingBlocks echoes an assistant turn's thinking blocks back to
+	// Anthropic, which needs them ahead of tool_use in multi-turn tool calls.
+	ThinkingBlocks json.RawMessage `json:"thinking_blocks,omitempty"`
 }
 
 // ParseContentParts extracts typed content parts from raw message content.
@@ -68,6 +72,48 @@ func ContentHasImage(raw json.RawMessage) bool {
 	return false
 }
 
+// ImageURLs returns the image URLs in message content, accepting both
+// "image_url": "..." and OpenAI's "image_url": {"url": "..."} forms.
+func ImageURLs(raw json.RawMessage) []string {
+	if len(raw) == 0 || raw[0] != '[' {
+		return nil
+	}
+	var parts []struct {
+		Type     string          `json:"type"`
+		ImageURL json.RawMessage `json:"image_url"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil
+	}
+	var urls []string
+	for _, p := range parts {
+		if p.Type != "image_url" || len(p.ImageURL) == 0 {
+			continue
+		}
+		var u string
+		if p.ImageURL[0] == '"' {
+			_ = json.Unmarshal(p.ImageURL, &u)
+		} else {
+			var obj struct {
+				URL string `json:"url"`
+			}
+			_ = json.Unmarshal(p.ImageURL, &obj)
+			u = obj.URL
+		}
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// Thinking is Anthropic's extended-thinking parameter: {"type": "enabled",
+// "budget_tokens": N} or {"type": "disabled"}.
+type Thinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens,omitempty"`
+}
+
 // StreamOptions controls streaming behavior.
 type StreamOptions struct {
 	IncludeUsage bool `json:"include_usage,omitempty"`
@@ -88,6 +134,7 @@ type ChatCompletionRequest struct {
 	FrequencyPenalty    *float64        `json:"frequency_penalty,omitempty"`
 	PresencePenalty     *float64        `json:"presence_penalty,omitempty"`
 	Seed                *int            `json:"seed,omitempty"`
+	LogitBias           json.RawMessage `json:"logit_bias,omitempty"`
 	Tools               json.RawMessage `json:"tools,omitempty"`
 	ToolChoice          json.RawMessage `json:"tool_choice,omitempty"`
 	ParallelToolCalls   *bool           `json:"parallel_tool_calls,omitempty"`
@@ -98,6 +145,18 @@ type ChatCompletionRequest struct {
 	Store               *bool           `json:"store,omitempty"`
 	Metadata            json.RawMessage `json:"metadata,omitempty"`
 	ServiceTier         string          `json:"service_tier,omitempty"`
+	ReasoningEffort     string          `json:"reasoning_effort,omitempty"`
+	Thinking            *Thinking       `json:"thinking,omitempty"`
+
+	// RouterX extensions: render a stored prompt template ahead of Messages.
+	// Both are cleared before the request is sent upstream.
+	PromptTemplate string            `json:"prompt_template,omitempty"`
+	Variables      map[string]string `json:"variables,omitempty"`
+	// MaxCostUSD caps the request's estimated cost; cleared like the above.
+	MaxCostUSD *float64 `json:"max_cost_usd,omitempty"`
+	// RequireParameters restricts routing to providers that honour every
+	// parameter the request sets; cleared like the above.
+	RequireParameters bool `json:"require_parameters,omitempty"`
 }
 
 type Usage struct {
@@ -111,6 +170,11 @@ type AssistantMessage struct {
 	Role      string          `json:"role"`
 	Content   *string         `json:"content"`
 	ToolCalls json.RawMessage `json:"tool_calls,omitempty"`
+
+	// ReasoningContent is the model's visible reasoning; ThinkingBlocks are
+	// Anthropic's raw thinking and redacted_thinking blocks with signatures.
+	ReasoningContent string          `json:"reasoning_content,omitempty"`
+	ThinkingBlocks   json.RawMessage `json:"thinking_blocks,omitempty"`
 }
 
 type Choice struct {
@@ -126,6 +190,10 @@ type ChatCompletionResponse struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   Usage    `json:"usage"`
+
+	// DroppedParams lists request parameters the serving provider could
+	// not honour; it is reported in a header, not the body.
+	DroppedParams []string `json:"-"`
 }
 
 type ErrorDetail struct {
@@ -139,22 +207,52 @@ type ErrorResponse struct {
 }
 
 type RequestLog struct {
-	ID           int       `json:"id"`
-	TenantID     string    `json:"tenant_id"`
-	Provider     string    `json:"provider"`
-	Model        string    `json:"model"`
-	LatencyMS    int64     `json:"latency_ms"`
-	TTFTMS       int64     `json:"ttft_ms"`
-	Tokens       int       `json:"tokens"`
-	CostUSD      float64   `json:"cost_usd"`
-	PromptHash   string    `json:"prompt_hash"`
-	FallbackUsed bool      `json:"fallback_used"`
-	StatusCode   int       `json:"status_code"`
-	ErrorCode    string    `json:"error_code"`
-	UserID       string    `json:"user_id,omitempty"`
-	AppTitle     string    `json:"app_title,omitempty"`
-	AppReferer   string    `json:"app_referer,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID        int     `json:"id"`
+	TenantID  string  `json:"tenant_id"`
+	Provider  string  `json:"provider"`
+	Model     string  `json:"model"`
+	LatencyMS int64   `json:"latency_ms"`
+	TTFTMS    int64   `json:"ttft_ms"`
+	Tokens    int     `json:"tokens"`
+	CostUSD   float64 `json:"cost_usd"`
+	// ProviderCostUSD estimates the upstream cost at the built-in list
+	// price (router.EstimateCostUSD); it is not what the provider billed.
+	ProviderCostUSD float64           `json:"provider_cost_usd"`
+	BilledUSD       float64           `json:"billed_usd"`
+	PromptHash      string            `json:"prompt_hash"`
+	FallbackUsed    bool              `json:"fallback_used"`
+	StatusCode      int               `json:"status_code"`
+	ErrorCode       string            `json:"error_code"`
+	UserID          string            `json:"user_id,omitempty"`
+	AppTitle        string            `json:"app_title,omitempty"`
+	AppReferer      string            `json:"app_referer,omitempty"`
+	KeyOwner        string            `json:"key_owner,omitempty"`
+	ExperimentID    string            `json:"experiment_id,omitempty"`
+	Variant         string            `json:"experiment_variant,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+
+	// TraceID links the request to its OpenTelemetry trace. QueueMS is the
+	// time from arrival until routing began (auth, limits, policy checks);
+	// LatencyMS starts after it.
+	TraceID  string         `json:"trace_id,omitempty"`
+	QueueMS  int64          `json:"queue_ms"`
+	Stream   bool           `json:"stream"`
+	Attempts []RouteAttempt `json:"attempts,omitempty"`
+	// ErrorMessage is the full routing error of a failed request.
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// RouteAttempt is one provider routing tried for a request, in order. Error
+// is empty for the attempt that served it. ErrorClass buckets the error:
+// an upstream class (rate_limited, timeout, server_error, ...) or, for a
+// provider skipped without a call, circuit_open, cooldown, budget or
+// unavailable.
+type RouteAttempt struct {
+	Provider   string `json:"provider"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	ErrorClass string `json:"error_class,omitempty"`
 }
 
 // StringPtr is a helper to create a *string.
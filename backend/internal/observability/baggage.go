@@ -0,0 +1,78 @@
+package observability
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Baggage keys RouterX sets on a /v1 request. BaggageSpanProcessor copies
+// them onto every span started under the request, so database, limiter and
+// upstream spans can be filtered by tenant without joining on the trace.
+const (
+	BaggageTenantID = "routerx.tenant_id"
+	BaggageModel    = "routerx.model"
+	BaggageProvider = "routerx.provider"
+
+	baggagePrefix = "routerx."
+)
+
+// WithTenant tags the request's span with the tenant and carries it in ctx's
+// baggage. Any routerx.* members the caller sent are dropped first: only
+// values RouterX set itself are trusted.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	b := baggage.FromContext(ctx)
+	for _, m := range b.Members() {
+		if strings.HasPrefix(m.Key(), baggagePrefix) {
+			b = b.DeleteMember(m.Key())
+		}
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(BaggageTenantID, tenantID))
+	return withMember(baggage.ContextWithBaggage(ctx, b), BaggageTenantID, tenantID)
+}
+
+// WithModel tags the request's span with the requested model and carries it
+// in ctx's baggage.
+func WithModel(ctx context.Context, model string) context.Context {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(BaggageModel, model))
+	return withMember(ctx, BaggageModel, model)
+}
+
+// WithProvider carries the provider an attempt goes to in ctx's baggage, for
+// the spans under that attempt. The request's span is left alone: which
+// provider served it is only known once routing is done.
+func WithProvider(ctx context.Context, provider string) context.Context {
+	return withMember(ctx, BaggageProvider, provider)
+}
+
+func withMember(ctx context.Context, key, value string) context.Context {
+	m, err := baggage.NewMemberRaw(key, value)
+	if err != nil {
+		return ctx
+	}
+	b, err := baggage.FromContext(ctx).SetMember(m)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, b)
+}
+
+// BaggageSpanProcessor sets the routerx.* baggage members of a span's parent
+// context as attributes on the span.
+type BaggageSpanProcessor struct{}
+
+func (BaggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	for _, m := range baggage.FromContext(parent).Members() {
+		if strings.HasPrefix(m.Key(), baggagePrefix) {
+			s.SetAttributes(attribute.String(m.Key(), m.Value()))
+		}
+	}
+}
+
+func (BaggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (BaggageSpanProcessor) Shutdown(context.Context) error   { return nil }
+func (BaggageSpanProcessor) ForceFlush(context.Context) error { return nil }
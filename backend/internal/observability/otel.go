@@ -1,16 +1,21 @@
-﻿package observability
+package observability
 
 import (
 	"context"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 )
 
+// InitTracer exports spans to the OTLP endpoint. Incoming W3C traceparent
+// and baggage headers are honoured even when the exporter cannot be set up,
+// so a caller's trace still continues through RouterX to the providers.
 func InitTracer(ctx context.Context, endpoint, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	exp, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
 	if err != nil {
 		return nil, err
@@ -20,6 +25,7 @@ func InitTracer(ctx context.Context, endpoint, service string) (func(context.Con
 		semconv.ServiceName(service),
 	)
 	provider := trace.NewTracerProvider(
+		trace.WithSpanProcessor(BaggageSpanProcessor{}),
 		trace.WithBatcher(exp),
 		trace.WithResource(res),
 	)
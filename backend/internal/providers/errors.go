@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UpstreamError is a non-2xx response from a provider. Its message is the
+// upstream response body.
+type UpstreamError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is how long the provider asked callers to back off, from
+	// Retry-After or the provider's rate-limit reset headers; zero when it
+	// gave no hint.
+	RetryAfter time.Duration
+}
+
+func (e *UpstreamError) Error() string { return e.Body }
+
+// RateLimited reports whether the provider refused the request for load:
+// 429 Too Many Requests, or Anthropic's 529 Overloaded.
+func (e *UpstreamError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == 529
+}
+
+func upstreamError(resp *http.Response, body []byte) error {
+	return &UpstreamError{StatusCode: resp.StatusCode, Body: string(body), RetryAfter: retryAfter(resp.Header, time.Now())}
+}
+
+// retryAfter reads the back-off a response asks for. Retry-After (seconds or
+// an HTTP date) wins; otherwise the longest of OpenAI's
+// x-ratelimit-reset-* durations and Anthropic's anthropic-ratelimit-*-reset
+// timestamps is used.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	if v := strings.TrimSpace(h.Get("Retry-After")); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(v); err == nil && t.After(now) {
+			return t.Sub(now)
+		}
+	}
+	var wait time.Duration
+	for _, name := range []string{"X-Ratelimit-Reset-Requests", "X-Ratelimit-Reset-Tokens"} {
+		if d, err := time.ParseDuration(h.Get(name)); err == nil && d > wait {
+			wait = d
+		}
+	}
+	for _, name := range []string{"Anthropic-Ratelimit-Requests-Reset", "Anthropic-Ratelimit-Tokens-Reset", "Anthropic-Ratelimit-Input-Tokens-Reset", "Anthropic-Ratelimit-Output-Tokens-Reset"} {
+		if t, err := time.Parse(time.RFC3339, h.Get(name)); err == nil && t.Sub(now) > wait {
+			wait = t.Sub(now)
+		}
+	}
+	return wait
+}
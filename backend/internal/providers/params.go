@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"encoding/json"
+
+	"routerx/internal/models"
+)
+
+// unsupportedParams names the OpenAI request parameters each provider type
+// has no equivalent for. Types not listed are OpenAI-compatible and receive
+// every parameter as sent.
+var unsupportedParams = map[string]map[string]bool{
+	"anthropic": {"frequency_penalty": true, "presence_penalty": true, "seed": true, "logit_bias": true, "logprobs": true, "top_logprobs": true},
+	"gemini":    {"logit_bias": true, "logprobs": true, "top_logprobs": true},
+}
+
+// setParams lists the optional sampling parameters req sets.
+func setParams(req models.ChatCompletionRequest) []string {
+	var set []string
+	add := func(name string, ok bool) {
+		if ok {
+			set = append(set, name)
+		}
+	}
+	add("temperature", req.Temperature != nil)
+	add("top_p", req.TopP != nil)
+	add("frequency_penalty", req.FrequencyPenalty != nil)
+	add("presence_penalty", req.PresencePenalty != nil)
+	add("seed", req.Seed != nil)
+	add("logit_bias", len(req.LogitBias) > 0 && string(req.LogitBias) != "null")
+	add("logprobs", req.LogProbs != nil && *req.LogProbs)
+	add("top_logprobs", req.TopLogProbs != nil)
+	return set
+}
+
+// UnsupportedParams lists the parameters set on req that a provider of
+// providerType drops rather than sends, in a stable order. Anthropic also
+// drops temperature and top_p when extended thinking is on.
+func UnsupportedParams(providerType string, req models.ChatCompletionRequest) []string {
+	var dropped []string
+	for _, name := range setParams(req) {
+		if unsupportedParams[providerType][name] {
+			dropped = append(dropped, name)
+			continue
+		}
+		if providerType == "anthropic" && thinkingBudget(req) > 0 && (name == "temperature" || name == "top_p") {
+			dropped = append(dropped, name)
+		}
+	}
+	return dropped
+}
+
+// stopSequences reads OpenAI's stop, which is a string or an array of
+// strings, as the list Anthropic and Gemini expect.
+func stopSequences(raw json.RawMessage) []string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		if one == "" {
+			return nil
+		}
+		return []string{one}
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err == nil && len(many) > 0 {
+		return many
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package providers
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -12,6 +11,9 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+
 	"routerx/internal/models"
 	"routerx/internal/store"
 )
@@ -33,13 +35,27 @@ type baseProvider struct {
 }
 
 func NewProvider(p store.Provider, enableReal bool) Provider {
-	client := &http.Client{Timeout: 120 * time.Second}
+	// otelhttp starts a client span per upstream call and injects traceparent.
+	// Baggage is not sent: it names the tenant, which providers have no
+	// business seeing.
+	client := &http.Client{Timeout: 120 * time.Second, Transport: otelhttp.NewTransport(Transport(p.Type, p.Network), otelhttp.WithPropagators(propagation.TraceContext{}))}
 	switch p.Type {
+	// BaseURL overrides the public endpoint for proxies, regional endpoints
+	// and mock servers.
 	case "openai":
+		if p.BaseURL == "" {
+			p.BaseURL = "https://api.openai.com"
+		}
 		return &openAIProvider{baseProvider{info: p, enableReal: enableReal, httpClient: client, providerType: "openai"}}
 	case "anthropic":
+		if p.BaseURL == "" {
+			p.BaseURL = "https://api.anthropic.com"
+		}
 		return &anthropicProvider{baseProvider{info: p, enableReal: enableReal, httpClient: client, providerType: "anthropic"}}
 	case "gemini":
+		if p.BaseURL == "" {
+			p.BaseURL = "https://generativelanguage.googleapis.com"
+		}
 		return &geminiProvider{baseProvider{info: p, enableReal: enableReal, httpClient: client, providerType: "gemini"}}
 	case "deepseek":
 		// DeepSeek uses OpenAI-compatible API
@@ -60,7 +76,7 @@ func NewProvider(p store.Provider, enableReal bool) Provider {
 	}
 }
 
-func (b *baseProvider) Name() string        { return b.info.Name }
+func (b *baseProvider) Name() string         { return b.info.Name }
 func (b *baseProvider) SupportsText() bool   { return b.info.SupportsText }
 func (b *baseProvider) SupportsVision() bool { return b.info.SupportsVision }
 
@@ -117,7 +133,7 @@ func (b *baseProvider) doOpenAIRequest(ctx context.Context, url string, payload
 func parseOpenAIResponse(resp *http.Response, model string) (models.ChatCompletionResponse, error) {
 	if resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return models.ChatCompletionResponse{}, errors.New(string(b))
+		return models.ChatCompletionResponse{}, upstreamError(resp, b)
 	}
 	var raw struct {
 		ID      string `json:"id"`
@@ -127,9 +143,10 @@ func parseOpenAIResponse(resp *http.Response, model string) (models.ChatCompleti
 		Choices []struct {
 			Index   int `json:"index"`
 			Message struct {
-				Role      string          `json:"role"`
-				Content   json.RawMessage `json:"content"`
-				ToolCalls json.RawMessage `json:"tool_calls,omitempty"`
+				Role             string          `json:"role"`
+				Content          json.RawMessage `json:"content"`
+				ToolCalls        json.RawMessage `json:"tool_calls,omitempty"`
+				ReasoningContent string          `json:"reasoning_content,omitempty"`
 			} `json:"message"`
 			Finish string `json:"finish_reason"`
 		} `json:"choices"`
@@ -146,7 +163,7 @@ func parseOpenAIResponse(resp *http.Response, model string) (models.ChatCompleti
 		Usage:   raw.Usage,
 	}
 	for _, c := range raw.Choices {
-		msg := models.AssistantMessage{Role: c.Message.Role, ToolCalls: c.Message.ToolCalls}
+		msg := models.AssistantMessage{Role: c.Message.Role, ToolCalls: c.Message.ToolCalls, ReasoningContent: c.Message.ReasoningContent}
 		// Content can be a string or null
 		if len(c.Message.Content) > 0 && string(c.Message.Content) != "null" {
 			var s string
@@ -171,24 +188,22 @@ func parseOpenAIResponse(resp *http.Response, model string) (models.ChatCompleti
 func handleOpenAIStream(resp *http.Response, model string, send StreamSender) (models.ChatCompletionResponse, int, error) {
 	if resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return models.ChatCompletionResponse{}, 0, errors.New(string(b))
+		return models.ChatCompletionResponse{}, 0, upstreamError(resp, b)
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
+	scanner := newSSEScanner(resp.Body)
 	var fullText strings.Builder
 	var totalTokens int
 	var respID string
+	done := false
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-		if !strings.HasPrefix(line, "data: ") {
+		data, ok := sseData(scanner.Text())
+		if !ok {
 			continue
 		}
-		data := strings.TrimPrefix(line, "data: ")
 		if data == "[DONE]" {
+			done = true
 			_ = send("[DONE]")
 			break
 		}
@@ -243,6 +258,11 @@ func handleOpenAIStream(resp *http.Response, model string, send StreamSender) (m
 		}},
 		Usage: models.Usage{TotalTokens: totalTokens},
 	}
+	// A broken stream still returns what arrived, so the partial
+	// completion is logged.
+	if err := scanner.Err(); err != nil || !done {
+		return out, totalTokens, streamReadError(err)
+	}
 	return out, totalTokens, nil
 }
 
@@ -250,28 +270,36 @@ func handleOpenAIStream(resp *http.Response, model string, send StreamSender) (m
 func handleAnthropicStream(resp *http.Response, model string, send StreamSender) (models.ChatCompletionResponse, int, error) {
 	if resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return models.ChatCompletionResponse{}, 0, errors.New(string(b))
+		return models.ChatCompletionResponse{}, 0, upstreamError(resp, b)
 	}
 
-	scanner := bufio.NewScanner(resp.Body)
+	scanner := newSSEScanner(resp.Body)
 	var fullText strings.Builder
 	var totalTokens int
+	finish := "stop"
+	// Tool calls are numbered in the order their blocks open; toolAt maps
+	// an Anthropic content block index to that number.
+	var tools []*anthropicStreamTool
+	toolAt := map[int]int{}
+	stopped := false
+	var streamErr error
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-		if !strings.HasPrefix(line, "data: ") {
+		data, ok := sseData(scanner.Text())
+		if !ok {
 			continue
 		}
-		data := strings.TrimPrefix(line, "data: ")
 
 		var event struct {
-			Type  string `json:"type"`
-			Delta struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
+			Type         string          `json:"type"`
+			Index        int             `json:"index"`
+			ContentBlock json.RawMessage `json:"content_block"`
+			Delta        struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				Thinking    string `json:"thinking"`
+				PartialJSON string `json:"partial_json"`
+				StopReason  string `json:"stop_reason"`
 			} `json:"delta"`
 			Usage struct {
 				InputTokens  int `json:"input_tokens"`
@@ -283,7 +311,41 @@ func handleAnthropicStream(resp *http.Response, model string, send StreamSender)
 		}
 
 		switch event.Type {
+		case "content_block_start":
+			// Redacted thinking arrives whole; pass it on so clients can
+			// send it back on the next turn.
+			var block struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(event.ContentBlock, &block) != nil {
+				continue
+			}
+			if block.Type == "redacted_thinking" && send != nil {
+				chunk := fmt.Sprintf(`{"choices":[{"delta":{"thinking_blocks":[%s]}}]}`, event.ContentBlock)
+				if err := send(chunk); err != nil {
+					return models.ChatCompletionResponse{}, totalTokens, err
+				}
+			}
+			if block.Type == "tool_use" {
+				toolAt[event.Index] = len(tools)
+				tools = append(tools, &anthropicStreamTool{id: block.ID, name: block.Name})
+				if send != nil {
+					delta := map[string]interface{}{"index": len(tools) - 1, "id": block.ID, "type": "function",
+						"function": map[string]string{"name": block.Name, "arguments": ""}}
+					if err := send(toolCallChunk(delta)); err != nil {
+						return models.ChatCompletionResponse{}, totalTokens, err
+					}
+				}
+			}
 		case "content_block_delta":
+			if event.Delta.Thinking != "" && send != nil {
+				chunk := fmt.Sprintf(`{"choices":[{"delta":{"reasoning_content":%s}}]}`, jsonString(event.Delta.Thinking))
+				if err := send(chunk); err != nil {
+					return models.ChatCompletionResponse{}, totalTokens, err
+				}
+			}
 			if event.Delta.Text != "" {
 				fullText.WriteString(event.Delta.Text)
 				chunk := fmt.Sprintf(`{"choices":[{"delta":{"content":%s}}]}`, jsonString(event.Delta.Text))
@@ -293,15 +355,51 @@ func handleAnthropicStream(resp *http.Response, model string, send StreamSender)
 					}
 				}
 			}
+			if i, ok := toolAt[event.Index]; ok && event.Delta.Type == "input_json_delta" && event.Delta.PartialJSON != "" {
+				tools[i].args.WriteString(event.Delta.PartialJSON)
+				if send != nil {
+					delta := map[string]interface{}{"index": i, "function": map[string]string{"arguments": event.Delta.PartialJSON}}
+					if err := send(toolCallChunk(delta)); err != nil {
+						return models.ChatCompletionResponse{}, totalTokens, err
+					}
+				}
+			}
 		case "message_delta":
 			if event.Usage.OutputTokens > 0 {
 				totalTokens = event.Usage.InputTokens + event.Usage.OutputTokens
 			}
+			if event.Delta.StopReason != "" {
+				finish = anthropicFinishReason(event.Delta.StopReason)
+				if send != nil {
+					chunk := fmt.Sprintf(`{"choices":[{"index":0,"delta":{},"finish_reason":%s}]}`, jsonString(finish))
+					if err := send(chunk); err != nil {
+						return models.ChatCompletionResponse{}, totalTokens, err
+					}
+				}
+			}
 		case "message_stop":
+			stopped = true
 			if send != nil {
 				_ = send("[DONE]")
 			}
+		case "error":
+			// Anthropic reports mid-stream failures (e.g. overloaded_error)
+			// as an error event and then closes the stream.
+			var e struct {
+				Error struct {
+					Type    string `json:"type"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			_ = json.Unmarshal([]byte(data), &e)
+			streamErr = fmt.Errorf("%w: %s: %s", ErrStreamInterrupted, e.Error.Type, e.Error.Message)
 		}
+		if stopped || streamErr != nil {
+			break
+		}
+	}
+	if streamErr == nil && !stopped {
+		streamErr = streamReadError(scanner.Err())
 	}
 
 	if totalTokens == 0 {
@@ -312,6 +410,18 @@ func handleAnthropicStream(resp *http.Response, model string, send StreamSender)
 	}
 
 	text := fullText.String()
+	msg := models.AssistantMessage{Role: "assistant", Content: &text}
+	if len(tools) > 0 {
+		calls := make([]map[string]interface{}, len(tools))
+		for i, t := range tools {
+			args := t.args.String()
+			if args == "" {
+				args = "{}"
+			}
+			calls[i] = map[string]interface{}{"id": t.id, "type": "function", "function": map[string]string{"name": t.name, "arguments": args}}
+		}
+		msg.ToolCalls, _ = json.Marshal(calls)
+	}
 	out := models.ChatCompletionResponse{
 		ID:      fmt.Sprintf("anthropic_%d", time.Now().UnixNano()),
 		Object:  "chat.completion",
@@ -319,12 +429,39 @@ func handleAnthropicStream(resp *http.Response, model string, send StreamSender)
 		Model:   model,
 		Choices: []models.Choice{{
 			Index:   0,
-			Message: models.AssistantMessage{Role: "assistant", Content: &text},
-			Finish:  "stop",
+			Message: msg,
+			Finish:  finish,
 		}},
 		Usage: models.Usage{TotalTokens: totalTokens},
 	}
-	return out, totalTokens, nil
+	return out, totalTokens, streamErr
+}
+
+// anthropicStreamTool accumulates a streamed tool_use block.
+type anthropicStreamTool struct {
+	id, name string
+	args     strings.Builder
+}
+
+// toolCallChunk is an OpenAI stream chunk carrying one tool_calls delta.
+func toolCallChunk(delta map[string]interface{}) string {
+	choice := map[string]interface{}{"index": 0, "delta": map[string]interface{}{"tool_calls": []interface{}{delta}}}
+	b, _ := json.Marshal(map[string]interface{}{"choices": []interface{}{choice}})
+	return string(b)
+}
+
+// anthropicFinishReason maps an Anthropic stop_reason to OpenAI's
+// finish_reason.
+func anthropicFinishReason(stop string) string {
+	switch stop {
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	case "refusal":
+		return "content_filter"
+	}
+	return "stop"
 }
 
 func jsonString(s string) string {
@@ -346,9 +483,10 @@ func (p *openAIProvider) Chat(ctx context.Context, req models.ChatCompletionRequ
 	if p.info.APIKey == "" {
 		return models.ChatCompletionResponse{}, 0, 0, fmt.Errorf("no API key configured for provider %s (openai)", p.info.Name)
 	}
-	url := "https://api.openai.com/v1/chat/completions"
+	url := strings.TrimRight(p.info.BaseURL, "/") + "/v1/chat/completions"
 
 	// Forward the entire request struct — all OpenAI-compatible fields are passed through
+	req = withOpenAIReasoning(req)
 	req.Stream = stream
 	if stream {
 		req.StreamOptions = &models.StreamOptions{IncludeUsage: true}
@@ -385,6 +523,7 @@ func (p *genericOpenAIProvider) Chat(ctx context.Context, req models.ChatComplet
 	}
 	url := fmt.Sprintf("%s/v1/chat/completions", strings.TrimRight(base, "/"))
 
+	req = withOpenAIReasoning(req)
 	req.Stream = stream
 	if stream {
 		req.StreamOptions = &models.StreamOptions{IncludeUsage: true}
@@ -415,7 +554,7 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 	if p.info.APIKey == "" {
 		return models.ChatCompletionResponse{}, 0, 0, fmt.Errorf("no API key configured for provider %s (anthropic)", p.info.Name)
 	}
-	url := "https://api.anthropic.com/v1/messages"
+	url := strings.TrimRight(p.info.BaseURL, "/") + "/v1/messages"
 
 	// Convert messages to Anthropic format
 	var system string
@@ -445,7 +584,7 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 				} `json:"function"`
 			}
 			if err := json.Unmarshal(msg.ToolCalls, &toolCalls); err == nil {
-				content := []interface{}{}
+				content := append([]interface{}{}, thinkingBlocks(msg.ThinkingBlocks)...)
 				text := models.ContentText(msg.Content)
 				if text != "" {
 					content = append(content, map[string]interface{}{"type": "text", "text": text})
@@ -462,7 +601,10 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 			}
 		}
 		// Regular message
-		content := models.ContentText(msg.Content)
+		var content interface{} = models.ContentText(msg.Content)
+		if blocks := thinkingBlocks(msg.ThinkingBlocks); msg.Role == "assistant" && len(blocks) > 0 {
+			content = append(blocks, map[string]interface{}{"type": "text", "text": content})
+		}
 		anthropicMsgs = append(anthropicMsgs, map[string]interface{}{
 			"role":    msg.Role,
 			"content": content,
@@ -478,6 +620,12 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 		maxTokens = req.MaxCompletionTokens
 	}
 
+	// max_tokens includes the thinking budget and must exceed it.
+	budget := thinkingBudget(req)
+	if budget > 0 && maxTokens <= budget {
+		maxTokens = budget + 4096
+	}
+
 	payload := map[string]interface{}{
 		"model":      req.Model,
 		"messages":   anthropicMsgs,
@@ -489,18 +637,20 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 	if stream {
 		payload["stream"] = true
 	}
-	if req.Temperature != nil {
-		payload["temperature"] = *req.Temperature
-	}
-	if req.TopP != nil {
-		payload["top_p"] = *req.TopP
-	}
-	if len(req.Stop) > 0 && string(req.Stop) != "null" {
-		var stop interface{}
-		if err := json.Unmarshal(req.Stop, &stop); err == nil {
-			payload["stop_sequences"] = stop
+	if budget > 0 {
+		// Extended thinking rejects sampling overrides, so they are dropped.
+		payload["thinking"] = map[string]interface{}{"type": "enabled", "budget_tokens": budget}
+	} else {
+		if req.Temperature != nil {
+			payload["temperature"] = *req.Temperature
+		}
+		if req.TopP != nil {
+			payload["top_p"] = *req.TopP
 		}
 	}
+	if stop := stopSequences(req.Stop); len(stop) > 0 {
+		payload["stop_sequences"] = stop
+	}
 
 	// Convert OpenAI tools to Anthropic format
 	if len(req.Tools) > 0 && string(req.Tools) != "null" {
@@ -571,21 +721,15 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 
 	if res.StatusCode >= 300 {
 		b, _ := io.ReadAll(res.Body)
-		return models.ChatCompletionResponse{}, time.Since(start), 0, errors.New(string(b))
+		return models.ChatCompletionResponse{}, time.Since(start), 0, upstreamError(res, b)
 	}
 
 	var anthropicResp struct {
-		ID      string `json:"id"`
-		Type    string `json:"type"`
-		Model   string `json:"model"`
-		Content []struct {
-			Type  string          `json:"type"`
-			Text  string          `json:"text,omitempty"`
-			ID    string          `json:"id,omitempty"`
-			Name  string          `json:"name,omitempty"`
-			Input json.RawMessage `json:"input,omitempty"`
-		} `json:"content"`
-		StopReason string `json:"stop_reason"`
+		ID         string            `json:"id"`
+		Type       string            `json:"type"`
+		Model      string            `json:"model"`
+		Content    []json.RawMessage `json:"content"`
+		StopReason string            `json:"stop_reason"`
 		Usage      struct {
 			InputTokens  int `json:"input_tokens"`
 			OutputTokens int `json:"output_tokens"`
@@ -595,12 +739,28 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 		return models.ChatCompletionResponse{}, time.Since(start), 0, err
 	}
 
-	var text string
+	var text, reasoning string
 	var toolCallsList []map[string]interface{}
-	for _, c := range anthropicResp.Content {
+	var thinking []json.RawMessage
+	for _, raw := range anthropicResp.Content {
+		var c struct {
+			Type     string          `json:"type"`
+			Text     string          `json:"text,omitempty"`
+			Thinking string          `json:"thinking,omitempty"`
+			ID       string          `json:"id,omitempty"`
+			Name     string          `json:"name,omitempty"`
+			Input    json.RawMessage `json:"input,omitempty"`
+		}
+		if err := json.Unmarshal(raw, &c); err != nil {
+			continue
+		}
 		if c.Type == "text" {
 			text += c.Text
 		}
+		if c.Type == "thinking" || c.Type == "redacted_thinking" {
+			reasoning += c.Thinking
+			thinking = append(thinking, raw)
+		}
 		if c.Type == "tool_use" {
 			args, _ := json.Marshal(c.Input)
 			toolCallsList = append(toolCallsList, map[string]interface{}{
@@ -622,10 +782,11 @@ func (p *anthropicProvider) Chat(ctx context.Context, req models.ChatCompletionR
 	if len(toolCallsList) > 0 {
 		msg.ToolCalls, _ = json.Marshal(toolCallsList)
 	}
-	finishReason := "stop"
-	if anthropicResp.StopReason == "tool_use" {
-		finishReason = "tool_calls"
+	if len(thinking) > 0 {
+		msg.ReasoningContent = reasoning
+		msg.ThinkingBlocks, _ = json.Marshal(thinking)
 	}
+	finishReason := anthropicFinishReason(anthropicResp.StopReason)
 
 	out := models.ChatCompletionResponse{
 		ID:      anthropicResp.ID,
@@ -657,9 +818,11 @@ type geminiResponse struct {
 	Candidates []struct {
 		Content struct {
 			Parts []struct {
-				Text string `json:"text"`
+				Text    string `json:"text"`
+				Thought bool   `json:"thought,omitempty"`
 			} `json:"parts"`
 		} `json:"content"`
+		FinishReason string `json:"finishReason,omitempty"`
 	} `json:"candidates"`
 	UsageMetadata struct {
 		PromptTokenCount     int `json:"promptTokenCount"`
@@ -727,11 +890,26 @@ func (p *geminiProvider) Chat(ctx context.Context, req models.ChatCompletionRequ
 	if req.TopP != nil {
 		gen["topP"] = *req.TopP
 	}
-	if len(req.Stop) > 0 && string(req.Stop) != "null" {
-		var stopSeqs interface{}
-		if err := json.Unmarshal(req.Stop, &stopSeqs); err == nil {
-			gen["stopSequences"] = stopSeqs
+	if stop := stopSequences(req.Stop); len(stop) > 0 {
+		gen["stopSequences"] = stop
+	}
+	if req.FrequencyPenalty != nil {
+		gen["frequencyPenalty"] = *req.FrequencyPenalty
+	}
+	if req.PresencePenalty != nil {
+		gen["presencePenalty"] = *req.PresencePenalty
+	}
+	if req.Seed != nil {
+		gen["seed"] = *req.Seed
+	}
+	if req.Thinking != nil || req.ReasoningEffort != "" {
+		// thinkingBudget 0 turns thinking off on models that allow it.
+		budget := thinkingBudget(req)
+		cfg := map[string]interface{}{"thinkingBudget": budget}
+		if budget > 0 {
+			cfg["includeThoughts"] = true
 		}
+		gen["thinkingConfig"] = cfg
 	}
 	if len(gen) > 0 {
 		payload["generationConfig"] = gen
@@ -743,7 +921,7 @@ func (p *geminiProvider) Chat(ctx context.Context, req models.ChatCompletionRequ
 	}
 
 	makeRequest := func(model string) (*http.Response, error) {
-		url := "https://generativelanguage.googleapis.com/v1beta/models/" + model + ":" + method
+		url := strings.TrimRight(p.info.BaseURL, "/") + "/v1beta/models/" + model + ":" + method
 		if apiKey != "" && !strings.Contains(url, "key=") {
 			if strings.Contains(url, "?") {
 				url = url + "&key=" + apiKey
@@ -780,11 +958,11 @@ func (p *geminiProvider) Chat(ctx context.Context, req models.ChatCompletionRequ
 			defer res2.Body.Close()
 			if res2.StatusCode >= 300 {
 				b2, _ := io.ReadAll(res2.Body)
-				return models.ChatCompletionResponse{}, time.Since(start), 0, errors.New(string(b2))
+				return models.ChatCompletionResponse{}, time.Since(start), 0, upstreamError(res2, b2)
 			}
 			res = res2
 		} else {
-			return models.ChatCompletionResponse{}, time.Since(start), 0, errors.New(body)
+			return models.ChatCompletionResponse{}, time.Since(start), 0, upstreamError(res, b)
 		}
 	}
 
@@ -800,9 +978,13 @@ func (p *geminiProvider) Chat(ctx context.Context, req models.ChatCompletionRequ
 	if err := json.Unmarshal(bodyBytes, &g); err != nil {
 		return models.ChatCompletionResponse{}, time.Since(start), 0, err
 	}
-	text := ""
+	text, reasoning := "", ""
 	if len(g.Candidates) > 0 {
 		for _, p := range g.Candidates[0].Content.Parts {
+			if p.Thought {
+				reasoning += p.Text
+				continue
+			}
 			if p.Text != "" {
 				if text != "" {
 					text += "\n"
@@ -827,27 +1009,25 @@ func (p *geminiProvider) Chat(ctx context.Context, req models.ChatCompletionRequ
 		Object:  "chat.completion",
 		Created: time.Now().Unix(),
 		Model:   req.Model,
-		Choices: []models.Choice{{Index: 0, Message: models.AssistantMessage{Role: "assistant", Content: &text}, Finish: "stop"}},
+		Choices: []models.Choice{{Index: 0, Message: models.AssistantMessage{Role: "assistant", Content: &text, ReasoningContent: reasoning}, Finish: "stop"}},
 		Usage:   usage,
 	}
 	return out, time.Since(start), out.Usage.TotalTokens, nil
 }
 
 func handleGeminiStream(resp *http.Response, model string, send StreamSender, start time.Time) (models.ChatCompletionResponse, time.Duration, int, error) {
-	scanner := bufio.NewScanner(resp.Body)
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner := newSSEScanner(resp.Body)
 	var fullText strings.Builder
 	var totalTokens int
+	// Gemini has no end-of-stream marker; the last chunk carries a
+	// finishReason.
+	finished := false
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
+		data, ok := sseData(scanner.Text())
+		if !ok {
 			continue
 		}
-		if !strings.HasPrefix(line, "data: ") {
-			continue
-		}
-		data := strings.TrimPrefix(line, "data: ")
 
 		var g geminiResponse
 		if err := json.Unmarshal([]byte(data), &g); err != nil {
@@ -855,7 +1035,17 @@ func handleGeminiStream(resp *http.Response, model string, send StreamSender, st
 		}
 
 		for _, cand := range g.Candidates {
+			if cand.FinishReason != "" {
+				finished = true
+			}
 			for _, part := range cand.Content.Parts {
+				if part.Thought && part.Text != "" {
+					chunk := fmt.Sprintf(`{"choices":[{"delta":{"reasoning_content":%s}}]}`, jsonString(part.Text))
+					if err := send(chunk); err != nil {
+						return models.ChatCompletionResponse{}, time.Since(start), totalTokens, err
+					}
+					continue
+				}
 				if part.Text != "" {
 					fullText.WriteString(part.Text)
 					chunk := fmt.Sprintf(`{"choices":[{"delta":{"content":%s}}]}`, jsonString(part.Text))
@@ -870,8 +1060,12 @@ func handleGeminiStream(resp *http.Response, model string, send StreamSender, st
 			totalTokens = g.UsageMetadata.TotalTokenCount
 		}
 	}
-
-	_ = send("[DONE]")
+	var streamErr error
+	if err := scanner.Err(); err != nil || !finished {
+		streamErr = streamReadError(err)
+	} else {
+		_ = send("[DONE]")
+	}
 
 	if totalTokens == 0 {
 		totalTokens = len(fullText.String()) / 4
@@ -893,5 +1087,5 @@ func handleGeminiStream(resp *http.Response, model string, send StreamSender, st
 		}},
 		Usage: models.Usage{TotalTokens: totalTokens},
 	}
-	return out, time.Since(start), totalTokens, nil
+	return out, time.Since(start), totalTokens, streamErr
 }
@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"routerx/internal/models"
+)
+
+// minThinkingBudget is the smallest budget_tokens Anthropic accepts.
+const minThinkingBudget = 1024
+
+// effortBudgets maps OpenAI reasoning_effort levels to a thinking token budget
+// for providers that take a budget instead.
+var effortBudgets = map[string]int{
+	"minimal": minThinkingBudget,
+	"low":     minThinkingBudget,
+	"medium":  4096,
+	"high":    16384,
+}
+
+// ValidateReasoning checks reasoning_effort and thinking before routing so
+// bad values fail with a 400 rather than as an upstream error.
+func ValidateReasoning(req models.ChatCompletionRequest) error {
+	if req.ReasoningEffort != "" {
+		if _, ok := effortBudgets[req.ReasoningEffort]; !ok {
+			return errors.New("reasoning_effort must be minimal, low, medium or high")
+		}
+	}
+	if t := req.Thinking; t != nil {
+		switch t.Type {
+		case "disabled":
+		case "enabled":
+			if t.BudgetTokens < minThinkingBudget {
+				return errors.New("thinking.budget_tokens must be at least 1024")
+			}
+		default:
+			return errors.New(`thinking.type must be "enabled" or "disabled"`)
+		}
+	}
+	return nil
+}
+
+// thinkingBudget returns the token budget a request asks for, from thinking
+// or else reasoning_effort; 0 means thinking is off.
+func thinkingBudget(req models.ChatCompletionRequest) int {
+	if req.Thinking != nil {
+		if req.Thinking.Type != "enabled" {
+			return 0
+		}
+		return req.Thinking.BudgetTokens
+	}
+	return effortBudgets[req.ReasoningEffort]
+}
+
+// reasoningEffort returns the reasoning_effort a request asks for, mapping a
+// thinking budget onto the nearest level.
+func reasoningEffort(req models.ChatCompletionRequest) string {
+	if req.ReasoningEffort != "" || req.Thinking == nil || req.Thinking.Type != "enabled" {
+		return req.ReasoningEffort
+	}
+	switch b := req.Thinking.BudgetTokens; {
+	case b <= effortBudgets["low"]:
+		return "low"
+	case b <= effortBudgets["medium"]:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// withOpenAIReasoning rewrites a request for OpenAI-compatible upstreams:
+// thinking becomes reasoning_effort, and Anthropic thinking blocks are
+// dropped from messages.
+func withOpenAIReasoning(req models.ChatCompletionRequest) models.ChatCompletionRequest {
+	req.ReasoningEffort = reasoningEffort(req)
+	req.Thinking = nil
+	msgs := make([]models.Message, len(req.Messages))
+	for i, m := range req.Messages {
+		m.ThinkingBlocks = nil
+		msgs[i] = m
+	}
+	req.Messages = msgs
+	return req
+}
+
+// thinkingBlocks decodes the thinking blocks an assistant message carries.
+func thinkingBlocks(raw json.RawMessage) []interface{} {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var blocks []interface{}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil
+	}
+	return blocks
+}
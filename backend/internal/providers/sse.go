@@ -0,0 +1,48 @@
+package providers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MaxStreamLineBytes bounds one SSE line from an upstream. Tool call
+// arguments and inline images can put megabytes on a line; a longer one
+// fails the stream instead of silently cutting it short. Set at startup.
+var MaxStreamLineBytes = 8 << 20
+
+// ErrStreamInterrupted marks an upstream stream that broke off before its
+// terminal event. Part of the completion may already have reached the
+// client, so the request must not be retried elsewhere.
+var ErrStreamInterrupted = errors.New("upstream stream interrupted")
+
+// newSSEScanner reads an upstream event stream line by line. bufio.ScanLines
+// also strips the \r of CRLF line endings.
+func newSSEScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), MaxStreamLineBytes)
+	return s
+}
+
+// sseData returns the payload of a "data:" line. The space after the colon
+// is optional per the SSE spec.
+func sseData(line string) (string, bool) {
+	if !strings.HasPrefix(line, "data:") {
+		return "", false
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "), true
+}
+
+// streamReadError explains why reading an upstream stream stopped early.
+// A clean EOF before the terminal event (err == nil) is an interruption too.
+func streamReadError(err error) error {
+	switch {
+	case err == nil:
+		return fmt.Errorf("%w: stream ended without a terminal event", ErrStreamInterrupted)
+	case errors.Is(err, bufio.ErrTooLong):
+		return fmt.Errorf("%w: line exceeds %d bytes (UPSTREAM_STREAM_MAX_LINE_BYTES)", ErrStreamInterrupted, MaxStreamLineBytes)
+	}
+	return fmt.Errorf("%w: %w", ErrStreamInterrupted, err)
+}
@@ -0,0 +1,209 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"routerx/internal/metrics"
+	"routerx/internal/store"
+)
+
+// PoolConfig tunes the upstream connection pools. Set Pool at startup,
+// before the first provider is built.
+type PoolConfig struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	HTTP2               bool
+}
+
+var Pool = PoolConfig{
+	MaxIdleConnsPerHost: 64,
+	IdleConnTimeout:     90 * time.Second,
+	DialTimeout:         10 * time.Second,
+	HTTP2:               true,
+}
+
+// transports holds one transport per provider type and network
+// configuration, so every provider of a type shares a warm connection pool.
+var transports sync.Map
+
+// ValidateNetwork checks a provider's proxy and TLS settings.
+func ValidateNetwork(n store.NetworkSettings) error {
+	_, err := buildTransport(n)
+	return err
+}
+
+// Transport returns the shared transport for a provider type and network
+// settings. A bad configuration yields a transport that fails every request
+// rather than one that silently bypasses the proxy or trusts the wrong CAs.
+func Transport(providerType string, n store.NetworkSettings) http.RoundTripper {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s", providerType, n.ProxyURL, n.CABundle, n.ClientCert, n.ClientKey)
+	key := string(h.Sum(nil))
+	if t, ok := transports.Load(key); ok {
+		return t.(http.RoundTripper)
+	}
+	var rt http.RoundTripper
+	t, err := buildTransport(n)
+	if err != nil {
+		rt = errTransport{err}
+	} else {
+		rt = &instrumentedTransport{base: t, providerType: providerType}
+	}
+	actual, _ := transports.LoadOrStore(key, rt)
+	return actual.(http.RoundTripper)
+}
+
+func buildTransport(n store.NetworkSettings) (*http.Transport, error) {
+	dialer := &net.Dialer{Timeout: Pool.DialTimeout, KeepAlive: 30 * time.Second}
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     Pool.HTTP2,
+		MaxIdleConnsPerHost:   Pool.MaxIdleConnsPerHost,
+		IdleConnTimeout:       Pool.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if !Pool.HTTP2 {
+		// A non-nil empty map turns off the transport's HTTP/2 upgrade.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if n.ProxyURL != "" {
+		u, err := url.Parse(n.ProxyURL)
+		if err != nil || u.Host == "" {
+			return nil, errors.New("proxy_url must be an absolute URL")
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, errors.New("proxy_url scheme must be http, https or socks5")
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if n.CABundle == "" && n.ClientCert == "" && n.ClientKey == "" {
+		return t, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if n.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(n.CABundle)) {
+			return nil, errors.New("ca_bundle contains no PEM certificates")
+		}
+		cfg.RootCAs = pool
+	}
+	if n.ClientCert != "" || n.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(n.ClientCert), []byte(n.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("client_cert/client_key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	t.TLSClientConfig = cfg
+	return t, nil
+}
+
+// instrumentedTransport records whether each request got a pooled connection
+// and how long its DNS lookup, connect, TLS handshake and time to first
+// response byte took, as metrics and as events on the request's span.
+type instrumentedTransport struct {
+	base         http.RoundTripper
+	providerType string
+}
+
+func (c *instrumentedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(r.Context())
+	var mu sync.Mutex
+	var dnsStart, tlsStart, wrote time.Time
+	connectStart := map[string]time.Time{}
+	phase := func(name string, start time.Time, attrs ...attribute.KeyValue) {
+		if start.IsZero() {
+			return
+		}
+		d := time.Since(start)
+		metrics.UpstreamPhaseMS.WithLabelValues(c.providerType, name).Observe(float64(d.Microseconds()) / 1000)
+		attrs = append(attrs, attribute.Float64("duration_ms", float64(d.Microseconds())/1000))
+		span.AddEvent("upstream."+name, trace.WithAttributes(attrs...))
+	}
+	errAttrs := func(err error) []attribute.KeyValue {
+		if err == nil {
+			return nil
+		}
+		return []attribute.KeyValue{attribute.String("error", err.Error())}
+	}
+	ct := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			dnsStart = time.Now()
+			mu.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			phase("dns", dnsStart, errAttrs(info.Err)...)
+		},
+		// With several addresses the dialer races connections; each is
+		// timed on its own.
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			connectStart[network+" "+addr] = time.Now()
+			mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			phase("connect", connectStart[network+" "+addr], append(errAttrs(err), attribute.String("net.peer.addr", addr))...)
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			tlsStart = time.Now()
+			mu.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			phase("tls", tlsStart, errAttrs(err)...)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.UpstreamConnectionsTotal.WithLabelValues(c.providerType, strconv.FormatBool(info.Reused)).Inc()
+			span.SetAttributes(attribute.Bool("routerx.conn_reused", info.Reused))
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			wrote = time.Now()
+			mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			phase("ttfb", wrote)
+		},
+	}
+	return c.base.RoundTrip(r.WithContext(httptrace.WithClientTrace(r.Context(), ct)))
+}
+
+type errTransport struct{ err error }
+
+func (e errTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		r.Body.Close()
+	}
+	return nil, fmt.Errorf("provider network settings: %w", e.err)
+}
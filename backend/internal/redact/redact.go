@@ -0,0 +1,134 @@
+// Package redact detects and masks personal data (emails, phone numbers,
+// payment card numbers and custom patterns) in free text.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Built-in detectors.
+const (
+	Email      = "email"
+	Phone      = "phone"
+	CreditCard = "credit_card"
+)
+
+// Detectors lists the built-in detectors in the order they are applied.
+var Detectors = []string{Email, CreditCard, Phone}
+
+var (
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// Card numbers: 13-19 digits, optionally split by spaces or dashes.
+	cardRe = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+	// Phone numbers: optional +country code, then 9-14 digits with common separators.
+	phoneRe = regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{2,4}\)[ .\-]?)?\d{2,4}[ .\-]?\d{3,4}[ .\-]?\d{3,4}\b`)
+)
+
+// Redactor masks matches of its detectors and custom patterns.
+type Redactor struct {
+	detectors []string
+	custom    []*regexp.Regexp
+}
+
+// New builds a redactor. Unknown detector names and invalid patterns are errors.
+func New(detectors, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, d := range Detectors {
+		for _, want := range detectors {
+			if want == d {
+				r.detectors = append(r.detectors, d)
+			}
+		}
+	}
+	for _, want := range detectors {
+		if !Valid(want) {
+			return nil, fmt.Errorf("unknown detector %q", want)
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		r.custom = append(r.custom, re)
+	}
+	return r, nil
+}
+
+func Valid(detector string) bool {
+	return detector == Email || detector == Phone || detector == CreditCard
+}
+
+// Empty reports whether the redactor would never change its input.
+func (r *Redactor) Empty() bool {
+	return r == nil || (len(r.detectors) == 0 && len(r.custom) == 0)
+}
+
+// String masks all matches in s and returns the result with the number of
+// replacements made.
+func (r *Redactor) String(s string) (string, int) {
+	if r.Empty() || s == "" {
+		return s, 0
+	}
+	n := 0
+	for _, d := range r.detectors {
+		switch d {
+		case Email:
+			s = replace(emailRe, s, "[EMAIL]", nil, &n)
+		case CreditCard:
+			s = replace(cardRe, s, "[CARD]", luhn, &n)
+		case Phone:
+			s = replace(phoneRe, s, "[PHONE]", phoneDigits, &n)
+		}
+	}
+	for _, re := range r.custom {
+		s = replace(re, s, "[REDACTED]", nil, &n)
+	}
+	return s, n
+}
+
+func replace(re *regexp.Regexp, s, with string, ok func(string) bool, n *int) string {
+	return re.ReplaceAllStringFunc(s, func(m string) string {
+		if ok != nil && !ok(m) {
+			return m
+		}
+		*n++
+		return with
+	})
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// luhn filters card-like digit runs down to ones with a valid checksum.
+func luhn(m string) bool {
+	d := digits(m)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(d); i++ {
+		v := int(d[len(d)-1-i] - '0')
+		if i%2 == 1 {
+			v *= 2
+			if v > 9 {
+				v -= 9
+			}
+		}
+		sum += v
+	}
+	return sum%10 == 0
+}
+
+func phoneDigits(m string) bool {
+	n := len(digits(m))
+	return n >= 9 && n <= 15
+}
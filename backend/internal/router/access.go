@@ -0,0 +1,45 @@
+package router
+
+import (
+	"errors"
+
+	"routerx/internal/store"
+)
+
+// ErrModelNotAllowed is returned when a tenant's model lists exclude the
+// requested model.
+var ErrModelNotAllowed = errors.New("model is not allowed for this tenant")
+
+// ApplyPolicy copies a tenant's provider and model allow/deny lists into o.
+func (o *RouteOptions) ApplyPolicy(p *store.RequestPolicy) {
+	o.AllowedProviders = p.AllowedProviders
+	o.DeniedProviders = p.DeniedProviders
+	o.AllowedModels = p.AllowedModels
+	o.DeniedModels = p.DeniedModels
+}
+
+// ModelAllowed reports whether a tenant's lists admit model. Entries may use
+// * wildcards; a deny match wins over an allow match.
+func ModelAllowed(model string, allowed, denied []string) bool {
+	if globMatchAny(denied, model) {
+		return false
+	}
+	return len(allowed) == 0 || globMatchAny(allowed, model)
+}
+
+// ProviderAllowed reports whether a tenant's lists admit p, by ID or name.
+func ProviderAllowed(p store.Provider, allowed, denied []string) bool {
+	if containsStr(denied, p.ID) || containsStr(denied, p.Name) {
+		return false
+	}
+	return len(allowed) == 0 || containsStr(allowed, p.ID) || containsStr(allowed, p.Name)
+}
+
+func globMatchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if globMatch(p, s) {
+			return true
+		}
+	}
+	return false
+}
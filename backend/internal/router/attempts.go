@@ -0,0 +1,78 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"routerx/internal/models"
+	"routerx/internal/providers"
+)
+
+type attemptTrailKey struct{}
+
+// AttemptTrail collects the provider attempts routing makes for one request,
+// including providers skipped at the door (circuit open, cooling down, at
+// budget), so a failed request shows why each candidate did not serve it.
+type AttemptTrail struct {
+	mu       sync.Mutex
+	attempts []models.RouteAttempt
+}
+
+// WithAttemptTrail returns a context whose routing records its attempts in
+// the returned trail.
+func WithAttemptTrail(ctx context.Context) (context.Context, *AttemptTrail) {
+	t := &AttemptTrail{}
+	return context.WithValue(ctx, attemptTrailKey{}, t), t
+}
+
+// Attempts returns the attempts recorded so far, oldest first.
+func (t *AttemptTrail) Attempts() []models.RouteAttempt {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]models.RouteAttempt(nil), t.attempts...)
+}
+
+func recordAttempt(ctx context.Context, provider string, d time.Duration, err error) {
+	t, ok := ctx.Value(attemptTrailKey{}).(*AttemptTrail)
+	if !ok {
+		return
+	}
+	a := models.RouteAttempt{Provider: provider, DurationMS: d.Milliseconds()}
+	if err != nil {
+		a.Error, a.ErrorClass = err.Error(), attemptErrorClass(err)
+	}
+	t.mu.Lock()
+	t.attempts = append(t.attempts, a)
+	t.mu.Unlock()
+}
+
+// skipError is an attempt routing declined without calling the provider;
+// class says why.
+type skipError struct{ class, msg string }
+
+func (e skipError) Error() string { return e.msg }
+
+// attemptErrorClass buckets an attempt's error for the stored trail: the
+// skip reason if the provider was never called, else its upstream class (see
+// classifyUpstreamError).
+func attemptErrorClass(err error) string {
+	var skip skipError
+	if errors.As(err, &skip) {
+		return skip.class
+	}
+	return classifyUpstreamError(err)
+}
+
+// RateLimited reports whether a routing error means the provider was busy
+// rather than broken: it answered 429/529, was cooling down after one, or
+// was at its rate budget. Retrying later is expected to succeed.
+func RateLimited(err error) bool {
+	var skip skipError
+	if errors.As(err, &skip) {
+		return skip.class == "cooldown" || skip.class == "budget"
+	}
+	var upErr *providers.UpstreamError
+	return errors.As(err, &upErr) && upErr.RateLimited()
+}
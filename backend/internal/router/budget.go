@@ -0,0 +1,114 @@
+package router
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"routerx/internal/store"
+)
+
+// budgetTTL keeps a minute's counters just past the end of the minute.
+const budgetTTL = 2 * time.Minute
+
+// reserveScript admits a request when the provider's current minute has room
+// for one more request and the estimated tokens, and records both in one step
+// so concurrent routers cannot overshoot together.
+// KEYS: requests, tokens. ARGV: rpm limit, tpm limit, estimated tokens, ttl.
+var reserveScript = redis.NewScript(`
+local rpm, tpm, est = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local toks = tonumber(redis.call('GET', KEYS[2]) or '0')
+if rpm > 0 and used >= rpm then return 1 end
+if tpm > 0 and toks > 0 and toks + est > tpm then return 2 end
+redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+redis.call('INCRBY', KEYS[2], est)
+redis.call('EXPIRE', KEYS[2], ARGV[4])
+return 0
+`)
+
+// BudgetUsage is a provider's consumption in the current minute.
+type BudgetUsage struct {
+	Requests int64 `json:"requests"`
+	Tokens   int64 `json:"tokens"`
+}
+
+// budgetKeys hash-tags both keys on the provider so reserveScript, which
+// touches both, stays on one Redis Cluster slot.
+func budgetKeys(providerID string, now time.Time) (string, string) {
+	minute := strconv.FormatInt(now.Unix()/60, 10)
+	prefix := "provider_budget:{" + providerID + "}:" + minute
+	return prefix + ":req", prefix + ":tok"
+}
+
+func hasBudget(p *store.Provider) bool {
+	return p.RPMLimit > 0 || p.TPMLimit > 0
+}
+
+// reserveBudget claims one request and est tokens of p's per-minute budget.
+// It returns "" when the request may go ahead, else which limit is used up.
+// Without Redis, or if Redis fails, budgets are not enforced.
+func (r *Router) reserveBudget(ctx context.Context, p *store.Provider, est int) string {
+	if r.Redis == nil || !hasBudget(p) {
+		return ""
+	}
+	reqKey, tokKey := budgetKeys(p.ID, time.Now())
+	code, err := reserveScript.Run(ctx, r.Redis, []string{reqKey, tokKey}, p.RPMLimit, p.TPMLimit, est, int(budgetTTL.Seconds())).Int()
+	if err != nil {
+		return ""
+	}
+	switch code {
+	case 1:
+		return "rpm"
+	case 2:
+		return "tpm"
+	}
+	return ""
+}
+
+// settleBudget replaces the token estimate reserved for a request with the
+// tokens it actually used.
+func (r *Router) settleBudget(ctx context.Context, p *store.Provider, est, actual int) {
+	if r.Redis == nil || p.TPMLimit <= 0 || actual == est {
+		return
+	}
+	_, tokKey := budgetKeys(p.ID, time.Now())
+	pipe := r.Redis.Pipeline()
+	pipe.IncrBy(ctx, tokKey, int64(actual-est))
+	pipe.Expire(ctx, tokKey, budgetTTL)
+	_, _ = pipe.Exec(ctx)
+}
+
+// BudgetUsage reads p's consumption in the current minute.
+func (r *Router) BudgetUsage(ctx context.Context, providerID string) BudgetUsage {
+	var u BudgetUsage
+	if r.Redis == nil {
+		return u
+	}
+	reqKey, tokKey := budgetKeys(providerID, time.Now())
+	vals, err := r.Redis.MGet(ctx, reqKey, tokKey).Result()
+	if err != nil {
+		return u
+	}
+	if s, ok := vals[0].(string); ok {
+		u.Requests, _ = strconv.ParseInt(s, 10, 64)
+	}
+	if s, ok := vals[1].(string); ok {
+		u.Tokens, _ = strconv.ParseInt(s, 10, 64)
+	}
+	return u
+}
+
+// budgetExhausted reports, without reserving anything, whether p's budget is
+// used up for a request of est tokens.
+func (r *Router) budgetExhausted(ctx context.Context, p store.Provider, est int) bool {
+	if r.Redis == nil || !hasBudget(&p) {
+		return false
+	}
+	u := r.BudgetUsage(ctx, p.ID)
+	return (p.RPMLimit > 0 && u.Requests >= int64(p.RPMLimit)) ||
+		(p.TPMLimit > 0 && u.Tokens > 0 && u.Tokens+int64(est) > int64(p.TPMLimit))
+}
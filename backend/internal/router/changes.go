@@ -0,0 +1,96 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	"routerx/internal/metrics"
+)
+
+// changesChannel carries admin changes between instances over Redis pub/sub,
+// so state an instance keeps in memory is refreshed within moments of a
+// change made through any other instance. Providers, the model catalog,
+// pricing and routing rules are read from Postgres per request and need no
+// notice.
+const changesChannel = "routerx:changes"
+
+// Change kinds.
+const (
+	// ChangeProvider drops the cached instance of provider ID.
+	ChangeProvider = "provider"
+	// ChangeCircuitClosed resets provider ID's breaker after a manual close.
+	ChangeCircuitClosed = "circuit_closed"
+	// ChangeAll drops every cached provider instance, after a bulk change
+	// such as applying a routing config document.
+	ChangeAll = "all"
+)
+
+// Change is one message on changesChannel.
+type Change struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Origin string `json:"origin"`
+}
+
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Notify applies a change on this instance and publishes it to the others.
+func (r *Router) Notify(ctx context.Context, c Change) {
+	r.applyChange(c)
+	if r.Redis == nil {
+		return
+	}
+	c.Origin = r.instanceID
+	b, _ := json.Marshal(c)
+	_ = r.Redis.Publish(ctx, changesChannel, b).Err()
+}
+
+// WatchChanges applies changes published by other instances until ctx ends.
+func (r *Router) WatchChanges(ctx context.Context) {
+	if r.Redis == nil {
+		return
+	}
+	sub := r.Redis.Subscribe(ctx, changesChannel)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var c Change
+			if err := json.Unmarshal([]byte(msg.Payload), &c); err != nil || c.Origin == r.instanceID {
+				continue
+			}
+			r.applyChange(c)
+		}
+	}
+}
+
+func (r *Router) applyChange(c Change) {
+	switch c.Kind {
+	case ChangeProvider:
+		r.Mu.Lock()
+		delete(r.instances, c.ID)
+		r.Mu.Unlock()
+	case ChangeCircuitClosed:
+		r.circuitFor(c.ID).reset()
+		if c.Name != "" {
+			metrics.CircuitOpen.WithLabelValues(c.Name).Set(0)
+		}
+	case ChangeAll:
+		r.Mu.Lock()
+		r.instances = nil
+		r.Mu.Unlock()
+	}
+}
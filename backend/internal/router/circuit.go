@@ -0,0 +1,133 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"routerx/internal/metrics"
+	"routerx/internal/store"
+)
+
+// Circuit-breaker defaults, used where a provider's CircuitSettings leave a
+// field zero.
+const (
+	DefaultCircuitWindow     = 20
+	DefaultCircuitThreshold  = 0.5
+	DefaultCircuitCooldown   = 30 * time.Second
+	DefaultCircuitMinSamples = 10
+	// DefaultCircuitHalfOpenMax trial requests pass a half-open circuit at
+	// once, and DefaultCircuitCloseAfter consecutive successes close it.
+	DefaultCircuitHalfOpenMax = 1
+	DefaultCircuitCloseAfter  = 3
+)
+
+// ValidateCircuitSettings checks stored circuit settings. Zero fields are
+// allowed and mean the default.
+func ValidateCircuitSettings(c store.CircuitSettings) error {
+	switch {
+	case c.WindowSize < 0 || c.CooldownSeconds < 0 || c.MinSamples < 0 || c.HalfOpenRequests < 0 || c.CloseAfterSuccesses < 0:
+		return errors.New("circuit settings must not be negative")
+	case c.FailureThreshold < 0 || c.FailureThreshold > 1:
+		return errors.New("failure_threshold must be between 0 and 1")
+	}
+	window := c.WindowSize
+	if window == 0 {
+		window = DefaultCircuitWindow
+	}
+	if c.MinSamples > window {
+		return fmt.Errorf("min_samples must not exceed window_size (%d)", window)
+	}
+	return nil
+}
+
+// configure applies a provider's settings, falling back to the defaults.
+// Samples beyond a shrunken window are dropped.
+func (c *CircuitState) configure(s store.CircuitSettings) {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+	c.WindowSize, c.Threshold, c.Cooldown, c.MinSamples = DefaultCircuitWindow, DefaultCircuitThreshold, DefaultCircuitCooldown, DefaultCircuitMinSamples
+	c.HalfOpenMax, c.CloseAfter = DefaultCircuitHalfOpenMax, DefaultCircuitCloseAfter
+	if s.WindowSize > 0 {
+		c.WindowSize = s.WindowSize
+	}
+	if s.FailureThreshold > 0 {
+		c.Threshold = s.FailureThreshold
+	}
+	if s.CooldownSeconds > 0 {
+		c.Cooldown = time.Duration(s.CooldownSeconds) * time.Second
+	}
+	if s.MinSamples > 0 {
+		c.MinSamples = s.MinSamples
+	}
+	if s.HalfOpenRequests > 0 {
+		c.HalfOpenMax = s.HalfOpenRequests
+	}
+	if s.CloseAfterSuccesses > 0 {
+		c.CloseAfter = s.CloseAfterSuccesses
+	}
+	if len(c.Samples) > c.WindowSize {
+		c.Samples = c.Samples[len(c.Samples)-c.WindowSize:]
+	}
+}
+
+func forcedOpenKey(providerID string) string {
+	return "circuit_forced_open:" + providerID
+}
+
+// OpenCircuit opens p's breaker by hand for d, or for its configured cooldown
+// when d is zero. The override is shared through Redis so every instance
+// stops routing to p.
+func (r *Router) OpenCircuit(ctx context.Context, p *store.Provider, d time.Duration) time.Time {
+	c := r.circuitFor(p.ID)
+	c.configure(p.Circuit)
+	c.Mu.Lock()
+	if d <= 0 {
+		d = c.Cooldown
+	}
+	until := time.Now().Add(d)
+	c.OpenUntil = until
+	c.open = true
+	c.halfOpen = false
+	c.Mu.Unlock()
+	if r.Redis != nil {
+		_ = r.Redis.Set(ctx, forcedOpenKey(p.ID), until.UnixMilli(), d).Err()
+	}
+	metrics.CircuitOpen.WithLabelValues(p.Name).Set(1)
+	r.recordProviderEvent(p, store.EventCircuitOpened, fmt.Sprintf("opened manually for %s", d))
+	return until
+}
+
+// CloseCircuit closes p's breaker by hand on every instance: it lifts a
+// manual open and clears each instance's failure window.
+func (r *Router) CloseCircuit(ctx context.Context, p *store.Provider) {
+	if r.Redis != nil {
+		_ = r.Redis.Del(ctx, forcedOpenKey(p.ID)).Err()
+	}
+	r.Notify(ctx, Change{Kind: ChangeCircuitClosed, ID: p.ID, Name: p.Name})
+	r.recordProviderEvent(p, store.EventCircuitClosed, "closed manually")
+}
+
+// reset returns the breaker to closed with an empty failure window.
+func (c *CircuitState) reset() {
+	c.Mu.Lock()
+	c.OpenUntil = time.Time{}
+	c.Samples = nil
+	c.open, c.halfOpen = false, false
+	c.trials, c.successes = 0, 0
+	c.Mu.Unlock()
+}
+
+// CircuitForcedOpenUntil returns when a manual open of providerID made on any
+// instance ends, or the zero time when there is none.
+func (r *Router) CircuitForcedOpenUntil(ctx context.Context, providerID string) time.Time {
+	if r.Redis == nil {
+		return time.Time{}
+	}
+	ms, err := r.Redis.Get(ctx, forcedOpenKey(providerID)).Int64()
+	if err != nil || ms <= time.Now().UnixMilli() {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
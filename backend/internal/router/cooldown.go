@@ -0,0 +1,67 @@
+package router
+
+import (
+	"context"
+	"time"
+
+	"routerx/internal/store"
+)
+
+const (
+	// DefaultCooldown applies when a rate-limited provider gives no
+	// Retry-After hint.
+	DefaultCooldown = 10 * time.Second
+	// MaxCooldown caps how long one rate-limit response can bench a provider.
+	MaxCooldown = 5 * time.Minute
+)
+
+func cooldownKey(providerID string) string {
+	return "provider_cooldown:" + providerID
+}
+
+// coolDown benches p for d after it answered 429/529, so routing falls
+// through to other candidates until the provider is ready again. The bench is
+// shared through Redis and kept locally as well. It returns the bench applied
+// and whether p was not already cooling down.
+func (r *Router) coolDown(ctx context.Context, p *store.Provider, d time.Duration) (time.Duration, bool) {
+	if d <= 0 {
+		d = DefaultCooldown
+	}
+	if d > MaxCooldown {
+		d = MaxCooldown
+	}
+	until := time.Now().Add(d)
+	r.Mu.Lock()
+	if r.cooldowns == nil {
+		r.cooldowns = map[string]time.Time{}
+	}
+	fresh := !time.Now().Before(r.cooldowns[p.ID])
+	if until.After(r.cooldowns[p.ID]) {
+		r.cooldowns[p.ID] = until
+	}
+	r.Mu.Unlock()
+	if r.Redis != nil {
+		_ = r.Redis.Set(ctx, cooldownKey(p.ID), until.UnixMilli(), d).Err()
+	}
+	return d, fresh
+}
+
+// CooldownUntil returns when a provider's rate-limit bench ends, or the zero
+// time when it is not benched.
+func (r *Router) CooldownUntil(ctx context.Context, providerID string) time.Time {
+	now := time.Now()
+	r.Mu.Lock()
+	until := r.cooldowns[providerID]
+	r.Mu.Unlock()
+	if until.After(now) {
+		return until
+	}
+	if r.Redis == nil {
+		return time.Time{}
+	}
+	ms, err := r.Redis.Get(ctx, cooldownKey(providerID)).Int64()
+	if err != nil || ms <= now.UnixMilli() {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
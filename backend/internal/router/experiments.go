@@ -0,0 +1,40 @@
+package router
+
+import (
+	"context"
+	"math/rand"
+
+	"routerx/internal/models"
+)
+
+const (
+	VariantControl = "control"
+	VariantTest    = "variant"
+)
+
+// ExperimentAssignment records which arm of an experiment a request landed in.
+// A zero value means no experiment applied.
+type ExperimentAssignment struct {
+	ExperimentID string
+	Variant      string
+}
+
+// ApplyExperiment looks up an active experiment for the tenant's model and,
+// for the sampled share of traffic, points the request at the variant model
+// and/or provider. Requests outside the sample are tagged as control.
+func (r *Router) ApplyExperiment(ctx context.Context, tenantID string, req *models.ChatCompletionRequest, opts *RouteOptions) ExperimentAssignment {
+	exp, err := r.Store.GetActiveExperiment(ctx, tenantID, req.Model)
+	if err != nil || exp == nil {
+		return ExperimentAssignment{}
+	}
+	if rand.Float64()*100 >= exp.TrafficPercent {
+		return ExperimentAssignment{ExperimentID: exp.ID, Variant: VariantControl}
+	}
+	if exp.VariantModel != "" {
+		req.Model = exp.VariantModel
+	}
+	if exp.VariantProviderID != "" {
+		opts.PreferProvider = exp.VariantProviderID
+	}
+	return ExperimentAssignment{ExperimentID: exp.ID, Variant: VariantTest}
+}
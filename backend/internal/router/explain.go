@@ -0,0 +1,244 @@
+package router
+
+import (
+	"context"
+
+	"routerx/internal/models"
+	"routerx/internal/store"
+)
+
+// ExplainCandidate is one provider considered for a request. Rejected says why
+// routing would skip it; an empty value means it would be attempted.
+type ExplainCandidate struct {
+	ProviderID   string `json:"provider_id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Region       string `json:"region,omitempty"`
+	Rejected     string `json:"rejected,omitempty"`
+	AvgLatencyMS int64  `json:"avg_latency_ms"`
+	// SLOMiss says which of the model's latency targets the provider misses;
+	// such providers are tried after those meeting them.
+	SLOMiss string `json:"slo_miss,omitempty"`
+}
+
+// ExplainStage is one routing step, with its candidates in attempt order
+// followed by the rejected ones.
+type ExplainStage struct {
+	Stage      string             `json:"stage"`
+	RuleID     string             `json:"rule_id,omitempty"`
+	Detail     string             `json:"detail,omitempty"`
+	Candidates []ExplainCandidate `json:"candidates"`
+}
+
+// ExplainRule records whether a routing rule matched and, if not, why.
+type ExplainRule struct {
+	ID       string `json:"id"`
+	Priority int    `json:"priority"`
+	Matched  bool   `json:"matched"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Explanation is the dry-run result of routing a request.
+type Explanation struct {
+	TenantID       string `json:"tenant_id"`
+	Model          string `json:"model"`
+	Capability     string `json:"capability"`
+	ContextTokens  int    `json:"context_tokens"`
+	HasTools       bool   `json:"has_tools"`
+	AllowFallbacks bool   `json:"allow_fallbacks"`
+	// Regions is the data-residency constraint applied; PreferRegion the
+	// region ordered first.
+	Regions      []string `json:"regions,omitempty"`
+	PreferRegion string   `json:"prefer_region,omitempty"`
+	// Interceptors may rewrite or answer the request; they are listed but not
+	// run.
+	Interceptors []string          `json:"interceptors,omitempty"`
+	Rules        []ExplainRule     `json:"rules"`
+	Stages       []ExplainStage    `json:"stages"`
+	Chosen       *ExplainCandidate `json:"chosen"`
+	Notes        []string          `json:"notes,omitempty"`
+}
+
+// Explain walks the same steps as routing without calling any provider or
+// changing circuit, session or health state. Chosen is the first provider
+// routing would attempt; it is nil when none is usable.
+func (r *Router) Explain(ctx context.Context, tenantID string, req models.ChatCompletionRequest, opts RouteOptions) Explanation {
+	capability := "text"
+	if requestHasImage(req) {
+		capability = "vision"
+	}
+	ex := Explanation{TenantID: tenantID, Capability: capability, AllowFallbacks: opts.AllowFallbacks, Regions: opts.Regions, PreferRegion: opts.PreferRegion, Rules: []ExplainRule{}, Stages: []ExplainStage{}}
+	for _, ic := range r.interceptors {
+		ex.Interceptors = append(ex.Interceptors, ic.Name())
+	}
+
+	rules, err := r.Store.ListRoutingRulesByTenant(ctx, tenantID)
+	if err != nil {
+		ex.Notes = append(ex.Notes, "routing rules: "+err.Error())
+	}
+	if req.Model == "" {
+		if m := defaultRuleModel(rules, capability); m != "" {
+			req.Model = m
+			ex.Notes = append(ex.Notes, "model taken from routing rule default")
+		} else {
+			req.Model = "default"
+		}
+	}
+	ex.Model = req.Model
+	if !ModelAllowed(req.Model, opts.AllowedModels, opts.DeniedModels) {
+		ex.Notes = append(ex.Notes, "model "+req.Model+" is not allowed for this tenant")
+		return ex
+	}
+	opts.requireParams(req)
+	opts.slo, _ = r.Store.GetModelSLO(ctx, req.Model)
+	in := newRuleInput(req, capability, opts.Tags)
+	ex.ContextTokens, ex.HasTools = in.ContextTokens, in.HasTools
+
+	if opts.PreferProvider == "" && opts.SessionKey != "" {
+		opts.PreferProvider = r.sessionProvider(ctx, sessionAffinityKey(tenantID, req.Model, opts.SessionKey), capability, opts)
+	}
+	if opts.PreferProvider != "" {
+		stage := ExplainStage{Stage: "preferred", Detail: "experiment variant or session pin"}
+		if p, err := r.Store.GetProviderByID(ctx, opts.PreferProvider); err == nil {
+			stage.Candidates = r.explainCandidates(ctx, []store.Provider{*p}, nil, capability, opts, ex.ContextTokens)
+		} else {
+			stage.Detail = "preferred provider " + opts.PreferProvider + " not found"
+		}
+		ex.Stages = append(ex.Stages, stage)
+	}
+
+	var overrides, fallbacks []store.RoutingRule
+	for _, rule := range rules {
+		reason := RuleMismatch(rule, in)
+		ex.Rules = append(ex.Rules, ExplainRule{ID: rule.ID, Priority: rule.Priority, Matched: reason == "", Reason: reason})
+	}
+	for _, rule := range MatchRules(rules, in) {
+		if rule.Priority > 0 {
+			overrides = append(overrides, rule)
+		} else {
+			fallbacks = append(fallbacks, rule)
+		}
+	}
+	for _, rule := range overrides {
+		ex.Stages = append(ex.Stages, r.explainRule(ctx, "rule_override", rule, capability, opts, ex.ContextTokens))
+	}
+
+	providerType, catalogOK, catalogErr := r.Store.GetModelProvider(ctx, req.Model)
+	var chain []store.Provider
+	if catalogOK {
+		chain, _ = r.Store.GetModelProviderChain(ctx, req.Model)
+	}
+	switch {
+	case len(chain) > 0:
+		ex.Stages = append(ex.Stages, ExplainStage{Stage: "provider_list", Detail: "model provider list", Candidates: r.explainCandidates(ctx, chain, requestOrder(opts), capability, opts, ex.ContextTokens)})
+	case catalogOK && providerType != "":
+		stage := ExplainStage{Stage: "auto_route", Detail: "enabled providers of type " + providerType}
+		list, err := r.Store.GetEnabledProvidersByType(ctx, providerType)
+		if err != nil {
+			stage.Detail += ": " + err.Error()
+		}
+		stage.Candidates = r.explainCandidates(ctx, list, func(c []store.Provider) []store.Provider {
+			if len(opts.ProviderOrder) > 0 {
+				return applyProviderOrder(c, opts.ProviderOrder)
+			}
+			r.sortCandidates(c, opts.Sort)
+			return c
+		}, capability, opts, ex.ContextTokens)
+		ex.Stages = append(ex.Stages, stage)
+	case catalogErr != nil:
+		ex.Notes = append(ex.Notes, "catalog lookup: "+catalogErr.Error())
+	default:
+		ex.Notes = append(ex.Notes, "model not in catalog")
+	}
+
+	for _, rule := range fallbacks {
+		ex.Stages = append(ex.Stages, r.explainRule(ctx, "rule_fallback", rule, capability, opts, ex.ContextTokens))
+	}
+
+	for _, stage := range ex.Stages {
+		for i := range stage.Candidates {
+			if stage.Candidates[i].Rejected == "" {
+				c := stage.Candidates[i]
+				ex.Chosen = &c
+				return ex
+			}
+		}
+		if !opts.AllowFallbacks && len(stage.Candidates) > 0 {
+			ex.Notes = append(ex.Notes, "fallbacks disabled: routing stops after the first attempt")
+			return ex
+		}
+	}
+	return ex
+}
+
+func (r *Router) explainRule(ctx context.Context, stageName string, rule store.RoutingRule, capability string, opts RouteOptions, est int) ExplainStage {
+	var list []store.Provider
+	stage := ExplainStage{Stage: stageName, RuleID: rule.ID}
+	for _, id := range rule.ProviderIDs {
+		p, err := r.Store.GetProviderByID(ctx, id)
+		if err != nil {
+			stage.Candidates = append(stage.Candidates, ExplainCandidate{ProviderID: id, Rejected: "provider not found"})
+			continue
+		}
+		list = append(list, *p)
+	}
+	stage.Candidates = append(r.explainCandidates(ctx, list, requestOrder(opts), capability, opts, est), stage.Candidates...)
+	return stage
+}
+
+// requestOrder applies the request's provider.order, keeping the list order
+// otherwise.
+func requestOrder(opts RouteOptions) func([]store.Provider) []store.Provider {
+	return func(c []store.Provider) []store.Provider {
+		if len(opts.ProviderOrder) > 0 {
+			return applyProviderOrder(c, opts.ProviderOrder)
+		}
+		return c
+	}
+}
+
+// explainCandidates orders the usable providers as routing would and lists
+// the rest with the reason they are skipped.
+func (r *Router) explainCandidates(ctx context.Context, list []store.Provider, order func([]store.Provider) []store.Provider, capability string, opts RouteOptions, est int) []ExplainCandidate {
+	var usable []store.Provider
+	var rejected []ExplainCandidate
+	for _, p := range list {
+		reason := candidateRejection(p, capability, opts)
+		if reason == "" && !p.Enabled {
+			reason = "provider disabled"
+		}
+		if reason != "" {
+			rejected = append(rejected, r.explainCandidate(p, reason))
+			continue
+		}
+		usable = append(usable, p)
+	}
+	if order != nil {
+		usable = order(usable)
+	}
+	if len(opts.ProviderOrder) == 0 {
+		usable = preferRegion(usable, opts.PreferRegion)
+		usable = r.preferSLO(usable, opts.slo)
+	}
+	out := make([]ExplainCandidate, 0, len(list))
+	for _, p := range usable {
+		reason := ""
+		if !r.CooldownUntil(ctx, p.ID).IsZero() {
+			reason = "rate limited, cooling down"
+		} else if !r.circuitFor(p.ID).Allow() || !r.CircuitForcedOpenUntil(ctx, p.ID).IsZero() {
+			reason = "circuit open"
+		} else if r.budgetExhausted(ctx, p, est) {
+			reason = "at rate budget"
+		}
+		c := r.explainCandidate(p, reason)
+		if opts.slo.TTFTMS > 0 || opts.slo.LatencyMS > 0 {
+			c.SLOMiss = r.sloMiss(p.ID, opts.slo)
+		}
+		out = append(out, c)
+	}
+	return append(out, rejected...)
+}
+
+func (r *Router) explainCandidate(p store.Provider, reason string) ExplainCandidate {
+	return ExplainCandidate{ProviderID: p.ID, Name: p.Name, Type: p.Type, Region: p.Region, Rejected: reason, AvgLatencyMS: r.Latency.Average(p.ID).Milliseconds()}
+}
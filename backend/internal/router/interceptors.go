@@ -0,0 +1,110 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+
+	"routerx/internal/models"
+	"routerx/internal/providers"
+)
+
+// Call is the routing call seen by interceptors. Request and Options may be
+// modified by Before hooks; later interceptors and routing see the changes.
+type Call struct {
+	TenantID string
+	Request  *models.ChatCompletionRequest
+	Options  *RouteOptions
+	Stream   bool
+}
+
+// Interceptor hooks into RouteWith. Interceptors run in registration order
+// before routing and in reverse order after it.
+type Interceptor interface {
+	Name() string
+	// Before may mutate the call. A non-nil response short-circuits routing:
+	// no provider is called and later interceptors are skipped. An error
+	// rejects the request.
+	Before(ctx context.Context, call *Call) (*models.ChatCompletionResponse, error)
+	// After post-processes a successful non-streaming response in place.
+	// Streamed responses have already been delivered and are not passed here.
+	After(ctx context.Context, call *Call, resp *models.ChatCompletionResponse) error
+}
+
+// InterceptorFuncs adapts plain functions to Interceptor; nil hooks are no-ops.
+type InterceptorFuncs struct {
+	ID         string
+	BeforeFunc func(ctx context.Context, call *Call) (*models.ChatCompletionResponse, error)
+	AfterFunc  func(ctx context.Context, call *Call, resp *models.ChatCompletionResponse) error
+}
+
+func (f InterceptorFuncs) Name() string { return f.ID }
+
+func (f InterceptorFuncs) Before(ctx context.Context, call *Call) (*models.ChatCompletionResponse, error) {
+	if f.BeforeFunc == nil {
+		return nil, nil
+	}
+	return f.BeforeFunc(ctx, call)
+}
+
+func (f InterceptorFuncs) After(ctx context.Context, call *Call, resp *models.ChatCompletionResponse) error {
+	if f.AfterFunc == nil {
+		return nil
+	}
+	return f.AfterFunc(ctx, call, resp)
+}
+
+// Use registers interceptors. It must be called during startup, before the
+// router serves requests.
+func (r *Router) Use(interceptors ...Interceptor) {
+	r.interceptors = append(r.interceptors, interceptors...)
+}
+
+// runBefore applies the Before hooks. When one short-circuits it returns the
+// response and the interceptor's name.
+func (r *Router) runBefore(ctx context.Context, call *Call) (*models.ChatCompletionResponse, string, error) {
+	for _, ic := range r.interceptors {
+		resp, err := ic.Before(ctx, call)
+		if err != nil {
+			return nil, ic.Name(), err
+		}
+		if resp != nil {
+			return resp, ic.Name(), nil
+		}
+	}
+	return nil, "", nil
+}
+
+func (r *Router) runAfter(ctx context.Context, call *Call, resp *models.ChatCompletionResponse) error {
+	for i := len(r.interceptors) - 1; i >= 0; i-- {
+		if err := r.interceptors[i].After(ctx, call, resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamSynthetic replays a short-circuit response to a streaming client as
+// one content chunk per choice followed by [DONE].
+func streamSynthetic(resp *models.ChatCompletionResponse, send providers.StreamSender) error {
+	for _, c := range resp.Choices {
+		content := ""
+		if c.Message.Content != nil {
+			content = *c.Message.Content
+		}
+		chunk, _ := json.Marshal(map[string]interface{}{
+			"id":      resp.ID,
+			"object":  "chat.completion.chunk",
+			"created": resp.Created,
+			"model":   resp.Model,
+			"choices": []map[string]interface{}{{
+				"index":         c.Index,
+				"delta":         map[string]string{"role": "assistant", "content": content},
+				"finish_reason": c.Finish,
+			}},
+		})
+		if err := send(string(chunk)); err != nil {
+			return err
+		}
+	}
+	return send("[DONE]")
+}
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"routerx/internal/providers"
+	"routerx/internal/store"
+)
+
+// byokKey marks a context whose candidates carry the caller's own API key;
+// such providers are built per request and never cached.
+type byokKey struct{}
+
+type cachedProvider struct {
+	hash     string
+	provider providers.Provider
+}
+
+// providerFor returns the provider instance for p, reusing the cached one
+// while p's configuration is unchanged. The configuration is re-read from the
+// store on every request, so a change made through another instance is
+// picked up here too.
+func (r *Router) providerFor(ctx context.Context, p *store.Provider) providers.Provider {
+	if byok, _ := ctx.Value(byokKey{}).(bool); byok {
+		return providers.NewProvider(*p, r.EnableReal)
+	}
+	hash := providerHash(p, r.EnableReal)
+	r.Mu.Lock()
+	defer r.Mu.Unlock()
+	if c, ok := r.instances[p.ID]; ok && c.hash == hash {
+		return c.provider
+	}
+	if r.instances == nil {
+		r.instances = map[string]cachedProvider{}
+	}
+	inst := providers.NewProvider(*p, r.EnableReal)
+	r.instances[p.ID] = cachedProvider{hash: hash, provider: inst}
+	return inst
+}
+
+// InvalidateProvider drops the cached instance for a provider on every
+// instance, after an admin change.
+func (r *Router) InvalidateProvider(ctx context.Context, id string) {
+	r.Notify(ctx, Change{Kind: ChangeProvider, ID: id})
+}
+
+// providerHash covers every field a provider instance is built from,
+// including the secrets its JSON form leaves out.
+func providerHash(p *store.Provider, enableReal bool) string {
+	b, _ := json.Marshal(p)
+	h := sha256.New()
+	fmt.Fprintf(h, "%t\x00%s\x00%s\x00", enableReal, p.APIKey, p.Network.ClientKey)
+	h.Write(b)
+	return string(h.Sum(nil))
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"strings"
+
+	"routerx/internal/store"
+)
+
+// ResidencyRegions combines a tenant's allowed regions with those a request
+// asks for. A request can narrow the tenant's set but never widen it; ok is
+// false when the request names only regions the tenant may not use.
+func ResidencyRegions(tenant, requested []string) ([]string, bool) {
+	requested = NormalizeRegions(requested)
+	if len(requested) == 0 {
+		return tenant, true
+	}
+	if len(tenant) == 0 {
+		return requested, true
+	}
+	var out []string
+	for _, r := range requested {
+		if containsStr(tenant, r) {
+			out = append(out, r)
+		}
+	}
+	return out, len(out) > 0
+}
+
+// InRegions reports whether p may serve a request restricted to regions. An
+// empty list allows every provider; otherwise providers without a region are
+// refused.
+func InRegions(p store.Provider, regions []string) bool {
+	return len(regions) == 0 || (p.Region != "" && containsStr(regions, p.Region))
+}
+
+// ParseRegions splits a comma-separated region list, dropping blanks.
+func ParseRegions(s string) []string {
+	return NormalizeRegions(strings.Split(s, ","))
+}
+
+// NormalizeRegions trims each region and drops blanks.
+func NormalizeRegions(list []string) []string {
+	var out []string
+	for _, r := range list {
+		if r = strings.TrimSpace(r); r != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// preferRegion moves providers in region to the front, keeping the order
+// within each group.
+func preferRegion(list []store.Provider, region string) []store.Provider {
+	if region == "" || len(list) < 2 {
+		return list
+	}
+	out := make([]store.Provider, 0, len(list))
+	for _, p := range list {
+		if strings.EqualFold(p.Region, region) {
+			out = append(out, p)
+		}
+	}
+	for _, p := range list {
+		if !strings.EqualFold(p.Region, region) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
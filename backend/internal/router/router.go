@@ -4,14 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
+	"routerx/internal/metrics"
 	"routerx/internal/models"
+	"routerx/internal/observability"
 	"routerx/internal/providers"
 	"routerx/internal/store"
 )
@@ -23,34 +30,124 @@ type CircuitState struct {
 	WindowSize  int
 	Threshold   float64
 	Cooldown    time.Duration
+	MinSamples  int
+	HalfOpenMax int
+	CloseAfter  int
+	open        bool
+	halfOpen    bool
+	trials      int // half-open trial requests in flight
+	successes   int // consecutive half-open trial successes
 }
 
 func (c *CircuitState) Allow() bool {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+	if c.halfOpen {
+		return c.trials < c.HalfOpenMax
+	}
+	return !time.Now().Before(c.OpenUntil)
+}
+
+// allow reports whether a request may pass. Once an open circuit's cooldown
+// ends it turns half-open and admits at most HalfOpenMax trial requests at a
+// time; trial reports whether this request is one of them, and halfOpened
+// whether this call made the open -> half-open transition.
+func (c *CircuitState) allow() (allowed, trial, halfOpened bool) {
 	c.Mu.Lock()
 	defer c.Mu.Unlock()
 	if time.Now().Before(c.OpenUntil) {
-		return false
+		return false, false, false
+	}
+	if c.open {
+		c.open = false
+		c.halfOpen = true
+		c.trials, c.successes = 0, 0
+		halfOpened = true
 	}
-	return true
+	if !c.halfOpen {
+		return true, false, false
+	}
+	if c.trials >= c.HalfOpenMax {
+		return false, false, halfOpened
+	}
+	c.trials++
+	return true, true, halfOpened
 }
 
-func (c *CircuitState) Record(ok bool) {
+// release frees a trial slot taken by a request that ended without a verdict
+// on the provider's health.
+func (c *CircuitState) release(trial bool) {
+	if !trial {
+		return
+	}
 	c.Mu.Lock()
 	defer c.Mu.Unlock()
+	if c.halfOpen && c.trials > 0 {
+		c.trials--
+	}
+}
+
+// state names the circuit's position: "closed", "open" or "half_open".
+func (c *CircuitState) state() string {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+	switch {
+	case c.halfOpen:
+		return "half_open"
+	case time.Now().Before(c.OpenUntil):
+		return "open"
+	}
+	return "closed"
+}
+
+// Record adds a sample and reports whether it tripped the circuit open, along
+// with the failure rate that did so. In the half-open state only trial
+// results count: a failed trial reopens the circuit, and CloseAfter
+// consecutive successes close it with a fresh window.
+func (c *CircuitState) Record(ok, trial bool) (opened, closed bool, rate float64) {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+	if c.halfOpen {
+		if !trial {
+			return false, false, 0
+		}
+		if c.trials > 0 {
+			c.trials--
+		}
+		if !ok {
+			c.halfOpen = false
+			c.open = true
+			c.OpenUntil = time.Now().Add(c.Cooldown)
+			return true, false, 0
+		}
+		c.successes++
+		if c.successes < c.CloseAfter {
+			return false, false, 0
+		}
+		c.halfOpen = false
+		c.Samples = nil
+		return false, true, 0
+	}
 	c.Samples = append(c.Samples, ok)
 	if len(c.Samples) > c.WindowSize {
 		c.Samples = c.Samples[len(c.Samples)-c.WindowSize:]
 	}
-	if len(c.Samples) >= 10 {
+	if len(c.Samples) >= c.MinSamples {
 		fail := 0
 		for _, s := range c.Samples {
-			if !s { fail++ }
+			if !s {
+				fail++
+			}
 		}
 		rate := float64(fail) / float64(len(c.Samples))
 		if rate >= c.Threshold {
 			c.OpenUntil = time.Now().Add(c.Cooldown)
+			opened := !c.open
+			c.open = true
+			return opened, false, rate
 		}
 	}
+	return false, false, 0
 }
 
 // LatencyTracker tracks rolling average latency per provider.
@@ -74,6 +171,13 @@ func (lt *LatencyTracker) Record(providerID string, d time.Duration) {
 	lt.Samples[providerID] = s
 }
 
+// Len is the number of samples held for providerID.
+func (lt *LatencyTracker) Len(providerID string) int {
+	lt.Mu.Lock()
+	defer lt.Mu.Unlock()
+	return len(lt.Samples[providerID])
+}
+
 func (lt *LatencyTracker) Average(providerID string) time.Duration {
 	lt.Mu.Lock()
 	defer lt.Mu.Unlock()
@@ -89,19 +193,45 @@ func (lt *LatencyTracker) Average(providerID string) time.Duration {
 }
 
 type Router struct {
-	Store        *store.Store
+	Store        Store
 	EnableReal   bool
-	Redis        *redis.Client
+	Redis        redis.UniversalClient
 	Circuits     map[string]*CircuitState
 	Latency      *LatencyTracker
 	Mu           sync.Mutex
+	health       map[string]string // providerID -> last recorded health status
+	interceptors []Interceptor
+	// OnProviderEvent, when set, is called for every recorded provider event.
+	OnProviderEvent func(ev store.ProviderEvent)
+	// cooldowns holds local rate-limit benches (providerID -> end), guarded
+	// by Mu.
+	cooldowns map[string]time.Time
+	// instances caches constructed providers by provider ID, guarded by Mu.
+	instances map[string]cachedProvider
+	// instanceID tells this process's change notices apart from others'.
+	instanceID string
+
+	// ModelTTFT and ModelLatency track rolling time to first token and total
+	// latency per provider and model, for catalog SLOs.
+	ModelTTFT    *LatencyTracker
+	ModelLatency *LatencyTracker
+
+	// MaxAttempts caps the candidates tried per request (0 tries them all);
+	// RetryBackoff is the pause before each fallback attempt.
+	MaxAttempts  int
+	RetryBackoff time.Duration
 }
 
-func New(store *store.Store, enableReal bool, redisClient *redis.Client) *Router {
+func New(store Store, enableReal bool, redisClient redis.UniversalClient) *Router {
 	return &Router{
 		Store: store, EnableReal: enableReal, Redis: redisClient,
 		Circuits: map[string]*CircuitState{},
 		Latency:  NewLatencyTracker(50),
+		health:   map[string]string{},
+
+		ModelTTFT:    NewLatencyTracker(50),
+		ModelLatency: NewLatencyTracker(50),
+		instanceID:   newInstanceID(),
 	}
 }
 
@@ -111,7 +241,7 @@ func (r *Router) circuitFor(providerID string) *CircuitState {
 	if c, ok := r.Circuits[providerID]; ok {
 		return c
 	}
-	c := &CircuitState{WindowSize: 20, Threshold: 0.5, Cooldown: 30 * time.Second}
+	c := &CircuitState{WindowSize: DefaultCircuitWindow, Threshold: DefaultCircuitThreshold, Cooldown: DefaultCircuitCooldown, MinSamples: DefaultCircuitMinSamples, HalfOpenMax: DefaultCircuitHalfOpenMax, CloseAfter: DefaultCircuitCloseAfter}
 	r.Circuits[providerID] = c
 	return c
 }
@@ -136,6 +266,41 @@ type RouteOptions struct {
 	UserID         string   // end-user ID for tracking
 	AppTitle       string   // app name for attribution
 	AppReferer     string   // app referer URL
+	PreferProvider string   // provider ID tried before normal routing (e.g. experiment variant)
+	SessionKey     string   // conversation affinity key; pins the session to the provider that served it
+
+	// Tags are the request's metadata tags, matched by routing rule conditions.
+	Tags map[string]string
+
+	// Regions, when set, is a hard data-residency constraint: providers
+	// outside these regions are never tried. PreferRegion moves providers in
+	// that region ahead of the rest.
+	Regions      []string
+	PreferRegion string
+
+	// AllowedProviders and DeniedProviders (IDs or names), and AllowedModels
+	// and DeniedModels, come from the tenant's policy. Unlike ProviderOnly and
+	// ProviderIgnore a request cannot widen them; see ApplyPolicy.
+	AllowedProviders []string
+	DeniedProviders  []string
+	AllowedModels    []string
+	DeniedModels     []string
+
+	// RequireParameters skips providers that would drop a parameter the
+	// request sets (see providers.UnsupportedParams) instead of sending the
+	// request without it.
+	RequireParameters bool
+	// paramReq is the request RequireParameters checks providers against.
+	paramReq *models.ChatCompletionRequest
+	// slo holds the requested model's catalog latency targets.
+	slo store.ModelSLO
+}
+
+// requireParams arms the RequireParameters check for req.
+func (o *RouteOptions) requireParams(req models.ChatCompletionRequest) {
+	if o.RequireParameters {
+		o.paramReq = &req
+	}
 }
 
 func DefaultRouteOptions() RouteOptions {
@@ -153,32 +318,172 @@ func (r *Router) RouteWithSort(ctx context.Context, tenantID string, req models.
 	return r.RouteWith(ctx, tenantID, req, stream, send, opts)
 }
 
+var tracer = otel.Tracer("routerx/router")
+
+// RouteWith routes req under a router.route span; each provider attempt is a
+// child span.
 func (r *Router) RouteWith(ctx context.Context, tenantID string, req models.ChatCompletionRequest, stream bool, send providers.StreamSender, opts RouteOptions) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
+	ctx, span := tracer.Start(ctx, "router.route", trace.WithAttributes(
+		attribute.String("routerx.tenant_id", tenantID),
+		attribute.String("routerx.model", req.Model),
+		attribute.Bool("routerx.stream", stream),
+	))
+	defer span.End()
+	resp, providerName, fallback, ttft, tokens, err := r.routeWith(ctx, tenantID, req, stream, send, opts)
+	span.SetAttributes(
+		attribute.String("routerx.provider", providerName),
+		attribute.Bool("routerx.fallback", fallback),
+		attribute.Int("routerx.tokens", tokens),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "routing failed")
+	}
+	return resp, providerName, fallback, ttft, tokens, err
+}
+
+func (r *Router) routeWith(ctx context.Context, tenantID string, req models.ChatCompletionRequest, stream bool, send providers.StreamSender, opts RouteOptions) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
+	if len(r.interceptors) == 0 {
+		return r.route(ctx, tenantID, req, stream, send, opts)
+	}
+	call := &Call{TenantID: tenantID, Request: &req, Options: &opts, Stream: stream}
+	short, name, err := r.runBefore(ctx, call)
+	if err != nil {
+		return models.ChatCompletionResponse{}, "", false, 0, 0, fmt.Errorf("interceptor %s: %w", name, err)
+	}
+	if short != nil {
+		// Synthetic responses are not billed: no provider was called.
+		if stream {
+			if err := streamSynthetic(short, send); err != nil {
+				return *short, "interceptor:" + name, false, 0, 0, err
+			}
+		}
+		return *short, "interceptor:" + name, false, 0, 0, nil
+	}
+	resp, providerName, fallback, ttft, tokens, err := r.route(ctx, tenantID, req, stream, send, opts)
+	if err == nil && !stream {
+		if aerr := r.runAfter(ctx, call, &resp); aerr != nil {
+			return resp, providerName, fallback, ttft, tokens, aerr
+		}
+	}
+	return resp, providerName, fallback, ttft, tokens, err
+}
+
+// route performs provider selection and the upstream call.
+func (r *Router) route(ctx context.Context, tenantID string, req models.ChatCompletionRequest, stream bool, send providers.StreamSender, opts RouteOptions) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
 	capability := "text"
 	if requestHasImage(req) {
 		capability = "vision"
 	}
 
-	// Step 1: Check if tenant has a routing rule override for this capability
-	rule, ruleErr := r.Store.GetRoutingRule(ctx, tenantID, capability)
+	// Step 1: Load the tenant's routing rules
+	rules, rulesErr := r.Store.ListRoutingRulesByTenant(ctx, tenantID)
 
-	// Step 2: If model not specified, try to get default from rule or use "default"
+	// Step 2: If model not specified, try to get default from a rule or use "default"
 	if req.Model == "" {
-		if rule != nil && rule.Model != "" {
-			req.Model = rule.Model
+		if m := defaultRuleModel(rules, capability); m != "" {
+			req.Model = m
 		} else {
 			req.Model = "default"
 		}
 	}
+	if !ModelAllowed(req.Model, opts.AllowedModels, opts.DeniedModels) {
+		return models.ChatCompletionResponse{}, "", false, 0, 0, fmt.Errorf("%w: %s", ErrModelNotAllowed, req.Model)
+	}
+	opts.requireParams(req)
+	opts.slo, _ = r.Store.GetModelSLO(ctx, req.Model)
 
 	var errs []string
+	if rulesErr != nil {
+		errs = append(errs, fmt.Sprintf("routing rules: %v", rulesErr))
+	}
+
+	// Sticky sessions: a conversation keeps going to the provider that served
+	// it last while that provider stays healthy.
+	if opts.SessionKey != "" {
+		key := sessionAffinityKey(tenantID, req.Model, opts.SessionKey)
+		if opts.PreferProvider == "" {
+			opts.PreferProvider = r.sessionProvider(ctx, key, capability, opts)
+		}
+		sb := &servedBy{}
+		ctx = context.WithValue(ctx, servedByKey{}, sb)
+		defer func() {
+			if sb.providerID != "" {
+				r.pinSession(context.WithoutCancel(ctx), key, sb.providerID)
+			}
+		}()
+	}
+
+	// Step 2b: A preferred provider (experiment variant or session pin) gets the first attempt;
+	// if it fails the request falls through to normal routing.
+	preferredFailed := false
+	if opts.PreferProvider != "" {
+		if p, err := r.Store.GetProviderByID(ctx, opts.PreferProvider); err == nil && len(filterCandidates([]store.Provider{*p}, capability, opts)) > 0 {
+			preferred := []store.Provider{*p}
+			pctx := applyBYOK(ctx, preferred, opts)
+			resp, providerName, _, ttft, tokens, err := r.tryProvider(pctx, &preferred[0], req, stream, send, opts.slo)
+			if err == nil || outputSent(err) {
+				return resp, providerName, false, ttft, tokens, err
+			}
+			errs = append(errs, fmt.Sprintf("preferred(%s): %v", p.Name, err))
+			preferredFailed = true
+		}
+	}
 
-	// Step 3: Try auto-routing via model_catalog
-	providerType, catalogOK, catalogErr := r.Store.GetModelProvider(ctx, req.Model)
-	if catalogOK && providerType != "" {
+	// Step 2c: Rules with a positive priority override catalog routing; the
+	// rest are tried only when the catalog cannot serve the model.
+	matched := MatchRules(rules, newRuleInput(req, capability, opts.Tags))
+	var overrides, fallbacks []store.RoutingRule
+	for _, rule := range matched {
+		if rule.Priority > 0 {
+			overrides = append(overrides, rule)
+		} else {
+			fallbacks = append(fallbacks, rule)
+		}
+	}
+	if len(overrides) > 0 {
+		resp, providerName, fallback, ttft, tokens, err := r.tryRules(ctx, overrides, capability, req, stream, send, opts)
+		if err == nil || outputSent(err) {
+			return resp, providerName, fallback || preferredFailed, ttft, tokens, err
+		}
+		errs = append(errs, err.Error())
+		if !opts.AllowFallbacks {
+			return models.ChatCompletionResponse{}, "", false, 0, 0, fmt.Errorf("routing failed for model %s: %s", req.Model, strings.Join(errs, "; "))
+		}
+		preferredFailed = true
+	}
+
+	// Step 3: Try the model's explicit provider list, else auto-route via model_catalog
+	selCtx, selSpan := tracer.Start(ctx, "router.select", trace.WithAttributes(attribute.String("routerx.model", req.Model)))
+	providerType, catalogOK, catalogErr := r.Store.GetModelProvider(selCtx, req.Model)
+	var chain []store.Provider
+	var chainErr error
+	if catalogOK {
+		chain, chainErr = r.Store.GetModelProviderChain(selCtx, req.Model)
+	}
+	if chainErr != nil {
+		selSpan.RecordError(chainErr)
+	}
+	selSpan.SetAttributes(
+		attribute.Bool("routerx.catalog_hit", catalogOK),
+		attribute.String("routerx.provider_type", providerType),
+		attribute.Int("routerx.chain_length", len(chain)),
+	)
+	selSpan.End()
+	if len(chain) > 0 {
+		resp, providerName, fallback, ttft, tokens, err := r.tryProviderChain(ctx, chain, capability, req, stream, send, opts)
+		if err == nil || outputSent(err) {
+			return resp, providerName, fallback || preferredFailed, ttft, tokens, err
+		}
+		errs = append(errs, fmt.Sprintf("provider-list(%s): %v", req.Model, err))
+	} else if chainErr != nil {
+		// The model may have a provider list we could not read; auto-routing
+		// by type could pick a provider the list leaves out.
+		errs = append(errs, fmt.Sprintf("provider-list(%s): %v", req.Model, chainErr))
+	} else if catalogOK && providerType != "" {
 		resp, providerName, fallback, ttft, tokens, err := r.tryProvidersByType(ctx, providerType, capability, req, stream, send, opts)
-		if err == nil {
-			return resp, providerName, fallback, ttft, tokens, nil
+		if err == nil || outputSent(err) {
+			return resp, providerName, fallback || preferredFailed, ttft, tokens, err
 		}
 		errs = append(errs, fmt.Sprintf("auto-route(%s): %v", providerType, err))
 	} else if catalogErr != nil {
@@ -187,27 +492,13 @@ func (r *Router) RouteWith(ctx context.Context, tenantID string, req models.Chat
 		errs = append(errs, "model not in catalog")
 	}
 
-	// Step 4: Fall back to routing_rules if available
-	if ruleErr == nil && rule != nil {
-		primary, err := r.Store.GetProviderByID(ctx, rule.PrimaryProviderID)
-		if err == nil {
-			resp, providerName, _, ttft, tokens, err := r.tryProvider(ctx, primary, req, stream, send)
-			if err == nil {
-				return resp, providerName, false, ttft, tokens, nil
-			}
-			errs = append(errs, fmt.Sprintf("rule-primary(%s): %v", primary.Name, err))
-			// Try secondary
-			if rule.SecondaryProviderID != "" {
-				secondary, err2 := r.Store.GetProviderByID(ctx, rule.SecondaryProviderID)
-				if err2 == nil {
-					resp2, provider2, _, ttft2, tokens2, err2 := r.tryProvider(ctx, secondary, req, stream, send)
-					if err2 == nil {
-						return resp2, provider2, true, ttft2, tokens2, nil
-					}
-					errs = append(errs, fmt.Sprintf("rule-secondary(%s): %v", secondary.Name, err2))
-				}
-			}
+	// Step 4: Fall back to the remaining matching routing rules
+	if len(fallbacks) > 0 {
+		resp, providerName, fallback, ttft, tokens, err := r.tryRules(ctx, fallbacks, capability, req, stream, send, opts)
+		if err == nil || outputSent(err) {
+			return resp, providerName, fallback || preferredFailed, ttft, tokens, err
 		}
+		errs = append(errs, err.Error())
 	}
 
 	// Step 5: No routing succeeded — show full error chain
@@ -224,105 +515,186 @@ func (r *Router) tryProvidersByType(ctx context.Context, providerType, capabilit
 		return models.ChatCompletionResponse{}, "", false, 0, 0, errors.New("no enabled provider for type: " + providerType)
 	}
 
-	// Filter by capability
-	var candidates []store.Provider
-	for _, p := range providersList {
-		if capability == "vision" && !p.SupportsVision {
-			continue
-		}
-		if capability == "text" && !p.SupportsText {
-			continue
-		}
-		// Apply provider.only filter
-		if len(opts.ProviderOnly) > 0 && !containsStr(opts.ProviderOnly, p.ID) && !containsStr(opts.ProviderOnly, p.Name) {
-			continue
-		}
-		// Apply provider.ignore filter
-		if len(opts.ProviderIgnore) > 0 && (containsStr(opts.ProviderIgnore, p.ID) || containsStr(opts.ProviderIgnore, p.Name)) {
-			continue
-		}
-		candidates = append(candidates, p)
-	}
+	candidates := filterCandidates(providersList, capability, opts)
 	if len(candidates) == 0 {
 		return models.ChatCompletionResponse{}, "", false, 0, 0, errors.New("no provider supports " + capability + " for type: " + providerType)
 	}
 
 	// Apply provider.order if specified
 	if len(opts.ProviderOrder) > 0 {
-		ordered := make([]store.Provider, 0, len(candidates))
-		for _, name := range opts.ProviderOrder {
-			for _, p := range candidates {
-				if p.ID == name || p.Name == name {
-					ordered = append(ordered, p)
-					break
-				}
-			}
+		candidates = applyProviderOrder(candidates, opts.ProviderOrder)
+	} else {
+		r.sortCandidates(candidates, opts.Sort)
+	}
+	return r.tryCandidates(ctx, candidates, req, stream, send, opts)
+}
+
+// tryProviderChain tries an admin-defined provider list for a model. The list
+// order is kept as-is unless the request supplies its own provider.order.
+func (r *Router) tryProviderChain(ctx context.Context, chain []store.Provider, capability string, req models.ChatCompletionRequest, stream bool, send providers.StreamSender, opts RouteOptions) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
+	candidates := filterCandidates(chain, capability, opts)
+	if len(candidates) == 0 {
+		return models.ChatCompletionResponse{}, "", false, 0, 0, errors.New("no listed provider supports " + capability)
+	}
+	if len(opts.ProviderOrder) > 0 {
+		candidates = applyProviderOrder(candidates, opts.ProviderOrder)
+	}
+	return r.tryCandidates(ctx, candidates, req, stream, send, opts)
+}
+
+// filterCandidates drops providers lacking the capability or excluded by the
+// request's provider.only / provider.ignore preferences.
+func filterCandidates(list []store.Provider, capability string, opts RouteOptions) []store.Provider {
+	var candidates []store.Provider
+	for _, p := range list {
+		if candidateRejection(p, capability, opts) == "" {
+			candidates = append(candidates, p)
 		}
-		// Append any remaining candidates not in the order list
+	}
+	return candidates
+}
+
+// candidateRejection reports why filterCandidates drops p, or "" if it keeps it.
+func candidateRejection(p store.Provider, capability string, opts RouteOptions) string {
+	if p.ArchivedAt != nil {
+		return "archived"
+	}
+	if p.UnderMaintenance() {
+		return "under maintenance"
+	}
+	if capability == "vision" && !p.SupportsVision {
+		return "provider lacks vision"
+	}
+	if capability == "text" && !p.SupportsText {
+		return "provider lacks text"
+	}
+	// Apply provider.only filter
+	if len(opts.ProviderOnly) > 0 && !containsStr(opts.ProviderOnly, p.ID) && !containsStr(opts.ProviderOnly, p.Name) {
+		return "not in provider.only"
+	}
+	// Apply provider.ignore filter
+	if len(opts.ProviderIgnore) > 0 && (containsStr(opts.ProviderIgnore, p.ID) || containsStr(opts.ProviderIgnore, p.Name)) {
+		return "in provider.ignore"
+	}
+	if !InRegions(p, opts.Regions) {
+		return "region not allowed"
+	}
+	if !ProviderAllowed(p, opts.AllowedProviders, opts.DeniedProviders) {
+		return "not allowed for tenant"
+	}
+	if opts.paramReq != nil {
+		if dropped := providers.UnsupportedParams(p.Type, *opts.paramReq); len(dropped) > 0 {
+			return "does not support " + strings.Join(dropped, ", ")
+		}
+	}
+	return ""
+}
+
+// applyProviderOrder moves the named providers to the front in the given order.
+func applyProviderOrder(candidates []store.Provider, order []string) []store.Provider {
+	ordered := make([]store.Provider, 0, len(candidates))
+	for _, name := range order {
 		for _, p := range candidates {
-			found := false
-			for _, o := range ordered {
-				if o.ID == p.ID {
-					found = true
-					break
-				}
-			}
-			if !found {
+			if p.ID == name || p.Name == name {
 				ordered = append(ordered, p)
+				break
 			}
 		}
-		candidates = ordered
-	} else {
-		// Sort candidates based on mode
-		switch opts.Sort {
-		case SortLatency:
-			sort.Slice(candidates, func(i, j int) bool {
-				li := r.Latency.Average(candidates[i].ID)
-				lj := r.Latency.Average(candidates[j].ID)
-				if li == 0 {
-					return false
-				}
-				if lj == 0 {
-					return true
-				}
-				return li < lj
-			})
-		default:
-			sort.Slice(candidates, func(i, j int) bool {
-				ci := r.circuitFor(candidates[i].ID).Allow()
-				cj := r.circuitFor(candidates[j].ID).Allow()
-				if ci != cj {
-					return ci
-				}
-				li := r.Latency.Average(candidates[i].ID)
-				lj := r.Latency.Average(candidates[j].ID)
-				if li == 0 || lj == 0 {
-					return false
-				}
-				return li < lj
-			})
+	}
+	// Append any remaining candidates not in the order list
+	for _, p := range candidates {
+		found := false
+		for _, o := range ordered {
+			if o.ID == p.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ordered = append(ordered, p)
 		}
 	}
+	return ordered
+}
 
-	// BYOK: override API key if provided
-	if opts.BYOKKey != "" {
-		for i := range candidates {
-			candidates[i].APIKey = opts.BYOKKey
-		}
+func (r *Router) sortCandidates(candidates []store.Provider, mode SortMode) {
+	switch mode {
+	case SortLatency:
+		sort.Slice(candidates, func(i, j int) bool {
+			li := r.Latency.Average(candidates[i].ID)
+			lj := r.Latency.Average(candidates[j].ID)
+			if li == 0 {
+				return false
+			}
+			if lj == 0 {
+				return true
+			}
+			return li < lj
+		})
+	default:
+		sort.Slice(candidates, func(i, j int) bool {
+			ci := r.circuitFor(candidates[i].ID).Allow()
+			cj := r.circuitFor(candidates[j].ID).Allow()
+			if ci != cj {
+				return ci
+			}
+			li := r.Latency.Average(candidates[i].ID)
+			lj := r.Latency.Average(candidates[j].ID)
+			if li == 0 || lj == 0 {
+				return false
+			}
+			return li < lj
+		})
+	}
+}
+
+// tryCandidates attempts each candidate in order until one succeeds.
+// applyBYOK swaps the tenant's own key, if it sent one, for the platform
+// key on every candidate and marks ctx as a BYOK request. Every path that
+// calls tryProvider must go through it.
+func applyBYOK(ctx context.Context, candidates []store.Provider, opts RouteOptions) context.Context {
+	if opts.BYOKKey == "" {
+		return ctx
+	}
+	for i := range candidates {
+		candidates[i].APIKey = opts.BYOKKey
+	}
+	return context.WithValue(ctx, byokKey{}, true)
+}
+
+func (r *Router) tryCandidates(ctx context.Context, candidates []store.Provider, req models.ChatCompletionRequest, stream bool, send providers.StreamSender, opts RouteOptions) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
+	ctx = applyBYOK(ctx, candidates, opts)
+
+	if len(opts.ProviderOrder) == 0 {
+		candidates = preferRegion(candidates, opts.PreferRegion)
+		candidates = r.preferSLO(candidates, opts.slo)
 	}
 
 	var lastErr error
 	for i, p := range candidates {
 		pCopy := p
-		resp, providerName, _, ttft, tokens, err := r.tryProvider(ctx, &pCopy, req, stream, send)
+		resp, providerName, _, ttft, tokens, err := r.tryProvider(ctx, &pCopy, req, stream, send, opts.slo)
 		if err == nil {
 			return resp, providerName, i > 0, ttft, tokens, nil
 		}
+		if outputSent(err) {
+			return resp, providerName, i > 0, ttft, tokens, err
+		}
 		lastErr = err
 		// If fallbacks disabled, stop after first attempt
 		if !opts.AllowFallbacks {
 			break
 		}
+		if r.MaxAttempts > 0 && i+1 >= r.MaxAttempts {
+			break
+		}
+		if r.RetryBackoff > 0 && i+1 < len(candidates) {
+			select {
+			case <-ctx.Done():
+				return models.ChatCompletionResponse{}, "", false, 0, 0, lastErr
+			case <-time.After(r.RetryBackoff):
+			}
+		}
 	}
 	return models.ChatCompletionResponse{}, "", false, 0, 0, lastErr
 }
@@ -336,36 +708,224 @@ func containsStr(list []string, s string) bool {
 	return false
 }
 
-func (r *Router) tryProvider(ctx context.Context, p *store.Provider, req models.ChatCompletionRequest, stream bool, send providers.StreamSender) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
+// outputSentError wraps the failure of a streaming attempt that had already
+// sent events to the client. Routing stops there: another provider would
+// repeat the answer from the start. Failures before the first event fall
+// through to the next candidate like buffered ones.
+type outputSentError struct{ error }
+
+func (e outputSentError) Unwrap() error { return e.error }
+
+func outputSent(err error) bool {
+	var o outputSentError
+	return errors.As(err, &o)
+}
+
+// tryProvider makes one attempt against p under a provider.attempt span,
+// abandoning it after the model's timeout (for streams, only while no event
+// has been sent).
+func (r *Router) tryProvider(ctx context.Context, p *store.Provider, req models.ChatCompletionRequest, stream bool, send providers.StreamSender, slo store.ModelSLO) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
+	ctx = observability.WithProvider(ctx, p.Name)
+	ctx, span := tracer.Start(ctx, "provider.attempt", trace.WithAttributes(
+		attribute.String("routerx.provider", p.Name),
+		attribute.String("routerx.provider_id", p.ID),
+		attribute.String("routerx.provider_type", p.Type),
+		attribute.String("routerx.model", req.Model),
+	))
+	defer span.End()
+	var timer *time.Timer
+	if slo.TimeoutMS > 0 {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		after := time.Duration(slo.TimeoutMS) * time.Millisecond
+		timer = time.AfterFunc(after, func() { cancel(attemptTimeout{after}) })
+		defer timer.Stop()
+	}
+	start := time.Now()
+	sent := false
+	var first time.Duration
+	if send != nil {
+		inner := send
+		send = func(event string) error {
+			if !sent {
+				sent, first = true, time.Since(start)
+				if timer != nil {
+					timer.Stop()
+				}
+			}
+			return inner(event)
+		}
+	}
+	resp, providerName, fallback, ttft, tokens, err := r.attemptProvider(ctx, p, req, stream, send)
+	recordAttempt(ctx, p.Name, time.Since(start), err)
+	if err != nil && sent {
+		err = outputSentError{err}
+	}
+	if err == nil {
+		if first == 0 {
+			first = ttft
+		}
+		r.recordModelLatency(p.ID, req.Model, first, ttft)
+	}
+	if err != nil {
+		span.SetAttributes(attribute.String("routerx.status", "fail"))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "provider attempt failed")
+	} else {
+		span.SetAttributes(attribute.String("routerx.status", "ok"), attribute.Int("routerx.tokens", tokens), attribute.Int64("routerx.ttft_ms", ttft.Milliseconds()))
+	}
+	return resp, providerName, fallback, ttft, tokens, err
+}
+
+func (r *Router) attemptProvider(ctx context.Context, p *store.Provider, req models.ChatCompletionRequest, stream bool, send providers.StreamSender) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
 	if !p.Enabled {
-		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, errors.New("provider disabled")
+		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, skipError{"unavailable", "provider disabled"}
 	}
 	if requestHasImage(req) && !p.SupportsVision {
-		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, errors.New("provider lacks vision")
+		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, skipError{"unavailable", "provider lacks vision"}
 	}
 	if !requestHasImage(req) && !p.SupportsText {
-		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, errors.New("provider lacks text")
+		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, skipError{"unavailable", "provider lacks text"}
+	}
+	if until := r.CooldownUntil(ctx, p.ID); !until.IsZero() {
+		metrics.UpstreamErrorsTotal.WithLabelValues(p.Name, "cooldown").Inc()
+		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, skipError{"cooldown", fmt.Sprintf("provider rate limited, cooling down for %s", time.Until(until).Round(time.Second))}
 	}
 	circuit := r.circuitFor(p.ID)
-	if !circuit.Allow() {
-		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, errors.New("circuit open")
+	circuit.configure(p.Circuit)
+	if !r.CircuitForcedOpenUntil(ctx, p.ID).IsZero() {
+		metrics.UpstreamErrorsTotal.WithLabelValues(p.Name, "circuit_open").Inc()
+		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, skipError{"circuit_open", "circuit open"}
+	}
+	allowed, trial, halfOpened := circuit.allow()
+	if halfOpened {
+		metrics.CircuitOpen.WithLabelValues(p.Name).Set(0.5)
+		r.recordProviderEvent(p, store.EventCircuitHalfOpen, fmt.Sprintf("cooldown elapsed, admitting %d trial request(s)", circuit.HalfOpenMax))
+	}
+	if !allowed {
+		metrics.UpstreamErrorsTotal.WithLabelValues(p.Name, "circuit_open").Inc()
+		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, skipError{"circuit_open", "circuit open"}
 	}
-	provider := providers.NewProvider(*p, r.EnableReal)
+	// A provider at its upstream rate budget is skipped without calling it,
+	// and without counting against its circuit.
+	est := EstimatePromptTokens(req)
+	if limit := r.reserveBudget(ctx, p, est); limit != "" {
+		metrics.ProviderBudgetSkipsTotal.WithLabelValues(p.Name, limit).Inc()
+		circuit.release(trial)
+		return models.ChatCompletionResponse{}, p.Name, false, 0, 0, skipError{"budget", fmt.Sprintf("provider at %s budget", limit)}
+	}
+	provider := r.providerFor(ctx, p)
 	resp, ttft, tokens, err := provider.Chat(ctx, req, stream, send)
-	circuit.Record(err == nil)
+	// Report a model timeout rather than the bare context error it causes.
+	var timeout attemptTimeout
+	if err != nil && errors.As(context.Cause(ctx), &timeout) {
+		err = timeout
+	}
+	if err != nil {
+		metrics.UpstreamErrorsTotal.WithLabelValues(p.Name, classifyUpstreamError(err)).Inc()
+	} else {
+		r.settleBudget(ctx, p, est, tokens)
+	}
+	// 429/529 means the provider is busy, not broken: bench it for the time it
+	// asked for and let routing move on, leaving the circuit window alone.
+	var upErr *providers.UpstreamError
+	if errors.As(err, &upErr) && upErr.RateLimited() {
+		if d, fresh := r.coolDown(ctx, p, upErr.RetryAfter); fresh {
+			r.recordProviderEvent(p, store.EventRateLimited, fmt.Sprintf("HTTP %d, cooling down for %s", upErr.StatusCode, d))
+		}
+		circuit.release(trial)
+		return resp, p.Name, false, ttft, tokens, err
+	}
+	switch opened, closed, rate := circuit.Record(err == nil, trial); {
+	case opened && trial:
+		metrics.CircuitOpen.WithLabelValues(p.Name).Set(1)
+		r.recordProviderEvent(p, store.EventCircuitOpened, fmt.Sprintf("trial request failed: %v", err))
+	case opened:
+		metrics.CircuitOpen.WithLabelValues(p.Name).Set(1)
+		r.recordProviderEvent(p, store.EventCircuitOpened, fmt.Sprintf("failure rate %.0f%% over last %d requests: %v", rate*100, circuit.WindowSize, err))
+	case closed:
+		metrics.CircuitOpen.WithLabelValues(p.Name).Set(0)
+		r.recordProviderEvent(p, store.EventCircuitClosed, fmt.Sprintf("%d consecutive trial requests succeeded", circuit.CloseAfter))
+	}
 	if err == nil {
 		r.Latency.Record(p.ID, ttft)
+		markServedBy(ctx, p.ID)
+		resp.DroppedParams = providers.UnsupportedParams(p.Type, req)
+	}
+	status := "ok"
+	if err != nil {
+		status = "fail"
 	}
 	if r.Redis != nil {
-		status := "ok"
-		if err != nil {
-			status = "fail"
-		}
 		_ = r.Redis.Set(ctx, "provider_health:"+p.ID, status, 30*time.Second).Err()
 	}
+	r.trackHealth(p, status, err)
 	return resp, p.Name, false, ttft, tokens, err
 }
 
+// classifyUpstreamError buckets a provider error for metrics. Providers return
+// the upstream error body as the message, so HTTP classes are recognised from
+// the text.
+func classifyUpstreamError(err error) string {
+	var netErr net.Error
+	var upErr *providers.UpstreamError
+	switch {
+	case errors.As(err, &upErr) && upErr.RateLimited():
+		return "rate_limited"
+	case errors.Is(err, providers.ErrStreamInterrupted):
+		return "stream_interrupted"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "connection"
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "rate limit"), strings.Contains(msg, "rate_limit"), strings.Contains(msg, "429"), strings.Contains(msg, "quota"):
+		return "rate_limited"
+	case strings.Contains(msg, "overloaded"), strings.Contains(msg, "server_error"), strings.Contains(msg, "internal error"), strings.Contains(msg, "unavailable"):
+		return "server_error"
+	case strings.Contains(msg, "invalid_request"), strings.Contains(msg, "invalid request"), strings.Contains(msg, "authentication"), strings.Contains(msg, "api key"), strings.Contains(msg, "permission"):
+		return "client_error"
+	}
+	return "other"
+}
+
+// trackHealth records a provider event when its health status flips.
+func (r *Router) trackHealth(p *store.Provider, status string, err error) {
+	r.Mu.Lock()
+	prev, seen := r.health[p.ID]
+	r.health[p.ID] = status
+	r.Mu.Unlock()
+	if !seen || prev == status {
+		return
+	}
+	if status == "ok" {
+		r.recordProviderEvent(p, store.EventHealthOK, "request succeeded")
+	} else {
+		r.recordProviderEvent(p, store.EventHealthFail, err.Error())
+	}
+}
+
+// recordProviderEvent persists a transition without blocking the request path.
+func (r *Router) recordProviderEvent(p *store.Provider, eventType, detail string) {
+	if r.Store == nil {
+		return
+	}
+	ev := store.ProviderEvent{ProviderID: p.ID, ProviderName: p.Name, EventType: eventType, Detail: detail, CreatedAt: time.Now().UTC()}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = r.Store.InsertProviderEvent(ctx, ev)
+		if r.OnProviderEvent != nil {
+			r.OnProviderEvent(ev)
+		}
+	}()
+}
+
 func requestHasImage(req models.ChatCompletionRequest) bool {
 	for _, msg := range req.Messages {
 		if models.ContentHasImage(msg.Content) {
@@ -386,12 +946,34 @@ func (r *Router) GetProviderLatencies() map[string]int64 {
 	return out
 }
 
-func (r *Router) GetCircuitStates() map[string]bool {
+// GetCircuitStates returns each known circuit's state: "closed", "open" or
+// "half_open".
+func (r *Router) GetCircuitStates() map[string]string {
 	r.Mu.Lock()
 	defer r.Mu.Unlock()
-	states := map[string]bool{}
+	states := map[string]string{}
 	for id, c := range r.Circuits {
-		states[id] = !c.Allow()
+		states[id] = c.state()
 	}
 	return states
 }
+
+// TestProvider sends a minimal completion to the provider, bypassing the
+// circuit breaker and health tracking, and reports how long it took. An
+// apiKey override lets callers validate a key before it goes live.
+func (r *Router) TestProvider(ctx context.Context, p store.Provider, apiKey string) (time.Duration, error) {
+	if apiKey != "" {
+		p.APIKey = apiKey
+	}
+	model := p.DefaultModel
+	if model == "" {
+		return 0, errors.New("provider has no default_model to test with")
+	}
+	req := models.ChatCompletionRequest{
+		Model:     model,
+		Messages:  []models.Message{{Role: "user", Content: []byte(`"ping"`)}},
+		MaxTokens: 1,
+	}
+	_, latency, _, err := providers.NewProvider(p, r.EnableReal).Chat(ctx, req, false, nil)
+	return latency, err
+}
@@ -0,0 +1,160 @@
+package router_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"routerx/internal/models"
+	"routerx/internal/router"
+	"routerx/internal/store"
+	"routerx/internal/store/storemock"
+)
+
+const completion = `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o",` +
+	`"choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],` +
+	`"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`
+
+// upstream is a fake OpenAI endpoint answering with status.
+type upstream struct {
+	status int
+	calls  atomic.Int32
+	auth   atomic.Value // Authorization header of the last call
+	srv    *httptest.Server
+}
+
+func newUpstream(t *testing.T, status int) *upstream {
+	u := &upstream{status: status}
+	u.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.calls.Add(1)
+		u.auth.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(u.status)
+		if u.status == http.StatusOK {
+			_, _ = w.Write([]byte(completion))
+			return
+		}
+		_, _ = w.Write([]byte(`{"error":{"message":"upstream failed"}}`))
+	}))
+	t.Cleanup(u.srv.Close)
+	return u
+}
+
+func (u *upstream) provider(id string) store.Provider {
+	return store.Provider{ID: id, Name: id, Type: "openai", BaseURL: u.srv.URL, APIKey: "sk-test", Enabled: true, SupportsText: true}
+}
+
+func TestRouteSelectionAndFailover(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      string
+		statusA    int
+		statusB    int
+		chain      bool  // serve the model from a provider list, else by type
+		chainErr   error // returned by the provider list lookup
+		opts       func(*router.RouteOptions)
+		wantName   string
+		wantFall   bool
+		wantErr    error // matched with errors.Is when set
+		wantFailed bool
+		wantCallsA int32
+		wantCallsB int32
+	}{
+		{name: "first listed provider serves", model: "gpt-4o", statusA: 200, statusB: 200, chain: true, wantName: "a", wantCallsA: 1},
+		{name: "falls over to next listed provider", model: "gpt-4o", statusA: 500, statusB: 200, chain: true, wantName: "b", wantFall: true, wantCallsA: 1, wantCallsB: 1},
+		{name: "falls over within provider type", model: "gpt-4o", statusA: 500, statusB: 200, wantName: "b", wantFall: true, wantCallsA: 1, wantCallsB: 1},
+		{name: "fallbacks disabled", model: "gpt-4o", statusA: 500, statusB: 200, chain: true,
+			opts: func(o *router.RouteOptions) { o.AllowFallbacks = false }, wantFailed: true, wantCallsA: 1},
+		{name: "every provider fails", model: "gpt-4o", statusA: 500, statusB: 502, chain: true, wantFailed: true, wantCallsA: 1, wantCallsB: 1},
+		{name: "provider.only narrows candidates", model: "gpt-4o", statusA: 200, statusB: 200, chain: true,
+			opts: func(o *router.RouteOptions) { o.ProviderOnly = []string{"b"} }, wantName: "b", wantCallsB: 1},
+		{name: "provider.order reorders candidates", model: "gpt-4o", statusA: 200, statusB: 200, chain: true,
+			opts: func(o *router.RouteOptions) { o.ProviderOrder = []string{"b", "a"} }, wantName: "b", wantCallsB: 1},
+		{name: "tenant-denied provider is skipped", model: "gpt-4o", statusA: 200, statusB: 200, chain: true,
+			opts: func(o *router.RouteOptions) { o.DeniedProviders = []string{"a"} }, wantName: "b", wantCallsB: 1},
+		{name: "tenant-denied model is refused", model: "gpt-4o", statusA: 200, statusB: 200, chain: true,
+			opts: func(o *router.RouteOptions) { o.DeniedModels = []string{"gpt-*"} }, wantErr: router.ErrModelNotAllowed, wantFailed: true},
+		{name: "provider list lookup fails", model: "gpt-4o", statusA: 200, statusB: 200, chainErr: errors.New("db down"), wantFailed: true},
+		{name: "model outside the catalog", model: "unknown", statusA: 200, statusB: 200, wantFailed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := newUpstream(t, tt.statusA), newUpstream(t, tt.statusB)
+			list := []store.Provider{a.provider("a"), b.provider("b")}
+			st := &storemock.Store{
+				GetModelProviderFunc: func(_ context.Context, model string) (string, bool, error) {
+					return "openai", model == "gpt-4o", nil
+				},
+				GetModelProviderChainFunc: func(context.Context, string) ([]store.Provider, error) {
+					if tt.chainErr != nil {
+						return nil, tt.chainErr
+					}
+					if tt.chain {
+						return list, nil
+					}
+					return nil, nil
+				},
+				GetEnabledProvidersByTypeFunc: func(context.Context, string) ([]store.Provider, error) {
+					return list, nil
+				},
+			}
+			rt := router.New(st, true, nil)
+			opts := router.DefaultRouteOptions()
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+			req := models.ChatCompletionRequest{Model: tt.model, Messages: []models.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}}}
+			resp, name, fallback, _, tokens, err := rt.RouteWith(context.Background(), "t1", req, false, nil, opts)
+
+			if tt.wantFailed {
+				if err == nil {
+					t.Fatalf("RouteWith succeeded via %q, want an error", name)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("RouteWith: %v", err)
+				}
+				if name != tt.wantName || fallback != tt.wantFall {
+					t.Errorf("served by %q (fallback %t), want %q (fallback %t)", name, fallback, tt.wantName, tt.wantFall)
+				}
+				if tokens != 5 || len(resp.Choices) != 1 {
+					t.Errorf("got %d tokens and %d choices, want 5 and 1", tokens, len(resp.Choices))
+				}
+			}
+			if got := a.calls.Load(); got != tt.wantCallsA {
+				t.Errorf("provider a called %d times, want %d", got, tt.wantCallsA)
+			}
+			if got := b.calls.Load(); got != tt.wantCallsB {
+				t.Errorf("provider b called %d times, want %d", got, tt.wantCallsB)
+			}
+		})
+	}
+}
+
+func TestPreferredProviderUsesBYOKKey(t *testing.T) {
+	a := newUpstream(t, http.StatusOK)
+	p := a.provider("a")
+	st := &storemock.Store{
+		GetProviderByIDFunc: func(context.Context, string) (*store.Provider, error) {
+			cp := p
+			return &cp, nil
+		},
+	}
+	rt := router.New(st, true, nil)
+	opts := router.DefaultRouteOptions()
+	opts.PreferProvider, opts.BYOKKey = "a", "sk-tenant"
+	req := models.ChatCompletionRequest{Model: "gpt-4o", Messages: []models.Message{{Role: "user", Content: json.RawMessage(`"hello"`)}}}
+	if _, name, _, _, _, err := rt.RouteWith(context.Background(), "t1", req, false, nil, opts); err != nil || name != "a" {
+		t.Fatalf("RouteWith = %q, %v; want served by a", name, err)
+	}
+	if got := a.auth.Load(); got != "Bearer sk-tenant" {
+		t.Errorf("upstream saw Authorization %q, want the tenant's key", got)
+	}
+}
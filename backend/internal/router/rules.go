@@ -0,0 +1,158 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"routerx/internal/models"
+	"routerx/internal/providers"
+	"routerx/internal/store"
+)
+
+// RuleInput is what routing rule conditions are evaluated against.
+type RuleInput struct {
+	Model         string
+	Capability    string
+	ContextTokens int
+	HasTools      bool
+	Tags          map[string]string
+}
+
+func newRuleInput(req models.ChatCompletionRequest, capability string, tags map[string]string) RuleInput {
+	return RuleInput{
+		Model:         req.Model,
+		Capability:    capability,
+		ContextTokens: EstimatePromptTokens(req),
+		HasTools:      len(req.Tools) > 0 && string(req.Tools) != "null",
+		Tags:          tags,
+	}
+}
+
+// EstimatePromptTokens approximates the prompt size at four characters per
+// token, which is close enough for threshold-style routing conditions.
+func EstimatePromptTokens(req models.ChatCompletionRequest) int {
+	chars := 0
+	for _, msg := range req.Messages {
+		chars += len(models.ContentText(msg.Content))
+	}
+	return (chars + 3) / 4
+}
+
+// RuleMismatch reports the first condition of rule that in does not meet, or
+// "" when the rule matches.
+func RuleMismatch(rule store.RoutingRule, in RuleInput) string {
+	switch {
+	case !rule.Enabled:
+		return "rule disabled"
+	case rule.Capability != "" && rule.Capability != in.Capability:
+		return fmt.Sprintf("capability %s, rule needs %s", in.Capability, rule.Capability)
+	case rule.ModelPattern != "" && !globMatch(rule.ModelPattern, in.Model):
+		return fmt.Sprintf("model %s does not match %s", in.Model, rule.ModelPattern)
+	case in.ContextTokens < rule.MinContextTokens:
+		return fmt.Sprintf("about %d context tokens, rule needs %d", in.ContextTokens, rule.MinContextTokens)
+	case rule.RequiresTools && !in.HasTools:
+		return "request has no tools"
+	}
+	for k, v := range rule.Tags {
+		if in.Tags[k] != v {
+			return fmt.Sprintf("tag %s is not %q", k, v)
+		}
+	}
+	return ""
+}
+
+// MatchRules returns the rules matching in, highest priority first. Rules of
+// equal priority keep their stored order.
+func MatchRules(rules []store.RoutingRule, in RuleInput) []store.RoutingRule {
+	var matched []store.RoutingRule
+	for _, rule := range rules {
+		if RuleMismatch(rule, in) == "" {
+			matched = append(matched, rule)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Priority > matched[j].Priority })
+	return matched
+}
+
+// defaultRuleModel returns the model of the highest-priority enabled rule for
+// capability that names one, for requests that leave the model empty.
+func defaultRuleModel(rules []store.RoutingRule, capability string) string {
+	best := -1
+	for i, rule := range rules {
+		if !rule.Enabled || rule.Model == "" || (rule.Capability != "" && rule.Capability != capability) {
+			continue
+		}
+		if best < 0 || rule.Priority > rules[best].Priority {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return rules[best].Model
+}
+
+// globMatch reports whether s matches pattern, where * matches any run of
+// characters (including "/"). Matching ignores case.
+func globMatch(pattern, s string) bool {
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// ruleCandidates loads a rule's providers in order, dropping disabled ones and
+// those filterCandidates rejects.
+func (r *Router) ruleCandidates(ctx context.Context, rule store.RoutingRule, capability string, opts RouteOptions) []store.Provider {
+	var list []store.Provider
+	for _, id := range rule.ProviderIDs {
+		p, err := r.Store.GetProviderByID(ctx, id)
+		if err != nil || !p.Enabled {
+			continue
+		}
+		list = append(list, *p)
+	}
+	return filterCandidates(list, capability, opts)
+}
+
+// tryRules tries each matching rule's providers in turn. fallback is false
+// only when the first provider of the first rule served the request.
+func (r *Router) tryRules(ctx context.Context, rules []store.RoutingRule, capability string, req models.ChatCompletionRequest, stream bool, send providers.StreamSender, opts RouteOptions) (models.ChatCompletionResponse, string, bool, time.Duration, int, error) {
+	var errs []string
+	for i, rule := range rules {
+		candidates := r.ruleCandidates(ctx, rule, capability, opts)
+		if len(candidates) == 0 {
+			errs = append(errs, fmt.Sprintf("rule(%s): no usable provider", rule.ID))
+			continue
+		}
+		if len(opts.ProviderOrder) > 0 {
+			candidates = applyProviderOrder(candidates, opts.ProviderOrder)
+		}
+		resp, providerName, fallback, ttft, tokens, err := r.tryCandidates(ctx, candidates, req, stream, send, opts)
+		if err == nil || outputSent(err) {
+			return resp, providerName, fallback || i > 0, ttft, tokens, err
+		}
+		errs = append(errs, fmt.Sprintf("rule(%s): %v", rule.ID, err))
+		if !opts.AllowFallbacks {
+			break
+		}
+	}
+	return models.ChatCompletionResponse{}, "", false, 0, 0, errors.New(strings.Join(errs, "; "))
+}
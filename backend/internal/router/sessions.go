@@ -0,0 +1,58 @@
+package router
+
+import (
+	"context"
+	"time"
+
+	"routerx/internal/store"
+	"routerx/internal/util"
+)
+
+// SessionTTL is how long a session stays pinned to a provider after its last
+// successful request.
+const SessionTTL = 30 * time.Minute
+
+type servedByKey struct{}
+
+// servedBy captures the provider that completed a request so the session can
+// be pinned to it once routing returns.
+type servedBy struct {
+	providerID string
+}
+
+func sessionAffinityKey(tenantID, model, session string) string {
+	return "session_affinity:" + tenantID + ":" + model + ":" + util.HashString(session)
+}
+
+// sessionProvider returns the provider a session is pinned to, or "" when
+// there is no pin or the pinned provider is no longer usable for this request.
+func (r *Router) sessionProvider(ctx context.Context, key, capability string, opts RouteOptions) string {
+	if r.Redis == nil {
+		return ""
+	}
+	id, err := r.Redis.Get(ctx, key).Result()
+	if err != nil || id == "" {
+		return ""
+	}
+	p, err := r.Store.GetProviderByID(ctx, id)
+	if err != nil || len(filterCandidates([]store.Provider{*p}, capability, opts)) == 0 {
+		return ""
+	}
+	if !r.circuitFor(p.ID).Allow() {
+		return ""
+	}
+	return p.ID
+}
+
+func (r *Router) pinSession(ctx context.Context, key, providerID string) {
+	if r.Redis == nil {
+		return
+	}
+	_ = r.Redis.Set(ctx, key, providerID, SessionTTL).Err()
+}
+
+func markServedBy(ctx context.Context, providerID string) {
+	if sb, ok := ctx.Value(servedByKey{}).(*servedBy); ok {
+		sb.providerID = providerID
+	}
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"routerx/internal/store"
+)
+
+// sloMinSamples is how many attempts a provider must have served for a model
+// before its rolling latency is judged against the model's SLO.
+const sloMinSamples = 5
+
+func sloKey(providerID, model string) string { return providerID + "|" + model }
+
+// recordModelLatency adds one successful attempt to the provider's rolling
+// per-model time to first token and total latency.
+func (r *Router) recordModelLatency(providerID, model string, ttft, total time.Duration) {
+	key := sloKey(providerID, model)
+	r.ModelTTFT.Record(key, ttft)
+	r.ModelLatency.Record(key, total)
+}
+
+// sloMiss returns which of slo's targets the provider's rolling latency for
+// the model misses, or "" if it meets them or has too few samples to tell.
+func (r *Router) sloMiss(providerID string, slo store.ModelSLO) string {
+	key := sloKey(providerID, slo.Model)
+	if r.ModelLatency.Len(key) < sloMinSamples {
+		return ""
+	}
+	if avg := r.ModelTTFT.Average(key); slo.TTFTMS > 0 && avg > time.Duration(slo.TTFTMS)*time.Millisecond {
+		return fmt.Sprintf("ttft %dms over %dms SLO", avg.Milliseconds(), slo.TTFTMS)
+	}
+	if avg := r.ModelLatency.Average(key); slo.LatencyMS > 0 && avg > time.Duration(slo.LatencyMS)*time.Millisecond {
+		return fmt.Sprintf("latency %dms over %dms SLO", avg.Milliseconds(), slo.LatencyMS)
+	}
+	return ""
+}
+
+// preferSLO moves providers missing the model's SLO behind those meeting it,
+// keeping the order within each group. Violators stay candidates: an SLO
+// miss is slow, not down.
+func (r *Router) preferSLO(list []store.Provider, slo store.ModelSLO) []store.Provider {
+	if slo.TTFTMS <= 0 && slo.LatencyMS <= 0 || len(list) < 2 {
+		return list
+	}
+	out := make([]store.Provider, 0, len(list))
+	var missed []store.Provider
+	for _, p := range list {
+		if r.sloMiss(p.ID, slo) == "" {
+			out = append(out, p)
+		} else {
+			missed = append(missed, p)
+		}
+	}
+	return append(out, missed...)
+}
+
+// SLOStatus reports providerID's rolling latency against each model in slos
+// that it has served.
+func (r *Router) SLOStatus(providerID string, slos []store.ModelSLO) []store.ModelSLOStatus {
+	var out []store.ModelSLOStatus
+	for _, slo := range slos {
+		if slo.TTFTMS <= 0 && slo.LatencyMS <= 0 {
+			continue
+		}
+		key := sloKey(providerID, slo.Model)
+		n := r.ModelLatency.Len(key)
+		if n == 0 {
+			continue
+		}
+		out = append(out, store.ModelSLOStatus{
+			Model:        slo.Model,
+			TTFTSLOMS:    slo.TTFTMS,
+			LatencySLOMS: slo.LatencyMS,
+			AvgTTFTMS:    r.ModelTTFT.Average(key).Milliseconds(),
+			AvgLatencyMS: r.ModelLatency.Average(key).Milliseconds(),
+			Samples:      n,
+			Compliant:    r.sloMiss(providerID, slo) == "",
+		})
+	}
+	return out
+}
+
+// attemptTimeout cancels a provider attempt that outlived its model's
+// timeout_ms. It unwraps to context.DeadlineExceeded so it is classified as
+// a timeout.
+type attemptTimeout struct{ after time.Duration }
+
+func (e attemptTimeout) Error() string {
+	return fmt.Sprintf("no response within the model's %s timeout", e.after)
+}
+
+func (e attemptTimeout) Unwrap() error { return context.DeadlineExceeded }
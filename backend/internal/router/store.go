@@ -0,0 +1,20 @@
+package router
+
+import (
+	"context"
+
+	"routerx/internal/store"
+)
+
+// Store is the data access routing needs. *store.Store implements it;
+// storemock.Store stands in for it in unit tests.
+type Store interface {
+	GetActiveExperiment(ctx context.Context, tenantID string, model string) (*store.Experiment, error)
+	GetEnabledProvidersByType(ctx context.Context, providerType string) ([]store.Provider, error)
+	GetModelProvider(ctx context.Context, model string) (string, bool, error)
+	GetModelProviderChain(ctx context.Context, model string) ([]store.Provider, error)
+	GetModelSLO(ctx context.Context, model string) (store.ModelSLO, error)
+	GetProviderByID(ctx context.Context, id string) (*store.Provider, error)
+	InsertProviderEvent(ctx context.Context, e store.ProviderEvent) error
+	ListRoutingRulesByTenant(ctx context.Context, tenantID string) ([]store.RoutingRule, error)
+}
@@ -0,0 +1,116 @@
+package routingconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"routerx/internal/store"
+)
+
+// Bootstrap is the PROVIDERS_CONFIG document applied at startup: a routing
+// document plus, since routing documents never carry keys, the environment
+// variable holding each provider's API key.
+type Bootstrap struct {
+	Document `yaml:",inline"`
+	// APIKeyEnv maps a provider id to the variable holding its key.
+	APIKeyEnv map[string]string `yaml:"api_key_env,omitempty"`
+}
+
+// KeyStore is a Store that can also set provider keys; *store.Store
+// implements it.
+type KeyStore interface {
+	Store
+	GetProviderByID(ctx context.Context, id string) (*store.Provider, error)
+	UpdateProviderAPIKey(ctx context.Context, id, apiKey string) error
+}
+
+// LoadBootstrap reads a bootstrap document from src, which is either the
+// document itself (YAML or JSON spanning several lines, or a JSON object) or
+// the path of a file holding it.
+func LoadBootstrap(src string) (Bootstrap, error) {
+	src = strings.TrimSpace(src)
+	if !strings.Contains(src, "\n") && !strings.HasPrefix(src, "{") {
+		b, err := os.ReadFile(src)
+		if err != nil {
+			return Bootstrap{}, err
+		}
+		src = string(b)
+	}
+	var b Bootstrap
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	dec.KnownFields(true)
+	if err := dec.Decode(&b); err != nil {
+		if errors.Is(err, io.EOF) {
+			return b, errors.New("empty document")
+		}
+		return b, err
+	}
+	if err := b.Validate(); err != nil {
+		return b, err
+	}
+	return b, nil
+}
+
+// Validate checks the document and that every key belongs to a provider it
+// defines.
+func (b Bootstrap) Validate() error {
+	if err := b.Document.Validate(); err != nil {
+		return err
+	}
+	defined := map[string]bool{}
+	for _, p := range b.Providers {
+		defined[p.ID] = true
+	}
+	for id, env := range b.APIKeyEnv {
+		if !defined[id] {
+			return fmt.Errorf("api_key_env: provider %s is not defined in the document", id)
+		}
+		if env == "" {
+			return fmt.Errorf("api_key_env: provider %s: variable name required", id)
+		}
+	}
+	return nil
+}
+
+// ApplyBootstrap applies b without pruning, then sets each listed provider's
+// key from getenv where it differs from the stored one, and returns the
+// changes and the providers whose key was set. Like Apply, it changes
+// nothing when the database already matches, so every replica can run it
+// on every start.
+func ApplyBootstrap(ctx context.Context, st KeyStore, b Bootstrap, getenv func(string) string) (Changes, []string, error) {
+	keys := map[string]string{}
+	for id, env := range b.APIKeyEnv {
+		key := getenv(env)
+		if key == "" {
+			return Changes{}, nil, fmt.Errorf("provider %s: %s is not set", id, env)
+		}
+		keys[id] = key
+	}
+	changes, err := Apply(ctx, st, b.Document, false, false)
+	if err != nil {
+		return changes, nil, err
+	}
+	var rotated []string
+	for id, key := range keys {
+		p, err := st.GetProviderByID(ctx, id)
+		if err != nil {
+			return changes, rotated, fmt.Errorf("provider %s: %w", id, err)
+		}
+		if p.APIKey == key {
+			continue
+		}
+		if err := st.UpdateProviderAPIKey(ctx, id, key); err != nil {
+			return changes, rotated, fmt.Errorf("provider %s: %w", id, err)
+		}
+		rotated = append(rotated, id)
+	}
+	sort.Strings(rotated)
+	return changes, rotated, nil
+}
@@ -0,0 +1,468 @@
+// Package routingconfig exports the routing setup as a YAML document and
+// applies such documents back, so routing can be kept in version control and
+// promoted between environments.
+//
+// A document holds providers (never their keys), the model catalog with each
+// model's provider list, pricing and routing rules. Applying is idempotent:
+// a document that matches the database changes nothing.
+package routingconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"routerx/internal/router"
+	"routerx/internal/store"
+)
+
+// Version is the document format version.
+const Version = 1
+
+type Document struct {
+	Version   int        `yaml:"version"`
+	Providers []Provider `yaml:"providers"`
+	Models    []Model    `yaml:"models"`
+	Pricing   []Price    `yaml:"pricing"`
+	Rules     []Rule     `yaml:"rules"`
+}
+
+// Provider is a provider without its API key. Keys stay in the database and
+// are set through the provider key endpoints.
+type Provider struct {
+	ID             string `yaml:"id"`
+	Name           string `yaml:"name"`
+	Type           string `yaml:"type"`
+	BaseURL        string `yaml:"base_url,omitempty"`
+	DefaultModel   string `yaml:"default_model,omitempty"`
+	Region         string `yaml:"region,omitempty"`
+	RPMLimit       int    `yaml:"rpm_limit,omitempty"`
+	TPMLimit       int    `yaml:"tpm_limit,omitempty"`
+	SupportsText   bool   `yaml:"supports_text"`
+	SupportsVision bool   `yaml:"supports_vision"`
+	Disabled       bool   `yaml:"disabled,omitempty"`
+	// Circuit overrides the circuit-breaker defaults; omitted fields keep them.
+	Circuit *Circuit `yaml:"circuit,omitempty"`
+}
+
+// Circuit mirrors store.CircuitSettings.
+type Circuit struct {
+	WindowSize       int     `yaml:"window_size,omitempty"`
+	FailureThreshold float64 `yaml:"failure_threshold,omitempty"`
+	CooldownSeconds  int     `yaml:"cooldown_seconds,omitempty"`
+	MinSamples       int     `yaml:"min_samples,omitempty"`
+
+	HalfOpenRequests    int `yaml:"half_open_requests,omitempty"`
+	CloseAfterSuccesses int `yaml:"close_after_successes,omitempty"`
+}
+
+// Model is a catalog entry. Providers, when set, is the model's ordered
+// provider list; otherwise requests auto-route across ProviderType.
+type Model struct {
+	Model        string          `yaml:"model"`
+	ProviderType string          `yaml:"provider_type"`
+	Providers    []ModelProvider `yaml:"providers,omitempty"`
+
+	ContextLength   int  `yaml:"context_length,omitempty"`
+	MaxOutputTokens int  `yaml:"max_output_tokens,omitempty"`
+	SupportsVision  bool `yaml:"supports_vision,omitempty"`
+	SupportsTools   bool `yaml:"supports_tools,omitempty"`
+
+	// Latency targets in milliseconds; see store.ModelSLO.
+	TTFTSLOMS    int `yaml:"ttft_slo_ms,omitempty"`
+	LatencySLOMS int `yaml:"latency_slo_ms,omitempty"`
+	TimeoutMS    int `yaml:"timeout_ms,omitempty"`
+
+	// Daily free allowance per tenant; see store.FreeAllowance.
+	FreeRequestsPerDay int `yaml:"free_requests_per_day,omitempty"`
+	FreeTokensPerDay   int `yaml:"free_tokens_per_day,omitempty"`
+}
+
+type ModelProvider struct {
+	ID       string `yaml:"id"`
+	Disabled bool   `yaml:"disabled,omitempty"`
+}
+
+type Price struct {
+	Model         string  `yaml:"model"`
+	PricePer1KUSD float64 `yaml:"price_per_1k_usd"`
+}
+
+type Rule struct {
+	ID               string            `yaml:"id"`
+	TenantID         string            `yaml:"tenant_id"`
+	Priority         int               `yaml:"priority,omitempty"`
+	Capability       string            `yaml:"capability,omitempty"`
+	Model            string            `yaml:"model,omitempty"`
+	ModelPattern     string            `yaml:"model_pattern,omitempty"`
+	MinContextTokens int               `yaml:"min_context_tokens,omitempty"`
+	RequiresTools    bool              `yaml:"requires_tools,omitempty"`
+	Tags             map[string]string `yaml:"tags,omitempty"`
+	Providers        []string          `yaml:"providers"`
+	Disabled         bool              `yaml:"disabled,omitempty"`
+}
+
+// Change lists the ids or models a section would create, update or delete.
+type Change struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Deleted []string `json:"deleted"`
+}
+
+// Changes is the result of comparing a document with the database.
+type Changes struct {
+	Providers Change `json:"providers"`
+	Models    Change `json:"models"`
+	Pricing   Change `json:"pricing"`
+	Rules     Change `json:"rules"`
+}
+
+// Empty reports whether applying would change nothing.
+func (c Changes) Empty() bool {
+	for _, ch := range []Change{c.Providers, c.Models, c.Pricing, c.Rules} {
+		if len(ch.Created)+len(ch.Updated)+len(ch.Deleted) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Store reads and writes the routing setup; *store.Store implements it.
+type Store interface {
+	GetRoutingConfig(ctx context.Context) (store.RoutingConfig, error)
+	ApplyRoutingConfig(ctx context.Context, c store.RoutingConfig, prune bool) error
+}
+
+// Export reads the current routing setup.
+func Export(ctx context.Context, st Store) (Document, error) {
+	c, err := st.GetRoutingConfig(ctx)
+	if err != nil {
+		return Document{}, err
+	}
+	return fromStore(c), nil
+}
+
+// Apply validates d, compares it with the database and, unless dryRun is set
+// or nothing differs, writes it in one transaction. With prune, models
+// missing from d are archived and pricing and rules missing from d are
+// deleted. Providers are never removed.
+func Apply(ctx context.Context, st Store, d Document, prune, dryRun bool) (Changes, error) {
+	if err := d.Validate(); err != nil {
+		return Changes{}, err
+	}
+	current, err := Export(ctx, st)
+	if err != nil {
+		return Changes{}, err
+	}
+	changes := Plan(current, d, prune)
+	if dryRun || changes.Empty() {
+		return changes, nil
+	}
+	return changes, st.ApplyRoutingConfig(ctx, d.toStore(), prune)
+}
+
+// Decode reads a YAML (or JSON) document. Unknown fields are rejected so a
+// misspelt key does not silently drop a setting.
+func Decode(r io.Reader) (Document, error) {
+	var d Document
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&d); err != nil {
+		if errors.Is(err, io.EOF) {
+			return d, errors.New("empty document")
+		}
+		return d, err
+	}
+	return d, nil
+}
+
+// Encode writes d as YAML.
+func Encode(w io.Writer, d Document) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(d); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Validate checks d is self-consistent: ids are unique and every provider a
+// model or rule lists is defined in the document.
+func (d Document) Validate() error {
+	if d.Version != Version {
+		return fmt.Errorf("unsupported version %d, want %d", d.Version, Version)
+	}
+	providers := map[string]bool{}
+	for _, p := range d.Providers {
+		switch {
+		case p.ID == "":
+			return errors.New("provider id required")
+		case providers[p.ID]:
+			return fmt.Errorf("provider %s defined twice", p.ID)
+		case p.Name == "" || p.Type == "":
+			return fmt.Errorf("provider %s: name and type required", p.ID)
+		case p.RPMLimit < 0 || p.TPMLimit < 0:
+			return fmt.Errorf("provider %s: rate limits must not be negative", p.ID)
+		}
+		if p.Circuit != nil {
+			if err := router.ValidateCircuitSettings(store.CircuitSettings(*p.Circuit)); err != nil {
+				return fmt.Errorf("provider %s: %w", p.ID, err)
+			}
+		}
+		providers[p.ID] = true
+	}
+	models := map[string]bool{}
+	for _, m := range d.Models {
+		switch {
+		case m.Model == "":
+			return errors.New("model name required")
+		case models[m.Model]:
+			return fmt.Errorf("model %s defined twice", m.Model)
+		case m.ProviderType == "":
+			return fmt.Errorf("model %s: provider_type required", m.Model)
+		case m.ContextLength < 0 || m.MaxOutputTokens < 0:
+			return fmt.Errorf("model %s: context_length and max_output_tokens must not be negative", m.Model)
+		case m.TTFTSLOMS < 0 || m.LatencySLOMS < 0 || m.TimeoutMS < 0:
+			return fmt.Errorf("model %s: ttft_slo_ms, latency_slo_ms and timeout_ms must not be negative", m.Model)
+		case m.FreeRequestsPerDay < 0 || m.FreeTokensPerDay < 0:
+			return fmt.Errorf("model %s: free_requests_per_day and free_tokens_per_day must not be negative", m.Model)
+		}
+		models[m.Model] = true
+		if err := checkProviderList("model "+m.Model, modelProviderIDs(m), providers); err != nil {
+			return err
+		}
+	}
+	prices := map[string]bool{}
+	for _, p := range d.Pricing {
+		switch {
+		case p.Model == "":
+			return errors.New("pricing model required")
+		case prices[p.Model]:
+			return fmt.Errorf("pricing for %s defined twice", p.Model)
+		case p.PricePer1KUSD < 0:
+			return fmt.Errorf("pricing for %s must not be negative", p.Model)
+		}
+		prices[p.Model] = true
+	}
+	rules := map[string]bool{}
+	for _, r := range d.Rules {
+		switch {
+		case r.ID == "":
+			return errors.New("rule id required")
+		case rules[r.ID]:
+			return fmt.Errorf("rule %s defined twice", r.ID)
+		case r.TenantID == "":
+			return fmt.Errorf("rule %s: tenant_id required", r.ID)
+		case len(r.Providers) == 0:
+			return fmt.Errorf("rule %s: providers required", r.ID)
+		case r.Capability != "" && r.Capability != "text" && r.Capability != "vision":
+			return fmt.Errorf("rule %s: capability must be text, vision or empty", r.ID)
+		case r.MinContextTokens < 0:
+			return fmt.Errorf("rule %s: min_context_tokens must not be negative", r.ID)
+		}
+		rules[r.ID] = true
+		if err := checkProviderList("rule "+r.ID, r.Providers, providers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkProviderList(owner string, ids []string, defined map[string]bool) error {
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("%s: provider %s listed twice", owner, id)
+		}
+		seen[id] = true
+		if !defined[id] {
+			return fmt.Errorf("%s: provider %s is not defined in the document", owner, id)
+		}
+	}
+	return nil
+}
+
+func modelProviderIDs(m Model) []string {
+	ids := make([]string, 0, len(m.Providers))
+	for _, p := range m.Providers {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// Plan compares current with desired. Models compare with their provider
+// lists, so reordering a list counts as an update.
+func Plan(current, desired Document, prune bool) Changes {
+	var c Changes
+	cur, want := map[string]interface{}{}, map[string]interface{}{}
+	for _, p := range current.Providers {
+		cur[p.ID] = p
+	}
+	for _, p := range desired.Providers {
+		want[p.ID] = p
+	}
+	// Providers are never pruned: they hold keys and request history.
+	c.Providers = diff(cur, want, false)
+
+	cur, want = map[string]interface{}{}, map[string]interface{}{}
+	for _, m := range current.Models {
+		cur[m.Model] = m
+	}
+	for _, m := range desired.Models {
+		want[m.Model] = m
+	}
+	c.Models = diff(cur, want, prune)
+
+	cur, want = map[string]interface{}{}, map[string]interface{}{}
+	for _, p := range current.Pricing {
+		cur[p.Model] = p
+	}
+	for _, p := range desired.Pricing {
+		want[p.Model] = p
+	}
+	c.Pricing = diff(cur, want, prune)
+
+	cur, want = map[string]interface{}{}, map[string]interface{}{}
+	for _, r := range current.Rules {
+		cur[r.ID] = r
+	}
+	for _, r := range desired.Rules {
+		want[r.ID] = r
+	}
+	c.Rules = diff(cur, want, prune)
+	return c
+}
+
+func diff(cur, want map[string]interface{}, prune bool) Change {
+	ch := Change{Created: []string{}, Updated: []string{}, Deleted: []string{}}
+	for k, v := range want {
+		old, ok := cur[k]
+		switch {
+		case !ok:
+			ch.Created = append(ch.Created, k)
+		case !reflect.DeepEqual(old, v):
+			ch.Updated = append(ch.Updated, k)
+		}
+	}
+	if prune {
+		for k := range cur {
+			if _, ok := want[k]; !ok {
+				ch.Deleted = append(ch.Deleted, k)
+			}
+		}
+	}
+	sort.Strings(ch.Created)
+	sort.Strings(ch.Updated)
+	sort.Strings(ch.Deleted)
+	return ch
+}
+
+func fromStore(c store.RoutingConfig) Document {
+	d := Document{Version: Version, Providers: []Provider{}, Models: []Model{}, Pricing: []Price{}, Rules: []Rule{}}
+	for _, p := range c.Providers {
+		d.Providers = append(d.Providers, Provider{
+			ID:             p.ID,
+			Name:           p.Name,
+			Type:           p.Type,
+			BaseURL:        p.BaseURL,
+			DefaultModel:   p.DefaultModel,
+			Region:         p.Region,
+			RPMLimit:       p.RPMLimit,
+			TPMLimit:       p.TPMLimit,
+			SupportsText:   p.SupportsText,
+			SupportsVision: p.SupportsVision,
+			Disabled:       !p.Enabled,
+		})
+		if p.Circuit != (store.CircuitSettings{}) {
+			c := Circuit(p.Circuit)
+			d.Providers[len(d.Providers)-1].Circuit = &c
+		}
+	}
+	lists := map[string][]ModelProvider{}
+	for _, e := range c.ModelProviders {
+		lists[e.Model] = append(lists[e.Model], ModelProvider{ID: e.ProviderID, Disabled: !e.Enabled})
+	}
+	for _, m := range c.Catalog {
+		d.Models = append(d.Models, Model{Model: m.Model, ProviderType: m.ProviderType, Providers: lists[m.Model],
+			ContextLength: m.ContextLength, MaxOutputTokens: m.MaxOutputTokens, SupportsVision: m.SupportsVision, SupportsTools: m.SupportsTools,
+			TTFTSLOMS: m.TTFTSLOMS, LatencySLOMS: m.LatencySLOMS, TimeoutMS: m.TimeoutMS,
+			FreeRequestsPerDay: m.FreeRequestsPerDay, FreeTokensPerDay: m.FreeTokensPerDay})
+	}
+	for _, p := range c.Pricing {
+		d.Pricing = append(d.Pricing, Price{Model: p.Model, PricePer1KUSD: p.PricePer1KUSD})
+	}
+	for _, r := range c.Rules {
+		rule := Rule{
+			ID:               r.ID,
+			TenantID:         r.TenantID,
+			Priority:         r.Priority,
+			Capability:       r.Capability,
+			Model:            r.Model,
+			ModelPattern:     r.ModelPattern,
+			MinContextTokens: r.MinContextTokens,
+			RequiresTools:    r.RequiresTools,
+			Providers:        r.ProviderIDs,
+			Disabled:         !r.Enabled,
+		}
+		if len(r.Tags) > 0 {
+			rule.Tags = r.Tags
+		}
+		d.Rules = append(d.Rules, rule)
+	}
+	return d
+}
+
+func (d Document) toStore() store.RoutingConfig {
+	var c store.RoutingConfig
+	for _, p := range d.Providers {
+		c.Providers = append(c.Providers, store.Provider{
+			ID:             p.ID,
+			Name:           p.Name,
+			Type:           p.Type,
+			BaseURL:        p.BaseURL,
+			DefaultModel:   p.DefaultModel,
+			Region:         p.Region,
+			RPMLimit:       p.RPMLimit,
+			TPMLimit:       p.TPMLimit,
+			SupportsText:   p.SupportsText,
+			SupportsVision: p.SupportsVision,
+			Enabled:        !p.Disabled,
+		})
+		if p.Circuit != nil {
+			c.Providers[len(c.Providers)-1].Circuit = store.CircuitSettings(*p.Circuit)
+		}
+	}
+	for _, m := range d.Models {
+		c.Catalog = append(c.Catalog, store.ModelCatalog{Model: m.Model, ProviderType: m.ProviderType,
+			ContextLength: m.ContextLength, MaxOutputTokens: m.MaxOutputTokens, SupportsVision: m.SupportsVision, SupportsTools: m.SupportsTools,
+			TTFTSLOMS: m.TTFTSLOMS, LatencySLOMS: m.LatencySLOMS, TimeoutMS: m.TimeoutMS,
+			FreeRequestsPerDay: m.FreeRequestsPerDay, FreeTokensPerDay: m.FreeTokensPerDay})
+		for _, p := range m.Providers {
+			c.ModelProviders = append(c.ModelProviders, store.ModelProviderEntry{Model: m.Model, ProviderID: p.ID, Enabled: !p.Disabled})
+		}
+	}
+	for _, p := range d.Pricing {
+		c.Pricing = append(c.Pricing, store.ModelPricing{Model: p.Model, PricePer1KUSD: p.PricePer1KUSD})
+	}
+	for _, r := range d.Rules {
+		c.Rules = append(c.Rules, store.RoutingRule{
+			ID:               r.ID,
+			TenantID:         r.TenantID,
+			Capability:       r.Capability,
+			ProviderIDs:      r.Providers,
+			Model:            r.Model,
+			Priority:         r.Priority,
+			ModelPattern:     r.ModelPattern,
+			MinContextTokens: r.MinContextTokens,
+			RequiresTools:    r.RequiresTools,
+			Tags:             r.Tags,
+			Enabled:          !r.Disabled,
+		})
+	}
+	return c
+}
@@ -0,0 +1,128 @@
+// Package secrets encrypts credentials stored in the database with AES-256-GCM.
+//
+// Sealed values look like "enc:v1:<key id>:<base64 nonce+ciphertext>". Values
+// without the prefix are treated as legacy plaintext so existing rows keep
+// working until they are re-encrypted.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const prefix = "enc:v1:"
+
+// Keyring holds the active encryption key plus retired keys that are still
+// accepted for decryption during key rotation.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyring builds a keyring from base64-encoded 32-byte keys. primaryID must
+// be present in keys.
+func NewKeyring(primaryID string, keys map[string]string) (*Keyring, error) {
+	k := &Keyring{primary: primaryID, aeads: map[string]cipher.AEAD{}}
+	for id, encoded := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("key %s: must be 32 bytes, got %d", id, len(raw))
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.aeads[id] = aead
+	}
+	if _, ok := k.aeads[primaryID]; !ok {
+		return nil, fmt.Errorf("primary key %q not configured", primaryID)
+	}
+	return k, nil
+}
+
+// ParseKeyList parses "id:base64,id:base64" as used for retired keys.
+func ParseKeyList(v string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, key, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry for %q", id)
+		}
+		out[id] = key
+	}
+	return out, nil
+}
+
+// IsSealed reports whether v was produced by Seal.
+func IsSealed(v string) bool {
+	return strings.HasPrefix(v, prefix)
+}
+
+// Seal encrypts plaintext with the primary key. aad binds the ciphertext to
+// its owner (e.g. a provider ID) so it cannot be copied to another row.
+func (k *Keyring) Seal(plaintext, aad string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return prefix + k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a sealed value. Legacy plaintext is returned unchanged.
+func (k *Keyring) Open(v, aad string) (string, error) {
+	if !IsSealed(v) {
+		return v, nil
+	}
+	id, data, ok := strings.Cut(strings.TrimPrefix(v, prefix), ":")
+	if !ok {
+		return "", errors.New("malformed sealed value")
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return "", fmt.Errorf("unknown encryption key %q", id)
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < aead.NonceSize() {
+		return "", errors.New("malformed sealed value")
+	}
+	plain, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", errors.New("failed to decrypt sealed value")
+	}
+	return string(plain), nil
+}
+
+// NeedsRotation reports whether v is plaintext or sealed with a retired key.
+func (k *Keyring) NeedsRotation(v string) bool {
+	if v == "" {
+		return false
+	}
+	return !strings.HasPrefix(v, prefix+k.primary+":")
+}
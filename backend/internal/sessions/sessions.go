@@ -0,0 +1,210 @@
+// Package sessions records the admin and tenant sessions the gateway issues
+// in Redis, keyed by their token's jti, so operators and tenants can see who
+// is logged in and revoke a session before its token expires.
+package sessions
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/ksuid"
+)
+
+const (
+	KindAdmin  = "admin"
+	KindTenant = "tenant"
+
+	// indexKey scores every live session id by its expiry; each tenant has
+	// its own index too so /user/sessions does not scan every session.
+	indexKey          = "sessions"
+	tenantIndexPrefix = "sessions:tenant:"
+	sessionPrefix     = "session:"
+)
+
+// ErrNotFound is returned for a session that expired, was revoked or never
+// existed.
+var ErrNotFound = errors.New("session not found")
+
+// touchScript records a request on a live session. It returns 0 when the
+// session no longer exists, i.e. it was revoked or has expired.
+// KEYS: session hash. ARGV: now (Unix seconds), client IP.
+var touchScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then return 0 end
+redis.call('HSET', KEYS[1], 'last_seen', ARGV[1], 'last_ip', ARGV[2])
+return 1
+`)
+
+// Session is one issued login.
+type Session struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Username  string    `json:"username"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	LastIP    string    `json:"last_ip"`
+	// TwoFactorPending marks an enrollment-only session.
+	TwoFactorPending bool `json:"2fa_pending,omitempty"`
+	// Current marks the session the listing was requested with.
+	Current bool `json:"current,omitempty"`
+}
+
+// Tracker stores sessions in Redis.
+type Tracker struct {
+	Redis redis.UniversalClient
+}
+
+func New(rdb redis.UniversalClient) *Tracker {
+	return &Tracker{Redis: rdb}
+}
+
+// NewID returns a fresh session id to use as a token's jti.
+func NewID() string {
+	return "sess_" + ksuid.New().String()
+}
+
+func sessionKey(id string) string {
+	return sessionPrefix + id
+}
+
+func tenantIndex(tenantID string) string {
+	return tenantIndexPrefix + tenantID
+}
+
+// Create records s, which lapses on its own at s.ExpiresAt.
+func (t *Tracker) Create(ctx context.Context, s Session) error {
+	key := sessionKey(s.ID)
+	pending := "0"
+	if s.TwoFactorPending {
+		pending = "1"
+	}
+	pipe := t.Redis.TxPipeline()
+	pipe.HSet(ctx, key,
+		"kind", s.Kind,
+		"username", s.Username,
+		"tenant_id", s.TenantID,
+		"ip", s.IP,
+		"user_agent", s.UserAgent,
+		"issued_at", s.IssuedAt.Unix(),
+		"expires_at", s.ExpiresAt.Unix(),
+		"last_seen", s.IssuedAt.Unix(),
+		"last_ip", s.IP,
+		"2fa_pending", pending)
+	pipe.ExpireAt(ctx, key, s.ExpiresAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	// The indexes are separate writes: in Redis Cluster they may live on
+	// other nodes than the session.
+	score := redis.Z{Score: float64(s.ExpiresAt.Unix()), Member: s.ID}
+	if err := t.Redis.ZAdd(ctx, indexKey, score).Err(); err != nil {
+		return err
+	}
+	if s.TenantID != "" {
+		return t.Redis.ZAdd(ctx, tenantIndex(s.TenantID), score).Err()
+	}
+	return nil
+}
+
+// Touch records a request from ip on session id and reports whether the
+// session is still live.
+func (t *Tracker) Touch(ctx context.Context, id, ip string) (bool, error) {
+	live, err := touchScript.Run(ctx, t.Redis, []string{sessionKey(id)}, time.Now().Unix(), ip).Int()
+	if err != nil {
+		return false, err
+	}
+	return live == 1, nil
+}
+
+// Get returns a live session.
+func (t *Tracker) Get(ctx context.Context, id string) (*Session, error) {
+	fields, err := t.Redis.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrNotFound
+	}
+	s := parseSession(id, fields)
+	return &s, nil
+}
+
+// List returns the live sessions, newest first: a tenant's when tenantID
+// is set, otherwise every admin and tenant session.
+func (t *Tracker) List(ctx context.Context, tenantID string) ([]Session, error) {
+	index := indexKey
+	if tenantID != "" {
+		index = tenantIndex(tenantID)
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	t.Redis.ZRemRangeByScore(ctx, index, "-inf", now)
+	ids, err := t.Redis.ZRangeByScore(ctx, index, &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, err
+	}
+	pipe := t.Redis.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, sessionKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]Session, 0, len(ids))
+	for i, id := range ids {
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			// Revoked by an instance that failed to update the index.
+			t.Redis.ZRem(ctx, index, id)
+			continue
+		}
+		out = append(out, parseSession(id, fields))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].IssuedAt.After(out[j].IssuedAt) })
+	return out, nil
+}
+
+// Revoke ends a session; its token is refused from the next request on.
+func (t *Tracker) Revoke(ctx context.Context, id string) error {
+	s, err := t.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := t.Redis.Del(ctx, sessionKey(id)).Err(); err != nil {
+		return err
+	}
+	t.Redis.ZRem(ctx, indexKey, id)
+	if s.TenantID != "" {
+		t.Redis.ZRem(ctx, tenantIndex(s.TenantID), id)
+	}
+	return nil
+}
+
+func parseSession(id string, f map[string]string) Session {
+	unix := func(k string) time.Time {
+		n, _ := strconv.ParseInt(f[k], 10, 64)
+		return time.Unix(n, 0).UTC()
+	}
+	return Session{
+		ID:               id,
+		Kind:             f["kind"],
+		Username:         f["username"],
+		TenantID:         f["tenant_id"],
+		IP:               f["ip"],
+		UserAgent:        f["user_agent"],
+		IssuedAt:         unix("issued_at"),
+		ExpiresAt:        unix("expires_at"),
+		LastSeen:         unix("last_seen"),
+		LastIP:           f["last_ip"],
+		TwoFactorPending: f["2fa_pending"] == "1",
+	}
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateAdminUser adds an admin console user; ErrUsernameTaken when the
+// username is in use.
+func (s *Store) CreateAdminUser(ctx context.Context, u AdminUser) error {
+	tag, err := s.DB.Exec(ctx, `INSERT INTO admin_users (id, username, password_hash) VALUES ($1,$2,$3) ON CONFLICT (username) DO NOTHING`, u.ID, u.Username, u.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+// SetPassword replaces the password hash of an admin (ActorAdmin) or tenant
+// user (ActorTenantUser) found by username.
+func (s *Store) SetPassword(ctx context.Context, actorType, username, hash string) error {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return err
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE `+table+` SET password_hash=$2 WHERE username=$1`, username, hash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// AdjustTenantBalance adds amount (negative to deduct) to a tenant's balance
+// and records it in the ledger in one transaction, returning the new
+// balance. Credits also count towards the lifetime top-up total.
+func (s *Store) AdjustTenantBalance(ctx context.Context, tenantID string, amount float64, description string) (float64, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	var balance float64
+	err = tx.QueryRow(ctx, `UPDATE tenants SET balance_usd = balance_usd + $2, total_topup_usd = total_topup_usd + GREATEST($2, 0) WHERE id=$1 RETURNING balance_usd`, tenantID, amount).Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO balance_transactions (tenant_id, type, amount_usd, balance_after, description) VALUES ($1,'adjustment',$2,$3,$4)`, tenantID, amount, balance, description); err != nil {
+		return 0, err
+	}
+	return balance, tx.Commit(ctx)
+}
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Alert metrics. Provider metrics are evaluated per provider name and tenant
+// spend per tenant; a rule's scope narrows evaluation to one provider or
+// tenant.
+const (
+	AlertProviderErrorRate = "provider_error_rate" // fraction of failed requests, 0-1
+	AlertCircuitOpens      = "circuit_opens"       // circuit_opened events in the window
+	AlertP95LatencyMS      = "p95_latency_ms"
+	AlertSpendPerHourUSD   = "spend_per_hour_usd" // estimated upstream cost, extrapolated to an hour
+)
+
+// Alert channels.
+const (
+	AlertChannelEmail     = "email"
+	AlertChannelSlack     = "slack"
+	AlertChannelPagerDuty = "pagerduty"
+)
+
+// Alert states.
+const (
+	AlertOK     = "ok"
+	AlertFiring = "firing"
+)
+
+// alertMinRequests keeps error rate and latency rules from firing on a
+// handful of requests.
+const alertMinRequests = 10
+
+func ValidAlertMetric(m string) bool {
+	switch m {
+	case AlertProviderErrorRate, AlertCircuitOpens, AlertP95LatencyMS, AlertSpendPerHourUSD:
+		return true
+	}
+	return false
+}
+
+func ValidAlertChannel(c string) bool {
+	switch c {
+	case AlertChannelEmail, AlertChannelSlack, AlertChannelPagerDuty:
+		return true
+	}
+	return false
+}
+
+// AlertRule fires when its metric exceeds Threshold for any subject over the
+// trailing window. Target is an email address, a Slack incoming-webhook URL or
+// a PagerDuty routing key depending on Channel.
+type AlertRule struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Metric          string     `json:"metric"`
+	Scope           string     `json:"scope"`
+	Threshold       float64    `json:"threshold"`
+	WindowMinutes   int        `json:"window_minutes"`
+	Channel         string     `json:"channel"`
+	Target          string     `json:"target"`
+	CooldownMinutes int        `json:"cooldown_minutes"`
+	Enabled         bool       `json:"enabled"`
+	State           string     `json:"state"`
+	LastNotifiedAt  *time.Time `json:"last_notified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type AlertEvent struct {
+	ID          int64     `json:"id"`
+	RuleID      string    `json:"rule_id"`
+	State       string    `json:"state"`
+	Value       float64   `json:"value"`
+	Message     string    `json:"message"`
+	NotifyError string    `json:"notify_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const alertRuleColumns = `id, name, metric, scope, threshold, window_minutes, channel, target, cooldown_minutes, enabled, state, last_notified_at, created_at, updated_at`
+
+func scanAlertRule(row pgx.Row) (AlertRule, error) {
+	var a AlertRule
+	err := row.Scan(&a.ID, &a.Name, &a.Metric, &a.Scope, &a.Threshold, &a.WindowMinutes, &a.Channel, &a.Target, &a.CooldownMinutes, &a.Enabled, &a.State, &a.LastNotifiedAt, &a.CreatedAt, &a.UpdatedAt)
+	return a, err
+}
+
+func (s *Store) queryAlertRules(ctx context.Context, query string, args ...interface{}) ([]AlertRule, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AlertRule
+	for rows.Next() {
+		a, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListAlertRules(ctx context.Context) ([]AlertRule, error) {
+	return s.queryAlertRules(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules ORDER BY created_at`)
+}
+
+func (s *Store) ListEnabledAlertRules(ctx context.Context) ([]AlertRule, error) {
+	return s.queryAlertRules(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE enabled=true ORDER BY created_at`)
+}
+
+func (s *Store) GetAlertRule(ctx context.Context, id string) (*AlertRule, error) {
+	a, err := scanAlertRule(s.DB.QueryRow(ctx, `SELECT `+alertRuleColumns+` FROM alert_rules WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *Store) CreateAlertRule(ctx context.Context, a AlertRule) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO alert_rules (id, name, metric, scope, threshold, window_minutes, channel, target, cooldown_minutes, enabled)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`,
+		a.ID, a.Name, a.Metric, a.Scope, a.Threshold, a.WindowMinutes, a.Channel, a.Target, a.CooldownMinutes, a.Enabled)
+	return err
+}
+
+// UpdateAlertRule replaces a rule's definition. Its firing state is kept.
+func (s *Store) UpdateAlertRule(ctx context.Context, a AlertRule) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE alert_rules SET name=$2, metric=$3, scope=$4, threshold=$5, window_minutes=$6, channel=$7, target=$8, cooldown_minutes=$9, enabled=$10, updated_at=NOW() WHERE id=$1`,
+		a.ID, a.Name, a.Metric, a.Scope, a.Threshold, a.WindowMinutes, a.Channel, a.Target, a.CooldownMinutes, a.Enabled)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Store) DeleteAlertRule(ctx context.Context, id string) error {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM alert_rules WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimAlertTransition moves a rule to state and stamps it notified at, but
+// only if it is still in the state the caller evaluated. It reports false when
+// another instance got there first, so each transition is notified once.
+func (s *Store) ClaimAlertTransition(ctx context.Context, rule AlertRule, state string, at time.Time) (bool, error) {
+	tag, err := s.DB.Exec(ctx, `UPDATE alert_rules SET state=$2, last_notified_at=$3 WHERE id=$1 AND state=$4 AND last_notified_at IS NOT DISTINCT FROM $5`,
+		rule.ID, state, at, rule.State, rule.LastNotifiedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (s *Store) InsertAlertEvent(ctx context.Context, e AlertEvent) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO alert_events (rule_id, state, value, message, notify_error) VALUES ($1,$2,$3,$4,$5)`,
+		e.RuleID, e.State, e.Value, e.Message, e.NotifyError)
+	return err
+}
+
+func (s *Store) ListAlertEvents(ctx context.Context, ruleID string, limit int) ([]AlertEvent, error) {
+	query := `SELECT id, rule_id, state, value, message, notify_error, created_at FROM alert_events`
+	args := []interface{}{}
+	if ruleID != "" {
+		args = append(args, ruleID)
+		query += fmt.Sprintf(" WHERE rule_id=$%d", len(args))
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AlertEvent
+	for rows.Next() {
+		var e AlertEvent
+		if err := rows.Scan(&e.ID, &e.RuleID, &e.State, &e.Value, &e.Message, &e.NotifyError, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// AlertMetricValues evaluates metric over the trailing window and returns one
+// value per subject (provider name, provider id for circuit opens, or tenant
+// id for spend). scope restricts the result to a single subject.
+func (s *Store) AlertMetricValues(ctx context.Context, metric, scope string, window time.Duration) (map[string]float64, error) {
+	since := time.Now().UTC().Add(-window)
+	var query string
+	args := []interface{}{since, scope}
+	switch metric {
+	case AlertProviderErrorRate:
+		query = `SELECT provider, COUNT(*) FILTER (WHERE status_code >= 400)::float8 / COUNT(*) FROM request_logs
+			WHERE created_at >= $1 AND ($2 = '' OR provider = $2) GROUP BY provider HAVING COUNT(*) >= $3`
+		args = append(args, alertMinRequests)
+	case AlertP95LatencyMS:
+		query = `SELECT provider, PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY latency_ms) FROM request_logs
+			WHERE created_at >= $1 AND ($2 = '' OR provider = $2) GROUP BY provider HAVING COUNT(*) >= $3`
+		args = append(args, alertMinRequests)
+	case AlertCircuitOpens:
+		query = `SELECT provider_id, COUNT(*)::float8 FROM provider_events
+			WHERE created_at >= $1 AND ($2 = '' OR provider_id = $2 OR provider_name = $2) AND event_type = $3 GROUP BY provider_id`
+		args = append(args, EventCircuitOpened)
+	case AlertSpendPerHourUSD:
+		query = `SELECT tenant_id, SUM(provider_cost_usd)::float8 * 60 / $3 FROM request_logs
+			WHERE created_at >= $1 AND ($2 = '' OR tenant_id = $2) GROUP BY tenant_id`
+		args = append(args, window.Minutes())
+	default:
+		return nil, fmt.Errorf("unknown alert metric %q", metric)
+	}
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]float64{}
+	for rows.Next() {
+		var subject string
+		var v float64
+		if err := rows.Scan(&subject, &v); err != nil {
+			return nil, err
+		}
+		out[subject] = v
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// ModelCost is spend on one model within an analytics window.
+type ModelCost struct {
+	Model    string  `json:"model"`
+	Requests int     `json:"requests"`
+	Tokens   int64   `json:"tokens"`
+	CostUSD  float64 `json:"cost_usd"`
+}
+
+// ProviderErrorRate is the share of failed attempts on a provider.
+type ProviderErrorRate struct {
+	Provider  string  `json:"provider"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// Percentiles holds p50/p95/p99 in milliseconds.
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+type TenantAnalytics struct {
+	From           time.Time           `json:"from"`
+	To             time.Time           `json:"to"`
+	Requests       int                 `json:"requests"`
+	Errors         int                 `json:"errors"`
+	FallbackRate   float64             `json:"fallback_rate"`
+	LatencyMS      Percentiles         `json:"latency_ms"`
+	TTFTMS         Percentiles         `json:"ttft_ms"`
+	TopModels      []ModelCost         `json:"top_models"`
+	ProviderErrors []ProviderErrorRate `json:"provider_errors"`
+}
+
+// GetTenantAnalytics summarizes a tenant's requests in [from, to). Rates are
+// fractions between 0 and 1; TTFT percentiles only count requests that
+// reported one.
+func (s *Store) GetTenantAnalytics(ctx context.Context, tenantID string, from, to time.Time, topN int) (*TenantAnalytics, error) {
+	a := &TenantAnalytics{From: from, To: to}
+	var fallbacks int
+	row := s.DB.QueryRow(ctx, `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status_code >= 400),
+		       COUNT(*) FILTER (WHERE fallback_used),
+		       COALESCE(PERCENTILE_CONT(0.50) WITHIN GROUP (ORDER BY latency_ms), 0),
+		       COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY latency_ms), 0),
+		       COALESCE(PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY latency_ms), 0),
+		       COALESCE(PERCENTILE_CONT(0.50) WITHIN GROUP (ORDER BY ttft_ms) FILTER (WHERE ttft_ms > 0), 0),
+		       COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY ttft_ms) FILTER (WHERE ttft_ms > 0), 0),
+		       COALESCE(PERCENTILE_CONT(0.99) WITHIN GROUP (ORDER BY ttft_ms) FILTER (WHERE ttft_ms > 0), 0)
+		FROM request_logs WHERE tenant_id=$1 AND created_at >= $2 AND created_at < $3
+	`, tenantID, from, to)
+	if err := row.Scan(&a.Requests, &a.Errors, &fallbacks,
+		&a.LatencyMS.P50, &a.LatencyMS.P95, &a.LatencyMS.P99,
+		&a.TTFTMS.P50, &a.TTFTMS.P95, &a.TTFTMS.P99); err != nil {
+		return nil, err
+	}
+	if a.Requests > 0 {
+		a.FallbackRate = float64(fallbacks) / float64(a.Requests)
+	}
+
+	rows, err := s.DB.Query(ctx, `
+		SELECT model, COUNT(*), COALESCE(SUM(tokens),0), COALESCE(SUM(cost_usd),0)
+		FROM request_logs WHERE tenant_id=$1 AND created_at >= $2 AND created_at < $3
+		GROUP BY model ORDER BY 4 DESC, 2 DESC LIMIT $4
+	`, tenantID, from, to, topN)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m ModelCost
+		if err := rows.Scan(&m.Model, &m.Requests, &m.Tokens, &m.CostUSD); err != nil {
+			return nil, err
+		}
+		a.TopModels = append(a.TopModels, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Errors are counted per provider attempt: a request that failed
+	// everywhere is logged without a provider, so its status alone would
+	// hide the failures. Requests logged without attempts, e.g. cache hits,
+	// count once for the provider that served them.
+	prow, err := s.DB.Query(ctx, `
+		SELECT provider, COUNT(*), COUNT(*) FILTER (WHERE failed) FROM (
+			SELECT a->>'provider' AS provider, COALESCE(a->>'error','') <> '' AS failed
+			FROM request_logs l CROSS JOIN LATERAL jsonb_array_elements(l.attempts) a
+			WHERE l.tenant_id=$1 AND l.created_at >= $2 AND l.created_at < $3
+			UNION ALL
+			SELECT provider, status_code >= 400
+			FROM request_logs WHERE tenant_id=$1 AND created_at >= $2 AND created_at < $3 AND provider <> '' AND attempts = '[]'::jsonb
+		) x WHERE provider <> ''
+		GROUP BY provider ORDER BY 2 DESC
+	`, tenantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer prow.Close()
+	for prow.Next() {
+		var p ProviderErrorRate
+		if err := prow.Scan(&p.Provider, &p.Requests, &p.Errors); err != nil {
+			return nil, err
+		}
+		if p.Requests > 0 {
+			p.ErrorRate = float64(p.Errors) / float64(p.Requests)
+		}
+		a.ProviderErrors = append(a.ProviderErrors, p)
+	}
+	if a.TopModels == nil {
+		a.TopModels = []ModelCost{}
+	}
+	if a.ProviderErrors == nil {
+		a.ProviderErrors = []ProviderErrorRate{}
+	}
+	return a, prow.Err()
+}
+
+// MarginLine compares estimated upstream cost, at list price, with the
+// amount billed for one provider or tenant.
+type MarginLine struct {
+	Key             string  `json:"key"`
+	Name            string  `json:"name"`
+	Requests        int     `json:"requests"`
+	Tokens          int64   `json:"tokens"`
+	ProviderCostUSD float64 `json:"provider_cost_usd"`
+	BilledUSD       float64 `json:"billed_usd"`
+	MarginUSD       float64 `json:"margin_usd"`
+	MarginPct       float64 `json:"margin_pct"`
+}
+
+type MarginReport struct {
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Total      MarginLine   `json:"total"`
+	ByProvider []MarginLine `json:"by_provider"`
+	ByTenant   []MarginLine `json:"by_tenant"`
+}
+
+// GetMarginReport aggregates upstream cost against billed amount per provider
+// and per tenant for successful requests in [from, to), least profitable first.
+func (s *Store) GetMarginReport(ctx context.Context, from, to time.Time) (*MarginReport, error) {
+	rep := &MarginReport{From: from, To: to, Total: MarginLine{Key: "total", Name: "Total"}}
+	var err error
+	rep.ByProvider, err = s.marginLines(ctx, `
+		SELECT provider, provider, COUNT(*), COALESCE(SUM(tokens),0), COALESCE(SUM(provider_cost_usd),0), COALESCE(SUM(billed_usd),0)
+		FROM request_logs WHERE status_code=200 AND created_at >= $1 AND created_at < $2
+		GROUP BY provider ORDER BY SUM(billed_usd) - SUM(provider_cost_usd)
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	rep.ByTenant, err = s.marginLines(ctx, `
+		SELECT l.tenant_id, COALESCE(t.name, l.tenant_id), COUNT(*), COALESCE(SUM(l.tokens),0), COALESCE(SUM(l.provider_cost_usd),0), COALESCE(SUM(l.billed_usd),0)
+		FROM request_logs l LEFT JOIN tenants t ON t.id = l.tenant_id
+		WHERE l.status_code=200 AND l.created_at >= $1 AND l.created_at < $2
+		GROUP BY l.tenant_id, t.name ORDER BY SUM(l.billed_usd) - SUM(l.provider_cost_usd)
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range rep.ByProvider {
+		rep.Total.Requests += l.Requests
+		rep.Total.Tokens += l.Tokens
+		rep.Total.ProviderCostUSD += l.ProviderCostUSD
+		rep.Total.BilledUSD += l.BilledUSD
+	}
+	rep.Total.setMargin()
+	return rep, nil
+}
+
+func (s *Store) marginLines(ctx context.Context, q string, args ...interface{}) ([]MarginLine, error) {
+	rows, err := s.DB.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []MarginLine{}
+	for rows.Next() {
+		var l MarginLine
+		if err := rows.Scan(&l.Key, &l.Name, &l.Requests, &l.Tokens, &l.ProviderCostUSD, &l.BilledUSD); err != nil {
+			return nil, err
+		}
+		l.setMargin()
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+func (l *MarginLine) setMargin() {
+	l.MarginUSD = l.BilledUSD - l.ProviderCostUSD
+	if l.BilledUSD > 0 {
+		l.MarginPct = l.MarginUSD / l.BilledUSD * 100
+	}
+}
+
+// AttemptFailure aggregates failed provider attempts sharing a provider and
+// error class. Requests counts the distinct requests they occurred in.
+type AttemptFailure struct {
+	Provider      string    `json:"provider"`
+	ErrorClass    string    `json:"error_class"`
+	Attempts      int       `json:"attempts"`
+	Requests      int       `json:"requests"`
+	AvgDurationMS float64   `json:"avg_duration_ms"`
+	LastSeen      time.Time `json:"last_seen"`
+	// SampleError is the most recent error message of the group.
+	SampleError string `json:"sample_error"`
+}
+
+// GetAttemptFailures groups the failed attempts recorded on request logs in
+// [from, to) by provider and error class, most frequent first. provider and
+// errorClass narrow the result when set.
+func (s *Store) GetAttemptFailures(ctx context.Context, from, to time.Time, provider, errorClass string) ([]AttemptFailure, error) {
+	rows, err := s.DB.Query(ctx, `
+		SELECT a->>'provider', COALESCE(a->>'error_class',''), COUNT(*), COUNT(DISTINCT l.id),
+		       COALESCE(AVG((a->>'duration_ms')::bigint),0), MAX(l.created_at),
+		       (ARRAY_AGG(a->>'error' ORDER BY l.created_at DESC))[1]
+		FROM request_logs l, jsonb_array_elements(l.attempts) a
+		WHERE (l.status_code <> 200 OR jsonb_array_length(l.attempts) > 1)
+		  AND l.created_at >= $1 AND l.created_at < $2
+		  AND COALESCE(a->>'error','') <> ''
+		  AND ($3 = '' OR a->>'provider' = $3) AND ($4 = '' OR a->>'error_class' = $4)
+		GROUP BY 1, 2 ORDER BY COUNT(*) DESC, 1, 2`, from, to, provider, errorClass)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AttemptFailure{}
+	for rows.Next() {
+		var f AttemptFailure
+		if err := rows.Scan(&f.Provider, &f.ErrorClass, &f.Attempts, &f.Requests, &f.AvgDurationMS, &f.LastSeen, &f.SampleError); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyColumns includes the key's usage over the last 30 days, summed from
+// the flushed daily counters.
+const apiKeyColumns = `key, tenant_id, COALESCE(name,''), COALESCE(allowed_models, ARRAY[]::text[]), created_at, COALESCE(signing_key_id,''), created_by, last_used_at, revoked_at, revoked_by, revoke_reason,
+	(SELECT COALESCE(SUM(u.requests), 0) FROM api_key_usage_daily u WHERE u.key=api_keys.key AND u.day > CURRENT_DATE - 30),
+	(SELECT COALESCE(SUM(u.tokens), 0) FROM api_key_usage_daily u WHERE u.key=api_keys.key AND u.day > CURRENT_DATE - 30)`
+
+func scanAPIKey(row pgx.Row) (APIKey, error) {
+	var k APIKey
+	err := row.Scan(&k.Key, &k.TenantID, &k.Name, &k.AllowedModels, &k.CreatedAt, &k.SigningKeyID, &k.CreatedBy, &k.LastUsedAt, &k.RevokedAt, &k.RevokedBy, &k.RevokeReason, &k.Requests30d, &k.Tokens30d)
+	return k, err
+}
+
+func (s *Store) queryAPIKeys(ctx context.Context, query string, args ...interface{}) ([]APIKey, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// APIKeyFilters narrows an admin key search. Revoked is "true", "false" or
+// empty for both.
+type APIKeyFilters struct {
+	Prefix        string
+	TenantID      string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Revoked       string
+	Limit         int
+}
+
+// SearchAPIKeys lists keys across all tenants, newest first.
+func (s *Store) SearchAPIKeys(ctx context.Context, f APIKeyFilters) ([]APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE 1=1`
+	args := []interface{}{}
+	if f.Prefix != "" {
+		// Escape LIKE wildcards so the prefix matches literally.
+		prefix := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Prefix)
+		args = append(args, prefix+"%")
+		query += fmt.Sprintf(" AND key LIKE $%d", len(args))
+	}
+	if f.TenantID != "" {
+		args = append(args, f.TenantID)
+		query += fmt.Sprintf(" AND tenant_id=$%d", len(args))
+	}
+	if !f.CreatedAfter.IsZero() {
+		args = append(args, f.CreatedAfter)
+		query += fmt.Sprintf(" AND created_at>=$%d", len(args))
+	}
+	if !f.CreatedBefore.IsZero() {
+		args = append(args, f.CreatedBefore)
+		query += fmt.Sprintf(" AND created_at<$%d", len(args))
+	}
+	switch f.Revoked {
+	case "true":
+		query += " AND revoked_at IS NOT NULL"
+	case "false":
+		query += " AND revoked_at IS NULL"
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+	return s.queryAPIKeys(ctx, query, args...)
+}
+
+// RevokeAPIKey disables a key without deleting it, so it stays visible for
+// incident review. Authentication rejects it from the next request on.
+func (s *Store) RevokeAPIKey(ctx context.Context, key, revokedBy, reason string) (*APIKey, error) {
+	k, err := scanAPIKey(s.DB.QueryRow(ctx, `UPDATE api_keys SET revoked_at=NOW(), revoked_by=$2, revoke_reason=$3
+		WHERE key=$1 AND revoked_at IS NULL RETURNING `+apiKeyColumns, key, revokedBy, reason))
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// APIKeyUsage is a batch of requests and tokens counted against a key on day.
+type APIKeyUsage struct {
+	Key      string
+	Day      time.Time
+	Requests int64
+	Tokens   int64
+	LastUsed time.Time
+}
+
+// AddAPIKeyUsage adds counted usage to the daily totals and advances each
+// key's last_used_at. Usage of keys deleted since it was counted is dropped.
+func (s *Store) AddAPIKeyUsage(ctx context.Context, usage []APIKeyUsage) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, u := range usage {
+		if _, err := tx.Exec(ctx, `INSERT INTO api_key_usage_daily (key, day, requests, tokens)
+			SELECT $1, $2, $3, $4 WHERE EXISTS (SELECT 1 FROM api_keys WHERE key=$1)
+			ON CONFLICT (key, day) DO UPDATE SET requests=api_key_usage_daily.requests+EXCLUDED.requests, tokens=api_key_usage_daily.tokens+EXCLUDED.tokens`,
+			u.Key, u.Day, u.Requests, u.Tokens); err != nil {
+			return err
+		}
+		if u.LastUsed.IsZero() {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `UPDATE api_keys SET last_used_at=GREATEST(COALESCE(last_used_at, $2), $2) WHERE key=$1`, u.Key, u.LastUsed); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ArchivedModel is a catalog model taken out of service. Its pricing,
+// provider list and the logs and usage that name it are kept.
+type ArchivedModel struct {
+	Model        string    `json:"model"`
+	ProviderType string    `json:"provider_type"`
+	ArchivedAt   time.Time `json:"archived_at"`
+	ArchivedBy   string    `json:"archived_by"`
+}
+
+// ArchiveProvider takes a provider out of routing and the default provider
+// listing without deleting it.
+func (s *Store) ArchiveProvider(ctx context.Context, id, actor string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET archived_at=NOW(), archived_by=$2 WHERE id=$1 AND archived_at IS NULL`, id, actor)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// RestoreProvider returns an archived provider to service.
+func (s *Store) RestoreProvider(ctx context.Context, id string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET archived_at=NULL, archived_by='' WHERE id=$1 AND archived_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ArchiveModel hides a catalog model from routing and /v1/models without
+// deleting it. Adding the model again restores it.
+func (s *Store) ArchiveModel(ctx context.Context, model, actor string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE model_catalog SET archived_at=NOW(), archived_by=$2 WHERE model=$1 AND archived_at IS NULL`, model, actor)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// RestoreModel returns an archived catalog model to service.
+func (s *Store) RestoreModel(ctx context.Context, model string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE model_catalog SET archived_at=NULL, archived_by='' WHERE model=$1 AND archived_at IS NOT NULL`, model)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ListArchivedModels returns archived catalog models, most recent first.
+func (s *Store) ListArchivedModels(ctx context.Context) ([]ArchivedModel, error) {
+	rows, err := s.DB.Query(ctx, `SELECT model, provider_type, archived_at, archived_by FROM model_catalog WHERE archived_at IS NOT NULL ORDER BY archived_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ArchivedModel
+	for rows.Next() {
+		var m ArchivedModel
+		if err := rows.Scan(&m.Model, &m.ProviderType, &m.ArchivedAt, &m.ArchivedBy); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audit actor types.
+const (
+	ActorAdmin      = "admin"
+	ActorTenantUser = "tenant_user"
+	// ActorCLI marks actions taken with "routerx admin" on the server host.
+	ActorCLI = "cli"
+)
+
+// AuditEntry records one mutating admin or tenant action. Before and After
+// are JSON snapshots of the target with secrets masked.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	ActorType  string          `json:"actor_type"`
+	Actor      string          `json:"actor"`
+	TenantID   string          `json:"tenant_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   string          `json:"target_id,omitempty"`
+	IP         string          `json:"ip"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	StatusCode int             `json:"status_code"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditFilters struct {
+	ActorType  string
+	Actor      string
+	TenantID   string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+func (s *Store) InsertAuditEntry(ctx context.Context, e AuditEntry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO audit_log (actor_type, actor, tenant_id, action, target_type, target_id, ip, before, after, status_code, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
+		e.ActorType, e.Actor, e.TenantID, e.Action, e.TargetType, e.TargetID, e.IP, nullJSON(e.Before), nullJSON(e.After), e.StatusCode, e.CreatedAt)
+	return err
+}
+
+func (s *Store) ListAuditLog(ctx context.Context, f AuditFilters) ([]AuditEntry, error) {
+	if f.Limit <= 0 || f.Limit > 1000 {
+		f.Limit = 200
+	}
+	where := "WHERE 1=1"
+	args := []interface{}{}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where += fmt.Sprintf(" AND "+cond, len(args))
+	}
+	if f.ActorType != "" {
+		add("actor_type=$%d", f.ActorType)
+	}
+	if f.Actor != "" {
+		add("actor=$%d", f.Actor)
+	}
+	if f.TenantID != "" {
+		add("tenant_id=$%d", f.TenantID)
+	}
+	if f.Action != "" {
+		add("action ILIKE $%d", "%"+f.Action+"%")
+	}
+	if f.TargetType != "" {
+		add("target_type=$%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id=$%d", f.TargetID)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < $%d", f.To)
+	}
+	args = append(args, f.Limit)
+	q := fmt.Sprintf(`SELECT id, actor_type, actor, tenant_id, action, target_type, target_id, ip, before, after, status_code, created_at
+		FROM audit_log %s ORDER BY created_at DESC, id DESC LIMIT $%d`, where, len(args))
+	rows, err := s.DB.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.ActorType, &e.Actor, &e.TenantID, &e.Action, &e.TargetType, &e.TargetID, &e.IP, &before, &after, &e.StatusCode, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// nullJSON maps an empty snapshot to SQL NULL.
+func nullJSON(b json.RawMessage) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
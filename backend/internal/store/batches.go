@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Batch states.
+const (
+	BatchInProgress = "in_progress"
+	BatchCompleted  = "completed"
+	BatchCancelled  = "cancelled"
+)
+
+// Batch item states. Running items hold a lease in next_attempt_at; an item
+// whose lease lapsed (the worker died) is claimed again.
+const (
+	BatchItemPending   = "pending"
+	BatchItemRunning   = "running"
+	BatchItemSucceeded = "succeeded"
+	BatchItemFailed    = "failed"
+	BatchItemCancelled = "cancelled"
+)
+
+type Batch struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	// APIKey is the key the batch was created with. Its items run as that
+	// key, so they stop once it is revoked.
+	APIKey         string            `json:"-"`
+	Status         string            `json:"status"`
+	RequestCount   int               `json:"request_count"`
+	CompletedCount int               `json:"completed_count"`
+	FailedCount    int               `json:"failed_count"`
+	Metadata       map[string]string `json:"metadata"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	CompletedAt    *time.Time        `json:"completed_at"`
+}
+
+type BatchItem struct {
+	ID          int64           `json:"-"`
+	BatchID     string          `json:"-"`
+	TenantID    string          `json:"-"`
+	APIKey      string          `json:"-"`
+	Index       int             `json:"index"`
+	CustomID    string          `json:"custom_id"`
+	Request     json.RawMessage `json:"-"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	StatusCode  int             `json:"status_code"`
+	Response    json.RawMessage `json:"response,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+const batchColumns = `id, tenant_id, status, request_count, completed_count, failed_count, metadata, created_at, updated_at, completed_at`
+
+func scanBatch(row pgx.Row) (Batch, error) {
+	var b Batch
+	var meta []byte
+	err := row.Scan(&b.ID, &b.TenantID, &b.Status, &b.RequestCount, &b.CompletedCount, &b.FailedCount, &meta, &b.CreatedAt, &b.UpdatedAt, &b.CompletedAt)
+	if err == nil {
+		_ = json.Unmarshal(meta, &b.Metadata)
+	}
+	return b, err
+}
+
+// CreateBatch stores a batch and its items in one transaction.
+func (s *Store) CreateBatch(ctx context.Context, b Batch, items []BatchItem) error {
+	meta, err := json.Marshal(b.Metadata)
+	if err != nil {
+		return err
+	}
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `INSERT INTO batches (id, tenant_id, api_key, status, request_count, metadata) VALUES ($1,$2,$3,$4,$5,$6)`,
+		b.ID, b.TenantID, b.APIKey, BatchInProgress, len(items), meta); err != nil {
+		return err
+	}
+	rows := make([][]interface{}, len(items))
+	for i, it := range items {
+		rows[i] = []interface{}{b.ID, b.TenantID, i, it.CustomID, []byte(it.Request)}
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"batch_items"}, []string{"batch_id", "tenant_id", "idx", "custom_id", "request"}, pgx.CopyFromRows(rows)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) GetBatch(ctx context.Context, tenantID, id string) (*Batch, error) {
+	b, err := scanBatch(s.DB.QueryRow(ctx, `SELECT `+batchColumns+` FROM batches WHERE id=$1 AND tenant_id=$2`, id, tenantID))
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (s *Store) ListBatches(ctx context.Context, tenantID string, limit int) ([]Batch, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	rows, err := s.DB.Query(ctx, `SELECT `+batchColumns+` FROM batches WHERE tenant_id=$1 ORDER BY created_at DESC LIMIT $2`, tenantID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Batch
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// CancelBatch stops an in-progress batch. Items already finished keep their
+// results; items not yet started are cancelled.
+func (s *Store) CancelBatch(ctx context.Context, tenantID, id string) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	tag, err := tx.Exec(ctx, `UPDATE batches SET status=$3, updated_at=NOW(), completed_at=NOW() WHERE id=$1 AND tenant_id=$2 AND status=$4`,
+		id, tenantID, BatchCancelled, BatchInProgress)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `UPDATE batch_items SET status=$2 WHERE batch_id=$1 AND status=$3`, id, BatchItemCancelled, BatchItemPending); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ClaimBatchItems marks up to limit due items of in-progress batches as
+// running with a lease. No tenant gets more than perTenant items running at
+// once, counting items already leased by other workers. Each item carries
+// its batch's API key.
+func (s *Store) ClaimBatchItems(ctx context.Context, limit, perTenant int, lease time.Duration) ([]BatchItem, error) {
+	rows, err := s.DB.Query(ctx, `WITH running AS (
+			SELECT tenant_id, COUNT(*) AS n FROM batch_items WHERE status='running' AND next_attempt_at > NOW() GROUP BY tenant_id
+		), due AS (
+			SELECT i.id, i.tenant_id, ROW_NUMBER() OVER (PARTITION BY i.tenant_id ORDER BY i.id) + COALESCE(r.n, 0) AS slot
+			FROM batch_items i
+			JOIN batches b ON b.id = i.batch_id AND b.status = 'in_progress'
+			LEFT JOIN running r ON r.tenant_id = i.tenant_id
+			WHERE i.status IN ('pending', 'running') AND i.next_attempt_at <= NOW()
+		)
+		UPDATE batch_items i SET status='running', attempts=i.attempts+1, next_attempt_at=NOW()+$3*INTERVAL '1 second'
+		FROM batches b
+		WHERE b.id = i.batch_id AND i.id IN (SELECT id FROM due WHERE slot <= $2 ORDER BY id LIMIT $1)
+			AND i.status IN ('pending', 'running') AND i.next_attempt_at <= NOW()
+		RETURNING i.id, i.batch_id, i.tenant_id, b.api_key, i.idx, i.custom_id, i.request, i.attempts`, limit, perTenant, int(lease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []BatchItem
+	for rows.Next() {
+		var it BatchItem
+		var req []byte
+		if err := rows.Scan(&it.ID, &it.BatchID, &it.TenantID, &it.APIKey, &it.Index, &it.CustomID, &req, &it.Attempts); err != nil {
+			return nil, err
+		}
+		it.Request = req
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// RetryBatchItem releases a running item back to pending until retryAt.
+func (s *Store) RetryBatchItem(ctx context.Context, id int64, statusCode int, errMsg string, retryAt time.Time) error {
+	_, err := s.DB.Exec(ctx, `UPDATE batch_items SET status='pending', status_code=$2, error=$3, next_attempt_at=$4 WHERE id=$1 AND status='running'`,
+		id, statusCode, errMsg, retryAt)
+	return err
+}
+
+// DeferBatchItem puts a claimed item back in the queue until retryAt and
+// gives back the attempt its claim took.
+func (s *Store) DeferBatchItem(ctx context.Context, id int64, statusCode int, errMsg string, retryAt time.Time) error {
+	_, err := s.DB.Exec(ctx, `UPDATE batch_items SET status='pending', attempts=GREATEST(attempts-1, 0), status_code=$2, error=$3, next_attempt_at=$4 WHERE id=$1 AND status='running'`,
+		id, statusCode, errMsg, retryAt)
+	return err
+}
+
+// FinishBatchItem stores an item's result, updates the batch counters and
+// completes the batch once every item has finished.
+func (s *Store) FinishBatchItem(ctx context.Context, it BatchItem) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	var resp []byte
+	if len(it.Response) > 0 {
+		resp = it.Response
+	}
+	tag, err := tx.Exec(ctx, `UPDATE batch_items SET status=$2, status_code=$3, response=$4, error=$5, completed_at=NOW() WHERE id=$1 AND status='running'`,
+		it.ID, it.Status, it.StatusCode, resp, it.Error)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		// Cancelled, or finished by a worker that took over a lapsed lease.
+		return nil
+	}
+	completed, failed := 1, 0
+	if it.Status != BatchItemSucceeded {
+		completed, failed = 0, 1
+	}
+	if _, err := tx.Exec(ctx, `UPDATE batches SET completed_count=completed_count+$2, failed_count=failed_count+$3, updated_at=NOW(),
+			status=CASE WHEN completed_count+failed_count+$2+$3 >= request_count AND status='in_progress' THEN 'completed' ELSE status END,
+			completed_at=CASE WHEN completed_count+failed_count+$2+$3 >= request_count AND status='in_progress' THEN NOW() ELSE completed_at END
+		WHERE id=$1`, it.BatchID, completed, failed); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListBatchResults returns finished items in request order.
+func (s *Store) ListBatchResults(ctx context.Context, batchID string) ([]BatchItem, error) {
+	rows, err := s.DB.Query(ctx, `SELECT idx, custom_id, status, attempts, status_code, response, error, completed_at FROM batch_items
+		WHERE batch_id=$1 AND status IN ('succeeded', 'failed') ORDER BY idx`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []BatchItem
+	for rows.Next() {
+		var it BatchItem
+		var resp []byte
+		if err := rows.Scan(&it.Index, &it.CustomID, &it.Status, &it.Attempts, &it.StatusCode, &resp, &it.Error, &it.CompletedAt); err != nil {
+			return nil, err
+		}
+		it.Response = resp
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Transaction types recorded for credits. Their amount is the change in
+// credits; balance_after is the paid balance, which credits never touch.
+const (
+	TxCreditGrant  = "credit_grant"
+	TxCreditCharge = "credit_charge"
+	TxCreditExpire = "credit_expire"
+	TxCreditRevoke = "credit_revoke"
+)
+
+// liveCreditsSQL sums a tenant's unexpired credits; it expects the tenants
+// row as t.
+const liveCreditsSQL = `COALESCE((SELECT SUM(remaining_usd) FROM credit_grants c WHERE c.tenant_id=t.id AND c.remaining_usd > 0 AND c.expires_at > NOW() AND c.revoked_at IS NULL), 0)::float8`
+
+// CreditGrant is promotional credit for a tenant, spent before its paid
+// balance until it runs out or expires.
+type CreditGrant struct {
+	ID           string     `json:"id"`
+	TenantID     string     `json:"tenant_id"`
+	AmountUSD    float64    `json:"amount_usd"`
+	RemainingUSD float64    `json:"remaining_usd"`
+	Reason       string     `json:"reason"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CreatedBy    string     `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiredAt    *time.Time `json:"expired_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Charge is how a charge was split between credits and the paid balance.
+type Charge struct {
+	CreditsUSD   float64
+	BalanceUSD   float64
+	BalanceAfter float64
+}
+
+// GrantCredits adds a credit grant and records it in the tenant's ledger.
+func (s *Store) GrantCredits(ctx context.Context, g CreditGrant) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `INSERT INTO credit_grants (id, tenant_id, amount_usd, remaining_usd, reason, expires_at, created_by) VALUES ($1,$2,$3,$3,$4,$5,$6)`,
+		g.ID, g.TenantID, g.AmountUSD, g.Reason, g.ExpiresAt, g.CreatedBy); err != nil {
+		return err
+	}
+	desc := fmt.Sprintf("Credit grant $%.2f, expires %s", g.AmountUSD, g.ExpiresAt.Format("2006-01-02"))
+	if g.Reason != "" {
+		desc += ": " + g.Reason
+	}
+	if err := recordCreditTx(ctx, tx, g.TenantID, TxCreditGrant, g.AmountUSD, desc); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListCreditGrants returns all of a tenant's grants, newest first.
+func (s *Store) ListCreditGrants(ctx context.Context, tenantID string) ([]CreditGrant, error) {
+	rows, err := s.DB.Query(ctx, `SELECT id, tenant_id, amount_usd::float8, remaining_usd::float8, reason, expires_at, created_by, created_at, expired_at, revoked_at
+		FROM credit_grants WHERE tenant_id=$1 ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CreditGrant
+	for rows.Next() {
+		var g CreditGrant
+		if err := rows.Scan(&g.ID, &g.TenantID, &g.AmountUSD, &g.RemainingUSD, &g.Reason, &g.ExpiresAt, &g.CreatedBy, &g.CreatedAt, &g.ExpiredAt, &g.RevokedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// RevokeCreditGrant withdraws what is left of a live grant and records it in
+// the ledger.
+func (s *Store) RevokeCreditGrant(ctx context.Context, tenantID, id string) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	var remaining float64
+	err = tx.QueryRow(ctx, `UPDATE credit_grants c SET revoked_at=NOW(), remaining_usd=0
+		FROM (SELECT id, remaining_usd FROM credit_grants WHERE id=$1 AND tenant_id=$2 AND revoked_at IS NULL AND expired_at IS NULL FOR UPDATE) old
+		WHERE c.id=old.id RETURNING old.remaining_usd::float8`, id, tenantID).Scan(&remaining)
+	if err != nil {
+		return err
+	}
+	if err := recordCreditTx(ctx, tx, tenantID, TxCreditRevoke, -remaining, "Credit grant "+id+" revoked"); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ChargeTenant bills amount to a tenant: unexpired credits first, earliest
+// expiry first, then the paid balance, which may go negative.
+func (s *Store) ChargeTenant(ctx context.Context, tenantID string, amount float64) (Charge, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return Charge{}, err
+	}
+	defer tx.Rollback(ctx)
+	rows, err := tx.Query(ctx, `SELECT id, remaining_usd::float8 FROM credit_grants
+		WHERE tenant_id=$1 AND remaining_usd > 0 AND expires_at > NOW() AND revoked_at IS NULL
+		ORDER BY expires_at, created_at FOR UPDATE`, tenantID)
+	if err != nil {
+		return Charge{}, err
+	}
+	type grant struct {
+		id        string
+		remaining float64
+	}
+	var grants []grant
+	for rows.Next() {
+		var g grant
+		if err := rows.Scan(&g.id, &g.remaining); err != nil {
+			rows.Close()
+			return Charge{}, err
+		}
+		grants = append(grants, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Charge{}, err
+	}
+	var c Charge
+	left := amount
+	for _, g := range grants {
+		if left <= 0 {
+			break
+		}
+		take := g.remaining
+		if take > left {
+			take = left
+		}
+		if _, err := tx.Exec(ctx, `UPDATE credit_grants SET remaining_usd=remaining_usd-$2 WHERE id=$1`, g.id, take); err != nil {
+			return Charge{}, err
+		}
+		c.CreditsUSD += take
+		left -= take
+	}
+	c.BalanceUSD = left
+	if err := tx.QueryRow(ctx, `UPDATE tenants SET balance_usd = balance_usd - $2 WHERE id=$1 RETURNING balance_usd`, tenantID, left).Scan(&c.BalanceAfter); err != nil {
+		return Charge{}, err
+	}
+	return c, tx.Commit(ctx)
+}
+
+// ExpireCreditGrants writes off what is left of every grant past its expiry,
+// recording each in its tenant's ledger. It returns how many it expired.
+func (s *Store) ExpireCreditGrants(ctx context.Context) (int, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	rows, err := tx.Query(ctx, `UPDATE credit_grants c SET expired_at=NOW(), remaining_usd=0
+		FROM (SELECT id, remaining_usd FROM credit_grants WHERE expires_at <= NOW() AND expired_at IS NULL AND revoked_at IS NULL FOR UPDATE SKIP LOCKED) old
+		WHERE c.id=old.id RETURNING c.id, c.tenant_id, old.remaining_usd::float8`)
+	if err != nil {
+		return 0, err
+	}
+	type expired struct {
+		id, tenantID string
+		remaining    float64
+	}
+	var list []expired
+	for rows.Next() {
+		var e expired
+		if err := rows.Scan(&e.id, &e.tenantID, &e.remaining); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		list = append(list, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	for _, e := range list {
+		if e.remaining <= 0 {
+			continue
+		}
+		if err := recordCreditTx(ctx, tx, e.tenantID, TxCreditExpire, -e.remaining, "Credit grant "+e.id+" expired"); err != nil {
+			return 0, err
+		}
+	}
+	return len(list), tx.Commit(ctx)
+}
+
+// recordCreditTx adds a credit ledger entry, stamped with the paid balance.
+func recordCreditTx(ctx context.Context, tx pgx.Tx, tenantID, txType string, amount float64, desc string) error {
+	_, err := tx.Exec(ctx, `INSERT INTO balance_transactions (tenant_id, type, amount_usd, balance_after, description)
+		SELECT id, $2, $3, balance_usd, $4 FROM tenants WHERE id=$1`, tenantID, txType, amount, desc)
+	return err
+}
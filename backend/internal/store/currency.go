@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BaseCurrency is the accounting unit; every stored amount is in it.
+const BaseCurrency = "USD"
+
+// Exchange rate sources.
+const (
+	RateSourceManual = "manual"
+	RateSourceECB    = "ecb"
+)
+
+// ExchangeRate converts USD for display: one USD is PerUSD units of Currency.
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	PerUSD    float64   `json:"per_usd"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *Store) ListExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	rows, err := s.DB.Query(ctx, `SELECT currency, per_usd, source, updated_at FROM exchange_rates ORDER BY currency`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ExchangeRate
+	for rows.Next() {
+		var r ExchangeRate
+		if err := rows.Scan(&r.Currency, &r.PerUSD, &r.Source, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetExchangeRate(ctx context.Context, currency string) (*ExchangeRate, error) {
+	var r ExchangeRate
+	err := s.DB.QueryRow(ctx, `SELECT currency, per_usd, source, updated_at FROM exchange_rates WHERE currency=$1`, currency).
+		Scan(&r.Currency, &r.PerUSD, &r.Source, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SetExchangeRate sets a manual rate, which feed refreshes leave alone.
+func (s *Store) SetExchangeRate(ctx context.Context, currency string, perUSD float64) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO exchange_rates (currency, per_usd, source, updated_at) VALUES ($1,$2,$3,NOW())
+		ON CONFLICT (currency) DO UPDATE SET per_usd=EXCLUDED.per_usd, source=EXCLUDED.source, updated_at=NOW()`,
+		currency, perUSD, RateSourceManual)
+	return err
+}
+
+// UpsertFeedRates stores rates from a feed, skipping currencies an operator
+// has set by hand. It returns how many rates changed.
+func (s *Store) UpsertFeedRates(ctx context.Context, source string, rates map[string]float64) (int, error) {
+	n := 0
+	for currency, perUSD := range rates {
+		tag, err := s.DB.Exec(ctx, `INSERT INTO exchange_rates (currency, per_usd, source, updated_at) VALUES ($1,$2,$3,NOW())
+			ON CONFLICT (currency) DO UPDATE SET per_usd=EXCLUDED.per_usd, updated_at=NOW() WHERE exchange_rates.source=EXCLUDED.source`,
+			currency, perUSD, source)
+		if err != nil {
+			return n, err
+		}
+		n += int(tag.RowsAffected())
+	}
+	return n, nil
+}
+
+func (s *Store) DeleteExchangeRate(ctx context.Context, currency string) error {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM exchange_rates WHERE currency=$1`, currency)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetTenantCurrency returns the tenant's display currency with its rate. A
+// currency whose rate has since been removed falls back to USD at 1.
+func (s *Store) GetTenantCurrency(ctx context.Context, tenantID string) (string, float64, error) {
+	var currency string
+	var perUSD *float64
+	err := s.DB.QueryRow(ctx, `SELECT t.display_currency, r.per_usd FROM tenants t LEFT JOIN exchange_rates r ON r.currency=t.display_currency WHERE t.id=$1`, tenantID).
+		Scan(&currency, &perUSD)
+	if err != nil {
+		return "", 0, err
+	}
+	if perUSD == nil || currency == BaseCurrency {
+		return BaseCurrency, 1, nil
+	}
+	return currency, *perUSD, nil
+}
+
+func (s *Store) SetTenantCurrency(ctx context.Context, tenantID, currency string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE tenants SET display_currency=$2 WHERE id=$1`, tenantID, currency)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// outlookDays is the trailing window the dashboard outlook's leaders,
+	// rates and trend cover.
+	outlookDays = 30
+	// runRateDays is how many full days the month-end projection averages.
+	runRateDays = 7
+	outlookTopN = 5
+)
+
+// DashboardOutlook is the admin dashboard's cost and capacity view, read
+// from usage_daily and request_stats_daily rather than request_logs. Rates
+// are percentages, like AdminDashboardStats.ErrorRate.
+type DashboardOutlook struct {
+	MonthToDateUSD float64 `json:"month_to_date_usd"`
+	// DailyRunRateUSD is the average billed spend of the last 7 full days.
+	DailyRunRateUSD float64 `json:"daily_run_rate_usd"`
+	// ProjectedMonthEndUSD is month-to-date spend plus the run rate for the
+	// rest of the month.
+	ProjectedMonthEndUSD float64             `json:"projected_month_end_usd"`
+	TopTenants           []CostLeader        `json:"top_tenants"`
+	TopModels            []CostLeader        `json:"top_models"`
+	FallbackRate         float64             `json:"fallback_rate"`
+	CacheHitRate         float64             `json:"cache_hit_rate"`
+	Daily                []DailyRequestStats `json:"daily"`
+}
+
+// CostLeader is a tenant or model ranked by billed spend over the last 30
+// days. Name is set for tenants.
+type CostLeader struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name,omitempty"`
+	CostUSD float64 `json:"cost_usd"`
+	Tokens  int64   `json:"tokens"`
+}
+
+// DailyRequestStats is one request_stats_daily row with its rates.
+type DailyRequestStats struct {
+	Day          time.Time `json:"day"`
+	Requests     int64     `json:"requests"`
+	Fallbacks    int64     `json:"fallbacks"`
+	FallbackRate float64   `json:"fallback_rate"`
+	CacheLookups int64     `json:"cache_lookups"`
+	CacheHits    int64     `json:"cache_hits"`
+	CacheHitRate float64   `json:"cache_hit_rate"`
+}
+
+// RecordCacheLookup counts a prompt cache lookup on the day of at.
+func (s *Store) RecordCacheLookup(ctx context.Context, hit bool, at time.Time) error {
+	hits := 0
+	if hit {
+		hits = 1
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO request_stats_daily (day, cache_lookups, cache_hits) VALUES ($1::date, 1, $2)
+	ON CONFLICT (day) DO UPDATE SET cache_lookups = request_stats_daily.cache_lookups + 1, cache_hits = request_stats_daily.cache_hits + EXCLUDED.cache_hits`,
+		at.UTC().Format("2006-01-02"), hits)
+	return err
+}
+
+// fillOutlook computes o as of now. Like the rest of the dashboard it is
+// best effort: a failed query leaves its fields empty.
+func (s *Store) fillOutlook(ctx context.Context, o *DashboardOutlook, now time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	since := today.AddDate(0, 0, -outlookDays+1)
+
+	var lastDays float64
+	_ = s.DB.QueryRow(ctx, `SELECT COALESCE(SUM(cost_usd) FILTER (WHERE day >= $1::date), 0)::float8,
+		COALESCE(SUM(cost_usd) FILTER (WHERE day >= $2::date AND day < $3::date), 0)::float8
+		FROM usage_daily WHERE day >= LEAST($1::date, $2::date)`,
+		monthStart.Format("2006-01-02"), today.AddDate(0, 0, -runRateDays).Format("2006-01-02"), today.Format("2006-01-02")).
+		Scan(&o.MonthToDateUSD, &lastDays)
+	o.DailyRunRateUSD = lastDays / runRateDays
+	o.ProjectedMonthEndUSD = o.MonthToDateUSD + o.DailyRunRateUSD*monthEnd.Sub(now).Hours()/24
+
+	o.TopTenants = s.costLeaders(ctx, `SELECT u.tenant_id, COALESCE(t.name, ''), SUM(u.cost_usd)::float8, SUM(u.tokens)::bigint
+		FROM usage_daily u LEFT JOIN tenants t ON t.id = u.tenant_id
+		WHERE u.day >= $1::date GROUP BY u.tenant_id, t.name ORDER BY 3 DESC LIMIT $2`, since)
+	o.TopModels = s.costLeaders(ctx, `SELECT model, '', SUM(cost_usd)::float8, SUM(tokens)::bigint
+		FROM usage_daily WHERE day >= $1::date GROUP BY model ORDER BY 3 DESC LIMIT $2`, since)
+
+	o.Daily = []DailyRequestStats{}
+	rows, err := s.DB.Query(ctx, `SELECT day, requests, fallbacks, cache_lookups, cache_hits FROM request_stats_daily WHERE day >= $1::date ORDER BY day`,
+		since.Format("2006-01-02"))
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	byDay := map[int64]DailyRequestStats{}
+	for rows.Next() {
+		var d DailyRequestStats
+		if err := rows.Scan(&d.Day, &d.Requests, &d.Fallbacks, &d.CacheLookups, &d.CacheHits); err != nil {
+			continue
+		}
+		byDay[d.Day.Unix()] = d
+	}
+	var total DailyRequestStats
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		d, ok := byDay[day.Unix()]
+		if !ok {
+			d = DailyRequestStats{Day: day}
+		}
+		d.setRates()
+		o.Daily = append(o.Daily, d)
+		total.Requests += d.Requests
+		total.Fallbacks += d.Fallbacks
+		total.CacheLookups += d.CacheLookups
+		total.CacheHits += d.CacheHits
+	}
+	total.setRates()
+	o.FallbackRate, o.CacheHitRate = total.FallbackRate, total.CacheHitRate
+}
+
+func (s *Store) costLeaders(ctx context.Context, query string, since time.Time) []CostLeader {
+	out := []CostLeader{}
+	rows, err := s.DB.Query(ctx, query, since.Format("2006-01-02"), outlookTopN)
+	if err != nil {
+		return out
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l CostLeader
+		if err := rows.Scan(&l.ID, &l.Name, &l.CostUSD, &l.Tokens); err != nil {
+			continue
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+func (d *DailyRequestStats) setRates() {
+	if d.Requests > 0 {
+		d.FallbackRate = float64(d.Fallbacks) / float64(d.Requests) * 100
+	}
+	if d.CacheLookups > 0 {
+		d.CacheHitRate = float64(d.CacheHits) / float64(d.CacheLookups) * 100
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Automatic suspension reasons. A negative_balance suspension lifts once the
+// balance is back at or above zero; an abuse suspension needs an operator.
+const (
+	SuspendNegativeBalance = "negative_balance"
+	SuspendAbuse           = "abuse"
+)
+
+// AbuseThresholds are per-tenant limits over the last hour; zero disables a
+// limit.
+type AbuseThresholds struct {
+	ModerationFlagsPerHour int
+	ClientErrorsPerHour    int
+}
+
+// Delinquent is a tenant the delinquency policy should suspend.
+type Delinquent struct {
+	TenantID   string
+	Name       string
+	BalanceUSD float64
+	Reason     string
+	// Detail says which limit was crossed, for notifications.
+	Detail string
+}
+
+// TrackNegativeBalances records when each tenant's balance went below its
+// overdraft allowance and clears it for tenants back within it, so a tenant
+// using its overdraft is not counted as delinquent.
+func (s *Store) TrackNegativeBalances(ctx context.Context) error {
+	if _, err := s.DB.Exec(ctx, `UPDATE tenants SET negative_since=NOW() WHERE balance_usd < -overdraft_usd AND negative_since IS NULL`); err != nil {
+		return err
+	}
+	_, err := s.DB.Exec(ctx, `UPDATE tenants SET negative_since=NULL WHERE balance_usd >= -overdraft_usd AND negative_since IS NOT NULL`)
+	return err
+}
+
+// ListNegativeBalanceTenants returns unsuspended tenants whose balance has
+// been negative for at least days. A tenant an operator unsuspended during
+// the same negative stretch is left alone.
+func (s *Store) ListNegativeBalanceTenants(ctx context.Context, days int) ([]Delinquent, error) {
+	rows, err := s.DB.Query(ctx, `SELECT id, name, balance_usd, negative_since FROM tenants
+		WHERE NOT suspended AND negative_since < NOW() - make_interval(days => $1)
+			AND (auto_suspended_at IS NULL OR auto_suspended_at < negative_since) ORDER BY negative_since`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Delinquent
+	for rows.Next() {
+		d := Delinquent{Reason: SuspendNegativeBalance}
+		var since time.Time
+		if err := rows.Scan(&d.TenantID, &d.Name, &d.BalanceUSD, &since); err != nil {
+			return nil, err
+		}
+		d.Detail = "balance negative since " + since.UTC().Format(time.RFC3339)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// ListAbusiveTenants returns unsuspended tenants over an abuse threshold in
+// the last hour. Events before a tenant's last automatic suspension are not
+// counted, so an operator lifting it is not overruled by the next sweep.
+func (s *Store) ListAbusiveTenants(ctx context.Context, th AbuseThresholds) ([]Delinquent, error) {
+	rows, err := s.DB.Query(ctx, `
+		SELECT id, name, balance_usd, flags, client_errors FROM (
+			SELECT t.id, t.name, t.balance_usd,
+				(SELECT COUNT(*) FROM moderation_events m WHERE m.tenant_id=t.id AND m.created_at >= w.since) AS flags,
+				(SELECT COUNT(*) FROM request_logs l WHERE l.tenant_id=t.id AND l.created_at >= w.since AND l.status_code BETWEEN 400 AND 499) AS client_errors
+			FROM tenants t CROSS JOIN LATERAL (SELECT GREATEST(NOW() - INTERVAL '1 hour', COALESCE(t.auto_suspended_at, '-infinity')) AS since) w
+			WHERE NOT t.suspended
+		) c
+		WHERE ($1 > 0 AND flags >= $1) OR ($2 > 0 AND client_errors >= $2)`,
+		th.ModerationFlagsPerHour, th.ClientErrorsPerHour)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Delinquent
+	for rows.Next() {
+		d := Delinquent{Reason: SuspendAbuse}
+		var flags, clientErrors int
+		if err := rows.Scan(&d.TenantID, &d.Name, &d.BalanceUSD, &flags, &clientErrors); err != nil {
+			return nil, err
+		}
+		if th.ModerationFlagsPerHour > 0 && flags >= th.ModerationFlagsPerHour {
+			d.Detail = fmt.Sprintf("%d moderation flags in the last hour", flags)
+		} else {
+			d.Detail = fmt.Sprintf("%d client errors in the last hour", clientErrors)
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// AutoSuspendTenant suspends a tenant for reason. It reports false when the
+// tenant was already suspended.
+func (s *Store) AutoSuspendTenant(ctx context.Context, tenantID, reason string) (bool, error) {
+	tag, err := s.DB.Exec(ctx, `UPDATE tenants SET suspended=true, auto_suspend_reason=$2, auto_suspended_at=NOW() WHERE id=$1 AND NOT suspended`, tenantID, reason)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseBalanceSuspensions lifts negative_balance suspensions of tenants
+// whose balance is back at or above zero, of tenantID only when it is set,
+// and returns the tenants released.
+func (s *Store) ReleaseBalanceSuspensions(ctx context.Context, tenantID string) ([]string, error) {
+	rows, err := s.DB.Query(ctx, `UPDATE tenants SET suspended=false, auto_suspend_reason='', auto_suspended_at=NULL, negative_since=NULL
+		WHERE suspended AND auto_suspend_reason=$1 AND balance_usd >= 0 AND ($2 = '' OR id=$2) RETURNING id`, SuspendNegativeBalance, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// TenantOwnerEmails returns the email addresses of a tenant's owners.
+func (s *Store) TenantOwnerEmails(ctx context.Context, tenantID string) ([]string, error) {
+	rows, err := s.DB.Query(ctx, `SELECT email FROM tenant_users WHERE tenant_id=$1 AND role=$2 AND email <> '' ORDER BY username`, tenantID, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var e string
+		if err := rows.Scan(&e); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
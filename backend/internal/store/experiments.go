@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Experiment routes a percentage of one tenant's traffic for a model to an
+// alternative provider and/or model.
+type Experiment struct {
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	TenantID          string    `json:"tenant_id"`
+	Model             string    `json:"model"`
+	VariantProviderID string    `json:"variant_provider_id"`
+	VariantModel      string    `json:"variant_model"`
+	TrafficPercent    float64   `json:"traffic_percent"`
+	Enabled           bool      `json:"enabled"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ExperimentArmStats aggregates request_logs for one arm of an experiment.
+type ExperimentArmStats struct {
+	Variant      string  `json:"variant"`
+	Requests     int     `json:"requests"`
+	Errors       int     `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMS float64 `json:"avg_latency_ms"`
+	P95LatencyMS float64 `json:"p95_latency_ms"`
+	AvgTTFTMS    float64 `json:"avg_ttft_ms"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	AvgCostUSD   float64 `json:"avg_cost_usd"`
+	Tokens       int     `json:"tokens"`
+}
+
+const experimentColumns = `id, name, tenant_id, model, COALESCE(variant_provider_id,''), variant_model, traffic_percent, enabled, created_at`
+
+func scanExperiment(row interface{ Scan(...any) error }) (Experiment, error) {
+	var e Experiment
+	err := row.Scan(&e.ID, &e.Name, &e.TenantID, &e.Model, &e.VariantProviderID, &e.VariantModel, &e.TrafficPercent, &e.Enabled, &e.CreatedAt)
+	return e, err
+}
+
+func (s *Store) ListExperiments(ctx context.Context, tenantID string) ([]Experiment, error) {
+	rows, err := s.DB.Query(ctx, `SELECT `+experimentColumns+` FROM experiments WHERE ($1='' OR tenant_id=$1) ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []Experiment
+	for rows.Next() {
+		e, err := scanExperiment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+func (s *Store) GetExperiment(ctx context.Context, id string) (*Experiment, error) {
+	e, err := scanExperiment(s.DB.QueryRow(ctx, `SELECT `+experimentColumns+` FROM experiments WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetActiveExperiment returns the enabled experiment for a tenant's model, if any.
+func (s *Store) GetActiveExperiment(ctx context.Context, tenantID, model string) (*Experiment, error) {
+	e, err := scanExperiment(s.DB.QueryRow(ctx, `SELECT `+experimentColumns+` FROM experiments WHERE tenant_id=$1 AND model=$2 AND enabled=true ORDER BY created_at DESC LIMIT 1`, tenantID, model))
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *Store) UpsertExperiment(ctx context.Context, e Experiment) error {
+	var variantProvider interface{}
+	if e.VariantProviderID != "" {
+		variantProvider = e.VariantProviderID
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO experiments (id, name, tenant_id, model, variant_provider_id, variant_model, traffic_percent, enabled)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name, tenant_id=EXCLUDED.tenant_id, model=EXCLUDED.model, variant_provider_id=EXCLUDED.variant_provider_id, variant_model=EXCLUDED.variant_model, traffic_percent=EXCLUDED.traffic_percent, enabled=EXCLUDED.enabled`,
+		e.ID, e.Name, e.TenantID, e.Model, variantProvider, e.VariantModel, e.TrafficPercent, e.Enabled)
+	return err
+}
+
+func (s *Store) DeleteExperiment(ctx context.Context, id string) error {
+	_, err := s.DB.Exec(ctx, `DELETE FROM experiments WHERE id=$1`, id)
+	return err
+}
+
+// GetExperimentResults compares latency, cost and error rate between the
+// control and variant arms of an experiment.
+func (s *Store) GetExperimentResults(ctx context.Context, id string) ([]ExperimentArmStats, error) {
+	rows, err := s.DB.Query(ctx, `
+		SELECT experiment_variant,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE status_code >= 400),
+		       COALESCE(AVG(latency_ms), 0),
+		       COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY latency_ms), 0),
+		       COALESCE(AVG(ttft_ms), 0),
+		       COALESCE(SUM(cost_usd), 0),
+		       COALESCE(SUM(tokens), 0)
+		FROM request_logs
+		WHERE experiment_id=$1
+		GROUP BY experiment_variant
+		ORDER BY experiment_variant
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var arms []ExperimentArmStats
+	for rows.Next() {
+		var a ExperimentArmStats
+		if err := rows.Scan(&a.Variant, &a.Requests, &a.Errors, &a.AvgLatencyMS, &a.P95LatencyMS, &a.AvgTTFTMS, &a.TotalCostUSD, &a.Tokens); err != nil {
+			return nil, err
+		}
+		if a.Requests > 0 {
+			a.ErrorRate = float64(a.Errors) / float64(a.Requests) * 100
+			a.AvgCostUSD = a.TotalCostUSD / float64(a.Requests)
+		}
+		arms = append(arms, a)
+	}
+	return arms, rows.Err()
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FreeAllowance is how much of a catalog model each tenant may use free per
+// UTC day. A request is admitted free while the tenant's requests and tokens
+// for the day are below every non-zero limit, so the token limit may be
+// overshot by the request that crosses it. Zero fields are unset.
+type FreeAllowance struct {
+	Model          string `json:"model"`
+	RequestsPerDay int    `json:"free_requests_per_day"`
+	TokensPerDay   int    `json:"free_tokens_per_day"`
+}
+
+// Active reports whether the model has any free allowance.
+func (a FreeAllowance) Active() bool {
+	return a.RequestsPerDay > 0 || a.TokensPerDay > 0
+}
+
+// GetModelFreeAllowance returns model's free allowance; a model without one,
+// or not in the catalog, gets the zero FreeAllowance.
+func (s *Store) GetModelFreeAllowance(ctx context.Context, model string) (FreeAllowance, error) {
+	a := FreeAllowance{Model: model}
+	err := s.DB.QueryRow(ctx, `SELECT free_requests_per_day, free_tokens_per_day FROM model_catalog WHERE model=$1 AND archived_at IS NULL`, model).
+		Scan(&a.RequestsPerDay, &a.TokensPerDay)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, nil
+	}
+	return a, err
+}
+
+// ListFreeAllowances returns the catalog models that have a free allowance.
+func (s *Store) ListFreeAllowances(ctx context.Context) ([]FreeAllowance, error) {
+	rows, err := s.DB.Query(ctx, `SELECT model, free_requests_per_day, free_tokens_per_day FROM model_catalog
+		WHERE (free_requests_per_day > 0 OR free_tokens_per_day > 0) AND archived_at IS NULL ORDER BY model`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []FreeAllowance
+	for rows.Next() {
+		var a FreeAllowance
+		if err := rows.Scan(&a.Model, &a.RequestsPerDay, &a.TokensPerDay); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// RecordFreeUsage adds a request served under a free allowance to the
+// tenant's usage for day. Its tokens count as usage at no cost.
+func (s *Store) RecordFreeUsage(ctx context.Context, tenantID, provider, model string, tokens int, day time.Time) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO usage_daily (tenant_id, provider, model, day, tokens, cost_usd, free_requests, free_tokens) VALUES ($1,$2,$3,$4,$5,0,1,$5)
+	ON CONFLICT (tenant_id, provider, model, day) DO UPDATE SET tokens = usage_daily.tokens + EXCLUDED.tokens,
+		free_requests = usage_daily.free_requests + 1, free_tokens = usage_daily.free_tokens + EXCLUDED.free_tokens`, tenantID, provider, model, day, tokens)
+	return err
+}
+
+// FreeUsageOn is the free requests and tokens the tenant used of model on
+// day (UTC), across providers.
+func (s *Store) FreeUsageOn(ctx context.Context, tenantID, model string, day time.Time) (requests, tokens int64, err error) {
+	err = s.DB.QueryRow(ctx, `SELECT COALESCE(SUM(free_requests),0)::bigint, COALESCE(SUM(free_tokens),0)::bigint FROM usage_daily
+		WHERE tenant_id=$1 AND model=$2 AND day=$3::date`, tenantID, model, day.UTC().Format("2006-01-02")).Scan(&requests, &tokens)
+	return requests, tokens, err
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Job states. A running job holds a lease in locked_until; a job whose lease
+// lapsed (its worker died) is claimed again.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	UniqueKey   *string         `json:"unique_key,omitempty"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedUntil *time.Time      `json:"locked_until"`
+	LockedBy    string          `json:"locked_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+}
+
+type JobFilters struct {
+	Kind   string
+	Status string
+	Limit  int
+}
+
+// JobCount is the number of jobs of a kind in a state.
+type JobCount struct {
+	Kind   string     `json:"kind"`
+	Status string     `json:"status"`
+	Count  int        `json:"count"`
+	Oldest *time.Time `json:"oldest_run_at"`
+}
+
+const jobColumns = `id, kind, payload, status, unique_key, attempts, max_attempts, last_error, run_at, locked_until, locked_by, created_at, started_at, finished_at`
+
+func scanJob(row pgx.Row) (Job, error) {
+	var j Job
+	var payload []byte
+	err := row.Scan(&j.ID, &j.Kind, &payload, &j.Status, &j.UniqueKey, &j.Attempts, &j.MaxAttempts, &j.LastError, &j.RunAt, &j.LockedUntil, &j.LockedBy, &j.CreatedAt, &j.StartedAt, &j.FinishedAt)
+	j.Payload = payload
+	return j, err
+}
+
+func (s *Store) queryJobs(ctx context.Context, query string, args ...interface{}) ([]Job, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// EnqueueJob inserts a queued job. It reports false without error when a live
+// job with the same unique key already exists.
+func (s *Store) EnqueueJob(ctx context.Context, j Job) (bool, error) {
+	payload := []byte(j.Payload)
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	tag, err := s.DB.Exec(ctx, `INSERT INTO jobs (id, kind, payload, unique_key, max_attempts, run_at) VALUES ($1,$2,$3,$4,$5,$6)
+		ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL AND status IN ('queued', 'running') DO NOTHING`,
+		j.ID, j.Kind, payload, j.UniqueKey, j.MaxAttempts, j.RunAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ClaimJobs leases up to limit due jobs of the given kinds to worker.
+func (s *Store) ClaimJobs(ctx context.Context, kinds []string, limit int, lease time.Duration, worker string) ([]Job, error) {
+	return s.queryJobs(ctx, `UPDATE jobs SET status='running', attempts=attempts+1, locked_until=NOW()+$3*INTERVAL '1 second', locked_by=$4, started_at=NOW()
+		WHERE id IN (
+			SELECT id FROM jobs WHERE kind = ANY($1)
+				AND ((status='queued' AND run_at<=NOW()) OR (status='running' AND locked_until<NOW()))
+			ORDER BY run_at LIMIT $2 FOR UPDATE SKIP LOCKED)
+		RETURNING `+jobColumns, kinds, limit, int(lease.Seconds()), worker)
+}
+
+// FinishJob records a job's outcome. A failed job with attempts left is
+// queued again at retryAt; otherwise status is final.
+func (s *Store) FinishJob(ctx context.Context, id, status, lastError string, retryAt time.Time) error {
+	_, err := s.DB.Exec(ctx, `UPDATE jobs SET status=$2, last_error=$3, locked_until=NULL,
+			run_at=CASE WHEN $2='queued' THEN $4 ELSE run_at END,
+			finished_at=CASE WHEN $2='queued' THEN NULL ELSE NOW() END
+		WHERE id=$1`, id, status, lastError, retryAt)
+	return err
+}
+
+func (s *Store) GetJob(ctx context.Context, id string) (*Job, error) {
+	j, err := scanJob(s.DB.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (s *Store) ListJobs(ctx context.Context, f JobFilters) ([]Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE 1=1`
+	args := []interface{}{}
+	if f.Kind != "" {
+		args = append(args, f.Kind)
+		query += fmt.Sprintf(" AND kind=$%d", len(args))
+	}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		query += fmt.Sprintf(" AND status=$%d", len(args))
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+	return s.queryJobs(ctx, query, args...)
+}
+
+// JobCounts summarizes jobs by kind and state. Oldest is the earliest run_at,
+// so a growing queue backlog is visible.
+func (s *Store) JobCounts(ctx context.Context) ([]JobCount, error) {
+	rows, err := s.DB.Query(ctx, `SELECT kind, status, COUNT(*), MIN(run_at) FROM jobs GROUP BY kind, status ORDER BY kind, status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []JobCount
+	for rows.Next() {
+		var c JobCount
+		if err := rows.Scan(&c.Kind, &c.Status, &c.Count, &c.Oldest); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// RetryJob queues a failed job to run now with a fresh attempt budget.
+func (s *Store) RetryJob(ctx context.Context, id string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE jobs SET status='queued', attempts=0, run_at=NOW(), finished_at=NULL WHERE id=$1 AND status='failed'`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// PruneJobs deletes finished jobs older than before.
+func (s *Store) PruneJobs(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM jobs WHERE status IN ('succeeded', 'failed') AND finished_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MaintenanceWindow is scheduled downtime for a provider. Routing skips the
+// provider from StartsAt until EndsAt.
+type MaintenanceWindow struct {
+	ID         int64     `json:"id"`
+	ProviderID string    `json:"provider_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const maintenanceWindowColumns = `id, provider_id, starts_at, ends_at, reason, created_at`
+
+func scanMaintenanceWindow(row pgx.Row) (MaintenanceWindow, error) {
+	var w MaintenanceWindow
+	err := row.Scan(&w.ID, &w.ProviderID, &w.StartsAt, &w.EndsAt, &w.Reason, &w.CreatedAt)
+	return w, err
+}
+
+// SetProviderMaintenance turns a provider's manual maintenance flag on or off.
+func (s *Store) SetProviderMaintenance(ctx context.Context, id string, on bool) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET maintenance=$2 WHERE id=$1`, id, on)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Store) CreateMaintenanceWindow(ctx context.Context, w MaintenanceWindow) (MaintenanceWindow, error) {
+	return scanMaintenanceWindow(s.DB.QueryRow(ctx, `INSERT INTO provider_maintenance_windows (provider_id, starts_at, ends_at, reason)
+		VALUES ($1,$2,$3,$4) RETURNING `+maintenanceWindowColumns, w.ProviderID, w.StartsAt, w.EndsAt, w.Reason))
+}
+
+// ListMaintenanceWindows returns a provider's current and upcoming windows,
+// soonest first.
+func (s *Store) ListMaintenanceWindows(ctx context.Context, providerID string) ([]MaintenanceWindow, error) {
+	rows, err := s.DB.Query(ctx, `SELECT `+maintenanceWindowColumns+` FROM provider_maintenance_windows
+		WHERE provider_id=$1 AND ends_at > now() ORDER BY starts_at`, providerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []MaintenanceWindow
+	for rows.Next() {
+		w, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) DeleteMaintenanceWindow(ctx context.Context, providerID string, id int64) error {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM provider_maintenance_windows WHERE provider_id=$1 AND id=$2`, providerID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ActiveMaintenanceEnds maps each provider inside a maintenance window to
+// when its current windows end.
+func (s *Store) ActiveMaintenanceEnds(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := s.DB.Query(ctx, `SELECT provider_id, MAX(ends_at) FROM provider_maintenance_windows
+		WHERE starts_at <= now() AND ends_at > now() GROUP BY provider_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]time.Time{}
+	for rows.Next() {
+		var id string
+		var end time.Time
+		if err := rows.Scan(&id, &end); err != nil {
+			return nil, err
+		}
+		out[id] = end
+	}
+	return out, rows.Err()
+}
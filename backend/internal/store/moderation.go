@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Moderation modes. Log records flagged content; flag also fires the
+// moderation.flagged webhook; block rejects the request as well.
+const (
+	ModerationOff   = "off"
+	ModerationLog   = "log"
+	ModerationFlag  = "flag"
+	ModerationBlock = "block"
+)
+
+func ValidModerationMode(mode string) bool {
+	switch mode {
+	case ModerationOff, ModerationLog, ModerationFlag, ModerationBlock:
+		return true
+	}
+	return false
+}
+
+type ModerationPolicy struct {
+	TenantID    string    `json:"tenant_id"`
+	Mode        string    `json:"mode"`
+	CheckOutput bool      `json:"check_output"`
+	Categories  []string  `json:"categories"` // empty means every category counts
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ModerationEvent struct {
+	ID         int64     `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	Direction  string    `json:"direction"`
+	Action     string    `json:"action"`
+	Model      string    `json:"model"`
+	Categories []string  `json:"categories"`
+	PromptHash string    `json:"prompt_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GetModerationPolicy returns the tenant's policy, or an "off" policy when
+// none is configured.
+func (s *Store) GetModerationPolicy(ctx context.Context, tenantID string) (*ModerationPolicy, error) {
+	p := ModerationPolicy{TenantID: tenantID}
+	err := s.DB.QueryRow(ctx, `SELECT mode, check_output, categories, updated_at FROM tenant_moderation_policies WHERE tenant_id=$1`, tenantID).
+		Scan(&p.Mode, &p.CheckOutput, &p.Categories, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &ModerationPolicy{TenantID: tenantID, Mode: ModerationOff, Categories: []string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Store) UpsertModerationPolicy(ctx context.Context, p ModerationPolicy) error {
+	if p.Categories == nil {
+		p.Categories = []string{}
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO tenant_moderation_policies (tenant_id, mode, check_output, categories, updated_at) VALUES ($1,$2,$3,$4,NOW())
+		ON CONFLICT (tenant_id) DO UPDATE SET mode=EXCLUDED.mode, check_output=EXCLUDED.check_output, categories=EXCLUDED.categories, updated_at=NOW()`,
+		p.TenantID, p.Mode, p.CheckOutput, p.Categories)
+	return err
+}
+
+func (s *Store) InsertModerationEvent(ctx context.Context, e ModerationEvent) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	if e.Categories == nil {
+		e.Categories = []string{}
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO moderation_events (tenant_id, direction, action, model, categories, prompt_hash, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+		e.TenantID, e.Direction, e.Action, e.Model, e.Categories, e.PromptHash, e.CreatedAt)
+	return err
+}
+
+func (s *Store) ListModerationEvents(ctx context.Context, tenantID string, from, to time.Time, limit int) ([]ModerationEvent, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	where := "WHERE 1=1"
+	args := []interface{}{}
+	if tenantID != "" {
+		args = append(args, tenantID)
+		where += fmt.Sprintf(" AND tenant_id=$%d", len(args))
+	}
+	if !from.IsZero() {
+		args = append(args, from)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	args = append(args, limit)
+	rows, err := s.DB.Query(ctx, fmt.Sprintf(`SELECT id, tenant_id, direction, action, model, categories, prompt_hash, created_at FROM moderation_events %s ORDER BY created_at DESC LIMIT $%d`, where, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ModerationEvent
+	for rows.Next() {
+		var e ModerationEvent
+		if err := rows.Scan(&e.ID, &e.TenantID, &e.Direction, &e.Action, &e.Model, &e.Categories, &e.PromptHash, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
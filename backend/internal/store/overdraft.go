@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TxOverdraft records the part of a charge that took the paid balance below
+// zero, into the tenant's overdraft allowance.
+const TxOverdraft = "overdraft"
+
+// SetTenantOverdraft sets how far below zero the tenant's balance may go
+// before requests are refused.
+func (s *Store) SetTenantOverdraft(ctx context.Context, tenantID string, overdraftUSD float64) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE tenants SET overdraft_usd=$2 WHERE id=$1`, tenantID, overdraftUSD)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrTemplateExists is returned when a tenant already has a template with the
+// same name.
+var ErrTemplateExists = errors.New("a prompt template with this name already exists")
+
+// TemplateMessage is one message of a prompt template. Content may contain
+// {{variable}} placeholders.
+type TemplateMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// PromptTemplate is a named, tenant-scoped list of messages rendered into chat
+// requests that reference it by name. Variables lists the placeholders found
+// in the messages; Version is bumped on every update.
+type PromptTemplate struct {
+	ID          string            `json:"id"`
+	TenantID    string            `json:"tenant_id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Messages    []TemplateMessage `json:"messages"`
+	Variables   []string          `json:"variables"`
+	Version     int               `json:"version"`
+	CreatedBy   string            `json:"created_by"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+const promptTemplateColumns = `id, tenant_id, name, description, messages, variables, version, created_by, created_at, updated_at`
+
+func scanPromptTemplate(row pgx.Row) (PromptTemplate, error) {
+	var t PromptTemplate
+	var messages []byte
+	if err := row.Scan(&t.ID, &t.TenantID, &t.Name, &t.Description, &messages, &t.Variables, &t.Version, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return t, err
+	}
+	if err := json.Unmarshal(messages, &t.Messages); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+func (s *Store) ListPromptTemplates(ctx context.Context, tenantID string) ([]PromptTemplate, error) {
+	rows, err := s.DB.Query(ctx, `SELECT `+promptTemplateColumns+` FROM prompt_templates WHERE tenant_id=$1 ORDER BY name`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PromptTemplate
+	for rows.Next() {
+		t, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) GetPromptTemplate(ctx context.Context, tenantID, id string) (*PromptTemplate, error) {
+	t, err := scanPromptTemplate(s.DB.QueryRow(ctx, `SELECT `+promptTemplateColumns+` FROM prompt_templates WHERE tenant_id=$1 AND id=$2`, tenantID, id))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Store) GetPromptTemplateByName(ctx context.Context, tenantID, name string) (*PromptTemplate, error) {
+	t, err := scanPromptTemplate(s.DB.QueryRow(ctx, `SELECT `+promptTemplateColumns+` FROM prompt_templates WHERE tenant_id=$1 AND name=$2`, tenantID, name))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Store) CreatePromptTemplate(ctx context.Context, t PromptTemplate) error {
+	messages, err := json.Marshal(t.Messages)
+	if err != nil {
+		return err
+	}
+	if t.Variables == nil {
+		t.Variables = []string{}
+	}
+	tag, err := s.DB.Exec(ctx, `INSERT INTO prompt_templates (id, tenant_id, name, description, messages, variables, version, created_by, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,1,$7,NOW(),NOW()) ON CONFLICT (tenant_id, name) DO NOTHING`,
+		t.ID, t.TenantID, t.Name, t.Description, messages, t.Variables, t.CreatedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTemplateExists
+	}
+	return nil
+}
+
+// UpdatePromptTemplate replaces a template's content and bumps its version.
+func (s *Store) UpdatePromptTemplate(ctx context.Context, t PromptTemplate) error {
+	messages, err := json.Marshal(t.Messages)
+	if err != nil {
+		return err
+	}
+	if t.Variables == nil {
+		t.Variables = []string{}
+	}
+	var conflict bool
+	err = s.DB.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM prompt_templates WHERE tenant_id=$1 AND name=$2 AND id<>$3)`, t.TenantID, t.Name, t.ID).Scan(&conflict)
+	if err != nil {
+		return err
+	}
+	if conflict {
+		return ErrTemplateExists
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE prompt_templates SET name=$3, description=$4, messages=$5, variables=$6, version=version+1, updated_at=NOW() WHERE tenant_id=$1 AND id=$2`,
+		t.TenantID, t.ID, t.Name, t.Description, messages, t.Variables)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Store) DeletePromptTemplate(ctx context.Context, tenantID, id string) error {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM prompt_templates WHERE tenant_id=$1 AND id=$2`, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Provider event types recorded on circuit, health and rate-limit transitions.
+const (
+	EventCircuitOpened = "circuit_opened"
+	EventCircuitClosed = "circuit_closed"
+	EventHealthOK      = "health_ok"
+	EventHealthFail    = "health_fail"
+	// EventRateLimited marks a provider benched after a 429/529 response.
+	EventRateLimited = "rate_limited"
+	// EventCircuitHalfOpen marks an open circuit starting to admit trial
+	// requests after its cooldown.
+	EventCircuitHalfOpen = "circuit_half_open"
+)
+
+type ProviderEvent struct {
+	ID           int       `json:"id"`
+	ProviderID   string    `json:"provider_id"`
+	ProviderName string    `json:"provider_name"`
+	EventType    string    `json:"event_type"`
+	Detail       string    `json:"detail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ProviderEventFilters struct {
+	ProviderID string
+	EventType  string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+// ProviderEventCount summarises transitions per provider over a window.
+type ProviderEventCount struct {
+	ProviderID   string `json:"provider_id"`
+	ProviderName string `json:"provider_name"`
+	EventType    string `json:"event_type"`
+	Count        int    `json:"count"`
+}
+
+func (s *Store) InsertProviderEvent(ctx context.Context, e ProviderEvent) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO provider_events (provider_id, provider_name, event_type, detail, created_at) VALUES ($1,$2,$3,$4,$5)`,
+		e.ProviderID, e.ProviderName, e.EventType, e.Detail, e.CreatedAt)
+	return err
+}
+
+func providerEventWhere(f ProviderEventFilters) (string, []interface{}) {
+	where := "WHERE 1=1"
+	args := []interface{}{}
+	if f.ProviderID != "" {
+		args = append(args, f.ProviderID)
+		where += fmt.Sprintf(" AND provider_id=$%d", len(args))
+	}
+	if f.EventType != "" {
+		args = append(args, f.EventType)
+		where += fmt.Sprintf(" AND event_type=$%d", len(args))
+	}
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	return where, args
+}
+
+func (s *Store) ListProviderEvents(ctx context.Context, f ProviderEventFilters) ([]ProviderEvent, error) {
+	if f.Limit <= 0 || f.Limit > 1000 {
+		f.Limit = 200
+	}
+	where, args := providerEventWhere(f)
+	args = append(args, f.Limit)
+	q := fmt.Sprintf(`SELECT id, provider_id, provider_name, event_type, detail, created_at FROM provider_events %s ORDER BY created_at DESC LIMIT $%d`, where, len(args))
+	rows, err := s.DB.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []ProviderEvent
+	for rows.Next() {
+		var e ProviderEvent
+		if err := rows.Scan(&e.ID, &e.ProviderID, &e.ProviderName, &e.EventType, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (s *Store) CountProviderEvents(ctx context.Context, f ProviderEventFilters) ([]ProviderEventCount, error) {
+	where, args := providerEventWhere(f)
+	rows, err := s.DB.Query(ctx, `SELECT provider_id, MAX(provider_name), event_type, COUNT(*) FROM provider_events `+where+` GROUP BY provider_id, event_type ORDER BY provider_id, event_type`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ProviderEventCount
+	for rows.Next() {
+		var c ProviderEventCount
+		if err := rows.Scan(&c.ProviderID, &c.ProviderName, &c.EventType, &c.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"routerx/internal/secrets"
+)
+
+var errNoKeyring = errors.New("provider key is encrypted but no encryption key is configured")
+
+// sealProviderKey encrypts an upstream key for storage when a keyring is
+// configured. The provider ID is used as associated data.
+func (s *Store) sealProviderKey(providerID, apiKey string) (string, error) {
+	if s.Keys == nil || apiKey == "" {
+		return apiKey, nil
+	}
+	return s.Keys.Seal(apiKey, providerID)
+}
+
+func (s *Store) openProviderKey(providerID, stored string) (string, error) {
+	if s.Keys == nil {
+		if secrets.IsSealed(stored) {
+			return "", errNoKeyring
+		}
+		return stored, nil
+	}
+	return s.Keys.Open(stored, providerID)
+}
+
+// openProvider decrypts p.APIKey and the mTLS client key in place.
+func (s *Store) openProvider(p *Provider) error {
+	key, err := s.openProviderKey(p.ID, p.APIKey)
+	if err != nil {
+		return err
+	}
+	p.APIKey = key
+	p.HasAPIKey = key != ""
+	if p.Network.ClientKey, err = s.openProviderKey(p.ID, p.Network.ClientKey); err != nil {
+		return err
+	}
+	p.Network.HasClientKey = p.Network.ClientKey != ""
+	return nil
+}
+
+// ReencryptProviderKeys rewrites every stored provider key (live, staged,
+// previous and mTLS client key) that is plaintext or sealed with a retired key using the primary
+// key. It returns the number of providers updated.
+func (s *Store) ReencryptProviderKeys(ctx context.Context) (int, error) {
+	if s.Keys == nil {
+		return 0, errors.New("no encryption key configured")
+	}
+	rows, err := s.DB.Query(ctx, `SELECT id, COALESCE(api_key,''), COALESCE(staged_api_key,''), COALESCE(previous_api_key,''), client_key FROM providers`)
+	if err != nil {
+		return 0, err
+	}
+	type providerKeys struct {
+		id   string
+		keys [4]string
+	}
+	var all []providerKeys
+	for rows.Next() {
+		var pk providerKeys
+		if err := rows.Scan(&pk.id, &pk.keys[0], &pk.keys[1], &pk.keys[2], &pk.keys[3]); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		all = append(all, pk)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, pk := range all {
+		orig := pk.keys
+		changed := false
+		for i, v := range pk.keys {
+			if !s.Keys.NeedsRotation(v) {
+				continue
+			}
+			plain, err := s.Keys.Open(v, pk.id)
+			if err != nil {
+				return updated, err
+			}
+			if pk.keys[i], err = s.Keys.Seal(plain, pk.id); err != nil {
+				return updated, err
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		// Only rewrite if the row is unchanged, so a concurrent rotation is not lost.
+		tag, err := s.DB.Exec(ctx, `UPDATE providers SET api_key=NULLIF($2,''), staged_api_key=NULLIF($3,''), previous_api_key=NULLIF($4,''), client_key=$5
+			WHERE id=$1 AND COALESCE(api_key,'')=$6 AND COALESCE(staged_api_key,'')=$7 AND COALESCE(previous_api_key,'')=$8 AND client_key=$9`,
+			pk.id, pk.keys[0], pk.keys[1], pk.keys[2], pk.keys[3], orig[0], orig[1], orig[2], orig[3])
+		if err != nil {
+			return updated, err
+		}
+		updated += int(tag.RowsAffected())
+	}
+	return updated, nil
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// ProviderBillingLine is what a provider billed for one model on one day.
+type ProviderBillingLine struct {
+	Day     time.Time `json:"day"`
+	Model   string    `json:"model"`
+	CostUSD float64   `json:"cost_usd"`
+	Tokens  int64     `json:"tokens"`
+}
+
+// ImportProviderBilling stores a provider's billing lines, replacing any
+// already imported for the same day and model.
+func (s *Store) ImportProviderBilling(ctx context.Context, providerID, source, actor string, lines []ProviderBillingLine) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, l := range lines {
+		if _, err := tx.Exec(ctx, `INSERT INTO provider_billing (provider_id, day, model, cost_usd, tokens, source, imported_by) VALUES ($1,$2,$3,$4,$5,$6,$7)
+			ON CONFLICT (provider_id, day, model) DO UPDATE SET cost_usd=EXCLUDED.cost_usd, tokens=EXCLUDED.tokens, source=EXCLUDED.source,
+			imported_by=EXCLUDED.imported_by, imported_at=NOW()`,
+			providerID, l.Day, l.Model, l.CostUSD, l.Tokens, source, actor); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// ReconciliationLine compares what a provider billed with what RouterX
+// computed from its request logs: their tokens at the model_pricing price,
+// or at the built-in list price for a model without one. DriftUSD is
+// billed minus computed: positive means the provider charged more than
+// that price predicted. DriftPct is relative to the billed amount.
+type ReconciliationLine struct {
+	Day            *time.Time `json:"day,omitempty"`
+	Model          string     `json:"model"`
+	BilledUSD      float64    `json:"billed_usd"`
+	ComputedUSD    float64    `json:"computed_usd"`
+	DriftUSD       float64    `json:"drift_usd"`
+	DriftPct       float64    `json:"drift_pct"`
+	BilledTokens   int64      `json:"billed_tokens"`
+	ComputedTokens int64      `json:"computed_tokens"`
+	Requests       int64      `json:"requests"`
+	// Flagged marks drift beyond the report's threshold, or a model only
+	// one side has, which usually means a name mismatch.
+	Flagged bool `json:"flagged"`
+}
+
+// ReconciliationReport covers the days in [From, To) with imported billing.
+type ReconciliationReport struct {
+	ProviderID   string               `json:"provider_id"`
+	From         time.Time            `json:"from"`
+	To           time.Time            `json:"to"`
+	ThresholdPct float64              `json:"threshold_pct"`
+	Days         []ReconciliationLine `json:"days"`
+	Models       []ReconciliationLine `json:"models"`
+	Total        ReconciliationLine   `json:"total"`
+}
+
+// GetReconciliation compares provider billing for the days in [from, to)
+// that have an import with the computed cost of the provider's successful
+// requests on those days, per day and model and per model overall. Models
+// in model_pricing are costed at that price; the rest keep the list-price
+// estimate the request was logged with.
+func (s *Store) GetReconciliation(ctx context.Context, providerID string, from, to time.Time, thresholdPct float64) (*ReconciliationReport, error) {
+	p, err := s.GetProviderByID(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(ctx, `
+		WITH billed AS (
+			SELECT day, model, cost_usd, tokens FROM provider_billing WHERE provider_id=$1 AND day >= $2::date AND day < $3::date
+		), computed AS (
+			SELECT (l.created_at AT TIME ZONE 'UTC')::date AS day, l.model, COUNT(*) AS requests, COALESCE(SUM(l.tokens),0)::bigint AS tokens,
+				COALESCE(SUM(CASE WHEN mp.model IS NULL THEN l.provider_cost_usd ELSE l.tokens * mp.price_per_1k_usd / 1000 END),0)::float8 AS cost
+			FROM request_logs l LEFT JOIN model_pricing mp ON mp.model=l.model
+			WHERE l.provider=$4 AND l.status_code=200 AND l.created_at >= $2 AND l.created_at < $3
+				AND (l.created_at AT TIME ZONE 'UTC')::date IN (SELECT DISTINCT day FROM billed)
+			GROUP BY 1, 2
+		)
+		SELECT COALESCE(b.day, c.day), COALESCE(b.model, c.model), COALESCE(b.cost_usd,0)::float8, COALESCE(b.tokens,0), COALESCE(c.cost,0), COALESCE(c.tokens,0), COALESCE(c.requests,0)
+		FROM billed b FULL OUTER JOIN computed c ON b.day=c.day AND b.model=c.model ORDER BY 1, 2`,
+		providerID, from, to, p.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rep := &ReconciliationReport{ProviderID: providerID, From: from, To: to, ThresholdPct: thresholdPct, Days: []ReconciliationLine{}, Models: []ReconciliationLine{}, Total: ReconciliationLine{Model: "total"}}
+	byModel := map[string]*ReconciliationLine{}
+	for rows.Next() {
+		var l ReconciliationLine
+		var day time.Time
+		if err := rows.Scan(&day, &l.Model, &l.BilledUSD, &l.BilledTokens, &l.ComputedUSD, &l.ComputedTokens, &l.Requests); err != nil {
+			return nil, err
+		}
+		l.Day = &day
+		l.setDrift(thresholdPct)
+		rep.Days = append(rep.Days, l)
+		m, ok := byModel[l.Model]
+		if !ok {
+			m = &ReconciliationLine{Model: l.Model}
+			byModel[l.Model] = m
+		}
+		m.add(l)
+		rep.Total.add(l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, m := range byModel {
+		m.setDrift(thresholdPct)
+		rep.Models = append(rep.Models, *m)
+	}
+	// Largest drift first: those are the prices to fix.
+	sort.Slice(rep.Models, func(i, j int) bool {
+		return math.Abs(rep.Models[i].DriftUSD) > math.Abs(rep.Models[j].DriftUSD)
+	})
+	rep.Total.setDrift(thresholdPct)
+	return rep, nil
+}
+
+func (l *ReconciliationLine) add(o ReconciliationLine) {
+	l.BilledUSD += o.BilledUSD
+	l.ComputedUSD += o.ComputedUSD
+	l.BilledTokens += o.BilledTokens
+	l.ComputedTokens += o.ComputedTokens
+	l.Requests += o.Requests
+}
+
+func (l *ReconciliationLine) setDrift(thresholdPct float64) {
+	l.DriftUSD = l.BilledUSD - l.ComputedUSD
+	l.DriftPct = 0
+	if l.BilledUSD > 0 {
+		l.DriftPct = l.DriftUSD / l.BilledUSD * 100
+	}
+	oneSided := (l.BilledUSD > 0) != (l.ComputedUSD > 0)
+	l.Flagged = oneSided || math.Abs(l.DriftPct) > thresholdPct
+}
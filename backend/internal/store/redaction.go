@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RedactionPolicy controls PII redaction for a tenant. RedactLogs masks PII in
+// stored request data (metadata tags, audit bodies); ScrubUpstream also masks
+// prompts before they are sent to providers.
+type RedactionPolicy struct {
+	TenantID      string    `json:"tenant_id"`
+	Detectors     []string  `json:"detectors"`
+	Patterns      []string  `json:"patterns"`
+	RedactLogs    bool      `json:"redact_logs"`
+	ScrubUpstream bool      `json:"scrub_upstream"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// DefaultRedactionPolicy applies every built-in detector to stored data only.
+func DefaultRedactionPolicy(tenantID string) *RedactionPolicy {
+	return &RedactionPolicy{
+		TenantID:   tenantID,
+		Detectors:  []string{"email", "phone", "credit_card"},
+		Patterns:   []string{},
+		RedactLogs: true,
+	}
+}
+
+func (s *Store) GetRedactionPolicy(ctx context.Context, tenantID string) (*RedactionPolicy, error) {
+	p := RedactionPolicy{TenantID: tenantID}
+	err := s.DB.QueryRow(ctx, `SELECT detectors, patterns, redact_logs, scrub_upstream, updated_at FROM tenant_redaction_policies WHERE tenant_id=$1`, tenantID).
+		Scan(&p.Detectors, &p.Patterns, &p.RedactLogs, &p.ScrubUpstream, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultRedactionPolicy(tenantID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Store) UpsertRedactionPolicy(ctx context.Context, p RedactionPolicy) error {
+	if p.Detectors == nil {
+		p.Detectors = []string{}
+	}
+	if p.Patterns == nil {
+		p.Patterns = []string{}
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO tenant_redaction_policies (tenant_id, detectors, patterns, redact_logs, scrub_upstream, updated_at) VALUES ($1,$2,$3,$4,$5,NOW())
+		ON CONFLICT (tenant_id) DO UPDATE SET detectors=EXCLUDED.detectors, patterns=EXCLUDED.patterns, redact_logs=EXCLUDED.redact_logs, scrub_upstream=EXCLUDED.scrub_upstream, updated_at=NOW()`,
+		p.TenantID, p.Detectors, p.Patterns, p.RedactLogs, p.ScrubUpstream)
+	return err
+}
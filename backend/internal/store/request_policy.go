@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RequestPolicy holds admin-set limits enforced on a tenant's chat requests.
+// Zero values mean "no limit".
+type RequestPolicy struct {
+	TenantID        string    `json:"tenant_id"`
+	MaxTokens       int       `json:"max_tokens"`
+	MinTemperature  *float64  `json:"min_temperature"`
+	MaxTemperature  *float64  `json:"max_temperature"`
+	DeniedModels    []string  `json:"denied_models"`
+	DeniedProviders []string  `json:"denied_providers"`
+	MaxMessages     int       `json:"max_messages"`
+	MaxBodyBytes    int64     `json:"max_body_bytes"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// AllowedRegions, when set, restricts routing to providers in these
+	// regions (data residency). Providers without a region are excluded.
+	AllowedRegions []string `json:"allowed_regions"`
+	// AllowedProviders (IDs or names) and AllowedModels, when set, are the
+	// only providers and models the tenant may use. Deny lists still apply.
+	AllowedProviders []string `json:"allowed_providers"`
+	AllowedModels    []string `json:"allowed_models"`
+	// DedupWindowSec, when positive, collapses identical non-streaming
+	// requests (same API key, model and prompt hash): a repeat arriving while
+	// the first is in flight, or up to this many seconds after it succeeded,
+	// gets the first one's response instead of a second upstream call.
+	DedupWindowSec int `json:"dedup_window_seconds"`
+	// Coalesce, when set, shares one upstream call among identical
+	// non-streaming requests in flight at once: CoalesceChargeOnce bills only
+	// the first request to receive the result, CoalesceChargeEach bills
+	// every one.
+	Coalesce string `json:"coalesce"`
+	// RateLimitQPS and RateLimitBurst override the limiter's default rate
+	// and token bucket capacity for the tenant; zero keeps the default.
+	RateLimitQPS   int `json:"rate_limit_qps"`
+	RateLimitBurst int `json:"rate_limit_burst"`
+	// DowngradeModels maps models to cheaper fallbacks (gpt-4o →
+	// gpt-4o-mini) used once today's spend leaves less than
+	// DowngradeBelowUSD of DailyBudgetUSD.
+	DailyBudgetUSD    float64           `json:"daily_budget_usd"`
+	DowngradeBelowUSD float64           `json:"downgrade_below_usd"`
+	DowngradeModels   map[string]string `json:"downgrade_models"`
+}
+
+// Request coalescing modes.
+const (
+	CoalesceOff        = ""
+	CoalesceChargeOnce = "charge_once"
+	CoalesceChargeEach = "charge_each"
+)
+
+// ValidCoalesce reports whether mode is a known coalescing mode.
+func ValidCoalesce(mode string) bool {
+	return mode == CoalesceOff || mode == CoalesceChargeOnce || mode == CoalesceChargeEach
+}
+
+// GetRequestPolicy returns the tenant's policy, or an empty (unrestricted)
+// policy when none is set.
+func (s *Store) GetRequestPolicy(ctx context.Context, tenantID string) (*RequestPolicy, error) {
+	p := RequestPolicy{TenantID: tenantID}
+	var downgrades []byte
+	err := s.DB.QueryRow(ctx, `SELECT max_tokens, min_temperature, max_temperature, denied_models, denied_providers, max_messages, max_body_bytes, updated_at, allowed_regions, allowed_providers, allowed_models, dedup_window_seconds, coalesce_mode, rate_limit_qps, rate_limit_burst, daily_budget_usd::float8, downgrade_below_usd::float8, downgrade_models FROM tenant_request_policies WHERE tenant_id=$1`, tenantID).
+		Scan(&p.MaxTokens, &p.MinTemperature, &p.MaxTemperature, &p.DeniedModels, &p.DeniedProviders, &p.MaxMessages, &p.MaxBodyBytes, &p.UpdatedAt, &p.AllowedRegions, &p.AllowedProviders, &p.AllowedModels, &p.DedupWindowSec, &p.Coalesce, &p.RateLimitQPS, &p.RateLimitBurst, &p.DailyBudgetUSD, &p.DowngradeBelowUSD, &downgrades)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &RequestPolicy{TenantID: tenantID, DeniedModels: []string{}, DeniedProviders: []string{}, AllowedRegions: []string{}, AllowedProviders: []string{}, AllowedModels: []string{}, DowngradeModels: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(downgrades, &p.DowngradeModels); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Store) UpsertRequestPolicy(ctx context.Context, p RequestPolicy) error {
+	if p.DeniedModels == nil {
+		p.DeniedModels = []string{}
+	}
+	if p.DeniedProviders == nil {
+		p.DeniedProviders = []string{}
+	}
+	if p.AllowedRegions == nil {
+		p.AllowedRegions = []string{}
+	}
+	if p.AllowedProviders == nil {
+		p.AllowedProviders = []string{}
+	}
+	if p.AllowedModels == nil {
+		p.AllowedModels = []string{}
+	}
+	if p.DowngradeModels == nil {
+		p.DowngradeModels = map[string]string{}
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO tenant_request_policies (tenant_id, max_tokens, min_temperature, max_temperature, denied_models, denied_providers, max_messages, max_body_bytes, allowed_regions, allowed_providers, allowed_models, dedup_window_seconds, coalesce_mode, rate_limit_qps, rate_limit_burst, daily_budget_usd, downgrade_below_usd, downgrade_models, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,NOW())
+		ON CONFLICT (tenant_id) DO UPDATE SET max_tokens=EXCLUDED.max_tokens, min_temperature=EXCLUDED.min_temperature, max_temperature=EXCLUDED.max_temperature,
+		denied_models=EXCLUDED.denied_models, denied_providers=EXCLUDED.denied_providers, max_messages=EXCLUDED.max_messages, max_body_bytes=EXCLUDED.max_body_bytes,
+		allowed_regions=EXCLUDED.allowed_regions, allowed_providers=EXCLUDED.allowed_providers, allowed_models=EXCLUDED.allowed_models, dedup_window_seconds=EXCLUDED.dedup_window_seconds, coalesce_mode=EXCLUDED.coalesce_mode,
+		rate_limit_qps=EXCLUDED.rate_limit_qps, rate_limit_burst=EXCLUDED.rate_limit_burst,
+		daily_budget_usd=EXCLUDED.daily_budget_usd, downgrade_below_usd=EXCLUDED.downgrade_below_usd, downgrade_models=EXCLUDED.downgrade_models, updated_at=NOW()`,
+		p.TenantID, p.MaxTokens, p.MinTemperature, p.MaxTemperature, p.DeniedModels, p.DeniedProviders, p.MaxMessages, p.MaxBodyBytes, p.AllowedRegions, p.AllowedProviders, p.AllowedModels, p.DedupWindowSec, p.Coalesce, p.RateLimitQPS, p.RateLimitBurst, p.DailyBudgetUSD, p.DowngradeBelowUSD, p.DowngradeModels)
+	return err
+}
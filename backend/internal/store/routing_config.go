@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+)
+
+// RoutingConfig is the global routing setup: providers (without keys), the
+// model catalog with each model's provider list, pricing and every tenant's
+// routing rules.
+type RoutingConfig struct {
+	Providers      []Provider
+	Catalog        []ModelCatalog
+	ModelProviders []ModelProviderEntry
+	Pricing        []ModelPricing
+	Rules          []RoutingRule
+}
+
+// GetRoutingConfig loads the routing setup. Provider keys are never read, and
+// archived providers and models are left out.
+func (s *Store) GetRoutingConfig(ctx context.Context) (RoutingConfig, error) {
+	var c RoutingConfig
+	rows, err := s.DB.Query(ctx, `SELECT id, name, type, COALESCE(base_url,''), COALESCE(default_model,''), supports_text, supports_vision, enabled, region, rpm_limit, tpm_limit,
+		circuit_window, circuit_threshold, circuit_cooldown_seconds, circuit_min_samples, circuit_half_open_requests, circuit_close_successes FROM providers WHERE archived_at IS NULL ORDER BY id`)
+	if err != nil {
+		return c, err
+	}
+	for rows.Next() {
+		var p Provider
+		if err := rows.Scan(&p.ID, &p.Name, &p.Type, &p.BaseURL, &p.DefaultModel, &p.SupportsText, &p.SupportsVision, &p.Enabled, &p.Region, &p.RPMLimit, &p.TPMLimit,
+			&p.Circuit.WindowSize, &p.Circuit.FailureThreshold, &p.Circuit.CooldownSeconds, &p.Circuit.MinSamples,
+			&p.Circuit.HalfOpenRequests, &p.Circuit.CloseAfterSuccesses); err != nil {
+			rows.Close()
+			return c, err
+		}
+		c.Providers = append(c.Providers, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+
+	rows, err = s.DB.Query(ctx, `SELECT model, provider_type, context_length, max_output_tokens, supports_vision, supports_tools, ttft_slo_ms, latency_slo_ms, timeout_ms, free_requests_per_day, free_tokens_per_day FROM model_catalog WHERE archived_at IS NULL ORDER BY model`)
+	if err != nil {
+		return c, err
+	}
+	for rows.Next() {
+		var m ModelCatalog
+		if err := rows.Scan(&m.Model, &m.ProviderType, &m.ContextLength, &m.MaxOutputTokens, &m.SupportsVision, &m.SupportsTools, &m.TTFTSLOMS, &m.LatencySLOMS, &m.TimeoutMS, &m.FreeRequestsPerDay, &m.FreeTokensPerDay); err != nil {
+			rows.Close()
+			return c, err
+		}
+		c.Catalog = append(c.Catalog, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+
+	rows, err = s.DB.Query(ctx, `SELECT mp.model, mp.provider_id, p.name, mp.priority, mp.enabled FROM model_providers mp JOIN providers p ON p.id=mp.provider_id WHERE p.archived_at IS NULL ORDER BY mp.model, mp.priority`)
+	if err != nil {
+		return c, err
+	}
+	for rows.Next() {
+		var e ModelProviderEntry
+		if err := rows.Scan(&e.Model, &e.ProviderID, &e.ProviderName, &e.Priority, &e.Enabled); err != nil {
+			rows.Close()
+			return c, err
+		}
+		c.ModelProviders = append(c.ModelProviders, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+
+	if c.Pricing, err = s.ListModelPricing(ctx); err != nil {
+		return c, err
+	}
+	c.Rules, err = s.ListRoutingRules(ctx)
+	return c, err
+}
+
+// ApplyRoutingConfig writes c in one transaction. Providers, catalog entries,
+// pricing and rules are upserted by id or model; every catalog model's
+// provider list is replaced with the entries c gives it. Existing provider
+// keys are kept and new providers are created without one. Archived providers
+// and models that c declares are restored. With prune, catalog models absent
+// from c are archived and pricing and rules absent from c are deleted;
+// providers are left alone.
+func (s *Store) ApplyRoutingConfig(ctx context.Context, c RoutingConfig, prune bool) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, p := range c.Providers {
+		if _, err := tx.Exec(ctx, `INSERT INTO providers (id, name, type, base_url, default_model, supports_text, supports_vision, enabled, region, rpm_limit, tpm_limit,
+			circuit_window, circuit_threshold, circuit_cooldown_seconds, circuit_min_samples, circuit_half_open_requests, circuit_close_successes)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
+		ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name, type=EXCLUDED.type, base_url=EXCLUDED.base_url, default_model=EXCLUDED.default_model, supports_text=EXCLUDED.supports_text, supports_vision=EXCLUDED.supports_vision, enabled=EXCLUDED.enabled,
+			region=EXCLUDED.region, rpm_limit=EXCLUDED.rpm_limit, tpm_limit=EXCLUDED.tpm_limit,
+			circuit_window=EXCLUDED.circuit_window, circuit_threshold=EXCLUDED.circuit_threshold, circuit_cooldown_seconds=EXCLUDED.circuit_cooldown_seconds, circuit_min_samples=EXCLUDED.circuit_min_samples,
+			circuit_half_open_requests=EXCLUDED.circuit_half_open_requests, circuit_close_successes=EXCLUDED.circuit_close_successes,
+			archived_at=NULL, archived_by=''`,
+			p.ID, p.Name, p.Type, p.BaseURL, p.DefaultModel, p.SupportsText, p.SupportsVision, p.Enabled, p.Region, p.RPMLimit, p.TPMLimit,
+			p.Circuit.WindowSize, p.Circuit.FailureThreshold, p.Circuit.CooldownSeconds, p.Circuit.MinSamples,
+			p.Circuit.HalfOpenRequests, p.Circuit.CloseAfterSuccesses); err != nil {
+			return err
+		}
+	}
+
+	models := make([]string, 0, len(c.Catalog))
+	for _, m := range c.Catalog {
+		if _, err := tx.Exec(ctx, `INSERT INTO model_catalog (model, provider_type, context_length, max_output_tokens, supports_vision, supports_tools, ttft_slo_ms, latency_slo_ms, timeout_ms, free_requests_per_day, free_tokens_per_day) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+			ON CONFLICT (model) DO UPDATE SET provider_type=EXCLUDED.provider_type, context_length=EXCLUDED.context_length, max_output_tokens=EXCLUDED.max_output_tokens,
+			supports_vision=EXCLUDED.supports_vision, supports_tools=EXCLUDED.supports_tools,
+			ttft_slo_ms=EXCLUDED.ttft_slo_ms, latency_slo_ms=EXCLUDED.latency_slo_ms, timeout_ms=EXCLUDED.timeout_ms,
+			free_requests_per_day=EXCLUDED.free_requests_per_day, free_tokens_per_day=EXCLUDED.free_tokens_per_day, archived_at=NULL, archived_by=''`,
+			m.Model, m.ProviderType, m.ContextLength, m.MaxOutputTokens, m.SupportsVision, m.SupportsTools, m.TTFTSLOMS, m.LatencySLOMS, m.TimeoutMS, m.FreeRequestsPerDay, m.FreeTokensPerDay); err != nil {
+			return err
+		}
+		models = append(models, m.Model)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM model_providers WHERE model = ANY($1)`, models); err != nil {
+		return err
+	}
+	priority := map[string]int{}
+	for _, e := range c.ModelProviders {
+		if _, err := tx.Exec(ctx, `INSERT INTO model_providers (model, provider_id, priority, enabled) VALUES ($1,$2,$3,$4)`, e.Model, e.ProviderID, priority[e.Model], e.Enabled); err != nil {
+			return err
+		}
+		priority[e.Model]++
+	}
+
+	prices := make([]string, 0, len(c.Pricing))
+	for _, m := range c.Pricing {
+		if _, err := tx.Exec(ctx, `INSERT INTO model_pricing (model, price_per_1k_usd) VALUES ($1,$2) ON CONFLICT (model) DO UPDATE SET price_per_1k_usd=EXCLUDED.price_per_1k_usd`, m.Model, m.PricePer1KUSD); err != nil {
+			return err
+		}
+		prices = append(prices, m.Model)
+	}
+
+	rules := make([]string, 0, len(c.Rules))
+	for _, r := range c.Rules {
+		args, err := routingRuleArgs(r)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, upsertRoutingRuleSQL, args...); err != nil {
+			return err
+		}
+		rules = append(rules, r.ID)
+	}
+
+	if prune {
+		if _, err := tx.Exec(ctx, `UPDATE model_catalog SET archived_at=NOW(), archived_by='routing config' WHERE NOT (model = ANY($1)) AND archived_at IS NULL`, models); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM model_pricing WHERE NOT (model = ANY($1))`, prices); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM routing_rules WHERE NOT (id = ANY($1))`, rules); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RoutingRule sends a tenant's matching requests to an ordered list of
+// providers. A rule matches when every set condition holds; among matching
+// rules the highest priority is tried first.
+type RoutingRule struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	// Capability is "text", "vision" or empty for both.
+	Capability  string   `json:"capability"`
+	ProviderIDs []string `json:"provider_ids"`
+	// PrimaryProviderID and SecondaryProviderID mirror the first two
+	// ProviderIDs for clients of the two-slot API.
+	PrimaryProviderID   string `json:"primary_provider_id"`
+	SecondaryProviderID string `json:"secondary_provider_id"`
+	// Model is used when the request names no model.
+	Model    string `json:"model"`
+	Priority int    `json:"priority"`
+	// ModelPattern is a glob ("gpt-4*") the requested model must match.
+	ModelPattern     string            `json:"model_pattern"`
+	MinContextTokens int               `json:"min_context_tokens"`
+	RequiresTools    bool              `json:"requires_tools"`
+	Tags             map[string]string `json:"tags"`
+	Enabled          bool              `json:"enabled"`
+}
+
+const routingRuleColumns = `id, tenant_id, capability, provider_ids, COALESCE(model,''), priority, model_pattern, min_context_tokens, requires_tools, match_tags, enabled`
+
+func scanRoutingRule(row pgx.Row) (RoutingRule, error) {
+	var r RoutingRule
+	var tags []byte
+	if err := row.Scan(&r.ID, &r.TenantID, &r.Capability, &r.ProviderIDs, &r.Model, &r.Priority, &r.ModelPattern, &r.MinContextTokens, &r.RequiresTools, &tags, &r.Enabled); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(tags, &r.Tags); err != nil {
+		return r, err
+	}
+	r.mirrorSlots()
+	return r, nil
+}
+
+func (r *RoutingRule) mirrorSlots() {
+	r.PrimaryProviderID, r.SecondaryProviderID = "", ""
+	if len(r.ProviderIDs) > 0 {
+		r.PrimaryProviderID = r.ProviderIDs[0]
+	}
+	if len(r.ProviderIDs) > 1 {
+		r.SecondaryProviderID = r.ProviderIDs[1]
+	}
+}
+
+func (s *Store) queryRoutingRules(ctx context.Context, query string, args ...interface{}) ([]RoutingRule, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rules []RoutingRule
+	for rows.Next() {
+		r, err := scanRoutingRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+func (s *Store) ListRoutingRules(ctx context.Context) ([]RoutingRule, error) {
+	return s.queryRoutingRules(ctx, `SELECT `+routingRuleColumns+` FROM routing_rules ORDER BY tenant_id, priority DESC, id`)
+}
+
+// ListRoutingRulesByTenant returns the tenant's rules, highest priority first.
+func (s *Store) ListRoutingRulesByTenant(ctx context.Context, tenantID string) ([]RoutingRule, error) {
+	return s.queryRoutingRules(ctx, `SELECT `+routingRuleColumns+` FROM routing_rules WHERE tenant_id=$1 ORDER BY priority DESC, id`, tenantID)
+}
+
+func (s *Store) GetRoutingRuleByID(ctx context.Context, id string) (*RoutingRule, error) {
+	r, err := scanRoutingRule(s.DB.QueryRow(ctx, `SELECT `+routingRuleColumns+` FROM routing_rules WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+const upsertRoutingRuleSQL = `INSERT INTO routing_rules (id, tenant_id, capability, provider_ids, primary_provider_id, secondary_provider_id, model, priority, model_pattern, min_context_tokens, requires_tools, match_tags, enabled)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+	ON CONFLICT (id) DO UPDATE SET tenant_id=EXCLUDED.tenant_id, capability=EXCLUDED.capability, provider_ids=EXCLUDED.provider_ids,
+		primary_provider_id=EXCLUDED.primary_provider_id, secondary_provider_id=EXCLUDED.secondary_provider_id, model=EXCLUDED.model,
+		priority=EXCLUDED.priority, model_pattern=EXCLUDED.model_pattern, min_context_tokens=EXCLUDED.min_context_tokens,
+		requires_tools=EXCLUDED.requires_tools, match_tags=EXCLUDED.match_tags, enabled=EXCLUDED.enabled`
+
+func (s *Store) UpsertRoutingRule(ctx context.Context, r RoutingRule) error {
+	args, err := routingRuleArgs(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, upsertRoutingRuleSQL, args...)
+	return err
+}
+
+// routingRuleArgs returns the upsertRoutingRuleSQL arguments for r.
+func routingRuleArgs(r RoutingRule) ([]interface{}, error) {
+	if r.TenantID == "" {
+		return nil, errors.New("tenant_id required")
+	}
+	if r.ProviderIDs == nil {
+		r.ProviderIDs = []string{}
+	}
+	tags := r.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	r.mirrorSlots()
+	var primary, secondary interface{}
+	if r.PrimaryProviderID != "" {
+		primary = r.PrimaryProviderID
+	}
+	if r.SecondaryProviderID != "" {
+		secondary = r.SecondaryProviderID
+	}
+	return []interface{}{r.ID, r.TenantID, r.Capability, r.ProviderIDs, primary, secondary, r.Model, r.Priority, r.ModelPattern, r.MinContextTokens, r.RequiresTools, tags, r.Enabled}, nil
+}
+
+func (s *Store) DeleteRoutingRule(ctx context.Context, id string) error {
+	_, err := s.DB.Exec(ctx, `DELETE FROM routing_rules WHERE id=$1`, id)
+	return err
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadySetUp is returned by Bootstrap once an admin user exists.
+var ErrAlreadySetUp = errors.New("already set up: an admin user exists")
+
+// NeedsSetup reports whether the database has no admin user yet.
+func (s *Store) NeedsSetup(ctx context.Context) (bool, error) {
+	var exists bool
+	err := s.DB.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_users)`).Scan(&exists)
+	return !exists, err
+}
+
+// Bootstrap creates the first admin user together with a tenant, its owner
+// and an API key, in one transaction. It fails with ErrAlreadySetUp when an
+// admin user exists; the table lock makes concurrent attempts serialize so
+// only one can win.
+func (s *Store) Bootstrap(ctx context.Context, admin AdminUser, t Tenant, owner TenantUser, key APIKey) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `LOCK TABLE admin_users IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM admin_users)`).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrAlreadySetUp
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO admin_users (id, username, password_hash) VALUES ($1,$2,$3)`, admin.ID, admin.Username, admin.PasswordHash); err != nil {
+		return err
+	}
+	if err := createTenantAccount(ctx, tx, t, owner, &key); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Spend alert periods.
+const (
+	SpendPeriodDaily   = "daily"
+	SpendPeriodMonthly = "monthly"
+)
+
+// Spend alert kinds. An absolute alert's threshold is in USD; a
+// balance_percent alert's is a percentage of the balance available for the
+// period (current balance plus what the period has spent so far).
+const (
+	SpendAlertAbsolute       = "absolute"
+	SpendAlertBalancePercent = "balance_percent"
+)
+
+// Spend alert channels. Webhook alerts go to the tenant's own webhooks
+// subscribed to spend.alert.
+const (
+	SpendAlertChannelEmail   = "email"
+	SpendAlertChannelWebhook = "webhook"
+)
+
+// TenantSpendAlert fires once per period when a tenant's spend for the day or
+// month crosses its threshold. Target is the email address for email alerts.
+type TenantSpendAlert struct {
+	ID              string     `json:"id"`
+	TenantID        string     `json:"tenant_id"`
+	Period          string     `json:"period"`
+	Kind            string     `json:"kind"`
+	Threshold       float64    `json:"threshold"`
+	Channel         string     `json:"channel"`
+	Target          string     `json:"target,omitempty"`
+	Enabled         bool       `json:"enabled"`
+	LastFiredPeriod string     `json:"last_fired_period,omitempty"`
+	LastFiredAt     *time.Time `json:"last_fired_at"`
+	LastNotifyError string     `json:"last_notify_error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+const spendAlertColumns = `id, tenant_id, period, kind, threshold, channel, target, enabled, last_fired_period, last_fired_at, last_notify_error, created_at, updated_at`
+
+func (s *Store) querySpendAlerts(ctx context.Context, query string, args ...interface{}) ([]TenantSpendAlert, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TenantSpendAlert
+	for rows.Next() {
+		var a TenantSpendAlert
+		if err := rows.Scan(&a.ID, &a.TenantID, &a.Period, &a.Kind, &a.Threshold, &a.Channel, &a.Target, &a.Enabled, &a.LastFiredPeriod, &a.LastFiredAt, &a.LastNotifyError, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListTenantSpendAlerts(ctx context.Context, tenantID string) ([]TenantSpendAlert, error) {
+	return s.querySpendAlerts(ctx, `SELECT `+spendAlertColumns+` FROM tenant_spend_alerts WHERE tenant_id=$1 ORDER BY created_at`, tenantID)
+}
+
+// ListEnabledSpendAlerts returns the enabled alerts of one tenant, or of every
+// tenant when tenantID is empty.
+func (s *Store) ListEnabledSpendAlerts(ctx context.Context, tenantID string) ([]TenantSpendAlert, error) {
+	return s.querySpendAlerts(ctx, `SELECT `+spendAlertColumns+` FROM tenant_spend_alerts WHERE enabled AND ($1 = '' OR tenant_id=$1) ORDER BY tenant_id, created_at`, tenantID)
+}
+
+func (s *Store) GetTenantSpendAlert(ctx context.Context, tenantID, id string) (*TenantSpendAlert, error) {
+	alerts, err := s.querySpendAlerts(ctx, `SELECT `+spendAlertColumns+` FROM tenant_spend_alerts WHERE id=$1 AND tenant_id=$2`, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &alerts[0], nil
+}
+
+func (s *Store) CreateTenantSpendAlert(ctx context.Context, a TenantSpendAlert) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO tenant_spend_alerts (id, tenant_id, period, kind, threshold, channel, target, enabled)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+		a.ID, a.TenantID, a.Period, a.Kind, a.Threshold, a.Channel, a.Target, a.Enabled)
+	return err
+}
+
+// UpdateTenantSpendAlert replaces an alert's definition. Changing it re-arms
+// the alert for the current period.
+func (s *Store) UpdateTenantSpendAlert(ctx context.Context, a TenantSpendAlert) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE tenant_spend_alerts SET period=$3, kind=$4, threshold=$5, channel=$6, target=$7, enabled=$8, last_fired_period='', updated_at=NOW()
+		WHERE id=$1 AND tenant_id=$2`,
+		a.ID, a.TenantID, a.Period, a.Kind, a.Threshold, a.Channel, a.Target, a.Enabled)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Store) DeleteTenantSpendAlert(ctx context.Context, tenantID, id string) error {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM tenant_spend_alerts WHERE id=$1 AND tenant_id=$2`, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimSpendAlert marks an alert fired for period unless it already fired in
+// it. It reports false when the alert already fired or another instance got
+// there first, so each alert notifies once per period.
+func (s *Store) ClaimSpendAlert(ctx context.Context, id, period string, at time.Time) (bool, error) {
+	tag, err := s.DB.Exec(ctx, `UPDATE tenant_spend_alerts SET last_fired_period=$2, last_fired_at=$3 WHERE id=$1 AND last_fired_period <> $2`, id, period, at)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// SetSpendAlertNotifyError records the outcome of an alert's last
+// notification; an empty message clears it.
+func (s *Store) SetSpendAlertNotifyError(ctx context.Context, id, msg string) error {
+	_, err := s.DB.Exec(ctx, `UPDATE tenant_spend_alerts SET last_notify_error=$2 WHERE id=$1`, id, msg)
+	return err
+}
+
+// TenantSpendSince is what the tenant was billed from day (UTC) onwards.
+func (s *Store) TenantSpendSince(ctx context.Context, tenantID string, day time.Time) (float64, error) {
+	var spent float64
+	err := s.DB.QueryRow(ctx, `SELECT COALESCE(SUM(cost_usd),0)::float8 FROM usage_daily WHERE tenant_id=$1 AND day>=$2::date`, tenantID, day.UTC().Format("2006-01-02")).Scan(&spent)
+	return spent, err
+}
@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"routerx/internal/models"
+	"routerx/internal/secrets"
 )
 
 type Store struct {
 	DB *pgxpool.Pool
+	// Keys encrypts provider API keys at rest. When nil, keys are stored as
+	// plaintext (and encrypted values cannot be read).
+	Keys *secrets.Keyring
 }
 
 type Provider struct {
@@ -25,15 +30,64 @@ type Provider struct {
 	SupportsText   bool   `json:"supports_text"`
 	SupportsVision bool   `json:"supports_vision"`
 	Enabled        bool   `json:"enabled"`
-}
-
-type RoutingRule struct {
-	ID                  string `json:"id"`
-	TenantID            string `json:"tenant_id"`
-	Capability          string `json:"capability"`
-	PrimaryProviderID   string `json:"primary_provider_id"`
-	SecondaryProviderID string `json:"secondary_provider_id"`
-	Model               string `json:"model"`
+	HasStagedKey   bool   `json:"has_staged_api_key"`
+
+	// Region is where the upstream endpoint runs (e.g. "eu", "us-east"); empty
+	// when unknown. Tenants restricted to regions never reach unknown ones.
+	Region string `json:"region"`
+	// RPMLimit and TPMLimit are the upstream contract's requests and tokens
+	// per minute; routing skips the provider once either is used up. Zero
+	// means unlimited.
+	RPMLimit int `json:"rpm_limit"`
+	TPMLimit int `json:"tpm_limit"`
+	// Circuit tunes the provider's circuit breaker.
+	Circuit CircuitSettings `json:"circuit"`
+	// Maintenance takes the provider out of routing by hand;
+	// InMaintenanceWindow is set while a scheduled window is active.
+	Maintenance         bool `json:"maintenance"`
+	InMaintenanceWindow bool `json:"in_maintenance_window"`
+	// Network customizes how upstream calls reach the provider.
+	Network NetworkSettings `json:"network"`
+	// ArchivedAt is set while the provider is archived: kept for history
+	// but out of routing.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	ArchivedBy string     `json:"archived_by,omitempty"`
+}
+
+// UnderMaintenance reports whether routing should skip p for maintenance.
+func (p Provider) UnderMaintenance() bool {
+	return p.Maintenance || p.InMaintenanceWindow
+}
+
+// CircuitSettings tunes a provider's circuit breaker. Zero fields use the
+// router's defaults.
+type CircuitSettings struct {
+	// WindowSize is how many recent outcomes the failure rate is taken over.
+	WindowSize int `json:"window_size"`
+	// FailureThreshold is the failure rate (0-1] that opens the breaker.
+	FailureThreshold float64 `json:"failure_threshold"`
+	// CooldownSeconds is how long an open breaker rejects requests.
+	CooldownSeconds int `json:"cooldown_seconds"`
+	// MinSamples is how many outcomes must be seen before the breaker can open.
+	MinSamples int `json:"min_samples"`
+	// HalfOpenRequests is how many trial requests a half-open breaker lets
+	// through at once.
+	HalfOpenRequests int `json:"half_open_requests"`
+	// CloseAfterSuccesses is how many consecutive trial successes close it.
+	CloseAfterSuccesses int `json:"close_after_successes"`
+}
+
+// NetworkSettings customizes the HTTP client used for a provider. Empty
+// fields fall back to the process defaults (HTTPS_PROXY, system CAs).
+type NetworkSettings struct {
+	// ProxyURL is an http://, https:// or socks5:// egress proxy.
+	ProxyURL string `json:"proxy_url"`
+	// CABundle is PEM certificates trusted in addition to the system pool.
+	CABundle string `json:"ca_bundle"`
+	// ClientCert and ClientKey are a PEM mTLS client certificate and key.
+	ClientCert   string `json:"client_cert"`
+	ClientKey    string `json:"-"`
+	HasClientKey bool   `json:"has_client_key"`
 }
 
 type Tenant struct {
@@ -47,20 +101,58 @@ type Tenant struct {
 	TotalSpentUSD float64    `json:"total_spent_usd"`
 	RateLimitRPM  int        `json:"rate_limit_rpm"`
 	SpendLimitUSD float64    `json:"spend_limit_usd"`
+	Tier          string     `json:"tier"`
+	// CreditsUSD is the unexpired promotional credit, spent before
+	// BalanceUSD.
+	CreditsUSD float64 `json:"credits_usd"`
+	// OverdraftUSD is how far below zero BalanceUSD may go before requests
+	// are refused.
+	OverdraftUSD float64 `json:"overdraft_usd"`
+	// AutoSuspendReason is set while the tenant is suspended by the
+	// delinquency policy (SuspendNegativeBalance, SuspendAbuse).
+	AutoSuspendReason string `json:"auto_suspend_reason,omitempty"`
+}
+
+// AvailableUSD is what the tenant can spend: credits plus paid balance plus
+// its overdraft allowance.
+func (t *Tenant) AvailableUSD() float64 {
+	return t.CreditsUSD + t.BalanceUSD + t.OverdraftUSD
+}
+
+// Tenant tiers, lowest priority first. Brownout sheds load from the lower
+// tiers before touching premium traffic.
+const (
+	TierFree     = "free"
+	TierStandard = "standard"
+	TierPremium  = "premium"
+)
+
+func ValidTier(tier string) bool {
+	return tier == TierFree || tier == TierStandard || tier == TierPremium
 }
 
 type APIKey struct {
-	Key           string    `json:"key"`
-	TenantID      string    `json:"tenant_id"`
-	Name          string    `json:"name"`
-	AllowedModels []string  `json:"allowed_models"`
-	CreatedAt     time.Time `json:"created_at"`
+	Key           string     `json:"key"`
+	TenantID      string     `json:"tenant_id"`
+	Name          string     `json:"name"`
+	AllowedModels []string   `json:"allowed_models"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SigningKeyID  string     `json:"signing_key_id,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy     string     `json:"revoked_by,omitempty"`
+	RevokeReason  string     `json:"revoke_reason,omitempty"`
+	Requests30d   int64      `json:"requests_30d"`
+	Tokens30d     int64      `json:"tokens_30d"`
 }
 
 type AdminUser struct {
 	ID           string
 	Username     string
 	PasswordHash string
+	TOTPSecret   string
+	TOTPEnabled  bool
 }
 
 type TenantUser struct {
@@ -68,24 +160,54 @@ type TenantUser struct {
 	TenantID     string
 	Username     string
 	PasswordHash string
+	Role         string
+	Email        string
+	TOTPSecret   string
+	TOTPEnabled  bool
 }
 
 type ModelPricing struct {
-	Model        string  `json:"model"`
+	Model         string  `json:"model"`
 	PricePer1KUSD float64 `json:"price_per_1k_usd"`
 }
 
 type ModelCatalog struct {
 	Model        string `json:"model"`
 	ProviderType string `json:"provider_type"`
+
+	// Metadata advertised on /v1/models; zero means unknown.
+	ContextLength   int  `json:"context_length"`
+	MaxOutputTokens int  `json:"max_output_tokens"`
+	SupportsVision  bool `json:"supports_vision"`
+	SupportsTools   bool `json:"supports_tools"`
+
+	// Latency targets; zero means none. See ModelSLO.
+	TTFTSLOMS    int `json:"ttft_slo_ms"`
+	LatencySLOMS int `json:"latency_slo_ms"`
+	TimeoutMS    int `json:"timeout_ms"`
+
+	// Daily free allowance per tenant; zero means none. See FreeAllowance.
+	FreeRequestsPerDay int `json:"free_requests_per_day"`
+	FreeTokensPerDay   int `json:"free_tokens_per_day"`
+}
+
+// ModelSLO is a catalog model's latency targets. Routing deprioritizes
+// providers whose rolling time to first token or total latency for the model
+// exceeds TTFTMS or LatencyMS, and abandons an attempt after TimeoutMS (for
+// streams, if no event has arrived by then). Zero fields are unset.
+type ModelSLO struct {
+	Model     string `json:"model"`
+	TTFTMS    int    `json:"ttft_slo_ms"`
+	LatencyMS int    `json:"latency_slo_ms"`
+	TimeoutMS int    `json:"timeout_ms"`
 }
 
 type TenantRequestSummary struct {
-	TotalRequests int              `json:"total_requests"`
-	TotalTokens   int              `json:"total_tokens"`
-	TotalCostUSD  float64          `json:"total_cost_usd"`
-	Daily         []TenantDayUsage `json:"daily"`
-	Recent        []TenantDayUsage `json:"recent"`
+	TotalRequests int                      `json:"total_requests"`
+	TotalTokens   int                      `json:"total_tokens"`
+	TotalCostUSD  float64                  `json:"total_cost_usd"`
+	Daily         []TenantDayUsage         `json:"daily"`
+	Recent        []TenantDayUsage         `json:"recent"`
 	RecentModels  []TenantRecentModelUsage `json:"recent_models"`
 }
 
@@ -125,106 +247,120 @@ func New(db *pgxpool.Pool) *Store {
 }
 
 func (s *Store) GetTenantByAPIKey(ctx context.Context, key string) (*Tenant, error) {
-	row := s.DB.QueryRow(ctx, `SELECT t.id, t.name, t.balance_usd, t.created_at, t.last_active, t.suspended, t.total_topup_usd, t.total_spent_usd FROM api_keys k JOIN tenants t ON k.tenant_id=t.id WHERE k.key=$1`, key)
+	row := s.DB.QueryRow(ctx, `SELECT t.id, t.name, t.balance_usd, t.created_at, t.last_active, t.suspended, t.total_topup_usd, t.total_spent_usd, t.tier, `+liveCreditsSQL+`, t.overdraft_usd FROM api_keys k JOIN tenants t ON k.tenant_id=t.id WHERE k.key=$1 AND k.revoked_at IS NULL`, key)
 	var t Tenant
-	if err := row.Scan(&t.ID, &t.Name, &t.BalanceUSD, &t.CreatedAt, &t.LastActive, &t.Suspended, &t.TotalTopupUSD, &t.TotalSpentUSD); err != nil {
+	if err := row.Scan(&t.ID, &t.Name, &t.BalanceUSD, &t.CreatedAt, &t.LastActive, &t.Suspended, &t.TotalTopupUSD, &t.TotalSpentUSD, &t.Tier, &t.CreditsUSD, &t.OverdraftUSD); err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
 func (s *Store) GetAPIKey(ctx context.Context, key string) (*APIKey, error) {
-	row := s.DB.QueryRow(ctx, `SELECT key, tenant_id, COALESCE(name,''), COALESCE(allowed_models, ARRAY[]::text[]), created_at FROM api_keys WHERE key=$1`, key)
-	var k APIKey
-	if err := row.Scan(&k.Key, &k.TenantID, &k.Name, &k.AllowedModels, &k.CreatedAt); err != nil {
+	k, err := scanAPIKey(s.DB.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key=$1`, key))
+	if err != nil {
 		return nil, err
 	}
 	return &k, nil
 }
 
-func (s *Store) GetProviders(ctx context.Context) ([]Provider, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, name, type, COALESCE(base_url,''), COALESCE(api_key,''), default_model, supports_text, supports_vision, enabled FROM providers`)
+// providerColumns reads providers aliased as p, so joins can reuse it.
+const providerColumns = `p.id, p.name, p.type, COALESCE(p.base_url,''), COALESCE(p.api_key,''), COALESCE(p.default_model,''), p.supports_text, p.supports_vision, p.enabled, p.staged_api_key IS NOT NULL,
+	p.region, p.rpm_limit, p.tpm_limit, p.circuit_window, p.circuit_threshold, p.circuit_cooldown_seconds, p.circuit_min_samples,
+	p.circuit_half_open_requests, p.circuit_close_successes, p.maintenance,
+	EXISTS (SELECT 1 FROM provider_maintenance_windows mw WHERE mw.provider_id=p.id AND mw.starts_at <= now() AND mw.ends_at > now()),
+	p.proxy_url, p.ca_bundle, p.client_cert, p.client_key, p.archived_at, p.archived_by`
+
+// scanProvider reads a providerColumns row and decrypts its key.
+func (s *Store) scanProvider(row pgx.Row) (Provider, error) {
+	var p Provider
+	if err := row.Scan(&p.ID, &p.Name, &p.Type, &p.BaseURL, &p.APIKey, &p.DefaultModel, &p.SupportsText, &p.SupportsVision, &p.Enabled, &p.HasStagedKey,
+		&p.Region, &p.RPMLimit, &p.TPMLimit, &p.Circuit.WindowSize, &p.Circuit.FailureThreshold, &p.Circuit.CooldownSeconds, &p.Circuit.MinSamples,
+		&p.Circuit.HalfOpenRequests, &p.Circuit.CloseAfterSuccesses, &p.Maintenance, &p.InMaintenanceWindow,
+		&p.Network.ProxyURL, &p.Network.CABundle, &p.Network.ClientCert, &p.Network.ClientKey, &p.ArchivedAt, &p.ArchivedBy); err != nil {
+		return p, err
+	}
+	return p, s.openProvider(&p)
+}
+
+func (s *Store) queryProviders(ctx context.Context, query string, args ...interface{}) ([]Provider, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var providers []Provider
 	for rows.Next() {
-		var p Provider
-		if err := rows.Scan(&p.ID, &p.Name, &p.Type, &p.BaseURL, &p.APIKey, &p.DefaultModel, &p.SupportsText, &p.SupportsVision, &p.Enabled); err != nil {
+		p, err := s.scanProvider(rows)
+		if err != nil {
 			return nil, err
 		}
-		p.HasAPIKey = p.APIKey != ""
 		providers = append(providers, p)
 	}
 	return providers, rows.Err()
 }
 
+func (s *Store) GetProviders(ctx context.Context) ([]Provider, error) {
+	return s.queryProviders(ctx, `SELECT `+providerColumns+` FROM providers p`)
+}
+
 func (s *Store) GetProviderByID(ctx context.Context, id string) (*Provider, error) {
-	row := s.DB.QueryRow(ctx, `SELECT id, name, type, COALESCE(base_url,''), COALESCE(api_key,''), default_model, supports_text, supports_vision, enabled FROM providers WHERE id=$1`, id)
-	var p Provider
-	if err := row.Scan(&p.ID, &p.Name, &p.Type, &p.BaseURL, &p.APIKey, &p.DefaultModel, &p.SupportsText, &p.SupportsVision, &p.Enabled); err != nil {
+	p, err := s.scanProvider(s.DB.QueryRow(ctx, `SELECT `+providerColumns+` FROM providers p WHERE p.id=$1`, id))
+	if err != nil {
 		return nil, err
 	}
-	p.HasAPIKey = p.APIKey != ""
 	return &p, nil
 }
 
 func (s *Store) GetEnabledProvidersByType(ctx context.Context, providerType string) ([]Provider, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, name, type, COALESCE(base_url,''), COALESCE(api_key,''), default_model, supports_text, supports_vision, enabled FROM providers WHERE type=$1 AND enabled=true`, providerType)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var providers []Provider
-	for rows.Next() {
-		var p Provider
-		if err := rows.Scan(&p.ID, &p.Name, &p.Type, &p.BaseURL, &p.APIKey, &p.DefaultModel, &p.SupportsText, &p.SupportsVision, &p.Enabled); err != nil {
-			return nil, err
-		}
-		p.HasAPIKey = p.APIKey != ""
-		providers = append(providers, p)
-	}
-	return providers, rows.Err()
+	return s.queryProviders(ctx, `SELECT `+providerColumns+` FROM providers p WHERE p.type=$1 AND p.enabled=true AND p.archived_at IS NULL`, providerType)
 }
 
 func (s *Store) GetTenantByID(ctx context.Context, id string) (*Tenant, error) {
-	row := s.DB.QueryRow(ctx, `SELECT id, name, balance_usd, created_at, last_active, suspended, total_topup_usd, total_spent_usd, rate_limit_rpm, spend_limit_usd FROM tenants WHERE id=$1`, id)
+	row := s.DB.QueryRow(ctx, `SELECT id, name, balance_usd, created_at, last_active, suspended, auto_suspend_reason, total_topup_usd, total_spent_usd, rate_limit_rpm, spend_limit_usd, tier, `+liveCreditsSQL+`, overdraft_usd FROM tenants t WHERE id=$1`, id)
 	var t Tenant
-	if err := row.Scan(&t.ID, &t.Name, &t.BalanceUSD, &t.CreatedAt, &t.LastActive, &t.Suspended, &t.TotalTopupUSD, &t.TotalSpentUSD, &t.RateLimitRPM, &t.SpendLimitUSD); err != nil {
+	if err := row.Scan(&t.ID, &t.Name, &t.BalanceUSD, &t.CreatedAt, &t.LastActive, &t.Suspended, &t.AutoSuspendReason, &t.TotalTopupUSD, &t.TotalSpentUSD, &t.RateLimitRPM, &t.SpendLimitUSD, &t.Tier, &t.CreditsUSD, &t.OverdraftUSD); err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
-func (s *Store) GetRoutingRule(ctx context.Context, tenantID, capability string) (*RoutingRule, error) {
-	row := s.DB.QueryRow(ctx, `SELECT id, tenant_id, capability, primary_provider_id, secondary_provider_id, model FROM routing_rules WHERE tenant_id=$1 AND capability=$2 LIMIT 1`, tenantID, capability)
-	var r RoutingRule
-	if err := row.Scan(&r.ID, &r.TenantID, &r.Capability, &r.PrimaryProviderID, &r.SecondaryProviderID, &r.Model); err != nil {
-		return nil, err
-	}
-	return &r, nil
-}
-
 func (s *Store) InsertRequestLog(ctx context.Context, log models.RequestLog) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO request_logs (tenant_id, provider, model, latency_ms, ttft_ms, tokens, cost_usd, prompt_hash, fallback_used, status_code, error_code, user_id, app_title, app_referer, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)`,
-		log.TenantID, log.Provider, log.Model, log.LatencyMS, log.TTFTMS, log.Tokens, log.CostUSD, log.PromptHash, log.FallbackUsed, log.StatusCode, log.ErrorCode, log.UserID, log.AppTitle, log.AppReferer, log.CreatedAt)
+	metadata := log.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	attempts := log.Attempts
+	if attempts == nil {
+		attempts = []models.RouteAttempt{}
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO request_logs (tenant_id, provider, model, latency_ms, ttft_ms, tokens, cost_usd, prompt_hash, fallback_used, status_code, error_code, user_id, app_title, app_referer, experiment_id, experiment_variant, metadata, provider_cost_usd, billed_usd, key_owner, created_at, trace_id, queue_ms, stream, attempts, error_message) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26)`,
+		log.TenantID, log.Provider, log.Model, log.LatencyMS, log.TTFTMS, log.Tokens, log.CostUSD, log.PromptHash, log.FallbackUsed, log.StatusCode, log.ErrorCode, log.UserID, log.AppTitle, log.AppReferer, log.ExperimentID, log.Variant, metadata, log.ProviderCostUSD, log.BilledUSD, log.KeyOwner, log.CreatedAt,
+		log.TraceID, log.QueueMS, log.Stream, attempts, log.ErrorMessage)
+	if err != nil {
+		return err
+	}
+	fallbacks := 0
+	if log.FallbackUsed {
+		fallbacks = 1
+	}
+	_, err = s.DB.Exec(ctx, `INSERT INTO request_stats_daily (day, requests, fallbacks) VALUES ($1::date, 1, $2)
+	ON CONFLICT (day) DO UPDATE SET requests = request_stats_daily.requests + 1, fallbacks = request_stats_daily.fallbacks + EXCLUDED.fallbacks`, log.CreatedAt.UTC().Format("2006-01-02"), fallbacks)
 	return err
 }
 
 func (s *Store) GetAdminByUsername(ctx context.Context, username string) (*AdminUser, error) {
-	row := s.DB.QueryRow(ctx, `SELECT id, username, password_hash FROM admin_users WHERE username=$1`, username)
+	row := s.DB.QueryRow(ctx, `SELECT id, username, password_hash, totp_secret, totp_enabled FROM admin_users WHERE username=$1`, username)
 	var u AdminUser
-	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash); err != nil {
+	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.TOTPSecret, &u.TOTPEnabled); err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
 
 func (s *Store) GetTenantUserByUsername(ctx context.Context, username string) (*TenantUser, error) {
-	row := s.DB.QueryRow(ctx, `SELECT id, tenant_id, username, password_hash FROM tenant_users WHERE username=$1`, username)
+	row := s.DB.QueryRow(ctx, `SELECT id, tenant_id, username, password_hash, role, email, totp_secret, totp_enabled FROM tenant_users WHERE username=$1`, username)
 	var u TenantUser
-	if err := row.Scan(&u.ID, &u.TenantID, &u.Username, &u.PasswordHash); err != nil {
+	if err := row.Scan(&u.ID, &u.TenantID, &u.Username, &u.PasswordHash, &u.Role, &u.Email, &u.TOTPSecret, &u.TOTPEnabled); err != nil {
 		return nil, err
 	}
 	return &u, nil
@@ -234,55 +370,122 @@ func (s *Store) ListProviders(ctx context.Context) ([]Provider, error) {
 	return s.GetProviders(ctx)
 }
 
-func (s *Store) ListRoutingRules(ctx context.Context) ([]RoutingRule, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, tenant_id, capability, primary_provider_id, secondary_provider_id, model FROM routing_rules`)
-	if err != nil {
+// TenantListFilters narrows and orders ListTenantsPaginated.
+type TenantListFilters struct {
+	// Search matches a substring of the tenant's name or id, ignoring case.
+	Search string
+	// From and To bound created_at as [From, To); zero leaves a side open.
+	From, To time.Time
+	// BalanceBelow and SpendAbove bound balance_usd and total_spent_usd
+	// when set.
+	BalanceBelow *float64
+	SpendAbove   *float64
+	// InactiveDays, when positive, keeps tenants with no request in that
+	// many days, including those never active.
+	InactiveDays int
+	// Suspended is "true", "false" or empty for either.
+	Suspended string
+	// SortBy is created_at (the default), name, spend, balance or
+	// last_active. Tenants never active sort last either way.
+	SortBy  string
+	SortDir string
+}
+
+type PaginatedTenants struct {
+	Data     []Tenant `json:"data"`
+	Total    int      `json:"total"`
+	Page     int      `json:"page"`
+	PageSize int      `json:"page_size"`
+}
+
+func (s *Store) ListTenantsPaginated(ctx context.Context, page, pageSize int, f TenantListFilters) (*PaginatedTenants, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	where := "WHERE 1=1"
+	args := []interface{}{}
+	argN := 1
+
+	if f.Search != "" {
+		where += fmt.Sprintf(" AND (name ILIKE $%d OR id ILIKE $%d)", argN, argN)
+		args = append(args, "%"+f.Search+"%")
+		argN++
+	}
+	if !f.From.IsZero() {
+		where += fmt.Sprintf(" AND created_at >= $%d", argN)
+		args = append(args, f.From)
+		argN++
+	}
+	if !f.To.IsZero() {
+		where += fmt.Sprintf(" AND created_at < $%d", argN)
+		args = append(args, f.To)
+		argN++
+	}
+	if f.BalanceBelow != nil {
+		where += fmt.Sprintf(" AND balance_usd < $%d", argN)
+		args = append(args, *f.BalanceBelow)
+		argN++
+	}
+	if f.SpendAbove != nil {
+		where += fmt.Sprintf(" AND total_spent_usd > $%d", argN)
+		args = append(args, *f.SpendAbove)
+		argN++
+	}
+	if f.InactiveDays > 0 {
+		where += fmt.Sprintf(" AND (last_active IS NULL OR last_active < NOW() - make_interval(days => $%d))", argN)
+		args = append(args, f.InactiveDays)
+		argN++
+	}
+	switch f.Suspended {
+	case "true":
+		where += " AND suspended"
+	case "false":
+		where += " AND NOT suspended"
+	}
+
+	var total int
+	if err := s.DB.QueryRow(ctx, "SELECT COUNT(*) FROM tenants "+where, args...).Scan(&total); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	var rules []RoutingRule
-	for rows.Next() {
-		var r RoutingRule
-		if err := rows.Scan(&r.ID, &r.TenantID, &r.Capability, &r.PrimaryProviderID, &r.SecondaryProviderID, &r.Model); err != nil {
-			return nil, err
-		}
-		rules = append(rules, r)
+
+	sortCol := "created_at"
+	switch f.SortBy {
+	case "name", "last_active":
+		sortCol = f.SortBy
+	case "spend":
+		sortCol = "total_spent_usd"
+	case "balance":
+		sortCol = "balance_usd"
+	}
+	sortDir := "DESC"
+	if f.SortDir == "asc" {
+		sortDir = "ASC"
 	}
-	return rules, rows.Err()
-}
 
-func (s *Store) ListTenants(ctx context.Context) ([]Tenant, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, name, balance_usd, created_at, last_active, suspended, total_topup_usd, total_spent_usd, rate_limit_rpm, spend_limit_usd FROM tenants ORDER BY created_at DESC`)
+	offset := (page - 1) * pageSize
+	rows, err := s.DB.Query(ctx, fmt.Sprintf(`SELECT id, name, balance_usd, created_at, last_active, suspended, auto_suspend_reason, total_topup_usd, total_spent_usd, rate_limit_rpm, spend_limit_usd, tier, `+liveCreditsSQL+`, overdraft_usd
+		FROM tenants t %s ORDER BY %s %s NULLS LAST, id LIMIT $%d OFFSET $%d`, where, sortCol, sortDir, argN, argN+1), append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var tenants []Tenant
+	tenants := []Tenant{}
 	for rows.Next() {
 		var t Tenant
-		if err := rows.Scan(&t.ID, &t.Name, &t.BalanceUSD, &t.CreatedAt, &t.LastActive, &t.Suspended, &t.TotalTopupUSD, &t.TotalSpentUSD, &t.RateLimitRPM, &t.SpendLimitUSD); err != nil {
+		if err := rows.Scan(&t.ID, &t.Name, &t.BalanceUSD, &t.CreatedAt, &t.LastActive, &t.Suspended, &t.AutoSuspendReason, &t.TotalTopupUSD, &t.TotalSpentUSD, &t.RateLimitRPM, &t.SpendLimitUSD, &t.Tier, &t.CreditsUSD, &t.OverdraftUSD); err != nil {
 			return nil, err
 		}
 		tenants = append(tenants, t)
 	}
-	return tenants, rows.Err()
+	return &PaginatedTenants{Data: tenants, Total: total, Page: page, PageSize: pageSize}, rows.Err()
 }
 
 func (s *Store) ListAPIKeysByTenant(ctx context.Context, tenantID string) ([]APIKey, error) {
-	rows, err := s.DB.Query(ctx, `SELECT key, tenant_id, COALESCE(name,''), COALESCE(allowed_models, ARRAY[]::text[]), created_at FROM api_keys WHERE tenant_id=$1 ORDER BY created_at DESC`, tenantID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var keys []APIKey
-	for rows.Next() {
-		var k APIKey
-		if err := rows.Scan(&k.Key, &k.TenantID, &k.Name, &k.AllowedModels, &k.CreatedAt); err != nil {
-			return nil, err
-		}
-		keys = append(keys, k)
-	}
-	return keys, rows.Err()
+	return s.queryAPIKeys(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE tenant_id=$1 ORDER BY created_at DESC`, tenantID)
 }
 
 func (s *Store) ListRequestLogs(ctx context.Context, limit int) ([]models.RequestLog, error) {
@@ -303,9 +506,11 @@ func (s *Store) ListRequestLogs(ctx context.Context, limit int) ([]models.Reques
 }
 
 func (s *Store) GetRequestLog(ctx context.Context, id int) (*models.RequestLog, error) {
-	row := s.DB.QueryRow(ctx, `SELECT id, tenant_id, provider, model, latency_ms, ttft_ms, tokens, cost_usd, prompt_hash, fallback_used, status_code, error_code, user_id, app_title, app_referer, created_at FROM request_logs WHERE id=$1`, id)
+	row := s.DB.QueryRow(ctx, `SELECT id, tenant_id, provider, model, latency_ms, ttft_ms, tokens, cost_usd, prompt_hash, fallback_used, status_code, error_code, user_id, app_title, app_referer, experiment_id, experiment_variant, metadata, provider_cost_usd, billed_usd, key_owner, created_at,
+		trace_id, queue_ms, stream, attempts, error_message FROM request_logs WHERE id=$1`, id)
 	var r models.RequestLog
-	if err := row.Scan(&r.ID, &r.TenantID, &r.Provider, &r.Model, &r.LatencyMS, &r.TTFTMS, &r.Tokens, &r.CostUSD, &r.PromptHash, &r.FallbackUsed, &r.StatusCode, &r.ErrorCode, &r.UserID, &r.AppTitle, &r.AppReferer, &r.CreatedAt); err != nil {
+	if err := row.Scan(&r.ID, &r.TenantID, &r.Provider, &r.Model, &r.LatencyMS, &r.TTFTMS, &r.Tokens, &r.CostUSD, &r.PromptHash, &r.FallbackUsed, &r.StatusCode, &r.ErrorCode, &r.UserID, &r.AppTitle, &r.AppReferer, &r.ExperimentID, &r.Variant, &r.Metadata, &r.ProviderCostUSD, &r.BilledUSD, &r.KeyOwner, &r.CreatedAt,
+		&r.TraceID, &r.QueueMS, &r.Stream, &r.Attempts, &r.ErrorMessage); err != nil {
 		return nil, err
 	}
 	return &r, nil
@@ -317,42 +522,149 @@ func (s *Store) DeleteRequestLog(ctx context.Context, id int) error {
 }
 
 func (s *Store) UpsertProvider(ctx context.Context, p Provider) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO providers (id, name, type, base_url, api_key, default_model, supports_text, supports_vision, enabled)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
-	ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name, type=EXCLUDED.type, base_url=EXCLUDED.base_url, api_key=EXCLUDED.api_key, default_model=EXCLUDED.default_model, supports_text=EXCLUDED.supports_text, supports_vision=EXCLUDED.supports_vision, enabled=EXCLUDED.enabled`,
-		p.ID, p.Name, p.Type, p.BaseURL, p.APIKey, p.DefaultModel, p.SupportsText, p.SupportsVision, p.Enabled)
+	apiKey, err := s.sealProviderKey(p.ID, p.APIKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `INSERT INTO providers (id, name, type, base_url, api_key, default_model, supports_text, supports_vision, enabled, region)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+	ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name, type=EXCLUDED.type, base_url=EXCLUDED.base_url, api_key=EXCLUDED.api_key, default_model=EXCLUDED.default_model, supports_text=EXCLUDED.supports_text, supports_vision=EXCLUDED.supports_vision, enabled=EXCLUDED.enabled, region=EXCLUDED.region`,
+		p.ID, p.Name, p.Type, p.BaseURL, apiKey, p.DefaultModel, p.SupportsText, p.SupportsVision, p.Enabled, p.Region)
 	return err
 }
 
 func (s *Store) UpdateProvider(ctx context.Context, p Provider) error {
-	_, err := s.DB.Exec(ctx, `UPDATE providers SET base_url=$2, api_key=$3, default_model=$4, supports_text=$5, supports_vision=$6, enabled=$7 WHERE id=$1`,
-		p.ID, p.BaseURL, p.APIKey, p.DefaultModel, p.SupportsText, p.SupportsVision, p.Enabled)
+	apiKey, err := s.sealProviderKey(p.ID, p.APIKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `UPDATE providers SET base_url=$2, api_key=$3, default_model=$4, supports_text=$5, supports_vision=$6, enabled=$7, region=$8 WHERE id=$1`,
+		p.ID, p.BaseURL, apiKey, p.DefaultModel, p.SupportsText, p.SupportsVision, p.Enabled, p.Region)
 	return err
 }
 
 func (s *Store) UpdateProviderAPIKey(ctx context.Context, id, apiKey string) error {
-	_, err := s.DB.Exec(ctx, `UPDATE providers SET api_key=$2 WHERE id=$1`, id, apiKey)
+	sealed, err := s.sealProviderKey(id, apiKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `UPDATE providers SET api_key=$2 WHERE id=$1`, id, sealed)
 	return err
 }
 
-func (s *Store) UpsertRoutingRule(ctx context.Context, r RoutingRule) error {
-	if r.TenantID == "" {
-		return errors.New("tenant_id required")
+// SetProviderRateBudget sets a provider's requests- and tokens-per-minute
+// budget; zero removes a limit.
+func (s *Store) SetProviderRateBudget(ctx context.Context, id string, rpm, tpm int) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET rpm_limit=$2, tpm_limit=$3 WHERE id=$1`, id, rpm, tpm)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// SetProviderNetwork stores a provider's proxy and TLS settings, sealing the
+// client key like an API key.
+func (s *Store) SetProviderNetwork(ctx context.Context, id string, n NetworkSettings) error {
+	clientKey, err := s.sealProviderKey(id, n.ClientKey)
+	if err != nil {
+		return err
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET proxy_url=$2, ca_bundle=$3, client_cert=$4, client_key=$5 WHERE id=$1`,
+		id, n.ProxyURL, n.CABundle, n.ClientCert, clientKey)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// SetProviderCircuitSettings stores a provider's circuit-breaker tuning.
+func (s *Store) SetProviderCircuitSettings(ctx context.Context, id string, c CircuitSettings) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET circuit_window=$2, circuit_threshold=$3, circuit_cooldown_seconds=$4, circuit_min_samples=$5,
+		circuit_half_open_requests=$6, circuit_close_successes=$7 WHERE id=$1`,
+		id, c.WindowSize, c.FailureThreshold, c.CooldownSeconds, c.MinSamples, c.HalfOpenRequests, c.CloseAfterSuccesses)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ---- Provider Key Rotation ----
+
+// StageProviderAPIKey stores a candidate key next to the live one without
+// affecting routing.
+func (s *Store) StageProviderAPIKey(ctx context.Context, id, apiKey string) error {
+	sealed, err := s.sealProviderKey(id, apiKey)
+	if err != nil {
+		return err
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET staged_api_key=$2 WHERE id=$1`, id, sealed)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("provider not found")
+	}
+	return nil
+}
+
+func (s *Store) GetStagedProviderAPIKey(ctx context.Context, id string) (string, error) {
+	row := s.DB.QueryRow(ctx, `SELECT COALESCE(staged_api_key,'') FROM providers WHERE id=$1`, id)
+	var key string
+	if err := row.Scan(&key); err != nil {
+		return "", err
 	}
-	_, err := s.DB.Exec(ctx, `INSERT INTO routing_rules (id, tenant_id, capability, primary_provider_id, secondary_provider_id, model)
-	VALUES ($1,$2,$3,$4,$5,$6)
-	ON CONFLICT (id) DO UPDATE SET tenant_id=EXCLUDED.tenant_id, capability=EXCLUDED.capability, primary_provider_id=EXCLUDED.primary_provider_id, secondary_provider_id=EXCLUDED.secondary_provider_id, model=EXCLUDED.model`,
-		r.ID, r.TenantID, r.Capability, r.PrimaryProviderID, r.SecondaryProviderID, r.Model)
+	return s.openProviderKey(id, key)
+}
+
+func (s *Store) ClearStagedProviderAPIKey(ctx context.Context, id string) error {
+	_, err := s.DB.Exec(ctx, `UPDATE providers SET staged_api_key=NULL WHERE id=$1`, id)
 	return err
 }
 
+// PromoteStagedProviderAPIKey swaps the staged key in as the live key in one
+// statement, keeping the old key for rollback.
+func (s *Store) PromoteStagedProviderAPIKey(ctx context.Context, id string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET previous_api_key=api_key, api_key=staged_api_key, staged_api_key=NULL, api_key_rotated_at=NOW() WHERE id=$1 AND staged_api_key IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("no staged api key")
+	}
+	return nil
+}
+
+// RollbackProviderAPIKey restores the key that was live before the last promotion.
+func (s *Store) RollbackProviderAPIKey(ctx context.Context, id string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE providers SET api_key=previous_api_key, previous_api_key=NULL, api_key_rotated_at=NOW() WHERE id=$1 AND previous_api_key IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("no previous api key")
+	}
+	return nil
+}
+
 func (s *Store) CreateTenant(ctx context.Context, t Tenant) error {
 	_, err := s.DB.Exec(ctx, `INSERT INTO tenants (id, name) VALUES ($1,$2) ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name`, t.ID, t.Name)
 	return err
 }
 
 func (s *Store) CreateTenantUser(ctx context.Context, u TenantUser) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO tenant_users (id, tenant_id, username, password_hash) VALUES ($1,$2,$3,$4)`, u.ID, u.TenantID, u.Username, u.PasswordHash)
+	if u.Role == "" {
+		u.Role = RoleOwner
+	}
+	_, err := s.DB.Exec(ctx, `INSERT INTO tenant_users (id, tenant_id, username, password_hash, role, email) VALUES ($1,$2,$3,$4,$5,$6)`, u.ID, u.TenantID, u.Username, u.PasswordHash, u.Role, u.Email)
 	return err
 }
 
@@ -360,10 +672,37 @@ func (s *Store) CreateAPIKey(ctx context.Context, k APIKey) error {
 	if k.CreatedAt.IsZero() {
 		k.CreatedAt = time.Now().UTC()
 	}
-	_, err := s.DB.Exec(ctx, `INSERT INTO api_keys (key, tenant_id, name, allowed_models, created_at) VALUES ($1,$2,$3,$4,$5) ON CONFLICT (key) DO NOTHING`, k.Key, k.TenantID, k.Name, k.AllowedModels, k.CreatedAt)
+	_, err := s.DB.Exec(ctx, `INSERT INTO api_keys (key, tenant_id, name, allowed_models, created_at, created_by) VALUES ($1,$2,$3,$4,$5,$6) ON CONFLICT (key) DO NOTHING`, k.Key, k.TenantID, k.Name, k.AllowedModels, k.CreatedAt, k.CreatedBy)
 	return err
 }
 
+// SetAPIKeySigningSecret attaches (or, with empty values, removes) an HMAC
+// signing credential on one of the tenant's API keys.
+func (s *Store) SetAPIKeySigningSecret(ctx context.Context, tenantID, key, keyID, secret string) error {
+	var id, sec interface{}
+	if keyID != "" {
+		id, sec = keyID, secret
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE api_keys SET signing_key_id=$3, signing_secret=$4 WHERE key=$1 AND tenant_id=$2`, key, tenantID, id, sec)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
+
+// GetSigningSecret resolves a signing key ID to its API key and shared secret.
+func (s *Store) GetSigningSecret(ctx context.Context, keyID string) (string, string, error) {
+	row := s.DB.QueryRow(ctx, `SELECT key, signing_secret FROM api_keys WHERE signing_key_id=$1 AND signing_secret IS NOT NULL AND revoked_at IS NULL`, keyID)
+	var key, secret string
+	if err := row.Scan(&key, &secret); err != nil {
+		return "", "", err
+	}
+	return key, secret, nil
+}
+
 func (s *Store) DeleteAPIKey(ctx context.Context, tenantID, key string) error {
 	_, err := s.DB.Exec(ctx, `DELETE FROM api_keys WHERE key=$1 AND tenant_id=$2`, key, tenantID)
 	return err
@@ -375,6 +714,13 @@ func (s *Store) RecordUsageDaily(ctx context.Context, tenantID, provider, model
 	return err
 }
 
+// TenantSpendOn is what the tenant was billed on day (UTC).
+func (s *Store) TenantSpendOn(ctx context.Context, tenantID string, day time.Time) (float64, error) {
+	var spent float64
+	err := s.DB.QueryRow(ctx, `SELECT COALESCE(SUM(cost_usd),0)::float8 FROM usage_daily WHERE tenant_id=$1 AND day=$2::date`, tenantID, day.UTC().Format("2006-01-02")).Scan(&spent)
+	return spent, err
+}
+
 func (s *Store) AddUsageCost(ctx context.Context, tenantID, provider, model string, tokens int, cost float64, day time.Time) error {
 	_, err := s.DB.Exec(ctx, `INSERT INTO usage_daily (tenant_id, provider, model, day, tokens, cost_usd) VALUES ($1,$2,$3,$4,$5,$6)
 	ON CONFLICT (tenant_id, provider, model, day) DO UPDATE SET tokens = usage_daily.tokens + EXCLUDED.tokens, cost_usd = usage_daily.cost_usd + EXCLUDED.cost_usd`, tenantID, provider, model, day, tokens, cost)
@@ -390,6 +736,12 @@ func (s *Store) UpdateTenantBalance(ctx context.Context, tenantID string, balanc
 	return err
 }
 
+// AddTenantTopup adds amount to a tenant's lifetime top-up total.
+func (s *Store) AddTenantTopup(ctx context.Context, tenantID string, amount float64) error {
+	_, err := s.DB.Exec(ctx, `UPDATE tenants SET total_topup_usd = total_topup_usd + $2 WHERE id=$1`, tenantID, amount)
+	return err
+}
+
 func (s *Store) UpdateTenantLastActive(ctx context.Context, tenantID string, at time.Time) error {
 	_, err := s.DB.Exec(ctx, `UPDATE tenants SET last_active=$2 WHERE id=$1`, tenantID, at)
 	return err
@@ -427,7 +779,7 @@ func (s *Store) GetModelPrice(ctx context.Context, model string) (float64, bool,
 }
 
 func (s *Store) GetModelProvider(ctx context.Context, model string) (string, bool, error) {
-	row := s.DB.QueryRow(ctx, `SELECT provider_type FROM model_catalog WHERE model=$1`, model)
+	row := s.DB.QueryRow(ctx, `SELECT provider_type FROM model_catalog WHERE model=$1 AND archived_at IS NULL`, model)
 	var p string
 	if err := row.Scan(&p); err != nil {
 		return "", false, err
@@ -436,7 +788,7 @@ func (s *Store) GetModelProvider(ctx context.Context, model string) (string, boo
 }
 
 func (s *Store) ListModelsByProviderType(ctx context.Context, providerType string) ([]string, error) {
-	rows, err := s.DB.Query(ctx, `SELECT model FROM model_catalog WHERE provider_type=$1 ORDER BY model`, providerType)
+	rows, err := s.DB.Query(ctx, `SELECT model FROM model_catalog WHERE provider_type=$1 AND archived_at IS NULL ORDER BY model`, providerType)
 	if err != nil {
 		return nil, err
 	}
@@ -452,24 +804,61 @@ func (s *Store) ListModelsByProviderType(ctx context.Context, providerType strin
 	return models, rows.Err()
 }
 
-func (s *Store) AddModelCatalog(ctx context.Context, model, providerType string) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO model_catalog (model, provider_type) VALUES ($1,$2) ON CONFLICT (model) DO UPDATE SET provider_type=EXCLUDED.provider_type`, model, providerType)
+func (s *Store) AddModelCatalog(ctx context.Context, m ModelCatalog) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO model_catalog (model, provider_type, context_length, max_output_tokens, supports_vision, supports_tools, ttft_slo_ms, latency_slo_ms, timeout_ms, free_requests_per_day, free_tokens_per_day) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		ON CONFLICT (model) DO UPDATE SET provider_type=EXCLUDED.provider_type, context_length=EXCLUDED.context_length, max_output_tokens=EXCLUDED.max_output_tokens,
+		supports_vision=EXCLUDED.supports_vision, supports_tools=EXCLUDED.supports_tools,
+		ttft_slo_ms=EXCLUDED.ttft_slo_ms, latency_slo_ms=EXCLUDED.latency_slo_ms, timeout_ms=EXCLUDED.timeout_ms,
+		free_requests_per_day=EXCLUDED.free_requests_per_day, free_tokens_per_day=EXCLUDED.free_tokens_per_day, archived_at=NULL, archived_by=''`,
+		m.Model, m.ProviderType, m.ContextLength, m.MaxOutputTokens, m.SupportsVision, m.SupportsTools, m.TTFTSLOMS, m.LatencySLOMS, m.TimeoutMS, m.FreeRequestsPerDay, m.FreeTokensPerDay)
 	return err
 }
 
-func (s *Store) DeleteModelCatalog(ctx context.Context, model string) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM model_catalog WHERE model=$1`, model)
-	return err
+// GetModelSLO returns model's latency targets; a model without any, or not
+// in the catalog, gets the zero ModelSLO.
+func (s *Store) GetModelSLO(ctx context.Context, model string) (ModelSLO, error) {
+	slo := ModelSLO{Model: model}
+	err := s.DB.QueryRow(ctx, `SELECT ttft_slo_ms, latency_slo_ms, timeout_ms FROM model_catalog WHERE model=$1`, model).
+		Scan(&slo.TTFTMS, &slo.LatencyMS, &slo.TimeoutMS)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return slo, nil
+	}
+	return slo, err
+}
+
+// ListModelSLOs returns the catalog models that have a latency target.
+func (s *Store) ListModelSLOs(ctx context.Context) ([]ModelSLO, error) {
+	rows, err := s.DB.Query(ctx, `SELECT model, ttft_slo_ms, latency_slo_ms, timeout_ms FROM model_catalog
+		WHERE (ttft_slo_ms > 0 OR latency_slo_ms > 0 OR timeout_ms > 0) AND archived_at IS NULL ORDER BY model`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []ModelSLO
+	for rows.Next() {
+		var m ModelSLO
+		if err := rows.Scan(&m.Model, &m.TTFTMS, &m.LatencyMS, &m.TimeoutMS); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
 }
 
 type ModelInfo struct {
 	Model        string  `json:"id"`
 	ProviderType string  `json:"provider_type"`
 	PricePer1K   float64 `json:"price_per_1k_usd"`
+
+	ContextLength   int  `json:"context_length"`
+	MaxOutputTokens int  `json:"max_output_tokens"`
+	SupportsVision  bool `json:"supports_vision"`
+	SupportsTools   bool `json:"supports_tools"`
 }
 
 func (s *Store) ListAllModels(ctx context.Context) ([]ModelInfo, error) {
-	rows, err := s.DB.Query(ctx, `SELECT mc.model, mc.provider_type, COALESCE(mp.price_per_1k_usd,0) FROM model_catalog mc LEFT JOIN model_pricing mp ON mc.model=mp.model ORDER BY mc.provider_type, mc.model`)
+	rows, err := s.DB.Query(ctx, `SELECT mc.model, mc.provider_type, COALESCE(mp.price_per_1k_usd,0), mc.context_length, mc.max_output_tokens, mc.supports_vision, mc.supports_tools
+		FROM model_catalog mc LEFT JOIN model_pricing mp ON mc.model=mp.model WHERE mc.archived_at IS NULL ORDER BY mc.provider_type, mc.model`)
 	if err != nil {
 		return nil, err
 	}
@@ -477,7 +866,7 @@ func (s *Store) ListAllModels(ctx context.Context) ([]ModelInfo, error) {
 	var out []ModelInfo
 	for rows.Next() {
 		var m ModelInfo
-		if err := rows.Scan(&m.Model, &m.ProviderType, &m.PricePer1K); err != nil {
+		if err := rows.Scan(&m.Model, &m.ProviderType, &m.PricePer1K, &m.ContextLength, &m.MaxOutputTokens, &m.SupportsVision, &m.SupportsTools); err != nil {
 			return nil, err
 		}
 		out = append(out, m)
@@ -485,15 +874,23 @@ func (s *Store) ListAllModels(ctx context.Context) ([]ModelInfo, error) {
 	return out, rows.Err()
 }
 
-func (s *Store) GetTenantRequestSummary(ctx context.Context, tenantID string) (*TenantRequestSummary, error) {
-	row := s.DB.QueryRow(ctx, `SELECT COUNT(*), COALESCE(SUM(tokens),0), COALESCE(SUM(cost_usd),0) FROM request_logs WHERE tenant_id=$1 AND status_code=200 AND tokens > 0`, tenantID)
+// GetTenantRequestSummary returns totals and a bucketed series for the window
+// (all time, daily when unset) plus a fixed last-24h view in 3h buckets.
+func (s *Store) GetTenantRequestSummary(ctx context.Context, tenantID string, w UsageWindow) (*TenantRequestSummary, error) {
+	cond, condArgs := w.where("created_at", 2)
+	args := append([]interface{}{tenantID}, condArgs...)
+	row := s.DB.QueryRow(ctx, `SELECT COUNT(*), COALESCE(SUM(tokens),0), COALESCE(SUM(cost_usd),0) FROM request_logs WHERE tenant_id=$1 AND status_code=200 AND tokens > 0`+cond, args...)
 	var totalReq int
 	var totalTokens int
 	var totalCost float64
 	if err := row.Scan(&totalReq, &totalTokens, &totalCost); err != nil {
 		return nil, err
 	}
-	rows, err := s.DB.Query(ctx, `SELECT DATE(created_at) as day, COUNT(*), COALESCE(SUM(tokens),0), COALESCE(SUM(cost_usd),0) FROM request_logs WHERE tenant_id=$1 AND status_code=200 AND tokens > 0 GROUP BY day ORDER BY day`, tenantID)
+	gran := w.Granularity
+	if gran == "" {
+		gran = GranularityDay
+	}
+	rows, err := s.DB.Query(ctx, fmt.Sprintf(`SELECT date_trunc($%d, created_at) as day, COUNT(*), COALESCE(SUM(tokens),0), COALESCE(SUM(cost_usd),0) FROM request_logs WHERE tenant_id=$1 AND status_code=200 AND tokens > 0%s GROUP BY day ORDER BY day`, len(args)+1, cond), append(args, gran)...)
 	if err != nil {
 		return nil, err
 	}
@@ -597,19 +994,25 @@ type AdminDashboardStats struct {
 	TotalTokensAllTime   int     `json:"total_tokens_all_time"`
 	TotalCostAllTime     float64 `json:"total_cost_all_time"`
 	TotalRevenueAllTime  float64 `json:"total_revenue_all_time"`
+	// Window the *24h fields and hourly_series cover (last 24h by default)
+	Window UsageWindow `json:"window"`
+	// Cost and capacity outlook from the daily rollups; see fillOutlook
+	Outlook DashboardOutlook `json:"outlook"`
 }
 
-func (s *Store) GetAdminDashboardStats(ctx context.Context) (*AdminDashboardStats, error) {
-	stats := &AdminDashboardStats{}
+// GetAdminDashboardStats fills the windowed KPIs (the *24h fields) and series
+// for w, which must have From and To set, alongside all-time totals.
+func (s *Store) GetAdminDashboardStats(ctx context.Context, w UsageWindow) (*AdminDashboardStats, error) {
+	stats := &AdminDashboardStats{Window: w}
 
 	// tenant counts
 	row := s.DB.QueryRow(ctx, `SELECT COUNT(*) FROM tenants`)
 	_ = row.Scan(&stats.TotalTenants)
 
-	row = s.DB.QueryRow(ctx, `SELECT COUNT(*) FROM tenants WHERE last_active >= NOW() - interval '24 hours'`)
+	row = s.DB.QueryRow(ctx, `SELECT COUNT(DISTINCT tenant_id) FROM request_logs WHERE created_at >= $1 AND created_at < $2`, w.From, w.To)
 	_ = row.Scan(&stats.ActiveTenants)
 
-	// 24h request stats
+	// windowed request stats
 	row = s.DB.QueryRow(ctx, `
 		SELECT COUNT(*),
 		       COUNT(*) FILTER (WHERE status_code >= 400),
@@ -617,8 +1020,8 @@ func (s *Store) GetAdminDashboardStats(ctx context.Context) (*AdminDashboardStat
 		       COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY latency_ms), 0),
 		       COALESCE(SUM(cost_usd), 0),
 		       COALESCE(SUM(tokens), 0)
-		FROM request_logs WHERE created_at >= NOW() - interval '24 hours'
-	`)
+		FROM request_logs WHERE created_at >= $1 AND created_at < $2
+	`, w.From, w.To)
 	_ = row.Scan(&stats.Requests24h, &stats.Errors24h, &stats.AvgLatencyMS, &stats.P95LatencyMS, &stats.Cost24h, &stats.Tokens24h)
 
 	if stats.Requests24h > 0 {
@@ -638,15 +1041,17 @@ func (s *Store) GetAdminDashboardStats(ctx context.Context) (*AdminDashboardStat
 	row = s.DB.QueryRow(ctx, `SELECT COALESCE(SUM(total_topup_usd), 0) FROM tenants`)
 	_ = row.Scan(&stats.TotalRevenueAllTime)
 
-	// hourly series
+	s.fillOutlook(ctx, &stats.Outlook, time.Now().UTC())
+
+	// bucketed series
 	rows, err := s.DB.Query(ctx, `
-		SELECT date_trunc('hour', created_at) AS hour,
+		SELECT date_trunc($3, created_at) AS hour,
 		       COUNT(*),
 		       COUNT(*) FILTER (WHERE status_code >= 400)
 		FROM request_logs
-		WHERE created_at >= NOW() - interval '24 hours'
+		WHERE created_at >= $1 AND created_at < $2
 		GROUP BY hour ORDER BY hour
-	`)
+	`, w.From, w.To, w.Granularity)
 	if err != nil {
 		return stats, nil
 	}
@@ -659,9 +1064,7 @@ func (s *Store) GetAdminDashboardStats(ctx context.Context) (*AdminDashboardStat
 		}
 		hourMap[b.Hour.Unix()] = b
 	}
-	now := time.Now().UTC()
-	for i := 23; i >= 0; i-- {
-		h := now.Add(-time.Duration(i) * time.Hour).Truncate(time.Hour)
+	for h := w.Truncate(w.From); h.Before(w.To); h = w.Next(h) {
 		if b, ok := hourMap[h.Unix()]; ok {
 			stats.HourlySeries = append(stats.HourlySeries, b)
 		} else {
@@ -679,8 +1082,16 @@ type RequestLogFilters struct {
 	Provider   string
 	Model      string
 	StatusCode int
-	SortBy     string
-	SortDir    string
+	UserID     string
+	AppTitle   string
+	KeyOwner   string
+	Tags       map[string]string // metadata key/value pairs that must all match
+	// Status is "success" for 2xx responses or "error" for anything else.
+	Status string
+	// From and To bound created_at as [From, To); zero leaves a side open.
+	From, To time.Time
+	SortBy   string
+	SortDir  string
 }
 
 type PaginatedRequestLogs struct {
@@ -722,6 +1133,42 @@ func (s *Store) ListRequestLogsPaginated(ctx context.Context, page, pageSize int
 		args = append(args, f.StatusCode)
 		argN++
 	}
+	if f.UserID != "" {
+		where += fmt.Sprintf(" AND user_id=$%d", argN)
+		args = append(args, f.UserID)
+		argN++
+	}
+	if f.AppTitle != "" {
+		where += fmt.Sprintf(" AND app_title=$%d", argN)
+		args = append(args, f.AppTitle)
+		argN++
+	}
+	if f.KeyOwner != "" {
+		where += fmt.Sprintf(" AND key_owner=$%d", argN)
+		args = append(args, f.KeyOwner)
+		argN++
+	}
+	if len(f.Tags) > 0 {
+		where += fmt.Sprintf(" AND metadata @> $%d", argN)
+		args = append(args, f.Tags)
+		argN++
+	}
+	switch f.Status {
+	case "success":
+		where += " AND status_code BETWEEN 200 AND 299"
+	case "error":
+		where += " AND (status_code < 200 OR status_code > 299)"
+	}
+	if !f.From.IsZero() {
+		where += fmt.Sprintf(" AND created_at >= $%d", argN)
+		args = append(args, f.From)
+		argN++
+	}
+	if !f.To.IsZero() {
+		where += fmt.Sprintf(" AND created_at < $%d", argN)
+		args = append(args, f.To)
+		argN++
+	}
 
 	// count
 	var total int
@@ -742,7 +1189,7 @@ func (s *Store) ListRequestLogsPaginated(ctx context.Context, page, pageSize int
 	}
 
 	offset := (page - 1) * pageSize
-	dataQ := fmt.Sprintf(`SELECT id, tenant_id, provider, model, latency_ms, ttft_ms, tokens, cost_usd, prompt_hash, fallback_used, status_code, error_code, created_at
+	dataQ := fmt.Sprintf(`SELECT id, tenant_id, provider, model, latency_ms, ttft_ms, tokens, cost_usd, prompt_hash, fallback_used, status_code, error_code, user_id, app_title, app_referer, metadata, key_owner, created_at
 		FROM request_logs %s ORDER BY %s %s LIMIT $%d OFFSET $%d`, where, sortCol, sortDir, argN, argN+1)
 	args = append(args, pageSize, offset)
 
@@ -754,7 +1201,7 @@ func (s *Store) ListRequestLogsPaginated(ctx context.Context, page, pageSize int
 	var logs []models.RequestLog
 	for rows.Next() {
 		var l models.RequestLog
-		if err := rows.Scan(&l.ID, &l.TenantID, &l.Provider, &l.Model, &l.LatencyMS, &l.TTFTMS, &l.Tokens, &l.CostUSD, &l.PromptHash, &l.FallbackUsed, &l.StatusCode, &l.ErrorCode, &l.CreatedAt); err != nil {
+		if err := rows.Scan(&l.ID, &l.TenantID, &l.Provider, &l.Model, &l.LatencyMS, &l.TTFTMS, &l.Tokens, &l.CostUSD, &l.PromptHash, &l.FallbackUsed, &l.StatusCode, &l.ErrorCode, &l.UserID, &l.AppTitle, &l.AppReferer, &l.Metadata, &l.KeyOwner, &l.CreatedAt); err != nil {
 			return nil, err
 		}
 		logs = append(logs, l)
@@ -762,40 +1209,99 @@ func (s *Store) ListRequestLogsPaginated(ctx context.Context, page, pageSize int
 	return &PaginatedRequestLogs{Data: logs, Total: total, Page: page, PageSize: pageSize}, rows.Err()
 }
 
-// ---- Routing Rules ----
+// ---- Usage By Tag ----
 
-func (s *Store) ListRoutingRulesByTenant(ctx context.Context, tenantID string) ([]RoutingRule, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, tenant_id, capability, primary_provider_id, COALESCE(secondary_provider_id,''), model FROM routing_rules WHERE tenant_id=$1 ORDER BY capability`, tenantID)
+// TagUsage is spend attributed to one value of a grouping dimension.
+type TagUsage struct {
+	Value    string  `json:"value"`
+	Requests int     `json:"requests"`
+	Tokens   int64   `json:"tokens"`
+	CostUSD  float64 `json:"cost_usd"`
+}
+
+// GetUsageByTag groups a tenant's request logs by app_title, app_referer,
+// user_id, or any other name, which is treated as a metadata key. Requests
+// without the dimension are reported under an empty value.
+func (s *Store) GetUsageByTag(ctx context.Context, tenantID, groupBy string, from, to time.Time) ([]TagUsage, error) {
+	args := []interface{}{}
+	argN := 1
+	var dim string
+	switch groupBy {
+	case "app_title", "app_referer", "user_id":
+		dim = groupBy
+	default:
+		dim = fmt.Sprintf("COALESCE(metadata->>$%d, '')", argN)
+		args = append(args, groupBy)
+		argN++
+	}
+	where := "WHERE 1=1"
+	if tenantID != "" {
+		where += fmt.Sprintf(" AND tenant_id=$%d", argN)
+		args = append(args, tenantID)
+		argN++
+	}
+	if !from.IsZero() {
+		where += fmt.Sprintf(" AND created_at >= $%d", argN)
+		args = append(args, from)
+		argN++
+	}
+	if !to.IsZero() {
+		where += fmt.Sprintf(" AND created_at < $%d", argN)
+		args = append(args, to)
+		argN++
+	}
+	q := fmt.Sprintf(`SELECT %s, COUNT(*), COALESCE(SUM(tokens),0), COALESCE(SUM(cost_usd),0)
+		FROM request_logs %s GROUP BY 1 ORDER BY 4 DESC`, dim, where)
+	rows, err := s.DB.Query(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var rules []RoutingRule
+	var out []TagUsage
 	for rows.Next() {
-		var r RoutingRule
-		if err := rows.Scan(&r.ID, &r.TenantID, &r.Capability, &r.PrimaryProviderID, &r.SecondaryProviderID, &r.Model); err != nil {
+		var u TagUsage
+		if err := rows.Scan(&u.Value, &u.Requests, &u.Tokens, &u.CostUSD); err != nil {
 			return nil, err
 		}
-		rules = append(rules, r)
+		out = append(out, u)
 	}
-	return rules, rows.Err()
-}
-
-func (s *Store) DeleteRoutingRule(ctx context.Context, id string) error {
-	_, err := s.DB.Exec(ctx, `DELETE FROM routing_rules WHERE id=$1`, id)
-	return err
+	return out, rows.Err()
 }
 
 // ---- Provider Health ----
 
 type ProviderHealthStatus struct {
-	ProviderID    string `json:"provider_id"`
-	ProviderName  string `json:"provider_name"`
-	Type          string `json:"type"`
-	Enabled       bool   `json:"enabled"`
-	HealthStatus  string `json:"health_status"`
-	CircuitOpen   bool   `json:"circuit_open"`
-	AvgLatencyMS  int64  `json:"avg_latency_ms"`
+	ProviderID   string `json:"provider_id"`
+	ProviderName string `json:"provider_name"`
+	Type         string `json:"type"`
+	Enabled      bool   `json:"enabled"`
+	HealthStatus string `json:"health_status"`
+	CircuitOpen  bool   `json:"circuit_open"`
+	AvgLatencyMS int64  `json:"avg_latency_ms"`
+
+	// CircuitState is "closed", "open" or "half_open".
+	CircuitState string `json:"circuit_state"`
+	// MaintenanceUntil is the end of the scheduled window the provider is
+	// in, if any. HealthStatus is "maintenance" while under maintenance.
+	MaintenanceUntil *time.Time `json:"maintenance_until"`
+
+	// CooldownUntil is set while the provider is benched after a 429/529.
+	CooldownUntil *time.Time `json:"cooldown_until"`
+
+	// SLO reports the provider's rolling latency against each catalog
+	// model's targets, for models it has served.
+	SLO []ModelSLOStatus `json:"slo,omitempty"`
+}
+
+// ModelSLOStatus is one provider's standing against a model's SLO.
+type ModelSLOStatus struct {
+	Model        string `json:"model"`
+	TTFTSLOMS    int    `json:"ttft_slo_ms,omitempty"`
+	LatencySLOMS int    `json:"latency_slo_ms,omitempty"`
+	AvgTTFTMS    int64  `json:"avg_ttft_ms"`
+	AvgLatencyMS int64  `json:"avg_latency_ms"`
+	Samples      int    `json:"samples"`
+	Compliant    bool   `json:"compliant"`
 }
 
 func (s *Store) ListModelUsage(ctx context.Context) ([]ModelUsageSummary, error) {
@@ -853,8 +1359,76 @@ func (s *Store) ListTransactions(ctx context.Context, tenantID string, limit int
 	return txs, rows.Err()
 }
 
+// TransactionFilters narrows ListTransactionsPaginated.
+type TransactionFilters struct {
+	Type string
+	// From and To bound created_at as [From, To); zero leaves a side open.
+	From, To time.Time
+}
+
+type PaginatedTransactions struct {
+	Data     []BalanceTransaction `json:"data"`
+	Total    int                  `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
+
+// ListTransactionsPaginated pages through a tenant's ledger, newest first.
+func (s *Store) ListTransactionsPaginated(ctx context.Context, tenantID string, page, pageSize int, f TransactionFilters) (*PaginatedTransactions, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
+
+	where := "WHERE tenant_id=$1"
+	args := []interface{}{tenantID}
+	argN := 2
+
+	if f.Type != "" {
+		where += fmt.Sprintf(" AND type=$%d", argN)
+		args = append(args, f.Type)
+		argN++
+	}
+	if !f.From.IsZero() {
+		where += fmt.Sprintf(" AND created_at >= $%d", argN)
+		args = append(args, f.From)
+		argN++
+	}
+	if !f.To.IsZero() {
+		where += fmt.Sprintf(" AND created_at < $%d", argN)
+		args = append(args, f.To)
+		argN++
+	}
+
+	var total int
+	if err := s.DB.QueryRow(ctx, "SELECT COUNT(*) FROM balance_transactions "+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * pageSize
+	rows, err := s.DB.Query(ctx, fmt.Sprintf(`SELECT id, tenant_id, type, amount_usd, balance_after, COALESCE(description,''), created_at
+		FROM balance_transactions %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, where, argN, argN+1), append(args, pageSize, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	txs := []BalanceTransaction{}
+	for rows.Next() {
+		var tx BalanceTransaction
+		if err := rows.Scan(&tx.ID, &tx.TenantID, &tx.Type, &tx.AmountUSD, &tx.BalanceAfter, &tx.Description, &tx.CreatedAt); err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return &PaginatedTransactions{Data: txs, Total: total, Page: page, PageSize: pageSize}, rows.Err()
+}
+
+// SuspendTenant sets a tenant's suspension by hand. Either way it clears
+// any automatic suspension reason, so a top-up no longer lifts it.
 func (s *Store) SuspendTenant(ctx context.Context, tenantID string, suspended bool) error {
-	_, err := s.DB.Exec(ctx, `UPDATE tenants SET suspended=$2 WHERE id=$1`, tenantID, suspended)
+	_, err := s.DB.Exec(ctx, `UPDATE tenants SET suspended=$2, auto_suspend_reason='' WHERE id=$1`, tenantID, suspended)
 	return err
 }
 
@@ -863,10 +1437,22 @@ func (s *Store) UpdateTenantLimits(ctx context.Context, tenantID string, rateLim
 	return err
 }
 
+func (s *Store) UpdateTenantTier(ctx context.Context, tenantID, tier string) error {
+	_, err := s.DB.Exec(ctx, `UPDATE tenants SET tier=$2 WHERE id=$1`, tenantID, tier)
+	return err
+}
+
+// Ping runs a trivial query so callers can measure database round-trip time.
+func (s *Store) Ping(ctx context.Context) error {
+	_, err := s.DB.Exec(ctx, `SELECT 1`)
+	return err
+}
+
 // ---- Webhooks ----
 
 type Webhook struct {
 	ID        int       `json:"id"`
+	TenantID  string    `json:"tenant_id,omitempty"` // empty for operator webhooks, which receive every tenant's events
 	URL       string    `json:"url"`
 	Events    []string  `json:"events"`
 	Secret    string    `json:"secret,omitempty"`
@@ -874,8 +1460,10 @@ type Webhook struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-func (s *Store) ListWebhooks(ctx context.Context) ([]Webhook, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, url, events, secret, enabled, created_at FROM webhooks ORDER BY id`)
+const webhookColumns = `id, COALESCE(tenant_id, ''), url, events, secret, enabled, created_at`
+
+func (s *Store) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]Webhook, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -883,7 +1471,7 @@ func (s *Store) ListWebhooks(ctx context.Context) ([]Webhook, error) {
 	var hooks []Webhook
 	for rows.Next() {
 		var h Webhook
-		if err := rows.Scan(&h.ID, &h.URL, &h.Events, &h.Secret, &h.Enabled, &h.CreatedAt); err != nil {
+		if err := rows.Scan(&h.ID, &h.TenantID, &h.URL, &h.Events, &h.Secret, &h.Enabled, &h.CreatedAt); err != nil {
 			return nil, err
 		}
 		hooks = append(hooks, h)
@@ -891,9 +1479,35 @@ func (s *Store) ListWebhooks(ctx context.Context) ([]Webhook, error) {
 	return hooks, rows.Err()
 }
 
-func (s *Store) CreateWebhook(ctx context.Context, url string, events []string, secret string) error {
-	_, err := s.DB.Exec(ctx, `INSERT INTO webhooks (url, events, secret) VALUES ($1, $2, $3)`, url, events, secret)
-	return err
+func (s *Store) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	return s.queryWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+}
+
+func (s *Store) ListTenantWebhooks(ctx context.Context, tenantID string) ([]Webhook, error) {
+	return s.queryWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE tenant_id=$1 ORDER BY id`, tenantID)
+}
+
+// CreateWebhook inserts a webhook and returns its id. An empty TenantID
+// creates an operator webhook.
+func (s *Store) CreateWebhook(ctx context.Context, h Webhook) (int, error) {
+	var tenantID interface{}
+	if h.TenantID != "" {
+		tenantID = h.TenantID
+	}
+	var id int
+	err := s.DB.QueryRow(ctx, `INSERT INTO webhooks (tenant_id, url, events, secret) VALUES ($1, $2, $3, $4) RETURNING id`, tenantID, h.URL, h.Events, h.Secret).Scan(&id)
+	return id, err
+}
+
+func (s *Store) UpdateWebhook(ctx context.Context, h Webhook) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE webhooks SET url=$2, events=$3, secret=$4, enabled=$5 WHERE id=$1`, h.ID, h.URL, h.Events, h.Secret, h.Enabled)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
 }
 
 func (s *Store) DeleteWebhook(ctx context.Context, id int) error {
@@ -901,19 +1515,74 @@ func (s *Store) DeleteWebhook(ctx context.Context, id int) error {
 	return err
 }
 
-func (s *Store) GetEnabledWebhooks(ctx context.Context, event string) ([]Webhook, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, url, events, secret, enabled, created_at FROM webhooks WHERE enabled=true AND $1=ANY(events)`, event)
+func (s *Store) DeleteTenantWebhook(ctx context.Context, tenantID string, id int) error {
+	tag, err := s.DB.Exec(ctx, `DELETE FROM webhooks WHERE id=$1 AND tenant_id=$2`, id, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetEnabledWebhooks returns the webhooks subscribed to an event: operator
+// webhooks always, and the tenant's own webhooks when tenantID is set.
+func (s *Store) GetEnabledWebhooks(ctx context.Context, event, tenantID string) ([]Webhook, error) {
+	return s.queryWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE enabled=true AND $1=ANY(events) AND (tenant_id IS NULL OR tenant_id=NULLIF($2, ''))`, event, tenantID)
+}
+
+// ---- Model Provider Lists ----
+
+// ModelProviderEntry is one slot in a model's admin-defined provider order.
+type ModelProviderEntry struct {
+	Model        string `json:"model"`
+	ProviderID   string `json:"provider_id"`
+	ProviderName string `json:"provider_name"`
+	Priority     int    `json:"priority"`
+	Enabled      bool   `json:"enabled"`
+}
+
+func (s *Store) ListModelProviderEntries(ctx context.Context, model string) ([]ModelProviderEntry, error) {
+	rows, err := s.DB.Query(ctx, `SELECT mp.model, mp.provider_id, p.name, mp.priority, mp.enabled FROM model_providers mp JOIN providers p ON p.id=mp.provider_id WHERE mp.model=$1 ORDER BY mp.priority`, model)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var hooks []Webhook
+	var entries []ModelProviderEntry
 	for rows.Next() {
-		var h Webhook
-		if err := rows.Scan(&h.ID, &h.URL, &h.Events, &h.Secret, &h.Enabled, &h.CreatedAt); err != nil {
+		var e ModelProviderEntry
+		if err := rows.Scan(&e.Model, &e.ProviderID, &e.ProviderName, &e.Priority, &e.Enabled); err != nil {
 			return nil, err
 		}
-		hooks = append(hooks, h)
+		entries = append(entries, e)
 	}
-	return hooks, rows.Err()
+	return entries, rows.Err()
+}
+
+// SetModelProviderEntries replaces the provider order for a model. Entries are
+// stored with their slice index as priority.
+func (s *Store) SetModelProviderEntries(ctx context.Context, model string, entries []ModelProviderEntry) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `DELETE FROM model_providers WHERE model=$1`, model); err != nil {
+		return err
+	}
+	for i, e := range entries {
+		if _, err := tx.Exec(ctx, `INSERT INTO model_providers (model, provider_id, priority, enabled) VALUES ($1,$2,$3,$4)`, model, e.ProviderID, i, e.Enabled); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// GetModelProviderChain returns the enabled providers configured for a model,
+// in priority order. An empty result means the model has no explicit list.
+func (s *Store) GetModelProviderChain(ctx context.Context, model string) ([]Provider, error) {
+	return s.queryProviders(ctx, `SELECT `+providerColumns+`
+		FROM model_providers mp JOIN providers p ON p.id=mp.provider_id
+		WHERE mp.model=$1 AND mp.enabled=true AND p.enabled=true AND p.archived_at IS NULL ORDER BY mp.priority`, model)
 }
@@ -0,0 +1,1771 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+// Package storemock is a stand-in for *store.Store in unit tests. Set the
+// function field for each method a test exercises; a method whose field is
+// nil returns zero values.
+package storemock
+
+//go:generate go run gen.go
+
+import (
+	"context"
+	"time"
+
+	"routerx/internal/api"
+	"routerx/internal/models"
+	"routerx/internal/router"
+	"routerx/internal/store"
+)
+
+var (
+	_ api.Store    = (*Store)(nil)
+	_ router.Store = (*Store)(nil)
+)
+
+// Store implements every exported method of *store.Store.
+type Store struct {
+	AcceptInvitationFunc            func(context.Context, string, store.TenantUser) (*store.Invitation, error)
+	ActiveMaintenanceEndsFunc       func(context.Context) (map[string]time.Time, error)
+	AddAPIKeyUsageFunc              func(context.Context, []store.APIKeyUsage) error
+	AddModelCatalogFunc             func(context.Context, store.ModelCatalog) error
+	AddTenantTopupFunc              func(context.Context, string, float64) error
+	AddUsageCostFunc                func(context.Context, string, string, string, int, float64, time.Time) error
+	AdjustTenantBalanceFunc         func(context.Context, string, float64, string) (float64, error)
+	AlertMetricValuesFunc           func(context.Context, string, string, time.Duration) (map[string]float64, error)
+	ApplyRoutingConfigFunc          func(context.Context, store.RoutingConfig, bool) error
+	ArchiveModelFunc                func(context.Context, string, string) error
+	ArchiveProviderFunc             func(context.Context, string, string) error
+	AutoSuspendTenantFunc           func(context.Context, string, string) (bool, error)
+	BootstrapFunc                   func(context.Context, store.AdminUser, store.Tenant, store.TenantUser, store.APIKey) error
+	CancelBatchFunc                 func(context.Context, string, string) error
+	ChargeTenantFunc                func(context.Context, string, float64) (store.Charge, error)
+	ClaimAlertTransitionFunc        func(context.Context, store.AlertRule, string, time.Time) (bool, error)
+	ClaimBatchItemsFunc             func(context.Context, int, int, time.Duration) ([]store.BatchItem, error)
+	ClaimDueWebhookDeliveriesFunc   func(context.Context, int, time.Duration) ([]store.WebhookDelivery, error)
+	ClaimJobsFunc                   func(context.Context, []string, int, time.Duration, string) ([]store.Job, error)
+	ClaimSpendAlertFunc             func(context.Context, string, string, time.Time) (bool, error)
+	ClaimTOTPAttemptFunc            func(context.Context, string, string, int, time.Duration) (bool, error)
+	ClearStagedProviderAPIKeyFunc   func(context.Context, string) error
+	CountProviderEventsFunc         func(context.Context, store.ProviderEventFilters) ([]store.ProviderEventCount, error)
+	CreateAPIKeyFunc                func(context.Context, store.APIKey) error
+	CreateAdminUserFunc             func(context.Context, store.AdminUser) error
+	CreateAlertRuleFunc             func(context.Context, store.AlertRule) error
+	CreateBatchFunc                 func(context.Context, store.Batch, []store.BatchItem) error
+	CreateInvitationFunc            func(context.Context, store.Invitation) error
+	CreateMaintenanceWindowFunc     func(context.Context, store.MaintenanceWindow) (store.MaintenanceWindow, error)
+	CreatePromptTemplateFunc        func(context.Context, store.PromptTemplate) error
+	CreateTenantFunc                func(context.Context, store.Tenant) error
+	CreateTenantAccountFunc         func(context.Context, store.Tenant, store.TenantUser, *store.APIKey) error
+	CreateTenantSpendAlertFunc      func(context.Context, store.TenantSpendAlert) error
+	CreateTenantUserFunc            func(context.Context, store.TenantUser) error
+	CreateWebhookFunc               func(context.Context, store.Webhook) (int, error)
+	CreateWebhookDeliveryFunc       func(context.Context, store.WebhookDelivery) error
+	DeferBatchItemFunc              func(context.Context, int64, int, string, time.Time) error
+	DeleteAPIKeyFunc                func(context.Context, string, string) error
+	DeleteAlertRuleFunc             func(context.Context, string) error
+	DeleteExchangeRateFunc          func(context.Context, string) error
+	DeleteExperimentFunc            func(context.Context, string) error
+	DeleteInvitationFunc            func(context.Context, string, string) error
+	DeleteMaintenanceWindowFunc     func(context.Context, string, int64) error
+	DeletePromptTemplateFunc        func(context.Context, string, string) error
+	DeleteRequestLogFunc            func(context.Context, int) error
+	DeleteRoutingRuleFunc           func(context.Context, string) error
+	DeleteTenantFunc                func(context.Context, string, string) (*store.TenantArchive, error)
+	DeleteTenantMemberFunc          func(context.Context, string, string) error
+	DeleteTenantSpendAlertFunc      func(context.Context, string, string) error
+	DeleteTenantWebhookFunc         func(context.Context, string, int) error
+	DeleteWebhookFunc               func(context.Context, int) error
+	DisableTOTPFunc                 func(context.Context, string, string) error
+	EachDailyUsageFunc              func(context.Context, string, time.Time, time.Time, func(store.DailyUsage) error) error
+	EachRequestLogFunc              func(context.Context, string, time.Time, time.Time, func(models.RequestLog) error) error
+	EachTransactionFunc             func(context.Context, string, func(store.BalanceTransaction) error) error
+	EnableTOTPFunc                  func(context.Context, string, string, []string) error
+	EnqueueJobFunc                  func(context.Context, store.Job) (bool, error)
+	EraseTenantFunc                 func(context.Context, string, string) (*store.TenantArchive, error)
+	ExpireCreditGrantsFunc          func(context.Context) (int, error)
+	FinishBatchItemFunc             func(context.Context, store.BatchItem) error
+	FinishJobFunc                   func(context.Context, string, string, string, time.Time) error
+	FreeUsageOnFunc                 func(context.Context, string, string, time.Time) (int64, int64, error)
+	GetAPIKeyFunc                   func(context.Context, string) (*store.APIKey, error)
+	GetActiveExperimentFunc         func(context.Context, string, string) (*store.Experiment, error)
+	GetAdminByUsernameFunc          func(context.Context, string) (*store.AdminUser, error)
+	GetAdminDashboardStatsFunc      func(context.Context, store.UsageWindow) (*store.AdminDashboardStats, error)
+	GetAlertRuleFunc                func(context.Context, string) (*store.AlertRule, error)
+	GetAttemptFailuresFunc          func(context.Context, time.Time, time.Time, string, string) ([]store.AttemptFailure, error)
+	GetBatchFunc                    func(context.Context, string, string) (*store.Batch, error)
+	GetEnabledProvidersByTypeFunc   func(context.Context, string) ([]store.Provider, error)
+	GetEnabledWebhooksFunc          func(context.Context, string, string) ([]store.Webhook, error)
+	GetExchangeRateFunc             func(context.Context, string) (*store.ExchangeRate, error)
+	GetExperimentFunc               func(context.Context, string) (*store.Experiment, error)
+	GetExperimentResultsFunc        func(context.Context, string) ([]store.ExperimentArmStats, error)
+	GetJobFunc                      func(context.Context, string) (*store.Job, error)
+	GetMarginReportFunc             func(context.Context, time.Time, time.Time) (*store.MarginReport, error)
+	GetModelFreeAllowanceFunc       func(context.Context, string) (store.FreeAllowance, error)
+	GetModelPriceFunc               func(context.Context, string) (float64, bool, error)
+	GetModelProviderFunc            func(context.Context, string) (string, bool, error)
+	GetModelProviderChainFunc       func(context.Context, string) ([]store.Provider, error)
+	GetModelSLOFunc                 func(context.Context, string) (store.ModelSLO, error)
+	GetModerationPolicyFunc         func(context.Context, string) (*store.ModerationPolicy, error)
+	GetPromptTemplateFunc           func(context.Context, string, string) (*store.PromptTemplate, error)
+	GetPromptTemplateByNameFunc     func(context.Context, string, string) (*store.PromptTemplate, error)
+	GetProviderByIDFunc             func(context.Context, string) (*store.Provider, error)
+	GetProvidersFunc                func(context.Context) ([]store.Provider, error)
+	GetReconciliationFunc           func(context.Context, string, time.Time, time.Time, float64) (*store.ReconciliationReport, error)
+	GetRedactionPolicyFunc          func(context.Context, string) (*store.RedactionPolicy, error)
+	GetRequestLogFunc               func(context.Context, int) (*models.RequestLog, error)
+	GetRequestPolicyFunc            func(context.Context, string) (*store.RequestPolicy, error)
+	GetRoutingConfigFunc            func(context.Context) (store.RoutingConfig, error)
+	GetRoutingRuleByIDFunc          func(context.Context, string) (*store.RoutingRule, error)
+	GetSigningSecretFunc            func(context.Context, string) (string, string, error)
+	GetStagedProviderAPIKeyFunc     func(context.Context, string) (string, error)
+	GetTenantAnalyticsFunc          func(context.Context, string, time.Time, time.Time, int) (*store.TenantAnalytics, error)
+	GetTenantByAPIKeyFunc           func(context.Context, string) (*store.Tenant, error)
+	GetTenantByIDFunc               func(context.Context, string) (*store.Tenant, error)
+	GetTenantCurrencyFunc           func(context.Context, string) (string, float64, error)
+	GetTenantRequestSummaryFunc     func(context.Context, string, store.UsageWindow) (*store.TenantRequestSummary, error)
+	GetTenantSpendAlertFunc         func(context.Context, string, string) (*store.TenantSpendAlert, error)
+	GetTenantUserByUsernameFunc     func(context.Context, string) (*store.TenantUser, error)
+	GetUsageByTagFunc               func(context.Context, string, string, time.Time, time.Time) ([]store.TagUsage, error)
+	GetWebhookFunc                  func(context.Context, int) (*store.Webhook, error)
+	GetWebhookDeliveryFunc          func(context.Context, string) (*store.WebhookDelivery, error)
+	GrantCreditsFunc                func(context.Context, store.CreditGrant) error
+	ImportProviderBillingFunc       func(context.Context, string, string, string, []store.ProviderBillingLine) error
+	InsertAlertEventFunc            func(context.Context, store.AlertEvent) error
+	InsertAuditEntryFunc            func(context.Context, store.AuditEntry) error
+	InsertModerationEventFunc       func(context.Context, store.ModerationEvent) error
+	InsertProviderEventFunc         func(context.Context, store.ProviderEvent) error
+	InsertRequestLogFunc            func(context.Context, models.RequestLog) error
+	JobCountsFunc                   func(context.Context) ([]store.JobCount, error)
+	ListAPIKeysByTenantFunc         func(context.Context, string) ([]store.APIKey, error)
+	ListAbusiveTenantsFunc          func(context.Context, store.AbuseThresholds) ([]store.Delinquent, error)
+	ListAlertEventsFunc             func(context.Context, string, int) ([]store.AlertEvent, error)
+	ListAlertRulesFunc              func(context.Context) ([]store.AlertRule, error)
+	ListAllModelsFunc               func(context.Context) ([]store.ModelInfo, error)
+	ListArchivedModelsFunc          func(context.Context) ([]store.ArchivedModel, error)
+	ListAuditLogFunc                func(context.Context, store.AuditFilters) ([]store.AuditEntry, error)
+	ListBatchResultsFunc            func(context.Context, string) ([]store.BatchItem, error)
+	ListBatchesFunc                 func(context.Context, string, int) ([]store.Batch, error)
+	ListCreditGrantsFunc            func(context.Context, string) ([]store.CreditGrant, error)
+	ListEnabledAlertRulesFunc       func(context.Context) ([]store.AlertRule, error)
+	ListEnabledSpendAlertsFunc      func(context.Context, string) ([]store.TenantSpendAlert, error)
+	ListExchangeRatesFunc           func(context.Context) ([]store.ExchangeRate, error)
+	ListExperimentsFunc             func(context.Context, string) ([]store.Experiment, error)
+	ListFreeAllowancesFunc          func(context.Context) ([]store.FreeAllowance, error)
+	ListJobsFunc                    func(context.Context, store.JobFilters) ([]store.Job, error)
+	ListMaintenanceWindowsFunc      func(context.Context, string) ([]store.MaintenanceWindow, error)
+	ListModelPricingFunc            func(context.Context) ([]store.ModelPricing, error)
+	ListModelProviderEntriesFunc    func(context.Context, string) ([]store.ModelProviderEntry, error)
+	ListModelSLOsFunc               func(context.Context) ([]store.ModelSLO, error)
+	ListModelUsageFunc              func(context.Context) ([]store.ModelUsageSummary, error)
+	ListModelsByProviderTypeFunc    func(context.Context, string) ([]string, error)
+	ListModerationEventsFunc        func(context.Context, string, time.Time, time.Time, int) ([]store.ModerationEvent, error)
+	ListNegativeBalanceTenantsFunc  func(context.Context, int) ([]store.Delinquent, error)
+	ListPendingInvitationsFunc      func(context.Context, string) ([]store.Invitation, error)
+	ListPromptTemplatesFunc         func(context.Context, string) ([]store.PromptTemplate, error)
+	ListProviderEventsFunc          func(context.Context, store.ProviderEventFilters) ([]store.ProviderEvent, error)
+	ListProvidersFunc               func(context.Context) ([]store.Provider, error)
+	ListRequestLogsFunc             func(context.Context, int) ([]models.RequestLog, error)
+	ListRequestLogsPaginatedFunc    func(context.Context, int, int, store.RequestLogFilters) (*store.PaginatedRequestLogs, error)
+	ListRoutingRulesFunc            func(context.Context) ([]store.RoutingRule, error)
+	ListRoutingRulesByTenantFunc    func(context.Context, string) ([]store.RoutingRule, error)
+	ListTenantArchivesFunc          func(context.Context, int) ([]store.TenantArchive, error)
+	ListTenantMembersFunc           func(context.Context, string) ([]store.TenantMember, error)
+	ListTenantSpendAlertsFunc       func(context.Context, string) ([]store.TenantSpendAlert, error)
+	ListTenantUsageFunc             func(context.Context, string, store.UsageWindow, int) ([]store.DailyUsage, error)
+	ListTenantWebhooksFunc          func(context.Context, string) ([]store.Webhook, error)
+	ListTenantsPaginatedFunc        func(context.Context, int, int, store.TenantListFilters) (*store.PaginatedTenants, error)
+	ListTransactionsFunc            func(context.Context, string, int) ([]store.BalanceTransaction, error)
+	ListTransactionsPaginatedFunc   func(context.Context, string, int, int, store.TransactionFilters) (*store.PaginatedTransactions, error)
+	ListWebhookDeliveriesFunc       func(context.Context, store.WebhookDeliveryFilters) ([]store.WebhookDelivery, error)
+	ListWebhooksFunc                func(context.Context) ([]store.Webhook, error)
+	MergeTenantsFunc                func(context.Context, string, string, string) (*store.TenantArchive, error)
+	NeedsSetupFunc                  func(context.Context) (bool, error)
+	PingFunc                        func(context.Context) error
+	PromoteStagedProviderAPIKeyFunc func(context.Context, string) error
+	PruneJobsFunc                   func(context.Context, time.Time) (int64, error)
+	RecordCacheLookupFunc           func(context.Context, bool, time.Time) error
+	RecordFreeUsageFunc             func(context.Context, string, string, string, int, time.Time) error
+	RecordTransactionFunc           func(context.Context, string, string, float64, float64, string) error
+	RecordUsageDailyFunc            func(context.Context, string, string, string, int, time.Time) error
+	RecordWebhookAttemptFunc        func(context.Context, string, store.WebhookDeliveryAttempt, string, time.Time) error
+	ReencryptProviderKeysFunc       func(context.Context) (int, error)
+	ReleaseBalanceSuspensionsFunc   func(context.Context, string) ([]string, error)
+	RenameTenantFunc                func(context.Context, string, string) error
+	ReplaceTOTPBackupCodesFunc      func(context.Context, string, string, []string) error
+	ResetTOTPAttemptsFunc           func(context.Context, string, string) error
+	RestoreModelFunc                func(context.Context, string) error
+	RestoreProviderFunc             func(context.Context, string) error
+	RetryBatchItemFunc              func(context.Context, int64, int, string, time.Time) error
+	RetryJobFunc                    func(context.Context, string) error
+	RevokeAPIKeyFunc                func(context.Context, string, string, string) (*store.APIKey, error)
+	RevokeCreditGrantFunc           func(context.Context, string, string) error
+	RollbackProviderAPIKeyFunc      func(context.Context, string) error
+	SearchAPIKeysFunc               func(context.Context, store.APIKeyFilters) ([]store.APIKey, error)
+	SetAPIKeySigningSecretFunc      func(context.Context, string, string, string, string) error
+	SetExchangeRateFunc             func(context.Context, string, float64) error
+	SetModelProviderEntriesFunc     func(context.Context, string, []store.ModelProviderEntry) error
+	SetPasswordFunc                 func(context.Context, string, string, string) error
+	SetPendingTOTPSecretFunc        func(context.Context, string, string, string) error
+	SetProviderCircuitSettingsFunc  func(context.Context, string, store.CircuitSettings) error
+	SetProviderMaintenanceFunc      func(context.Context, string, bool) error
+	SetProviderNetworkFunc          func(context.Context, string, store.NetworkSettings) error
+	SetProviderRateBudgetFunc       func(context.Context, string, int, int) error
+	SetSpendAlertNotifyErrorFunc    func(context.Context, string, string) error
+	SetTenantCurrencyFunc           func(context.Context, string, string) error
+	SetTenantOverdraftFunc          func(context.Context, string, float64) error
+	SetTenantRequire2FAFunc         func(context.Context, string, bool) error
+	StageProviderAPIKeyFunc         func(context.Context, string, string) error
+	SuspendTenantFunc               func(context.Context, string, bool) error
+	TenantOwnerEmailsFunc           func(context.Context, string) ([]string, error)
+	TenantRequires2FAFunc           func(context.Context, string) (bool, error)
+	TenantSpendOnFunc               func(context.Context, string, time.Time) (float64, error)
+	TenantSpendSinceFunc            func(context.Context, string, time.Time) (float64, error)
+	TrackNegativeBalancesFunc       func(context.Context) error
+	UpdateAlertRuleFunc             func(context.Context, store.AlertRule) error
+	UpdatePromptTemplateFunc        func(context.Context, store.PromptTemplate) error
+	UpdateProviderFunc              func(context.Context, store.Provider) error
+	UpdateProviderAPIKeyFunc        func(context.Context, string, string) error
+	UpdateTenantBalanceFunc         func(context.Context, string, float64) error
+	UpdateTenantLastActiveFunc      func(context.Context, string, time.Time) error
+	UpdateTenantLimitsFunc          func(context.Context, string, int, float64) error
+	UpdateTenantMemberRoleFunc      func(context.Context, string, string, string) error
+	UpdateTenantSpendAlertFunc      func(context.Context, store.TenantSpendAlert) error
+	UpdateTenantTierFunc            func(context.Context, string, string) error
+	UpdateWebhookFunc               func(context.Context, store.Webhook) error
+	UpsertExperimentFunc            func(context.Context, store.Experiment) error
+	UpsertFeedRatesFunc             func(context.Context, string, map[string]float64) (int, error)
+	UpsertModelPricingFunc          func(context.Context, store.ModelPricing) error
+	UpsertModerationPolicyFunc      func(context.Context, store.ModerationPolicy) error
+	UpsertProviderFunc              func(context.Context, store.Provider) error
+	UpsertRedactionPolicyFunc       func(context.Context, store.RedactionPolicy) error
+	UpsertRequestPolicyFunc         func(context.Context, store.RequestPolicy) error
+	UpsertRoutingRuleFunc           func(context.Context, store.RoutingRule) error
+	UseTOTPBackupCodeFunc           func(context.Context, string, string, string) (bool, error)
+	UseTOTPStepFunc                 func(context.Context, string, string, int64) (bool, error)
+}
+
+func (m *Store) AcceptInvitation(p0 context.Context, p1 string, p2 store.TenantUser) (r0 *store.Invitation, r1 error) {
+	if m.AcceptInvitationFunc != nil {
+		return m.AcceptInvitationFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ActiveMaintenanceEnds(p0 context.Context) (r0 map[string]time.Time, r1 error) {
+	if m.ActiveMaintenanceEndsFunc != nil {
+		return m.ActiveMaintenanceEndsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) AddAPIKeyUsage(p0 context.Context, p1 []store.APIKeyUsage) (r0 error) {
+	if m.AddAPIKeyUsageFunc != nil {
+		return m.AddAPIKeyUsageFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) AddModelCatalog(p0 context.Context, p1 store.ModelCatalog) (r0 error) {
+	if m.AddModelCatalogFunc != nil {
+		return m.AddModelCatalogFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) AddTenantTopup(p0 context.Context, p1 string, p2 float64) (r0 error) {
+	if m.AddTenantTopupFunc != nil {
+		return m.AddTenantTopupFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) AddUsageCost(p0 context.Context, p1 string, p2 string, p3 string, p4 int, p5 float64, p6 time.Time) (r0 error) {
+	if m.AddUsageCostFunc != nil {
+		return m.AddUsageCostFunc(p0, p1, p2, p3, p4, p5, p6)
+	}
+	return
+}
+
+func (m *Store) AdjustTenantBalance(p0 context.Context, p1 string, p2 float64, p3 string) (r0 float64, r1 error) {
+	if m.AdjustTenantBalanceFunc != nil {
+		return m.AdjustTenantBalanceFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) AlertMetricValues(p0 context.Context, p1 string, p2 string, p3 time.Duration) (r0 map[string]float64, r1 error) {
+	if m.AlertMetricValuesFunc != nil {
+		return m.AlertMetricValuesFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ApplyRoutingConfig(p0 context.Context, p1 store.RoutingConfig, p2 bool) (r0 error) {
+	if m.ApplyRoutingConfigFunc != nil {
+		return m.ApplyRoutingConfigFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ArchiveModel(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.ArchiveModelFunc != nil {
+		return m.ArchiveModelFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ArchiveProvider(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.ArchiveProviderFunc != nil {
+		return m.ArchiveProviderFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) AutoSuspendTenant(p0 context.Context, p1 string, p2 string) (r0 bool, r1 error) {
+	if m.AutoSuspendTenantFunc != nil {
+		return m.AutoSuspendTenantFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) Bootstrap(p0 context.Context, p1 store.AdminUser, p2 store.Tenant, p3 store.TenantUser, p4 store.APIKey) (r0 error) {
+	if m.BootstrapFunc != nil {
+		return m.BootstrapFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) CancelBatch(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.CancelBatchFunc != nil {
+		return m.CancelBatchFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ChargeTenant(p0 context.Context, p1 string, p2 float64) (r0 store.Charge, r1 error) {
+	if m.ChargeTenantFunc != nil {
+		return m.ChargeTenantFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ClaimAlertTransition(p0 context.Context, p1 store.AlertRule, p2 string, p3 time.Time) (r0 bool, r1 error) {
+	if m.ClaimAlertTransitionFunc != nil {
+		return m.ClaimAlertTransitionFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ClaimBatchItems(p0 context.Context, p1 int, p2 int, p3 time.Duration) (r0 []store.BatchItem, r1 error) {
+	if m.ClaimBatchItemsFunc != nil {
+		return m.ClaimBatchItemsFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ClaimDueWebhookDeliveries(p0 context.Context, p1 int, p2 time.Duration) (r0 []store.WebhookDelivery, r1 error) {
+	if m.ClaimDueWebhookDeliveriesFunc != nil {
+		return m.ClaimDueWebhookDeliveriesFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ClaimJobs(p0 context.Context, p1 []string, p2 int, p3 time.Duration, p4 string) (r0 []store.Job, r1 error) {
+	if m.ClaimJobsFunc != nil {
+		return m.ClaimJobsFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) ClaimSpendAlert(p0 context.Context, p1 string, p2 string, p3 time.Time) (r0 bool, r1 error) {
+	if m.ClaimSpendAlertFunc != nil {
+		return m.ClaimSpendAlertFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ClaimTOTPAttempt(p0 context.Context, p1 string, p2 string, p3 int, p4 time.Duration) (r0 bool, r1 error) {
+	if m.ClaimTOTPAttemptFunc != nil {
+		return m.ClaimTOTPAttemptFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) ClearStagedProviderAPIKey(p0 context.Context, p1 string) (r0 error) {
+	if m.ClearStagedProviderAPIKeyFunc != nil {
+		return m.ClearStagedProviderAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CountProviderEvents(p0 context.Context, p1 store.ProviderEventFilters) (r0 []store.ProviderEventCount, r1 error) {
+	if m.CountProviderEventsFunc != nil {
+		return m.CountProviderEventsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateAPIKey(p0 context.Context, p1 store.APIKey) (r0 error) {
+	if m.CreateAPIKeyFunc != nil {
+		return m.CreateAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateAdminUser(p0 context.Context, p1 store.AdminUser) (r0 error) {
+	if m.CreateAdminUserFunc != nil {
+		return m.CreateAdminUserFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateAlertRule(p0 context.Context, p1 store.AlertRule) (r0 error) {
+	if m.CreateAlertRuleFunc != nil {
+		return m.CreateAlertRuleFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateBatch(p0 context.Context, p1 store.Batch, p2 []store.BatchItem) (r0 error) {
+	if m.CreateBatchFunc != nil {
+		return m.CreateBatchFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) CreateInvitation(p0 context.Context, p1 store.Invitation) (r0 error) {
+	if m.CreateInvitationFunc != nil {
+		return m.CreateInvitationFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateMaintenanceWindow(p0 context.Context, p1 store.MaintenanceWindow) (r0 store.MaintenanceWindow, r1 error) {
+	if m.CreateMaintenanceWindowFunc != nil {
+		return m.CreateMaintenanceWindowFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreatePromptTemplate(p0 context.Context, p1 store.PromptTemplate) (r0 error) {
+	if m.CreatePromptTemplateFunc != nil {
+		return m.CreatePromptTemplateFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateTenant(p0 context.Context, p1 store.Tenant) (r0 error) {
+	if m.CreateTenantFunc != nil {
+		return m.CreateTenantFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateTenantAccount(p0 context.Context, p1 store.Tenant, p2 store.TenantUser, p3 *store.APIKey) (r0 error) {
+	if m.CreateTenantAccountFunc != nil {
+		return m.CreateTenantAccountFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) CreateTenantSpendAlert(p0 context.Context, p1 store.TenantSpendAlert) (r0 error) {
+	if m.CreateTenantSpendAlertFunc != nil {
+		return m.CreateTenantSpendAlertFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateTenantUser(p0 context.Context, p1 store.TenantUser) (r0 error) {
+	if m.CreateTenantUserFunc != nil {
+		return m.CreateTenantUserFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateWebhook(p0 context.Context, p1 store.Webhook) (r0 int, r1 error) {
+	if m.CreateWebhookFunc != nil {
+		return m.CreateWebhookFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) CreateWebhookDelivery(p0 context.Context, p1 store.WebhookDelivery) (r0 error) {
+	if m.CreateWebhookDeliveryFunc != nil {
+		return m.CreateWebhookDeliveryFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) DeferBatchItem(p0 context.Context, p1 int64, p2 int, p3 string, p4 time.Time) (r0 error) {
+	if m.DeferBatchItemFunc != nil {
+		return m.DeferBatchItemFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) DeleteAPIKey(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.DeleteAPIKeyFunc != nil {
+		return m.DeleteAPIKeyFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeleteAlertRule(p0 context.Context, p1 string) (r0 error) {
+	if m.DeleteAlertRuleFunc != nil {
+		return m.DeleteAlertRuleFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) DeleteExchangeRate(p0 context.Context, p1 string) (r0 error) {
+	if m.DeleteExchangeRateFunc != nil {
+		return m.DeleteExchangeRateFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) DeleteExperiment(p0 context.Context, p1 string) (r0 error) {
+	if m.DeleteExperimentFunc != nil {
+		return m.DeleteExperimentFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) DeleteInvitation(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.DeleteInvitationFunc != nil {
+		return m.DeleteInvitationFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeleteMaintenanceWindow(p0 context.Context, p1 string, p2 int64) (r0 error) {
+	if m.DeleteMaintenanceWindowFunc != nil {
+		return m.DeleteMaintenanceWindowFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeletePromptTemplate(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.DeletePromptTemplateFunc != nil {
+		return m.DeletePromptTemplateFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeleteRequestLog(p0 context.Context, p1 int) (r0 error) {
+	if m.DeleteRequestLogFunc != nil {
+		return m.DeleteRequestLogFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) DeleteRoutingRule(p0 context.Context, p1 string) (r0 error) {
+	if m.DeleteRoutingRuleFunc != nil {
+		return m.DeleteRoutingRuleFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) DeleteTenant(p0 context.Context, p1 string, p2 string) (r0 *store.TenantArchive, r1 error) {
+	if m.DeleteTenantFunc != nil {
+		return m.DeleteTenantFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeleteTenantMember(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.DeleteTenantMemberFunc != nil {
+		return m.DeleteTenantMemberFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeleteTenantSpendAlert(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.DeleteTenantSpendAlertFunc != nil {
+		return m.DeleteTenantSpendAlertFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeleteTenantWebhook(p0 context.Context, p1 string, p2 int) (r0 error) {
+	if m.DeleteTenantWebhookFunc != nil {
+		return m.DeleteTenantWebhookFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) DeleteWebhook(p0 context.Context, p1 int) (r0 error) {
+	if m.DeleteWebhookFunc != nil {
+		return m.DeleteWebhookFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) DisableTOTP(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.DisableTOTPFunc != nil {
+		return m.DisableTOTPFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) EachDailyUsage(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 func(store.DailyUsage) error) (r0 error) {
+	if m.EachDailyUsageFunc != nil {
+		return m.EachDailyUsageFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) EachRequestLog(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 func(models.RequestLog) error) (r0 error) {
+	if m.EachRequestLogFunc != nil {
+		return m.EachRequestLogFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) EachTransaction(p0 context.Context, p1 string, p2 func(store.BalanceTransaction) error) (r0 error) {
+	if m.EachTransactionFunc != nil {
+		return m.EachTransactionFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) EnableTOTP(p0 context.Context, p1 string, p2 string, p3 []string) (r0 error) {
+	if m.EnableTOTPFunc != nil {
+		return m.EnableTOTPFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) EnqueueJob(p0 context.Context, p1 store.Job) (r0 bool, r1 error) {
+	if m.EnqueueJobFunc != nil {
+		return m.EnqueueJobFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) EraseTenant(p0 context.Context, p1 string, p2 string) (r0 *store.TenantArchive, r1 error) {
+	if m.EraseTenantFunc != nil {
+		return m.EraseTenantFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ExpireCreditGrants(p0 context.Context) (r0 int, r1 error) {
+	if m.ExpireCreditGrantsFunc != nil {
+		return m.ExpireCreditGrantsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) FinishBatchItem(p0 context.Context, p1 store.BatchItem) (r0 error) {
+	if m.FinishBatchItemFunc != nil {
+		return m.FinishBatchItemFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) FinishJob(p0 context.Context, p1 string, p2 string, p3 string, p4 time.Time) (r0 error) {
+	if m.FinishJobFunc != nil {
+		return m.FinishJobFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) FreeUsageOn(p0 context.Context, p1 string, p2 string, p3 time.Time) (r0 int64, r1 int64, r2 error) {
+	if m.FreeUsageOnFunc != nil {
+		return m.FreeUsageOnFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) GetAPIKey(p0 context.Context, p1 string) (r0 *store.APIKey, r1 error) {
+	if m.GetAPIKeyFunc != nil {
+		return m.GetAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetActiveExperiment(p0 context.Context, p1 string, p2 string) (r0 *store.Experiment, r1 error) {
+	if m.GetActiveExperimentFunc != nil {
+		return m.GetActiveExperimentFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetAdminByUsername(p0 context.Context, p1 string) (r0 *store.AdminUser, r1 error) {
+	if m.GetAdminByUsernameFunc != nil {
+		return m.GetAdminByUsernameFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetAdminDashboardStats(p0 context.Context, p1 store.UsageWindow) (r0 *store.AdminDashboardStats, r1 error) {
+	if m.GetAdminDashboardStatsFunc != nil {
+		return m.GetAdminDashboardStatsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetAlertRule(p0 context.Context, p1 string) (r0 *store.AlertRule, r1 error) {
+	if m.GetAlertRuleFunc != nil {
+		return m.GetAlertRuleFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetAttemptFailures(p0 context.Context, p1 time.Time, p2 time.Time, p3 string, p4 string) (r0 []store.AttemptFailure, r1 error) {
+	if m.GetAttemptFailuresFunc != nil {
+		return m.GetAttemptFailuresFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) GetBatch(p0 context.Context, p1 string, p2 string) (r0 *store.Batch, r1 error) {
+	if m.GetBatchFunc != nil {
+		return m.GetBatchFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetEnabledProvidersByType(p0 context.Context, p1 string) (r0 []store.Provider, r1 error) {
+	if m.GetEnabledProvidersByTypeFunc != nil {
+		return m.GetEnabledProvidersByTypeFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetEnabledWebhooks(p0 context.Context, p1 string, p2 string) (r0 []store.Webhook, r1 error) {
+	if m.GetEnabledWebhooksFunc != nil {
+		return m.GetEnabledWebhooksFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetExchangeRate(p0 context.Context, p1 string) (r0 *store.ExchangeRate, r1 error) {
+	if m.GetExchangeRateFunc != nil {
+		return m.GetExchangeRateFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetExperiment(p0 context.Context, p1 string) (r0 *store.Experiment, r1 error) {
+	if m.GetExperimentFunc != nil {
+		return m.GetExperimentFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetExperimentResults(p0 context.Context, p1 string) (r0 []store.ExperimentArmStats, r1 error) {
+	if m.GetExperimentResultsFunc != nil {
+		return m.GetExperimentResultsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetJob(p0 context.Context, p1 string) (r0 *store.Job, r1 error) {
+	if m.GetJobFunc != nil {
+		return m.GetJobFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetMarginReport(p0 context.Context, p1 time.Time, p2 time.Time) (r0 *store.MarginReport, r1 error) {
+	if m.GetMarginReportFunc != nil {
+		return m.GetMarginReportFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetModelFreeAllowance(p0 context.Context, p1 string) (r0 store.FreeAllowance, r1 error) {
+	if m.GetModelFreeAllowanceFunc != nil {
+		return m.GetModelFreeAllowanceFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetModelPrice(p0 context.Context, p1 string) (r0 float64, r1 bool, r2 error) {
+	if m.GetModelPriceFunc != nil {
+		return m.GetModelPriceFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetModelProvider(p0 context.Context, p1 string) (r0 string, r1 bool, r2 error) {
+	if m.GetModelProviderFunc != nil {
+		return m.GetModelProviderFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetModelProviderChain(p0 context.Context, p1 string) (r0 []store.Provider, r1 error) {
+	if m.GetModelProviderChainFunc != nil {
+		return m.GetModelProviderChainFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetModelSLO(p0 context.Context, p1 string) (r0 store.ModelSLO, r1 error) {
+	if m.GetModelSLOFunc != nil {
+		return m.GetModelSLOFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetModerationPolicy(p0 context.Context, p1 string) (r0 *store.ModerationPolicy, r1 error) {
+	if m.GetModerationPolicyFunc != nil {
+		return m.GetModerationPolicyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetPromptTemplate(p0 context.Context, p1 string, p2 string) (r0 *store.PromptTemplate, r1 error) {
+	if m.GetPromptTemplateFunc != nil {
+		return m.GetPromptTemplateFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetPromptTemplateByName(p0 context.Context, p1 string, p2 string) (r0 *store.PromptTemplate, r1 error) {
+	if m.GetPromptTemplateByNameFunc != nil {
+		return m.GetPromptTemplateByNameFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetProviderByID(p0 context.Context, p1 string) (r0 *store.Provider, r1 error) {
+	if m.GetProviderByIDFunc != nil {
+		return m.GetProviderByIDFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetProviders(p0 context.Context) (r0 []store.Provider, r1 error) {
+	if m.GetProvidersFunc != nil {
+		return m.GetProvidersFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetReconciliation(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 float64) (r0 *store.ReconciliationReport, r1 error) {
+	if m.GetReconciliationFunc != nil {
+		return m.GetReconciliationFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) GetRedactionPolicy(p0 context.Context, p1 string) (r0 *store.RedactionPolicy, r1 error) {
+	if m.GetRedactionPolicyFunc != nil {
+		return m.GetRedactionPolicyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetRequestLog(p0 context.Context, p1 int) (r0 *models.RequestLog, r1 error) {
+	if m.GetRequestLogFunc != nil {
+		return m.GetRequestLogFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetRequestPolicy(p0 context.Context, p1 string) (r0 *store.RequestPolicy, r1 error) {
+	if m.GetRequestPolicyFunc != nil {
+		return m.GetRequestPolicyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetRoutingConfig(p0 context.Context) (r0 store.RoutingConfig, r1 error) {
+	if m.GetRoutingConfigFunc != nil {
+		return m.GetRoutingConfigFunc(p0)
+	}
+	return
+}
+
+func (m *Store) GetRoutingRuleByID(p0 context.Context, p1 string) (r0 *store.RoutingRule, r1 error) {
+	if m.GetRoutingRuleByIDFunc != nil {
+		return m.GetRoutingRuleByIDFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetSigningSecret(p0 context.Context, p1 string) (r0 string, r1 string, r2 error) {
+	if m.GetSigningSecretFunc != nil {
+		return m.GetSigningSecretFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetStagedProviderAPIKey(p0 context.Context, p1 string) (r0 string, r1 error) {
+	if m.GetStagedProviderAPIKeyFunc != nil {
+		return m.GetStagedProviderAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetTenantAnalytics(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 int) (r0 *store.TenantAnalytics, r1 error) {
+	if m.GetTenantAnalyticsFunc != nil {
+		return m.GetTenantAnalyticsFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) GetTenantByAPIKey(p0 context.Context, p1 string) (r0 *store.Tenant, r1 error) {
+	if m.GetTenantByAPIKeyFunc != nil {
+		return m.GetTenantByAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetTenantByID(p0 context.Context, p1 string) (r0 *store.Tenant, r1 error) {
+	if m.GetTenantByIDFunc != nil {
+		return m.GetTenantByIDFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetTenantCurrency(p0 context.Context, p1 string) (r0 string, r1 float64, r2 error) {
+	if m.GetTenantCurrencyFunc != nil {
+		return m.GetTenantCurrencyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetTenantRequestSummary(p0 context.Context, p1 string, p2 store.UsageWindow) (r0 *store.TenantRequestSummary, r1 error) {
+	if m.GetTenantRequestSummaryFunc != nil {
+		return m.GetTenantRequestSummaryFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetTenantSpendAlert(p0 context.Context, p1 string, p2 string) (r0 *store.TenantSpendAlert, r1 error) {
+	if m.GetTenantSpendAlertFunc != nil {
+		return m.GetTenantSpendAlertFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) GetTenantUserByUsername(p0 context.Context, p1 string) (r0 *store.TenantUser, r1 error) {
+	if m.GetTenantUserByUsernameFunc != nil {
+		return m.GetTenantUserByUsernameFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetUsageByTag(p0 context.Context, p1 string, p2 string, p3 time.Time, p4 time.Time) (r0 []store.TagUsage, r1 error) {
+	if m.GetUsageByTagFunc != nil {
+		return m.GetUsageByTagFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) GetWebhook(p0 context.Context, p1 int) (r0 *store.Webhook, r1 error) {
+	if m.GetWebhookFunc != nil {
+		return m.GetWebhookFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GetWebhookDelivery(p0 context.Context, p1 string) (r0 *store.WebhookDelivery, r1 error) {
+	if m.GetWebhookDeliveryFunc != nil {
+		return m.GetWebhookDeliveryFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) GrantCredits(p0 context.Context, p1 store.CreditGrant) (r0 error) {
+	if m.GrantCreditsFunc != nil {
+		return m.GrantCreditsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ImportProviderBilling(p0 context.Context, p1 string, p2 string, p3 string, p4 []store.ProviderBillingLine) (r0 error) {
+	if m.ImportProviderBillingFunc != nil {
+		return m.ImportProviderBillingFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) InsertAlertEvent(p0 context.Context, p1 store.AlertEvent) (r0 error) {
+	if m.InsertAlertEventFunc != nil {
+		return m.InsertAlertEventFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) InsertAuditEntry(p0 context.Context, p1 store.AuditEntry) (r0 error) {
+	if m.InsertAuditEntryFunc != nil {
+		return m.InsertAuditEntryFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) InsertModerationEvent(p0 context.Context, p1 store.ModerationEvent) (r0 error) {
+	if m.InsertModerationEventFunc != nil {
+		return m.InsertModerationEventFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) InsertProviderEvent(p0 context.Context, p1 store.ProviderEvent) (r0 error) {
+	if m.InsertProviderEventFunc != nil {
+		return m.InsertProviderEventFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) InsertRequestLog(p0 context.Context, p1 models.RequestLog) (r0 error) {
+	if m.InsertRequestLogFunc != nil {
+		return m.InsertRequestLogFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) JobCounts(p0 context.Context) (r0 []store.JobCount, r1 error) {
+	if m.JobCountsFunc != nil {
+		return m.JobCountsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListAPIKeysByTenant(p0 context.Context, p1 string) (r0 []store.APIKey, r1 error) {
+	if m.ListAPIKeysByTenantFunc != nil {
+		return m.ListAPIKeysByTenantFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListAbusiveTenants(p0 context.Context, p1 store.AbuseThresholds) (r0 []store.Delinquent, r1 error) {
+	if m.ListAbusiveTenantsFunc != nil {
+		return m.ListAbusiveTenantsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListAlertEvents(p0 context.Context, p1 string, p2 int) (r0 []store.AlertEvent, r1 error) {
+	if m.ListAlertEventsFunc != nil {
+		return m.ListAlertEventsFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ListAlertRules(p0 context.Context) (r0 []store.AlertRule, r1 error) {
+	if m.ListAlertRulesFunc != nil {
+		return m.ListAlertRulesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListAllModels(p0 context.Context) (r0 []store.ModelInfo, r1 error) {
+	if m.ListAllModelsFunc != nil {
+		return m.ListAllModelsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListArchivedModels(p0 context.Context) (r0 []store.ArchivedModel, r1 error) {
+	if m.ListArchivedModelsFunc != nil {
+		return m.ListArchivedModelsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListAuditLog(p0 context.Context, p1 store.AuditFilters) (r0 []store.AuditEntry, r1 error) {
+	if m.ListAuditLogFunc != nil {
+		return m.ListAuditLogFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListBatchResults(p0 context.Context, p1 string) (r0 []store.BatchItem, r1 error) {
+	if m.ListBatchResultsFunc != nil {
+		return m.ListBatchResultsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListBatches(p0 context.Context, p1 string, p2 int) (r0 []store.Batch, r1 error) {
+	if m.ListBatchesFunc != nil {
+		return m.ListBatchesFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ListCreditGrants(p0 context.Context, p1 string) (r0 []store.CreditGrant, r1 error) {
+	if m.ListCreditGrantsFunc != nil {
+		return m.ListCreditGrantsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListEnabledAlertRules(p0 context.Context) (r0 []store.AlertRule, r1 error) {
+	if m.ListEnabledAlertRulesFunc != nil {
+		return m.ListEnabledAlertRulesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListEnabledSpendAlerts(p0 context.Context, p1 string) (r0 []store.TenantSpendAlert, r1 error) {
+	if m.ListEnabledSpendAlertsFunc != nil {
+		return m.ListEnabledSpendAlertsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListExchangeRates(p0 context.Context) (r0 []store.ExchangeRate, r1 error) {
+	if m.ListExchangeRatesFunc != nil {
+		return m.ListExchangeRatesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListExperiments(p0 context.Context, p1 string) (r0 []store.Experiment, r1 error) {
+	if m.ListExperimentsFunc != nil {
+		return m.ListExperimentsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListFreeAllowances(p0 context.Context) (r0 []store.FreeAllowance, r1 error) {
+	if m.ListFreeAllowancesFunc != nil {
+		return m.ListFreeAllowancesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListJobs(p0 context.Context, p1 store.JobFilters) (r0 []store.Job, r1 error) {
+	if m.ListJobsFunc != nil {
+		return m.ListJobsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListMaintenanceWindows(p0 context.Context, p1 string) (r0 []store.MaintenanceWindow, r1 error) {
+	if m.ListMaintenanceWindowsFunc != nil {
+		return m.ListMaintenanceWindowsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListModelPricing(p0 context.Context) (r0 []store.ModelPricing, r1 error) {
+	if m.ListModelPricingFunc != nil {
+		return m.ListModelPricingFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListModelProviderEntries(p0 context.Context, p1 string) (r0 []store.ModelProviderEntry, r1 error) {
+	if m.ListModelProviderEntriesFunc != nil {
+		return m.ListModelProviderEntriesFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListModelSLOs(p0 context.Context) (r0 []store.ModelSLO, r1 error) {
+	if m.ListModelSLOsFunc != nil {
+		return m.ListModelSLOsFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListModelUsage(p0 context.Context) (r0 []store.ModelUsageSummary, r1 error) {
+	if m.ListModelUsageFunc != nil {
+		return m.ListModelUsageFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListModelsByProviderType(p0 context.Context, p1 string) (r0 []string, r1 error) {
+	if m.ListModelsByProviderTypeFunc != nil {
+		return m.ListModelsByProviderTypeFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListModerationEvents(p0 context.Context, p1 string, p2 time.Time, p3 time.Time, p4 int) (r0 []store.ModerationEvent, r1 error) {
+	if m.ListModerationEventsFunc != nil {
+		return m.ListModerationEventsFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) ListNegativeBalanceTenants(p0 context.Context, p1 int) (r0 []store.Delinquent, r1 error) {
+	if m.ListNegativeBalanceTenantsFunc != nil {
+		return m.ListNegativeBalanceTenantsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListPendingInvitations(p0 context.Context, p1 string) (r0 []store.Invitation, r1 error) {
+	if m.ListPendingInvitationsFunc != nil {
+		return m.ListPendingInvitationsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListPromptTemplates(p0 context.Context, p1 string) (r0 []store.PromptTemplate, r1 error) {
+	if m.ListPromptTemplatesFunc != nil {
+		return m.ListPromptTemplatesFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListProviderEvents(p0 context.Context, p1 store.ProviderEventFilters) (r0 []store.ProviderEvent, r1 error) {
+	if m.ListProviderEventsFunc != nil {
+		return m.ListProviderEventsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListProviders(p0 context.Context) (r0 []store.Provider, r1 error) {
+	if m.ListProvidersFunc != nil {
+		return m.ListProvidersFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListRequestLogs(p0 context.Context, p1 int) (r0 []models.RequestLog, r1 error) {
+	if m.ListRequestLogsFunc != nil {
+		return m.ListRequestLogsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListRequestLogsPaginated(p0 context.Context, p1 int, p2 int, p3 store.RequestLogFilters) (r0 *store.PaginatedRequestLogs, r1 error) {
+	if m.ListRequestLogsPaginatedFunc != nil {
+		return m.ListRequestLogsPaginatedFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ListRoutingRules(p0 context.Context) (r0 []store.RoutingRule, r1 error) {
+	if m.ListRoutingRulesFunc != nil {
+		return m.ListRoutingRulesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ListRoutingRulesByTenant(p0 context.Context, p1 string) (r0 []store.RoutingRule, r1 error) {
+	if m.ListRoutingRulesByTenantFunc != nil {
+		return m.ListRoutingRulesByTenantFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListTenantArchives(p0 context.Context, p1 int) (r0 []store.TenantArchive, r1 error) {
+	if m.ListTenantArchivesFunc != nil {
+		return m.ListTenantArchivesFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListTenantMembers(p0 context.Context, p1 string) (r0 []store.TenantMember, r1 error) {
+	if m.ListTenantMembersFunc != nil {
+		return m.ListTenantMembersFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListTenantSpendAlerts(p0 context.Context, p1 string) (r0 []store.TenantSpendAlert, r1 error) {
+	if m.ListTenantSpendAlertsFunc != nil {
+		return m.ListTenantSpendAlertsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListTenantUsage(p0 context.Context, p1 string, p2 store.UsageWindow, p3 int) (r0 []store.DailyUsage, r1 error) {
+	if m.ListTenantUsageFunc != nil {
+		return m.ListTenantUsageFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ListTenantWebhooks(p0 context.Context, p1 string) (r0 []store.Webhook, r1 error) {
+	if m.ListTenantWebhooksFunc != nil {
+		return m.ListTenantWebhooksFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListTenantsPaginated(p0 context.Context, p1 int, p2 int, p3 store.TenantListFilters) (r0 *store.PaginatedTenants, r1 error) {
+	if m.ListTenantsPaginatedFunc != nil {
+		return m.ListTenantsPaginatedFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ListTransactions(p0 context.Context, p1 string, p2 int) (r0 []store.BalanceTransaction, r1 error) {
+	if m.ListTransactionsFunc != nil {
+		return m.ListTransactionsFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ListTransactionsPaginated(p0 context.Context, p1 string, p2 int, p3 int, p4 store.TransactionFilters) (r0 *store.PaginatedTransactions, r1 error) {
+	if m.ListTransactionsPaginatedFunc != nil {
+		return m.ListTransactionsPaginatedFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) ListWebhookDeliveries(p0 context.Context, p1 store.WebhookDeliveryFilters) (r0 []store.WebhookDelivery, r1 error) {
+	if m.ListWebhookDeliveriesFunc != nil {
+		return m.ListWebhookDeliveriesFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) ListWebhooks(p0 context.Context) (r0 []store.Webhook, r1 error) {
+	if m.ListWebhooksFunc != nil {
+		return m.ListWebhooksFunc(p0)
+	}
+	return
+}
+
+func (m *Store) MergeTenants(p0 context.Context, p1 string, p2 string, p3 string) (r0 *store.TenantArchive, r1 error) {
+	if m.MergeTenantsFunc != nil {
+		return m.MergeTenantsFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) NeedsSetup(p0 context.Context) (r0 bool, r1 error) {
+	if m.NeedsSetupFunc != nil {
+		return m.NeedsSetupFunc(p0)
+	}
+	return
+}
+
+func (m *Store) Ping(p0 context.Context) (r0 error) {
+	if m.PingFunc != nil {
+		return m.PingFunc(p0)
+	}
+	return
+}
+
+func (m *Store) PromoteStagedProviderAPIKey(p0 context.Context, p1 string) (r0 error) {
+	if m.PromoteStagedProviderAPIKeyFunc != nil {
+		return m.PromoteStagedProviderAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) PruneJobs(p0 context.Context, p1 time.Time) (r0 int64, r1 error) {
+	if m.PruneJobsFunc != nil {
+		return m.PruneJobsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RecordCacheLookup(p0 context.Context, p1 bool, p2 time.Time) (r0 error) {
+	if m.RecordCacheLookupFunc != nil {
+		return m.RecordCacheLookupFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) RecordFreeUsage(p0 context.Context, p1 string, p2 string, p3 string, p4 int, p5 time.Time) (r0 error) {
+	if m.RecordFreeUsageFunc != nil {
+		return m.RecordFreeUsageFunc(p0, p1, p2, p3, p4, p5)
+	}
+	return
+}
+
+func (m *Store) RecordTransaction(p0 context.Context, p1 string, p2 string, p3 float64, p4 float64, p5 string) (r0 error) {
+	if m.RecordTransactionFunc != nil {
+		return m.RecordTransactionFunc(p0, p1, p2, p3, p4, p5)
+	}
+	return
+}
+
+func (m *Store) RecordUsageDaily(p0 context.Context, p1 string, p2 string, p3 string, p4 int, p5 time.Time) (r0 error) {
+	if m.RecordUsageDailyFunc != nil {
+		return m.RecordUsageDailyFunc(p0, p1, p2, p3, p4, p5)
+	}
+	return
+}
+
+func (m *Store) RecordWebhookAttempt(p0 context.Context, p1 string, p2 store.WebhookDeliveryAttempt, p3 string, p4 time.Time) (r0 error) {
+	if m.RecordWebhookAttemptFunc != nil {
+		return m.RecordWebhookAttemptFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) ReencryptProviderKeys(p0 context.Context) (r0 int, r1 error) {
+	if m.ReencryptProviderKeysFunc != nil {
+		return m.ReencryptProviderKeysFunc(p0)
+	}
+	return
+}
+
+func (m *Store) ReleaseBalanceSuspensions(p0 context.Context, p1 string) (r0 []string, r1 error) {
+	if m.ReleaseBalanceSuspensionsFunc != nil {
+		return m.ReleaseBalanceSuspensionsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RenameTenant(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.RenameTenantFunc != nil {
+		return m.RenameTenantFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) ReplaceTOTPBackupCodes(p0 context.Context, p1 string, p2 string, p3 []string) (r0 error) {
+	if m.ReplaceTOTPBackupCodesFunc != nil {
+		return m.ReplaceTOTPBackupCodesFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) ResetTOTPAttempts(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.ResetTOTPAttemptsFunc != nil {
+		return m.ResetTOTPAttemptsFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) RestoreModel(p0 context.Context, p1 string) (r0 error) {
+	if m.RestoreModelFunc != nil {
+		return m.RestoreModelFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RestoreProvider(p0 context.Context, p1 string) (r0 error) {
+	if m.RestoreProviderFunc != nil {
+		return m.RestoreProviderFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RetryBatchItem(p0 context.Context, p1 int64, p2 int, p3 string, p4 time.Time) (r0 error) {
+	if m.RetryBatchItemFunc != nil {
+		return m.RetryBatchItemFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) RetryJob(p0 context.Context, p1 string) (r0 error) {
+	if m.RetryJobFunc != nil {
+		return m.RetryJobFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) RevokeAPIKey(p0 context.Context, p1 string, p2 string, p3 string) (r0 *store.APIKey, r1 error) {
+	if m.RevokeAPIKeyFunc != nil {
+		return m.RevokeAPIKeyFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) RevokeCreditGrant(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.RevokeCreditGrantFunc != nil {
+		return m.RevokeCreditGrantFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) RollbackProviderAPIKey(p0 context.Context, p1 string) (r0 error) {
+	if m.RollbackProviderAPIKeyFunc != nil {
+		return m.RollbackProviderAPIKeyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) SearchAPIKeys(p0 context.Context, p1 store.APIKeyFilters) (r0 []store.APIKey, r1 error) {
+	if m.SearchAPIKeysFunc != nil {
+		return m.SearchAPIKeysFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) SetAPIKeySigningSecret(p0 context.Context, p1 string, p2 string, p3 string, p4 string) (r0 error) {
+	if m.SetAPIKeySigningSecretFunc != nil {
+		return m.SetAPIKeySigningSecretFunc(p0, p1, p2, p3, p4)
+	}
+	return
+}
+
+func (m *Store) SetExchangeRate(p0 context.Context, p1 string, p2 float64) (r0 error) {
+	if m.SetExchangeRateFunc != nil {
+		return m.SetExchangeRateFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetModelProviderEntries(p0 context.Context, p1 string, p2 []store.ModelProviderEntry) (r0 error) {
+	if m.SetModelProviderEntriesFunc != nil {
+		return m.SetModelProviderEntriesFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetPassword(p0 context.Context, p1 string, p2 string, p3 string) (r0 error) {
+	if m.SetPasswordFunc != nil {
+		return m.SetPasswordFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) SetPendingTOTPSecret(p0 context.Context, p1 string, p2 string, p3 string) (r0 error) {
+	if m.SetPendingTOTPSecretFunc != nil {
+		return m.SetPendingTOTPSecretFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) SetProviderCircuitSettings(p0 context.Context, p1 string, p2 store.CircuitSettings) (r0 error) {
+	if m.SetProviderCircuitSettingsFunc != nil {
+		return m.SetProviderCircuitSettingsFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetProviderMaintenance(p0 context.Context, p1 string, p2 bool) (r0 error) {
+	if m.SetProviderMaintenanceFunc != nil {
+		return m.SetProviderMaintenanceFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetProviderNetwork(p0 context.Context, p1 string, p2 store.NetworkSettings) (r0 error) {
+	if m.SetProviderNetworkFunc != nil {
+		return m.SetProviderNetworkFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetProviderRateBudget(p0 context.Context, p1 string, p2 int, p3 int) (r0 error) {
+	if m.SetProviderRateBudgetFunc != nil {
+		return m.SetProviderRateBudgetFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) SetSpendAlertNotifyError(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.SetSpendAlertNotifyErrorFunc != nil {
+		return m.SetSpendAlertNotifyErrorFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetTenantCurrency(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.SetTenantCurrencyFunc != nil {
+		return m.SetTenantCurrencyFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetTenantOverdraft(p0 context.Context, p1 string, p2 float64) (r0 error) {
+	if m.SetTenantOverdraftFunc != nil {
+		return m.SetTenantOverdraftFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SetTenantRequire2FA(p0 context.Context, p1 string, p2 bool) (r0 error) {
+	if m.SetTenantRequire2FAFunc != nil {
+		return m.SetTenantRequire2FAFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) StageProviderAPIKey(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.StageProviderAPIKeyFunc != nil {
+		return m.StageProviderAPIKeyFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) SuspendTenant(p0 context.Context, p1 string, p2 bool) (r0 error) {
+	if m.SuspendTenantFunc != nil {
+		return m.SuspendTenantFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) TenantOwnerEmails(p0 context.Context, p1 string) (r0 []string, r1 error) {
+	if m.TenantOwnerEmailsFunc != nil {
+		return m.TenantOwnerEmailsFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) TenantRequires2FA(p0 context.Context, p1 string) (r0 bool, r1 error) {
+	if m.TenantRequires2FAFunc != nil {
+		return m.TenantRequires2FAFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) TenantSpendOn(p0 context.Context, p1 string, p2 time.Time) (r0 float64, r1 error) {
+	if m.TenantSpendOnFunc != nil {
+		return m.TenantSpendOnFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) TenantSpendSince(p0 context.Context, p1 string, p2 time.Time) (r0 float64, r1 error) {
+	if m.TenantSpendSinceFunc != nil {
+		return m.TenantSpendSinceFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) TrackNegativeBalances(p0 context.Context) (r0 error) {
+	if m.TrackNegativeBalancesFunc != nil {
+		return m.TrackNegativeBalancesFunc(p0)
+	}
+	return
+}
+
+func (m *Store) UpdateAlertRule(p0 context.Context, p1 store.AlertRule) (r0 error) {
+	if m.UpdateAlertRuleFunc != nil {
+		return m.UpdateAlertRuleFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpdatePromptTemplate(p0 context.Context, p1 store.PromptTemplate) (r0 error) {
+	if m.UpdatePromptTemplateFunc != nil {
+		return m.UpdatePromptTemplateFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpdateProvider(p0 context.Context, p1 store.Provider) (r0 error) {
+	if m.UpdateProviderFunc != nil {
+		return m.UpdateProviderFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpdateProviderAPIKey(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.UpdateProviderAPIKeyFunc != nil {
+		return m.UpdateProviderAPIKeyFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) UpdateTenantBalance(p0 context.Context, p1 string, p2 float64) (r0 error) {
+	if m.UpdateTenantBalanceFunc != nil {
+		return m.UpdateTenantBalanceFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) UpdateTenantLastActive(p0 context.Context, p1 string, p2 time.Time) (r0 error) {
+	if m.UpdateTenantLastActiveFunc != nil {
+		return m.UpdateTenantLastActiveFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) UpdateTenantLimits(p0 context.Context, p1 string, p2 int, p3 float64) (r0 error) {
+	if m.UpdateTenantLimitsFunc != nil {
+		return m.UpdateTenantLimitsFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) UpdateTenantMemberRole(p0 context.Context, p1 string, p2 string, p3 string) (r0 error) {
+	if m.UpdateTenantMemberRoleFunc != nil {
+		return m.UpdateTenantMemberRoleFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) UpdateTenantSpendAlert(p0 context.Context, p1 store.TenantSpendAlert) (r0 error) {
+	if m.UpdateTenantSpendAlertFunc != nil {
+		return m.UpdateTenantSpendAlertFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpdateTenantTier(p0 context.Context, p1 string, p2 string) (r0 error) {
+	if m.UpdateTenantTierFunc != nil {
+		return m.UpdateTenantTierFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) UpdateWebhook(p0 context.Context, p1 store.Webhook) (r0 error) {
+	if m.UpdateWebhookFunc != nil {
+		return m.UpdateWebhookFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpsertExperiment(p0 context.Context, p1 store.Experiment) (r0 error) {
+	if m.UpsertExperimentFunc != nil {
+		return m.UpsertExperimentFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpsertFeedRates(p0 context.Context, p1 string, p2 map[string]float64) (r0 int, r1 error) {
+	if m.UpsertFeedRatesFunc != nil {
+		return m.UpsertFeedRatesFunc(p0, p1, p2)
+	}
+	return
+}
+
+func (m *Store) UpsertModelPricing(p0 context.Context, p1 store.ModelPricing) (r0 error) {
+	if m.UpsertModelPricingFunc != nil {
+		return m.UpsertModelPricingFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpsertModerationPolicy(p0 context.Context, p1 store.ModerationPolicy) (r0 error) {
+	if m.UpsertModerationPolicyFunc != nil {
+		return m.UpsertModerationPolicyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpsertProvider(p0 context.Context, p1 store.Provider) (r0 error) {
+	if m.UpsertProviderFunc != nil {
+		return m.UpsertProviderFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpsertRedactionPolicy(p0 context.Context, p1 store.RedactionPolicy) (r0 error) {
+	if m.UpsertRedactionPolicyFunc != nil {
+		return m.UpsertRedactionPolicyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpsertRequestPolicy(p0 context.Context, p1 store.RequestPolicy) (r0 error) {
+	if m.UpsertRequestPolicyFunc != nil {
+		return m.UpsertRequestPolicyFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UpsertRoutingRule(p0 context.Context, p1 store.RoutingRule) (r0 error) {
+	if m.UpsertRoutingRuleFunc != nil {
+		return m.UpsertRoutingRuleFunc(p0, p1)
+	}
+	return
+}
+
+func (m *Store) UseTOTPBackupCode(p0 context.Context, p1 string, p2 string, p3 string) (r0 bool, r1 error) {
+	if m.UseTOTPBackupCodeFunc != nil {
+		return m.UseTOTPBackupCodeFunc(p0, p1, p2, p3)
+	}
+	return
+}
+
+func (m *Store) UseTOTPStep(p0 context.Context, p1 string, p2 string, p3 int64) (r0 bool, r1 error) {
+	if m.UseTOTPStepFunc != nil {
+		return m.UseTOTPStepFunc(p0, p1, p2, p3)
+	}
+	return
+}
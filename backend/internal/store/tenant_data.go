@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+)
+
+// ErasedActor replaces the names of a tenant's users in the audit log once
+// the tenant is erased.
+const ErasedActor = "erased"
+
+// EachTransaction calls fn for every ledger entry of a tenant, oldest first,
+// without buffering the result set.
+func (s *Store) EachTransaction(ctx context.Context, tenantID string, fn func(BalanceTransaction) error) error {
+	rows, err := s.DB.Query(ctx, `SELECT id, tenant_id, type, amount_usd, balance_after, COALESCE(description,''), created_at FROM balance_transactions WHERE tenant_id=$1 ORDER BY created_at, id`, tenantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tx BalanceTransaction
+		if err := rows.Scan(&tx.ID, &tx.TenantID, &tx.Type, &tx.AmountUSD, &tx.BalanceAfter, &tx.Description, &tx.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EraseTenant deletes a tenant and everything it owns on a data-subject
+// request. Unlike DeleteTenant it leaves nothing that identifies a person:
+// the archive keeps only anonymous totals, and the tenant's audit entries
+// lose their user names, IPs and snapshots while keeping what was done when.
+func (s *Store) EraseTenant(ctx context.Context, id, actor string) (*TenantArchive, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	snap, err := snapshotTenant(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	snap.anonymize()
+	archive, err := insertTenantArchive(ctx, tx, snap, ArchiveErased, "", actor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE audit_log SET actor=CASE WHEN actor_type=$2 THEN $3 ELSE actor END, ip='', before=NULL, after=NULL
+		WHERE tenant_id=$1 OR (target_type='tenant' AND target_id=$1)`, id, ActorTenantUser, ErasedActor); err != nil {
+		return nil, err
+	}
+	if err := deleteTenantRows(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	return archive, tx.Commit(ctx)
+}
+
+// anonymize drops names, emails and key identifiers, keeping counts, dates
+// and financial totals.
+func (snap *TenantSnapshot) anonymize() {
+	snap.Tenant.Name = ""
+	for i := range snap.Members {
+		snap.Members[i].Username, snap.Members[i].Email = "", ""
+	}
+	for i := range snap.APIKeys {
+		snap.APIKeys[i].Prefix, snap.APIKeys[i].Name, snap.APIKeys[i].CreatedBy = "", "", ""
+	}
+}
@@ -0,0 +1,388 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrTenantExists    = errors.New("tenant already exists")
+	ErrUsernameTaken   = errors.New("username already taken")
+	ErrMergeSameTenant = errors.New("cannot merge a tenant into itself")
+)
+
+// Tenant archive reasons.
+const (
+	ArchiveDeleted = "deleted"
+	ArchiveMerged  = "merged"
+	// ArchiveErased archives are anonymized; see EraseTenant.
+	ArchiveErased = "erased"
+)
+
+// TenantArchive keeps a summary of a deleted or merged tenant. Secrets
+// (password hashes, full API keys) are never archived.
+type TenantArchive struct {
+	ID         int64          `json:"id"`
+	TenantID   string         `json:"tenant_id"`
+	Name       string         `json:"name"`
+	Reason     string         `json:"reason"`
+	MergedInto string         `json:"merged_into,omitempty"`
+	Snapshot   TenantSnapshot `json:"snapshot"`
+	ArchivedBy string         `json:"archived_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+type TenantSnapshot struct {
+	Tenant       Tenant               `json:"tenant"`
+	Members      []ArchivedMember     `json:"members"`
+	APIKeys      []ArchivedAPIKey     `json:"api_keys"`
+	Usage        []ArchivedUsage      `json:"usage"`
+	Transactions []ArchivedTxTotal    `json:"transactions"`
+	Requests     ArchivedRequestTotal `json:"requests"`
+}
+
+type ArchivedMember struct {
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ArchivedAPIKey struct {
+	Prefix    string    `json:"prefix"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArchivedUsage is a tenant's lifetime usage of one model on one provider.
+type ArchivedUsage struct {
+	Provider string  `json:"provider"`
+	Model    string  `json:"model"`
+	Tokens   int64   `json:"tokens"`
+	CostUSD  float64 `json:"cost_usd"`
+}
+
+type ArchivedTxTotal struct {
+	Type      string  `json:"type"`
+	Count     int64   `json:"count"`
+	AmountUSD float64 `json:"amount_usd"`
+}
+
+type ArchivedRequestTotal struct {
+	Count     int64      `json:"count"`
+	Tokens    int64      `json:"tokens"`
+	BilledUSD float64    `json:"billed_usd"`
+	First     *time.Time `json:"first"`
+	Last      *time.Time `json:"last"`
+}
+
+// CreateTenantAccount creates a tenant with its owner and, if key is not
+// nil, a first API key. A positive opening balance is recorded as a topup.
+func (s *Store) CreateTenantAccount(ctx context.Context, t Tenant, owner TenantUser, key *APIKey) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := createTenantAccount(ctx, tx, t, owner, key); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func createTenantAccount(ctx context.Context, tx pgx.Tx, t Tenant, owner TenantUser, key *APIKey) error {
+	if t.Tier == "" {
+		t.Tier = TierStandard
+	}
+	tag, err := tx.Exec(ctx, `INSERT INTO tenants (id, name, balance_usd, total_topup_usd, tier) VALUES ($1,$2,$3,$3,$4) ON CONFLICT (id) DO NOTHING`,
+		t.ID, t.Name, t.BalanceUSD, t.Tier)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTenantExists
+	}
+	tag, err = tx.Exec(ctx, `INSERT INTO tenant_users (id, tenant_id, username, password_hash, role, email) VALUES ($1,$2,$3,$4,$5,$6) ON CONFLICT (username) DO NOTHING`,
+		owner.ID, t.ID, owner.Username, owner.PasswordHash, RoleOwner, owner.Email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUsernameTaken
+	}
+	if key != nil {
+		if _, err := tx.Exec(ctx, `INSERT INTO api_keys (key, tenant_id, name, allowed_models, created_at, created_by) VALUES ($1,$2,$3,$4,$5,$6)`,
+			key.Key, t.ID, key.Name, key.AllowedModels, time.Now().UTC(), key.CreatedBy); err != nil {
+			return err
+		}
+	}
+	if t.BalanceUSD > 0 {
+		if _, err := tx.Exec(ctx, `INSERT INTO balance_transactions (tenant_id, type, amount_usd, balance_after, description) VALUES ($1,'topup',$2,$2,'Opening balance')`,
+			t.ID, t.BalanceUSD); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) RenameTenant(ctx context.Context, id, name string) error {
+	tag, err := s.DB.Exec(ctx, `UPDATE tenants SET name=$2 WHERE id=$1`, id, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// tenantOwnedTables hold rows keyed by tenant_id without ON DELETE CASCADE.
+// Delete removes them; merge moves them to the surviving tenant. The audit
+// log is deliberately absent: it stays as a historical record.
+var tenantOwnedTables = []string{
+	"api_keys",
+	"routing_rules",
+	"tenant_users",
+	"balance_transactions",
+	"request_logs",
+	"moderation_events",
+	"batch_items",
+	"credit_grants",
+}
+
+// tenantMovedTables cascade on tenant delete; merge moves their rows to the
+// surviving tenant.
+var tenantMovedTables = []string{"experiments", "webhooks", "batches", "tenant_invitations"}
+
+// tenantPolicyTables hold one-per-tenant settings. They cascade on delete,
+// and on merge the target's win.
+var tenantPolicyTables = []string{
+	"tenant_moderation_policies",
+	"tenant_redaction_policies",
+	"tenant_request_policies",
+	"tenant_spend_alerts",
+}
+
+// tenantHistoryTables outlive the tenant as a record of what happened to it.
+var tenantHistoryTables = []string{"audit_log", "tenant_archives"}
+
+// DeleteTenant archives a summary of the tenant and deletes it with its keys,
+// members, usage, request logs and everything else it owns.
+func (s *Store) DeleteTenant(ctx context.Context, id, actor string) (*TenantArchive, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	archive, err := archiveTenant(ctx, tx, id, ArchiveDeleted, "", actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := deleteTenantRows(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	return archive, tx.Commit(ctx)
+}
+
+// deleteTenantRows deletes the tenant and everything it owns.
+func deleteTenantRows(ctx context.Context, tx pgx.Tx, id string) error {
+	for _, table := range append(tenantOwnedTables, "usage_daily") {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE tenant_id=$1`, id); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(ctx, `DELETE FROM tenants WHERE id=$1`, id)
+	return err
+}
+
+// MergeTenants moves everything the source tenant owns into the target, adds
+// its balance and lifetime totals to the target's and deletes the source.
+// The target's policies win; source prompt templates whose names clash are
+// renamed with the source id as a suffix.
+func (s *Store) MergeTenants(ctx context.Context, sourceID, targetID, actor string) (*TenantArchive, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeSameTenant
+	}
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	// Lock both rows in a fixed order so concurrent merges cannot deadlock.
+	rows, err := tx.Query(ctx, `SELECT id FROM tenants WHERE id IN ($1,$2) ORDER BY id FOR UPDATE`, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	locked := 0
+	for rows.Next() {
+		locked++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if locked != 2 {
+		return nil, pgx.ErrNoRows
+	}
+	archive, err := archiveTenant(ctx, tx, sourceID, ArchiveMerged, targetID, actor)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range append(tenantOwnedTables, tenantMovedTables...) {
+		if _, err := tx.Exec(ctx, `UPDATE `+table+` SET tenant_id=$2 WHERE tenant_id=$1`, sourceID, targetID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE prompt_templates p SET tenant_id=$2,
+			name=CASE WHEN EXISTS (SELECT 1 FROM prompt_templates t WHERE t.tenant_id=$2 AND t.name=p.name) THEN p.name || ' (' || $1 || ')' ELSE p.name END
+		WHERE tenant_id=$1`, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO usage_daily (tenant_id, provider, model, day, tokens, cost_usd, free_requests, free_tokens)
+			SELECT $2, provider, model, day, tokens, cost_usd, free_requests, free_tokens FROM usage_daily WHERE tenant_id=$1
+		ON CONFLICT (tenant_id, provider, model, day) DO UPDATE SET tokens = usage_daily.tokens + EXCLUDED.tokens, cost_usd = usage_daily.cost_usd + EXCLUDED.cost_usd,
+			free_requests = usage_daily.free_requests + EXCLUDED.free_requests, free_tokens = usage_daily.free_tokens + EXCLUDED.free_tokens`,
+		sourceID, targetID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM usage_daily WHERE tenant_id=$1`, sourceID); err != nil {
+		return nil, err
+	}
+	src := archive.Snapshot.Tenant
+	var balance float64
+	if err := tx.QueryRow(ctx, `UPDATE tenants SET balance_usd=balance_usd+$2, total_topup_usd=total_topup_usd+$3, total_spent_usd=total_spent_usd+$4 WHERE id=$1 RETURNING balance_usd`,
+		targetID, src.BalanceUSD, src.TotalTopupUSD, src.TotalSpentUSD).Scan(&balance); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO balance_transactions (tenant_id, type, amount_usd, balance_after, description) VALUES ($1,'merge',$2,$3,$4)`,
+		targetID, src.BalanceUSD, balance, "Merged tenant "+src.Name+" ("+src.ID+")"); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM tenants WHERE id=$1`, sourceID); err != nil {
+		return nil, err
+	}
+	return archive, tx.Commit(ctx)
+}
+
+// archiveTenant snapshots a tenant and stores the archive row.
+func archiveTenant(ctx context.Context, tx pgx.Tx, id, reason, mergedInto, actor string) (*TenantArchive, error) {
+	snap, err := snapshotTenant(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return insertTenantArchive(ctx, tx, snap, reason, mergedInto, actor)
+}
+
+// snapshotTenant summarizes a tenant, locking its row.
+func snapshotTenant(ctx context.Context, tx pgx.Tx, id string) (*TenantSnapshot, error) {
+	snap := &TenantSnapshot{}
+	t := &snap.Tenant
+	if err := tx.QueryRow(ctx, `SELECT id, name, balance_usd, created_at, last_active, suspended, total_topup_usd, total_spent_usd, rate_limit_rpm, spend_limit_usd, tier FROM tenants WHERE id=$1 FOR UPDATE`, id).
+		Scan(&t.ID, &t.Name, &t.BalanceUSD, &t.CreatedAt, &t.LastActive, &t.Suspended, &t.TotalTopupUSD, &t.TotalSpentUSD, &t.RateLimitRPM, &t.SpendLimitUSD, &t.Tier); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `SELECT username, email, role, created_at FROM tenant_users WHERE tenant_id=$1 ORDER BY created_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var m ArchivedMember
+		if err := rows.Scan(&m.Username, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snap.Members = append(snap.Members, m)
+	}
+	rows.Close()
+
+	rows, err = tx.Query(ctx, `SELECT LEFT(key, 8), COALESCE(name,''), created_by, created_at FROM api_keys WHERE tenant_id=$1 ORDER BY created_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var k ArchivedAPIKey
+		if err := rows.Scan(&k.Prefix, &k.Name, &k.CreatedBy, &k.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snap.APIKeys = append(snap.APIKeys, k)
+	}
+	rows.Close()
+
+	rows, err = tx.Query(ctx, `SELECT provider, model, SUM(tokens)::bigint, SUM(cost_usd)::float8 FROM usage_daily WHERE tenant_id=$1 GROUP BY provider, model ORDER BY provider, model`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var u ArchivedUsage
+		if err := rows.Scan(&u.Provider, &u.Model, &u.Tokens, &u.CostUSD); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snap.Usage = append(snap.Usage, u)
+	}
+	rows.Close()
+
+	rows, err = tx.Query(ctx, `SELECT type, COUNT(*), SUM(amount_usd)::float8 FROM balance_transactions WHERE tenant_id=$1 GROUP BY type ORDER BY type`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var tt ArchivedTxTotal
+		if err := rows.Scan(&tt.Type, &tt.Count, &tt.AmountUSD); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snap.Transactions = append(snap.Transactions, tt)
+	}
+	rows.Close()
+
+	rq := &snap.Requests
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*), COALESCE(SUM(tokens),0)::bigint, COALESCE(SUM(billed_usd),0)::float8, MIN(created_at), MAX(created_at) FROM request_logs WHERE tenant_id=$1`, id).
+		Scan(&rq.Count, &rq.Tokens, &rq.BilledUSD, &rq.First, &rq.Last); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+func insertTenantArchive(ctx context.Context, tx pgx.Tx, snap *TenantSnapshot, reason, mergedInto, actor string) (*TenantArchive, error) {
+	body, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	t := snap.Tenant
+	a := &TenantArchive{TenantID: t.ID, Name: t.Name, Reason: reason, MergedInto: mergedInto, Snapshot: *snap, ArchivedBy: actor}
+	if err := tx.QueryRow(ctx, `INSERT INTO tenant_archives (tenant_id, name, reason, merged_into, snapshot, archived_by) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id, created_at`,
+		t.ID, t.Name, reason, mergedInto, body, actor).Scan(&a.ID, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *Store) ListTenantArchives(ctx context.Context, limit int) ([]TenantArchive, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := s.DB.Query(ctx, `SELECT id, tenant_id, name, reason, merged_into, snapshot, archived_by, created_at FROM tenant_archives ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TenantArchive
+	for rows.Next() {
+		var a TenantArchive
+		var snap []byte
+		if err := rows.Scan(&a.ID, &a.TenantID, &a.Name, &a.Reason, &a.MergedInto, &snap, &a.ArchivedBy, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal(snap, &a.Snapshot)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"io/fs"
+	"regexp"
+	"strings"
+	"testing"
+
+	"routerx/migrations"
+)
+
+var (
+	createTableRe    = regexp.MustCompile(`(?s)CREATE TABLE (?:IF NOT EXISTS )?(\w+) \((.*?)\n\);`)
+	tenantColumnRe   = regexp.MustCompile(`(?m)^\s*tenant_id\s[^\n]*`)
+	addTenantIDRe    = regexp.MustCompile(`ALTER TABLE (\w+) ADD COLUMN (?:IF NOT EXISTS )?tenant_id\s[^;]*`)
+	tenantMergeExtra = []string{"usage_daily", "prompt_templates"}
+)
+
+// TestTenantTablesClassified fails when a migration adds a tenant_id column
+// that DeleteTenant and MergeTenants do not know about, so a new table
+// cannot be left behind (or, without a cascade, block the delete).
+func TestTenantTablesClassified(t *testing.T) {
+	cascade := map[string]bool{}
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		b, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range createTableRe.FindAllStringSubmatch(string(b), -1) {
+			if col := tenantColumnRe.FindString(m[2]); col != "" {
+				cascade[m[1]] = strings.Contains(col, "ON DELETE CASCADE")
+			}
+		}
+		for _, m := range addTenantIDRe.FindAllStringSubmatch(string(b), -1) {
+			cascade[m[1]] = strings.Contains(m[0], "ON DELETE CASCADE")
+		}
+	}
+	if len(cascade) == 0 {
+		t.Fatal("found no tables with a tenant_id column")
+	}
+
+	known := map[string]bool{}
+	for _, list := range [][]string{tenantOwnedTables, tenantMovedTables, tenantPolicyTables, tenantHistoryTables, tenantMergeExtra} {
+		for _, table := range list {
+			known[table] = true
+		}
+	}
+	deleted := map[string]bool{"usage_daily": true}
+	for _, list := range [][]string{tenantOwnedTables, tenantHistoryTables} {
+		for _, table := range list {
+			deleted[table] = true
+		}
+	}
+	for table, cascades := range cascade {
+		if !known[table] {
+			t.Errorf("%s has a tenant_id column but is not in a tenant table list in tenant_lifecycle.go", table)
+		}
+		if !cascades && !deleted[table] {
+			t.Errorf("%s does not cascade on tenant delete and is not in tenantOwnedTables", table)
+		}
+	}
+	for table := range known {
+		if _, ok := cascade[table]; !ok {
+			t.Errorf("%s is listed in tenant_lifecycle.go but no migration gives it a tenant_id column", table)
+		}
+	}
+}
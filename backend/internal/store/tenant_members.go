@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Tenant user roles. Owners manage members, billing and keys; members manage
+// API keys; viewers are read-only.
+const (
+	RoleOwner  = "owner"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+func ValidRole(role string) bool {
+	return role == RoleOwner || role == RoleMember || role == RoleViewer
+}
+
+var ErrLastOwner = errors.New("tenant must keep at least one owner")
+
+// TenantMember is the public view of a tenant user.
+type TenantMember struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email,omitempty"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Invitation struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	TokenHash  string     `json:"-"`
+	InvitedBy  string     `json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+func (s *Store) ListTenantMembers(ctx context.Context, tenantID string) ([]TenantMember, error) {
+	rows, err := s.DB.Query(ctx, `SELECT id, username, email, role, created_at FROM tenant_users WHERE tenant_id=$1 ORDER BY created_at`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TenantMember
+	for rows.Next() {
+		var m TenantMember
+		if err := rows.Scan(&m.ID, &m.Username, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// UpdateTenantMemberRole changes a member's role, refusing to demote the last owner.
+func (s *Store) UpdateTenantMemberRole(ctx context.Context, tenantID, userID, role string) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockTenant(ctx, tx, tenantID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `UPDATE tenant_users SET role=$3 WHERE id=$1 AND tenant_id=$2`, userID, tenantID, role)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("member not found")
+	}
+	if err := ensureOwner(ctx, tx, tenantID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteTenantMember removes a member, refusing to remove the last owner.
+func (s *Store) DeleteTenantMember(ctx context.Context, tenantID, userID string) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockTenant(ctx, tx, tenantID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM tenant_users WHERE id=$1 AND tenant_id=$2`, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("member not found")
+	}
+	if err := ensureOwner(ctx, tx, tenantID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// lockTenant serializes membership changes on a tenant until tx ends, so
+// two concurrent demotions cannot each count the other's owner and leave
+// the tenant with none.
+func lockTenant(ctx context.Context, tx pgx.Tx, tenantID string) error {
+	_, err := tx.Exec(ctx, `SELECT 1 FROM tenants WHERE id=$1 FOR UPDATE`, tenantID)
+	return err
+}
+
+// ensureOwner checks the tenant still has an owner. tx must hold lockTenant.
+func ensureOwner(ctx context.Context, tx pgx.Tx, tenantID string) error {
+	var owners int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM tenant_users WHERE tenant_id=$1 AND role=$2`, tenantID, RoleOwner).Scan(&owners); err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func (s *Store) CreateInvitation(ctx context.Context, inv Invitation) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO tenant_invitations (id, tenant_id, email, role, token_hash, invited_by, created_at, expires_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+		inv.ID, inv.TenantID, inv.Email, inv.Role, inv.TokenHash, inv.InvitedBy, inv.CreatedAt, inv.ExpiresAt)
+	return err
+}
+
+// ListPendingInvitations returns unaccepted, unexpired invitations for a tenant.
+func (s *Store) ListPendingInvitations(ctx context.Context, tenantID string) ([]Invitation, error) {
+	rows, err := s.DB.Query(ctx, `SELECT id, tenant_id, email, role, token_hash, invited_by, created_at, expires_at, accepted_at
+		FROM tenant_invitations WHERE tenant_id=$1 AND accepted_at IS NULL AND expires_at > NOW() ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Invitation
+	for rows.Next() {
+		var inv Invitation
+		if err := rows.Scan(&inv.ID, &inv.TenantID, &inv.Email, &inv.Role, &inv.TokenHash, &inv.InvitedBy, &inv.CreatedAt, &inv.ExpiresAt, &inv.AcceptedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) DeleteInvitation(ctx context.Context, tenantID, id string) error {
+	_, err := s.DB.Exec(ctx, `DELETE FROM tenant_invitations WHERE id=$1 AND tenant_id=$2 AND accepted_at IS NULL`, id, tenantID)
+	return err
+}
+
+// AcceptInvitation creates the invited user and marks the invitation used.
+// The invitation must be pending and unexpired.
+func (s *Store) AcceptInvitation(ctx context.Context, tokenHash string, u TenantUser) (*Invitation, error) {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	var inv Invitation
+	err = tx.QueryRow(ctx, `UPDATE tenant_invitations SET accepted_at=NOW()
+		WHERE token_hash=$1 AND accepted_at IS NULL AND expires_at > NOW()
+		RETURNING id, tenant_id, email, role, invited_by, created_at, expires_at`, tokenHash).
+		Scan(&inv.ID, &inv.TenantID, &inv.Email, &inv.Role, &inv.InvitedBy, &inv.CreatedAt, &inv.ExpiresAt)
+	if err != nil {
+		return nil, errors.New("invitation is invalid or expired")
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO tenant_users (id, tenant_id, username, password_hash, role, email) VALUES ($1,$2,$3,$4,$5,$6)`,
+		u.ID, inv.TenantID, u.Username, u.PasswordHash, inv.Role, inv.Email); err != nil {
+		return nil, errors.New("username already taken")
+	}
+	return &inv, tx.Commit(ctx)
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// totpTable maps an actor type to the table holding its credentials.
+func totpTable(actorType string) (string, error) {
+	switch actorType {
+	case ActorAdmin:
+		return "admin_users", nil
+	case ActorTenantUser:
+		return "tenant_users", nil
+	}
+	return "", errors.New("unknown actor type")
+}
+
+// SetPendingTOTPSecret stores a new secret for enrollment. It only takes
+// effect once EnableTOTP confirms the user can produce codes from it.
+func (s *Store) SetPendingTOTPSecret(ctx context.Context, actorType, userID, secret string) error {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return err
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE `+table+` SET totp_secret=$2, totp_last_step=0 WHERE id=$1 AND NOT totp_enabled`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("two-factor authentication already enabled")
+	}
+	return nil
+}
+
+// EnableTOTP turns on two-factor authentication with the given hashed backup codes.
+func (s *Store) EnableTOTP(ctx context.Context, actorType, userID string, backupHashes []string) error {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `UPDATE `+table+` SET totp_enabled=TRUE, totp_backup_codes=$2 WHERE id=$1 AND totp_secret <> ''`, userID, backupHashes)
+	return err
+}
+
+func (s *Store) DisableTOTP(ctx context.Context, actorType, userID string) error {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `UPDATE `+table+` SET totp_enabled=FALSE, totp_secret='', totp_last_step=0, totp_backup_codes='{}' WHERE id=$1`, userID)
+	return err
+}
+
+// ClaimTOTPAttempt counts a second-factor attempt before the code is
+// checked, so parallel guesses are counted too. The limit-th attempt since
+// the last success locks the account for lockout; it returns false while
+// the account is locked.
+func (s *Store) ClaimTOTPAttempt(ctx context.Context, actorType, userID string, limit int, lockout time.Duration) (bool, error) {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return false, err
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE `+table+` SET
+			totp_attempts = CASE WHEN totp_locked_until IS NOT NULL THEN 1 ELSE totp_attempts + 1 END,
+			totp_locked_until = CASE WHEN totp_locked_until IS NULL AND totp_attempts + 1 >= $2 THEN NOW() + make_interval(secs => $3) END
+		WHERE id=$1 AND (totp_locked_until IS NULL OR totp_locked_until <= NOW())`,
+		userID, limit, lockout.Seconds())
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ResetTOTPAttempts clears the attempt count after a code was accepted.
+func (s *Store) ResetTOTPAttempts(ctx context.Context, actorType, userID string) error {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `UPDATE `+table+` SET totp_attempts=0, totp_locked_until=NULL WHERE id=$1`, userID)
+	return err
+}
+
+func (s *Store) ReplaceTOTPBackupCodes(ctx context.Context, actorType, userID string, backupHashes []string) error {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(ctx, `UPDATE `+table+` SET totp_backup_codes=$2 WHERE id=$1`, userID, backupHashes)
+	return err
+}
+
+// UseTOTPStep records step as used. It returns false when the step (or a
+// later one) was already used, so each code is accepted at most once.
+func (s *Store) UseTOTPStep(ctx context.Context, actorType, userID string, step int64) (bool, error) {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return false, err
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE `+table+` SET totp_last_step=$2 WHERE id=$1 AND totp_last_step < $2`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseTOTPBackupCode consumes a hashed backup code, returning false if it is unknown.
+func (s *Store) UseTOTPBackupCode(ctx context.Context, actorType, userID, codeHash string) (bool, error) {
+	table, err := totpTable(actorType)
+	if err != nil {
+		return false, err
+	}
+	tag, err := s.DB.Exec(ctx, `UPDATE `+table+` SET totp_backup_codes=array_remove(totp_backup_codes, $2) WHERE id=$1 AND $2 = ANY(totp_backup_codes)`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *Store) TenantRequires2FA(ctx context.Context, tenantID string) (bool, error) {
+	var required bool
+	err := s.DB.QueryRow(ctx, `SELECT require_2fa FROM tenants WHERE id=$1`, tenantID).Scan(&required)
+	return required, err
+}
+
+func (s *Store) SetTenantRequire2FA(ctx context.Context, tenantID string, required bool) error {
+	_, err := s.DB.Exec(ctx, `UPDATE tenants SET require_2fa=$2 WHERE id=$1`, tenantID, required)
+	return err
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"routerx/internal/models"
+)
+
+// Granularities accepted by usage queries; they map onto Postgres date_trunc.
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+func ValidGranularity(g string) bool {
+	switch g {
+	case GranularityHour, GranularityDay, GranularityWeek, GranularityMonth:
+		return true
+	}
+	return false
+}
+
+// UsageWindow is a [From, To) time range bucketed by Granularity. A zero From
+// means unbounded.
+type UsageWindow struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Granularity string    `json:"granularity"`
+}
+
+// Truncate aligns t to the start of its bucket, matching date_trunc.
+func (w UsageWindow) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch w.Granularity {
+	case GranularityHour:
+		return t.Truncate(time.Hour)
+	case GranularityWeek:
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Next returns the start of the bucket after the one starting at t.
+func (w UsageWindow) Next(t time.Time) time.Time {
+	switch w.Granularity {
+	case GranularityHour:
+		return t.Add(time.Hour)
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// BucketCount is the number of buckets between From and To.
+func (w UsageWindow) BucketCount() int {
+	if w.From.IsZero() {
+		return 0
+	}
+	n := 0
+	for t := w.Truncate(w.From); t.Before(w.To); t = w.Next(t) {
+		n++
+	}
+	return n
+}
+
+// where returns a created_at range condition starting at placeholder $argN.
+func (w UsageWindow) where(col string, argN int) (string, []interface{}) {
+	var cond string
+	var args []interface{}
+	if !w.From.IsZero() {
+		cond += fmt.Sprintf(" AND %s >= $%d", col, argN)
+		args = append(args, w.From)
+		argN++
+	}
+	if !w.To.IsZero() {
+		cond += fmt.Sprintf(" AND %s < $%d", col, argN)
+		args = append(args, w.To)
+	}
+	return cond, args
+}
+
+// ListTenantUsage aggregates a tenant's usage per provider and model in each
+// bucket of the window, newest first. Day and coarser buckets come from
+// usage_daily; hourly buckets need request_logs.
+func (s *Store) ListTenantUsage(ctx context.Context, tenantID string, w UsageWindow, limit int) ([]DailyUsage, error) {
+	var q string
+	var cond string
+	var args []interface{}
+	if w.Granularity == GranularityHour {
+		cond, args = w.where("created_at", 3)
+		q = `SELECT date_trunc($2, created_at) AS bucket, provider, model, COALESCE(SUM(tokens),0), COALESCE(SUM(cost_usd),0)
+			FROM request_logs WHERE tenant_id=$1 AND status_code=200` + cond + `
+			GROUP BY bucket, provider, model HAVING SUM(tokens) > 0 OR SUM(cost_usd) > 0 ORDER BY bucket DESC`
+	} else {
+		cond, args = w.where("day", 3)
+		q = `SELECT date_trunc($2, day::timestamp) AS bucket, provider, model, SUM(tokens), SUM(cost_usd)
+			FROM usage_daily WHERE tenant_id=$1` + cond + `
+			GROUP BY bucket, provider, model HAVING SUM(tokens) > 0 OR SUM(cost_usd) > 0 ORDER BY bucket DESC`
+	}
+	gran := w.Granularity
+	if gran == "" {
+		gran = GranularityDay
+	}
+	args = append([]interface{}{tenantID, gran}, args...)
+	if limit > 0 {
+		q += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.DB.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DailyUsage
+	for rows.Next() {
+		var u DailyUsage
+		if err := rows.Scan(&u.Day, &u.Provider, &u.Model, &u.Tokens, &u.CostUSD); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// DailyUsage is one usage_daily row.
+type DailyUsage struct {
+	Day      time.Time `json:"day"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Tokens   int64     `json:"tokens"`
+	CostUSD  float64   `json:"cost_usd"`
+}
+
+// EachRequestLog calls fn for every request log of a tenant in [from, to),
+// oldest first, without buffering the result set.
+func (s *Store) EachRequestLog(ctx context.Context, tenantID string, from, to time.Time, fn func(models.RequestLog) error) error {
+	rows, err := s.DB.Query(ctx, `SELECT id, tenant_id, provider, model, latency_ms, ttft_ms, tokens, cost_usd, prompt_hash, fallback_used, status_code, error_code, user_id, app_title, app_referer, metadata, key_owner, created_at
+		FROM request_logs WHERE tenant_id=$1 AND created_at >= $2 AND created_at < $3 ORDER BY created_at`, tenantID, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l models.RequestLog
+		if err := rows.Scan(&l.ID, &l.TenantID, &l.Provider, &l.Model, &l.LatencyMS, &l.TTFTMS, &l.Tokens, &l.CostUSD, &l.PromptHash, &l.FallbackUsed, &l.StatusCode, &l.ErrorCode, &l.UserID, &l.AppTitle, &l.AppReferer, &l.Metadata, &l.KeyOwner, &l.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachDailyUsage calls fn for every non-empty usage_daily row of a tenant
+// whose day falls in [from, to), oldest first.
+func (s *Store) EachDailyUsage(ctx context.Context, tenantID string, from, to time.Time, fn func(DailyUsage) error) error {
+	rows, err := s.DB.Query(ctx, `SELECT day, provider, model, tokens, cost_usd FROM usage_daily
+		WHERE tenant_id=$1 AND day >= $2::date AND day < $3 AND (tokens > 0 OR cost_usd > 0) ORDER BY day, provider, model`, tenantID, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u DailyUsage
+		if err := rows.Scan(&u.Day, &u.Provider, &u.Model, &u.Tokens, &u.CostUSD); err != nil {
+			return err
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
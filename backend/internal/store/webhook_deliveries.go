@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Webhook delivery states. Pending deliveries are retried with backoff until
+// they succeed or run out of attempts and become dead.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookDelivery struct {
+	ID             string                   `json:"id"`
+	WebhookID      int                      `json:"webhook_id"`
+	EventType      string                   `json:"event_type"`
+	Payload        json.RawMessage          `json:"payload"`
+	Status         string                   `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	LastStatusCode int                      `json:"last_status_code"`
+	LastError      string                   `json:"last_error"`
+	NextAttemptAt  time.Time                `json:"next_attempt_at"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+	AttemptLog     []WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+type WebhookDeliveryAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryFilters struct {
+	WebhookID int
+	Status    string
+	EventType string
+	Limit     int
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, created_at, updated_at`
+
+func scanWebhookDelivery(row pgx.Row) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	var payload []byte
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt)
+	d.Payload = payload
+	return d, err
+}
+
+func (s *Store) GetWebhook(ctx context.Context, id int) (*Webhook, error) {
+	var h Webhook
+	err := s.DB.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id=$1`, id).
+		Scan(&h.ID, &h.TenantID, &h.URL, &h.Events, &h.Secret, &h.Enabled, &h.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, d WebhookDelivery) error {
+	_, err := s.DB.Exec(ctx, `INSERT INTO webhook_deliveries (id, webhook_id, event_type, payload, status, next_attempt_at) VALUES ($1,$2,$3,$4,$5,$6)`,
+		d.ID, d.WebhookID, d.EventType, []byte(d.Payload), DeliveryPending, d.NextAttemptAt)
+	return err
+}
+
+// ClaimDueWebhookDeliveries returns pending deliveries whose next attempt is
+// due and pushes their next_attempt_at out by lease, so concurrent workers do
+// not pick up the same delivery while it is in flight.
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	rows, err := s.DB.Query(ctx, `UPDATE webhook_deliveries SET next_attempt_at=NOW()+$2*INTERVAL '1 second'
+		WHERE id IN (SELECT id FROM webhook_deliveries WHERE status='pending' AND next_attempt_at<=NOW() ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING `+webhookDeliveryColumns, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// RecordWebhookAttempt logs one delivery attempt and moves the delivery to its
+// resulting state.
+func (s *Store) RecordWebhookAttempt(ctx context.Context, deliveryID string, a WebhookDeliveryAttempt, status string, nextAttempt time.Time) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms) VALUES ($1,$2,$3,$4,$5)`,
+		deliveryID, a.Attempt, a.StatusCode, a.Error, a.DurationMS); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE webhook_deliveries SET status=$2, attempts=$3, last_status_code=$4, last_error=$5, next_attempt_at=$6, updated_at=NOW() WHERE id=$1`,
+		deliveryID, status, a.Attempt, a.StatusCode, a.Error, nextAttempt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetWebhookDelivery returns a delivery with its attempt log.
+func (s *Store) GetWebhookDelivery(ctx context.Context, id string) (*WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(s.DB.QueryRow(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id=$1`, id))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(ctx, `SELECT attempt, status_code, error, duration_ms, created_at FROM webhook_delivery_attempts WHERE delivery_id=$1 ORDER BY attempt`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	d.AttemptLog = []WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var a WebhookDeliveryAttempt
+		if err := rows.Scan(&a.Attempt, &a.StatusCode, &a.Error, &a.DurationMS, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.AttemptLog = append(d.AttemptLog, a)
+	}
+	return &d, rows.Err()
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, f WebhookDeliveryFilters) ([]WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE 1=1`
+	args := []interface{}{}
+	if f.WebhookID > 0 {
+		args = append(args, f.WebhookID)
+		query += fmt.Sprintf(" AND webhook_id=$%d", len(args))
+	}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		query += fmt.Sprintf(" AND status=$%d", len(args))
+	}
+	if f.EventType != "" {
+		args = append(args, f.EventType)
+		query += fmt.Sprintf(" AND event_type=$%d", len(args))
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	args = append(args, f.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+	rows, err := s.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for a webhook URL, or a connection, that
+// would reach a loopback, private, link-local or unspecified address.
+// Webhook URLs are tenant-supplied, so without this check a tenant could
+// make the gateway call its own network or the cloud metadata endpoint.
+var ErrPrivateAddress = errors.New("webhook URL must resolve to a public address")
+
+// publicAddr reports whether ip may be the target of a webhook.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// ValidateURL checks that raw is an http(s) URL whose host resolves only
+// to public addresses. The dialer checks again on every connection, since
+// the name may resolve differently by the time a delivery is sent.
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("url must be an http(s) URL")
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("url host does not resolve: %w", err)
+	}
+	for _, a := range addrs {
+		if !publicAddr(a) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to non-public addresses. It runs after
+// name resolution, so a name rebound to a private address is caught too.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil || !publicAddr(ap.Addr()) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// newClient returns the client deliveries are sent with. It ignores proxy
+// settings: a proxy would be dialled in place of the endpoint, and checking
+// its address says nothing about where the request ends up.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: dialControl}
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
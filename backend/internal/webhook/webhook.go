@@ -7,22 +7,116 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"time"
 
+	"github.com/segmentio/ksuid"
+
+	"routerx/internal/jobs"
 	"routerx/internal/store"
 )
 
-// Dispatcher sends webhook events to registered endpoints.
+// Event types.
+const (
+	EventRequestCompleted      = "request.completed"
+	EventRequestFailed         = "request.failed"
+	EventModerationFlagged     = "moderation.flagged"
+	EventProviderCircuitOpened = "provider.circuit_opened"
+	EventProviderCircuitClosed = "provider.circuit_closed"
+	EventTenantBalanceLow      = "tenant.balance_low"
+	EventTenantSuspended       = "tenant.suspended"
+	EventTenantUnsuspended     = "tenant.unsuspended"
+	EventSpendThresholdCrossed = "spend.threshold_crossed"
+	EventSpendAlert            = "spend.alert"
+	EventKeyCreated            = "key.created"
+	EventKeyRevoked            = "key.revoked"
+
+	// EventWebhookTest is only sent by Test and cannot be subscribed to.
+	EventWebhookTest = "webhook.test"
+)
+
+// TenantEvents are the events a tenant's own webhooks may subscribe to.
+// Provider events describe shared infrastructure and are operator-only.
+var TenantEvents = []string{
+	EventRequestCompleted,
+	EventRequestFailed,
+	EventModerationFlagged,
+	EventTenantBalanceLow,
+	EventTenantSuspended,
+	EventTenantUnsuspended,
+	EventSpendThresholdCrossed,
+	EventSpendAlert,
+	EventKeyCreated,
+	EventKeyRevoked,
+}
+
+// Events lists every event type.
+var Events = append([]string{EventProviderCircuitOpened, EventProviderCircuitClosed}, TenantEvents...)
+
+// ValidEvents reports whether every event is known, restricted to
+// TenantEvents for tenant webhooks.
+func ValidEvents(events []string, tenant bool) bool {
+	allowed := Events
+	if tenant {
+		allowed = TenantEvents
+	}
+	for _, e := range events {
+		ok := false
+		for _, a := range allowed {
+			if e == a {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Defaults for delivery retries. A failed delivery is retried after
+// BaseBackoff, doubling up to MaxBackoff, until MaxAttempts is reached and the
+// delivery is dead-lettered.
+const (
+	DefaultMaxAttempts = 6
+	DefaultBaseBackoff = 30 * time.Second
+	DefaultMaxBackoff  = time.Hour
+
+	// deliveryLease keeps the retry worker away from a delivery whose attempt
+	// is in flight.
+	deliveryLease = 2 * time.Minute
+)
+
+// Job kinds registered by Register.
+const (
+	JobDeliver    = "webhook.deliver"
+	JobRetrySweep = "webhook.retry_sweep"
+)
+
+// Dispatcher sends webhook events to registered endpoints. Every event is
+// persisted as a delivery per endpoint so failures can be retried and
+// inspected.
 type Dispatcher struct {
-	Store  *store.Store
-	Client *http.Client
+	Store       *store.Store
+	Client      *http.Client
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jobs, when set by Register, runs first attempts from the job queue
+	// instead of a goroutine, so they survive a restart.
+	Jobs *jobs.Runner
 }
 
 func New(st *store.Store) *Dispatcher {
 	return &Dispatcher{
-		Store:  st,
-		Client: &http.Client{Timeout: 5 * time.Second},
+		Store:       st,
+		Client:      newClient(),
+		MaxAttempts: DefaultMaxAttempts,
+		BaseBackoff: DefaultBaseBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
 	}
 }
 
@@ -33,10 +127,12 @@ type Event struct {
 	Data      interface{} `json:"data"`
 }
 
-// Fire sends an event to all enabled webhooks matching the event type.
-// It runs asynchronously and does not block.
-func (d *Dispatcher) Fire(ctx context.Context, eventType string, data interface{}) {
-	hooks, err := d.Store.GetEnabledWebhooks(ctx, eventType)
+// Fire records a delivery for every enabled webhook matching the event type
+// and makes the first attempt asynchronously. Failed attempts are picked up
+// by the retry sweep. Events about a tenant also go to that tenant's own webhooks; pass an
+// empty tenantID for operator-only events.
+func (d *Dispatcher) Fire(ctx context.Context, tenantID, eventType string, data interface{}) {
+	hooks, err := d.Store.GetEnabledWebhooks(ctx, eventType, tenantID)
 	if err != nil || len(hooks) == 0 {
 		return
 	}
@@ -50,26 +146,176 @@ func (d *Dispatcher) Fire(ctx context.Context, eventType string, data interface{
 		return
 	}
 	for _, hook := range hooks {
-		go d.send(hook, body)
+		delivery := store.WebhookDelivery{
+			ID:            ksuid.New().String(),
+			WebhookID:     hook.ID,
+			EventType:     eventType,
+			Payload:       body,
+			NextAttemptAt: time.Now().UTC().Add(deliveryLease),
+		}
+		if err := d.Store.CreateWebhookDelivery(ctx, delivery); err != nil {
+			continue
+		}
+		if d.Jobs != nil {
+			if _, err := d.Jobs.Enqueue(ctx, JobDeliver, deliveryJob{DeliveryID: delivery.ID}, time.Time{}); err == nil {
+				continue
+			}
+		}
+		go d.attempt(context.Background(), hook, delivery)
 	}
 }
 
-func (d *Dispatcher) send(hook store.Webhook, body []byte) {
-	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(body))
+type deliveryJob struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// Register runs first attempts and the retry sweep (every interval) on r.
+func (d *Dispatcher) Register(r *jobs.Runner, interval time.Duration) {
+	r.Handle(JobDeliver, 1, d.deliverJob)
+	r.Every(JobRetrySweep, interval, func(ctx context.Context, _ json.RawMessage) error {
+		return d.RetryDue(ctx)
+	})
+	d.Jobs = r
+}
+
+// deliverJob makes the first attempt at a delivery queued by Fire. Failures
+// are retried by the sweep, not the job queue.
+func (d *Dispatcher) deliverJob(ctx context.Context, payload json.RawMessage) error {
+	var job deliveryJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+	delivery, err := d.Store.GetWebhookDelivery(ctx, job.DeliveryID)
 	if err != nil {
-		return
+		return err
+	}
+	if delivery.Status != store.DeliveryPending || delivery.Attempts > 0 {
+		return nil
+	}
+	hook, err := d.Store.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+	d.attempt(ctx, *hook, *delivery)
+	return nil
+}
+
+// RetryDue makes another attempt at every delivery whose retry is due.
+func (d *Dispatcher) RetryDue(ctx context.Context) error {
+	due, err := d.Store.ClaimDueWebhookDeliveries(ctx, 50, deliveryLease)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range due {
+		hook, err := d.Store.GetWebhook(ctx, delivery.WebhookID)
+		if err != nil {
+			continue
+		}
+		d.attempt(ctx, *hook, delivery)
+	}
+	return nil
+}
+
+// Redeliver makes one more attempt at a delivery regardless of its state and
+// returns the updated delivery. A dead delivery that fails again stays dead.
+func (d *Dispatcher) Redeliver(ctx context.Context, id string) (*store.WebhookDelivery, error) {
+	delivery, err := d.Store.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hook, err := d.Store.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	d.attempt(ctx, *hook, *delivery)
+	return d.Store.GetWebhookDelivery(ctx, id)
+}
+
+// TestResult reports the outcome of a test delivery.
+type TestResult struct {
+	StatusCode int    `json:"status_code"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Test sends a signed sample event to hook and reports the response. Test
+// deliveries are not persisted or retried.
+func (d *Dispatcher) Test(ctx context.Context, hook store.Webhook) TestResult {
+	body, err := json.Marshal(Event{
+		Type:      EventWebhookTest,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      map[string]interface{}{"webhook_id": hook.ID, "message": "This is a test event from RouterX."},
+	})
+	if err != nil {
+		return TestResult{Error: err.Error()}
+	}
+	start := time.Now()
+	code, err := d.send(ctx, hook, store.WebhookDelivery{ID: "test_" + ksuid.New().String(), EventType: EventWebhookTest, Payload: body})
+	res := TestResult{StatusCode: code, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// attempt sends the delivery once and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, hook store.Webhook, delivery store.WebhookDelivery) {
+	start := time.Now()
+	code, err := d.send(ctx, hook, delivery)
+	a := store.WebhookDeliveryAttempt{
+		Attempt:    delivery.Attempts + 1,
+		StatusCode: code,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	status, next := store.DeliveryDelivered, time.Now().UTC()
+	if err != nil {
+		a.Error = err.Error()
+		status = store.DeliveryPending
+		next = next.Add(d.backoff(a.Attempt))
+		if a.Attempt >= d.MaxAttempts {
+			status = store.DeliveryDead
+		}
+	}
+	_ = d.Store.RecordWebhookAttempt(ctx, delivery.ID, a, status, next)
+}
+
+// backoff returns the wait before retrying after the given attempt.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempt && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+	return wait
+}
+
+// send posts the payload and returns the response status. Transport errors and
+// non-2xx responses are failures.
+func (d *Dispatcher) send(ctx context.Context, hook store.Webhook, delivery store.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "RouterX-Webhook/1.0")
+	req.Header.Set("X-RouterX-Event", delivery.EventType)
+	req.Header.Set("X-RouterX-Delivery", delivery.ID)
 	if hook.Secret != "" {
 		mac := hmac.New(sha256.New, []byte(hook.Secret))
-		mac.Write(body)
+		mac.Write(delivery.Payload)
 		sig := hex.EncodeToString(mac.Sum(nil))
 		req.Header.Set("X-RouterX-Signature", sig)
 	}
 	resp, err := d.Client.Do(req)
 	if err != nil {
-		return
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d", resp.StatusCode)
 	}
-	resp.Body.Close()
+	return resp.StatusCode, nil
 }
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS model_providers (
+  model TEXT NOT NULL REFERENCES model_catalog(model) ON DELETE CASCADE,
+  provider_id TEXT NOT NULL REFERENCES providers(id) ON DELETE CASCADE,
+  priority INT NOT NULL DEFAULT 0,
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  PRIMARY KEY (model, provider_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_model_providers_model ON model_providers (model, priority);
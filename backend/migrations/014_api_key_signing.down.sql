@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_api_keys_signing_key_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS signing_secret;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS signing_key_id;
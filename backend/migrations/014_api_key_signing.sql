@@ -0,0 +1,4 @@
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS signing_key_id TEXT;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS signing_secret TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_signing_key_id ON api_keys (signing_key_id) WHERE signing_key_id IS NOT NULL;
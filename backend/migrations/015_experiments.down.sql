@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_request_logs_experiment;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS experiment_variant;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS experiment_id;
+DROP TABLE IF EXISTS experiments;
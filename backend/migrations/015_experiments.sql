@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS experiments (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL DEFAULT '',
+  tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  model TEXT NOT NULL,
+  variant_provider_id TEXT REFERENCES providers(id) ON DELETE SET NULL,
+  variant_model TEXT NOT NULL DEFAULT '',
+  traffic_percent NUMERIC(5,2) NOT NULL DEFAULT 0,
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiments_tenant_model ON experiments (tenant_id, model);
+
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS experiment_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS experiment_variant TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_request_logs_experiment ON request_logs (experiment_id) WHERE experiment_id <> '';
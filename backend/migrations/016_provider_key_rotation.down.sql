@@ -0,0 +1,3 @@
+ALTER TABLE providers DROP COLUMN IF EXISTS api_key_rotated_at;
+ALTER TABLE providers DROP COLUMN IF EXISTS previous_api_key;
+ALTER TABLE providers DROP COLUMN IF EXISTS staged_api_key;
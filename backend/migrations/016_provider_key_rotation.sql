@@ -0,0 +1,3 @@
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS staged_api_key TEXT;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS previous_api_key TEXT;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS api_key_rotated_at TIMESTAMP;
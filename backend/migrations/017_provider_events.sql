@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS provider_events (
+  id SERIAL PRIMARY KEY,
+  provider_id TEXT NOT NULL,
+  provider_name TEXT NOT NULL DEFAULT '',
+  event_type TEXT NOT NULL,
+  detail TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_events_provider ON provider_events (provider_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_provider_events_created_at ON provider_events (created_at DESC);
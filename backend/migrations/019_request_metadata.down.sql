@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_request_logs_app_title;
+DROP INDEX IF EXISTS idx_request_logs_metadata;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS metadata;
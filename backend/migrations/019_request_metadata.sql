@@ -0,0 +1,4 @@
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_request_logs_metadata ON request_logs USING GIN (metadata);
+CREATE INDEX IF NOT EXISTS idx_request_logs_app_title ON request_logs (tenant_id, app_title) WHERE app_title <> '';
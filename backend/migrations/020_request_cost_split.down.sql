@@ -0,0 +1,2 @@
+ALTER TABLE request_logs DROP COLUMN IF EXISTS billed_usd;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS provider_cost_usd;
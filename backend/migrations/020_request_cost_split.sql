@@ -0,0 +1,5 @@
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS provider_cost_usd NUMERIC(12,6) NOT NULL DEFAULT 0;
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS billed_usd NUMERIC(12,6) NOT NULL DEFAULT 0;
+
+-- Historical rows were billed at cost_usd; upstream cost was not recorded.
+UPDATE request_logs SET billed_usd = cost_usd WHERE status_code = 200;
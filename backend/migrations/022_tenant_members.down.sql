@@ -0,0 +1,9 @@
+-- Before roles every tenant user was its owner; drop the other members so
+-- they do not come back with an owner's access.
+DELETE FROM tenant_users WHERE role <> 'owner';
+ALTER TABLE request_logs DROP COLUMN IF EXISTS key_owner;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS created_by;
+DROP TABLE IF EXISTS tenant_invitations;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS created_at;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS email;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS role;
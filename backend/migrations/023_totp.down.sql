@@ -0,0 +1,9 @@
+ALTER TABLE tenants DROP COLUMN IF EXISTS require_2fa;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS totp_backup_codes;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS totp_secret;
+ALTER TABLE admin_users DROP COLUMN IF EXISTS totp_backup_codes;
+ALTER TABLE admin_users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE admin_users DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE admin_users DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS moderation_events;
+DROP TABLE IF EXISTS tenant_moderation_policies;
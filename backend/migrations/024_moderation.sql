@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS tenant_moderation_policies (
+  tenant_id TEXT PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+  mode TEXT NOT NULL DEFAULT 'off',
+  check_output BOOLEAN NOT NULL DEFAULT FALSE,
+  categories TEXT[] NOT NULL DEFAULT '{}',
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS moderation_events (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  direction TEXT NOT NULL,
+  action TEXT NOT NULL,
+  model TEXT NOT NULL DEFAULT '',
+  categories TEXT[] NOT NULL DEFAULT '{}',
+  prompt_hash TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_events_tenant ON moderation_events (tenant_id, created_at DESC);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS tenant_redaction_policies (
+  tenant_id TEXT PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+  detectors TEXT[] NOT NULL DEFAULT '{email,phone,credit_card}',
+  patterns TEXT[] NOT NULL DEFAULT '{}',
+  redact_logs BOOLEAN NOT NULL DEFAULT TRUE,
+  scrub_upstream BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
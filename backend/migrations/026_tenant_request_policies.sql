@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS tenant_request_policies (
+  tenant_id TEXT PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+  max_tokens INT NOT NULL DEFAULT 0,
+  min_temperature DOUBLE PRECISION,
+  max_temperature DOUBLE PRECISION,
+  denied_models TEXT[] NOT NULL DEFAULT '{}',
+  denied_providers TEXT[] NOT NULL DEFAULT '{}',
+  max_messages INT NOT NULL DEFAULT 0,
+  max_body_bytes INT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+-- Without tenant_id every webhook is global; drop tenants' own webhooks
+-- rather than send them every tenant's events.
+DELETE FROM webhooks WHERE tenant_id IS NOT NULL;
+DROP INDEX IF EXISTS idx_webhooks_tenant;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS tenant_id;
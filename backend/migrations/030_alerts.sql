@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS alert_rules (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  metric TEXT NOT NULL,
+  scope TEXT NOT NULL DEFAULT '',
+  threshold DOUBLE PRECISION NOT NULL,
+  window_minutes INT NOT NULL DEFAULT 5,
+  channel TEXT NOT NULL,
+  target TEXT NOT NULL,
+  cooldown_minutes INT NOT NULL DEFAULT 30,
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  state TEXT NOT NULL DEFAULT 'ok',
+  last_notified_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS alert_events (
+  id BIGSERIAL PRIMARY KEY,
+  rule_id TEXT NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+  state TEXT NOT NULL,
+  value DOUBLE PRECISION NOT NULL DEFAULT 0,
+  message TEXT NOT NULL DEFAULT '',
+  notify_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_events_rule ON alert_events(rule_id, created_at DESC);
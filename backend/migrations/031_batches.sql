@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS batches (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'in_progress',
+  request_count INT NOT NULL DEFAULT 0,
+  completed_count INT NOT NULL DEFAULT 0,
+  failed_count INT NOT NULL DEFAULT 0,
+  metadata JSONB NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_batches_tenant ON batches(tenant_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS batch_items (
+  id BIGSERIAL PRIMARY KEY,
+  batch_id TEXT NOT NULL REFERENCES batches(id) ON DELETE CASCADE,
+  tenant_id TEXT NOT NULL,
+  idx INT NOT NULL,
+  custom_id TEXT NOT NULL DEFAULT '',
+  request JSONB NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  status_code INT NOT NULL DEFAULT 0,
+  response JSONB,
+  error TEXT NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  completed_at TIMESTAMP,
+  UNIQUE(batch_id, idx)
+);
+
+CREATE INDEX IF NOT EXISTS idx_batch_items_due ON batch_items(next_attempt_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_batch_items_running ON batch_items(tenant_id) WHERE status='running';
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS jobs (
+  id TEXT PRIMARY KEY,
+  kind TEXT NOT NULL,
+  payload JSONB NOT NULL DEFAULT '{}',
+  status TEXT NOT NULL DEFAULT 'queued',
+  unique_key TEXT,
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL DEFAULT 1,
+  last_error TEXT NOT NULL DEFAULT '',
+  run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  locked_until TIMESTAMP,
+  locked_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(run_at) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_kind_status ON jobs(kind, status, created_at DESC);
+-- One live job per unique key; periodic jobs use their kind as the key.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique_live ON jobs(unique_key) WHERE unique_key IS NOT NULL AND status IN ('queued', 'running');
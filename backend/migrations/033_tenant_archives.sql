@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS tenant_archives (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  merged_into TEXT NOT NULL DEFAULT '',
+  snapshot JSONB NOT NULL,
+  archived_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_archives_tenant ON tenant_archives (tenant_id);
@@ -0,0 +1,9 @@
+-- Without revoked_at a revoked key would authenticate again; delete them.
+DELETE FROM api_keys WHERE revoked_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_api_keys_key_prefix;
+DROP INDEX IF EXISTS idx_api_keys_created_at;
+DROP INDEX IF EXISTS idx_api_keys_tenant;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS revoke_reason;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS revoked_by;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS last_used_at;
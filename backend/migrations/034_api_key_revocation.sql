@@ -0,0 +1,8 @@
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS revoked_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS revoke_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys (tenant_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_api_keys_created_at ON api_keys (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_api_keys_key_prefix ON api_keys (key text_pattern_ops);
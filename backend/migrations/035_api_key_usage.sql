@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS api_key_usage_daily (
+  key TEXT NOT NULL REFERENCES api_keys(key) ON DELETE CASCADE,
+  day DATE NOT NULL,
+  requests BIGINT NOT NULL DEFAULT 0,
+  tokens BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (key, day)
+);
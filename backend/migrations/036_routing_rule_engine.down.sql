@@ -0,0 +1,15 @@
+-- Rules the old shape cannot express would match more than they did (or,
+-- with no primary provider, not fit at all); delete them.
+DELETE FROM routing_rules
+WHERE primary_provider_id IS NULL OR NOT enabled OR model_pattern <> '' OR min_context_tokens > 0
+  OR requires_tools OR match_tags <> '{}';
+DROP INDEX IF EXISTS idx_routing_rules_priority;
+ALTER TABLE routing_rules ALTER COLUMN model DROP DEFAULT;
+ALTER TABLE routing_rules ALTER COLUMN primary_provider_id SET NOT NULL;
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS enabled;
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS match_tags;
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS requires_tools;
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS min_context_tokens;
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS model_pattern;
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS priority;
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS provider_ids;
@@ -0,0 +1,18 @@
+-- Routing rules become ordered provider lists with match conditions.
+-- primary_provider_id/secondary_provider_id are kept in sync with the first
+-- two entries of provider_ids for older clients.
+ALTER TABLE routing_rules ADD COLUMN IF NOT EXISTS provider_ids TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE routing_rules ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0;
+ALTER TABLE routing_rules ADD COLUMN IF NOT EXISTS model_pattern TEXT NOT NULL DEFAULT '';
+ALTER TABLE routing_rules ADD COLUMN IF NOT EXISTS min_context_tokens INT NOT NULL DEFAULT 0;
+ALTER TABLE routing_rules ADD COLUMN IF NOT EXISTS requires_tools BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE routing_rules ADD COLUMN IF NOT EXISTS match_tags JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE routing_rules ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE routing_rules ALTER COLUMN primary_provider_id DROP NOT NULL;
+ALTER TABLE routing_rules ALTER COLUMN model SET DEFAULT '';
+
+UPDATE routing_rules
+SET provider_ids = array_remove(array_remove(ARRAY[primary_provider_id, secondary_provider_id], NULL), '')
+WHERE provider_ids = '{}';
+
+CREATE INDEX IF NOT EXISTS idx_routing_rules_priority ON routing_rules (tenant_id, priority DESC);
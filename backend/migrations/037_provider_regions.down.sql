@@ -0,0 +1,2 @@
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS allowed_regions;
+ALTER TABLE providers DROP COLUMN IF EXISTS region;
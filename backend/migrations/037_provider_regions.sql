@@ -0,0 +1,5 @@
+-- Provider regions and per-tenant data residency. An empty region means
+-- unknown; tenants restricted to regions never route to such providers.
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS region TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS allowed_regions TEXT[] NOT NULL DEFAULT '{}';
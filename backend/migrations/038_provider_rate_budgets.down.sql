@@ -0,0 +1,2 @@
+ALTER TABLE providers DROP COLUMN IF EXISTS tpm_limit;
+ALTER TABLE providers DROP COLUMN IF EXISTS rpm_limit;
@@ -0,0 +1,4 @@
+-- Per-provider upstream rate budgets (requests and tokens per minute). Zero
+-- means no budget.
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS rpm_limit INT NOT NULL DEFAULT 0;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS tpm_limit INT NOT NULL DEFAULT 0;
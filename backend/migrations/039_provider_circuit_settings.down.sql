@@ -0,0 +1,4 @@
+ALTER TABLE providers DROP COLUMN IF EXISTS circuit_min_samples;
+ALTER TABLE providers DROP COLUMN IF EXISTS circuit_cooldown_seconds;
+ALTER TABLE providers DROP COLUMN IF EXISTS circuit_threshold;
+ALTER TABLE providers DROP COLUMN IF EXISTS circuit_window;
@@ -0,0 +1,6 @@
+-- Per-provider circuit-breaker tuning. Zero means the router default
+-- (20-request window, 50% failure threshold, 30 s cooldown, 10 samples).
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS circuit_window INT NOT NULL DEFAULT 0;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS circuit_threshold DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS circuit_cooldown_seconds INT NOT NULL DEFAULT 0;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS circuit_min_samples INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE providers DROP COLUMN IF EXISTS circuit_close_successes;
+ALTER TABLE providers DROP COLUMN IF EXISTS circuit_half_open_requests;
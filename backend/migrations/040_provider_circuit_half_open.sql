@@ -0,0 +1,5 @@
+-- Half-open circuit tuning: trial requests admitted at once after the
+-- cooldown, and consecutive trial successes needed to close. Zero means the
+-- router default (1 and 3).
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS circuit_half_open_requests INT NOT NULL DEFAULT 0;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS circuit_close_successes INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS provider_maintenance_windows;
+ALTER TABLE providers DROP COLUMN IF EXISTS maintenance;
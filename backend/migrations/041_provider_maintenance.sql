@@ -0,0 +1,15 @@
+-- Provider maintenance: a manual flag and scheduled downtime windows. Routing
+-- skips a provider while either applies.
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS maintenance BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS provider_maintenance_windows (
+  id BIGSERIAL PRIMARY KEY,
+  provider_id TEXT NOT NULL REFERENCES providers(id) ON DELETE CASCADE,
+  starts_at TIMESTAMPTZ NOT NULL,
+  ends_at TIMESTAMPTZ NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_maintenance_windows_provider ON provider_maintenance_windows(provider_id, ends_at);
@@ -0,0 +1,2 @@
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS allowed_models;
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS allowed_providers;
@@ -0,0 +1,4 @@
+-- Tenant allowlists for providers and models, alongside the existing deny
+-- lists. Empty means no restriction.
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS allowed_providers TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS allowed_models TEXT[] NOT NULL DEFAULT '{}';
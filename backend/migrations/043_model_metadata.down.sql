@@ -0,0 +1,4 @@
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS supports_tools;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS supports_vision;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS max_output_tokens;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS context_length;
@@ -0,0 +1,6 @@
+-- Per-model metadata advertised on /v1/models so clients can pick models
+-- themselves. Zero lengths mean unknown.
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS context_length INT NOT NULL DEFAULT 0;
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS max_output_tokens INT NOT NULL DEFAULT 0;
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS supports_vision BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS supports_tools BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+ALTER TABLE providers DROP COLUMN IF EXISTS client_key;
+ALTER TABLE providers DROP COLUMN IF EXISTS client_cert;
+ALTER TABLE providers DROP COLUMN IF EXISTS ca_bundle;
+ALTER TABLE providers DROP COLUMN IF EXISTS proxy_url;
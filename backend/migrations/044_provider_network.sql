@@ -0,0 +1,6 @@
+-- Per-provider egress settings: an HTTP(S) proxy, extra CA certificates and
+-- an mTLS client certificate. client_key is sealed like api_key.
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS proxy_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS ca_bundle TEXT NOT NULL DEFAULT '';
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS client_cert TEXT NOT NULL DEFAULT '';
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS client_key TEXT NOT NULL DEFAULT '';
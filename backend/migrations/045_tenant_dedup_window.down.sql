@@ -0,0 +1 @@
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS dedup_window_seconds;
@@ -0,0 +1,3 @@
+-- Per-tenant window for collapsing identical requests from the same key.
+-- 0 disables deduplication.
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS dedup_window_seconds INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Per-tenant coalescing of identical in-flight requests: '' (off),
+-- 'charge_once' or 'charge_each'.
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS coalesce_mode TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS rate_limit_burst;
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS rate_limit_qps;
@@ -0,0 +1,3 @@
+-- Per-tenant rate limit overrides; 0 uses the limiter defaults.
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS rate_limit_qps INT NOT NULL DEFAULT 0;
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS rate_limit_burst INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS downgrade_models;
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS downgrade_below_usd;
+ALTER TABLE tenant_request_policies DROP COLUMN IF EXISTS daily_budget_usd;
@@ -0,0 +1,6 @@
+-- Per-tenant model downgrade under daily budget pressure: once today's spend
+-- leaves less than downgrade_below_usd of daily_budget_usd, requests for a
+-- model in downgrade_models go to its mapped cheaper model.
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS daily_budget_usd NUMERIC(12,4) NOT NULL DEFAULT 0;
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS downgrade_below_usd NUMERIC(12,4) NOT NULL DEFAULT 0;
+ALTER TABLE tenant_request_policies ADD COLUMN IF NOT EXISTS downgrade_models JSONB NOT NULL DEFAULT '{}';
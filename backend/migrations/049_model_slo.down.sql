@@ -0,0 +1,3 @@
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS timeout_ms;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS latency_slo_ms;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS ttft_slo_ms;
@@ -0,0 +1,6 @@
+-- Per-model latency targets. Routing moves providers whose rolling time to
+-- first token or total latency for the model exceeds its SLO behind those
+-- that meet it; timeout_ms caps each provider attempt. Zero means unset.
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS ttft_slo_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS latency_slo_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS timeout_ms INTEGER NOT NULL DEFAULT 0;
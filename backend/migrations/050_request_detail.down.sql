@@ -0,0 +1,4 @@
+ALTER TABLE request_logs DROP COLUMN IF EXISTS attempts;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS stream;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS queue_ms;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS trace_id;
@@ -0,0 +1,6 @@
+-- Per-request diagnostics for GET /admin/requests/{id}: the trace, time spent
+-- before routing, whether the response streamed, and each provider attempt.
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS trace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS queue_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS stream BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS attempts JSONB NOT NULL DEFAULT '[]';
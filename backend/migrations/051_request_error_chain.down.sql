@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_request_logs_failed_attempts;
+ALTER TABLE request_logs DROP COLUMN IF EXISTS error_message;
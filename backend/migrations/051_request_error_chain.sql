@@ -0,0 +1,7 @@
+-- Failed routings keep their full error chain; attempts (added in 050) now
+-- carry an error_class per provider. Every row with a failed attempt either
+-- failed or fell back, which the partial index covers for failure-pattern
+-- queries over a time window.
+ALTER TABLE request_logs ADD COLUMN IF NOT EXISTS error_message TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_request_logs_failed_attempts ON request_logs (created_at)
+  WHERE status_code <> 200 OR jsonb_array_length(attempts) > 1;
@@ -0,0 +1,20 @@
+-- Spend alerts tenants configure for themselves. last_fired_period is the
+-- day (YYYY-MM-DD) or month (YYYY-MM) the alert last fired in, so it fires
+-- at most once per period.
+CREATE TABLE IF NOT EXISTS tenant_spend_alerts (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+  period TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  threshold DOUBLE PRECISION NOT NULL,
+  channel TEXT NOT NULL,
+  target TEXT NOT NULL DEFAULT '',
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  last_fired_period TEXT NOT NULL DEFAULT '',
+  last_fired_at TIMESTAMP,
+  last_notify_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_spend_alerts_tenant ON tenant_spend_alerts(tenant_id) WHERE enabled;
@@ -0,0 +1,18 @@
+-- Promotional credits granted by operators, kept apart from the paid
+-- balance. Charges consume unexpired credits, earliest expiry first, before
+-- the balance. expired_at is set when the expiry sweep writes off what is
+-- left, revoked_at when an operator withdraws a grant.
+CREATE TABLE IF NOT EXISTS credit_grants (
+  id TEXT PRIMARY KEY,
+  tenant_id TEXT NOT NULL REFERENCES tenants(id),
+  amount_usd NUMERIC(12,4) NOT NULL,
+  remaining_usd NUMERIC(12,4) NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  expires_at TIMESTAMP NOT NULL,
+  created_by TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  expired_at TIMESTAMP,
+  revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_grants_live ON credit_grants (tenant_id, expires_at) WHERE remaining_usd > 0;
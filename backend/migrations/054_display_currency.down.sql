@@ -0,0 +1,2 @@
+ALTER TABLE tenants DROP COLUMN IF EXISTS display_currency;
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,11 @@
+-- Accounting stays in USD; these only convert amounts for display.
+-- per_usd is units of the currency per US dollar. source is manual for rates
+-- set by an operator, which the ECB feed never overwrites, or ecb.
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  currency TEXT PRIMARY KEY,
+  per_usd DOUBLE PRECISION NOT NULL,
+  source TEXT NOT NULL DEFAULT 'manual',
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS display_currency TEXT NOT NULL DEFAULT 'USD';
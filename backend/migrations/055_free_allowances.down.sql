@@ -0,0 +1,4 @@
+ALTER TABLE usage_daily DROP COLUMN IF EXISTS free_tokens;
+ALTER TABLE usage_daily DROP COLUMN IF EXISTS free_requests;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS free_tokens_per_day;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS free_requests_per_day;
@@ -0,0 +1,9 @@
+-- Daily free allowances per tenant for a catalog model; zero means none.
+-- Requests within the allowance bypass balance checks and are not billed.
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS free_requests_per_day INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS free_tokens_per_day INTEGER NOT NULL DEFAULT 0;
+
+-- Free usage, counted in Redis while the day runs and recorded here so the
+-- counters can be rebuilt if Redis loses them.
+ALTER TABLE usage_daily ADD COLUMN IF NOT EXISTS free_requests INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE usage_daily ADD COLUMN IF NOT EXISTS free_tokens BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- Archived providers are disabled so routing keeps skipping them; archived
+-- catalog models are listed again.
+UPDATE providers SET enabled = false WHERE archived_at IS NOT NULL;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS archived_by;
+ALTER TABLE model_catalog DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE providers DROP COLUMN IF EXISTS archived_by;
+ALTER TABLE providers DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,7 @@
+-- Archiving replaces deleting providers and catalog models, so request logs,
+-- usage and pricing keep pointing at rows that still exist. Archived rows
+-- are hidden from routing and listings until restored.
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+ALTER TABLE providers ADD COLUMN IF NOT EXISTS archived_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+ALTER TABLE model_catalog ADD COLUMN IF NOT EXISTS archived_by TEXT NOT NULL DEFAULT '';
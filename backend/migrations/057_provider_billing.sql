@@ -0,0 +1,14 @@
+-- Provider billing exports, one row per provider account, day and model.
+-- Re-importing a day replaces its rows. Reconciliation compares them with
+-- request_logs.provider_cost_usd to catch mispriced models.
+CREATE TABLE IF NOT EXISTS provider_billing (
+  provider_id TEXT NOT NULL REFERENCES providers(id),
+  day DATE NOT NULL,
+  model TEXT NOT NULL,
+  cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+  tokens BIGINT NOT NULL DEFAULT 0,
+  source TEXT NOT NULL DEFAULT '',
+  imported_by TEXT NOT NULL DEFAULT '',
+  imported_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (provider_id, day, model)
+);
@@ -0,0 +1,15 @@
+-- Gateway-wide request counters per day, so the dashboard's fallback and
+-- prompt-cache trends read a rollup instead of scanning request_logs.
+-- Cache hits are answered before routing and have no request log row.
+CREATE TABLE IF NOT EXISTS request_stats_daily (
+  day DATE PRIMARY KEY,
+  requests BIGINT NOT NULL DEFAULT 0,
+  fallbacks BIGINT NOT NULL DEFAULT 0,
+  cache_lookups BIGINT NOT NULL DEFAULT 0,
+  cache_hits BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO request_stats_daily (day, requests, fallbacks)
+SELECT created_at::date, COUNT(*), COUNT(*) FILTER (WHERE fallback_used)
+FROM request_logs GROUP BY 1
+ON CONFLICT (day) DO NOTHING;
@@ -0,0 +1,3 @@
+ALTER TABLE tenants DROP COLUMN IF EXISTS auto_suspended_at;
+ALTER TABLE tenants DROP COLUMN IF EXISTS auto_suspend_reason;
+ALTER TABLE tenants DROP COLUMN IF EXISTS negative_since;
@@ -0,0 +1,10 @@
+-- Automatic suspension. negative_since is when the balance last went below
+-- zero (maintained by the delinquency sweep); auto_suspend_reason is set
+-- while a tenant is suspended by policy rather than by an operator, so a
+-- top-up can lift a negative_balance suspension on its own.
+-- auto_suspended_at survives an operator lifting the suspension, so the
+-- sweep does not suspend again for what it already acted on.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS negative_since TIMESTAMP;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS auto_suspend_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS auto_suspended_at TIMESTAMP;
+UPDATE tenants SET negative_since = NOW() WHERE balance_usd < 0 AND negative_since IS NULL;
@@ -0,0 +1,4 @@
+-- How far below zero a tenant's paid balance may go before requests are
+-- refused, so a request admitted with a little balance left can finish and
+-- be charged in full. 0 keeps the old behaviour: admit only above zero.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS overdraft_usd NUMERIC(12,4) NOT NULL DEFAULT 0;
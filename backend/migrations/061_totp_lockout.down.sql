@@ -0,0 +1,4 @@
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS totp_locked_until;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS totp_attempts;
+ALTER TABLE admin_users DROP COLUMN IF EXISTS totp_locked_until;
+ALTER TABLE admin_users DROP COLUMN IF EXISTS totp_attempts;
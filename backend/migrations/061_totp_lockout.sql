@@ -0,0 +1,8 @@
+-- Second-factor attempts since the last success. Reaching the limit locks
+-- the account's two-factor checks until totp_locked_until, so a known
+-- password does not let a 6-digit code be guessed online.
+ALTER TABLE admin_users ADD COLUMN IF NOT EXISTS totp_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE admin_users ADD COLUMN IF NOT EXISTS totp_locked_until TIMESTAMPTZ;
+
+ALTER TABLE tenant_users ADD COLUMN IF NOT EXISTS totp_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE tenant_users ADD COLUMN IF NOT EXISTS totp_locked_until TIMESTAMPTZ;
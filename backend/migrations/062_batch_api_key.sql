@@ -0,0 +1,4 @@
+-- The API key a batch was created with. Its items run as that key, so they
+-- see its model restrictions and stop once it is revoked. Batches queued
+-- before this have no key recorded and their remaining items are failed.
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS api_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations into the binary, so it can
+// migrate its database wherever it is deployed.
+package migrations
+
+import "embed"
+
+// FS holds the migration files at its root.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"routerx/internal/models"
+)
+
+// The chat types are the gateway's own, so the client cannot drift from
+// what the server accepts.
+type (
+	Message                = models.Message
+	ChatCompletionRequest  = models.ChatCompletionRequest
+	ChatCompletionResponse = models.ChatCompletionResponse
+	Choice                 = models.Choice
+	AssistantMessage       = models.AssistantMessage
+	Usage                  = models.Usage
+	StreamOptions          = models.StreamOptions
+	Thinking               = models.Thinking
+)
+
+// UserMessage returns a user message with text content.
+func UserMessage(text string) Message {
+	return textMessage("user", text)
+}
+
+// SystemMessage returns a system message with text content.
+func SystemMessage(text string) Message {
+	return textMessage("system", text)
+}
+
+// AssistantText returns an assistant message with text content, for
+// replaying earlier turns.
+func AssistantText(text string) Message {
+	return textMessage("assistant", text)
+}
+
+func textMessage(role, text string) Message {
+	content, _ := json.Marshal(text)
+	return Message{Role: role, Content: content}
+}
+
+// Routing is how the gateway served a request, from its X-RouterX-*
+// response headers or, for a stream, its closing comment.
+type Routing struct {
+	Provider  string
+	Model     string
+	LatencyMS int64
+	// CostUSD is the estimated price of the request; HasCost is false when
+	// the gateway did not report one.
+	CostUSD  float64
+	HasCost  bool
+	Fallback bool
+	// Cache is hit, miss, partial or bypass when caching was requested.
+	Cache         string
+	DroppedParams []string
+}
+
+func routingFromHeaders(h http.Header) Routing {
+	r := Routing{
+		Provider: h.Get("X-RouterX-Provider"),
+		Model:    h.Get("X-RouterX-Model"),
+		Fallback: h.Get("X-RouterX-Fallback") == "true",
+		Cache:    h.Get("X-RouterX-Cache"),
+	}
+	r.LatencyMS, _ = strconv.ParseInt(h.Get("X-RouterX-Latency-Ms"), 10, 64)
+	if v := h.Get("X-RouterX-Cost-USD"); v != "" {
+		r.CostUSD, _ = strconv.ParseFloat(v, 64)
+		r.HasCost = true
+	}
+	if v := h.Get("X-RouterX-Dropped-Params"); v != "" {
+		r.DroppedParams = strings.Split(v, ",")
+	}
+	return r
+}
+
+// routingFromComment parses the SSE comment a stream ends with, e.g.
+// ": provider=openai model=gpt-4o latency_ms=812 fallback=false".
+func routingFromComment(line string) Routing {
+	var r Routing
+	for _, field := range strings.Fields(strings.TrimPrefix(line, ":")) {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "provider":
+			r.Provider = v
+		case "model":
+			r.Model = v
+		case "latency_ms":
+			r.LatencyMS, _ = strconv.ParseInt(v, 10, 64)
+		case "cost_usd":
+			r.CostUSD, _ = strconv.ParseFloat(v, 64)
+			r.HasCost = true
+		case "fallback":
+			r.Fallback = v == "true"
+		case "cache":
+			r.Cache = v
+		case "dropped_params":
+			r.DroppedParams = strings.Split(v, ",")
+		}
+	}
+	return r
+}
+
+// ChatCompletion is a buffered completion with how it was routed.
+type ChatCompletion struct {
+	ChatCompletionResponse
+	Routing Routing
+}
+
+// ChatCompletion sends a buffered chat completion. req.Stream is ignored;
+// use ChatCompletionStream to stream.
+func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest, opts ...RequestOption) (*ChatCompletion, error) {
+	req.Stream = false
+	var out ChatCompletion
+	h, err := c.doJSON(ctx, http.MethodPost, "/v1/chat/completions", nil, req, &out.ChatCompletionResponse, authAPIKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	out.Routing = routingFromHeaders(h)
+	return &out, nil
+}
+
+// ChatCompletionChunk is one streamed event.
+type ChatCompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created"`
+	Model   string        `json:"model"`
+	Choices []ChunkChoice `json:"choices"`
+	// Usage is set on the last chunk when StreamOptions.IncludeUsage is.
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+type ChunkChoice struct {
+	Index        int        `json:"index"`
+	Delta        ChunkDelta `json:"delta"`
+	FinishReason *string    `json:"finish_reason"`
+}
+
+type ChunkDelta struct {
+	Role             string          `json:"role,omitempty"`
+	Content          string          `json:"content,omitempty"`
+	ToolCalls        json.RawMessage `json:"tool_calls,omitempty"`
+	ReasoningContent string          `json:"reasoning_content,omitempty"`
+}
+
+// ChatStream reads a streamed chat completion:
+//
+//	for s.Next() {
+//		fmt.Print(s.Chunk().Choices[0].Delta.Content)
+//	}
+//	if err := s.Err(); err != nil { ... }
+//
+// Close must be called when done, including after an error.
+type ChatStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	chunk   ChatCompletionChunk
+	routing Routing
+	done    bool
+	err     error
+}
+
+// ChatCompletionStream starts a streamed chat completion. Only the request
+// is retried; once events flow, a failure ends the stream with an error.
+func (c *Client) ChatCompletionStream(ctx context.Context, req ChatCompletionRequest, opts ...RequestOption) (*ChatStream, error) {
+	req.Stream = true
+	resp, err := c.do(ctx, http.MethodPost, "/v1/chat/completions", nil, req, authAPIKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 4<<20)
+	return &ChatStream{body: resp.Body, scanner: sc}, nil
+}
+
+// Next advances to the next chunk, reporting false at the end of the
+// stream or on error.
+func (s *ChatStream) Next() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			s.done = true
+			s.readTrailer()
+			return false
+		}
+		if err := apiErrorEvent(data); err != nil {
+			s.err = err
+			return false
+		}
+		s.chunk = ChatCompletionChunk{}
+		if err := json.Unmarshal([]byte(data), &s.chunk); err != nil {
+			s.err = err
+			return false
+		}
+		return true
+	}
+	s.err = s.scanner.Err()
+	if s.err == nil {
+		s.err = io.ErrUnexpectedEOF
+	}
+	return false
+}
+
+// readTrailer reads the routing comment that follows data: [DONE].
+func (s *ChatStream) readTrailer() {
+	for s.scanner.Scan() {
+		if line := s.scanner.Text(); strings.HasPrefix(line, ": provider=") {
+			s.routing = routingFromComment(line)
+			return
+		}
+	}
+}
+
+// apiErrorEvent returns the error a stream that failed midway reports as
+// its last event, or nil for an ordinary chunk.
+func apiErrorEvent(data string) error {
+	if !strings.HasPrefix(data, `{"error"`) {
+		return nil
+	}
+	var ev models.ErrorResponse
+	if err := json.Unmarshal([]byte(data), &ev); err != nil {
+		return err
+	}
+	return &APIError{StatusCode: http.StatusBadGateway, Type: ev.Error.Type, Code: ev.Error.Code, Message: ev.Error.Message}
+}
+
+// Chunk returns the chunk Next advanced to.
+func (s *ChatStream) Chunk() ChatCompletionChunk { return s.chunk }
+
+// Err returns the error that ended the stream, if any.
+func (s *ChatStream) Err() error { return s.err }
+
+// Routing returns how the stream was served. The gateway sends it after the
+// last chunk, so it is only set once Next has returned false without error.
+func (s *ChatStream) Routing() Routing { return s.routing }
+
+// Close releases the connection.
+func (s *ChatStream) Close() error {
+	s.done = true
+	return s.body.Close()
+}
+
+// Collect reads the rest of the stream and returns the concatenated content
+// of the first choice.
+func (s *ChatStream) Collect() (string, error) {
+	var b strings.Builder
+	for s.Next() {
+		if ch := s.Chunk(); len(ch.Choices) > 0 {
+			b.WriteString(ch.Choices[0].Delta.Content)
+		}
+	}
+	return b.String(), s.Err()
+}
@@ -0,0 +1,301 @@
+// Package client is a Go client for the RouterX gateway. It wraps the
+// OpenAI-compatible /v1 endpoints, authenticated with an API key, and the
+// tenant /user endpoints, authenticated with a session token from Login.
+//
+//	c := client.New("https://routerx.example.com", client.WithAPIKey(key))
+//	resp, err := c.ChatCompletion(ctx, client.ChatCompletionRequest{
+//		Model:    "gpt-4o-mini",
+//		Messages: []client.Message{client.UserMessage("Hello")},
+//	})
+//
+// Requests rejected with 429, 502, 503 or 504, or that fail before a
+// response arrives, are retried with exponential backoff, honouring
+// Retry-After. Errors from the gateway are returned as *APIError.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 2
+	defaultRetryWait  = 500 * time.Millisecond
+	maxRetryWait      = 30 * time.Second
+)
+
+// Client talks to one RouterX deployment. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	maxRetries int
+	retryWait  time.Duration
+	userAgent  string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey sets the API key sent to the /v1 endpoints.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithToken sets the session token sent to the /user endpoints, for a
+// token obtained elsewhere instead of through Login.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient replaces http.DefaultClient. Streams last as long as the
+// completion, so its Timeout should allow for that or be zero.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetries sets how many times a failed request is retried (0 disables
+// retries) and the wait before the first retry, which doubles each time.
+func WithRetries(max int, wait time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.retryWait = max, wait }
+}
+
+// WithUserAgent sets the User-Agent header.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New returns a client for the gateway at baseURL, e.g.
+// "https://routerx.example.com".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		retryWait:  defaultRetryWait,
+		userAgent:  "routerx-go",
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// SetToken replaces the session token used for the /user endpoints.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+// Token returns the current session token.
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// APIError is an error response from the gateway. The /v1 endpoints return
+// OpenAI-style errors with a Type and Code; the /user endpoints return a
+// plain message.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	// RetryAfter is the wait the gateway asked for, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("routerx: HTTP %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("routerx: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed if retried.
+func (e *APIError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RequestOption adjusts one request, usually by setting one of the
+// X-RouterX-* request headers.
+type RequestOption func(http.Header)
+
+// WithHeader sets a request header.
+func WithHeader(key, value string) RequestOption {
+	return func(h http.Header) { h.Set(key, value) }
+}
+
+// WithCache serves a repeated request from the gateway's cache.
+func WithCache() RequestOption {
+	return WithHeader("X-RouterX-Cache", "true")
+}
+
+// WithSession pins requests sharing key to one provider while it is
+// healthy.
+func WithSession(key string) RequestOption {
+	return WithHeader("X-RouterX-Session", key)
+}
+
+// WithProviderOrder sets the providers to try first, in order.
+func WithProviderOrder(providers ...string) RequestOption {
+	return WithHeader("X-RouterX-Provider-Order", strings.Join(providers, ","))
+}
+
+// WithProviderOnly restricts routing to the given providers.
+func WithProviderOnly(providers ...string) RequestOption {
+	return WithHeader("X-RouterX-Provider-Only", strings.Join(providers, ","))
+}
+
+// WithoutFallbacks fails the request rather than fall back to another
+// provider.
+func WithoutFallbacks() RequestOption {
+	return WithHeader("X-RouterX-Allow-Fallbacks", "false")
+}
+
+// WithSort picks providers by "price" or "latency".
+func WithSort(mode string) RequestOption {
+	return WithHeader("X-RouterX-Sort", mode)
+}
+
+// auth selects the credential a request carries.
+type auth int
+
+const (
+	authNone auth = iota
+	authAPIKey
+	authToken
+)
+
+// Get sends a GET to path on behalf of the logged-in user and decodes the
+// JSON response into out. It covers /user endpoints without a typed method.
+func (c *Client) Get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	_, err := c.doJSON(ctx, http.MethodGet, path, query, nil, out, authToken, nil)
+	return err
+}
+
+// doJSON sends body as JSON and decodes a 2xx response into out, if set.
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body, out interface{}, a auth, opts []RequestOption) (http.Header, error) {
+	resp, err := c.do(ctx, method, path, query, body, a, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.Header, fmt.Errorf("routerx: decoding %s %s: %w", method, path, err)
+	}
+	return resp.Header, nil
+}
+
+// do sends a request, retrying temporary failures, and returns a 2xx
+// response for the caller to read and close.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, a auth, opts []RequestOption) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+		for _, o := range opts {
+			o(req.Header)
+		}
+		switch a {
+		case authAPIKey:
+			req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		case authToken:
+			req.Header.Set("Authorization", "Bearer "+c.Token())
+		}
+		resp, err := c.httpClient.Do(req)
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		if err == nil {
+			err = readAPIError(resp)
+		}
+		if attempt >= c.maxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if !apiErr.Temporary() {
+				return nil, err
+			}
+			if apiErr.RetryAfter > 0 {
+				wait = apiErr.RetryAfter
+			}
+		}
+		if wait > maxRetryWait {
+			wait = maxRetryWait
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+// readAPIError reads and closes a non-2xx response.
+func readAPIError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &APIError{StatusCode: resp.StatusCode}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+	var parsed struct {
+		Error *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error != nil {
+		e.Type, e.Code, e.Message = parsed.Error.Type, parsed.Error.Code, parsed.Error.Message
+		return e
+	}
+	e.Message = strings.TrimSpace(string(body))
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
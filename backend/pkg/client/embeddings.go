@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// EmbeddingRequest is a /v1/embeddings request. Input is a string, a list
+// of strings, or token arrays; see StringsInput.
+type EmbeddingRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"`
+	EncodingFormat string          `json:"encoding_format,omitempty"`
+	Dimensions     int             `json:"dimensions,omitempty"`
+	User           string          `json:"user,omitempty"`
+}
+
+// StringsInput encodes texts as an embeddings input.
+func StringsInput(texts ...string) json.RawMessage {
+	b, _ := json.Marshal(texts)
+	return b
+}
+
+type Embedding struct {
+	Object string `json:"object"`
+	Index  int    `json:"index"`
+	// Embedding is a float array, or a base64 string with
+	// EncodingFormat "base64"; Floats decodes the former.
+	Embedding json.RawMessage `json:"embedding"`
+}
+
+// Floats decodes a float embedding.
+func (e Embedding) Floats() ([]float64, error) {
+	var v []float64
+	err := json.Unmarshal(e.Embedding, &v)
+	return v, err
+}
+
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Data   []Embedding `json:"data"`
+	Model  string      `json:"model"`
+	Usage  struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+	Routing Routing `json:"-"`
+}
+
+// Embeddings embeds req.Input, one embedding per input in input order.
+// With WithCache, inputs embedded before are served from the gateway's
+// cache.
+func (c *Client) Embeddings(ctx context.Context, req EmbeddingRequest, opts ...RequestOption) (*EmbeddingResponse, error) {
+	var out EmbeddingResponse
+	h, err := c.doJSON(ctx, http.MethodPost, "/v1/embeddings", nil, req, &out, authAPIKey, opts)
+	if err != nil {
+		return nil, err
+	}
+	out.Routing = routingFromHeaders(h)
+	return &out, nil
+}
+
+// Model is a /v1/models entry. Pricing is USD per token, as a decimal
+// string, and absent for unpriced models.
+type Model struct {
+	ID              string `json:"id"`
+	Object          string `json:"object"`
+	Created         int64  `json:"created"`
+	OwnedBy         string `json:"owned_by"`
+	Provider        string `json:"provider"`
+	ContextLength   int    `json:"context_length,omitempty"`
+	MaxOutputTokens int    `json:"max_output_tokens,omitempty"`
+	Pricing         *struct {
+		Prompt     string `json:"prompt"`
+		Completion string `json:"completion"`
+	} `json:"pricing,omitempty"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// ListModels lists the models the gateway serves. It needs no credentials.
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	var out struct {
+		Data []Model `json:"data"`
+	}
+	if _, err := c.doJSON(ctx, http.MethodGet, "/v1/models", nil, nil, &out, authNone, nil); err != nil {
+		return nil, err
+	}
+	return out.Data, nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// defaultPageSize is how many items a Pager fetches per request.
+const defaultPageSize = 100
+
+// Page is one page of a paginated /user listing.
+type Page[T any] struct {
+	Data     []T `json:"data"`
+	Total    int `json:"total"`
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+}
+
+// Pager walks a paginated listing one item at a time, fetching pages as
+// needed:
+//
+//	p := c.Requests(client.RequestFilter{Model: "gpt-4o"})
+//	for p.Next(ctx) {
+//		log := p.Item()
+//	}
+//	if err := p.Err(); err != nil { ... }
+type Pager[T any] struct {
+	c        *Client
+	path     string
+	query    url.Values
+	pageSize int
+
+	page  int
+	items []T
+	idx   int
+	total int
+	done  bool
+	err   error
+}
+
+func newPager[T any](c *Client, path string, query url.Values) *Pager[T] {
+	if query == nil {
+		query = url.Values{}
+	}
+	return &Pager[T]{c: c, path: path, query: query, pageSize: defaultPageSize, idx: -1}
+}
+
+// PageSize sets how many items each request fetches (the gateway caps it at
+// 200). It must be called before the first Next.
+func (p *Pager[T]) PageSize(n int) *Pager[T] {
+	p.pageSize = n
+	return p
+}
+
+// Next advances to the next item, fetching the next page when the current
+// one is used up. It reports false when the listing is exhausted or a
+// request failed.
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	if p.err != nil {
+		return false
+	}
+	if p.idx+1 < len(p.items) {
+		p.idx++
+		return true
+	}
+	if p.done {
+		return false
+	}
+	p.page++
+	q := url.Values{}
+	for k, v := range p.query {
+		q[k] = v
+	}
+	q.Set("page", strconv.Itoa(p.page))
+	q.Set("page_size", strconv.Itoa(p.pageSize))
+	var pg Page[T]
+	if err := p.c.Get(ctx, p.path, q, &pg); err != nil {
+		p.err = err
+		return false
+	}
+	p.items, p.idx, p.total = pg.Data, 0, pg.Total
+	if len(pg.Data) == 0 || pg.Page*pg.PageSize >= pg.Total {
+		p.done = true
+	}
+	return len(p.items) > 0
+}
+
+// Item returns the item Next advanced to.
+func (p *Pager[T]) Item() T { return p.items[p.idx] }
+
+// Total returns the number of matching items as of the last page fetched.
+func (p *Pager[T]) Total() int { return p.total }
+
+// Err returns the error that stopped the pager, if any.
+func (p *Pager[T]) Err() error { return p.err }
+
+// All collects the remaining items.
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var out []T
+	for p.Next(ctx) {
+		out = append(out, p.Item())
+	}
+	return out, p.Err()
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"routerx/internal/models"
+)
+
+// ErrTwoFactorEnrollment is returned by Login for a user who must enrol in
+// two-factor authentication first. The token Login keeps only reaches the
+// /user/2fa endpoints.
+var ErrTwoFactorEnrollment = errors.New("routerx: two-factor enrollment required")
+
+// Login signs a tenant user in and keeps the session token for the /user
+// methods. otp is the TOTP or backup code of an enrolled user, else empty.
+func (c *Client) Login(ctx context.Context, username, password, otp string) error {
+	var out struct {
+		Token              string `json:"token"`
+		EnrollmentRequired bool   `json:"2fa_enrollment_required"`
+	}
+	body := map[string]string{"username": username, "password": password, "otp": otp}
+	if _, err := c.doJSON(ctx, http.MethodPost, "/user/login", nil, body, &out, authNone, nil); err != nil {
+		return err
+	}
+	c.SetToken(out.Token)
+	if out.EnrollmentRequired {
+		return ErrTwoFactorEnrollment
+	}
+	return nil
+}
+
+// Display is an amount block converted to the tenant's display currency:
+// "currency", "exchange_rate" and one entry per amount.
+type Display map[string]interface{}
+
+type Profile struct {
+	TenantID      string  `json:"tenant_id"`
+	Name          string  `json:"name"`
+	Username      string  `json:"username"`
+	Role          string  `json:"role"`
+	BalanceUSD    float64 `json:"balance_usd"`
+	CreditsUSD    float64 `json:"credits_usd"`
+	ReservedUSD   float64 `json:"reserved_usd"`
+	Suspended     bool    `json:"suspended"`
+	TotalTopupUSD float64 `json:"total_topup_usd"`
+	TotalSpentUSD float64 `json:"total_spent_usd"`
+	Display       Display `json:"display"`
+}
+
+// Profile returns the logged-in user's tenant and balance.
+func (c *Client) Profile(ctx context.Context) (*Profile, error) {
+	var out Profile
+	if err := c.Get(ctx, "/user/profile", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+type Transaction struct {
+	ID           int       `json:"id"`
+	TenantID     string    `json:"tenant_id"`
+	Type         string    `json:"type"`
+	AmountUSD    float64   `json:"amount_usd"`
+	BalanceAfter float64   `json:"balance_after"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+	Display      Display   `json:"display"`
+}
+
+// Transactions returns the tenant's latest ledger entries, newest first;
+// limit is capped at 500 and defaults to 100.
+func (c *Client) Transactions(ctx context.Context, limit int) ([]Transaction, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var out []Transaction
+	if err := c.Get(ctx, "/user/transactions", q, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UsageWindow bounds a usage query. Zero times leave a side open;
+// Granularity is hour, day, week or month.
+type UsageWindow struct {
+	From, To    time.Time
+	Granularity string
+}
+
+func (w UsageWindow) query() url.Values {
+	q := url.Values{}
+	if !w.From.IsZero() {
+		q.Set("from", w.From.UTC().Format(time.RFC3339))
+	}
+	if !w.To.IsZero() {
+		q.Set("to", w.To.UTC().Format(time.RFC3339))
+	}
+	if w.Granularity != "" {
+		q.Set("granularity", w.Granularity)
+	}
+	return q
+}
+
+// DailyUsage is a tenant's usage of one model at one provider in a bucket.
+type DailyUsage struct {
+	Day      time.Time `json:"day"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Tokens   int64     `json:"tokens"`
+	CostUSD  float64   `json:"cost_usd"`
+	Display  Display   `json:"display"`
+}
+
+// Usage returns usage per provider and model in the window, or the 30 most
+// recent rows for an empty window.
+func (c *Client) Usage(ctx context.Context, w UsageWindow) ([]DailyUsage, error) {
+	var out []DailyUsage
+	if err := c.Get(ctx, "/user/usage", w.query(), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RequestLog is one logged request.
+type RequestLog = models.RequestLog
+
+// RequestFilter narrows Requests; zero fields match everything. Status is
+// success or error.
+type RequestFilter struct {
+	Provider   string
+	Model      string
+	Status     string
+	StatusCode int
+	UserID     string
+	AppTitle   string
+	KeyOwner   string
+	// Tags match request metadata; every pair must match.
+	Tags     map[string]string
+	From, To time.Time
+	// SortBy and SortDir order the listing; the default is newest first.
+	SortBy, SortDir string
+}
+
+// Requests pages through the tenant's request logs.
+func (c *Client) Requests(f RequestFilter) *Pager[RequestLog] {
+	q := url.Values{}
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("provider", f.Provider)
+	set("model", f.Model)
+	set("status", f.Status)
+	if f.StatusCode != 0 {
+		q.Set("status_code", strconv.Itoa(f.StatusCode))
+	}
+	set("user_id", f.UserID)
+	set("app_title", f.AppTitle)
+	set("key_owner", f.KeyOwner)
+	for k, v := range f.Tags {
+		q.Add("tag", k+":"+v)
+	}
+	if !f.From.IsZero() {
+		q.Set("from", f.From.UTC().Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		q.Set("to", f.To.UTC().Format(time.RFC3339))
+	}
+	set("sort_by", f.SortBy)
+	set("sort_dir", f.SortDir)
+	return newPager[RequestLog](c, "/user/requests", q)
+}
+
+type APIKey struct {
+	Key           string     `json:"key"`
+	TenantID      string     `json:"tenant_id"`
+	Name          string     `json:"name"`
+	AllowedModels []string   `json:"allowed_models"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SigningKeyID  string     `json:"signing_key_id,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy     string     `json:"revoked_by,omitempty"`
+	RevokeReason  string     `json:"revoke_reason,omitempty"`
+	Requests30d   int64      `json:"requests_30d"`
+	Tokens30d     int64      `json:"tokens_30d"`
+}
+
+// APIKeys lists the tenant's API keys.
+func (c *Client) APIKeys(ctx context.Context) ([]APIKey, error) {
+	var out []APIKey
+	if err := c.Get(ctx, "/user/api-keys", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateAPIKey creates an API key and returns it. An empty allowedModels
+// allows every model.
+func (c *Client) CreateAPIKey(ctx context.Context, name string, allowedModels []string) (string, error) {
+	var out struct {
+		Key string `json:"key"`
+	}
+	body := map[string]interface{}{"name": name, "allowed_models": allowedModels}
+	if _, err := c.doJSON(ctx, http.MethodPost, "/user/api-keys", nil, body, &out, authToken, nil); err != nil {
+		return "", err
+	}
+	return out.Key, nil
+}
+
+// DeleteAPIKey deletes one of the tenant's API keys.
+func (c *Client) DeleteAPIKey(ctx context.Context, key string) error {
+	_, err := c.doJSON(ctx, http.MethodDelete, "/user/api-keys/"+url.PathEscape(key), nil, nil, nil, authToken, nil)
+	return err
+}
+
+type Session struct {
+	ID               string    `json:"id"`
+	Kind             string    `json:"kind"`
+	Username         string    `json:"username"`
+	TenantID         string    `json:"tenant_id,omitempty"`
+	IP               string    `json:"ip"`
+	UserAgent        string    `json:"user_agent"`
+	IssuedAt         time.Time `json:"issued_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	LastSeen         time.Time `json:"last_seen"`
+	LastIP           string    `json:"last_ip"`
+	TwoFactorPending bool      `json:"2fa_pending,omitempty"`
+	Current          bool      `json:"current,omitempty"`
+}
+
+// Sessions lists the logged-in user's live sessions, or every member's for
+// an owner, newest first.
+func (c *Client) Sessions(ctx context.Context) ([]Session, error) {
+	var out []Session
+	if err := c.Get(ctx, "/user/sessions", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeSession ends a session. Revoking the current one logs the client
+// out.
+func (c *Client) RevokeSession(ctx context.Context, id string) error {
+	_, err := c.doJSON(ctx, http.MethodDelete, "/user/sessions/"+url.PathEscape(id), nil, nil, nil, authToken, nil)
+	return err
+}
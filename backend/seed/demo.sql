@@ -0,0 +1,15 @@
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+-- Demo accounts with well-known credentials, for local development only.
+-- Never load this into a reachable deployment: use "routerx init" or
+-- POST /setup, which generate random credentials, instead.
+INSERT INTO tenants (id, name, balance_usd) VALUES ('demo', 'Demo Tenant', 10.00) ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name, balance_usd=EXCLUDED.balance_usd;
+INSERT INTO api_keys (key, tenant_id, name, allowed_models) VALUES ('demo_key_fake_123456', 'demo', 'Default', ARRAY[]::text[]) ON CONFLICT (key) DO UPDATE SET allowed_models=EXCLUDED.allowed_models;
+
+INSERT INTO admin_users (id, username, password_hash)
+VALUES ('admin', 'admin', crypt('admin123', gen_salt('bf')))
+ON CONFLICT (id) DO UPDATE SET username=EXCLUDED.username, password_hash=EXCLUDED.password_hash;
+
+INSERT INTO tenant_users (id, tenant_id, username, password_hash)
+VALUES ('demo-user', 'demo', 'demo', crypt('demo123', gen_salt('bf')))
+ON CONFLICT (id) DO UPDATE SET tenant_id=EXCLUDED.tenant_id, username=EXCLUDED.username, password_hash=EXCLUDED.password_hash;
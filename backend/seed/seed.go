@@ -0,0 +1,15 @@
+// Package seed embeds the data loaded by "routerx seed".
+package seed
+
+import _ "embed"
+
+// SQL loads the provider list, model catalog and pricing.
+//
+//go:embed seed.sql
+var SQL string
+
+// Demo adds the demo tenant, API key and admin with fixed, well-known
+// credentials. It is for local development only.
+//
+//go:embed demo.sql
+var Demo string
@@ -6,9 +6,6 @@ TRUNCATE usage_daily;
 DELETE FROM balance_transactions;
 DELETE FROM routing_rules;
 
-INSERT INTO tenants (id, name, balance_usd) VALUES ('demo', 'Demo Tenant', 10.00) ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name, balance_usd=EXCLUDED.balance_usd;
-INSERT INTO api_keys (key, tenant_id, name, allowed_models) VALUES ('demo_key_fake_123456', 'demo', 'Default', ARRAY[]::text[]) ON CONFLICT (key) DO UPDATE SET allowed_models=EXCLUDED.allowed_models;
-
 INSERT INTO providers (id, name, type, base_url, api_key, default_model, supports_text, supports_vision, enabled)
 VALUES
   ('openai', 'OpenAI', 'openai', NULL, NULL, 'gpt-4.1-mini', true, true, true),
@@ -19,14 +16,6 @@ VALUES
   ('generic1', 'Generic OpenAI Compatible', 'generic-openai', 'http://localhost:9001', NULL, 'local-model', true, false, false)
 ON CONFLICT (id) DO UPDATE SET name=EXCLUDED.name, type=EXCLUDED.type, base_url=EXCLUDED.base_url, api_key=EXCLUDED.api_key, default_model=EXCLUDED.default_model, supports_text=EXCLUDED.supports_text, supports_vision=EXCLUDED.supports_vision, enabled=EXCLUDED.enabled;
 
-INSERT INTO admin_users (id, username, password_hash)
-VALUES ('admin', 'admin', crypt('admin123', gen_salt('bf')))
-ON CONFLICT (id) DO UPDATE SET username=EXCLUDED.username, password_hash=EXCLUDED.password_hash;
-
-INSERT INTO tenant_users (id, tenant_id, username, password_hash)
-VALUES ('demo-user', 'demo', 'demo', crypt('demo123', gen_salt('bf')))
-ON CONFLICT (id) DO UPDATE SET tenant_id=EXCLUDED.tenant_id, username=EXCLUDED.username, password_hash=EXCLUDED.password_hash;
-
 -- Comprehensive model pricing (50+ models)
 INSERT INTO model_pricing (model, price_per_1k_usd) VALUES
   -- OpenAI
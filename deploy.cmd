@@ -26,6 +26,8 @@ if errorlevel 1 exit /b 1
 if /I "%ROUTERX_SEED%"=="1" (
   "C:\Program Files\Go\bin\go.exe" run .\cmd\server seed
   if errorlevel 1 exit /b 1
+  "C:\Program Files\Go\bin\go.exe" run .\cmd\server init
+  if errorlevel 1 exit /b 1
 )
 
 echo Deploy complete. UI: http://localhost:3000
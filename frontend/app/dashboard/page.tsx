@@ -80,13 +80,13 @@ export default function DashboardPage() {
       apiGet('/admin/stats', token),
       apiGet('/admin/provider-health', token),
       apiGet('/admin/model-usage', token),
-      apiGet('/admin/tenants', token)
+      apiGet('/admin/tenants?page_size=200', token)
     ])
       .then(([s, h, m, t]) => {
         setStats(s);
         setHealth(Array.isArray(h) ? h : []);
         setModelUsage(Array.isArray(m) ? m : []);
-        setTenants(Array.isArray(t) ? t : []);
+        setTenants(Array.isArray(t?.data) ? t.data : []);
       })
       .catch((e) => setError(e.message || 'Failed to load'))
       .finally(() => setLoading(false));
@@ -50,9 +50,9 @@ export default function RoutingPage() {
   const token = typeof window !== 'undefined' ? localStorage.getItem('routerx_token') || '' : '';
 
   useEffect(() => {
-    Promise.all([apiGet('/admin/tenants', token), apiGet('/admin/providers', token)])
+    Promise.all([apiGet('/admin/tenants?page_size=200', token), apiGet('/admin/providers', token)])
       .then(([t, p]) => {
-        setTenants(Array.isArray(t) ? t : []);
+        setTenants(Array.isArray(t?.data) ? t.data : []);
         setProviders(Array.isArray(p) ? p : []);
       })
       .catch((e) => setError(e.message));
@@ -65,7 +65,7 @@ export default function TenantDetailPage() {
         apiGet(`/admin/tenants/${tenantId}/transactions`, token())
       ]);
       setTenant(t);
-      setTransactions(Array.isArray(txs) ? txs : []);
+      setTransactions(Array.isArray(txs?.data) ? txs.data : []);
     } catch (err: any) {
       setError(err.message || 'Failed to load');
     } finally {
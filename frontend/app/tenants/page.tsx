@@ -33,8 +33,8 @@ export default function TenantsPage() {
 
   async function refresh() {
     try {
-      const list = await apiGet('/admin/tenants', token());
-      setItems(Array.isArray(list) ? list : []);
+      const list = await apiGet('/admin/tenants?page_size=200', token());
+      setItems(Array.isArray(list?.data) ? list.data : []);
     } catch (err: any) {
       setError(err.message || 'Failed to load');
     }